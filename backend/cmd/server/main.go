@@ -1,67 +1,97 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
 
 	"github.com/todomyday/backend/internal/config"
 	"github.com/todomyday/backend/internal/crypto"
 	"github.com/todomyday/backend/internal/database"
+	"github.com/todomyday/backend/internal/logging"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/repository"
 	"github.com/todomyday/backend/internal/router"
 	"github.com/todomyday/backend/internal/services"
+	"github.com/todomyday/backend/internal/tracing"
 )
 
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", "error", err)
+	}
+
+	if err := logging.Init(logging.Config{Level: cfg.LogLevel, Format: cfg.LogFormat}); err != nil {
+		fatal("Invalid logging config", "error", err)
 	}
 
 	// Validate required config for Supabase
 	if cfg.SupabaseURL == "" {
-		log.Fatal("SUPABASE_URL environment variable is required")
+		fatal("SUPABASE_URL environment variable is required")
 	}
 	if cfg.SupabaseJWTSecret == "" {
-		log.Fatal("SUPABASE_JWT_SECRET environment variable is required")
+		fatal("SUPABASE_JWT_SECRET environment variable is required")
 	}
 	if cfg.SupabaseAnonKey == "" {
-		log.Fatal("SUPABASE_ANON_KEY environment variable is required")
+		fatal("SUPABASE_ANON_KEY environment variable is required")
 	}
 	if cfg.SupabaseServiceRoleKey == "" {
-		log.Fatal("SUPABASE_SERVICE_ROLE_KEY environment variable is required")
+		fatal("SUPABASE_SERVICE_ROLE_KEY environment variable is required")
+	}
+
+	// Initialize tracing (a no-op unless an exporter is configured)
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		Stdout:       cfg.TraceStdout,
+	})
+	if err != nil {
+		slog.Warn("Failed to initialize tracing", "error", err)
+	} else {
+		defer shutdownTracing(context.Background())
 	}
 
 	// Connect to database
-	db, err := database.Connect(cfg.DatabasePath)
+	db, err := database.Connect(cfg.DatabasePath, database.Config{
+		CacheSizeKB:       cfg.DBCacheSizeKB,
+		Synchronous:       cfg.DBSynchronous,
+		BusyTimeoutMS:     cfg.DBBusyTimeoutMS,
+		WALAutocheckpoint: cfg.DBWALAutocheckpoint,
+	})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", "error", err)
 	}
 	defer db.Close()
 
-	log.Printf("Connected to database: %s", cfg.DatabasePath)
+	slog.Info("Connected to database", "path", cfg.DatabasePath)
 
 	// === Database Health Checks ===
 
 	// 1. Check WAL checkpoint status and recover if needed
-	log.Println("Performing database health checks...")
+	slog.Info("Performing database health checks")
 	var walCheckpointResult string
 	err = db.QueryRow("PRAGMA wal_checkpoint(PASSIVE)").Scan(&walCheckpointResult)
 	if err != nil {
-		log.Printf("Warning: WAL checkpoint failed: %v", err)
+		slog.Warn("WAL checkpoint failed", "error", err)
 	} else {
-		log.Printf("WAL checkpoint status: %s", walCheckpointResult)
+		slog.Info("WAL checkpoint complete", "status", walCheckpointResult)
 	}
 
 	// 2. Verify database integrity
 	var integrityCheck string
 	err = db.QueryRow("PRAGMA integrity_check").Scan(&integrityCheck)
 	if err != nil {
-		log.Printf("Warning: Integrity check failed: %v", err)
+		slog.Warn("Integrity check failed", "error", err)
 	} else if integrityCheck != "ok" {
-		log.Printf("⚠️  WARNING: Database integrity check failed: %s", integrityCheck)
+		slog.Error("Database integrity check failed", "result", integrityCheck)
 	} else {
-		log.Println("✅ Database integrity check passed")
+		slog.Info("Database integrity check passed")
 	}
 
 	// 3. Check critical table counts
@@ -69,16 +99,20 @@ func main() {
 	db.QueryRow("SELECT COUNT(*) FROM users").Scan(&userCount)
 	db.QueryRow("SELECT COUNT(*) FROM todos").Scan(&todoCount)
 	db.QueryRow("SELECT COUNT(*) FROM memories").Scan(&memoryCount)
-	log.Printf("Database stats - Users: %d, Todos: %d, Memories: %d", userCount, todoCount, memoryCount)
+	slog.Info("Database stats", "users", userCount, "todos", todoCount, "memories", memoryCount)
 
 	// 4. Check for WAL file presence
 	var journalMode string
 	db.QueryRow("PRAGMA journal_mode").Scan(&journalMode)
-	log.Printf("Journal mode: %s", journalMode)
+	slog.Info("Journal mode", "mode", journalMode)
 
-	log.Println("Database health checks complete")
+	slog.Info("Database health checks complete")
 	// === End Health Checks ===
 
+	// Background loops stop on this context before a restore swaps the database out
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	todoRepo := repository.NewTodoRepository(db)
@@ -86,6 +120,30 @@ func main() {
 	aiProviderRepo := repository.NewAIProviderRepository(db)
 	memoryRepo := repository.NewMemoryRepository(db)
 	chatRepo := repository.NewChatRepository(db)
+	promptTemplateRepo := repository.NewPromptTemplateRepository(db)
+	todoTemplateRepo := repository.NewTodoTemplateRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	ipAllowlistRepo := repository.NewIPAllowlistRepository(db)
+	keywordBlocklistRepo := repository.NewKeywordBlocklistRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	systemSettingsRepo := repository.NewSystemSettingsRepository(db)
+	rssFeedRepo := repository.NewRSSFeedRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	searchHistoryRepo := repository.NewSearchHistoryRepository(db)
+	shareTokenRepo := repository.NewShareTokenRepository(db)
+	inboundWebhookSourceRepo := repository.NewInboundWebhookSourceRepository(db)
+	ragIndexQueueRepo := repository.NewRAGIndexQueueRepository(db)
+	backgroundJobRepo := repository.NewBackgroundJobRepository(db)
+	categoryCorrectionRepo := repository.NewCategoryCorrectionRepository(db)
+
+	// Background jobs run on cron schedules that can be changed through the admin API.
+	// Jobs are registered below as their features are set up, and start with the server.
+	jobScheduler := services.NewJobScheduler(backgroundJobRepo)
+	registerJob := func(name, defaultSchedule string, run services.JobFunc) {
+		if err := jobScheduler.Register(name, defaultSchedule, run); err != nil {
+			fatal("Failed to register background job", "job", name, "error", err)
+		}
+	}
 
 	// Initialize encryptor for API keys
 	encryptor := crypto.NewEncryptor(cfg.EncryptionKey)
@@ -99,34 +157,106 @@ func main() {
 		cfg.SupabaseServiceRoleKey,
 	)
 
+	// App tokens signed with JWT_SECRET, for OIDC sign-in and admin impersonation
+	var authService *services.AuthService
+	if len(cfg.JWTSecret) >= 32 {
+		authService = services.NewAuthService(userRepo, cfg.JWTSecret, cfg.JWTExpiration)
+	}
+
+	// OIDC single sign-on (optional); its users get app tokens
+	var oidcService *services.OIDCService
+	if cfg.OIDCProviderURL != "" {
+		if cfg.OIDCClientID == "" || cfg.OIDCRedirectURI == "" {
+			fatal("OIDC_CLIENT_ID and OIDC_REDIRECT_URI are required when OIDC_PROVIDER_URL is set")
+		}
+		if authService == nil {
+			fatal("JWT_SECRET of at least 32 characters is required when OIDC_PROVIDER_URL is set")
+		}
+		oidcService = services.NewOIDCService(cfg.OIDCProviderURL, cfg.OIDCProviderName, cfg.OIDCClientID, cfg.OIDCClientSecret, cfg.OIDCRedirectURI, userRepo, authService)
+		slog.Info("OIDC single sign-on enabled", "provider", cfg.OIDCProviderURL)
+	}
+
 	// Initialize core services
 	aiService := services.NewAIService(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.OpenAIModel)
-	aiProviderService := services.NewAIProviderService(aiProviderRepo, encryptor)
-	groupService := services.NewGroupService(groupRepo)
+	auditService := services.NewAuditService(auditRepo)
+	impersonationService := services.NewImpersonationService(authService, userRepo, auditService)
+	aiProviderService := services.NewAIProviderService(aiProviderRepo, encryptor, auditService)
+	if cfg.PIIScrubbingEnabled {
+		piiScrubber := services.NewPIIScrubber()
+		aiService.SetPIIScrubber(piiScrubber)
+		aiProviderService.SetPIIScrubber(piiScrubber)
+		slog.Info("PII scrubbing enabled for AI processing")
+	}
+	groupService := services.NewGroupService(groupRepo, todoRepo)
+	promptTemplateService := services.NewPromptTemplateService(promptTemplateRepo)
+	ipAllowlistService := services.NewIPAllowlistService(ipAllowlistRepo, auditService)
+	blocklistService := services.NewBlocklistService(keywordBlocklistRepo, cfg.SystemBlocklistPatterns)
+	sessionService := services.NewSessionService(sessionRepo)
+	searchHistoryService := services.NewSearchHistoryService(searchHistoryRepo, cfg.SearchHistoryEmpty)
+	userPreferencesService := services.NewUserPreferencesService(userRepo)
 
 	// Initialize scraper service (optional - for web search)
 	var scraperService *services.ScraperService
 	if len(cfg.SearXNGURLs) > 0 {
 		scraperService = services.NewScraperService(cfg.SearXNGURLs)
-		log.Printf("Web search enabled via SearXNG: %v", cfg.SearXNGURLs)
+		slog.Info("Web search enabled via SearXNG", "urls", cfg.SearXNGURLs)
 	}
 
 	// Log AI configuration status
 	if aiService.IsConfigured() {
-		log.Printf("AI service configured with model: %s", cfg.OpenAIModel)
+		slog.Info("AI service configured", "model", cfg.OpenAIModel)
 	} else {
-		log.Println("AI service not configured - todos will use original titles")
+		slog.Info("AI service not configured - todos will use original titles")
 	}
 
+	// Create FTS repository and initialize tables. Keyword search and autocomplete
+	// only need SQLite, so this runs whether or not RAG is enabled. Rebuilding the
+	// index from existing data can take a while on large databases, so it runs in the
+	// background and /ready reports 503 until it finishes.
+	ftsTokenizer, err := repository.NormalizeFTSTokenizer(cfg.FTSTokenizer)
+	if err != nil {
+		fatal("Invalid FTS_TOKENIZER", "error", err)
+	}
+	ftsRepo := repository.NewFTSRepository(db)
+	ftsReady := make(chan struct{})
+	go func() {
+		defer close(ftsReady)
+		if err := ftsRepo.InitFTSTables(ftsTokenizer); err != nil {
+			slog.Warn("Failed to initialize FTS tables", "error", err)
+			return
+		}
+		start := time.Now()
+		// FTS5 tables can't be altered, so a new tokenizer means building the table again
+		current, err := ftsRepo.GetTokenizer()
+		if err != nil {
+			slog.Warn("Failed to read FTS tokenizer", "error", err)
+			return
+		}
+		if current != ftsTokenizer {
+			slog.Info("FTS tokenizer changed, rebuilding index", "from", current, "to", ftsTokenizer)
+			if err := ftsRepo.RebuildWithTokenizer(ftsTokenizer); err != nil {
+				slog.Warn("Failed to rebuild FTS", "error", err)
+				return
+			}
+		} else if err := ftsRepo.PopulateFTSFromExisting(); err != nil {
+			slog.Warn("Failed to populate FTS", "error", err)
+			return
+		}
+		slog.Info("FTS index populated", "tokenizer", ftsTokenizer, "duration", time.Since(start))
+	}()
+
+	systemSettingsService := services.NewSystemSettingsService(systemSettingsRepo)
+
 	// Initialize RAG components (before todo/memory services so they can use it)
 	var ragService *services.RAGService
 	var vectorRepo *repository.VectorRepository
+	var embeddingService *services.EmbeddingService
 
 	if cfg.RAGEnabled && cfg.NIMAPIKey != "" {
-		log.Println("Initializing RAG service with NVIDIA NIM embeddings...")
+		slog.Info("Initializing RAG service with NVIDIA NIM embeddings")
 
 		// Create NIM embedding service
-		embeddingService := services.NewEmbeddingService(
+		embeddingService = services.NewEmbeddingService(
 			cfg.NIMBaseURL,
 			cfg.NIMAPIKey,
 			cfg.NIMModel,
@@ -134,14 +264,19 @@ func main() {
 			cfg.NIMEmbeddingDim,
 		)
 
-		// Create FTS repository and initialize tables
-		ftsRepo := repository.NewFTSRepository(db)
-		if err := ftsRepo.InitFTSTables(); err != nil {
-			log.Printf("Warning: Failed to initialize FTS tables: %v", err)
-		} else {
-			// Populate FTS from existing data
-			if err := ftsRepo.PopulateFTSFromExisting(); err != nil {
-				log.Printf("Warning: Failed to populate FTS: %v", err)
+		// With EMBEDDING_ENSEMBLE=true, documents and queries are embedded by every
+		// provider in the embedding_ensemble system setting
+		var embedder repository.EmbeddingService = embeddingService
+		dimension := embeddingService.GetDimension()
+		var ensemble *services.EnsembleEmbeddingService
+		if cfg.EmbeddingEnsemble {
+			if ensemble, err = services.LoadEmbeddingEnsemble(systemSettingsService, embeddingService, aiProviderService); err != nil {
+				slog.Warn("Failed to load embedding ensemble, using NIM embeddings alone", "error", err)
+				ensemble = nil
+			} else {
+				embedder = ensemble
+				dimension = ensemble.GetDimension()
+				slog.Info("Embedding with an ensemble", "model", ensemble.GetModel(), "dim", dimension)
 			}
 		}
 
@@ -149,12 +284,12 @@ func main() {
 		vRepo, err := repository.NewVectorRepository(
 			repository.VectorConfig{
 				PersistPath: cfg.VectorDBPath,
-				Dimension:   embeddingService.GetDimension(),
+				Dimension:   dimension,
 			},
-			embeddingService,
+			embedder,
 		)
 		if err != nil {
-			log.Printf("Warning: Failed to create vector repository: %v", err)
+			slog.Warn("Failed to create vector repository", "error", err)
 		} else {
 			vectorRepo = vRepo
 			// Create RAG service
@@ -167,23 +302,97 @@ func main() {
 				aiService,
 				aiProviderService,
 				scraperService,
+				searchHistoryService,
 			)
-			log.Printf("RAG service initialized with NIM embedding model: %s (dim=%d, rpm=%d)",
-				cfg.NIMModel, cfg.NIMEmbeddingDim, cfg.NIMRPMLimit)
+			ragService.SetEmbeddingWorkers(cfg.EmbeddingWorkers)
+			if ensemble != nil {
+				ragService.SetEmbeddingEnsemble(ensemble)
+			}
+			slog.Info("RAG service initialized with NIM embeddings",
+				"model", cfg.NIMModel, "dim", cfg.NIMEmbeddingDim, "rpm", cfg.NIMRPMLimit, "workers", cfg.EmbeddingWorkers)
 		}
 	} else {
-		log.Println("RAG service not enabled - set NIM_API_KEY to enable")
+		slog.Info("RAG service not enabled - set NIM_API_KEY to enable")
 	}
 
 	// Initialize todo and memory services (with RAG integration)
-	todoService := services.NewTodoService(todoRepo, aiService, aiProviderService, ragService)
-	memoryService := services.NewMemoryService(memoryRepo, todoRepo, aiService, aiProviderService, scraperService, ragService)
+	todoService := services.NewTodoService(todoRepo, groupRepo, userRepo, aiService, aiProviderService, ragService, promptTemplateService, auditService, userPreferencesService)
+	todoTemplateService := services.NewTodoTemplateService(todoTemplateRepo, todoService)
 
-	// Initialize user data service (for data management)
-	userDataService := services.NewUserDataService(memoryRepo, todoRepo, groupRepo, vectorRepo, ragService)
+	// Move the priorities of pending todos with their due dates, for users who turn on auto-priority
+	userPreferencesService.SetTodoService(todoService)
+	registerJob(models.JobAutoPriority, services.AutoPrioritySchedule, todoService.ApplyAutoPriorities)
 
-	// Initialize file parser service
-	fileParserService := services.NewFileParserService()
+	if ragService != nil {
+		ragService.SetTodoService(todoService)
+
+		// Retry background indexing that failed, with backoff
+		ragRetryService := services.NewRAGRetryService(ragIndexQueueRepo, todoRepo, memoryRepo, ragService)
+		ragService.SetRetryService(ragRetryService)
+		registerJob(models.JobRAGIndexRetry, services.RAGRetrySchedule, ragRetryService.RetryDue)
+	}
+	// Initialize attachment storage (optional - keeps uploaded images)
+	var storageService services.StorageService
+	if cfg.StorageEndpoint != "" && cfg.StorageBucket != "" {
+		s3Storage, err := services.NewS3StorageService(cfg.StorageEndpoint, cfg.StorageBucket, cfg.StorageAccessKey, cfg.StorageSecretKey)
+		if err != nil {
+			slog.Warn("Failed to initialize attachment storage", "error", err)
+		} else {
+			storageService = s3Storage
+			slog.Info("Attachment storage enabled", "endpoint", cfg.StorageEndpoint, "bucket", cfg.StorageBucket)
+		}
+	} else {
+		slog.Info("Attachment storage not configured - uploaded images will not be kept")
+	}
+	attachmentService := services.NewAttachmentService(attachmentRepo, memoryRepo, storageService)
+
+	categoryModel := services.NewPersonalCategoryModel(categoryCorrectionRepo)
+	memoryService := services.NewMemoryService(memoryRepo, todoRepo, groupRepo, aiService, aiProviderService, scraperService, ragService, auditService, searchHistoryService, userPreferencesService, categoryModel, blocklistService, attachmentService)
+	memoryService.SetPromptTemplateService(promptTemplateService)
+	rssFeedService := services.NewRSSFeedService(rssFeedRepo, memoryRepo, memoryService, scraperService)
+	registerJob(models.JobRSSFeedImport, services.RSSFeedImportSchedule, rssFeedService.ImportSavedFeeds)
+
+	// AI plan for the day, cached until the user creates a todo or memory
+	dailyBriefingService := services.NewDailyBriefingService(todoRepo, memoryRepo, todoService)
+	todoService.SetDailyBriefingService(dailyBriefingService)
+	memoryService.SetDailyBriefingService(dailyBriefingService)
+
+	// Email opted-in users their weekly digest (optional - needs an SMTP server)
+	emailService := services.NewEmailService(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass, cfg.SMTPFrom)
+	if emailService.IsConfigured() {
+		digestScheduler := services.NewDigestScheduler(userRepo, memoryService, emailService)
+		registerJob(models.JobWeeklyDigest, services.DigestSchedule, func(ctx context.Context) error {
+			_, err := digestScheduler.SendDue(ctx)
+			return err
+		})
+		slog.Info("Weekly digest emails enabled", "smtp_host", cfg.SMTPHost, "smtp_port", cfg.SMTPPort)
+	} else {
+		slog.Info("Email not configured - set SMTP_HOST and SMTP_FROM to send weekly digests")
+	}
+
+	// Keep scraped URL content of memories current (needs web scraping)
+	if scraperService != nil && cfg.URLRefreshIntervalDays > 0 {
+		urlRefreshScheduler := services.NewURLRefreshScheduler(memoryService, cfg.URLRefreshIntervalDays)
+		registerJob(models.JobURLRefresh, services.URLRefreshSchedule, func(ctx context.Context) error {
+			_, err := urlRefreshScheduler.RefreshDue(ctx)
+			return err
+		})
+		slog.Info("Memory URL content refresh enabled", "interval_days", cfg.URLRefreshIntervalDays)
+	}
+
+	// Webhooks from external services (GitHub, Linear, Jira) create todos and memories
+	webhookIngestionService := services.NewWebhookIngestionService(inboundWebhookSourceRepo, userRepo, todoService, memoryService, aiProviderService)
+
+	// Public share links to memories; expired ones are pruned daily
+	shareService := services.NewShareService(shareTokenRepo, memoryRepo, cfg.PublicURL)
+	registerJob(models.JobSharePruning, services.ShareTokenPruneSchedule, func(ctx context.Context) error {
+		_, err := shareService.PruneExpired()
+		return err
+	})
+
+	// Initialize user data service (for data management)
+	userDataService := services.NewUserDataService(userRepo, memoryRepo, todoRepo, groupRepo, vectorRepo, ragService, aiProviderService, auditService, supabaseAuthService, blocklistService, attachmentService)
+	userDataService.SetDailyBriefingService(dailyBriefingService)
 
 	// Initialize upload job service
 	uploadJobService := services.NewUploadJobService()
@@ -191,22 +400,161 @@ func main() {
 	// Initialize vision service for image processing (GLM-4.5V)
 	visionService := services.NewVisionService(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, "glm-4.5v")
 	if visionService.IsConfigured() {
-		log.Println("Vision service configured with GLM-4.5V for image processing")
+		slog.Info("Vision service configured with GLM-4.5V for image processing")
 	} else {
-		log.Println("Vision service not configured - image upload will be unavailable")
+		slog.Info("Vision service not configured - image upload will be unavailable")
 	}
 
+	// Initialize file parser service (scanned PDFs are OCRed with the vision service)
+	fileParserService := services.NewFileParserService(visionService)
+
+	// Initialize search service (autocomplete over the FTS index)
+	searchService := services.NewSearchService(ftsRepo)
+
+	// Check the FTS index for drift from crashes, rebuilding it if needed. Runs before
+	// the index is populated at startup are skipped.
+	registerJob(models.JobFTSHealthCheck, services.FTSHealthCheckSchedule, func(ctx context.Context) error {
+		select {
+		case <-ftsReady:
+		default:
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+		defer cancel()
+		_, err := searchService.CheckFTSHealth(ctx)
+		return err
+	})
+
 	// Initialize chat service
-	chatService := services.NewChatService(chatRepo)
+	chatService := services.NewChatService(chatRepo, aiProviderService, ragService)
+
+	// Allowed origins saved through the admin API override ALLOWED_ORIGINS
+	allowedOrigins := cfg.AllowedOrigins
+	if stored, err := systemSettingsService.GetAllowedOrigins(); err != nil {
+		slog.Warn("Failed to load stored allowed origins", "error", err)
+	} else if stored != nil {
+		allowedOrigins = stored
+	}
+	corsMiddleware, err := middleware.NewDynamicCORS(allowedOrigins)
+	if err != nil {
+		fatal("Invalid allowed origins", "error", err)
+	}
+
+	// Pick up origin changes made by other instances
+	go func() {
+		ticker := time.NewTicker(services.AllowedOriginsPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-backgroundCtx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			origins, err := systemSettingsService.GetAllowedOrigins()
+			if err != nil {
+				slog.Warn("Failed to reload allowed origins", "error", err)
+				continue
+			}
+			if origins == nil {
+				continue
+			}
+			if err := corsMiddleware.SetOrigins(origins); err != nil {
+				slog.Warn("Ignoring invalid stored allowed origins", "error", err)
+			}
+		}
+	}()
+
+	// A restore hands its validated database file over here to be swapped in
+	restoreRequests := make(chan string, 1)
+	backupService := services.NewBackupService(repository.NewBackupRepository(db), cfg.DatabasePath, func(staged string) {
+		restoreRequests <- staged
+	})
+
+	if err := jobScheduler.Start(backgroundCtx); err != nil {
+		fatal("Failed to start background jobs", "error", err)
+	}
+
+	// Report dependency status on /health, and readiness once the FTS index is populated
+	healthService := services.NewHealthService(db, ragService, embeddingService, scraperService, ftsReady)
 
 	// Setup router
-	r := router.Setup(supabaseAuthService, userRepo, todoService, groupService, aiProviderService, memoryService, ragService, userDataService, fileParserService, uploadJobService, visionService, chatService, cfg.AllowedOrigins)
+	r := router.Setup(supabaseAuthService, userRepo, todoService, groupService, aiProviderService, memoryService, ragService, userDataService, fileParserService, uploadJobService, visionService, chatService, scraperService, promptTemplateService, auditService, searchService, searchHistoryService, ipAllowlistService, blocklistService, attachmentService, todoTemplateService, rssFeedService, systemSettingsService, backupService, userPreferencesService, sessionService, healthService, shareService, authService, oidcService, impersonationService, jobScheduler, webhookIngestionService, dailyBriefingService, corsMiddleware, cfg.TrustedProxies, cfg.AdminSecret)
+
+	// Serve metrics on a separate port, away from the public API
+	if cfg.MetricsPort == cfg.Port {
+		slog.Warn("METRICS_PORT matches PORT - metrics endpoint disabled")
+	} else {
+		go func() {
+			slog.Info("Metrics server starting", "port", cfg.MetricsPort)
+			if err := router.SetupMetrics().Run(":" + cfg.MetricsPort); err != nil {
+				slog.Warn("Metrics server stopped", "error", err)
+			}
+		}()
+	}
 
 	// Start server
-	log.Printf("Server starting on port %s", cfg.Port)
-	log.Printf("Allowed origins: %v", cfg.AllowedOrigins)
+	slog.Info("Server starting", "port", cfg.Port)
+	slog.Info("Allowed origins", "origins", corsMiddleware.Origins())
+
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("Failed to start server", "error", err)
+		}
+	}()
+
+	// Serve until a restore arrives, then stop everything that touches the database,
+	// swap the restored file in and start over
+	staged := <-restoreRequests
+	slog.Info("Restore requested - stopping background work and draining requests")
+	stopBackground()
 
-	if err := r.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Server did not shut down cleanly", "error", err)
+	}
+	cancel()
+
+	if err := db.Close(); err != nil {
+		slog.Warn("Failed to close database", "error", err)
+	}
+
+	// The vector index holds the content of the database being replaced, so it's
+	// dropped and the restored rows are marked unindexed; POST /api/rag/index rebuilds
+	// each user's index
+	if err := database.ResetIndexState(staged); err != nil {
+		slog.Warn("Failed to mark restored todos and memories unindexed", "error", err)
+	}
+	if err := database.ReplaceFile(staged, cfg.DatabasePath); err != nil {
+		fatal("Failed to restore database", "error", err)
+	}
+	if cfg.VectorDBPath != "" {
+		if err := os.RemoveAll(cfg.VectorDBPath); err != nil {
+			slog.Warn("Failed to remove the vector index", "path", cfg.VectorDBPath, "error", err)
+		}
+	}
+
+	slog.Info("Database restored - restarting")
+	if err := restart(); err != nil {
+		fatal("Failed to restart after restore", "error", err)
+	}
+}
+
+// fatal logs msg at error level and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// restart replaces this process with a fresh copy of itself, with the same arguments
+// and environment
+func restart() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
 	}
+	return syscall.Exec(executable, os.Args, os.Environ())
 }
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -11,30 +13,82 @@ import (
 
 type Config struct {
 	Port           string
+	MetricsPort    string
 	DatabasePath   string
 	JWTSecret      string
 	JWTExpiration  time.Duration
 	EncryptionKey  string
+	AdminSecret    string
 	OpenAIBaseURL  string
 	OpenAIAPIKey   string
 	OpenAIModel    string
 	AllowedOrigins []string
-	SearXNGURLs    []string
+	// TrustedProxies are the proxy IPs or CIDRs whose X-Forwarded-For header is believed
+	// when working out a client's IP. Empty trusts none, so the peer address is used
+	TrustedProxies []string
+	// PublicURL is the frontend's address, used to build memory share links
+	PublicURL   string
+	SearXNGURLs []string
+	// SystemBlocklistPatterns is content no user's memories may contain: keywords, or
+	// regular expressions written /like this/ (comma-separated, so without commas)
+	SystemBlocklistPatterns []string
 	// RAG/Embedding settings
 	EmbeddingModel string
 	VectorDBPath   string
 	RAGEnabled     bool
+	// SearchHistoryEmpty records searches that returned no results (on unless "false")
+	SearchHistoryEmpty bool
+	// PIIScrubbingEnabled replaces personal data in todos and memories with placeholders
+	// before they're sent to AI providers (off unless "true")
+	PIIScrubbingEnabled bool
 	// NIM Embedding settings
 	NIMAPIKey       string
 	NIMBaseURL      string
 	NIMModel        string
 	NIMRPMLimit     int
 	NIMEmbeddingDim int
+	// EmbeddingWorkers is how many documents a full re-index embeds concurrently
+	EmbeddingWorkers int
+	// EmbeddingEnsemble embeds with every provider in the embedding_ensemble system
+	// setting and combines their vectors (off unless "true")
+	EmbeddingEnsemble bool
 	// Supabase settings
 	SupabaseURL           string
 	SupabaseAnonKey       string
 	SupabaseServiceRoleKey string
 	SupabaseJWTSecret      string
+	// OpenID Connect single sign-on; disabled unless OIDC_PROVIDER_URL is set
+	OIDCProviderURL  string
+	OIDCProviderName string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURI  string
+	// S3-compatible object storage for memory attachments
+	StorageEndpoint  string
+	StorageBucket    string
+	StorageAccessKey string
+	StorageSecretKey string
+	// OpenTelemetry tracing (disabled unless one of these is set)
+	OTLPEndpoint string
+	TraceStdout  bool
+	// Logging: LOG_LEVEL (debug, info, warn, error) and LOG_FORMAT (text, json)
+	LogLevel  string
+	LogFormat string
+	// Days before a memory's scraped URL content is refreshed; 0 turns refreshing off
+	URLRefreshIntervalDays int
+	// SMTP server for outgoing email (weekly digests); disabled unless SMTP_HOST is set
+	SMTPHost string
+	SMTPPort int
+	SMTPUser string
+	SMTPPass string
+	SMTPFrom string
+	// SQLite PRAGMAs applied to every database connection
+	DBCacheSizeKB       int
+	DBSynchronous       string
+	DBBusyTimeoutMS     int
+	DBWALAutocheckpoint int
+	// FTSTokenizer is the FTS5 tokenizer keyword search is built with
+	FTSTokenizer string
 }
 
 func Load() (*Config, error) {
@@ -58,6 +112,12 @@ func Load() (*Config, error) {
 		port = "8099"
 	}
 
+	// Metrics get their own port so they're never reachable through the public API
+	metricsPort := os.Getenv("METRICS_PORT")
+	if metricsPort == "" {
+		metricsPort = "9099"
+	}
+
 	dbPath := os.Getenv("DATABASE_PATH")
 	if dbPath == "" {
 		dbPath = "./data/todomyday.db"
@@ -73,6 +133,12 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Share links point at the frontend, which is usually the first allowed origin
+	publicURL := strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+	if publicURL == "" {
+		publicURL = strings.TrimSuffix(origins[0], "/")
+	}
+
 	openaiModel := os.Getenv("OPENAI_MODEL")
 	if openaiModel == "" {
 		openaiModel = "gpt-3.5-turbo"
@@ -97,6 +163,26 @@ func Load() (*Config, error) {
 		}
 	}
 
+	var trustedProxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: must be an IP or CIDR", proxy)
+			}
+		}
+		trustedProxies = append(trustedProxies, proxy)
+	}
+
+	var systemBlocklistPatterns []string
+	for _, pattern := range strings.Split(os.Getenv("SYSTEM_BLOCKLIST_PATTERNS"), ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			systemBlocklistPatterns = append(systemBlocklistPatterns, pattern)
+		}
+	}
+
 	// RAG/Embedding settings
 	embeddingModel := os.Getenv("EMBEDDING_MODEL")
 	if embeddingModel == "" {
@@ -135,28 +221,123 @@ func Load() (*Config, error) {
 		}
 	}
 
+	embeddingWorkers := 4
+	if workersStr := os.Getenv("EMBEDDING_WORKERS"); workersStr != "" {
+		if workers, err := strconv.Atoi(workersStr); err == nil && workers > 0 {
+			embeddingWorkers = workers
+		}
+	}
+
+	// OTEL_TRACES_EXPORTER=console prints spans locally instead of exporting them
+	tracesExporter := os.Getenv("OTEL_TRACES_EXPORTER")
+	traceStdout := tracesExporter == "console" || tracesExporter == "stdout"
+
+	urlRefreshIntervalDays := 7
+	if daysStr := os.Getenv("URL_REFRESH_INTERVAL_DAYS"); daysStr != "" {
+		if days, err := strconv.Atoi(daysStr); err == nil && days >= 0 {
+			urlRefreshIntervalDays = days
+		}
+	}
+
+	smtpPort := 587
+	if portStr := os.Getenv("SMTP_PORT"); portStr != "" {
+		if parsed, err := strconv.Atoi(portStr); err == nil && parsed > 0 {
+			smtpPort = parsed
+		}
+	}
+
+	dbCacheSizeKB := 32768
+	if sizeStr := os.Getenv("DB_CACHE_SIZE_KB"); sizeStr != "" {
+		if size, err := strconv.Atoi(sizeStr); err == nil && size > 0 {
+			dbCacheSizeKB = size
+		}
+	}
+
+	dbSynchronous := strings.ToUpper(os.Getenv("DB_SYNCHRONOUS"))
+	if dbSynchronous == "" {
+		dbSynchronous = "NORMAL"
+	}
+
+	dbBusyTimeoutMS := 5000
+	if timeoutStr := os.Getenv("DB_BUSY_TIMEOUT_MS"); timeoutStr != "" {
+		if timeout, err := strconv.Atoi(timeoutStr); err == nil && timeout >= 0 {
+			dbBusyTimeoutMS = timeout
+		}
+	}
+
+	// 0 turns automatic checkpoints off
+	dbWALAutocheckpoint := 1000
+	if pagesStr := os.Getenv("DB_WAL_AUTOCHECKPOINT"); pagesStr != "" {
+		if pages, err := strconv.Atoi(pagesStr); err == nil && pages >= 0 {
+			dbWALAutocheckpoint = pages
+		}
+	}
+
+	ftsTokenizer := os.Getenv("FTS_TOKENIZER")
+	if ftsTokenizer == "" {
+		ftsTokenizer = "porter unicode61"
+	}
+
+	oidcProviderName := os.Getenv("OIDC_PROVIDER_NAME")
+	if oidcProviderName == "" {
+		oidcProviderName = "SSO"
+	}
+
 	return &Config{
 		Port:                  port,
+		MetricsPort:           metricsPort,
 		DatabasePath:          dbPath,
 		JWTSecret:             os.Getenv("JWT_SECRET"),
 		JWTExpiration:         expDuration,
 		EncryptionKey:          encryptionKey,
+		AdminSecret:           os.Getenv("ADMIN_SECRET"),
 		OpenAIBaseURL:         os.Getenv("OPENAI_BASE_URL"),
 		OpenAIAPIKey:          os.Getenv("OPENAI_API_KEY"),
 		OpenAIModel:           openaiModel,
 		AllowedOrigins:        origins,
+		TrustedProxies:        trustedProxies,
+		PublicURL:             publicURL,
 		SearXNGURLs:           searxngURLs,
+		SystemBlocklistPatterns: systemBlocklistPatterns,
 		EmbeddingModel:        embeddingModel,
 		VectorDBPath:          vectorDBPath,
 		RAGEnabled:            ragEnabled,
+		SearchHistoryEmpty:    os.Getenv("SEARCH_HISTORY_EMPTY") != "false",
+		PIIScrubbingEnabled:   os.Getenv("PII_SCRUBBING_ENABLED") == "true",
 		NIMAPIKey:             os.Getenv("NIM_API_KEY"),
 		NIMBaseURL:            nimBaseURL,
 		NIMModel:              nimModel,
 		NIMRPMLimit:           nimRPMLimit,
 		NIMEmbeddingDim:       nimEmbeddingDim,
+		EmbeddingWorkers:      embeddingWorkers,
+		EmbeddingEnsemble:     os.Getenv("EMBEDDING_ENSEMBLE") == "true",
 		SupabaseURL:           os.Getenv("SUPABASE_URL"),
 		SupabaseAnonKey:       os.Getenv("SUPABASE_ANON_KEY"),
 		SupabaseServiceRoleKey: os.Getenv("SUPABASE_SERVICE_ROLE_KEY"),
 		SupabaseJWTSecret:     os.Getenv("SUPABASE_JWT_SECRET"),
+		OIDCProviderURL:       os.Getenv("OIDC_PROVIDER_URL"),
+		OIDCProviderName:      oidcProviderName,
+		OIDCClientID:          os.Getenv("OIDC_CLIENT_ID"),
+		OIDCClientSecret:      os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURI:       os.Getenv("OIDC_REDIRECT_URI"),
+		StorageEndpoint:       os.Getenv("STORAGE_ENDPOINT"),
+		StorageBucket:         os.Getenv("STORAGE_BUCKET"),
+		StorageAccessKey:      os.Getenv("STORAGE_ACCESS_KEY"),
+		StorageSecretKey:      os.Getenv("STORAGE_SECRET_KEY"),
+		OTLPEndpoint:          os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		TraceStdout:           traceStdout,
+		LogLevel:              os.Getenv("LOG_LEVEL"),
+		LogFormat:             os.Getenv("LOG_FORMAT"),
+		URLRefreshIntervalDays: urlRefreshIntervalDays,
+		SMTPHost:              os.Getenv("SMTP_HOST"),
+		SMTPPort:              smtpPort,
+		SMTPUser:              os.Getenv("SMTP_USER"),
+		SMTPPass:              os.Getenv("SMTP_PASS"),
+		SMTPFrom:              os.Getenv("SMTP_FROM"),
+		DBCacheSizeKB:         dbCacheSizeKB,
+		DBSynchronous:         dbSynchronous,
+		DBBusyTimeoutMS:       dbBusyTimeoutMS,
+		DBWALAutocheckpoint:   dbWALAutocheckpoint,
+		FTSTokenizer:          ftsTokenizer,
 	}, nil
 }
@@ -75,6 +75,16 @@ func (e *Encryptor) Decrypt(ciphertext string) (string, error) {
 	return string(plaintext), nil
 }
 
+// ReEncrypt decrypts ciphertext with this encryptor and encrypts the plaintext
+// again with newEncryptor, for rotating to a new key
+func (e *Encryptor) ReEncrypt(ciphertext string, newEncryptor *Encryptor) (string, error) {
+	plaintext, err := e.Decrypt(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return newEncryptor.Encrypt(plaintext)
+}
+
 // MaskAPIKey returns a masked version of the API key for display
 // Shows first 4 and last 4 characters only
 func MaskAPIKey(apiKey string) string {
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrInvalidBackup is returned when a file offered for restore isn't a sound
+// SQLite database of this app
+var ErrInvalidBackup = errors.New("invalid database backup")
+
+// Backup writes a consistent copy of the live database to path, which must not
+// exist yet. VACUUM INTO reads a single snapshot, so writers aren't blocked and
+// the copy never holds a half-applied transaction.
+func Backup(db *sql.DB, path string) error {
+	if _, err := db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+	return nil
+}
+
+// ValidateBackup checks that the file at path is an SQLite database that passes
+// PRAGMA integrity_check and holds this app's tables
+func ValidateBackup(path string) error {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	defer db.Close()
+
+	var integrity string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&integrity); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("%w: integrity check failed: %s", ErrInvalidBackup, integrity)
+	}
+
+	var tables int
+	if err := db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('users', 'todos', 'memories')
+	`).Scan(&tables); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if tables != 3 {
+		return fmt.Errorf("%w: not a todomyday database", ErrInvalidBackup)
+	}
+	return nil
+}
+
+// ResetIndexState marks every todo and memory in the database at path as never
+// indexed. The vector index is kept outside the database, so after a restore it no
+// longer matches the rows; with this the rows count as stale until they're re-indexed.
+func ResetIndexState(path string) error {
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, table := range []string{"todos", "memories"} {
+		// Backups from before indexing times were kept don't have the column, and get
+		// it empty when migrated
+		var count int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = 'last_indexed_at'`, table).Scan(&count); err != nil {
+			return fmt.Errorf("failed to check for last_indexed_at column on %s: %w", table, err)
+		}
+		if count == 0 {
+			continue
+		}
+		if _, err := db.Exec(`UPDATE ` + table + ` SET last_indexed_at = NULL`); err != nil {
+			return fmt.Errorf("failed to reset indexing times of %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// ReplaceFile moves the database at staged over dbPath. The live database must be
+// closed first; its WAL and shared-memory files are removed so SQLite doesn't replay
+// the old database's log over the restored one.
+func ReplaceFile(staged, dbPath string) error {
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dbPath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", dbPath+suffix, err)
+		}
+	}
+	if err := os.Rename(staged, dbPath); err != nil {
+		return fmt.Errorf("failed to replace database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResetIndexState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "restored.db")
+	db, err := Connect(path, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO users (id, email) VALUES ('user', 'restore@example.com')`); err != nil {
+		t.Fatal(err)
+	}
+	indexedAt := time.Now()
+	for _, stmt := range []string{
+		`INSERT INTO todos (id, user_id, title, last_indexed_at) VALUES ('todo', 'user', 'Restored todo', ?)`,
+		`INSERT INTO memories (id, user_id, content, last_indexed_at) VALUES ('memory', 'user', 'Restored memory', ?)`,
+	} {
+		if _, err := db.Exec(stmt, indexedAt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	if err := ResetIndexState(path); err != nil {
+		t.Fatalf("ResetIndexState: %v", err)
+	}
+	if err := ValidateBackup(path); err != nil {
+		t.Fatalf("ValidateBackup after ResetIndexState: %v", err)
+	}
+
+	db, err = Connect(path, DefaultConfig())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer db.Close()
+	for _, table := range []string{"todos", "memories"} {
+		var indexed int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table + ` WHERE last_indexed_at IS NOT NULL`).Scan(&indexed); err != nil {
+			t.Fatal(err)
+		}
+		if indexed != 0 {
+			t.Errorf("%d %s still have an indexing time", indexed, table)
+		}
+	}
+}
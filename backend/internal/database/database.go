@@ -1,33 +1,35 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
 )
 
-func Connect(dbPath string) (*sql.DB, error) {
+func Connect(dbPath string, cfg Config) (*sql.DB, error) {
+	dsn, err := cfg.dsn(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// Ensure the directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
-	}
-
 	// Enable WAL mode for better concurrency
 	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
 		return nil, fmt.Errorf("failed to enable WAL mode: %w", err)
@@ -47,10 +49,16 @@ func runMigrations(db *sql.DB) error {
 	CREATE TABLE IF NOT EXISTS users (
 		id TEXT PRIMARY KEY,
 		supabase_id TEXT UNIQUE,
+		oidc_sub TEXT UNIQUE,
 		email TEXT UNIQUE NOT NULL,
 		password_hash TEXT,
 		full_name TEXT,
 		theme TEXT DEFAULT 'light',
+		timezone TEXT DEFAULT 'UTC',
+		email_digest_enabled INTEGER DEFAULT 0,
+		last_digest_sent_at DATETIME,
+		sort_mode TEXT DEFAULT 'manual',
+		daily_goal INTEGER DEFAULT 3,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -61,7 +69,10 @@ func runMigrations(db *sql.DB) error {
 		user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
 		name TEXT NOT NULL,
 		color_code TEXT DEFAULT '#4F46E5',
+		color_dark TEXT,
 		is_default INTEGER DEFAULT 0,
+		is_archived INTEGER DEFAULT 0,
+		parent_id TEXT REFERENCES groups(id) ON DELETE SET NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -78,6 +89,11 @@ func runMigrations(db *sql.DB) error {
 		status TEXT DEFAULT 'pending' CHECK(status IN ('pending', 'completed')),
 		position TEXT DEFAULT '1000',
 		tags TEXT DEFAULT '[]',
+		story_points INTEGER,
+		estimated_duration TEXT,
+		original_priority TEXT,
+		priority_locked_until DATETIME,
+		last_indexed_at DATETIME,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -87,12 +103,23 @@ func runMigrations(db *sql.DB) error {
 		id TEXT PRIMARY KEY,
 		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
 		name TEXT NOT NULL,
-		provider_type TEXT NOT NULL CHECK(provider_type IN ('openai', 'anthropic', 'google', 'custom')),
+		provider_type TEXT NOT NULL CHECK(provider_type IN ('openai', 'anthropic', 'google', 'custom', 'assistant', 'ollama')),
 		base_url TEXT NOT NULL,
 		api_key_encrypted TEXT NOT NULL,
 		selected_model TEXT,
 		is_default INTEGER DEFAULT 0,
 		is_enabled INTEGER DEFAULT 1,
+		requests_per_minute INTEGER DEFAULT 60,
+		timeout_seconds INTEGER DEFAULT 30,
+		no_auth INTEGER DEFAULT 0,
+		supports_structured_output INTEGER DEFAULT 0,
+		default_temperature REAL DEFAULT 0.3,
+		default_max_tokens INTEGER DEFAULT 500,
+		metadata TEXT,
+		embedding_model TEXT,
+		embedding_dimension INTEGER,
+		fallback_provider_id TEXT REFERENCES ai_providers(id) ON DELETE SET NULL,
+		system_prompt TEXT NOT NULL DEFAULT '',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -103,6 +130,7 @@ func runMigrations(db *sql.DB) error {
 		provider_id TEXT NOT NULL REFERENCES ai_providers(id) ON DELETE CASCADE,
 		model_id TEXT NOT NULL,
 		model_name TEXT NOT NULL,
+		capabilities TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(provider_id, model_id)
 	);
@@ -118,7 +146,16 @@ func runMigrations(db *sql.DB) error {
 		url_title TEXT,
 		url_content TEXT,
 		is_archived INTEGER DEFAULT 0,
+		is_pinned INTEGER DEFAULT 0,
+		ai_processing_failed INTEGER DEFAULT 0,
+		content_language TEXT,
 		position TEXT DEFAULT '1000',
+		last_scraped_at DATETIME,
+		generated_title TEXT,
+		scrape_mode TEXT NOT NULL DEFAULT 'full',
+		thumbnail_url TEXT,
+		summary_source TEXT,
+		last_indexed_at DATETIME,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -150,6 +187,7 @@ func runMigrations(db *sql.DB) error {
 	CREATE TABLE IF NOT EXISTS chat_threads (
 		id TEXT PRIMARY KEY,
 		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		title TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -162,9 +200,227 @@ func runMigrations(db *sql.DB) error {
 		content TEXT NOT NULL,
 		mode TEXT,
 		sources TEXT,
+		is_partial INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Prompt templates table (per-user overrides for built-in AI prompts)
+	CREATE TABLE IF NOT EXISTS prompt_templates (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		template_name TEXT NOT NULL CHECK(template_name IN ('todo_processing', 'memory_categorization', 'weekly_digest')),
+		template_body TEXT NOT NULL,
+		is_active INTEGER DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Audit log for sensitive operations (retained for 90 days)
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		action TEXT NOT NULL,
+		metadata TEXT DEFAULT '{}',
+		ip_address TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- IP allowlist (networks each user's account may be accessed from)
+	CREATE TABLE IF NOT EXISTS ip_allowlist (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		cidr TEXT NOT NULL,
+		label TEXT DEFAULT '',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- Keywords and regular expressions each user's memory content must not match
+	CREATE TABLE IF NOT EXISTS keyword_blocklist (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		pattern TEXT NOT NULL,
+		is_regex BOOLEAN NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Attachments (files in object storage linked to a memory)
+	CREATE TABLE IF NOT EXISTS attachments (
+		id TEXT PRIMARY KEY,
+		memory_id TEXT NOT NULL REFERENCES memories(id) ON DELETE CASCADE,
+		storage_key TEXT NOT NULL,
+		mime_type TEXT NOT NULL,
+		size_bytes INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Todo templates (reusable task sets; system templates have no user_id)
+	CREATE TABLE IF NOT EXISTS todo_templates (
+		id TEXT PRIMARY KEY,
+		user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		description TEXT DEFAULT '',
+		tasks TEXT NOT NULL DEFAULT '[]',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- RSS feeds imported as memories in the background
+	CREATE TABLE IF NOT EXISTS saved_rss_feeds (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		url TEXT NOT NULL,
+		max_items INTEGER NOT NULL DEFAULT 10,
+		last_fetched_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(user_id, url)
+	);
+
+	-- Instance-wide settings managed through the admin API
+	CREATE TABLE IF NOT EXISTS system_settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Links between semantically related memories; each pair is stored once with memory_id_a < memory_id_b
+	CREATE TABLE IF NOT EXISTS memory_links (
+		memory_id_a TEXT NOT NULL REFERENCES memories(id) ON DELETE CASCADE,
+		memory_id_b TEXT NOT NULL REFERENCES memories(id) ON DELETE CASCADE,
+		similarity REAL NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (memory_id_a, memory_id_b)
+	);
+
+	-- Signed-in devices, keyed by the Supabase session ID; revoked sessions are kept so their tokens stay rejected
+	CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		device_fingerprint TEXT NOT NULL DEFAULT '',
+		ip_address TEXT NOT NULL DEFAULT '',
+		user_agent TEXT NOT NULL DEFAULT '',
+		last_active DATETIME DEFAULT CURRENT_TIMESTAMP,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		refresh_token_hash TEXT,
+		revoked_at DATETIME
+	);
+
+	-- Public read-only links to single memories
+	CREATE TABLE IF NOT EXISTS share_tokens (
+		token TEXT PRIMARY KEY,
+		memory_id TEXT NOT NULL REFERENCES memories(id) ON DELETE CASCADE,
+		expires_at DATETIME NOT NULL,
+		view_count INTEGER NOT NULL DEFAULT 0,
+		max_views INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Earlier versions of a memory's content, snapshotted before each edit to it
+	CREATE TABLE IF NOT EXISTS memory_revisions (
+		id TEXT PRIMARY KEY,
+		memory_id TEXT NOT NULL REFERENCES memories(id) ON DELETE CASCADE,
+		content TEXT NOT NULL,
+		summary TEXT,
+		changed_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		changed_by TEXT NOT NULL DEFAULT ''
+	);
+
+	-- Todos that must be completed before another todo can start (blocker blocks blocked)
+	CREATE TABLE IF NOT EXISTS todo_dependencies (
+		blocker_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+		blocked_id TEXT NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (blocker_id, blocked_id)
+	);
+
+	-- Searches each user has run, newest kept
+	CREATE TABLE IF NOT EXISTS search_history (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		query TEXT NOT NULL,
+		content_types TEXT NOT NULL DEFAULT '[]',
+		result_count INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Named searches users can run again
+	CREATE TABLE IF NOT EXISTS saved_searches (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		query TEXT NOT NULL,
+		content_types TEXT NOT NULL DEFAULT '[]',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Todos and memories whose background RAG indexing failed, retried with backoff
+	-- until they succeed or are marked dead
+	CREATE TABLE IF NOT EXISTS rag_index_queue (
+		id TEXT PRIMARY KEY,
+		content_type TEXT NOT NULL,
+		content_id TEXT NOT NULL,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		attempt_count INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at DATETIME NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending' CHECK(status IN ('pending', 'dead')),
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (content_type, content_id)
+	);
+
+	-- Per-user settings stored as key/value pairs, e.g. ai_process_todos = 'false'
+	CREATE TABLE IF NOT EXISTS user_preferences (
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		preference_key TEXT NOT NULL,
+		preference_value TEXT NOT NULL,
+		PRIMARY KEY (user_id, preference_key)
+	);
+
+	-- Categories users changed memories to, by a hash of the start of the memory's
+	-- content, so future memories like it can skip AI categorization
+	CREATE TABLE IF NOT EXISTS category_corrections (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		content_hash TEXT NOT NULL,
+		original_category TEXT NOT NULL,
+		corrected_category TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Todos each user completed per day, in their timezone, for completion streaks
+	CREATE TABLE IF NOT EXISTS todo_completions (
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		completed_date DATE NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (user_id, completed_date)
+	);
+
+	-- Schedules and last run status of background jobs, editable through the admin API
+	CREATE TABLE IF NOT EXISTS background_jobs (
+		id TEXT PRIMARY KEY,
+		job_name TEXT UNIQUE NOT NULL,
+		cron_expression TEXT NOT NULL,
+		is_enabled BOOLEAN NOT NULL DEFAULT 1,
+		last_run_at DATETIME,
+		last_duration_ms INTEGER,
+		last_error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- External services that may send webhooks to /api/webhooks/inbound/:name, signed
+	-- with the secret encrypted in secret_encrypted; rules (JSON) say which payloads
+	-- become todos or memories of user_id
+	CREATE TABLE IF NOT EXISTS inbound_webhook_sources (
+		id TEXT PRIMARY KEY,
+		name TEXT UNIQUE NOT NULL,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		secret_encrypted TEXT NOT NULL,
+		rules TEXT NOT NULL DEFAULT '[]',
+		is_enabled BOOLEAN NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
 	-- Indexes
 	CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
 	-- Note: idx_users_supabase_id is created in runDataMigrations after ensuring column exists
@@ -172,8 +428,12 @@ func runMigrations(db *sql.DB) error {
 	CREATE INDEX IF NOT EXISTS idx_todos_group_id ON todos(group_id);
 	CREATE INDEX IF NOT EXISTS idx_todos_status ON todos(status);
 	CREATE INDEX IF NOT EXISTS idx_todos_position ON todos(position);
+	CREATE INDEX IF NOT EXISTS idx_todo_dependencies_blocked_id ON todo_dependencies(blocked_id);
+	CREATE INDEX IF NOT EXISTS idx_search_history_user_created ON search_history(user_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_saved_searches_user_id ON saved_searches(user_id);
 	CREATE INDEX IF NOT EXISTS idx_groups_user_id ON groups(user_id);
 	CREATE INDEX IF NOT EXISTS idx_groups_is_default ON groups(is_default);
+	-- Note: idx_groups_parent_id is created in runDataMigrations after ensuring column exists
 	CREATE INDEX IF NOT EXISTS idx_ai_providers_user_id ON ai_providers(user_id);
 	CREATE INDEX IF NOT EXISTS idx_ai_providers_is_default ON ai_providers(is_default);
 	CREATE INDEX IF NOT EXISTS idx_ai_provider_models_provider_id ON ai_provider_models(provider_id);
@@ -187,6 +447,21 @@ func runMigrations(db *sql.DB) error {
 	CREATE INDEX IF NOT EXISTS idx_chat_threads_user_id ON chat_threads(user_id);
 	CREATE INDEX IF NOT EXISTS idx_chat_messages_thread_id ON chat_messages(thread_id);
 	CREATE INDEX IF NOT EXISTS idx_chat_messages_created_at ON chat_messages(created_at);
+	CREATE INDEX IF NOT EXISTS idx_prompt_templates_user_name ON prompt_templates(user_id, template_name);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_user_created ON audit_log(user_id, created_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at);
+	CREATE INDEX IF NOT EXISTS idx_ip_allowlist_user_id ON ip_allowlist(user_id);
+	CREATE INDEX IF NOT EXISTS idx_keyword_blocklist_user_id ON keyword_blocklist(user_id);
+	CREATE INDEX IF NOT EXISTS idx_attachments_memory_id ON attachments(memory_id);
+	CREATE INDEX IF NOT EXISTS idx_todo_templates_user_id ON todo_templates(user_id);
+	CREATE INDEX IF NOT EXISTS idx_memory_links_memory_id_b ON memory_links(memory_id_b);
+	CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+	CREATE INDEX IF NOT EXISTS idx_memory_revisions_memory_changed ON memory_revisions(memory_id, changed_at DESC);
+	CREATE INDEX IF NOT EXISTS idx_share_tokens_memory_id ON share_tokens(memory_id);
+	CREATE INDEX IF NOT EXISTS idx_share_tokens_expires_at ON share_tokens(expires_at);
+	CREATE INDEX IF NOT EXISTS idx_rag_index_queue_due ON rag_index_queue(status, next_attempt_at);
+	CREATE INDEX IF NOT EXISTS idx_rag_index_queue_user_id ON rag_index_queue(user_id);
+	CREATE INDEX IF NOT EXISTS idx_category_corrections_user_hash ON category_corrections(user_id, content_hash);
 	`
 
 	if _, err := db.Exec(schema); err != nil {
@@ -196,10 +471,10 @@ func runMigrations(db *sql.DB) error {
 	// Seed default groups if they don't exist
 	seedGroups := `
 	INSERT OR IGNORE INTO groups (id, name, color_code, is_default, user_id) VALUES
-		('default-work', 'Work', '#EF4444', 1, NULL),
-		('default-college', 'College', '#F59E0B', 1, NULL),
-		('default-personal', 'Personal', '#10B981', 1, NULL),
-		('default-travel', 'Travel', '#3B82F6', 1, NULL);
+		('default-work', 'Work', '#DC2626', 1, NULL),
+		('default-college', 'College', '#B45309', 1, NULL),
+		('default-personal', 'Personal', '#047857', 1, NULL),
+		('default-travel', 'Travel', '#2563EB', 1, NULL);
 	`
 
 	if _, err := db.Exec(seedGroups); err != nil {
@@ -226,6 +501,34 @@ func runMigrations(db *sql.DB) error {
 		return fmt.Errorf("failed to seed default memory categories: %w", err)
 	}
 
+	// Seed system todo templates if they don't exist
+	seedTodoTemplates := `
+	INSERT OR IGNORE INTO todo_templates (id, name, description, tasks, user_id) VALUES
+		('template-weekly-review', 'Weekly Review', 'Close out the week and plan the next one', '[
+			{"title": "Review completed tasks", "priority": "medium", "tags": ["review"], "relative_due_days": 0},
+			{"title": "Clear inbox and notes", "priority": "medium", "tags": ["review"], "relative_due_days": 0},
+			{"title": "Check upcoming calendar", "priority": "low", "tags": ["planning"], "relative_due_days": 0},
+			{"title": "Set top priorities for next week", "priority": "high", "tags": ["planning"], "relative_due_days": 1}
+		]', NULL),
+		('template-project-launch', 'Project Launch', 'Get a project from final checks to announcement', '[
+			{"title": "Finalize launch checklist", "priority": "high", "tags": ["launch"], "relative_due_days": 0},
+			{"title": "Run final QA pass", "priority": "high", "tags": ["launch", "qa"], "relative_due_days": 2},
+			{"title": "Prepare release notes", "priority": "medium", "tags": ["launch", "docs"], "relative_due_days": 3},
+			{"title": "Announce launch", "priority": "medium", "tags": ["launch"], "relative_due_days": 5},
+			{"title": "Collect launch feedback", "priority": "low", "tags": ["launch"], "relative_due_days": 12}
+		]', NULL),
+		('template-bug-fix', 'Bug Fix', 'Track a bug from report to verified fix', '[
+			{"title": "Reproduce the bug", "priority": "high", "tags": ["bug"], "relative_due_days": 0},
+			{"title": "Identify root cause", "priority": "high", "tags": ["bug"], "relative_due_days": 1},
+			{"title": "Write fix and tests", "priority": "high", "tags": ["bug"], "relative_due_days": 2},
+			{"title": "Verify fix and close report", "priority": "medium", "tags": ["bug"], "relative_due_days": 3}
+		]', NULL);
+	`
+
+	if _, err := db.Exec(seedTodoTemplates); err != nil {
+		return fmt.Errorf("failed to seed system todo templates: %w", err)
+	}
+
 	// Run data migrations (add missing columns to existing tables)
 	if err := runDataMigrations(db); err != nil {
 		return fmt.Errorf("failed to run data migrations: %w", err)
@@ -270,6 +573,383 @@ func runDataMigrations(db *sql.DB) error {
 		return fmt.Errorf("failed to create position index: %w", err)
 	}
 
+	// Check if memories.is_pinned column exists, add it if not
+	var isPinnedCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('memories') WHERE name = 'is_pinned'
+	`).Scan(&isPinnedCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for is_pinned column: %w", err)
+	}
+
+	if isPinnedCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE memories ADD COLUMN is_pinned INTEGER DEFAULT 0;
+		`); err != nil {
+			return fmt.Errorf("failed to add is_pinned column to memories: %w", err)
+		}
+	}
+
+	// Check if memories.ai_processing_failed column exists, add it if not
+	var aiProcessingFailedCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('memories') WHERE name = 'ai_processing_failed'
+	`).Scan(&aiProcessingFailedCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for ai_processing_failed column: %w", err)
+	}
+
+	if aiProcessingFailedCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE memories ADD COLUMN ai_processing_failed INTEGER DEFAULT 0;
+		`); err != nil {
+			return fmt.Errorf("failed to add ai_processing_failed column to memories: %w", err)
+		}
+	}
+
+	// Check if memories.content_language column exists, add it if not
+	var contentLanguageCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('memories') WHERE name = 'content_language'
+	`).Scan(&contentLanguageCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for content_language column: %w", err)
+	}
+
+	if contentLanguageCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE memories ADD COLUMN content_language TEXT;
+		`); err != nil {
+			return fmt.Errorf("failed to add content_language column to memories: %w", err)
+		}
+	}
+
+	// Check if memories.last_scraped_at column exists, add it if not
+	var lastScrapedCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('memories') WHERE name = 'last_scraped_at'
+	`).Scan(&lastScrapedCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for last_scraped_at column: %w", err)
+	}
+
+	if lastScrapedCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE memories ADD COLUMN last_scraped_at DATETIME;
+		`); err != nil {
+			return fmt.Errorf("failed to add last_scraped_at column to memories: %w", err)
+		}
+
+		// Existing URL content was scraped when the memory was created
+		if _, err := db.Exec(`
+			UPDATE memories SET last_scraped_at = created_at WHERE url IS NOT NULL AND url_content IS NOT NULL;
+		`); err != nil {
+			return fmt.Errorf("failed to backfill last_scraped_at for memories: %w", err)
+		}
+	}
+
+	// Check if memories.last_indexed_at and todos.last_indexed_at exist, add them if not
+	for _, table := range []string{"memories", "todos"} {
+		var lastIndexedCount int
+		err = db.QueryRow(`
+			SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = 'last_indexed_at'
+		`, table).Scan(&lastIndexedCount)
+		if err != nil {
+			return fmt.Errorf("failed to check for last_indexed_at column on %s: %w", table, err)
+		}
+
+		if lastIndexedCount == 0 {
+			if _, err := db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN last_indexed_at DATETIME;`); err != nil {
+				return fmt.Errorf("failed to add last_indexed_at column to %s: %w", table, err)
+			}
+		}
+	}
+
+	// Check if memories.generated_title column exists, add it if not
+	var generatedTitleCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('memories') WHERE name = 'generated_title'
+	`).Scan(&generatedTitleCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for generated_title column: %w", err)
+	}
+
+	if generatedTitleCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE memories ADD COLUMN generated_title TEXT;
+		`); err != nil {
+			return fmt.Errorf("failed to add generated_title column to memories: %w", err)
+		}
+	}
+
+	// Add memories.scrape_mode, memories.thumbnail_url and memories.summary_source if
+	// they don't exist
+	for column, definition := range map[string]string{
+		"scrape_mode":    "TEXT NOT NULL DEFAULT 'full'",
+		"thumbnail_url":  "TEXT",
+		"summary_source": "TEXT",
+	} {
+		var count int
+		err = db.QueryRow(`
+			SELECT COUNT(*) FROM pragma_table_info('memories') WHERE name = ?
+		`, column).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to check for %s column: %w", column, err)
+		}
+		if count == 0 {
+			if _, err := db.Exec(`ALTER TABLE memories ADD COLUMN ` + column + ` ` + definition + `;`); err != nil {
+				return fmt.Errorf("failed to add %s column to memories: %w", column, err)
+			}
+			// Summaries couldn't be edited before summary_source, so all came from the AI
+			if column == "summary_source" {
+				if _, err := db.Exec(`UPDATE memories SET summary_source = 'ai_categorize' WHERE summary IS NOT NULL`); err != nil {
+					return fmt.Errorf("failed to backfill summary_source: %w", err)
+				}
+			}
+		}
+	}
+
+	// Check if groups.is_archived column exists, add it if not
+	var groupArchivedCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('groups') WHERE name = 'is_archived'
+	`).Scan(&groupArchivedCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for groups is_archived column: %w", err)
+	}
+
+	if groupArchivedCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE groups ADD COLUMN is_archived INTEGER DEFAULT 0;
+		`); err != nil {
+			return fmt.Errorf("failed to add is_archived column to groups: %w", err)
+		}
+	}
+
+	// Check if groups.color_dark column exists, add it if not
+	var colorDarkCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('groups') WHERE name = 'color_dark'
+	`).Scan(&colorDarkCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for color_dark column: %w", err)
+	}
+
+	if colorDarkCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE groups ADD COLUMN color_dark TEXT;
+		`); err != nil {
+			return fmt.Errorf("failed to add color_dark column to groups: %w", err)
+		}
+	}
+
+	// Default groups use shades that meet WCAG AA contrast on the light and dark page
+	// backgrounds; move them off the original colors, which didn't
+	for _, group := range []struct{ id, oldColor, color, dark string }{
+		{"default-work", "#EF4444", "#DC2626", "#F87171"},
+		{"default-college", "#F59E0B", "#B45309", "#FBBF24"},
+		{"default-personal", "#10B981", "#047857", "#34D399"},
+		{"default-travel", "#3B82F6", "#2563EB", "#60A5FA"},
+	} {
+		if _, err := db.Exec(`
+			UPDATE groups
+			SET color_code = ?, color_dark = COALESCE(color_dark, ?)
+			WHERE id = ? AND is_default = 1 AND color_code IN (?, ?)
+		`, group.color, group.dark, group.id, group.oldColor, group.color); err != nil {
+			return fmt.Errorf("failed to update colors of default group %s: %w", group.id, err)
+		}
+	}
+
+	// Check if todos.story_points column exists, add it (and estimated_duration) if not
+	var storyPointsCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('todos') WHERE name = 'story_points'
+	`).Scan(&storyPointsCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for story_points column: %w", err)
+	}
+
+	if storyPointsCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE todos ADD COLUMN story_points INTEGER;
+			ALTER TABLE todos ADD COLUMN estimated_duration TEXT;
+		`); err != nil {
+			return fmt.Errorf("failed to add effort estimate columns to todos: %w", err)
+		}
+	}
+
+	// Check if todos.original_priority column exists, add it (and priority_locked_until) if not
+	var originalPriorityCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('todos') WHERE name = 'original_priority'
+	`).Scan(&originalPriorityCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for original_priority column: %w", err)
+	}
+
+	if originalPriorityCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE todos ADD COLUMN original_priority TEXT;
+			ALTER TABLE todos ADD COLUMN priority_locked_until DATETIME;
+		`); err != nil {
+			return fmt.Errorf("failed to add auto-priority columns to todos: %w", err)
+		}
+	}
+
+	// Check if groups.parent_id column exists, add it if not
+	var groupParentCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('groups') WHERE name = 'parent_id'
+	`).Scan(&groupParentCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for groups parent_id column: %w", err)
+	}
+
+	if groupParentCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE groups ADD COLUMN parent_id TEXT REFERENCES groups(id) ON DELETE SET NULL;
+		`); err != nil {
+			return fmt.Errorf("failed to add parent_id column to groups: %w", err)
+		}
+	}
+
+	// Always ensure the index exists (for both new and migrated databases)
+	if _, err := db.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_groups_parent_id ON groups(parent_id);
+	`); err != nil {
+		return fmt.Errorf("failed to create groups parent_id index: %w", err)
+	}
+
+	// Check if ai_providers.requests_per_minute column exists, add it if not
+	var rpmCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('ai_providers') WHERE name = 'requests_per_minute'
+	`).Scan(&rpmCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for requests_per_minute column: %w", err)
+	}
+
+	if rpmCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE ai_providers ADD COLUMN requests_per_minute INTEGER DEFAULT 60;
+		`); err != nil {
+			return fmt.Errorf("failed to add requests_per_minute column to ai_providers: %w", err)
+		}
+	}
+
+	// Check if ai_providers.metadata column exists, add it if not
+	var providerMetadataCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('ai_providers') WHERE name = 'metadata'
+	`).Scan(&providerMetadataCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for ai_providers metadata column: %w", err)
+	}
+
+	if providerMetadataCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE ai_providers ADD COLUMN metadata TEXT;
+		`); err != nil {
+			return fmt.Errorf("failed to add metadata column to ai_providers: %w", err)
+		}
+	}
+
+	// Rebuild ai_providers if its provider_type CHECK predates the newest ('ollama') type.
+	// SQLite can't alter a CHECK constraint, so the table is copied into a new one.
+	var providersSQL string
+	err = db.QueryRow(`
+		SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'ai_providers'
+	`).Scan(&providersSQL)
+	if err != nil {
+		return fmt.Errorf("failed to read ai_providers schema: %w", err)
+	}
+
+	if !strings.Contains(providersSQL, "'ollama'") {
+		if err := rebuildAIProvidersTable(db); err != nil {
+			return err
+		}
+	}
+
+	// Check if the newer ai_providers columns exist, add them if not (after the
+	// ai_providers rebuild above, which only carries over the columns it finds)
+	for _, column := range []struct{ name, def string }{
+		{"embedding_model", "TEXT"},
+		{"embedding_dimension", "INTEGER"},
+		{"timeout_seconds", "INTEGER DEFAULT 30"},
+		{"no_auth", "INTEGER DEFAULT 0"},
+		{"supports_structured_output", "INTEGER DEFAULT 0"},
+		{"default_temperature", "REAL DEFAULT 0.3"},
+		{"default_max_tokens", "INTEGER DEFAULT 500"},
+		{"fallback_provider_id", "TEXT REFERENCES ai_providers(id) ON DELETE SET NULL"},
+		{"system_prompt", "TEXT NOT NULL DEFAULT ''"},
+	} {
+		var columnCount int
+		err = db.QueryRow(`
+			SELECT COUNT(*) FROM pragma_table_info('ai_providers') WHERE name = ?
+		`, column.name).Scan(&columnCount)
+		if err != nil {
+			return fmt.Errorf("failed to check for ai_providers %s column: %w", column.name, err)
+		}
+
+		if columnCount == 0 {
+			if _, err := db.Exec("ALTER TABLE ai_providers ADD COLUMN " + column.name + " " + column.def); err != nil {
+				return fmt.Errorf("failed to add %s column to ai_providers: %w", column.name, err)
+			}
+		}
+	}
+
+	// Check if chat_threads.title column exists, add it if not
+	var threadTitleCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('chat_threads') WHERE name = 'title'
+	`).Scan(&threadTitleCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for chat_threads title column: %w", err)
+	}
+
+	if threadTitleCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE chat_threads ADD COLUMN title TEXT;
+		`); err != nil {
+			return fmt.Errorf("failed to add title column to chat_threads: %w", err)
+		}
+	}
+
+	// Check if chat_messages.is_partial column exists, add it if not
+	var messagePartialCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('chat_messages') WHERE name = 'is_partial'
+	`).Scan(&messagePartialCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for chat_messages is_partial column: %w", err)
+	}
+
+	if messagePartialCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE chat_messages ADD COLUMN is_partial INTEGER DEFAULT 0;
+		`); err != nil {
+			return fmt.Errorf("failed to add is_partial column to chat_messages: %w", err)
+		}
+	}
+
+	// Check if ai_provider_models.capabilities column exists, add it if not. Models cached
+	// before it existed keep NULL and have their capabilities detected when read.
+	var modelCapabilitiesCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('ai_provider_models') WHERE name = 'capabilities'
+	`).Scan(&modelCapabilitiesCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for ai_provider_models capabilities column: %w", err)
+	}
+
+	if modelCapabilitiesCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE ai_provider_models ADD COLUMN capabilities TEXT;
+		`); err != nil {
+			return fmt.Errorf("failed to add capabilities column to ai_provider_models: %w", err)
+		}
+	}
+
 	// Check if users.supabase_id column exists, add it if not
 	var supabaseIDCount int
 	err = db.QueryRow(`
@@ -417,5 +1097,255 @@ func runDataMigrations(db *sql.DB) error {
 		log.Println("Successfully migrated users table with password_hash nullable")
 	}
 
+	// Check if users.timezone column exists, add it if not
+	// (after the users table rebuild above, which doesn't carry it over)
+	var timezoneCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'timezone'
+	`).Scan(&timezoneCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for timezone column: %w", err)
+	}
+
+	if timezoneCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE users ADD COLUMN timezone TEXT DEFAULT 'UTC';
+		`); err != nil {
+			return fmt.Errorf("failed to add timezone column to users: %w", err)
+		}
+	}
+
+	// Check if the weekly digest email columns exist, add them if not
+	for _, column := range []struct{ name, def string }{
+		{"email_digest_enabled", "INTEGER DEFAULT 0"},
+		{"last_digest_sent_at", "DATETIME"},
+	} {
+		var columnCount int
+		err = db.QueryRow(`
+			SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = ?
+		`, column.name).Scan(&columnCount)
+		if err != nil {
+			return fmt.Errorf("failed to check for users %s column: %w", column.name, err)
+		}
+
+		if columnCount == 0 {
+			if _, err := db.Exec("ALTER TABLE users ADD COLUMN " + column.name + " " + column.def); err != nil {
+				return fmt.Errorf("failed to add %s column to users: %w", column.name, err)
+			}
+		}
+	}
+
+	// Check if users.sort_mode column exists, add it if not
+	var sortModeCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'sort_mode'
+	`).Scan(&sortModeCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for sort_mode column: %w", err)
+	}
+
+	if sortModeCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE users ADD COLUMN sort_mode TEXT DEFAULT 'manual';
+		`); err != nil {
+			return fmt.Errorf("failed to add sort_mode column to users: %w", err)
+		}
+	}
+
+	// Check if users.daily_goal column exists, add it if not
+	var dailyGoalCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'daily_goal'
+	`).Scan(&dailyGoalCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for daily_goal column: %w", err)
+	}
+
+	if dailyGoalCount == 0 {
+		if _, err := db.Exec(`
+			ALTER TABLE users ADD COLUMN daily_goal INTEGER DEFAULT 3;
+		`); err != nil {
+			return fmt.Errorf("failed to add daily_goal column to users: %w", err)
+		}
+	}
+
+	// Check if users.oidc_sub column exists, add it if not
+	var oidcSubCount int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = 'oidc_sub'
+	`).Scan(&oidcSubCount)
+	if err != nil {
+		return fmt.Errorf("failed to check for oidc_sub column: %w", err)
+	}
+
+	if oidcSubCount == 0 {
+		// SQLite can't add a UNIQUE column, so uniqueness comes from the index
+		if _, err := db.Exec(`
+			ALTER TABLE users ADD COLUMN oidc_sub TEXT;
+		`); err != nil {
+			return fmt.Errorf("failed to add oidc_sub column to users: %w", err)
+		}
+
+		if _, err := db.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc_sub ON users(oidc_sub) WHERE oidc_sub IS NOT NULL;
+		`); err != nil {
+			return fmt.Errorf("failed to create oidc_sub index: %w", err)
+		}
+	}
+
+	// Give group-less todos with a blank or non-numeric position one after the user's
+	// other group-less todos, in creation order, so they sort predictably. Idempotent:
+	// once backfilled every position is numeric.
+	if _, err := db.Exec(`
+		WITH ranked AS (
+			SELECT id, user_id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at ASC, id ASC) AS rn
+			FROM todos
+			WHERE group_id IS NULL AND (position IS NULL OR position = '' OR position GLOB '*[^0-9]*')
+		), last_positions AS (
+			SELECT user_id, MAX(CAST(position AS INTEGER)) AS max_position
+			FROM todos
+			WHERE group_id IS NULL AND position <> '' AND position NOT GLOB '*[^0-9]*'
+			GROUP BY user_id
+		)
+		UPDATE todos
+		SET position = (
+			SELECT CAST(COALESCE(last_positions.max_position, 0) + ranked.rn * 1000 AS TEXT)
+			FROM ranked LEFT JOIN last_positions ON last_positions.user_id = ranked.user_id
+			WHERE ranked.id = todos.id
+		)
+		WHERE id IN (SELECT id FROM ranked)
+	`); err != nil {
+		return fmt.Errorf("failed to backfill positions for ungrouped todos: %w", err)
+	}
+
+	// Cursor pagination orders todos and memories by SortableTimestamp of created_at,
+	// which replaced ordering by the created_at text
+	for _, table := range []string{"todos", "memories"} {
+		if _, err := db.Exec(fmt.Sprintf(`
+			DROP INDEX IF EXISTS idx_%[1]s_user_created;
+			CREATE INDEX IF NOT EXISTS idx_%[1]s_user_created_at ON %[1]s(user_id, %[2]s, id);
+		`, table, SortableTimestamp("created_at"))); err != nil {
+			return fmt.Errorf("failed to create creation time index on %s: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// SortableTimestamp returns SQL for a timestamp column as a Julian day number, which
+// orders by instant unlike the stored text. Times written by the driver are stored as
+// time.Time's String(), such as "2025-06-01 09:30:00.5 -0700 MST m=+1.5", in the
+// server's zone of the moment and with a varying number of fractional digits, so that
+// form is rewritten as "2025-06-01 09:30:00.5-07:00" for julianday. Forms julianday
+// parses as they are, like CURRENT_TIMESTAMP's UTC one, are used directly.
+func SortableTimestamp(column string) string {
+	return fmt.Sprintf(`COALESCE(julianday(%[1]s), julianday(`+
+		`substr(%[1]s, 1, 19) || `+
+		`substr(substr(%[1]s, 20), 1, instr(substr(%[1]s, 20), ' ') - 1) || `+
+		`substr(substr(%[1]s, 20), instr(substr(%[1]s, 20), ' ') + 1, 3) || ':' || `+
+		`substr(substr(%[1]s, 20), instr(substr(%[1]s, 20), ' ') + 4, 2)))`, column)
+}
+
+// SortableTimestampParam formats t for julianday(?) to compare with SortableTimestamp
+func SortableTimestampParam(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05.999999999Z07:00")
+}
+
+// rebuildAIProvidersTable recreates ai_providers with the current provider_type
+// CHECK constraint, copying over whichever columns the old table has. Foreign keys
+// are disabled on a dedicated connection while the old table is dropped so cached
+// ai_provider_models rows aren't cascade-deleted.
+func rebuildAIProvidersTable(db *sql.DB) error {
+	log.Println("Migrating ai_providers table to allow new provider types...")
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection for ai_providers migration: %w", err)
+	}
+	defer conn.Close()
+
+	// foreign_keys is a no-op inside a transaction, so toggle it before starting one
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		CREATE TABLE ai_providers_new (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			provider_type TEXT NOT NULL CHECK(provider_type IN ('openai', 'anthropic', 'google', 'custom', 'assistant', 'ollama')),
+			base_url TEXT NOT NULL,
+			api_key_encrypted TEXT NOT NULL,
+			selected_model TEXT,
+			is_default INTEGER DEFAULT 0,
+			is_enabled INTEGER DEFAULT 1,
+			requests_per_minute INTEGER DEFAULT 60,
+			timeout_seconds INTEGER DEFAULT 30,
+			no_auth INTEGER DEFAULT 0,
+			supports_structured_output INTEGER DEFAULT 0,
+			default_temperature REAL DEFAULT 0.3,
+			default_max_tokens INTEGER DEFAULT 500,
+			metadata TEXT,
+			embedding_model TEXT,
+			embedding_dimension INTEGER,
+			fallback_provider_id TEXT REFERENCES ai_providers(id) ON DELETE SET NULL,
+			system_prompt TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create new ai_providers table: %w", err)
+	}
+
+	// Older tables may predate some columns; those take their defaults
+	rows, err := tx.Query("SELECT name FROM pragma_table_info('ai_providers')")
+	if err != nil {
+		return fmt.Errorf("failed to read ai_providers columns: %w", err)
+	}
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read ai_providers columns: %w", err)
+		}
+		columns = append(columns, name)
+	}
+	rows.Close()
+
+	columnList := strings.Join(columns, ", ")
+	if _, err := tx.Exec(
+		"INSERT INTO ai_providers_new (" + columnList + ") SELECT " + columnList + " FROM ai_providers",
+	); err != nil {
+		return fmt.Errorf("failed to copy data to new ai_providers table: %w", err)
+	}
+
+	if _, err := tx.Exec("DROP TABLE ai_providers"); err != nil {
+		return fmt.Errorf("failed to drop old ai_providers table: %w", err)
+	}
+	if _, err := tx.Exec("ALTER TABLE ai_providers_new RENAME TO ai_providers"); err != nil {
+		return fmt.Errorf("failed to rename ai_providers_new table: %w", err)
+	}
+	if _, err := tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_ai_providers_user_id ON ai_providers(user_id);
+		CREATE INDEX IF NOT EXISTS idx_ai_providers_is_default ON ai_providers(is_default);
+	`); err != nil {
+		return fmt.Errorf("failed to recreate ai_providers indexes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit ai_providers migration: %w", err)
+	}
+
+	log.Println("Successfully migrated ai_providers table")
 	return nil
 }
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Config tunes SQLite for the workload. Unlike journal_mode, which is stored in the
+// database file, these PRAGMAs only last for the connection that runs them, so they
+// are passed to the driver to apply on every connection in the pool.
+type Config struct {
+	// CacheSizeKB is each connection's page cache. A larger cache saves disk reads on
+	// big databases, at the cost of that much memory per open connection.
+	CacheSizeKB int
+	// Synchronous is OFF, NORMAL, FULL or EXTRA. In WAL mode NORMAL can't corrupt the
+	// database but may lose the last commits on power loss or an OS crash; FULL keeps
+	// them at the cost of an fsync per commit, and OFF risks corruption.
+	Synchronous string
+	// BusyTimeoutMS is how long a connection waits for a lock before failing with
+	// "database is locked". Longer waits ride out write bursts but hold requests open.
+	BusyTimeoutMS int
+	// WALAutocheckpoint is the WAL size in pages (4KB each by default) that triggers a
+	// checkpoint. Higher values batch checkpoint I/O under heavy writes but let the
+	// WAL file grow, which slows reads and recovery.
+	WALAutocheckpoint int
+}
+
+// SynchronousModes are the accepted values of Config.Synchronous, in the order of the
+// numbers PRAGMA synchronous reports them as
+var SynchronousModes = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+
+// DefaultConfig returns the settings used when nothing is configured
+func DefaultConfig() Config {
+	return Config{
+		CacheSizeKB:       32768,
+		Synchronous:       "NORMAL",
+		BusyTimeoutMS:     5000,
+		WALAutocheckpoint: 1000,
+	}
+}
+
+// dsn returns dbPath with the config's PRAGMAs and foreign_keys as _pragma parameters,
+// which the driver runs on each new connection (busy_timeout first)
+func (c Config) dsn(dbPath string) (string, error) {
+	synchronous := strings.ToUpper(c.Synchronous)
+	if !slices.Contains(SynchronousModes, synchronous) {
+		return "", fmt.Errorf("invalid synchronous mode %q (want one of %v)", c.Synchronous, SynchronousModes)
+	}
+	if c.CacheSizeKB <= 0 || c.BusyTimeoutMS < 0 || c.WALAutocheckpoint < 0 {
+		return "", fmt.Errorf("invalid database config: cache size must be positive, busy timeout and WAL autocheckpoint not negative")
+	}
+
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", c.BusyTimeoutMS))
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", fmt.Sprintf("cache_size(-%d)", c.CacheSizeKB)) // negative sizes are in KiB rather than pages
+	params.Add("_pragma", "synchronous("+synchronous+")")
+	params.Add("_pragma", fmt.Sprintf("wal_autocheckpoint(%d)", c.WALAutocheckpoint))
+	return dbPath + "?" + params.Encode(), nil
+}
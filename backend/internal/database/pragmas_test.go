@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestConnectAppliesPragmasToEveryConnection(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.BusyTimeoutMS = 1234
+	db, err := Connect(filepath.Join(t.TempDir(), "test.db"), cfg)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer db.Close()
+
+	// Hold several connections at once so the pool has to open new ones
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Conn: %v", err)
+		}
+		defer conn.Close()
+
+		var foreignKeys, busyTimeout int
+		if err := conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+			t.Fatalf("PRAGMA foreign_keys: %v", err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&busyTimeout); err != nil {
+			t.Fatalf("PRAGMA busy_timeout: %v", err)
+		}
+		if foreignKeys != 1 {
+			t.Errorf("connection %d: foreign_keys = %d, want 1", i, foreignKeys)
+		}
+		if busyTimeout != 1234 {
+			t.Errorf("connection %d: busy_timeout = %d, want 1234", i, busyTimeout)
+		}
+	}
+}
+
+func TestConfigDSNRejectsInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{"unknown synchronous mode", func(c *Config) { c.Synchronous = "SOMETIMES" }},
+		{"zero cache size", func(c *Config) { c.CacheSizeKB = 0 }},
+		{"negative busy timeout", func(c *Config) { c.BusyTimeoutMS = -1 }},
+		{"negative WAL autocheckpoint", func(c *Config) { c.WALAutocheckpoint = -1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(&cfg)
+			if _, err := cfg.dsn("test.db"); err == nil {
+				t.Error("dsn succeeded, want an error")
+			}
+		})
+	}
+}
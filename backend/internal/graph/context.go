@@ -0,0 +1,60 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+var errUnauthorized = errors.New("unauthorized")
+
+type contextKey int
+
+const requestKey contextKey = iota
+
+// request is what resolvers need from the HTTP request, which they don't see
+type request struct {
+	userID   string
+	clientIP string
+	groups   *groupLoader
+}
+
+// WithUser returns ctx carrying the authenticated user of a GraphQL request, along
+// with a group loader of its own so todo groups are batched per request
+func (r *Resolver) WithUser(ctx context.Context, userID, clientIP string) context.Context {
+	return context.WithValue(ctx, requestKey, &request{
+		userID:   userID,
+		clientIP: clientIP,
+		groups:   newGroupLoader(r.groupService, userID),
+	})
+}
+
+func requestFromContext(ctx context.Context) (*request, error) {
+	req, ok := ctx.Value(requestKey).(*request)
+	if !ok || req.userID == "" {
+		return nil, errUnauthorized
+	}
+	return req, nil
+}
+
+// internalError logs err and returns a message that doesn't leak it, like the REST
+// handlers' "failed to ..." errors
+func internalError(action string, err error) error {
+	log.Printf("[GraphQL] Failed to %s: %v", action, err)
+	return fmt.Errorf("failed to %s", action)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func derefInt(n *int) int {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
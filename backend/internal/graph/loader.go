@@ -0,0 +1,100 @@
+package graph
+
+import (
+	"sync"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+const (
+	// groupLoaderWait is how long a batch collects group IDs before it is fetched.
+	// Field resolvers of a list run concurrently, so the groups of all todos in a
+	// response land in the same batch.
+	groupLoaderWait = 2 * time.Millisecond
+	// groupLoaderMaxBatch fetches a batch early once it holds this many IDs
+	groupLoaderMaxBatch = 100
+)
+
+// groupLoader batches the group lookups of a request DataLoader-style: loads made
+// within groupLoaderWait of each other are fetched with a single query, and every
+// group is fetched at most once per request
+type groupLoader struct {
+	groupService *services.GroupService
+	userID       string
+
+	mu    sync.Mutex
+	cache map[string]*groupResult
+	batch *groupBatch
+}
+
+type groupResult struct {
+	group *models.Group
+	err   error
+	done  chan struct{}
+}
+
+type groupBatch struct {
+	ids     []string
+	results map[string]*groupResult
+	fetched bool
+}
+
+func newGroupLoader(groupService *services.GroupService, userID string) *groupLoader {
+	return &groupLoader{
+		groupService: groupService,
+		userID:       userID,
+		cache:        make(map[string]*groupResult),
+	}
+}
+
+// Load returns the group with the ID, or nil if the user can't see it
+func (l *groupLoader) Load(id string) (*models.Group, error) {
+	l.mu.Lock()
+	result, ok := l.cache[id]
+	if !ok {
+		result = &groupResult{done: make(chan struct{})}
+		l.cache[id] = result
+
+		if l.batch == nil {
+			batch := &groupBatch{results: make(map[string]*groupResult)}
+			l.batch = batch
+			time.AfterFunc(groupLoaderWait, func() { l.fetch(batch) })
+		}
+		l.batch.ids = append(l.batch.ids, id)
+		l.batch.results[id] = result
+		if len(l.batch.ids) >= groupLoaderMaxBatch {
+			batch := l.batch
+			go l.fetch(batch)
+		}
+	}
+	l.mu.Unlock()
+
+	<-result.done
+	return result.group, result.err
+}
+
+// fetch runs a batch's query, once, and hands every waiting Load its group
+func (l *groupLoader) fetch(batch *groupBatch) {
+	l.mu.Lock()
+	if batch.fetched {
+		l.mu.Unlock()
+		return
+	}
+	batch.fetched = true
+	if l.batch == batch {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	groups, err := l.groupService.GetByIDs(l.userID, batch.ids)
+	for id, result := range batch.results {
+		if err != nil {
+			result.err = err
+		} else {
+			result.group = groups[id]
+		}
+		close(result.done)
+	}
+}
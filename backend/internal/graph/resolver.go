@@ -0,0 +1,25 @@
+package graph
+
+//go:generate go tool gqlgen generate
+
+import (
+	"github.com/todomyday/backend/internal/services"
+)
+
+// Resolver resolves the GraphQL schema with the same services as the REST handlers,
+// so the API has no logic of its own
+type Resolver struct {
+	todoService   *services.TodoService
+	memoryService *services.MemoryService
+	ragService    *services.RAGService
+	groupService  *services.GroupService
+}
+
+func NewResolver(todoService *services.TodoService, memoryService *services.MemoryService, ragService *services.RAGService, groupService *services.GroupService) *Resolver {
+	return &Resolver{
+		todoService:   todoService,
+		memoryService: memoryService,
+		ragService:    ragService,
+		groupService:  groupService,
+	}
+}
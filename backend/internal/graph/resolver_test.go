@@ -0,0 +1,269 @@
+package graph
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/todomyday/backend/internal/database"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// testAPI runs GraphQL requests against services over a fresh database
+type testAPI struct {
+	t        *testing.T
+	db       *sql.DB
+	resolver *Resolver
+	server   *handler.Server
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	db, err := database.Connect(filepath.Join(t.TempDir(), "test.db"), database.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	userRepo := repository.NewUserRepository(db)
+	todoRepo := repository.NewTodoRepository(db)
+	groupRepo := repository.NewGroupRepository(db)
+	memoryRepo := repository.NewMemoryRepository(db)
+	todoService := services.NewTodoService(todoRepo, groupRepo, userRepo, nil, nil, nil, nil, nil, services.NewUserPreferencesService(userRepo))
+	memoryService := services.NewMemoryService(memoryRepo, todoRepo, groupRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	resolver := NewResolver(todoService, memoryService, nil, services.NewGroupService(groupRepo, todoRepo))
+
+	server := handler.New(NewExecutableSchema(Config{Resolvers: resolver}))
+	server.AddTransport(transport.POST{})
+	return &testAPI{t: t, db: db, resolver: resolver, server: server}
+}
+
+// newUser creates a user with the given email
+func (a *testAPI) newUser(email string) *models.User {
+	a.t.Helper()
+	user := &models.User{Email: email}
+	if err := repository.NewUserRepository(a.db).Create(user); err != nil {
+		a.t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+// do sends query as userID, or unauthenticated when it's empty
+func (a *testAPI) do(userID, query string, variables map[string]interface{}) graphQLResponse {
+	a.t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		req = req.WithContext(a.resolver.WithUser(req.Context(), userID, "192.0.2.1"))
+	}
+	w := httptest.NewRecorder()
+	a.server.ServeHTTP(w, req)
+
+	var resp graphQLResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		a.t.Fatalf("invalid response %s: %v", w.Body.String(), err)
+	}
+	return resp
+}
+
+// errorMessage returns the message of the response's only error, or "" without one
+func (r graphQLResponse) errorMessage(t *testing.T) string {
+	t.Helper()
+	switch len(r.Errors) {
+	case 0:
+		return ""
+	case 1:
+		return r.Errors[0].Message
+	}
+	t.Fatalf("got %d errors, want at most one: %+v", len(r.Errors), r.Errors)
+	return ""
+}
+
+func TestQueryResolvers(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.newUser("graphql@example.com")
+	other := api.newUser("other@example.com")
+
+	group := &models.Group{UserID: &user.ID, Name: "Errands", ColorCode: "#4F46E5", ColorDark: "#818CF8"}
+	if err := repository.NewGroupRepository(api.db).Create(group); err != nil {
+		t.Fatal(err)
+	}
+	todoRepo := repository.NewTodoRepository(api.db)
+	for _, todo := range []*models.Todo{
+		{UserID: user.ID, Title: "Buy milk", Priority: models.PriorityLow, Status: models.StatusPending, GroupID: &group.ID, Position: "a"},
+		{UserID: user.ID, Title: "Post letter", Priority: models.PriorityHigh, Status: models.StatusCompleted, GroupID: &group.ID, Position: "b"},
+		{UserID: other.ID, Title: "Someone else's", Priority: models.PriorityLow, Status: models.StatusPending, Position: "a"},
+	} {
+		if err := todoRepo.Create(todo); err != nil {
+			t.Fatal(err)
+		}
+	}
+	memoryRepo := repository.NewMemoryRepository(api.db)
+	for _, memory := range []*models.Memory{
+		{UserID: user.ID, Content: "Pasta recipe with basil", Category: "Recipes"},
+		{UserID: other.ID, Content: "Other pasta notes", Category: "Recipes"},
+	} {
+		if err := memoryRepo.Create(memory); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("todos with their groups", func(t *testing.T) {
+		resp := api.do(user.ID, `{ todos { title group { name } } }`, nil)
+		if msg := resp.errorMessage(t); msg != "" {
+			t.Fatalf("error: %s", msg)
+		}
+		var todos []struct {
+			Title string
+			Group *struct{ Name string }
+		}
+		json.Unmarshal(resp.Data["todos"], &todos)
+		if len(todos) != 2 {
+			t.Fatalf("got %d todos, want the user's 2: %+v", len(todos), todos)
+		}
+		for _, todo := range todos {
+			if todo.Group == nil || todo.Group.Name != "Errands" {
+				t.Errorf("todo %q group = %+v, want Errands", todo.Title, todo.Group)
+			}
+		}
+	})
+
+	t.Run("todos filtered by status", func(t *testing.T) {
+		resp := api.do(user.ID, `{ todos(status: COMPLETED) { title status } }`, nil)
+		var todos []struct{ Title, Status string }
+		json.Unmarshal(resp.Data["todos"], &todos)
+		if len(todos) != 1 || todos[0].Title != "Post letter" || todos[0].Status != "COMPLETED" {
+			t.Errorf("todos = %+v, want only Post letter", todos)
+		}
+	})
+
+	t.Run("invalid todo filter", func(t *testing.T) {
+		resp := api.do(user.ID, `{ todos(sort: "owner") { title } }`, nil)
+		if msg := resp.errorMessage(t); msg == "" {
+			t.Error("sorting by an unknown field succeeded")
+		}
+	})
+
+	t.Run("memories", func(t *testing.T) {
+		resp := api.do(user.ID, `{ memories { content category } }`, nil)
+		var memories []struct{ Content, Category string }
+		json.Unmarshal(resp.Data["memories"], &memories)
+		if len(memories) != 1 || memories[0].Content != "Pasta recipe with basil" {
+			t.Errorf("memories = %+v, want only the user's", memories)
+		}
+	})
+
+	t.Run("ask without RAG", func(t *testing.T) {
+		resp := api.do(user.ID, `{ ask(question: "What do I cook?") { answer } }`, nil)
+		if msg := resp.errorMessage(t); msg != "RAG service not configured" {
+			t.Errorf("error = %q, want RAG service not configured", msg)
+		}
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		resp := api.do("", `{ todos { title } }`, nil)
+		if msg := resp.errorMessage(t); msg != errUnauthorized.Error() {
+			t.Errorf("error = %q, want %q", msg, errUnauthorized)
+		}
+	})
+}
+
+func TestMutationResolvers(t *testing.T) {
+	api := newTestAPI(t)
+	user := api.newUser("graphql@example.com")
+	other := api.newUser("other@example.com")
+
+	resp := api.do(user.ID, `mutation($title: String!) { createTodo(input: {title: $title, priority: HIGH}) { id title priority } }`,
+		map[string]interface{}{"title": "Water the plants"})
+	if msg := resp.errorMessage(t); msg != "" {
+		t.Fatalf("createTodo: %s", msg)
+	}
+	var created struct{ ID, Title, Priority string }
+	json.Unmarshal(resp.Data["createTodo"], &created)
+	if created.ID == "" || created.Title != "Water the plants" || created.Priority != "HIGH" {
+		t.Fatalf("createTodo = %+v", created)
+	}
+
+	t.Run("createTodo without a title", func(t *testing.T) {
+		resp := api.do(user.ID, `mutation { createTodo(input: {title: "  "}) { id } }`, nil)
+		if msg := resp.errorMessage(t); msg != "title is required" {
+			t.Errorf("error = %q, want title is required", msg)
+		}
+	})
+
+	t.Run("updateTodo", func(t *testing.T) {
+		resp := api.do(user.ID, `mutation($id: ID!) { updateTodo(id: $id, input: {status: COMPLETED}) { status } }`,
+			map[string]interface{}{"id": created.ID})
+		var updated struct{ Status string }
+		json.Unmarshal(resp.Data["updateTodo"], &updated)
+		if msg := resp.errorMessage(t); msg != "" || updated.Status != "COMPLETED" {
+			t.Errorf("updateTodo = %+v, error %q", updated, msg)
+		}
+	})
+
+	t.Run("updateTodo of another user", func(t *testing.T) {
+		resp := api.do(other.ID, `mutation($id: ID!) { updateTodo(id: $id, input: {title: "Mine now"}) { id } }`,
+			map[string]interface{}{"id": created.ID})
+		if msg := resp.errorMessage(t); msg != services.ErrTodoNotFound.Error() {
+			t.Errorf("error = %q, want %q", msg, services.ErrTodoNotFound)
+		}
+	})
+
+	t.Run("deleteTodo of another user", func(t *testing.T) {
+		resp := api.do(other.ID, `mutation($id: ID!) { deleteTodo(id: $id) }`, map[string]interface{}{"id": created.ID})
+		if msg := resp.errorMessage(t); msg != services.ErrTodoNotFound.Error() {
+			t.Errorf("error = %q, want %q", msg, services.ErrTodoNotFound)
+		}
+	})
+
+	t.Run("deleteTodo", func(t *testing.T) {
+		resp := api.do(user.ID, `mutation($id: ID!) { deleteTodo(id: $id) }`, map[string]interface{}{"id": created.ID})
+		if msg := resp.errorMessage(t); msg != "" || string(resp.Data["deleteTodo"]) != "true" {
+			t.Errorf("deleteTodo = %s, error %q", resp.Data["deleteTodo"], msg)
+		}
+		todo, err := repository.NewTodoRepository(api.db).GetByID(created.ID)
+		if err != nil || todo != nil {
+			t.Errorf("todo after deleteTodo = %+v, %v", todo, err)
+		}
+	})
+
+	t.Run("deleteMemory", func(t *testing.T) {
+		memory := &models.Memory{UserID: user.ID, Content: "Gate code 1234", Category: "Notes"}
+		if err := repository.NewMemoryRepository(api.db).Create(memory); err != nil {
+			t.Fatal(err)
+		}
+		vars := map[string]interface{}{"id": memory.ID}
+
+		resp := api.do(other.ID, `mutation($id: ID!) { deleteMemory(id: $id) }`, vars)
+		if msg := resp.errorMessage(t); msg != services.ErrMemoryNotFound.Error() {
+			t.Errorf("other user's error = %q, want %q", msg, services.ErrMemoryNotFound)
+		}
+		resp = api.do(user.ID, `mutation($id: ID!) { deleteMemory(id: $id) }`, vars)
+		if msg := resp.errorMessage(t); msg != "" || string(resp.Data["deleteMemory"]) != "true" {
+			t.Errorf("deleteMemory = %s, error %q", resp.Data["deleteMemory"], msg)
+		}
+	})
+
+	t.Run("createMemory with an invalid scrape mode", func(t *testing.T) {
+		resp := api.do(user.ID, `mutation { createMemory(input: {content: "Notes", scrapeMode: "everything"}) { id } }`, nil)
+		if msg := resp.errorMessage(t); msg != "scrapeMode must be full, metadata_only or none" {
+			t.Errorf("error = %q", msg)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/todomyday/backend/internal/graph/model"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+// ContentType is the resolver for the contentType field.
+func (r *documentResolver) ContentType(ctx context.Context, obj *models.Document) (string, error) {
+	return string(obj.ContentType), nil
+}
+
+// CreateTodo is the resolver for the createTodo field.
+func (r *mutationResolver) CreateTodo(ctx context.Context, input model.CreateTodoInput) (*models.Todo, error) {
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(input.Title) == "" {
+		return nil, errors.New("title is required")
+	}
+
+	create := &models.TodoCreateRequest{
+		Title:       input.Title,
+		Description: input.Description,
+		DueDate:     input.DueDate,
+		GroupID:     input.GroupID,
+	}
+	if input.Priority != nil {
+		create.Priority = *input.Priority
+	}
+	if input.EstimateEffort != nil {
+		create.EstimateEffort = *input.EstimateEffort
+	}
+
+	todo, err := r.todoService.Create(req.userID, create)
+	if err != nil {
+		if errors.Is(err, services.ErrGroupNotLeaf) || errors.Is(err, services.ErrGroupNotFound) {
+			return nil, err
+		}
+		return nil, internalError("create todo", err)
+	}
+	return todo, nil
+}
+
+// UpdateTodo is the resolver for the updateTodo field.
+func (r *mutationResolver) UpdateTodo(ctx context.Context, id string, input model.UpdateTodoInput) (*models.Todo, error) {
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := r.todoService.Update(req.userID, id, &models.TodoUpdateRequest{
+		Title:               input.Title,
+		Description:         input.Description,
+		DueDate:             input.DueDate,
+		Priority:            input.Priority,
+		Status:              input.Status,
+		GroupID:             input.GroupID,
+		Position:            input.Position,
+		Tags:                input.Tags,
+		PriorityLockedUntil: input.PriorityLockedUntil,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrTodoNotFound) || errors.Is(err, services.ErrGroupNotLeaf) ||
+			errors.Is(err, services.ErrGroupNotFound) || errors.Is(err, services.ErrPriorityAutoManaged) {
+			return nil, err
+		}
+		return nil, internalError("update todo", err)
+	}
+	return todo, nil
+}
+
+// DeleteTodo is the resolver for the deleteTodo field.
+func (r *mutationResolver) DeleteTodo(ctx context.Context, id string) (bool, error) {
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.todoService.Delete(req.userID, id, req.clientIP); err != nil {
+		if errors.Is(err, services.ErrTodoNotFound) {
+			return false, err
+		}
+		return false, internalError("delete todo", err)
+	}
+	return true, nil
+}
+
+// CreateMemory is the resolver for the createMemory field.
+func (r *mutationResolver) CreateMemory(ctx context.Context, input model.CreateMemoryInput) (*models.Memory, error) {
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(input.Content) == "" {
+		return nil, errors.New("content is required")
+	}
+
+	create := &models.MemoryCreateRequest{Content: input.Content}
+	if input.ScrapeMode != nil {
+		switch *input.ScrapeMode {
+		case models.ScrapeModeFull, models.ScrapeModeMetadataOnly, models.ScrapeModeNone:
+			create.ScrapeMode = *input.ScrapeMode
+		default:
+			return nil, errors.New("scrapeMode must be full, metadata_only or none")
+		}
+	}
+
+	memory, err := r.memoryService.Create(req.userID, create)
+	if err != nil {
+		return nil, internalError("create memory", err)
+	}
+	return memory, nil
+}
+
+// DeleteMemory is the resolver for the deleteMemory field.
+func (r *mutationResolver) DeleteMemory(ctx context.Context, id string) (bool, error) {
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.memoryService.Delete(req.userID, id, req.clientIP); err != nil {
+		if errors.Is(err, services.ErrMemoryNotFound) {
+			return false, err
+		}
+		return false, internalError("delete memory", err)
+	}
+	return true, nil
+}
+
+// Todos is the resolver for the todos field.
+func (r *queryResolver) Todos(ctx context.Context, status *models.Status, priority *models.Priority, groupID *string, tags []string, tagOp *string, sort *string, order *string, includeArchivedGroups *bool) ([]models.Todo, error) {
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := &models.TodoFilterRequest{
+		Tags:                  tags,
+		TagOp:                 deref(tagOp),
+		GroupID:               deref(groupID),
+		Sort:                  deref(sort),
+		Order:                 deref(order),
+		IncludeArchivedGroups: includeArchivedGroups != nil && *includeArchivedGroups,
+	}
+	if status != nil {
+		filter.Status = *status
+	}
+	if priority != nil {
+		filter.Priority = *priority
+	}
+
+	todos, err := r.todoService.GetAll(req.userID, filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidTodoFilter) {
+			return nil, err
+		}
+		return nil, internalError("fetch todos", err)
+	}
+	return todos, nil
+}
+
+// Memories is the resolver for the memories field.
+func (r *queryResolver) Memories(ctx context.Context, limit *int, offset *int) ([]models.Memory, error) {
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	memories, err := r.memoryService.GetAll(req.userID, derefInt(limit), derefInt(offset))
+	if err != nil {
+		return nil, internalError("fetch memories", err)
+	}
+	return memories, nil
+}
+
+// SearchMemories is the resolver for the searchMemories field.
+func (r *queryResolver) SearchMemories(ctx context.Context, query string, category *string, dateFrom *string, dateTo *string, languages []string, limit *int, offset *int) ([]models.Memory, error) {
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	memories, err := r.memoryService.Search(req.userID, &models.MemorySearchRequest{
+		Query:          query,
+		Category:       category,
+		DateFrom:       dateFrom,
+		DateTo:         dateTo,
+		LanguageFilter: languages,
+		Limit:          derefInt(limit),
+		Offset:         derefInt(offset),
+	})
+	if err != nil {
+		return nil, internalError("search memories", err)
+	}
+	return memories, nil
+}
+
+// Ask is the resolver for the ask field.
+func (r *queryResolver) Ask(ctx context.Context, question string, mode *models.AskMode, contentTypes []string, maxContext *int) (*models.AskResponse, error) {
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.ragService == nil || !r.ragService.IsConfigured() {
+		return nil, errors.New("RAG service not configured")
+	}
+	if strings.TrimSpace(question) == "" {
+		return nil, errors.New("question is required")
+	}
+
+	ask := &models.AskRequest{
+		Question:     question,
+		ContentTypes: contentTypes,
+		MaxContext:   derefInt(maxContext),
+	}
+	if mode != nil {
+		ask.Mode = *mode
+	}
+
+	resp, err := r.ragService.Ask(ctx, req.userID, ask)
+	if err != nil {
+		return nil, internalError("answer question", err)
+	}
+	return resp, nil
+}
+
+// Group is the resolver for the group field.
+func (r *todoResolver) Group(ctx context.Context, obj *models.Todo) (*models.Group, error) {
+	if obj.GroupID == nil {
+		return nil, nil
+	}
+	req, err := requestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := req.groups.Load(*obj.GroupID)
+	if err != nil {
+		return nil, internalError("fetch group", err)
+	}
+	return group, nil
+}
+
+// Document returns DocumentResolver implementation.
+func (r *Resolver) Document() DocumentResolver { return &documentResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Todo returns TodoResolver implementation.
+func (r *Resolver) Todo() TodoResolver { return &todoResolver{r} }
+
+type documentResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type todoResolver struct{ *Resolver }
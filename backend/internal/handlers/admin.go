@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type AdminHandler struct {
+	aiProviderService     *services.AIProviderService
+	systemSettingsService *services.SystemSettingsService
+	searchService         *services.SearchService
+	backupService         *services.BackupService
+	jobScheduler          *services.JobScheduler
+	impersonationService  *services.ImpersonationService
+	cors                  *middleware.DynamicCORS
+}
+
+func NewAdminHandler(aiProviderService *services.AIProviderService, systemSettingsService *services.SystemSettingsService, searchService *services.SearchService, backupService *services.BackupService, jobScheduler *services.JobScheduler, impersonationService *services.ImpersonationService, cors *middleware.DynamicCORS) *AdminHandler {
+	return &AdminHandler{
+		aiProviderService:     aiProviderService,
+		systemSettingsService: systemSettingsService,
+		searchService:         searchService,
+		backupService:         backupService,
+		jobScheduler:          jobScheduler,
+		impersonationService:  impersonationService,
+		cors:                  cors,
+	}
+}
+
+// RotateEncryptionKey re-encrypts all stored AI provider API keys and inbound
+// webhook secrets with a new key
+func (h *AdminHandler) RotateEncryptionKey(c *gin.Context) {
+	var req models.KeyRotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	rotated, failures, err := h.aiProviderService.RotateEncryptionKey(req.NewKey)
+	if err != nil {
+		if errors.Is(err, services.ErrKeyRotationFailed) {
+			c.Error(models.NewAPIError(models.ErrCodeConflict, err.Error()).WithDetails(gin.H{"failed": failures}))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to rotate encryption key"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rotated": rotated,
+	})
+}
+
+// GetAllowedOrigins returns the CORS allowed origins currently in effect
+func (h *AdminHandler) GetAllowedOrigins(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"origins": h.cors.Origins(),
+	})
+}
+
+// UpdateAllowedOrigins stores a new CORS allowed origin list and applies it immediately
+func (h *AdminHandler) UpdateAllowedOrigins(c *gin.Context) {
+	var req models.AllowedOriginsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	origins, err := h.systemSettingsService.SetAllowedOrigins(req.Origins)
+	if err != nil {
+		if errors.Is(err, services.ErrNoAllowedOrigins) || errors.Is(err, services.ErrInvalidOrigin) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to save allowed origins"))
+		return
+	}
+
+	if err := h.cors.SetOrigins(origins); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to apply allowed origins"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"origins": origins,
+	})
+}
+
+// GetEmbeddingEnsemble returns the stored embedding ensemble, or null if none is set
+func (h *AdminHandler) GetEmbeddingEnsemble(c *gin.Context) {
+	ensemble, err := h.systemSettingsService.GetEmbeddingEnsemble()
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to load embedding ensemble"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ensemble": ensemble,
+	})
+}
+
+// UpdateEmbeddingEnsemble stores the embedding ensemble. It takes effect on restart
+// with EMBEDDING_ENSEMBLE=true, and the index must then be rebuilt.
+func (h *AdminHandler) UpdateEmbeddingEnsemble(c *gin.Context) {
+	var req models.EmbeddingEnsembleConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	ensemble, err := h.systemSettingsService.SetEmbeddingEnsemble(&req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidEmbeddingEnsemble) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to save embedding ensemble"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ensemble": ensemble,
+	})
+}
+
+// GetFTSHealth returns the result of the last background FTS index health check
+func (h *AdminHandler) GetFTSHealth(c *gin.Context) {
+	report := h.searchService.LastFTSHealth()
+	if report == nil {
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "no FTS health check has run yet"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"health": report,
+	})
+}
+
+// GetDatabasePragmas returns the SQLite PRAGMAs in effect, to confirm the DB_* tuning settings applied
+func (h *AdminHandler) GetDatabasePragmas(c *gin.Context) {
+	pragmas, err := h.systemSettingsService.DatabasePragmas()
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to read database pragmas"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pragmas": pragmas,
+	})
+}
+
+// GetJobs returns every background job with its schedule, last run and next run
+func (h *AdminHandler) GetJobs(c *gin.Context) {
+	jobs, err := h.jobScheduler.GetAll()
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to load background jobs"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs": jobs,
+	})
+}
+
+// UpdateJob changes a background job's cron schedule or turns it on or off; the change
+// applies immediately
+func (h *AdminHandler) UpdateJob(c *gin.Context) {
+	var req models.BackgroundJobUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	job, err := h.jobScheduler.Update(c.Param("name"), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrJobNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrInvalidCronExpression):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to update background job"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job": job,
+	})
+}
+
+// Backup streams a snapshot of the whole database as a file download
+func (h *AdminHandler) Backup(c *gin.Context) {
+	path, filename, cleanup, err := h.backupService.CreateBackup()
+	if err != nil {
+		log.Printf("[Admin] Backup failed: %v", err)
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to create backup"))
+		return
+	}
+	defer cleanup()
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.File(path)
+}
+
+// Restore replaces the live database with an uploaded backup and restarts the server.
+// It requires confirm=true, since everything written after the backup is lost.
+func (h *AdminHandler) Restore(c *gin.Context) {
+	if c.Query("confirm") != "true" {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "restore requires confirm=true; the server restarts once the database is replaced"))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "a .db backup file is required"))
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(file.Filename), ".db") {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "backup file must have a .db extension"))
+		return
+	}
+
+	upload, err := file.Open()
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to read backup file"))
+		return
+	}
+	defer upload.Close()
+
+	if err := h.backupService.Restore(upload); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidBackup):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		case errors.Is(err, services.ErrRestoreInProgress):
+			c.Error(models.NewAPIError(models.ErrCodeConflict, err.Error()))
+		default:
+			log.Printf("[Admin] Restore failed: %v", err)
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to restore backup"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "backup accepted; the server is restarting with the restored database",
+	})
+}
+
+// Impersonate issues a 15-minute token for acting as a user, for support. The reason
+// goes in the X-Admin-Reason header and is recorded in the user's audit log.
+func (h *AdminHandler) Impersonate(c *gin.Context) {
+	var req models.ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	token, err := h.impersonationService.Start(req.Email, c.GetHeader(middleware.AdminReasonHeader), c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrImpersonationReason):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, fmt.Sprintf("the %s header is required", middleware.AdminReasonHeader)))
+		case errors.Is(err, services.ErrImpersonatedUserNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrImpersonationUnavailable):
+			c.Error(models.NewAPIError(models.ErrCodeUnavailable, err.Error()))
+		default:
+			log.Printf("[Admin] Impersonation failed: %v", err)
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to issue impersonation token"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// GetImpersonationLog returns the latest impersonation events of all users: tokens
+// issued and the requests made with them
+func (h *AdminHandler) GetImpersonationLog(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(services.DefaultImpersonationLogLimit)))
+
+	entries, err := h.impersonationService.GetLog(limit)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch impersonation log"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+	})
+}
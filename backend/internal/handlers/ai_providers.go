@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -22,13 +23,17 @@ func (h *AIProviderHandler) Create(c *gin.Context) {
 
 	var input models.AIProviderCreate
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
-	provider, err := h.service.Create(userID, &input)
+	provider, err := h.service.Create(userID, &input, c.ClientIP())
+	if errors.Is(err, services.ErrInvalidEmbeddingDimension) || errors.Is(err, services.ErrEmbeddingNotSupported) || errors.Is(err, services.ErrSystemPromptTooLong) {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -40,7 +45,7 @@ func (h *AIProviderHandler) GetAll(c *gin.Context) {
 
 	providers, err := h.service.GetByUserID(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -57,7 +62,7 @@ func (h *AIProviderHandler) GetByID(c *gin.Context) {
 	id := c.Param("id")
 	provider, err := h.service.GetByID(id, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Provider not found"})
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "Provider not found"))
 		return
 	}
 
@@ -70,13 +75,17 @@ func (h *AIProviderHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 	var input models.AIProviderUpdate
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
-	provider, err := h.service.Update(id, userID, &input)
+	provider, err := h.service.Update(id, userID, &input, c.ClientIP())
+	if errors.Is(err, services.ErrInvalidEmbeddingDimension) || errors.Is(err, services.ErrEmbeddingNotSupported) || errors.Is(err, services.ErrInvalidFallbackProvider) || errors.Is(err, services.ErrSystemPromptTooLong) {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -88,7 +97,7 @@ func (h *AIProviderHandler) Delete(c *gin.Context) {
 
 	id := c.Param("id")
 	if err := h.service.Delete(id, userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -98,13 +107,13 @@ func (h *AIProviderHandler) Delete(c *gin.Context) {
 func (h *AIProviderHandler) TestConnection(c *gin.Context) {
 	var input models.TestConnectionRequest
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	result, err := h.service.TestConnection(&input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -117,7 +126,7 @@ func (h *AIProviderHandler) FetchModels(c *gin.Context) {
 	id := c.Param("id")
 	fetchedModels, err := h.service.FetchAndSaveModels(id, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -130,7 +139,7 @@ func (h *AIProviderHandler) GetModels(c *gin.Context) {
 	id := c.Param("id")
 	providerModels, err := h.service.GetModels(id, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
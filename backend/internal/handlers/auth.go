@@ -1,20 +1,30 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/repository"
+	"github.com/todomyday/backend/internal/services"
 )
 
 type AuthHandler struct {
-	userRepo *repository.UserRepository
+	userRepo       *repository.UserRepository
+	sessionService *services.SessionService
+	oidcService    *services.OIDCService
 }
 
-func NewAuthHandler(userRepo *repository.UserRepository) *AuthHandler {
+// NewAuthHandler creates an auth handler; oidcService is nil when OIDC isn't configured
+func NewAuthHandler(userRepo *repository.UserRepository, sessionService *services.SessionService, oidcService *services.OIDCService) *AuthHandler {
 	return &AuthHandler{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		oidcService:    oidcService,
 	}
 }
 
@@ -27,13 +37,13 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 func (h *AuthHandler) Me(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
 		return
 	}
 
 	user, err := h.userRepo.GetByID(userID)
 	if err != nil || user == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "user not found"))
 		return
 	}
 
@@ -41,3 +51,175 @@ func (h *AuthHandler) Me(c *gin.Context) {
 		"user": user.ToResponse(),
 	})
 }
+
+func (h *AuthHandler) UpdateMe(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	if userID == "" {
+		c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if req.Timezone != nil {
+		if *req.Timezone == "" {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, "timezone cannot be empty"))
+			return
+		}
+		if _, err := time.LoadLocation(*req.Timezone); err != nil {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, "invalid timezone"))
+			return
+		}
+		updates["timezone"] = *req.Timezone
+	}
+	if req.EmailDigestEnabled != nil {
+		updates["email_digest_enabled"] = *req.EmailDigestEnabled
+	}
+
+	if len(updates) > 0 {
+		if err := h.userRepo.Update(userID, updates); err != nil {
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to update user"))
+			return
+		}
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil || user == nil {
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "user not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": user.ToResponse(),
+	})
+}
+
+// GetSessions lists the user's active sessions
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	sessions, err := h.sessionService.GetAll(userID, middleware.GetSessionID(c))
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch sessions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+	})
+}
+
+// RevokeSession signs out one of the user's sessions
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	if err := h.sessionService.Revoke(userID, c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to revoke session"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "session revoked successfully",
+	})
+}
+
+// RevokeOtherSessions signs out every session except the one making the request
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	revoked, err := h.sessionService.RevokeOthers(userID, middleware.GetSessionID(c))
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to revoke sessions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revoked": revoked,
+	})
+}
+
+// OIDCProviders lists the OIDC providers users can sign in with
+func (h *AuthHandler) OIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"providers": h.oidcService.Providers(),
+	})
+}
+
+// oidcStateCookie ties an OIDC login to the browser that started it, so a callback
+// carrying someone else's code and state is refused (login CSRF)
+const oidcStateCookie = "oidc_state"
+
+// setOIDCStateCookie sets the state cookie, or clears it when maxAge is negative
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, "/api/auth/oidc", "", secure, true)
+}
+
+// OIDCInitiate starts an OIDC login, returning the provider URL to send the user to.
+// ?reauth=true makes the provider ask for the user's credentials again.
+func (h *AuthHandler) OIDCInitiate(c *gin.Context) {
+	resp, err := h.oidcService.InitiateLogin(c.Request.Context(), c.Query("reauth") == "true")
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOIDCNotConfigured):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrOIDCTooManyLogins):
+			c.Error(models.NewAPIError(models.ErrCodeUnavailable, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeUpstream, "failed to start OIDC login: "+err.Error()))
+		}
+		return
+	}
+
+	setOIDCStateCookie(c, resp.State, int(services.OIDCStateTTL.Seconds()))
+	c.JSON(http.StatusOK, resp)
+}
+
+// OIDCCallback completes an OIDC login with the code and state the provider redirected
+// back with, returning an app token
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "OIDC login failed: "+providerErr).WithDetails(c.Query("error_description")))
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "code and state are required"))
+		return
+	}
+	cookieState, err := c.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookieState), []byte(state)) != 1 {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "OIDC login state doesn't match this browser"))
+		return
+	}
+	setOIDCStateCookie(c, "", -1)
+
+	resp, err := h.oidcService.CompleteLogin(c.Request.Context(), code, state)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrOIDCNotConfigured):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrOIDCInvalidState):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		case errors.Is(err, services.ErrOIDCCodeRejected),
+			errors.Is(err, services.ErrOIDCInvalidToken),
+			errors.Is(err, services.ErrOIDCEmailNotVerified):
+			c.Error(models.NewAPIError(models.ErrCodeUnauthorized, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeUpstream, "failed to complete OIDC login: "+err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
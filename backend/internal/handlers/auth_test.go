@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+)
+
+func TestOIDCCallbackRequiresStateCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// Without OIDC configured, a callback that passes the state check fails with 404
+	handler := NewAuthHandler(nil, nil, nil)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/api/auth/oidc/callback", handler.OIDCCallback)
+
+	tests := []struct {
+		name   string
+		cookie string
+		want   int
+	}{
+		{"no cookie", "", http.StatusBadRequest},
+		{"other browser's state", "attacker-state", http.StatusBadRequest},
+		{"matching state", "victim-state", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?code=abc&state=victim-state", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type BlocklistHandler struct {
+	blocklistService *services.BlocklistService
+}
+
+func NewBlocklistHandler(blocklistService *services.BlocklistService) *BlocklistHandler {
+	return &BlocklistHandler{
+		blocklistService: blocklistService,
+	}
+}
+
+// GetAll returns the system blocklist patterns and the user's own
+func (h *BlocklistHandler) GetAll(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	patterns, err := h.blocklistService.GetAll(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch blocklist"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"patterns": patterns,
+	})
+}
+
+// Create adds a keyword or regular expression to the user's blocklist
+func (h *BlocklistHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.BlocklistPatternCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	pattern, err := h.blocklistService.Create(userID, &req)
+	if err != nil {
+		h.blocklistError(c, err, "failed to create blocklist pattern")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"pattern": pattern,
+	})
+}
+
+// Update changes one of the user's blocklist patterns
+func (h *BlocklistHandler) Update(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	patternID := c.Param("id")
+
+	var req models.BlocklistPatternUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	pattern, err := h.blocklistService.Update(userID, patternID, &req)
+	if err != nil {
+		h.blocklistError(c, err, "failed to update blocklist pattern")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pattern": pattern,
+	})
+}
+
+// Delete removes one of the user's blocklist patterns
+func (h *BlocklistHandler) Delete(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	patternID := c.Param("id")
+
+	if err := h.blocklistService.Delete(userID, patternID); err != nil {
+		h.blocklistError(c, err, "failed to delete blocklist pattern")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "blocklist pattern deleted successfully",
+	})
+}
+
+func (h *BlocklistHandler) blocklistError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidBlocklistPattern):
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+	case errors.Is(err, services.ErrBlocklistPatternNotFound):
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+	case errors.Is(err, services.ErrSystemBlocklistPattern):
+		c.Error(models.NewAPIError(models.ErrCodeForbidden, err.Error()))
+	default:
+		c.Error(models.NewAPIError(models.ErrCodeInternal, message))
+	}
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -25,14 +28,14 @@ func (h *ChatHandler) GetActiveThread(c *gin.Context) {
 
 	thread, err := h.chatService.GetOrCreateActiveThread(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get active thread"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to get active thread"))
 		return
 	}
 
 	// Get messages for this thread
 	messages, err := h.chatService.GetThreadWithMessages(userID, thread.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get messages"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to get messages"))
 		return
 	}
 
@@ -45,7 +48,7 @@ func (h *ChatHandler) GetAllThreads(c *gin.Context) {
 
 	threads, err := h.chatService.GetAllThreads(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch threads"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch threads"))
 		return
 	}
 
@@ -61,7 +64,7 @@ func (h *ChatHandler) GetThread(c *gin.Context) {
 
 	response, err := h.chatService.GetThreadWithMessages(userID, threadID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "thread not found"})
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "thread not found"))
 		return
 	}
 
@@ -74,7 +77,7 @@ func (h *ChatHandler) CreateThread(c *gin.Context) {
 
 	thread, err := h.chatService.CreateThread(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create thread"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to create thread"))
 		return
 	}
 
@@ -90,13 +93,13 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 
 	var req models.ChatMessageCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	message, err := h.chatService.AddMessage(userID, threadID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -105,13 +108,106 @@ func (h *ChatHandler) AddMessage(c *gin.Context) {
 	})
 }
 
+// UpdateThreadTitle manually overrides a thread's title
+func (h *ChatHandler) UpdateThreadTitle(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	threadID := c.Param("id")
+
+	var req models.ChatThreadTitleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	thread, err := h.chatService.UpdateThreadTitle(userID, threadID, req.Title)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"thread": thread,
+	})
+}
+
+// Ask answers a question in the context of the thread's conversation history
+func (h *ChatHandler) Ask(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	threadID := c.Param("id")
+
+	var req models.ChatAskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	response, err := h.chatService.Ask(c.Request.Context(), threadID, userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AskStream answers a question like Ask, as server-sent events: a "thinking" event
+// straight away, a "token" event per piece of the reply, then "done" with the stored
+// message's ID (or "error"). A client that disconnects mid-stream cancels the AI
+// call and the reply so far is kept as a partial message.
+func (h *ChatHandler) AskStream(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	threadID := c.Param("id")
+
+	var req models.ChatAskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // stop nginx from holding events back
+	c.Status(http.StatusOK)
+
+	writeChatEvent(c, gin.H{"type": "thinking"})
+
+	response, err := h.chatService.AskStream(c.Request.Context(), threadID, userID, &req, func(token string) {
+		writeChatEvent(c, gin.H{"type": "token", "content": token})
+	})
+	if err != nil {
+		apiErr := middleware.ToAPIError(err)
+		if apiErr.Code == models.ErrCodeInternal {
+			log.Printf("[ChatHandler] Streamed answer failed for thread %s: %v", threadID, err)
+		}
+		writeChatEvent(c, gin.H{"type": "error", "error": apiErr.Message})
+		return
+	}
+
+	done := gin.H{"type": "done", "message_id": response.AssistantMessage.ID}
+	if response.CreatedTodo != nil {
+		done["created_todo"] = response.CreatedTodo
+	}
+	writeChatEvent(c, done)
+}
+
+// writeChatEvent sends one server-sent event and flushes it to the client
+func writeChatEvent(c *gin.Context, event gin.H) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+	c.Writer.Flush()
+}
+
 // DeleteThread deletes a thread
 func (h *ChatHandler) DeleteThread(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	threadID := c.Param("id")
 
 	if err := h.chatService.DeleteThread(userID, threadID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type DailyBriefingHandler struct {
+	briefingService *services.DailyBriefingService
+}
+
+func NewDailyBriefingHandler(briefingService *services.DailyBriefingService) *DailyBriefingHandler {
+	return &DailyBriefingHandler{
+		briefingService: briefingService,
+	}
+}
+
+// Get returns today's AI plan from the user's due todos and recent memories, with the
+// IDs of the todos and memories it was written from
+func (h *DailyBriefingHandler) Get(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	briefing, err := h.briefingService.Get(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrBriefingAINotConfigured):
+			c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, err.Error()))
+		case errors.Is(err, services.ErrBriefingGenerationFailed):
+			c.Error(models.NewAPIError(models.ErrCodeUpstream, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to generate daily briefing"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, briefing)
+}
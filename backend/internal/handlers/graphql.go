@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/graph"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// GraphQLEndpoint is where the GraphQL API is served
+const GraphQLEndpoint = "/graphql"
+
+// graphQLQueryCacheSize is how many parsed queries are kept, since integrations tend
+// to send the same few over and over
+const graphQLQueryCacheSize = 1000
+
+// graphQLComplexityLimit caps the cost of a request, one per field selected.
+// graphQLAskComplexity is what an ask costs on top of its fields, since each runs a
+// search and an AI call, so a request can ask only one question.
+const (
+	graphQLComplexityLimit = 500
+	graphQLAskComplexity   = 300
+)
+
+type GraphQLHandler struct {
+	resolver   *graph.Resolver
+	server     *handler.Server
+	playground http.HandlerFunc
+}
+
+func NewGraphQLHandler(resolver *graph.Resolver) *GraphQLHandler {
+	config := graph.Config{Resolvers: resolver}
+	config.Complexity.Query.Ask = func(childComplexity int, _ string, _ *models.AskMode, _ []string, _ *int) int {
+		return childComplexity + graphQLAskComplexity
+	}
+
+	server := handler.New(graph.NewExecutableSchema(config))
+	server.AddTransport(transport.POST{})
+	server.SetQueryCache(lru.New[*ast.QueryDocument](graphQLQueryCacheSize))
+	server.Use(extension.Introspection{})
+	server.Use(extension.FixedComplexityLimit(graphQLComplexityLimit))
+
+	return &GraphQLHandler{
+		resolver:   resolver,
+		server:     server,
+		playground: playground.Handler("todomyday GraphQL", GraphQLEndpoint),
+	}
+}
+
+// Query runs a GraphQL query or mutation as the authenticated user
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	ctx := h.resolver.WithUser(c.Request.Context(), middleware.GetUserID(c), c.ClientIP())
+	h.server.ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+}
+
+// Playground serves the GraphiQL IDE for the API. Its requests need a user token in
+// the Authorization header like any other client's.
+func (h *GraphQLHandler) Playground(c *gin.Context) {
+	h.playground.ServeHTTP(c.Writer, c.Request)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/graph"
+)
+
+func TestGraphQLComplexityLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewGraphQLHandler(graph.NewResolver(nil, nil, nil, nil))
+	r := gin.New()
+	r.POST(GraphQLEndpoint, handler.Query)
+
+	tests := []struct {
+		name     string
+		query    string
+		rejected bool
+	}{
+		{"one question", `{ ask(question: "a") { answer sources { score } } }`, false},
+		{"two questions", `{ a: ask(question: "a") { answer } b: ask(question: "b") { answer } }`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]string{"query": tt.query})
+			req := httptest.NewRequest(http.MethodPost, GraphQLEndpoint, bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			// Unauthenticated requests that pass the limit fail in the resolvers instead
+			rejected := strings.Contains(w.Body.String(), "COMPLEXITY_LIMIT_EXCEEDED")
+			if rejected != tt.rejected {
+				t.Errorf("rejected = %v, want %v (body %s)", rejected, tt.rejected, w.Body.String())
+			}
+		})
+	}
+}
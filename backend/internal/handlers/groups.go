@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -11,20 +12,24 @@ import (
 
 type GroupHandler struct {
 	groupService *services.GroupService
+	todoService  *services.TodoService
 }
 
-func NewGroupHandler(groupService *services.GroupService) *GroupHandler {
+func NewGroupHandler(groupService *services.GroupService, todoService *services.TodoService) *GroupHandler {
 	return &GroupHandler{
 		groupService: groupService,
+		todoService:  todoService,
 	}
 }
 
 func (h *GroupHandler) GetAll(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
-	groups, err := h.groupService.GetAll(userID)
+	includeArchived := c.Query("include_archived") == "true"
+
+	groups, err := h.groupService.GetAll(userID, includeArchived)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch groups"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch groups"))
 		return
 	}
 
@@ -38,13 +43,19 @@ func (h *GroupHandler) Create(c *gin.Context) {
 
 	var req models.GroupCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	group, err := h.groupService.Create(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create group"})
+		switch {
+		case errors.Is(err, services.ErrParentGroupNotFound), errors.Is(err, services.ErrGroupTooDeep),
+			errors.Is(err, services.ErrInvalidGroupColor):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to create group"))
+		}
 		return
 	}
 
@@ -59,11 +70,11 @@ func (h *GroupHandler) GetByID(c *gin.Context) {
 
 	group, err := h.groupService.GetByID(userID, groupID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch group"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch group"))
 		return
 	}
 	if group == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "group not found"))
 		return
 	}
 
@@ -72,19 +83,137 @@ func (h *GroupHandler) GetByID(c *gin.Context) {
 	})
 }
 
+func (h *GroupHandler) GetStats(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	groupID := c.Param("id")
+
+	stats, err := h.groupService.GetStats(userID, groupID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch group stats"))
+		return
+	}
+	if stats == nil {
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "group not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stats": stats,
+	})
+}
+
+// GetChildren returns a group's direct sub-groups
+func (h *GroupHandler) GetChildren(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	groupID := c.Param("id")
+
+	children, err := h.groupService.GetChildren(userID, groupID)
+	if err != nil {
+		if errors.Is(err, services.ErrGroupNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch sub-groups"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"groups": children,
+	})
+}
+
+// GetColorPreview returns a group's light and dark colors with their WCAG contrast
+// ratios against the light and dark page backgrounds
+func (h *GroupHandler) GetColorPreview(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	groupID := c.Param("id")
+
+	preview, err := h.groupService.ColorPreview(userID, groupID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrGroupNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrInvalidGroupColor):
+			c.Error(models.NewAPIError(models.ErrCodeUnprocessable, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to preview group colors"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// GetTodos returns a group's todos in position order
+func (h *GroupHandler) GetTodos(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	groupID := c.Param("id")
+
+	todos, err := h.groupService.GetTodos(userID, groupID)
+	if err != nil {
+		if errors.Is(err, services.ErrGroupNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch todos"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"todos": todos,
+	})
+}
+
+// ReorderTodos reorders todos within a group; every todo must belong to the group
+func (h *GroupHandler) ReorderTodos(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	groupID := c.Param("id")
+
+	var req models.TodoReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	if err := h.groupService.ValidateReorder(userID, groupID, &req); err != nil {
+		switch {
+		case errors.Is(err, services.ErrGroupNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrTodoNotInGroup):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to reorder todos"))
+		}
+		return
+	}
+
+	if err := h.todoService.Reorder(userID, &req); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "todos reordered successfully",
+	})
+}
+
 func (h *GroupHandler) Update(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	groupID := c.Param("id")
 
 	var req models.GroupUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	group, err := h.groupService.Update(userID, groupID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrInvalidGroupColor) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(err)
 		return
 	}
 
@@ -98,7 +227,7 @@ func (h *GroupHandler) Delete(c *gin.Context) {
 	groupID := c.Param("id")
 
 	if err := h.groupService.Delete(userID, groupID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -106,3 +235,39 @@ func (h *GroupHandler) Delete(c *gin.Context) {
 		"message": "group deleted successfully",
 	})
 }
+
+func (h *GroupHandler) Archive(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+func (h *GroupHandler) Unarchive(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *GroupHandler) setArchived(c *gin.Context, archived bool) {
+	userID := middleware.GetUserID(c)
+	groupID := c.Param("id")
+
+	var group *models.Group
+	var err error
+	if archived {
+		group, err = h.groupService.Archive(userID, groupID)
+	} else {
+		group, err = h.groupService.Unarchive(userID, groupID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrGroupNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrDefaultGroupArchived):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to update group"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group": group,
+	})
+}
@@ -4,10 +4,34 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
 )
 
-func HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "healthy",
-	})
+type HealthHandler struct {
+	healthService *services.HealthService
+}
+
+func NewHealthHandler(healthService *services.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// Health reports the status of each dependency. It responds 503 only when SQLite,
+// without which nothing works, is down.
+func (h *HealthHandler) Health(c *gin.Context) {
+	health := h.healthService.Check(c.Request.Context())
+	status := http.StatusOK
+	if health.Status == models.HealthError {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, health)
+}
+
+// Ready is the readiness probe: 503 until startup work has finished
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if !h.healthService.Ready() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready"})
 }
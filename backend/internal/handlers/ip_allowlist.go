@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type IPAllowlistHandler struct {
+	ipAllowlistService *services.IPAllowlistService
+}
+
+func NewIPAllowlistHandler(ipAllowlistService *services.IPAllowlistService) *IPAllowlistHandler {
+	return &IPAllowlistHandler{
+		ipAllowlistService: ipAllowlistService,
+	}
+}
+
+// GetAll returns the user's allowlist entries
+func (h *IPAllowlistHandler) GetAll(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	entries, err := h.ipAllowlistService.GetAll(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch ip allowlist"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries":   entries,
+		"client_ip": c.ClientIP(),
+	})
+}
+
+// Create adds a network to the user's allowlist
+func (h *IPAllowlistHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.IPAllowlistCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	entry, err := h.ipAllowlistService.Create(userID, &req, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCIDR) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to create ip allowlist entry"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"entry": entry,
+	})
+}
+
+// Update changes an allowlist entry's network or label
+func (h *IPAllowlistHandler) Update(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	entryID := c.Param("id")
+
+	var req models.IPAllowlistUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	entry, err := h.ipAllowlistService.Update(userID, entryID, &req, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCIDR) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entry": entry,
+	})
+}
+
+// Delete removes an allowlist entry
+func (h *IPAllowlistHandler) Delete(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	entryID := c.Param("id")
+
+	if err := h.ipAllowlistService.Delete(userID, entryID, c.ClientIP()); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "ip allowlist entry deleted successfully",
+	})
+}
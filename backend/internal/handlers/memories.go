@@ -1,17 +1,21 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/todomyday/backend/internal/middleware"
 	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/services"
+	"github.com/todomyday/backend/internal/tracing"
 )
 
 type MemoryHandler struct {
@@ -19,27 +23,46 @@ type MemoryHandler struct {
 	fileParserService *services.FileParserService
 	uploadJobService  *services.UploadJobService
 	visionService     *services.VisionService
+	attachmentService *services.AttachmentService
 }
 
-func NewMemoryHandler(memoryService *services.MemoryService, fileParserService *services.FileParserService, uploadJobService *services.UploadJobService, visionService *services.VisionService) *MemoryHandler {
+func NewMemoryHandler(memoryService *services.MemoryService, fileParserService *services.FileParserService, uploadJobService *services.UploadJobService, visionService *services.VisionService, attachmentService *services.AttachmentService) *MemoryHandler {
 	return &MemoryHandler{
 		memoryService:     memoryService,
 		fileParserService: fileParserService,
 		uploadJobService:  uploadJobService,
 		visionService:     visionService,
+		attachmentService: attachmentService,
 	}
 }
 
-// GetAll returns all memories for the user
+// GetAll returns all memories for the user. With ?after= (empty for the first page)
+// it returns a cursor page, newest first, with next_cursor and has_more; otherwise
+// ?offset= pages in the user's sort mode.
 func (h *MemoryHandler) GetAll(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	if after, ok := c.GetQuery("after"); ok {
+		page, err := h.memoryService.GetPage(userID, after, limit)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidCursor) {
+				c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+				return
+			}
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch memories"))
+			return
+		}
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
 
 	memories, err := h.memoryService.GetAll(userID, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch memories"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch memories"))
 		return
 	}
 
@@ -54,13 +77,18 @@ func (h *MemoryHandler) Create(c *gin.Context) {
 
 	var req models.MemoryCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	memory, err := h.memoryService.Create(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create memory"})
+		var blocked *models.ContentBlockedError
+		if errors.As(err, &blocked) {
+			c.Error(blocked)
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to create memory"))
 		return
 	}
 
@@ -69,6 +97,34 @@ func (h *MemoryHandler) Create(c *gin.Context) {
 	})
 }
 
+// CreateBatch creates up to models.MaxMemoryBatchSize memories in one transaction and
+// reports which were created and which failed
+func (h *MemoryHandler) CreateBatch(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.MemoryBatchCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	result, err := h.memoryService.CreateBatch(userID, req.Memories, req.StopOnError)
+	if err != nil {
+		if errors.Is(err, services.ErrEmptyMemoryBatch) || errors.Is(err, services.ErrMemoryBatchTooLarge) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to create memories"))
+		return
+	}
+
+	status := http.StatusCreated
+	if len(result.Created) == 0 {
+		status = http.StatusOK
+	}
+	c.JSON(status, result)
+}
+
 // GetByID returns a single memory
 func (h *MemoryHandler) GetByID(c *gin.Context) {
 	userID := middleware.GetUserID(c)
@@ -76,11 +132,11 @@ func (h *MemoryHandler) GetByID(c *gin.Context) {
 
 	memory, err := h.memoryService.GetByID(userID, memoryID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch memory"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch memory"))
 		return
 	}
 	if memory == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "memory not found"})
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "memory not found"))
 		return
 	}
 
@@ -89,6 +145,52 @@ func (h *MemoryHandler) GetByID(c *gin.Context) {
 	})
 }
 
+// GetRelated returns the memories auto-linked to a memory by embedding similarity
+func (h *MemoryHandler) GetRelated(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	memory, err := h.memoryService.GetByID(userID, memoryID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch memory"))
+		return
+	}
+	if memory == nil {
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "memory not found"))
+		return
+	}
+
+	related, err := h.memoryService.GetRelated(userID, memoryID, limit)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch related memories"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"memories": related,
+	})
+}
+
+// GetPreview returns the memory's Markdown content rendered as sanitized HTML
+func (h *MemoryHandler) GetPreview(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	preview, err := h.memoryService.GetPreview(userID, memoryID)
+	if err != nil {
+		if errors.Is(err, services.ErrMemoryNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, "memory not found"))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to render memory preview"))
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(preview))
+}
+
 // Update updates a memory
 func (h *MemoryHandler) Update(c *gin.Context) {
 	userID := middleware.GetUserID(c)
@@ -96,13 +198,13 @@ func (h *MemoryHandler) Update(c *gin.Context) {
 
 	var req models.MemoryUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	memory, err := h.memoryService.Update(userID, memoryID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -116,8 +218,8 @@ func (h *MemoryHandler) Delete(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	memoryID := c.Param("id")
 
-	if err := h.memoryService.Delete(userID, memoryID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.memoryService.Delete(userID, memoryID, c.ClientIP()); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -126,13 +228,243 @@ func (h *MemoryHandler) Delete(c *gin.Context) {
 	})
 }
 
+// BulkDelete removes all memories matching a category and/or date cutoff.
+// Requires ?confirm=true since the deletion can't be undone.
+func (h *MemoryHandler) BulkDelete(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	if c.Query("confirm") != "true" {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "bulk delete requires confirm=true"))
+		return
+	}
+
+	var filter models.BulkDeleteFilter
+	if err := c.ShouldBindJSON(&filter); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	deleted, err := h.memoryService.BulkDelete(userID, filter, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrEmptyBulkDeleteFilter) || errors.Is(err, services.ErrInvalidBeforeDate) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
+
+// BulkArchive archives all memories older than a number of days and/or in a category.
+// Pinned memories are skipped unless include_pinned is true.
+func (h *MemoryHandler) BulkArchive(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var filter models.BulkArchiveFilter
+	if err := c.ShouldBindJSON(&filter); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	archived, err := h.memoryService.BulkArchive(userID, filter)
+	if err != nil {
+		respondBulkArchiveError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"archived": archived})
+}
+
+// BulkUnarchive restores all archived memories older than a number of days and/or in a category
+func (h *MemoryHandler) BulkUnarchive(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var filter models.BulkArchiveFilter
+	if err := c.ShouldBindJSON(&filter); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	unarchived, err := h.memoryService.BulkUnarchive(userID, filter)
+	if err != nil {
+		respondBulkArchiveError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unarchived": unarchived})
+}
+
+func respondBulkArchiveError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrEmptyBulkArchiveFilter) || errors.Is(err, services.ErrInvalidOlderThanDays) {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+	c.Error(err)
+}
+
+// Clone duplicates a memory, optionally overriding its content and category
+func (h *MemoryHandler) Clone(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	var overrides models.MemoryCloneOverrides
+	// Body is optional - an empty request clones the memory as-is
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&overrides); err != nil {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+	}
+
+	memory, err := h.memoryService.Clone(userID, memoryID, &overrides)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"memory": memory,
+	})
+}
+
+// Pin pins a memory to the top of the list
+func (h *MemoryHandler) Pin(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	memory, err := h.memoryService.Pin(userID, memoryID)
+	if err != nil {
+		if errors.Is(err, services.ErrPinLimitReached) {
+			c.Error(models.NewAPIError(models.ErrCodeConflict, fmt.Sprintf("you can pin at most %d memories, unpin one first", services.MaxPinnedMemories)))
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"memory": memory,
+	})
+}
+
+// RefreshURL re-scrapes a memory's URL and updates its page title and summary
+func (h *MemoryHandler) RefreshURL(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	memory, err := h.memoryService.RefreshURL(c.Request.Context(), userID, memoryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMemoryNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrMemoryHasNoURL):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		case errors.Is(err, services.ErrScraperNotEnabled):
+			c.Error(models.NewAPIError(models.ErrCodeUnavailable, err.Error()))
+		case errors.Is(err, services.ErrURLScrapeFailed):
+			c.Error(models.NewAPIError(models.ErrCodeUpstream, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to refresh URL content"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"memory": memory,
+	})
+}
+
+// GenerateTitle has the AI write a display title for a memory
+func (h *MemoryHandler) GenerateTitle(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	memory, err := h.memoryService.GenerateTitle(userID, memoryID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMemoryNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrMemoryAINotConfigured):
+			c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, err.Error()))
+		case errors.Is(err, services.ErrTitleGenerationFailed):
+			c.Error(models.NewAPIError(models.ErrCodeUpstream, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to generate title"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"memory": memory,
+	})
+}
+
+// GetRevisions lists a memory's latest revisions, newest first
+func (h *MemoryHandler) GetRevisions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	revisions, err := h.memoryService.ListRevisions(userID, memoryID)
+	if err != nil {
+		if errors.Is(err, services.ErrMemoryNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch revisions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": revisions,
+	})
+}
+
+// RestoreRevision replaces a memory's content with that of one of its revisions
+func (h *MemoryHandler) RestoreRevision(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	memory, err := h.memoryService.RestoreRevision(userID, memoryID, c.Param("revisionID"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMemoryNotFound), errors.Is(err, services.ErrRevisionNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to restore revision"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"memory": memory,
+	})
+}
+
+// Unpin removes a memory from the pinned list
+func (h *MemoryHandler) Unpin(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	memory, err := h.memoryService.Unpin(userID, memoryID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"memory": memory,
+	})
+}
+
 // GetCategories returns all available categories
 func (h *MemoryHandler) GetCategories(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	categories, err := h.memoryService.GetCategories(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch categories"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch categories"))
 		return
 	}
 
@@ -151,7 +483,7 @@ func (h *MemoryHandler) GetByCategory(c *gin.Context) {
 
 	memories, err := h.memoryService.GetByCategory(userID, category, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch memories"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch memories"))
 		return
 	}
 
@@ -166,13 +498,13 @@ func (h *MemoryHandler) Search(c *gin.Context) {
 
 	var req models.MemorySearchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	memories, err := h.memoryService.Search(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search memories"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to search memories"))
 		return
 	}
 
@@ -192,7 +524,11 @@ func (h *MemoryHandler) ConvertToTodo(c *gin.Context) {
 
 	todo, err := h.memoryService.ConvertToTodo(userID, memoryID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrGroupNotLeaf) || errors.Is(err, services.ErrGroupNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(err)
 		return
 	}
 
@@ -208,7 +544,7 @@ func (h *MemoryHandler) GetDigest(c *gin.Context) {
 
 	digest, err := h.memoryService.GetOrGenerateDigest(userID, false)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -223,7 +559,7 @@ func (h *MemoryHandler) GenerateDigest(c *gin.Context) {
 
 	digest, err := h.memoryService.GetOrGenerateDigest(userID, true)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -236,13 +572,17 @@ func (h *MemoryHandler) GenerateDigest(c *gin.Context) {
 func (h *MemoryHandler) WebSearch(c *gin.Context) {
 	var req models.WebSearchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	results, err := h.memoryService.WebSearch(req.Query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrSearXNGUnavailable) {
+			c.Error(models.NewAPIError(models.ErrCodeUnavailable, err.Error()))
+			return
+		}
+		c.Error(err)
 		return
 	}
 
@@ -257,12 +597,12 @@ func (h *MemoryHandler) Reorder(c *gin.Context) {
 
 	var req models.MemoryReorderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	if err := h.memoryService.Reorder(userID, &req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -271,19 +611,90 @@ func (h *MemoryHandler) Reorder(c *gin.Context) {
 	})
 }
 
+// ResetPositions renumbers the user's memories 1000 apart, keeping their order
+func (h *MemoryHandler) ResetPositions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	updated, err := h.memoryService.ResetPositions(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to reset memory positions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// GetKeywords returns the top keywords of the user's memories by TF-IDF, for a tag
+// cloud. ?limit= caps the keywords (default 50) and ?min_frequency= drops rarer words
+// (default 2).
+func (h *MemoryHandler) GetKeywords(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(services.DefaultKeywordLimit)))
+	minFreq, _ := strconv.Atoi(c.DefaultQuery("min_frequency", strconv.Itoa(services.DefaultKeywordMinFrequency)))
+
+	keywords, err := h.memoryService.GetKeywords(userID, limit, minFreq)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to compute keywords"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keywords": keywords,
+	})
+}
+
 // GetStats returns memory statistics
 func (h *MemoryHandler) GetStats(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	stats, err := h.memoryService.GetStats(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch stats"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch stats"))
 		return
 	}
 
 	c.JSON(http.StatusOK, stats)
 }
 
+// ExportCSV streams the user's memories as a CSV file. ?fields= picks and orders the
+// columns; ?category=, ?from= and ?to= (YYYY-MM-DD) narrow the memories.
+func (h *MemoryHandler) ExportCSV(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	filter := models.ExportFilter{
+		Category: c.Query("category"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+	}
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			filter.Fields = append(filter.Fields, field)
+		}
+	}
+
+	reader, err := h.memoryService.ExportCSV(userID, filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidExportFilter) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to export memories"))
+		return
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="memories-export.csv"`)
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		// The status is already sent; just cut the response short
+		log.Printf("[MemoryHandler] CSV export failed for user %s: %v", userID, err)
+	}
+}
+
 // UploadMemoryFile handles file upload for creating memories asynchronously
 // Returns a job ID that can be polled for progress
 func (h *MemoryHandler) UploadMemoryFile(c *gin.Context) {
@@ -292,34 +703,34 @@ func (h *MemoryHandler) UploadMemoryFile(c *gin.Context) {
 	// 1. Get uploaded file from multipart form-data
 	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "No file uploaded"))
 		return
 	}
 
 	// 2. Validate file (type and size)
 	if err := h.fileParserService.ValidateFile(file.Filename, file.Size); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
 	// 3. Open and read file content
 	fileContent, err := file.Open()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "Failed to read file"))
 		return
 	}
 	defer fileContent.Close()
 
 	contentBytes, err := io.ReadAll(fileContent)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file content"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "Failed to read file content"))
 		return
 	}
 
 	// 4. Parse file into memory sections
 	sections, err := h.fileParserService.ParseFile(file.Filename, contentBytes)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Parse error: %v", err)})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, fmt.Sprintf("Parse error: %v", err)))
 		return
 	}
 
@@ -330,7 +741,8 @@ func (h *MemoryHandler) UploadMemoryFile(c *gin.Context) {
 	log.Printf("[UploadMemoryFile] Created job %s for user %s with %d sections", job.ID, userID, len(sections))
 
 	// 6. Process sections asynchronously
-	go h.processUploadJob(job.ID, userID, sections)
+	// The job outlives the request but stays in its trace
+	go h.processUploadJob(tracing.Detach(c.Request.Context()), job.ID, userID, sections)
 
 	// 7. Return job ID immediately
 	c.JSON(http.StatusAccepted, models.UploadJobCreateResponse{
@@ -342,13 +754,27 @@ func (h *MemoryHandler) UploadMemoryFile(c *gin.Context) {
 }
 
 // processUploadJob processes file sections asynchronously and updates job status
-func (h *MemoryHandler) processUploadJob(jobID, userID string, sections []services.ParsedMemorySection) {
+func (h *MemoryHandler) processUploadJob(ctx context.Context, jobID, userID string, sections []services.ParsedMemorySection) {
 	// Update job status to processing
 	h.uploadJobService.UpdateJobStatus(jobID, models.JobStatusProcessing)
 
 	log.Printf("[UploadJob:%s] Starting processing of %d sections", jobID, len(sections))
 
+	// Skip sections that are near-copies of existing memories, e.g. a re-imported book
+	duplicates, err := h.memoryService.FindSemanticDuplicates(ctx, userID, sections, services.DefaultDuplicateThreshold)
+	if err != nil {
+		log.Printf("[UploadJob:%s] Duplicate check failed, importing all sections: %v", jobID, err)
+	}
+	if len(duplicates) > 0 {
+		log.Printf("[UploadJob:%s] Skipping %d duplicate sections", jobID, len(duplicates))
+		h.uploadJobService.MarkDuplicates(jobID, len(duplicates))
+	}
+
 	for i, section := range sections {
+		if _, isDuplicate := duplicates[i]; isDuplicate {
+			continue
+		}
+
 		req := &models.MemoryCreateRequest{
 			Content: section.Content,
 		}
@@ -361,7 +787,7 @@ func (h *MemoryHandler) processUploadJob(jobID, userID string, sections []servic
 		}
 
 		// Add memory to job (this updates the memories list progressively)
-		if err := h.uploadJobService.AddMemoryToJob(jobID, *memory); err != nil {
+		if err := h.uploadJobService.AddMemoryToJob(jobID, *memory, section.Source); err != nil {
 			log.Printf("[UploadJob:%s] Failed to add memory to job: %v", jobID, err)
 		}
 
@@ -373,13 +799,98 @@ func (h *MemoryHandler) processUploadJob(jobID, userID string, sections []servic
 	log.Printf("[UploadJob:%s] Completed processing", jobID)
 }
 
+// ImportVault imports the notes of a ZIP archive (e.g. an Obsidian vault export).
+// Sections are created one at a time with AI categorization, and a summary is returned.
+func (h *MemoryHandler) ImportVault(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "No file uploaded"))
+		return
+	}
+
+	if ext := strings.ToLower(filepath.Ext(file.Filename)); ext != ".zip" {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "Only .zip files allowed"))
+		return
+	}
+
+	if err := h.fileParserService.ValidateFile(file.Filename, file.Size); err != nil {
+		c.Error(err)
+		return
+	}
+
+	fileContent, err := file.Open()
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "Failed to read file"))
+		return
+	}
+	defer fileContent.Close()
+
+	contentBytes, err := io.ReadAll(fileContent)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "Failed to read file content"))
+		return
+	}
+
+	result, err := h.fileParserService.ParseVaultZip(contentBytes)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, fmt.Sprintf("Parse error: %v", err)))
+		return
+	}
+
+	log.Printf("[ImportVault] Importing %d sections from %s for user %s", len(result.Sections), file.Filename, userID)
+
+	response := models.VaultImportResponse{
+		Skipped:          result.Skipped,
+		Errors:           append([]models.VaultImportError{}, result.Errors...),
+		ImportedBySource: map[string]int{},
+	}
+
+	duplicates, err := h.memoryService.FindSemanticDuplicates(c.Request.Context(), userID, result.Sections, services.DefaultDuplicateThreshold)
+	if err != nil {
+		log.Printf("[ImportVault] Duplicate check failed, importing all sections: %v", err)
+	}
+	response.DeduplicatedCount = len(duplicates)
+
+	for i, section := range result.Sections {
+		if _, isDuplicate := duplicates[i]; isDuplicate {
+			continue
+		}
+
+		req := &models.MemoryCreateRequest{
+			Content: section.Heading + "\n\n" + section.Content,
+		}
+
+		if _, err := h.memoryService.Create(userID, req); err != nil {
+			log.Printf("[ImportVault] Failed to create memory for %q: %v", section.Heading, err)
+			message := "failed to create memory"
+			var blocked *models.ContentBlockedError
+			if errors.As(err, &blocked) {
+				message = blocked.Error()
+			}
+			response.Errors = append(response.Errors, models.VaultImportError{
+				File:  section.Heading,
+				Error: message,
+			})
+			continue
+		}
+		response.Imported++
+		if section.Source != "" {
+			response.ImportedBySource[string(section.Source)]++
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // GetUploadJobStatus returns the current status of an upload job
 func (h *MemoryHandler) GetUploadJobStatus(c *gin.Context) {
 	jobID := c.Param("job_id")
 
 	status, err := h.uploadJobService.GetJobStatus(jobID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "Job not found"))
 		return
 	}
 
@@ -392,14 +903,14 @@ func (h *MemoryHandler) UploadImage(c *gin.Context) {
 
 	// Check if vision service is configured
 	if h.visionService == nil || !h.visionService.IsConfigured() {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Vision service not configured"})
+		c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, "Vision service not configured"))
 		return
 	}
 
 	// Get uploaded file
 	file, err := c.FormFile("image")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No image uploaded"})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "No image uploaded"))
 		return
 	}
 
@@ -427,7 +938,7 @@ func (h *MemoryHandler) UploadImage(c *gin.Context) {
 		if mimeType, ok := validExts[ext]; ok {
 			contentType = mimeType
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image type. Supported: JPG, PNG, GIF, WebP"})
+			c.Error(models.NewAPIError(models.ErrCodeValidation, "Invalid image type. Supported: JPG, PNG, GIF, WebP"))
 			return
 		}
 	}
@@ -435,21 +946,21 @@ func (h *MemoryHandler) UploadImage(c *gin.Context) {
 	// Validate file size (max 10MB)
 	maxSize := int64(10 * 1024 * 1024)
 	if file.Size > maxSize {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Image too large. Maximum size is 10MB"})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "Image too large. Maximum size is 10MB"))
 		return
 	}
 
 	// Read file content
 	fileContent, err := file.Open()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read image"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "Failed to read image"))
 		return
 	}
 	defer fileContent.Close()
 
 	imageData, err := io.ReadAll(fileContent)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read image content"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "Failed to read image content"))
 		return
 	}
 
@@ -459,10 +970,25 @@ func (h *MemoryHandler) UploadImage(c *gin.Context) {
 	visionResult, err := h.visionService.ProcessImage(imageData, contentType)
 	if err != nil {
 		log.Printf("[UploadImage] Vision processing failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to process image: %v", err)})
+		if errors.Is(err, services.ErrModelDoesNotSupportVision) {
+			c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, fmt.Sprintf("Failed to process image: %v", err)))
 		return
 	}
 
+	// Keep the original image when object storage is configured
+	var storageKey string
+	if h.attachmentService.IsConfigured() {
+		storageKey, err = h.attachmentService.Upload(c.Request.Context(), userID, contentType, imageData)
+		if err != nil {
+			log.Printf("[UploadImage] Failed to store image: %v", err)
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "Failed to store image"))
+			return
+		}
+	}
+
 	// Create memory from extracted content
 	req := &models.MemoryCreateRequest{
 		Content: visionResult.Content,
@@ -471,14 +997,60 @@ func (h *MemoryHandler) UploadImage(c *gin.Context) {
 	memory, err := h.memoryService.CreateWithCategory(userID, req, visionResult.Category, visionResult.Summary)
 	if err != nil {
 		log.Printf("[UploadImage] Failed to create memory: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save memory"})
+		// Nothing will reference the stored image
+		h.attachmentService.DeleteObjects(c.Request.Context(), storageKey)
+		var blocked *models.ContentBlockedError
+		if errors.As(err, &blocked) {
+			c.Error(blocked)
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "Failed to save memory"))
 		return
 	}
 
 	log.Printf("[UploadImage] Created memory %s from image with category %s", memory.ID, memory.Category)
 
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"memory":        memory,
 		"vision_result": visionResult,
+	}
+
+	if storageKey != "" {
+		attachment, err := h.attachmentService.Link(memory.ID, storageKey, contentType, int64(len(imageData)))
+		if err != nil {
+			// The memory is already saved; only the link to the image is lost, so the
+			// unreferenced image is removed
+			log.Printf("[UploadImage] Failed to link attachment to memory %s: %v", memory.ID, err)
+			h.attachmentService.DeleteObjects(c.Request.Context(), storageKey)
+		} else {
+			response["attachment"] = attachment
+		}
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetAttachment returns a pre-signed download URL for a memory attachment
+func (h *MemoryHandler) GetAttachment(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	if !h.attachmentService.IsConfigured() {
+		c.Error(models.NewAPIError(models.ErrCodeUnavailable, "Attachment storage not configured"))
+		return
+	}
+
+	url, expiresAt, err := h.attachmentService.GetURL(c.Request.Context(), userID, c.Param("id"), c.Param("attachmentID"))
+	if err != nil {
+		if errors.Is(err, services.ErrAttachmentNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"url":        url,
+		"expires_at": expiresAt,
 	})
 }
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type PromptTemplateHandler struct {
+	promptTemplateService *services.PromptTemplateService
+}
+
+func NewPromptTemplateHandler(promptTemplateService *services.PromptTemplateService) *PromptTemplateHandler {
+	return &PromptTemplateHandler{
+		promptTemplateService: promptTemplateService,
+	}
+}
+
+// GetAll returns all prompt templates for the user
+func (h *PromptTemplateHandler) GetAll(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	templates, err := h.promptTemplateService.GetAll(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch prompt templates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+	})
+}
+
+// Create creates a new prompt template
+func (h *PromptTemplateHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.PromptTemplateCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	template, err := h.promptTemplateService.Create(userID, &req)
+	if err != nil {
+		if isPromptTemplateValidationError(err) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to create prompt template"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"template": template,
+	})
+}
+
+// GetByID returns a single prompt template
+func (h *PromptTemplateHandler) GetByID(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	templateID := c.Param("id")
+
+	template, err := h.promptTemplateService.GetByID(userID, templateID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch prompt template"))
+		return
+	}
+	if template == nil {
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "template not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"template": template,
+	})
+}
+
+// Update updates a prompt template
+func (h *PromptTemplateHandler) Update(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	templateID := c.Param("id")
+
+	var req models.PromptTemplateUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	template, err := h.promptTemplateService.Update(userID, templateID, &req)
+	if err != nil {
+		if isPromptTemplateValidationError(err) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"template": template,
+	})
+}
+
+// Delete deletes a prompt template
+func (h *PromptTemplateHandler) Delete(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	templateID := c.Param("id")
+
+	if err := h.promptTemplateService.Delete(userID, templateID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "prompt template deleted successfully",
+	})
+}
+
+func isPromptTemplateValidationError(err error) bool {
+	return errors.Is(err, services.ErrUnknownPromptTemplate) ||
+		errors.Is(err, services.ErrPromptTooLong) ||
+		errors.Is(err, services.ErrInvalidPromptTemplate)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"log"
 	"net/http"
 
@@ -22,33 +23,30 @@ func NewRAGHandler(ragService *services.RAGService) *RAGHandler {
 func (h *RAGHandler) Search(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
 		return
 	}
 
 	if h.ragService == nil || !h.ragService.IsConfigured() {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "RAG service not configured",
-			"message": "Please configure embedding API settings",
-		})
+		c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, "RAG service not configured").WithDetails("Please configure embedding API settings"))
 		return
 	}
 
 	var req models.SearchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	if req.Query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "query is required"))
 		return
 	}
 
 	resp, err := h.ragService.Search(c.Request.Context(), userID, &req)
 	if err != nil {
 		log.Printf("[RAG Handler] Search error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "search failed"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "search failed"))
 		return
 	}
 
@@ -60,33 +58,30 @@ func (h *RAGHandler) Search(c *gin.Context) {
 func (h *RAGHandler) Ask(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
 		return
 	}
 
 	if h.ragService == nil || !h.ragService.IsConfigured() {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "RAG service not configured",
-			"message": "Please configure embedding API settings",
-		})
+		c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, "RAG service not configured").WithDetails("Please configure embedding API settings"))
 		return
 	}
 
 	var req models.AskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	if req.Question == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "question is required"})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "question is required"))
 		return
 	}
 
 	resp, err := h.ragService.Ask(c.Request.Context(), userID, &req)
 	if err != nil {
 		log.Printf("[RAG Handler] Ask error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to answer question"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to answer question"))
 		return
 	}
 
@@ -98,22 +93,19 @@ func (h *RAGHandler) Ask(c *gin.Context) {
 func (h *RAGHandler) IndexAll(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
 		return
 	}
 
 	if h.ragService == nil || !h.ragService.IsConfigured() {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error":   "RAG service not configured",
-			"message": "Please configure embedding API settings",
-		})
+		c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, "RAG service not configured").WithDetails("Please configure embedding API settings"))
 		return
 	}
 
 	resp, err := h.ragService.IndexAllForUser(c.Request.Context(), userID)
 	if err != nil {
 		log.Printf("[RAG Handler] Index error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "indexing failed"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "indexing failed"))
 		return
 	}
 
@@ -125,14 +117,12 @@ func (h *RAGHandler) IndexAll(c *gin.Context) {
 func (h *RAGHandler) GetStats(c *gin.Context) {
 	userID := c.GetString("userID")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
 		return
 	}
 
 	if h.ragService == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "RAG service not available",
-		})
+		c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, "RAG service not available"))
 		return
 	}
 
@@ -143,3 +133,85 @@ func (h *RAGHandler) GetStats(c *gin.Context) {
 		"stats":      stats,
 	})
 }
+
+// ReindexMemory indexes one memory now, for when its index entry went stale
+// POST /api/memories/:id/reindex
+func (h *RAGHandler) ReindexMemory(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
+		return
+	}
+
+	if h.ragService == nil || !h.ragService.IsConfigured() {
+		c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, "RAG service not configured").WithDetails("Please configure embedding API settings"))
+		return
+	}
+
+	resp, err := h.ragService.ReindexMemory(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrMemoryNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		log.Printf("[RAG Handler] Reindex memory error: %v", err)
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "indexing failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ReindexTodo indexes one todo now, for when its index entry went stale
+// POST /api/todos/:id/reindex
+func (h *RAGHandler) ReindexTodo(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
+		return
+	}
+
+	if h.ragService == nil || !h.ragService.IsConfigured() {
+		c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, "RAG service not configured").WithDetails("Please configure embedding API settings"))
+		return
+	}
+
+	resp, err := h.ragService.ReindexTodo(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrTodoNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		log.Printf("[RAG Handler] Reindex todo error: %v", err)
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "indexing failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetQueue lists the user's todos and memories whose background indexing failed,
+// both those waiting for a retry and those that ran out of retries
+// GET /api/rag/queue
+func (h *RAGHandler) GetQueue(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
+		return
+	}
+
+	if h.ragService == nil {
+		c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, "RAG service not available"))
+		return
+	}
+
+	items, err := h.ragService.GetIndexQueue(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch index queue"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type RSSFeedHandler struct {
+	rssFeedService *services.RSSFeedService
+}
+
+func NewRSSFeedHandler(rssFeedService *services.RSSFeedService) *RSSFeedHandler {
+	return &RSSFeedHandler{
+		rssFeedService: rssFeedService,
+	}
+}
+
+// Import creates memories from a feed's latest items, skipping URLs already saved
+func (h *RSSFeedHandler) Import(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.RSSImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	result, err := h.rssFeedService.Import(userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidFeedURL):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		case errors.Is(err, services.ErrInvalidFeed):
+			c.Error(models.NewAPIError(models.ErrCodeUnprocessable, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeUpstream, "failed to import feed: "+err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetAll returns the user's saved feeds
+func (h *RSSFeedHandler) GetAll(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	feeds, err := h.rssFeedService.GetAll(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch feeds"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"feeds": feeds,
+	})
+}
+
+// Delete stops the background import of a saved feed
+func (h *RSSFeedHandler) Delete(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	feedID := c.Param("id")
+
+	if err := h.rssFeedService.Delete(userID, feedID); err != nil {
+		if errors.Is(err, services.ErrRSSFeedNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to delete feed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "feed deleted successfully",
+	})
+}
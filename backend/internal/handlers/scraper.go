@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type ScraperHandler struct {
+	scraperService *services.ScraperService
+}
+
+func NewScraperHandler(scraperService *services.ScraperService) *ScraperHandler {
+	return &ScraperHandler{
+		scraperService: scraperService,
+	}
+}
+
+// Health returns the per-instance SearXNG health status
+func (h *ScraperHandler) Health(c *gin.Context) {
+	if h.scraperService == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"configured": false,
+			"instances":  []services.SearXNGInstanceStatus{},
+		})
+		return
+	}
+
+	instances := h.scraperService.HealthStatus()
+	healthy := 0
+	for _, instance := range instances {
+		if instance.Healthy {
+			healthy++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"configured": true,
+		"healthy":    healthy,
+		"total":      len(instances),
+		"instances":  instances,
+	})
+}
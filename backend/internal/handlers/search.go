@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type SearchHandler struct {
+	searchService        *services.SearchService
+	searchHistoryService *services.SearchHistoryService
+	ragService           *services.RAGService
+}
+
+func NewSearchHandler(searchService *services.SearchService, searchHistoryService *services.SearchHistoryService, ragService *services.RAGService) *SearchHandler {
+	return &SearchHandler{
+		searchService:        searchService,
+		searchHistoryService: searchHistoryService,
+		ragService:           ragService,
+	}
+}
+
+// Suggest returns title autocomplete suggestions for the search bar
+// Query params: q (prefix), types (comma-separated: todo,memory), limit
+func (h *SearchHandler) Suggest(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "5"))
+
+	var contentTypes []string
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			contentTypes = append(contentTypes, t)
+		}
+	}
+
+	suggestions, err := h.searchService.Suggest(c.Request.Context(), userID, c.Query("q"), contentTypes, limit)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch suggestions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suggestions": suggestions,
+	})
+}
+
+// GetHistory returns the user's recent searches, newest first
+// Query params: limit (default 20, max 100)
+func (h *SearchHandler) GetHistory(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(models.DefaultSearchHistoryLimit)))
+
+	entries, err := h.searchHistoryService.GetHistory(userID, limit)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch search history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": entries,
+	})
+}
+
+// ClearHistory deletes the user's whole search history
+func (h *SearchHandler) ClearHistory(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	deleted, err := h.searchHistoryService.ClearHistory(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to clear search history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted": deleted,
+	})
+}
+
+// GetSaved returns the user's saved searches
+func (h *SearchHandler) GetSaved(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	searches, err := h.searchHistoryService.GetSaved(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch saved searches"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"saved_searches": searches,
+	})
+}
+
+// CreateSaved saves a named search
+func (h *SearchHandler) CreateSaved(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.SavedSearchCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	search, err := h.searchHistoryService.CreateSaved(userID, &req)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to save search"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"saved_search": search,
+	})
+}
+
+// DeleteSaved deletes a saved search
+func (h *SearchHandler) DeleteSaved(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	if err := h.searchHistoryService.DeleteSaved(userID, c.Param("id")); err != nil {
+		respondSavedSearchError(c, err, "failed to delete saved search")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "saved search deleted successfully",
+	})
+}
+
+// RunSaved runs a saved search through the same hybrid search as POST /rag/search
+func (h *SearchHandler) RunSaved(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	search, err := h.searchHistoryService.GetSavedByID(userID, c.Param("id"))
+	if err != nil {
+		respondSavedSearchError(c, err, "failed to run saved search")
+		return
+	}
+
+	if h.ragService == nil || !h.ragService.IsConfigured() {
+		c.Error(models.NewAPIError(models.ErrCodeAIUnavailable, "RAG service not configured").WithDetails("Please configure embedding API settings"))
+		return
+	}
+
+	resp, err := h.ragService.Search(c.Request.Context(), userID, &models.SearchRequest{
+		Query:        search.Query,
+		ContentTypes: search.ContentTypes,
+	})
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "search failed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func respondSavedSearchError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrSavedSearchNotFound):
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+	default:
+		c.Error(models.NewAPIError(models.ErrCodeInternal, fallback))
+	}
+}
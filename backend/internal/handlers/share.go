@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type ShareHandler struct {
+	shareService *services.ShareService
+}
+
+func NewShareHandler(shareService *services.ShareService) *ShareHandler {
+	return &ShareHandler{shareService: shareService}
+}
+
+// Create makes a public share link for a memory
+func (h *ShareHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	var req models.ShareCreateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+	}
+
+	link, err := h.shareService.Create(userID, memoryID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrMemoryNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to create share link"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// Revoke deletes all share links of a memory
+func (h *ShareHandler) Revoke(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	memoryID := c.Param("id")
+
+	revoked, err := h.shareService.Revoke(userID, memoryID)
+	if err != nil {
+		if errors.Is(err, services.ErrMemoryNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to revoke share links"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revoked": revoked,
+	})
+}
+
+// GetShared returns the memory behind a share link (public, no auth)
+func (h *ShareHandler) GetShared(c *gin.Context) {
+	memory, err := h.shareService.GetShared(c.Param("token"))
+	if err != nil {
+		if errors.Is(err, services.ErrShareNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to load shared memory"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"memory": memory,
+	})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type TodoTemplateHandler struct {
+	todoTemplateService *services.TodoTemplateService
+}
+
+func NewTodoTemplateHandler(todoTemplateService *services.TodoTemplateService) *TodoTemplateHandler {
+	return &TodoTemplateHandler{
+		todoTemplateService: todoTemplateService,
+	}
+}
+
+// GetAll returns the system templates and the user's own templates
+func (h *TodoTemplateHandler) GetAll(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	templates, err := h.todoTemplateService.GetAll(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch todo templates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": templates,
+	})
+}
+
+// Create creates a new todo template
+func (h *TodoTemplateHandler) Create(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.TodoTemplateCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	template, err := h.todoTemplateService.Create(userID, &req)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to create todo template"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"template": template,
+	})
+}
+
+// GetByID returns a single todo template
+func (h *TodoTemplateHandler) GetByID(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	templateID := c.Param("id")
+
+	template, err := h.todoTemplateService.GetByID(userID, templateID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch todo template"))
+		return
+	}
+	if template == nil {
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "template not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"template": template,
+	})
+}
+
+// Update updates one of the user's todo templates
+func (h *TodoTemplateHandler) Update(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	templateID := c.Param("id")
+
+	var req models.TodoTemplateUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	template, err := h.todoTemplateService.Update(userID, templateID, &req)
+	if err != nil {
+		respondTodoTemplateError(c, err, "failed to update todo template")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"template": template,
+	})
+}
+
+// Delete deletes one of the user's todo templates
+func (h *TodoTemplateHandler) Delete(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	templateID := c.Param("id")
+
+	if err := h.todoTemplateService.Delete(userID, templateID); err != nil {
+		respondTodoTemplateError(c, err, "failed to delete todo template")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "todo template deleted successfully",
+	})
+}
+
+// Apply creates todos from every task in a template
+func (h *TodoTemplateHandler) Apply(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	templateID := c.Param("id")
+
+	// The body is optional; an empty one applies the template with no overrides
+	var opts models.TemplateApplyOptions
+	if err := c.ShouldBindJSON(&opts); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	todos, err := h.todoTemplateService.Apply(userID, templateID, opts)
+	if err != nil {
+		respondTodoTemplateError(c, err, "failed to apply todo template")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"todos": todos,
+	})
+}
+
+func respondTodoTemplateError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrTodoTemplateNotFound):
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+	case errors.Is(err, services.ErrSystemTemplateImmutable):
+		c.Error(models.NewAPIError(models.ErrCodeForbidden, err.Error()))
+	case errors.Is(err, services.ErrInvalidTemplateStart):
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+	default:
+		c.Error(models.NewAPIError(models.ErrCodeInternal, fallback))
+	}
+}
@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/todomyday/backend/internal/middleware"
@@ -22,9 +27,35 @@ func NewTodoHandler(todoService *services.TodoService) *TodoHandler {
 func (h *TodoHandler) GetAll(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
-	todos, err := h.todoService.GetAll(userID)
+	// With ?after= (empty for the first page), page through all todos newest first
+	if after, ok := c.GetQuery("after"); ok {
+		h.getPage(c, userID, after)
+		return
+	}
+
+	filter := &models.TodoFilterRequest{
+		TagOp:    c.Query("tag_op"),
+		Status:   models.Status(c.Query("status")),
+		Priority: models.Priority(c.Query("priority")),
+		GroupID:  c.Query("group_id"),
+		Sort:     c.Query("sort"),
+		Order:    c.Query("order"),
+		// Todos in archived groups are hidden unless asked for
+		IncludeArchivedGroups: c.Query("include_archived_groups") == "true",
+	}
+	for _, tag := range strings.Split(c.Query("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+
+	todos, err := h.todoService.GetAll(userID, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch todos"})
+		if errors.Is(err, services.ErrInvalidTodoFilter) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch todos"))
 		return
 	}
 
@@ -33,18 +64,46 @@ func (h *TodoHandler) GetAll(c *gin.Context) {
 	})
 }
 
+// getPage returns a cursor page of todos with next_cursor and has_more. Filters and sort
+// keys don't apply to cursor pages, so they are rejected rather than ignored.
+func (h *TodoHandler) getPage(c *gin.Context, userID, after string) {
+	for _, param := range []string{"tags", "tag_op", "status", "priority", "group_id", "sort", "order"} {
+		if c.Query(param) != "" {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, fmt.Sprintf("%s can't be combined with after", param)))
+			return
+		}
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(models.DefaultPageLimit)))
+	page, err := h.todoService.GetPage(userID, after, limit, c.Query("include_archived_groups") == "true")
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch todos"))
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
 func (h *TodoHandler) Create(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	var req models.TodoCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	todo, err := h.todoService.Create(userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create todo"})
+		if errors.Is(err, services.ErrGroupNotLeaf) || errors.Is(err, services.ErrGroupNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to create todo"))
 		return
 	}
 
@@ -59,11 +118,11 @@ func (h *TodoHandler) GetByID(c *gin.Context) {
 
 	todo, err := h.todoService.GetByID(userID, todoID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch todo"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch todo"))
 		return
 	}
 	if todo == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "todo not found"})
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, "todo not found"))
 		return
 	}
 
@@ -78,13 +137,50 @@ func (h *TodoHandler) Update(c *gin.Context) {
 
 	var req models.TodoUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	todo, err := h.todoService.Update(userID, todoID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrGroupNotLeaf) || errors.Is(err, services.ErrGroupNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		if errors.Is(err, services.ErrPriorityAutoManaged) {
+			c.Error(models.NewAPIError(models.ErrCodeConflict, err.Error()))
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"todo": todo,
+	})
+}
+
+// SetEstimate manually overrides a todo's story points and estimated duration
+func (h *TodoHandler) SetEstimate(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	todoID := c.Param("id")
+
+	var req models.TodoEstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	todo, err := h.todoService.SetEstimate(userID, todoID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTodoNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrInvalidStoryPoints):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to update estimate"))
+		}
 		return
 	}
 
@@ -93,12 +189,148 @@ func (h *TodoHandler) Update(c *gin.Context) {
 	})
 }
 
+// GetBlockers lists the todos that must be completed before this one
+func (h *TodoHandler) GetBlockers(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	todoID := c.Param("id")
+
+	todos, err := h.todoService.GetBlockers(userID, todoID)
+	if err != nil {
+		if errors.Is(err, services.ErrTodoNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch blockers"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"todos": todos,
+	})
+}
+
+// GetStreak returns the user's completion streaks and today's completion count
+func (h *TodoHandler) GetStreak(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	streak, err := h.todoService.GetStreak(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch streak"))
+		return
+	}
+
+	c.JSON(http.StatusOK, streak)
+}
+
+// GetStreakCalendar returns per-day completion counts for one month, defaulting to
+// the current one
+func (h *TodoHandler) GetStreakCalendar(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	now := time.Now()
+	year, month := now.Year(), int(now.Month())
+	var err error
+	if value := c.Query("year"); value != "" {
+		if year, err = strconv.Atoi(value); err != nil {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, services.ErrInvalidCalendarMonth.Error()))
+			return
+		}
+	}
+	if value := c.Query("month"); value != "" {
+		if month, err = strconv.Atoi(value); err != nil {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, services.ErrInvalidCalendarMonth.Error()))
+			return
+		}
+	}
+
+	days, err := h.todoService.GetStreakCalendar(userID, year, month)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCalendarMonth) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch streak calendar"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days": days,
+	})
+}
+
+// GetBlocking lists the todos waiting on this one
+func (h *TodoHandler) GetBlocking(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	todoID := c.Param("id")
+
+	todos, err := h.todoService.GetBlocking(userID, todoID)
+	if err != nil {
+		if errors.Is(err, services.ErrTodoNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch blocked todos"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"todos": todos,
+	})
+}
+
+// AddDependency marks this todo as blocked by another
+func (h *TodoHandler) AddDependency(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	todoID := c.Param("id")
+
+	var req models.TodoDependencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	if err := h.todoService.AddDependency(userID, req.BlockerID, todoID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrTodoNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrDependencyCycle), errors.Is(err, services.ErrDependencyTooDeep):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to add dependency"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "dependency added successfully",
+	})
+}
+
+// RemoveDependency unmarks this todo as blocked by another
+func (h *TodoHandler) RemoveDependency(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+	todoID := c.Param("id")
+
+	if err := h.todoService.RemoveDependency(userID, c.Param("blockerID"), todoID); err != nil {
+		if errors.Is(err, services.ErrTodoNotFound) || errors.Is(err, services.ErrDependencyNotFound) {
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to remove dependency"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "dependency removed successfully",
+	})
+}
+
 func (h *TodoHandler) Delete(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 	todoID := c.Param("id")
 
-	if err := h.todoService.Delete(userID, todoID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.todoService.Delete(userID, todoID, c.ClientIP()); err != nil {
+		c.Error(err)
 		return
 	}
 
@@ -107,17 +339,58 @@ func (h *TodoHandler) Delete(c *gin.Context) {
 	})
 }
 
+func (h *TodoHandler) SyncTags(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	if err := h.todoService.SyncGroupTags(userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "todo tags synced successfully",
+	})
+}
+
+// BulkUpdatePriority changes the priority of up to 100 todos in one request
+func (h *TodoHandler) BulkUpdatePriority(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.TodoBulkPriorityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	updated, err := h.todoService.BulkUpdatePriority(userID, req.IDs, req.Priority)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTooManyTodoIDs):
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		case errors.Is(err, services.ErrTodosNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrPriorityAutoManaged):
+			c.Error(models.NewAPIError(models.ErrCodeConflict, err.Error()))
+		default:
+			c.Error(err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
 func (h *TodoHandler) Reorder(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
 	var req models.TodoReorderRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
 		return
 	}
 
 	if err := h.todoService.Reorder(userID, &req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Error(err)
 		return
 	}
 
@@ -125,3 +398,16 @@ func (h *TodoHandler) Reorder(c *gin.Context) {
 		"message": "todos reordered successfully",
 	})
 }
+
+// ResetPositions renumbers the user's todos 1000 apart, keeping their order
+func (h *TodoHandler) ResetPositions(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	updated, err := h.todoService.ResetPositions(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to reset todo positions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": updated})
+}
@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/services"
 )
 
@@ -24,7 +29,7 @@ func (h *UserDataHandler) GetDataStats(c *gin.Context) {
 
 	stats, err := h.userDataService.GetDataStats(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get data stats"})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to get data stats"))
 		return
 	}
 
@@ -37,10 +42,7 @@ func (h *UserDataHandler) ClearMemories(c *gin.Context) {
 
 	result, err := h.userDataService.ClearAllMemories(userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to clear memories",
-			"details": err.Error(),
-		})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to clear memories").WithDetails(err.Error()))
 		return
 	}
 
@@ -51,12 +53,75 @@ func (h *UserDataHandler) ClearMemories(c *gin.Context) {
 func (h *UserDataHandler) ClearAllData(c *gin.Context) {
 	userID := middleware.GetUserID(c)
 
-	result, err := h.userDataService.ClearAllData(userID)
+	result, err := h.userDataService.ClearAllData(userID, c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "failed to clear all data",
-			"details": err.Error(),
-		})
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to clear all data").WithDetails(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteAccount permanently deletes the user's account and all their data
+func (h *UserDataHandler) DeleteAccount(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	if err := h.userDataService.DeleteAccount(userID, req.Password, middleware.GetAuthTime(c)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrAccountDeletionRateLimited):
+			c.Error(models.NewAPIError(models.ErrCodeRateLimited, "account deletion can only be attempted once every 10 minutes"))
+		case errors.Is(err, services.ErrInvalidPassword):
+			c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "invalid password"))
+		case errors.Is(err, services.ErrReauthenticationRequired):
+			c.Error(models.NewAPIError(models.ErrCodeUnauthorized, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to delete account").WithDetails(err.Error()))
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ExportJSON streams the user's full dataset as a JSON document
+func (h *UserDataHandler) ExportJSON(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="mrbrain-export-%s.json"`, time.Now().UTC().Format("2006-01-02")))
+
+	if err := h.userDataService.ExportJSON(userID, c.Writer); err != nil {
+		log.Printf("[UserDataHandler] JSON export failed for user %s: %v", userID, err)
+		// Once streaming has started the status is already sent; just cut the response short
+		if !c.Writer.Written() {
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to export data"))
+		}
+	}
+}
+
+// ImportJSON creates records from a JSON export, skipping IDs that already exist
+func (h *UserDataHandler) ImportJSON(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var data models.DataExport
+	if err := c.ShouldBindJSON(&data); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	result, err := h.userDataService.ImportJSON(userID, &data)
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedExportVersion) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to import data").WithDetails(err.Error()))
 		return
 	}
 
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/middleware"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type UserPreferencesHandler struct {
+	preferencesService *services.UserPreferencesService
+}
+
+func NewUserPreferencesHandler(preferencesService *services.UserPreferencesService) *UserPreferencesHandler {
+	return &UserPreferencesHandler{
+		preferencesService: preferencesService,
+	}
+}
+
+// GetMemorySort returns the order the user's memory list is sorted in
+func (h *UserPreferencesHandler) GetMemorySort(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"sort_mode": h.preferencesService.GetSortMode(userID),
+	})
+}
+
+// UpdateMemorySort changes the order the user's memory list is sorted in
+func (h *UserPreferencesHandler) UpdateMemorySort(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.MemorySortRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	if err := h.preferencesService.SetSortMode(userID, req.SortMode); err != nil {
+		if errors.Is(err, services.ErrInvalidSortMode) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to update memory sort mode"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sort_mode": req.SortMode,
+	})
+}
+
+// GetDailyGoal returns how many todos a day the user aims to complete
+func (h *UserPreferencesHandler) GetDailyGoal(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"daily_goal": h.preferencesService.GetDailyGoal(userID),
+	})
+}
+
+// UpdateDailyGoal changes how many todos a day the user aims to complete
+func (h *UserPreferencesHandler) UpdateDailyGoal(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.DailyGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	if err := h.preferencesService.SetDailyGoal(userID, req.DailyGoal); err != nil {
+		if errors.Is(err, services.ErrInvalidDailyGoal) {
+			c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+			return
+		}
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to update daily goal"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"daily_goal": req.DailyGoal,
+	})
+}
+
+// GetPreferences returns the user's AI processing switches
+func (h *UserPreferencesHandler) GetPreferences(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	preferences, err := h.preferencesService.GetPreferences(userID)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// UpdatePreferences changes the preferences present in the body, leaving the rest
+func (h *UserPreferencesHandler) UpdatePreferences(c *gin.Context) {
+	userID := middleware.GetUserID(c)
+
+	var req models.UserPreferencesUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	preferences, err := h.preferencesService.UpdatePreferences(userID, &req)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to update preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
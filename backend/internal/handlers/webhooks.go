@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookIngestionService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookIngestionService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// ReceiveInbound creates a todo or memory from a webhook whose signature
+// middleware.ValidateWebhookSignature has checked
+func (h *WebhookHandler) ReceiveInbound(c *gin.Context) {
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, "failed to read webhook body"))
+		return
+	}
+
+	result, err := h.webhookService.Process(c.Param("source"), payload)
+	if err != nil {
+		var blocked *models.ContentBlockedError
+		switch {
+		case errors.As(err, &blocked):
+			c.Error(blocked)
+		case errors.Is(err, services.ErrWebhookSourceNotFound):
+			c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+		case errors.Is(err, services.ErrInvalidWebhookPayload):
+			c.Error(models.NewAPIError(models.ErrCodeUnprocessable, err.Error()))
+		default:
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to process webhook"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetSources returns every inbound webhook source (admin)
+func (h *WebhookHandler) GetSources(c *gin.Context) {
+	sources, err := h.webhookService.GetAll()
+	if err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to fetch webhook sources"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sources": sources,
+	})
+}
+
+// CreateSource adds an inbound webhook source (admin)
+func (h *WebhookHandler) CreateSource(c *gin.Context) {
+	var req models.InboundWebhookSourceCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	source, err := h.webhookService.Create(&req)
+	if err != nil {
+		h.sourceError(c, err, "failed to create webhook source")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"source": source,
+	})
+}
+
+// UpdateSource changes an inbound webhook source's user, secret, rules or enabled flag (admin)
+func (h *WebhookHandler) UpdateSource(c *gin.Context) {
+	var req models.InboundWebhookSourceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+		return
+	}
+
+	source, err := h.webhookService.Update(c.Param("id"), &req)
+	if err != nil {
+		h.sourceError(c, err, "failed to update webhook source")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"source": source,
+	})
+}
+
+// DeleteSource removes an inbound webhook source (admin)
+func (h *WebhookHandler) DeleteSource(c *gin.Context) {
+	if err := h.webhookService.Delete(c.Param("id")); err != nil {
+		h.sourceError(c, err, "failed to delete webhook source")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "webhook source deleted successfully",
+	})
+}
+
+func (h *WebhookHandler) sourceError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWebhookSourceNotFound):
+		c.Error(models.NewAPIError(models.ErrCodeNotFound, err.Error()))
+	case errors.Is(err, services.ErrWebhookSourceExists):
+		c.Error(models.NewAPIError(models.ErrCodeDuplicate, err.Error()))
+	case errors.Is(err, services.ErrWebhookSourceUserAbsent):
+		c.Error(models.NewAPIError(models.ErrCodeValidation, err.Error()))
+	default:
+		c.Error(models.NewAPIError(models.ErrCodeInternal, message))
+	}
+}
@@ -0,0 +1,124 @@
+// Package logging configures the process-wide slog logger and enriches records
+// with the trace and user the request context carries.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Output formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Attribute keys added from the context
+const (
+	KeyTraceID = "trace_id"
+	KeySpanID  = "span_id"
+	KeyUserID  = "user_id"
+)
+
+// Config selects the minimum level and output format
+type Config struct {
+	// Level is LOG_LEVEL: debug, info, warn or error (default info)
+	Level string
+	// Format is LOG_FORMAT: text or json (default json)
+	Format string
+}
+
+// ParseLevel parses a LOG_LEVEL value; empty means info
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q (expected debug, info, warn or error)", level)
+}
+
+// NewHandler returns a handler writing to w in the configured format, wrapped in
+// a ContextHandler
+func NewHandler(w io.Writer, cfg Config) (slog.Handler, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch strings.ToLower(strings.TrimSpace(cfg.Format)) {
+	case "", FormatJSON:
+		return ContextHandler{slog.NewJSONHandler(w, opts)}, nil
+	case FormatText:
+		return ContextHandler{slog.NewTextHandler(w, opts)}, nil
+	}
+	return nil, fmt.Errorf("invalid log format %q (expected text or json)", cfg.Format)
+}
+
+// Init installs the default logger, writing to stderr. Output from the standard
+// log package is routed through it too, at info level.
+func Init(cfg Config) error {
+	handler, err := NewHandler(os.Stderr, cfg)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// ContextHandler adds the trace and span IDs of the context's span, and the user
+// set by WithUserID, to every record logged with a context
+type ContextHandler struct {
+	slog.Handler
+}
+
+func (h ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			r.AddAttrs(
+				slog.String(KeyTraceID, spanContext.TraceID().String()),
+				slog.String(KeySpanID, spanContext.SpanID().String()),
+			)
+		}
+		if userID := UserID(ctx); userID != "" {
+			r.AddAttrs(slog.String(KeyUserID, userID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return ContextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h ContextHandler) WithGroup(name string) slog.Handler {
+	return ContextHandler{h.Handler.WithGroup(name)}
+}
+
+type userIDKey struct{}
+
+// WithUserID returns a context whose log records carry userID
+func WithUserID(ctx context.Context, userID string) context.Context {
+	if userID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserID returns the user set by WithUserID, or ""
+func UserID(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey{}).(string)
+	return userID
+}
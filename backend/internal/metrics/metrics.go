@@ -0,0 +1,149 @@
+// Package metrics defines the Prometheus collectors exported on the metrics port.
+package metrics
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name
+const namespace = "mrbrain"
+
+// AI request statuses used as the status label
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+// Cache resources used as the resource label
+const (
+	CacheBlocklist    = "blocklist"
+	CacheBriefing     = "briefing"
+	CacheEmbedding    = "embedding"
+	CacheIPAllowlist  = "ip_allowlist"
+	CacheKeywords     = "keywords"
+	CachePreferences  = "preferences"
+	CachePreview      = "preview"
+	CacheSessions     = "sessions"
+	CacheSuggest      = "suggest"
+	CacheThreadTitles = "thread_titles"
+	CacheTodos        = "todos"
+)
+
+// AI response kinds used as the response label
+const (
+	AIResponseTodo       = "todo"
+	AIResponseMemory     = "memory"
+	AIResponseMemoryTool = "memory_tool"
+	AIResponseEffort     = "effort"
+)
+
+// RAG search types used as the type label
+const (
+	SearchHybrid  = "hybrid"
+	SearchVector  = "vector"
+	SearchKeyword = "keyword"
+)
+
+// Registry holds every collector below. It's separate from the default registry
+// so only our metrics (plus Go and process stats) are exported.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	// AIRequestsTotal counts AI provider calls by provider, model and outcome
+	AIRequestsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_requests_total",
+		Help:      "AI provider requests by provider, model and status.",
+	}, []string{"provider", "model", "status"})
+
+	// AIRequestDuration observes AI provider call latency, including rate limit waits
+	AIRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ai_request_duration_seconds",
+		Help:      "AI provider request latency in seconds.",
+		Buckets:   []float64{0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+	}, []string{"provider"})
+
+	// AIInvalidResponseTotal counts AI responses that failed schema validation, by response kind
+	AIInvalidResponseTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_invalid_response_total",
+		Help:      "AI responses that failed JSON schema validation by response kind.",
+	}, []string{"response"})
+
+	// MemoriesCreatedTotal counts stored memories by category
+	MemoriesCreatedTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "memories_created_total",
+		Help:      "Memories created by category.",
+	}, []string{"category"})
+
+	// TodosCreatedTotal counts stored todos
+	TodosCreatedTotal = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "todos_created_total",
+		Help:      "Todos created.",
+	})
+
+	// CacheHitsTotal counts cache hits by resource
+	CacheHitsTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_hits_total",
+		Help:      "Cache hits by resource.",
+	}, []string{"resource"})
+
+	// CacheMissesTotal counts cache misses by resource
+	CacheMissesTotal = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_misses_total",
+		Help:      "Cache misses by resource.",
+	}, []string{"resource"})
+
+	// RAGSearchDuration observes RAG search latency for the hybrid search and each of its legs
+	RAGSearchDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rag_search_duration_seconds",
+		Help:      "RAG search latency in seconds by search type.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"type"})
+
+	// VectorDocuments reports how many documents the vector index holds, read
+	// from the source set by SetVectorDocumentsSource at scrape time
+	VectorDocuments = factory.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "vector_documents_total",
+		Help:      "Documents in the vector index across all collections.",
+	}, func() float64 {
+		if count := vectorDocumentsSource.Load(); count != nil {
+			return float64((*count)())
+		}
+		return 0
+	})
+)
+
+var vectorDocumentsSource atomic.Pointer[func() int]
+
+// SetVectorDocumentsSource sets the function VectorDocuments reads the document count from
+func SetVectorDocumentsSource(count func() int) {
+	vectorDocumentsSource.Store(&count)
+}
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
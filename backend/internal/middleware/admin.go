@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/models"
+)
+
+// AdminSecretHeader carries the ADMIN_SECRET for admin routes
+const AdminSecretHeader = "X-Admin-Secret"
+
+// AdminSecretMiddleware guards admin routes with a shared secret. With no secret
+// configured the routes are disabled entirely.
+func AdminSecretMiddleware(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if secret == "" {
+			c.Error(models.NewAPIError(models.ErrCodeForbidden, "admin endpoints are disabled"))
+			c.Abort()
+			return
+		}
+
+		provided := c.GetHeader(AdminSecretHeader)
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(secret)) != 1 {
+			log.Printf("[Admin] Rejected request to %s from %s", c.FullPath(), c.ClientIP())
+			c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
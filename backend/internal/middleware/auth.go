@@ -1,11 +1,14 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
-	"net/http"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/services"
 )
 
@@ -16,14 +19,21 @@ func min(a, b int) int {
 	return b
 }
 
-const UserIDKey = "userID"
+const (
+	UserIDKey    = "userID"
+	SessionIDKey = "sessionID"
+	// AuthTimeKey holds when the user of an app token authenticated at the OIDC provider
+	AuthTimeKey = "authTime"
+)
 
-func AuthMiddleware(supabaseAuthService *services.SupabaseAuthService) gin.HandlerFunc {
+// AuthMiddleware accepts app tokens issued after OIDC sign-in or for impersonation (when
+// authService isn't nil) and Supabase tokens
+func AuthMiddleware(supabaseAuthService *services.SupabaseAuthService, authService *services.AuthService, sessionService *services.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "unauthorized"))
 			c.Abort()
 			return
 		}
@@ -31,19 +41,58 @@ func AuthMiddleware(supabaseAuthService *services.SupabaseAuthService) gin.Handl
 		// Check for Bearer token
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+			c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "invalid authorization header"))
 			c.Abort()
 			return
 		}
 		tokenString := parts[1]
 
+		// App tokens are tried first; anything else must be a Supabase token
+		if authService != nil {
+			if appClaims, err := authService.ValidateToken(tokenString); err == nil {
+				user, err := authService.GetCurrentUser(appClaims.UserID)
+				if err != nil {
+					c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to load user"))
+					c.Abort()
+					return
+				}
+				// Deleted accounts keep no row, so their tokens stop working here
+				if user == nil {
+					c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "invalid or expired token"))
+					c.Abort()
+					return
+				}
+				if !touchSession(c, sessionService, user.ID, appClaims.SessionID) {
+					return
+				}
+				c.Set(UserIDKey, user.ID)
+				c.Set(SessionIDKey, appClaims.SessionID)
+				if appClaims.AuthTime != nil {
+					c.Set(AuthTimeKey, appClaims.AuthTime.Time)
+				}
+				if appClaims.ImpersonatedBy != "" {
+					c.Set(ImpersonatedByKey, appClaims.ImpersonatedBy)
+					c.Set(ImpersonationReasonKey, appClaims.ImpersonationReason)
+				}
+				c.Next()
+				return
+			}
+		}
+
 		// Verify Supabase JWT token
 		claims, err := supabaseAuthService.VerifyToken(tokenString)
 		if err != nil {
 			// Log the error for debugging
 			fmt.Printf("DEBUG: Token verification failed: %v\n", err)
 			fmt.Printf("DEBUG: Token (first 50 chars): %s\n", tokenString[:min(50, len(tokenString))])
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token", "details": err.Error()})
+			c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "invalid or expired token").WithDetails(err.Error()))
+			c.Abort()
+			return
+		}
+
+		// Reject tokens belonging to deleted accounts
+		if supabaseAuthService.IsSubjectRevoked(claims.Sub) {
+			c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "invalid or expired token"))
 			c.Abort()
 			return
 		}
@@ -53,17 +102,36 @@ func AuthMiddleware(supabaseAuthService *services.SupabaseAuthService) gin.Handl
 		if err != nil {
 			fmt.Printf("ERROR: Failed to sync user from token: %v\n", err)
 			fmt.Printf("ERROR: Claims - Sub: %s, Email: %s\n", claims.Sub, claims.Email)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sync user", "details": err.Error()})
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to sync user").WithDetails(err.Error()))
 			c.Abort()
 			return
 		}
 
+		if !touchSession(c, sessionService, user.ID, claims.SessionID) {
+			return
+		}
+
 		// Set user ID (local DB ID) in context for downstream handlers
 		c.Set(UserIDKey, user.ID)
+		c.Set(SessionIDKey, claims.SessionID)
 		c.Next()
 	}
 }
 
+// touchSession tracks the session, aborting with 401 if the user has revoked it
+func touchSession(c *gin.Context, sessionService *services.SessionService, userID, sessionID string) bool {
+	if err := sessionService.Touch(userID, sessionID, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		if errors.Is(err, services.ErrSessionRevoked) {
+			c.Error(models.NewAPIError(models.ErrCodeUnauthorized, "session revoked"))
+			c.Abort()
+			return false
+		}
+		// Tracking is best effort; don't lock users out over it
+		log.Printf("[Auth] Failed to record session %s for user %s: %v", sessionID, userID, err)
+	}
+	return true
+}
+
 func GetUserID(c *gin.Context) string {
 	userID, exists := c.Get(UserIDKey)
 	if !exists {
@@ -71,3 +139,14 @@ func GetUserID(c *gin.Context) string {
 	}
 	return userID.(string)
 }
+
+// GetAuthTime returns when the user authenticated at the OIDC provider, or the zero
+// time for tokens that don't say
+func GetAuthTime(c *gin.Context) time.Time {
+	return c.GetTime(AuthTimeKey)
+}
+
+// GetSessionID returns the Supabase session ID of the request's token, or "" if it has none
+func GetSessionID(c *gin.Context) string {
+	return c.GetString(SessionIDKey)
+}
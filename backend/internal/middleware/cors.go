@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"log"
+	"slices"
+	"sync"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// DynamicCORS applies CORS with an allowed origin list that can be replaced at
+// runtime. Each change rebuilds the cors handler, which is swapped in under a lock
+// so every request sees either the old list or the new one.
+type DynamicCORS struct {
+	mu      sync.RWMutex
+	origins []string
+	handler gin.HandlerFunc
+}
+
+// NewDynamicCORS builds the CORS middleware for the initial allowed origins
+func NewDynamicCORS(origins []string) (*DynamicCORS, error) {
+	d := &DynamicCORS{}
+	if err := d.SetOrigins(origins); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func corsConfig(origins []string) cors.Config {
+	return cors.Config{
+		AllowOrigins:     origins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		ExposeHeaders:    []string{"Content-Length"},
+		AllowCredentials: true,
+	}
+}
+
+// SetOrigins replaces the allowed origins. An invalid list is rejected and the
+// current one kept; an unchanged list is a no-op.
+func (d *DynamicCORS) SetOrigins(origins []string) error {
+	config := corsConfig(origins)
+	// cors.New panics on an invalid config, so check it first
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.handler != nil && slices.Equal(d.origins, origins) {
+		return nil
+	}
+	d.origins = slices.Clone(origins)
+	d.handler = cors.New(config)
+	log.Printf("[CORS] Allowed origins: %v", origins)
+	return nil
+}
+
+// Origins returns the allowed origins currently in effect
+func (d *DynamicCORS) Origins() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return slices.Clone(d.origins)
+}
+
+// Handler returns the middleware; it always applies the latest origin list
+func (d *DynamicCORS) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d.mu.RLock()
+		handler := d.handler
+		d.mu.RUnlock()
+		handler(c)
+	}
+}
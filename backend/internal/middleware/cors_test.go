@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDynamicCORSSetOrigins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	corsMiddleware, err := NewDynamicCORS([]string{"https://app.example.com"})
+	if err != nil {
+		t.Fatalf("NewDynamicCORS: %v", err)
+	}
+	r := gin.New()
+	r.Use(corsMiddleware.Handler())
+	r.POST("/api/todos", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	// preflight sends a CORS preflight for origin and reports whether it was allowed
+	preflight := func(origin string) bool {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodOptions, server.URL+"/api/todos", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "Content-Type, Authorization")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		allowed := resp.Header.Get("Access-Control-Allow-Origin") == origin
+		if allowed != (resp.StatusCode < 300) {
+			t.Fatalf("preflight from %s: status %d with allowed origin %q", origin, resp.StatusCode, resp.Header.Get("Access-Control-Allow-Origin"))
+		}
+		return allowed
+	}
+
+	const newOrigin = "https://new.example.com"
+	if !preflight("https://app.example.com") {
+		t.Error("configured origin rejected")
+	}
+	if preflight(newOrigin) {
+		t.Fatal("origin accepted before it was added")
+	}
+
+	if err := corsMiddleware.SetOrigins([]string{"https://app.example.com", newOrigin}); err != nil {
+		t.Fatalf("SetOrigins: %v", err)
+	}
+	if !preflight(newOrigin) {
+		t.Error("added origin rejected right after SetOrigins")
+	}
+
+	// An invalid list is rejected and the current one kept
+	if err := corsMiddleware.SetOrigins([]string{"not an origin"}); err == nil {
+		t.Error("SetOrigins accepted an invalid origin")
+	}
+	if !preflight(newOrigin) {
+		t.Error("added origin rejected after an invalid SetOrigins")
+	}
+
+	if err := corsMiddleware.SetOrigins([]string{"https://app.example.com"}); err != nil {
+		t.Fatalf("SetOrigins: %v", err)
+	}
+	if preflight(newOrigin) {
+		t.Error("origin still accepted after it was removed")
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/models"
+)
+
+// errorStatuses is the HTTP status of each APIError code
+var errorStatuses = map[string]int{
+	models.ErrCodeValidation:    http.StatusBadRequest,
+	models.ErrCodeUnauthorized:  http.StatusUnauthorized,
+	models.ErrCodeForbidden:     http.StatusForbidden,
+	models.ErrCodeNotFound:      http.StatusNotFound,
+	models.ErrCodeConflict:      http.StatusConflict,
+	models.ErrCodeDuplicate:     http.StatusConflict,
+	models.ErrCodeUnprocessable: http.StatusUnprocessableEntity,
+	models.ErrCodeRateLimited:   http.StatusTooManyRequests,
+	models.ErrCodeInternal:      http.StatusInternalServerError,
+	models.ErrCodeUpstream:      http.StatusBadGateway,
+	models.ErrCodeAIUnavailable: http.StatusServiceUnavailable,
+	models.ErrCodeUnavailable:   http.StatusServiceUnavailable,
+}
+
+// ErrorStatus returns the HTTP status of an error code, 500 for unknown ones
+func ErrorStatus(code string) int {
+	if status, ok := errorStatuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// internalErrorMessage is sent for errors that don't say how to send them, whose
+// messages can hold SQL, file paths or provider responses
+const internalErrorMessage = "internal error"
+
+// ToAPIError returns what is sent for err: the APIError of the first APIErrorer in
+// its chain, or a generic ErrCodeInternal error
+func ToAPIError(err error) models.APIError {
+	var apiErr models.APIErrorer
+	if errors.As(err, &apiErr) {
+		return apiErr.APIError()
+	}
+	return models.APIError{Code: models.ErrCodeInternal, Message: internalErrorMessage}
+}
+
+// ErrorHandler sends the last error recorded with c.Error by a handler or middleware
+// as an APIError, with the status of its code. Errors sent as a generic internal
+// error are logged. Nothing is sent if the response has already been written, such
+// as a stream that failed part way.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		var apiErrorer models.APIErrorer
+		if !errors.As(err, &apiErrorer) {
+			log.Printf("[ErrorHandler] %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+		apiErr := ToAPIError(err)
+		c.JSON(ErrorStatus(apiErr.Code), apiErr)
+	}
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		code string
+		want int
+	}{
+		{models.ErrCodeValidation, http.StatusBadRequest},
+		{models.ErrCodeUnauthorized, http.StatusUnauthorized},
+		{models.ErrCodeForbidden, http.StatusForbidden},
+		{models.ErrCodeNotFound, http.StatusNotFound},
+		{models.ErrCodeConflict, http.StatusConflict},
+		{models.ErrCodeDuplicate, http.StatusConflict},
+		{models.ErrCodeUnprocessable, http.StatusUnprocessableEntity},
+		{models.ErrCodeRateLimited, http.StatusTooManyRequests},
+		{models.ErrCodeInternal, http.StatusInternalServerError},
+		{models.ErrCodeUpstream, http.StatusBadGateway},
+		{models.ErrCodeAIUnavailable, http.StatusServiceUnavailable},
+		{models.ErrCodeUnavailable, http.StatusServiceUnavailable},
+		{"ERR_SOMETHING_NEW", http.StatusInternalServerError},
+		{"", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := ErrorStatus(tt.code); got != tt.want {
+				t.Errorf("ErrorStatus(%q) = %d, want %d", tt.code, got, tt.want)
+			}
+		})
+	}
+
+	// Every code in the map is covered above, so a new code can't go untested
+	for code := range errorStatuses {
+		found := false
+		for _, tt := range tests {
+			found = found || tt.code == code
+		}
+		if !found {
+			t.Errorf("error code %s has no test case", code)
+		}
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody models.APIError
+	}{
+		{
+			name:     "api error",
+			err:      models.NewAPIError(models.ErrCodeNotFound, "memory not found"),
+			wantCode: http.StatusNotFound,
+			wantBody: models.APIError{Code: models.ErrCodeNotFound, Message: "memory not found"},
+		},
+		{
+			name:     "wrapped api error",
+			err:      fmt.Errorf("loading memory: %w", models.NewAPIError(models.ErrCodeRateLimited, "slow down")),
+			wantCode: http.StatusTooManyRequests,
+			wantBody: models.APIError{Code: models.ErrCodeRateLimited, Message: "slow down"},
+		},
+		{
+			name:     "plain error",
+			err:      errors.New("database is locked"),
+			wantCode: http.StatusInternalServerError,
+			wantBody: models.APIError{Code: models.ErrCodeInternal, Message: "internal error"},
+		},
+		{
+			name:     "service error",
+			err:      fmt.Errorf("loading memory: %w", services.ErrMemoryNotFound),
+			wantCode: http.StatusNotFound,
+			wantBody: models.APIError{Code: models.ErrCodeNotFound, Message: "memory not found"},
+		},
+		{
+			name:     "wrapped plain error",
+			err:      fmt.Errorf("failed to load thread: %w", errors.New("no such table: chat_threads")),
+			wantCode: http.StatusInternalServerError,
+			wantBody: models.APIError{Code: models.ErrCodeInternal, Message: "internal error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(ErrorHandler())
+			r.GET("/", func(c *gin.Context) { c.Error(tt.err) })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			var body models.APIError
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid body %s: %v", w.Body.String(), err)
+			}
+			if body.Code != tt.wantBody.Code || body.Message != tt.wantBody.Message {
+				t.Errorf("body = %+v, want %+v", body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("response already written", func(t *testing.T) {
+		r := gin.New()
+		r.Use(ErrorHandler())
+		r.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, "partial")
+			c.Error(errors.New("stream failed"))
+		})
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK || w.Body.String() != "partial" {
+			t.Errorf("response = %d %q, want the handler's 200 \"partial\"", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+// AdminReasonHeader carries why an admin is impersonating a user
+const AdminReasonHeader = "X-Admin-Reason"
+
+const (
+	ImpersonatedByKey      = "impersonatedBy"
+	ImpersonationReasonKey = "impersonationReason"
+)
+
+// IsImpersonated reports whether the request was made with an impersonation token
+func IsImpersonated(c *gin.Context) bool {
+	return c.GetString(ImpersonatedByKey) != ""
+}
+
+// impersonationForbidden reports whether an impersonation token is refused for the
+// request: admin routes, MFA settings and deleting the account stay with the real user
+func impersonationForbidden(method, path string) bool {
+	switch {
+	case path == "/api/admin" || strings.HasPrefix(path, "/api/admin/"):
+		return true
+	case path == "/api/auth/mfa" || strings.HasPrefix(path, "/api/auth/mfa/"):
+		return true
+	case method == http.MethodDelete && path == "/api/auth/account":
+		return true
+	}
+	return false
+}
+
+// requestPath is the matched route, or the raw path when no route matched
+func requestPath(c *gin.Context) string {
+	if path := c.FullPath(); path != "" {
+		return path
+	}
+	return c.Request.URL.Path
+}
+
+// ImpersonationMiddleware audits every request made with an impersonation token and
+// refuses the routes it can't be used for. It must run after AuthMiddleware.
+func ImpersonationMiddleware(impersonationService *services.ImpersonationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsImpersonated(c) {
+			c.Next()
+			return
+		}
+
+		path := requestPath(c)
+		blocked := impersonationForbidden(c.Request.Method, path)
+		impersonationService.LogRequest(GetUserID(c), c.GetString(ImpersonatedByKey), c.GetString(ImpersonationReasonKey), c.Request.Method, path, c.ClientIP(), blocked)
+		if blocked {
+			log.Printf("[Impersonation] Refused %s %s for user %s", c.Request.Method, path, GetUserID(c))
+			c.Error(models.NewAPIError(models.ErrCodeForbidden, "not allowed while impersonating"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RejectImpersonationMiddleware refuses requests bearing an impersonation token on
+// routes that don't run AuthMiddleware, such as the admin API
+func RejectImpersonationMiddleware(impersonationService *services.ImpersonationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok {
+			c.Next()
+			return
+		}
+		claims, impersonated := impersonationService.ParseToken(token)
+		if !impersonated {
+			c.Next()
+			return
+		}
+
+		path := requestPath(c)
+		impersonationService.LogRequest(claims.UserID, claims.ImpersonatedBy, claims.ImpersonationReason, c.Request.Method, path, c.ClientIP(), true)
+		log.Printf("[Impersonation] Refused %s %s for user %s", c.Request.Method, path, claims.UserID)
+		c.Error(models.NewAPIError(models.ErrCodeForbidden, "not allowed while impersonating"))
+		c.Abort()
+	}
+}
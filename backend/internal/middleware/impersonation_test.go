@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestImpersonationForbidden(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{http.MethodGet, "/api/memories", false},
+		{http.MethodPost, "/api/memories", false},
+		{http.MethodGet, "/api/auth/me", false},
+		{http.MethodPatch, "/api/auth/me", false},
+		{http.MethodDelete, "/api/auth/sessions", false},
+		{http.MethodGet, "/api/admin", true},
+		{http.MethodPost, "/api/admin/impersonate", true},
+		{http.MethodGet, "/api/admin/backup", true},
+		{http.MethodGet, "/api/administrators", false},
+		{http.MethodGet, "/api/auth/mfa", true},
+		{http.MethodPost, "/api/auth/mfa/disable", true},
+		{http.MethodGet, "/api/auth/mfa-status", false},
+		{http.MethodDelete, "/api/auth/account", true},
+		{http.MethodGet, "/api/auth/account", false},
+		{http.MethodDelete, "/api/auth/account/export", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if got := impersonationForbidden(tt.method, tt.path); got != tt.want {
+				t.Errorf("impersonationForbidden(%s, %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImpersonationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(impersonatedBy string) *gin.Engine {
+		r := gin.New()
+		r.Use(ErrorHandler())
+		r.Use(func(c *gin.Context) {
+			c.Set(UserIDKey, "user-1")
+			if impersonatedBy != "" {
+				c.Set(ImpersonatedByKey, impersonatedBy)
+			}
+			c.Next()
+		})
+		r.Use(ImpersonationMiddleware(nil))
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		r.GET("/api/memories", ok)
+		r.GET("/api/auth/me", ok)
+		r.DELETE("/api/auth/account", ok)
+		r.POST("/api/auth/mfa/disable", ok)
+		return r
+	}
+
+	tests := []struct {
+		name           string
+		impersonatedBy string
+		method         string
+		path           string
+		want           int
+	}{
+		{"impersonated read", "admin", http.MethodGet, "/api/memories", http.StatusOK},
+		{"impersonated profile", "admin", http.MethodGet, "/api/auth/me", http.StatusOK},
+		{"impersonated account deletion", "admin", http.MethodDelete, "/api/auth/account", http.StatusForbidden},
+		{"impersonated mfa change", "admin", http.MethodPost, "/api/auth/mfa/disable", http.StatusForbidden},
+		{"impersonated unmatched admin path", "admin", http.MethodGet, "/api/admin/backup", http.StatusForbidden},
+		{"real user account deletion", "", http.MethodDelete, "/api/auth/account", http.StatusOK},
+		{"real user mfa change", "", http.MethodPost, "/api/auth/mfa/disable", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newRouter(tt.impersonatedBy).ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d (body %s)", tt.method, tt.path, w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/services"
+)
+
+// IPAllowlistMiddleware rejects requests from IPs outside the authenticated user's
+// allowlist. It must run after AuthMiddleware, which sets the user ID.
+func IPAllowlistMiddleware(ipAllowlistService *services.IPAllowlistService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := GetUserID(c)
+		if userID == "" {
+			c.Next()
+			return
+		}
+
+		allowed, err := ipAllowlistService.IsAllowed(userID, c.ClientIP())
+		if err != nil {
+			log.Printf("[IPAllowlist] Failed to load allowlist for user %s: %v", userID, err)
+			c.Error(models.NewAPIError(models.ErrCodeInternal, "failed to check ip allowlist"))
+			c.Abort()
+			return
+		}
+		if !allowed {
+			log.Printf("[IPAllowlist] Rejected request from %s for user %s", c.ClientIP(), userID)
+			c.Error(models.NewAPIError(models.ErrCodeForbidden, "ip_not_allowed"))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
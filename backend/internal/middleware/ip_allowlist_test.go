@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/database"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+	"github.com/todomyday/backend/internal/services"
+)
+
+func TestIPAllowlistMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.Connect(filepath.Join(t.TempDir(), "test.db"), database.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	user := &models.User{Email: "allowlist@example.com"}
+	if err := repository.NewUserRepository(db).Create(user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	repo := repository.NewIPAllowlistRepository(db)
+	if err := repo.Create(&models.IPAllowlistEntry{UserID: user.ID, CIDR: "10.0.0.0/8"}); err != nil {
+		t.Fatalf("failed to create allowlist entry: %v", err)
+	}
+	ipAllowlistService := services.NewIPAllowlistService(repo, nil)
+
+	newRouter := func(trustedProxies []string) *gin.Engine {
+		r := gin.New()
+		if err := r.SetTrustedProxies(trustedProxies); err != nil {
+			t.Fatal(err)
+		}
+		r.Use(ErrorHandler())
+		r.Use(func(c *gin.Context) {
+			c.Set(UserIDKey, user.ID)
+			c.Next()
+		})
+		r.Use(IPAllowlistMiddleware(ipAllowlistService))
+		r.GET("/api/memories", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return r
+	}
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		forwardedFor   string
+		want           int
+	}{
+		{"allowed peer", nil, "10.1.2.3:4000", "", http.StatusOK},
+		{"disallowed peer", nil, "203.0.113.9:4000", "", http.StatusForbidden},
+		{"spoofed header from disallowed peer", nil, "203.0.113.9:4000", "10.0.0.1", http.StatusForbidden},
+		{"header from untrusted proxy", []string{"192.0.2.1"}, "203.0.113.9:4000", "10.0.0.1", http.StatusForbidden},
+		{"allowed client behind trusted proxy", []string{"192.0.2.0/24"}, "192.0.2.1:4000", "10.0.0.1", http.StatusOK},
+		{"disallowed client behind trusted proxy", []string{"192.0.2.0/24"}, "192.0.2.1:4000", "203.0.113.9", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/memories", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			w := httptest.NewRecorder()
+			newRouter(tt.trustedProxies).ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
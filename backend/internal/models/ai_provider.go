@@ -9,8 +9,44 @@ const (
 	ProviderTypeAnthropic ProviderType = "anthropic"
 	ProviderTypeGoogle    ProviderType = "google"
 	ProviderTypeCustom    ProviderType = "custom"
+	// ProviderTypeAssistant routes prompts through an OpenAI Assistant; SelectedModel holds the assistant ID
+	ProviderTypeAssistant ProviderType = "assistant"
+	// ProviderTypeOllama is a local Ollama server; chat goes through its OpenAI-compatible
+	// API, while model listing and embeddings use its native /api endpoints
+	ProviderTypeOllama ProviderType = "ollama"
 )
 
+// DefaultRequestsPerMinute is the rate limit applied when a provider doesn't set one
+const DefaultRequestsPerMinute = 60
+
+// DefaultTimeoutSeconds is the HTTP timeout for provider calls when a provider doesn't set one
+const DefaultTimeoutSeconds = 30
+
+// MaxTimeoutSeconds caps a provider's HTTP timeout
+const MaxTimeoutSeconds = 300
+
+// Response defaults applied when a provider doesn't set its own
+const (
+	DefaultTemperature = 0.3
+	DefaultMaxTokens   = 500
+)
+
+// MaxSystemPromptLength caps a provider's system prompt, in characters
+const MaxSystemPromptLength = 2000
+
+// MaxFallbackDepth is how many fallback_provider_id links are followed from the default
+// provider, which also stops a cycle of fallbacks
+const MaxFallbackDepth = 3
+
+// Bounds on a provider's default_temperature and default_max_tokens
+const (
+	MaxTemperature    = 2.0
+	MaxResponseTokens = 32000
+)
+
+// MaxEmbeddingDimension is the largest embedding size a provider may configure
+const MaxEmbeddingDimension = 4096
+
 type AIProvider struct {
 	ID              string       `json:"id"`
 	UserID          string       `json:"user_id"`
@@ -22,16 +58,58 @@ type AIProvider struct {
 	SelectedModel   *string      `json:"selected_model"`
 	IsDefault       bool         `json:"is_default"`
 	IsEnabled       bool         `json:"is_enabled"`
-	CreatedAt       time.Time    `json:"created_at"`
-	UpdatedAt       time.Time    `json:"updated_at"`
+	// RequestsPerMinute caps calls to this provider across all requests in the process
+	RequestsPerMinute  int  `json:"requests_per_minute"`
+	RateLimitRemaining *int `json:"rate_limit_remaining,omitempty"`
+	// TimeoutSeconds is the HTTP timeout for calls to this provider; slow local models need more
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// NoAuth omits the Authorization header, for local servers that don't check it
+	NoAuth bool `json:"no_auth"`
+	// SupportsStructuredOutput sends JSON schemas as response_format "json_schema";
+	// well-known OpenAI models are detected without it
+	SupportsStructuredOutput bool `json:"supports_structured_output"`
+	// DefaultTemperature and DefaultMaxTokens shape every response from this provider,
+	// unless a call sets its own token limit
+	DefaultTemperature float64 `json:"default_temperature"`
+	DefaultMaxTokens   int     `json:"default_max_tokens"`
+	// EmbeddingModel and EmbeddingDimension override the global embedding model for RAG indexing
+	EmbeddingModel     *string `json:"embedding_model"`
+	EmbeddingDimension *int    `json:"embedding_dimension"`
+	// FallbackProviderID is the provider tried next when a call to this one fails
+	FallbackProviderID *string `json:"fallback_provider_id"`
+	// SystemPrompt is sent as the system message of every call to this provider
+	SystemPrompt string `json:"system_prompt"`
+	// Metadata holds provider-specific state, e.g. "thread_id" for assistants
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
 }
 
+// Model capabilities, detected from the model name when a provider's models are fetched
+const (
+	ModelCapabilityVision           = "vision"
+	ModelCapabilityFunctionCalling  = "function_calling"
+	ModelCapabilityStreaming        = "streaming"
+	ModelCapabilityStructuredOutput = "structured_output"
+)
+
 type AIProviderModel struct {
-	ID         string    `json:"id"`
-	ProviderID string    `json:"provider_id"`
-	ModelID    string    `json:"model_id"`
-	ModelName  string    `json:"model_name"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID           string    `json:"id"`
+	ProviderID   string    `json:"provider_id"`
+	ModelID      string    `json:"model_id"`
+	ModelName    string    `json:"model_name"`
+	Capabilities []string  `json:"capabilities"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// HasCapability reports whether the model lists capability
+func (m *AIProviderModel) HasCapability(capability string) bool {
+	for _, c := range m.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
 }
 
 type AIProviderCreate struct {
@@ -40,6 +118,16 @@ type AIProviderCreate struct {
 	BaseURL      string       `json:"base_url" binding:"required"`
 	APIKey       string       `json:"api_key" binding:"required"`
 	IsDefault    bool         `json:"is_default"`
+	// RequestsPerMinute defaults to DefaultRequestsPerMinute when omitted
+	RequestsPerMinute int `json:"requests_per_minute" binding:"omitempty,min=1"`
+	// TimeoutSeconds defaults to DefaultTimeoutSeconds when omitted
+	TimeoutSeconds           int  `json:"timeout_seconds" binding:"omitempty,min=1,max=300"`
+	NoAuth                   bool `json:"no_auth"`
+	SupportsStructuredOutput bool `json:"supports_structured_output"`
+	// EmbeddingDimension is required whenever EmbeddingModel is set
+	EmbeddingModel     *string `json:"embedding_model"`
+	EmbeddingDimension *int    `json:"embedding_dimension" binding:"omitempty,min=1,max=4096"`
+	SystemPrompt       string  `json:"system_prompt" binding:"max=2000"`
 }
 
 type AIProviderUpdate struct {
@@ -49,12 +137,27 @@ type AIProviderUpdate struct {
 	SelectedModel *string `json:"selected_model"`
 	IsDefault     *bool   `json:"is_default"`
 	IsEnabled     *bool   `json:"is_enabled"`
+
+	RequestsPerMinute        *int  `json:"requests_per_minute" binding:"omitempty,min=1"`
+	TimeoutSeconds           *int  `json:"timeout_seconds" binding:"omitempty,min=1,max=300"`
+	NoAuth                   *bool `json:"no_auth"`
+	SupportsStructuredOutput *bool `json:"supports_structured_output"`
+
+	DefaultTemperature *float64 `json:"default_temperature" binding:"omitempty,min=0,max=2"`
+	DefaultMaxTokens   *int     `json:"default_max_tokens" binding:"omitempty,min=1,max=32000"`
+	// An empty EmbeddingModel clears the embedding override
+	EmbeddingModel     *string `json:"embedding_model"`
+	EmbeddingDimension *int    `json:"embedding_dimension" binding:"omitempty,min=1,max=4096"`
+	// An empty FallbackProviderID clears the fallback
+	FallbackProviderID *string `json:"fallback_provider_id"`
+	SystemPrompt       *string `json:"system_prompt" binding:"omitempty,max=2000"`
 }
 
 type TestConnectionRequest struct {
 	ProviderType ProviderType `json:"provider_type" binding:"required"`
 	BaseURL      string       `json:"base_url" binding:"required"`
 	APIKey       string       `json:"api_key" binding:"required"`
+	NoAuth       bool         `json:"no_auth"`
 }
 
 type TestConnectionResponse struct {
@@ -63,6 +166,19 @@ type TestConnectionResponse struct {
 	Models  []string `json:"models,omitempty"`
 }
 
+// KeyRotationRequest is the body of the admin encryption key rotation endpoint
+type KeyRotationRequest struct {
+	NewKey string `json:"new_key" binding:"required"`
+}
+
+// KeyRotationFailure is a provider whose API key, or an inbound webhook source whose
+// secret, couldn't be re-encrypted
+type KeyRotationFailure struct {
+	ProviderID      string `json:"provider_id,omitempty"`
+	WebhookSourceID string `json:"webhook_source_id,omitempty"`
+	Error           string `json:"error"`
+}
+
 // GetDefaultBaseURL returns the default base URL for a provider type
 func GetDefaultBaseURL(providerType ProviderType) string {
 	switch providerType {
@@ -0,0 +1,55 @@
+package models
+
+// Error codes of APIError. middleware.ErrorHandler sends each with its HTTP status.
+const (
+	ErrCodeValidation    = "ERR_VALIDATION"
+	ErrCodeUnauthorized  = "ERR_UNAUTHORIZED"
+	ErrCodeForbidden     = "ERR_FORBIDDEN"
+	ErrCodeNotFound      = "ERR_NOT_FOUND"
+	ErrCodeConflict      = "ERR_CONFLICT"
+	ErrCodeDuplicate     = "ERR_DUPLICATE"
+	ErrCodeUnprocessable = "ERR_UNPROCESSABLE"
+	ErrCodeRateLimited   = "ERR_RATE_LIMITED"
+	ErrCodeInternal      = "ERR_INTERNAL"
+	// ErrCodeUpstream is a failure of a service the request depends on, such as a
+	// feed, an identity provider or the AI provider
+	ErrCodeUpstream = "ERR_UPSTREAM"
+	// ErrCodeAIUnavailable is an AI feature (chat, embeddings, vision) that isn't
+	// configured or can't serve the request
+	ErrCodeAIUnavailable = "ERR_AI_UNAVAILABLE"
+	// ErrCodeUnavailable is any other optional feature that isn't configured
+	ErrCodeUnavailable = "ERR_UNAVAILABLE"
+)
+
+// APIError is the body of every error response: a machine-readable code, a message
+// for people and optional details. The message is sent as "error", where clients
+// have always read it.
+type APIError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"error"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// APIErrorer is an error that says how it is sent to API clients. Handlers record
+// errors with c.Error; those that aren't APIErrorers are sent as ErrCodeInternal.
+type APIErrorer interface {
+	APIError() APIError
+}
+
+func NewAPIError(code, message string) *APIError {
+	return &APIError{Code: code, Message: message}
+}
+
+// WithDetails returns the error with details attached
+func (e *APIError) WithDetails(details interface{}) *APIError {
+	e.Details = details
+	return e
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+func (e *APIError) APIError() APIError {
+	return *e
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Attachment is a file stored in object storage and linked to a memory,
+// e.g. the image a memory was extracted from
+type Attachment struct {
+	ID         string    `json:"id"`
+	MemoryID   string    `json:"memory_id"`
+	StorageKey string    `json:"-"`
+	MimeType   string    `json:"mime_type"`
+	SizeBytes  int64     `json:"size_bytes"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Audit log actions for sensitive operations
+const (
+	AuditActionMemoryDeleted = "memory.deleted"
+	AuditActionTodoDeleted   = "todo.deleted"
+	AuditActionAPIKeyChanged = "api_key.changed"
+	AuditActionDataCleared   = "data.cleared"
+
+	AuditActionIPAllowlistChanged = "ip_allowlist.changed"
+
+	// AuditActionImpersonationStarted is recorded when an admin is issued a token to act
+	// as the user, and AuditActionImpersonatedRequest for each request made with it
+	AuditActionImpersonationStarted = "impersonation.started"
+	AuditActionImpersonatedRequest  = "impersonation.request"
+)
+
+// Events recorded in the audit log for integrations to pick up
+const (
+	// AuditActionTodoUnblocked is recorded for a todo whose last pending blocker was completed
+	AuditActionTodoUnblocked = "todo.unblocked"
+)
+
+type AuditLogEntry struct {
+	ID        string            `json:"id"`
+	UserID    string            `json:"user_id"`
+	Action    string            `json:"action"`
+	Metadata  map[string]string `json:"metadata"`
+	IPAddress *string           `json:"ip_address"`
+	CreatedAt time.Time         `json:"created_at"`
+}
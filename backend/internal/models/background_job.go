@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Names of the background jobs run by the job scheduler
+const (
+	JobRAGIndexRetry  = "rag_index_retry"
+	JobWeeklyDigest   = "weekly_digest"
+	JobURLRefresh     = "url_refresh"
+	JobSharePruning   = "share_pruning"
+	JobFTSHealthCheck = "fts_health_check"
+	JobRSSFeedImport  = "rss_feed_import"
+	JobAutoPriority   = "auto_priority"
+)
+
+// BackgroundJob is a background job's schedule and the outcome of its last run
+type BackgroundJob struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"job_name"`
+	CronExpression string     `json:"cron_expression"`
+	IsEnabled      bool       `json:"is_enabled"`
+	LastRunAt      *time.Time `json:"last_run_at"`
+	LastDurationMS *int64     `json:"last_duration_ms"`
+	LastError      *string    `json:"last_error"` // nil if the last run succeeded
+	// Available is false when the job's feature isn't set up on this server, e.g. the
+	// weekly digest without SMTP; such jobs keep their settings but never run
+	Available bool       `json:"available"`
+	NextRunAt *time.Time `json:"next_run_at"` // nil when disabled or unavailable
+}
+
+// BackgroundJobUpdateRequest changes a job's schedule or turns it on or off; omitted
+// fields are left as they are
+type BackgroundJobUpdateRequest struct {
+	CronExpression *string `json:"cron_expression"`
+	IsEnabled      *bool   `json:"is_enabled"`
+}
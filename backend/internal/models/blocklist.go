@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// BlocklistPattern is a keyword or regular expression that memory content must not
+// match, so passwords and private data aren't stored by accident. Keywords match
+// anywhere in the content and both kinds ignore case. System patterns come from
+// SYSTEM_BLOCKLIST_PATTERNS, apply to every user and can't be changed via the API.
+type BlocklistPattern struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id,omitempty"`
+	Pattern   string    `json:"pattern"`
+	IsRegex   bool      `json:"is_regex"`
+	IsSystem  bool      `json:"is_system"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BlocklistPatternCreateRequest struct {
+	Pattern string `json:"pattern" binding:"required,max=500"`
+	IsRegex bool   `json:"is_regex"`
+}
+
+type BlocklistPatternUpdateRequest struct {
+	Pattern *string `json:"pattern" binding:"omitempty,max=500"`
+	IsRegex *bool   `json:"is_regex"`
+}
+
+// ContentBlockedError is returned when content matches a blocklist pattern. Only the
+// pattern is reported; the matched text is never echoed back or logged.
+type ContentBlockedError struct {
+	Pattern string
+}
+
+func (e *ContentBlockedError) Error() string {
+	return "content_blocked"
+}
+
+func (e *ContentBlockedError) APIError() APIError {
+	return APIError{
+		Code:    ErrCodeUnprocessable,
+		Message: "content_blocked",
+		Details: map[string]string{"matched_pattern": e.Pattern},
+	}
+}
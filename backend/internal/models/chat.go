@@ -5,6 +5,7 @@ import "time"
 type ChatThread struct {
 	ID        string    `json:"id"`
 	UserID    string    `json:"user_id"`
+	Title     *string   `json:"title"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -16,6 +17,7 @@ type ChatMessage struct {
 	Content   string    `json:"content"`
 	Mode      *string   `json:"mode"`      // 'memories', 'internet', 'hybrid', 'llm'
 	Sources   *string   `json:"sources"`    // JSON array of sources
+	IsPartial bool      `json:"is_partial"` // Set when a streamed reply was cut off by the client disconnecting
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -23,6 +25,10 @@ type ChatThreadCreateRequest struct {
 	// Empty for now, can add fields later if needed
 }
 
+type ChatThreadTitleUpdateRequest struct {
+	Title string `json:"title" binding:"required"`
+}
+
 type ChatMessageCreateRequest struct {
 	Role    string  `json:"role" binding:"required"`
 	Content string  `json:"content" binding:"required"`
@@ -30,6 +36,22 @@ type ChatMessageCreateRequest struct {
 	Sources *string `json:"sources"`
 }
 
+type ChatAskRequest struct {
+	Question     string   `json:"question" binding:"required"`
+	Mode         AskMode  `json:"mode"`
+	ContentTypes []string `json:"content_types"`
+	MaxContext   int      `json:"max_context"`
+	AllowActions bool     `json:"allow_actions"` // see AskRequest.AllowActions
+}
+
+type ChatAskResponse struct {
+	UserMessage      *ChatMessage   `json:"user_message"`
+	AssistantMessage *ChatMessage   `json:"assistant_message"`
+	Sources          []SearchResult `json:"sources"`
+	TimeTaken        float64        `json:"time_taken_ms"`
+	CreatedTodo      *Todo          `json:"created_todo,omitempty"`
+}
+
 type ChatThreadResponse struct {
 	Thread   *ChatThread    `json:"thread"`
 	Messages []ChatMessage  `json:"messages"`
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// DailyBriefing is an AI-written plan for the user's day, from their pending todos
+// due today or overdue and the memories they saved in the last week
+type DailyBriefing struct {
+	Date        string               `json:"date"`
+	Briefing    string               `json:"briefing"`
+	Sources     DailyBriefingSources `json:"sources"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	Cached      bool                 `json:"cached"`
+}
+
+// DailyBriefingSources are the todos and memories a briefing was written from
+type DailyBriefingSources struct {
+	TodoIDs   []string `json:"todo_ids"`
+	MemoryIDs []string `json:"memory_ids"`
+}
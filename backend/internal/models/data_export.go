@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// DataExportVersion is the format version written to and accepted from JSON exports
+const DataExportVersion = "1"
+
+// DataExport is a user's full dataset in the JSON export format. API keys are
+// always masked, so AI providers in an export can't be imported.
+type DataExport struct {
+	Version     string           `json:"version"`
+	ExportedAt  time.Time        `json:"exported_at"`
+	Todos       []Todo           `json:"todos"`
+	Memories    []Memory         `json:"memories"`
+	Groups      []Group          `json:"groups"`
+	AIProviders []AIProvider     `json:"ai_providers"`
+	Categories  []MemoryCategory `json:"categories"`
+}
+
+// DataImportCounts holds per-type record counts for a JSON import
+type DataImportCounts struct {
+	Groups      int `json:"groups"`
+	Categories  int `json:"categories"`
+	Todos       int `json:"todos"`
+	Memories    int `json:"memories"`
+	AIProviders int `json:"ai_providers"`
+}
+
+// DataImportResult reports what a JSON import created and what it skipped
+// because the ID already existed, the record can't be imported or, for memories,
+// the content matches the user's blocklist
+type DataImportResult struct {
+	Imported DataImportCounts `json:"imported"`
+	Skipped  DataImportCounts `json:"skipped"`
+}
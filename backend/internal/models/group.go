@@ -2,22 +2,57 @@ package models
 
 import "time"
 
+// DefaultPersonalGroupID is the default "Personal" group, which takes in the todos
+// of deleted groups
+const DefaultPersonalGroupID = "default-personal"
+
 type Group struct {
-	ID        string    `json:"id"`
-	UserID    *string   `json:"user_id"`
-	Name      string    `json:"name"`
-	ColorCode string    `json:"color_code"`
-	IsDefault bool      `json:"is_default"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string    `json:"id"`
+	UserID     *string   `json:"user_id"`
+	Name       string    `json:"name"`
+	ColorCode  string    `json:"color_code"`
+	ColorDark  string    `json:"color_dark"` // dark-mode variant; derived from ColorCode unless set
+	IsDefault  bool      `json:"is_default"`
+	IsArchived bool      `json:"is_archived"`
+	ParentID   *string   `json:"parent_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	// Stats is only populated when listing groups
+	Stats *GroupStats `json:"stats,omitempty"`
+	// Children holds the group's direct sub-groups; only populated when listing groups
+	Children []Group `json:"children,omitempty"`
+}
+
+// GroupStats counts a user's todos in a group. Overdue todos are pending ones past their due date.
+// StoryPoints totals the estimates of the group's todos.
+type GroupStats struct {
+	Total       int `json:"total"`
+	Completed   int `json:"completed"`
+	Pending     int `json:"pending"`
+	Overdue     int `json:"overdue"`
+	StoryPoints int `json:"story_points"`
 }
 
 type GroupCreateRequest struct {
-	Name      string `json:"name" binding:"required"`
-	ColorCode string `json:"color_code"`
+	Name      string  `json:"name" binding:"required"`
+	ColorCode string  `json:"color_code"`
+	ColorDark string  `json:"color_dark"`
+	ParentID  *string `json:"parent_id"`
 }
 
+// GroupUpdateRequest updates the given fields. An empty ColorDark goes back to the
+// variant derived from ColorCode.
 type GroupUpdateRequest struct {
 	Name      *string `json:"name"`
 	ColorCode *string `json:"color_code"`
+	ColorDark *string `json:"color_dark"`
+}
+
+// GroupColorPreview is a group's light and dark colors with their WCAG 2.1 contrast
+// ratios against the light and dark page backgrounds
+type GroupColorPreview struct {
+	ColorLight    string  `json:"color_light"`
+	ColorDark     string  `json:"color_dark"`
+	ContrastLight float64 `json:"contrast_light"`
+	ContrastDark  float64 `json:"contrast_dark"`
 }
@@ -0,0 +1,38 @@
+package models
+
+// Overall health statuses
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthError    = "error"
+)
+
+// Dependency statuses. A dependency that isn't configured is "disabled" and doesn't
+// count against overall health.
+const (
+	DependencyOK       = "ok"
+	DependencyDown     = "down"
+	DependencyDisabled = "disabled"
+)
+
+// Dependencies reported by the health endpoint
+const (
+	DependencySQLite    = "sqlite"
+	DependencyRAG       = "rag"
+	DependencyEmbedding = "embedding"
+	DependencySearXNG   = "searxng"
+)
+
+// DependencyHealth is the result of checking one dependency
+type DependencyHealth struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthResponse is the body of GET /health. Status is "error" only when SQLite is
+// down, and "degraded" when any other dependency is.
+type HealthResponse struct {
+	Status string                      `json:"status"`
+	Deps   map[string]DependencyHealth `json:"deps"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ImpersonateRequest names the user an admin wants to act as
+type ImpersonateRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ImpersonationToken is a short-lived token for acting as a user
+type ImpersonationToken struct {
+	Token     string    `json:"token"`
+	UserID    string    `json:"user_id"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
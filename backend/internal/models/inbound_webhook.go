@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// InboundWebhookSignatureHeader carries the GitHub-style signature of an inbound
+// webhook: "sha256=" and the hex HMAC-SHA256 of the body keyed with the source's secret
+const InboundWebhookSignatureHeader = "X-Hub-Signature-256"
+
+// MaxInboundWebhookSize caps the body of an inbound webhook
+const MaxInboundWebhookSize = 1 << 20
+
+// What an inbound webhook rule creates
+const (
+	InboundWebhookCreateTodo   = "todo"
+	InboundWebhookCreateMemory = "memory"
+)
+
+// InboundWebhookRule routes a matching payload to a new todo or memory. The first
+// rule of a source that matches a payload applies.
+type InboundWebhookRule struct {
+	// Object is the top-level payload field holding the item, e.g. "issue"
+	Object string `json:"object" binding:"required"`
+	// Action, when set, must equal the payload's top-level "action"
+	Action string `json:"action,omitempty"`
+	// Merged, when set, must equal the object's "merged" field
+	Merged *bool `json:"merged,omitempty"`
+	// TitleField and BodyField are dotted paths in the object, "title" and "body" by default
+	TitleField string `json:"title_field,omitempty"`
+	BodyField  string `json:"body_field,omitempty"`
+	Create     string `json:"create" binding:"required,oneof=todo memory"`
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// DefaultInboundWebhookRules are the rules of a source created without any: GitHub
+// issues become todos and merged pull requests become memories
+var DefaultInboundWebhookRules = []InboundWebhookRule{
+	{Object: "issue", Action: "opened", Create: InboundWebhookCreateTodo},
+	{Object: "pull_request", Action: "closed", Merged: boolPtr(true), Create: InboundWebhookCreateMemory},
+}
+
+// InboundWebhookSource is an external service allowed to send webhooks to
+// /api/webhooks/inbound/:name. What it sends is created for UserID. The secret
+// signing its webhooks is stored encrypted, like AI provider API keys.
+type InboundWebhookSource struct {
+	ID              string               `json:"id"`
+	Name            string               `json:"name"`
+	UserID          string               `json:"user_id"`
+	SecretEncrypted string               `json:"-"`
+	Rules           []InboundWebhookRule `json:"rules"`
+	IsEnabled       bool                 `json:"is_enabled"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+// InboundWebhookSourceCreateRequest adds a source; without rules it gets
+// DefaultInboundWebhookRules
+type InboundWebhookSourceCreateRequest struct {
+	Name      string               `json:"name" binding:"required,max=64"`
+	UserID    string               `json:"user_id" binding:"required"`
+	Secret    string               `json:"secret" binding:"required,min=16"`
+	Rules     []InboundWebhookRule `json:"rules" binding:"omitempty,dive"`
+	IsEnabled *bool                `json:"is_enabled"`
+}
+
+type InboundWebhookSourceUpdateRequest struct {
+	UserID    *string               `json:"user_id"`
+	Secret    *string               `json:"secret" binding:"omitempty,min=16"`
+	Rules     *[]InboundWebhookRule `json:"rules" binding:"omitempty,dive"`
+	IsEnabled *bool                 `json:"is_enabled"`
+}
+
+// InboundWebhookResult is what an inbound webhook created. Created is empty when no
+// rule matched the payload.
+type InboundWebhookResult struct {
+	Source  string `json:"source"`
+	Created string `json:"created,omitempty"`
+	ID      string `json:"id,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// IPAllowlistEntry is a network a user's account may be accessed from.
+// A user with no entries can be accessed from anywhere.
+type IPAllowlistEntry struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	CIDR      string    `json:"cidr"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IPAllowlistCreateRequest accepts a CIDR range or a single IPv4/IPv6 address
+type IPAllowlistCreateRequest struct {
+	CIDR  string `json:"cidr" binding:"required"`
+	Label string `json:"label"`
+}
+
+type IPAllowlistUpdateRequest struct {
+	CIDR  *string `json:"cidr"`
+	Label *string `json:"label"`
+}
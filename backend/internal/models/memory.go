@@ -3,20 +3,57 @@ package models
 import "time"
 
 type Memory struct {
-	ID         string    `json:"id"`
-	UserID     string    `json:"user_id"`
-	Content    string    `json:"content"`
-	Summary    *string   `json:"summary"`
-	Category   string    `json:"category"`
-	URL        *string   `json:"url"`
-	URLTitle   *string   `json:"url_title"`
-	URLContent *string   `json:"url_content"`
-	IsArchived bool      `json:"is_archived"`
-	Position   string    `json:"position"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID                 string     `json:"id"`
+	UserID             string     `json:"user_id"`
+	Content            string     `json:"content"`
+	Summary            *string    `json:"summary"`
+	Category           string     `json:"category"`
+	URL                *string    `json:"url"`
+	URLTitle           *string    `json:"url_title"`
+	URLContent         *string    `json:"url_content"`
+	IsArchived         bool       `json:"is_archived"`
+	IsPinned           bool       `json:"is_pinned"`
+	AIProcessingFailed bool       `json:"ai_processing_failed"` // AI response stayed invalid after a retry; saved with defaults
+	ContentLanguage    *string    `json:"content_language"`     // ISO 639-1 code of the detected language, e.g. "fr"
+	Position           string     `json:"position"`
+	LastScrapedAt      *time.Time `json:"last_scraped_at"` // when url_content was last fetched from url
+	GeneratedTitle     *string    `json:"generated_title"` // AI display title for long content without a url_title
+	ScrapeMode         string     `json:"scrape_mode"`     // how much of the url is fetched, see ScrapeModeFull
+	ThumbnailURL       *string    `json:"thumbnail_url"`   // the url's og:image
+	SummarySource      *string    `json:"summary_source"`  // where summary came from, see SummarySourceManual
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
+const (
+	// GeneratedTitleMinContentLength is the content length, in characters, above which
+	// a memory without a url_title gets a generated title
+	GeneratedTitleMinContentLength = 200
+	// MaxGeneratedTitleLength caps generated titles, in characters
+	MaxGeneratedTitleLength = 60
+)
+
+// Summary sources: what wrote a memory's summary
+const (
+	// SummarySourceAICategorize is the summary the AI wrote when categorizing the memory
+	SummarySourceAICategorize = "ai_categorize"
+	// SummarySourceURLSummary is the AI summary of the URL's page, written when it is refreshed
+	SummarySourceURLSummary = "url_summary"
+	// SummarySourceManual is a summary the user set; it is never regenerated
+	SummarySourceManual = "manual"
+)
+
+// Scrape modes: how much of a memory's URL is fetched
+const (
+	// ScrapeModeFull reads the whole page and has the AI summarize it
+	ScrapeModeFull = "full"
+	// ScrapeModeMetadataOnly reads only the page's title and Open Graph tags, for
+	// large, non-HTML or paywalled pages
+	ScrapeModeMetadataOnly = "metadata_only"
+	// ScrapeModeNone stores the URL without fetching it
+	ScrapeModeNone = "none"
+)
+
 type MemoryCategory struct {
 	ID        string    `json:"id"`
 	UserID    *string   `json:"user_id"`
@@ -38,21 +75,124 @@ type MemoryDigest struct {
 
 type MemoryCreateRequest struct {
 	Content string `json:"content" binding:"required"`
+	// ScrapeMode defaults to ScrapeModeFull
+	ScrapeMode string `json:"scrape_mode" binding:"omitempty,oneof=full metadata_only none"`
+}
+
+// MaxMemoryBatchSize caps the number of memories in one batch create request
+const MaxMemoryBatchSize = 50
+
+// MemoryBatchCreateRequest creates several memories in one transaction. Items are
+// validated individually, so an empty one is reported in the result rather than
+// rejecting the request.
+type MemoryBatchCreateRequest struct {
+	Memories    []MemoryCreateRequest `json:"memories" binding:"required,min=1,max=50"`
+	StopOnError bool                  `json:"stop_on_error"`
+}
+
+// BatchCreateFailure is an item of a batch that wasn't created
+type BatchCreateFailure struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// BatchCreateResult lists the IDs of the memories a batch created, in request order,
+// and the items that failed
+type BatchCreateResult struct {
+	Created []string             `json:"created"`
+	Failed  []BatchCreateFailure `json:"failed"`
 }
 
 type MemoryUpdateRequest struct {
-	Content    *string `json:"content"`
+	Content *string `json:"content"`
+	// Summary replaces the summary, which refreshing the URL then leaves alone
+	Summary    *string `json:"summary"`
 	Category   *string `json:"category"`
 	IsArchived *bool   `json:"is_archived"`
 }
 
-type MemorySearchRequest struct {
-	Query    string  `json:"query"`
+const (
+	// MaxMemoryRevisions is how many revisions are kept per memory; older ones are pruned
+	MaxMemoryRevisions = 20
+	// MemoryRevisionListLimit is how many revisions the revisions endpoint returns
+	MemoryRevisionListLimit = 10
+)
+
+// MemoryRevision is a memory's content and summary as they were before an edit.
+// DiffChars is how many characters the edit that replaced this version changed.
+type MemoryRevision struct {
+	ID        string    `json:"id"`
+	MemoryID  string    `json:"memory_id"`
+	Content   string    `json:"content"`
+	Summary   *string   `json:"summary"`
+	ChangedAt time.Time `json:"changed_at"`
+	ChangedBy string    `json:"changed_by"`
+	DiffChars int       `json:"diff_chars"`
+}
+
+const (
+	// CategorySnippetLength is how many characters of a memory's content identify it
+	// in category corrections
+	CategorySnippetLength = 100
+	// MinCategoryCorrections is how many times a user must have corrected memories
+	// starting the same way to one category before new ones get it without the AI
+	MinCategoryCorrections = 3
+)
+
+// CategoryCorrection records a user changing a memory's category. ContentHash is a
+// hash of the start of the memory's content, see CategorySnippetLength.
+type CategoryCorrection struct {
+	ID                string    `json:"id"`
+	UserID            string    `json:"user_id"`
+	ContentHash       string    `json:"content_hash"`
+	OriginalCategory  string    `json:"original_category"`
+	CorrectedCategory string    `json:"corrected_category"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// MemoryCloneOverrides optionally replaces fields on a cloned memory
+type MemoryCloneOverrides struct {
+	Content  *string `json:"content"`
 	Category *string `json:"category"`
-	DateFrom *string `json:"date_from"`
-	DateTo   *string `json:"date_to"`
-	Limit    int     `json:"limit"`
-	Offset   int     `json:"offset"`
+}
+
+type MemorySearchRequest struct {
+	Query          string   `json:"query"`
+	Category       *string  `json:"category"`
+	DateFrom       *string  `json:"date_from"`
+	DateTo         *string  `json:"date_to"`
+	LanguageFilter []string `json:"language_filter"` // ISO 639-1 codes; matches any of them
+	Limit          int      `json:"limit"`
+	Offset         int      `json:"offset"`
+}
+
+// BulkDeleteFilter selects memories for bulk deletion; at least one field must be set
+type BulkDeleteFilter struct {
+	Category   string `json:"category"`
+	BeforeDate string `json:"before_date"` // YYYY-MM-DD, exclusive
+}
+
+// MemoryCSVFields are the columns a memory CSV export can contain, in their default order
+var MemoryCSVFields = []string{
+	"id", "content", "summary", "category", "url", "url_title", "generated_title",
+	"content_language", "is_pinned", "created_at", "updated_at",
+}
+
+// ExportFilter selects the memories and columns of a CSV export. Zero-valued fields
+// don't filter.
+type ExportFilter struct {
+	Fields   []string // columns in order, from MemoryCSVFields; empty means all of them
+	Category string
+	From     string // YYYY-MM-DD, inclusive
+	To       string // YYYY-MM-DD, inclusive
+}
+
+// BulkArchiveFilter selects memories for bulk archiving or unarchiving; older_than_days
+// or category must be set. Pinned memories are left alone unless IncludePinned is true.
+type BulkArchiveFilter struct {
+	OlderThanDays int    `json:"older_than_days"`
+	Category      string `json:"category"`
+	IncludePinned bool   `json:"include_pinned"`
 }
 
 type MemoryToTodoRequest struct {
@@ -86,18 +226,42 @@ type MemoryStats struct {
 	ByCategory map[string]int `json:"by_category"`
 	ThisWeek   int            `json:"this_week"`
 	ThisMonth  int            `json:"this_month"`
+
+	// Content length analytics
+	TotalCharacters             int                `json:"total_characters"`
+	AvgCharactersPerMemory      int                `json:"avg_characters_per_memory"`
+	EstimatedReadingTimeMinutes int                `json:"estimated_reading_time_minutes"`
+	LongestMemory               *MemoryLengthStats `json:"longest_memory"`
+	URLCount                    int                `json:"url_count"`
+	WithSummaryCount            int                `json:"with_summary_count"`
+}
+
+// KeywordScore is a keyword of the user's memories, ranked by its summed TF-IDF score
+type KeywordScore struct {
+	Keyword       string  `json:"keyword"`
+	Score         float64 `json:"score"`
+	Frequency     int     `json:"frequency"`      // occurrences across all memories
+	DocumentCount int     `json:"document_count"` // memories containing it
+}
+
+// MemoryLengthStats identifies a memory by its content length
+type MemoryLengthStats struct {
+	ID            string `json:"id"`
+	ContentLength int    `json:"content_length"`
 }
 
 type AIProcessedMemory struct {
-	Summary     string   `json:"summary"`
-	Category    string   `json:"category"`
-	DetectedURL *string  `json:"detected_url"`
-	Tags        []string `json:"tags"`
+	Summary          string   `json:"summary"`
+	Category         string   `json:"category"`
+	DetectedURL      *string  `json:"detected_url"`
+	Tags             []string `json:"tags"`
+	ProcessingFailed bool     `json:"processing_failed"`
 }
 
 type URLSummary struct {
-	Title   string `json:"title"`
-	Summary string `json:"summary"`
+	Title        string `json:"title"`
+	Summary      string `json:"summary"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
 }
 
 // MemoryBulkCreateResponse is the response for bulk memory creation from file upload
@@ -107,3 +271,20 @@ type MemoryBulkCreateResponse struct {
 	Filename     string   `json:"filename"`
 	FileType     string   `json:"file_type"`
 }
+
+// VaultImportError describes a note that failed to import
+type VaultImportError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// VaultImportResponse summarizes a vault ZIP import
+type VaultImportResponse struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+	// DeduplicatedCount is the number of notes skipped as near-copies of existing memories
+	DeduplicatedCount int                `json:"deduplicated_count"`
+	Errors            []VaultImportError `json:"errors"`
+	// ImportedBySource counts the imported notes by kind, e.g. "zip" or "apple_notes"
+	ImportedBySource map[string]int `json:"imported_by_source"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// OIDCProvider is an OpenID Connect provider users can sign in with
+type OIDCProvider struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Issuer      string `json:"issuer"`
+	InitiateURL string `json:"initiate_url"`
+}
+
+// OIDCInitiateResponse starts an SSO login: the client sends the user to AuthURL, and
+// the provider redirects back to OIDC_REDIRECT_URI with a code and State
+type OIDCInitiateResponse struct {
+	AuthURL string `json:"auth_url"`
+	State   string `json:"state"`
+}
+
+// OIDCLoginResponse is an app token issued after a successful SSO login
+type OIDCLoginResponse struct {
+	Token     string        `json:"token"`
+	TokenType string        `json:"token_type"`
+	ExpiresIn int64         `json:"expires_in"` // seconds
+	User      *UserResponse `json:"user"`
+}
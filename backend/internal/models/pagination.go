@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const (
+	// DefaultPageLimit and MaxPageLimit bound the items in a cursor page
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// PageCursor marks the last item of a page in newest-first order. The client gets it
+// as an opaque token: base64url-encoded JSON.
+type PageCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+// MemoryPage is a page of memories, newest first. NextCursor is empty on the last page.
+type MemoryPage struct {
+	Memories   []Memory `json:"memories"`
+	NextCursor string   `json:"next_cursor"`
+	HasMore    bool     `json:"has_more"`
+}
+
+// TodoPage is a page of todos, newest first. NextCursor is empty on the last page.
+type TodoPage struct {
+	Todos      []Todo `json:"todos"`
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
+}
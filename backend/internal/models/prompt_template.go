@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Prompt template names that can be overridden per user
+const (
+	PromptTemplateTodoProcessing       = "todo_processing"
+	PromptTemplateMemoryCategorization = "memory_categorization"
+	PromptTemplateWeeklyDigest         = "weekly_digest"
+)
+
+type PromptTemplate struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	TemplateName string    `json:"template_name"`
+	TemplateBody string    `json:"template_body"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type PromptTemplateCreateRequest struct {
+	TemplateName string `json:"template_name" binding:"required"`
+	TemplateBody string `json:"template_body" binding:"required"`
+	IsActive     *bool  `json:"is_active"`
+}
+
+type PromptTemplateUpdateRequest struct {
+	TemplateBody *string `json:"template_body"`
+	IsActive     *bool   `json:"is_active"`
+}
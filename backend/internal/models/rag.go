@@ -61,6 +61,15 @@ type SearchResult struct {
 	Score      float64   `json:"score"`
 	MatchType  string    `json:"match_type"` // "vector", "keyword", "hybrid"
 	Highlights []string  `json:"highlights,omitempty"`
+	// Spans locate the highlighted words of keyword matches in Document.Content
+	Spans []HighlightSpan `json:"spans,omitempty"`
+}
+
+// HighlightSpan is a highlighted part of a document's content, from Start up to End,
+// in characters (Unicode code points) from the start of the content
+type HighlightSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 // SearchResponse contains search results
@@ -91,6 +100,13 @@ type AskRequest struct {
 	ContentTypes []string `json:"content_types"`
 	MaxContext   int      `json:"max_context"` // Max docs to include in context
 	Mode         AskMode  `json:"mode"`        // Ask mode: memories, internet, hybrid, llm
+	// AllowActions lets the AI act on requests like "add a todo to ..." instead of only answering
+	AllowActions bool `json:"allow_actions"`
+
+	// Prior conversation turns, oldest first (set by the chat service, not the client)
+	History []ChatMessage `json:"-"`
+	// OnToken receives the answer piece by piece as the AI streams it (set by the chat service)
+	OnToken func(token string) `json:"-"`
 }
 
 // AskResponse contains the answer and sources
@@ -99,6 +115,8 @@ type AskResponse struct {
 	Sources   []SearchResult `json:"sources"`
 	Question  string         `json:"question"`
 	TimeTaken float64        `json:"time_taken_ms"`
+	// CreatedTodo is set when AllowActions was on and the AI created a todo
+	CreatedTodo *Todo `json:"created_todo,omitempty"`
 }
 
 // IndexStats provides statistics about the vector index
@@ -107,6 +125,15 @@ type IndexStats struct {
 	ByContentType  map[string]int `json:"by_content_type"`
 	ByUser         map[string]int `json:"by_user"`
 	LastIndexedAt  *time.Time     `json:"last_indexed_at"`
+	// StaleCount is how many of the user's todos and memories were never indexed or
+	// changed since they last were
+	StaleCount int `json:"stale_count"`
+}
+
+// ReindexResponse reports an explicit re-index of a single todo or memory
+type ReindexResponse struct {
+	Indexed   bool  `json:"indexed"`
+	ElapsedMS int64 `json:"elapsed_ms"`
 }
 
 // IndexRequest for triggering indexing
@@ -116,12 +143,16 @@ type IndexRequest struct {
 	Reindex     bool        `json:"reindex"` // Force reindex even if already indexed
 }
 
-// IndexResponse after indexing
+// IndexResponse after indexing, also logged as progress while indexing runs
 type IndexResponse struct {
 	Indexed   int    `json:"indexed"`
 	Skipped   int    `json:"skipped"`
 	Errors    int    `json:"errors"`
+	// Total is every todo and memory considered, including those already indexed
+	Total     int    `json:"total"`
 	TimeTaken float64 `json:"time_taken_ms"`
+	// LastIndexedAt is when the index was brought up to date; unset while in progress
+	LastIndexedAt *time.Time `json:"last_indexed_at,omitempty"`
 }
 
 // EmbeddingRequest for generating embeddings
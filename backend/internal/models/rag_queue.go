@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Statuses of a RAG index queue entry
+const (
+	// IndexQueuePending entries are retried once NextAttemptAt passes
+	IndexQueuePending = "pending"
+	// IndexQueueDead entries failed every retry and are kept for inspection only
+	IndexQueueDead = "dead"
+)
+
+// IndexQueueItem is a todo or memory whose background indexing failed, waiting to be
+// retried
+type IndexQueueItem struct {
+	ID            string      `json:"id"`
+	ContentType   ContentType `json:"content_type"`
+	ContentID     string      `json:"content_id"`
+	UserID        string      `json:"user_id"`
+	AttemptCount  int         `json:"attempt_count"` // retries so far, not counting the original attempt
+	LastError     string      `json:"last_error"`
+	NextAttemptAt time.Time   `json:"next_attempt_at"`
+	Status        string      `json:"status"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
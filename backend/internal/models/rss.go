@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// DefaultRSSImportItems and MaxRSSImportItems bound how many feed items one import reads
+const (
+	DefaultRSSImportItems = 10
+	MaxRSSImportItems     = 50
+)
+
+// RSSItem is an entry read from an RSS or Atom feed
+type RSSItem struct {
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Description string     `json:"description"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+// RSSImportRequest imports a feed's latest items as memories. With save set the
+// feed is also stored and re-imported in the background.
+type RSSImportRequest struct {
+	URL      string `json:"url" binding:"required"`
+	MaxItems int    `json:"max_items"`
+	Save     bool   `json:"save"`
+}
+
+// RSSImportItemError explains why one feed item wasn't imported
+type RSSImportItemError struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// RSSImportResult reports the outcome of a feed import. Items whose URL is already
+// saved as a memory are counted as skipped.
+type RSSImportResult struct {
+	Imported int                  `json:"imported"`
+	Skipped  int                  `json:"skipped"`
+	Failed   int                  `json:"failed"`
+	Errors   []RSSImportItemError `json:"errors"`
+}
+
+// SavedRSSFeed is a feed imported for a user every few hours
+type SavedRSSFeed struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	URL           string     `json:"url"`
+	MaxItems      int        `json:"max_items"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Search history limits: GET /search/history returns DefaultSearchHistoryLimit
+// entries unless asked for up to MaxSearchHistoryLimit, and only the newest
+// MaxSearchHistoryEntries are kept per user
+const (
+	DefaultSearchHistoryLimit = 20
+	MaxSearchHistoryLimit     = 100
+	MaxSearchHistoryEntries   = 200
+)
+
+// SearchHistoryEntry records one search a user ran
+type SearchHistoryEntry struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	Query        string    `json:"query"`
+	ContentTypes []string  `json:"content_types"`
+	ResultCount  int       `json:"result_count"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SavedSearch is a named query the user can run again
+type SavedSearch struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	Name         string    `json:"name"`
+	Query        string    `json:"query"`
+	ContentTypes []string  `json:"content_types"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type SavedSearchCreateRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	Query        string   `json:"query" binding:"required"`
+	ContentTypes []string `json:"content_types"`
+}
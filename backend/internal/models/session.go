@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Session is a signed-in device. Its ID is the session_id claim Supabase puts in
+// every access token for the login, so a session stays the same across token refreshes.
+type Session struct {
+	ID                string     `json:"id"`
+	UserID            string     `json:"user_id"`
+	DeviceFingerprint string     `json:"device_fingerprint"`
+	IPAddress         string     `json:"ip_address"`
+	UserAgent         string     `json:"user_agent"`
+	LastActive        time.Time  `json:"last_active"`
+	CreatedAt         time.Time  `json:"created_at"`
+	RefreshTokenHash  *string    `json:"-"`
+	RevokedAt         *time.Time `json:"revoked_at,omitempty"`
+}
+
+// SessionResponse is a session as shown to its user, with the IP address masked
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	IPAddress  string    `json:"ip_address"`
+	LastActive time.Time `json:"last_active"`
+	CreatedAt  time.Time `json:"created_at"`
+	IsCurrent  bool      `json:"is_current"`
+}
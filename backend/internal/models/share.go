@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Share link limits
+const (
+	// DefaultShareExpiryDays is how long a share link lasts when no expiry is requested
+	DefaultShareExpiryDays = 7
+	// MaxShareExpiryDays caps the requested expiry of a share link
+	MaxShareExpiryDays = 365
+)
+
+// ShareToken grants anyone holding it read access to one memory until it expires or
+// has been viewed MaxViews times
+type ShareToken struct {
+	Token     string    `json:"token"`
+	MemoryID  string    `json:"memory_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ViewCount int       `json:"view_count"`
+	MaxViews  *int      `json:"max_views"` // nil allows unlimited views
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ShareCreateRequest optionally limits a new share link
+type ShareCreateRequest struct {
+	ExpiresInDays *int `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+	MaxViews      *int `json:"max_views" binding:"omitempty,min=1"`
+}
+
+// ShareLink is a created share link
+type ShareLink struct {
+	URL       string    `json:"url"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	MaxViews  *int      `json:"max_views"`
+}
+
+// SharedMemory is the public view of a shared memory; it leaves out the owner and
+// other internal fields
+type SharedMemory struct {
+	Content        string    `json:"content"`
+	Summary        *string   `json:"summary"`
+	Category       string    `json:"category"`
+	URL            *string   `json:"url"`
+	URLTitle       *string   `json:"url_title"`
+	URLContent     *string   `json:"url_content"`
+	GeneratedTitle *string   `json:"generated_title"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -0,0 +1,50 @@
+package models
+
+// System setting keys
+const (
+	// SettingAllowedOrigins holds the CORS allowed origins as a JSON array, overriding ALLOWED_ORIGINS
+	SettingAllowedOrigins = "allowed_origins"
+	// SettingEmbeddingEnsemble holds the EmbeddingEnsembleConfig used when EMBEDDING_ENSEMBLE=true
+	SettingEmbeddingEnsemble = "embedding_ensemble"
+)
+
+// AllowedOriginsRequest replaces the CORS allowed origins
+type AllowedOriginsRequest struct {
+	Origins []string `json:"origins" binding:"required"`
+}
+
+// EmbeddingEnsembleMode is how an embedding ensemble combines its members' vectors
+type EmbeddingEnsembleMode string
+
+const (
+	// EnsembleModeConcat joins the members' vectors; the dimension is the sum of theirs
+	EnsembleModeConcat EmbeddingEnsembleMode = "concat"
+	// EnsembleModeAverage averages the members' vectors, which must share a dimension
+	EnsembleModeAverage EmbeddingEnsembleMode = "average"
+)
+
+// EnsembleProviderNIM is the ensemble provider ID of the global NIM embedding service
+const EnsembleProviderNIM = "nim"
+
+// EmbeddingEnsembleProvider is a member of the embedding ensemble: the global NIM
+// service, or an AI provider with an embedding model. Weight defaults to 1.
+type EmbeddingEnsembleProvider struct {
+	ProviderID string  `json:"provider_id" binding:"required"`
+	Weight     float64 `json:"weight"`
+}
+
+// EmbeddingEnsembleConfig is the stored embedding ensemble. Mode defaults to concat.
+type EmbeddingEnsembleConfig struct {
+	Mode      EmbeddingEnsembleMode       `json:"mode"`
+	Providers []EmbeddingEnsembleProvider `json:"providers" binding:"required,min=1,dive"`
+}
+
+// DatabasePragmas are the SQLite PRAGMA values in effect on a database connection
+type DatabasePragmas struct {
+	JournalMode       string `json:"journal_mode"`
+	ForeignKeys       bool   `json:"foreign_keys"`
+	CacheSize         int    `json:"cache_size"` // negative values are KiB, positive are pages
+	Synchronous       string `json:"synchronous"`
+	BusyTimeoutMS     int    `json:"busy_timeout_ms"`
+	WALAutocheckpoint int    `json:"wal_autocheckpoint"`
+}
@@ -17,18 +17,27 @@ const (
 )
 
 type Todo struct {
-	ID          string    `json:"id"`
-	UserID      string    `json:"user_id"`
-	GroupID     *string   `json:"group_id"`
-	Title       string    `json:"title"`
-	Description *string   `json:"description"`
-	DueDate     *string   `json:"due_date"`
-	Priority    Priority  `json:"priority"`
-	Status      Status    `json:"status"`
-	Position    string    `json:"position"`
-	Tags        []string  `json:"tags"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                string   `json:"id"`
+	UserID            string   `json:"user_id"`
+	GroupID           *string  `json:"group_id"`
+	Title             string   `json:"title"`
+	Description       *string  `json:"description"`
+	DueDate           *string  `json:"due_date"`
+	Priority          Priority `json:"priority"`
+	Status            Status   `json:"status"`
+	Position          string   `json:"position"`
+	Tags              []string `json:"tags"`
+	StoryPoints       *int     `json:"story_points"` // one of StoryPointValues
+	EstimatedDuration *string  `json:"estimated_duration"`
+	Dependencies      []string `json:"dependencies,omitempty"` // IDs of the todos blocking this one; set by GET /todos/:id
+	// OriginalPriority is the priority auto-priority changed, restored when the user
+	// turns it off; nil while auto-priority hasn't touched the todo
+	OriginalPriority *Priority `json:"original_priority,omitempty"`
+	// PriorityLockedUntil keeps auto-priority off the todo until then, so its priority
+	// can be set by hand
+	PriorityLockedUntil *time.Time `json:"priority_locked_until"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 type TodoCreateRequest struct {
@@ -37,6 +46,29 @@ type TodoCreateRequest struct {
 	DueDate     *string  `json:"due_date"`
 	Priority    Priority `json:"priority"`
 	GroupID     *string  `json:"group_id"`
+	// EstimateEffort asks the AI for story points and a duration
+	EstimateEffort bool `json:"estimate_effort"`
+}
+
+// TodoEstimateRequest replaces a todo's effort estimate; nil fields clear it
+type TodoEstimateRequest struct {
+	StoryPoints       *int    `json:"story_points"`
+	EstimatedDuration *string `json:"estimated_duration"`
+}
+
+// TodoDependencyRequest marks the todo in the URL as blocked by BlockerID
+type TodoDependencyRequest struct {
+	BlockerID string `json:"blocker_id" binding:"required"`
+}
+
+// StoryPointValues are the story points a todo can be estimated at
+var StoryPointValues = []int{1, 2, 3, 5, 8, 13}
+
+// EffortEstimate is a todo's effort in story points and as a human duration
+// such as "~2 hours"; either may be nil when unknown
+type EffortEstimate struct {
+	StoryPoints       *int    `json:"story_points"`
+	EstimatedDuration *string `json:"estimated_duration"`
 }
 
 type TodoUpdateRequest struct {
@@ -48,6 +80,52 @@ type TodoUpdateRequest struct {
 	GroupID     *string   `json:"group_id"`
 	Position    *string   `json:"position"`
 	Tags        []string  `json:"tags"`
+	// PriorityLockedUntil sets Todo.PriorityLockedUntil; a time in the past ends the lock
+	PriorityLockedUntil *time.Time `json:"priority_locked_until"`
+}
+
+// Tag match modes for TodoFilterRequest
+const (
+	TagOpAnd = "AND"
+	TagOpOr  = "OR"
+)
+
+// Sort keys for TodoFilterRequest
+const (
+	TodoSortPosition  = "position"
+	TodoSortDueDate   = "due_date"
+	TodoSortCreatedAt = "created_at"
+	TodoSortPriority  = "priority"
+	TodoSortTitle     = "title"
+)
+
+// Sort orders for TodoFilterRequest
+const (
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+// TodoFilterRequest narrows and orders the todo list; zero-valued fields don't
+// filter, and an empty Sort keeps position order
+type TodoFilterRequest struct {
+	// Tags matches todos with any (TagOpOr) or all (TagOpAnd) of these tags, ignoring case
+	Tags                  []string
+	TagOp                 string
+	Status                Status
+	Priority              Priority
+	GroupID               string
+	IncludeArchivedGroups bool
+	// Sort is one of the TodoSort keys; Order is SortOrderAsc (default) or SortOrderDesc
+	Sort  string
+	Order string
+}
+
+// MaxBulkTodoIDs caps the number of todos a single bulk request may change
+const MaxBulkTodoIDs = 100
+
+type TodoBulkPriorityRequest struct {
+	IDs      []string `json:"ids" binding:"required,min=1,max=100,dive,required"`
+	Priority Priority `json:"priority" binding:"required,oneof=low medium high"`
 }
 
 type TodoReorderRequest struct {
@@ -58,3 +136,25 @@ type TodoPosition struct {
 	ID       string `json:"id" binding:"required"`
 	Position string `json:"position" binding:"required"`
 }
+
+// PositionResetStep is the gap between the positions todos and memories are given
+// when their positions are reset, leaving room to move items in between
+const PositionResetStep = 1000
+
+// StreakInfo summarizes a user's run of days with at least one completed todo.
+// A streak still counts as current when today has no completions yet but
+// yesterday did.
+type StreakInfo struct {
+	CurrentStreak int `json:"current_streak"`
+	LongestStreak int `json:"longest_streak"`
+	TodayCount    int `json:"today_count"`
+	DailyGoal     int `json:"daily_goal"`
+}
+
+// DayActivity is the number of todos completed on one day (YYYY-MM-DD, in the
+// user's timezone), for the streak calendar heatmap
+type DayActivity struct {
+	Date      string `json:"date"`
+	Count     int    `json:"count"`
+	IsGoalMet bool   `json:"is_goal_met"`
+}
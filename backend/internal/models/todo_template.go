@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// MaxTemplateTasks caps the number of tasks a single todo template may hold
+const MaxTemplateTasks = 50
+
+// TodoTemplateTask is one task a template creates when applied
+type TodoTemplateTask struct {
+	Title           string   `json:"title" binding:"required"`
+	Priority        Priority `json:"priority" binding:"omitempty,oneof=low medium high"`
+	Tags            []string `json:"tags"`
+	RelativeDueDays *int     `json:"relative_due_days" binding:"omitempty,min=0"`
+}
+
+// TodoTemplate is a reusable set of tasks. System templates have no user_id and can't be changed.
+type TodoTemplate struct {
+	ID          string             `json:"id"`
+	UserID      *string            `json:"user_id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Tasks       []TodoTemplateTask `json:"tasks"`
+	IsSystem    bool               `json:"is_system"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+type TodoTemplateCreateRequest struct {
+	Name        string             `json:"name" binding:"required"`
+	Description string             `json:"description"`
+	Tasks       []TodoTemplateTask `json:"tasks" binding:"required,min=1,max=50,dive"`
+}
+
+type TodoTemplateUpdateRequest struct {
+	Name        *string            `json:"name"`
+	Description *string            `json:"description"`
+	Tasks       []TodoTemplateTask `json:"tasks" binding:"omitempty,min=1,max=50,dive"`
+}
+
+// TemplateApplyOptions overrides how a template's tasks are created
+type TemplateApplyOptions struct {
+	// GroupID puts every created todo in this group
+	GroupID *string `json:"group_id"`
+	// StartDate (YYYY-MM-DD) replaces today as the date relative_due_days counts from
+	StartDate *string `json:"start_date"`
+}
@@ -3,22 +3,28 @@ package models
 import "time"
 
 type User struct {
-	ID           string    `json:"id"`
-	SupabaseID   *string   `json:"-"` // Supabase user ID (UUID)
-	Email        string    `json:"email"`
-	PasswordHash *string   `json:"-"` // Nullable for OAuth users
-	FullName     *string   `json:"full_name"`
-	Theme        string    `json:"theme"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                 string     `json:"id"`
+	SupabaseID         *string    `json:"-"` // Supabase user ID (UUID)
+	OIDCSub            *string    `json:"-"` // Subject at the OIDC provider, for SSO users
+	Email              string     `json:"email"`
+	PasswordHash       *string    `json:"-"` // Nullable for OAuth users
+	FullName           *string    `json:"full_name"`
+	Theme              string     `json:"theme"`
+	Timezone           string     `json:"timezone"`             // IANA name, used for server-side date parsing
+	EmailDigestEnabled bool       `json:"email_digest_enabled"` // opts into the weekly digest email
+	LastDigestSentAt   *time.Time `json:"-"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
 }
 
 type UserResponse struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	FullName  *string   `json:"full_name"`
-	Theme     string    `json:"theme"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                 string    `json:"id"`
+	Email              string    `json:"email"`
+	FullName           *string   `json:"full_name"`
+	Theme              string    `json:"theme"`
+	Timezone           string    `json:"timezone"`
+	EmailDigestEnabled bool      `json:"email_digest_enabled"`
+	CreatedAt          time.Time `json:"created_at"`
 }
 
 type RegisterRequest struct {
@@ -26,6 +32,73 @@ type RegisterRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 }
 
+type UpdateUserRequest struct {
+	Timezone           *string `json:"timezone"`
+	EmailDigestEnabled *bool   `json:"email_digest_enabled"`
+}
+
+// Memory sort modes, chosen per user for the memory list
+const (
+	MemorySortManual       = "manual" // drag-and-drop position
+	MemorySortNewest       = "newest"
+	MemorySortUpdated      = "updated"
+	MemorySortAlphabetical = "alphabetical"
+	MemorySortCategory     = "category"
+)
+
+// MemorySortModes are the valid memory sort modes
+var MemorySortModes = map[string]bool{
+	MemorySortManual:       true,
+	MemorySortNewest:       true,
+	MemorySortUpdated:      true,
+	MemorySortAlphabetical: true,
+	MemorySortCategory:     true,
+}
+
+type MemorySortRequest struct {
+	SortMode string `json:"sort_mode" binding:"required"`
+}
+
+// Keys of the user_preferences table
+const (
+	PreferenceAIProcessTodos    = "ai_process_todos"
+	PreferenceAIProcessMemories = "ai_process_memories"
+	PreferenceAutoPriority      = "auto_priority"
+)
+
+// UserPreferences are the per-user switches kept in the user_preferences table
+type UserPreferences struct {
+	// AIProcessTodos lets the AI clean up titles and tag new todos (default true)
+	AIProcessTodos bool `json:"ai_process_todos"`
+	// AIProcessMemories lets the AI categorize, summarize and title new memories (default true)
+	AIProcessMemories bool `json:"ai_process_memories"`
+	// AutoPriority sets the priority of pending todos from how soon they are due (default false)
+	AutoPriority bool `json:"auto_priority"`
+}
+
+// UserPreferencesUpdateRequest changes the preferences that are set, leaving the rest
+type UserPreferencesUpdateRequest struct {
+	AIProcessTodos    *bool `json:"ai_process_todos"`
+	AIProcessMemories *bool `json:"ai_process_memories"`
+	AutoPriority      *bool `json:"auto_priority"`
+}
+
+// Bounds of the number of todos a user aims to complete each day
+const (
+	DefaultDailyGoal = 3
+	MaxDailyGoal     = 100
+)
+
+type DailyGoalRequest struct {
+	DailyGoal int `json:"daily_goal" binding:"required"`
+}
+
+// DeleteAccountRequest confirms account deletion. SSO users leave out the password
+// and sign in at their provider again first (GET /api/auth/oidc/initiate?reauth=true).
+type DeleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
@@ -33,10 +106,12 @@ type LoginRequest struct {
 
 func (u *User) ToResponse() *UserResponse {
 	return &UserResponse{
-		ID:        u.ID,
-		Email:     u.Email,
-		FullName:  u.FullName,
-		Theme:     u.Theme,
-		CreatedAt: u.CreatedAt,
+		ID:                 u.ID,
+		Email:              u.Email,
+		FullName:           u.FullName,
+		Theme:              u.Theme,
+		Timezone:           u.Timezone,
+		EmailDigestEnabled: u.EmailDigestEnabled,
+		CreatedAt:          u.CreatedAt,
 	}
 }
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/todomyday/backend/internal/models"
@@ -17,10 +19,14 @@ func NewAIProviderRepository(db *sql.DB) *AIProviderRepository {
 
 func (r *AIProviderRepository) Create(provider *models.AIProvider) error {
 	query := `
-		INSERT INTO ai_providers (id, user_id, name, provider_type, base_url, api_key_encrypted, selected_model, is_default, is_enabled, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO ai_providers (id, user_id, name, provider_type, base_url, api_key_encrypted, selected_model, is_default, is_enabled, requests_per_minute, timeout_seconds, no_auth, supports_structured_output, default_temperature, default_max_tokens, metadata, embedding_model, embedding_dimension, fallback_provider_id, system_prompt, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := r.db.Exec(query,
+	metadata, err := encodeProviderMetadata(provider.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(query,
 		provider.ID,
 		provider.UserID,
 		provider.Name,
@@ -30,6 +36,17 @@ func (r *AIProviderRepository) Create(provider *models.AIProvider) error {
 		provider.SelectedModel,
 		provider.IsDefault,
 		provider.IsEnabled,
+		provider.RequestsPerMinute,
+		provider.TimeoutSeconds,
+		provider.NoAuth,
+		provider.SupportsStructuredOutput,
+		provider.DefaultTemperature,
+		provider.DefaultMaxTokens,
+		metadata,
+		provider.EmbeddingModel,
+		provider.EmbeddingDimension,
+		provider.FallbackProviderID,
+		provider.SystemPrompt,
 		provider.CreatedAt,
 		provider.UpdatedAt,
 	)
@@ -38,11 +55,12 @@ func (r *AIProviderRepository) Create(provider *models.AIProvider) error {
 
 func (r *AIProviderRepository) GetByID(id string) (*models.AIProvider, error) {
 	query := `
-		SELECT id, user_id, name, provider_type, base_url, api_key_encrypted, selected_model, is_default, is_enabled, created_at, updated_at
+		SELECT id, user_id, name, provider_type, base_url, api_key_encrypted, selected_model, is_default, is_enabled, requests_per_minute, timeout_seconds, no_auth, supports_structured_output, default_temperature, default_max_tokens, metadata, embedding_model, embedding_dimension, fallback_provider_id, system_prompt, created_at, updated_at
 		FROM ai_providers WHERE id = ?
 	`
 	var provider models.AIProvider
-	var selectedModel sql.NullString
+	var selectedModel, metadata, embeddingModel, fallbackProviderID sql.NullString
+	var embeddingDimension sql.NullInt64
 	err := r.db.QueryRow(query, id).Scan(
 		&provider.ID,
 		&provider.UserID,
@@ -53,6 +71,17 @@ func (r *AIProviderRepository) GetByID(id string) (*models.AIProvider, error) {
 		&selectedModel,
 		&provider.IsDefault,
 		&provider.IsEnabled,
+		&provider.RequestsPerMinute,
+		&provider.TimeoutSeconds,
+		&provider.NoAuth,
+		&provider.SupportsStructuredOutput,
+		&provider.DefaultTemperature,
+		&provider.DefaultMaxTokens,
+		&metadata,
+		&embeddingModel,
+		&embeddingDimension,
+		&fallbackProviderID,
+		&provider.SystemPrompt,
 		&provider.CreatedAt,
 		&provider.UpdatedAt,
 	)
@@ -62,12 +91,17 @@ func (r *AIProviderRepository) GetByID(id string) (*models.AIProvider, error) {
 	if selectedModel.Valid {
 		provider.SelectedModel = &selectedModel.String
 	}
+	provider.Metadata = decodeProviderMetadata(metadata)
+	scanEmbeddingConfig(&provider, embeddingModel, embeddingDimension)
+	if fallbackProviderID.Valid {
+		provider.FallbackProviderID = &fallbackProviderID.String
+	}
 	return &provider, nil
 }
 
 func (r *AIProviderRepository) GetByUserID(userID string) ([]models.AIProvider, error) {
 	query := `
-		SELECT id, user_id, name, provider_type, base_url, api_key_encrypted, selected_model, is_default, is_enabled, created_at, updated_at
+		SELECT id, user_id, name, provider_type, base_url, api_key_encrypted, selected_model, is_default, is_enabled, requests_per_minute, timeout_seconds, no_auth, supports_structured_output, default_temperature, default_max_tokens, metadata, embedding_model, embedding_dimension, fallback_provider_id, system_prompt, created_at, updated_at
 		FROM ai_providers WHERE user_id = ? ORDER BY created_at DESC
 	`
 	rows, err := r.db.Query(query, userID)
@@ -79,7 +113,8 @@ func (r *AIProviderRepository) GetByUserID(userID string) ([]models.AIProvider,
 	var providers []models.AIProvider
 	for rows.Next() {
 		var provider models.AIProvider
-		var selectedModel sql.NullString
+		var selectedModel, metadata, embeddingModel, fallbackProviderID sql.NullString
+		var embeddingDimension sql.NullInt64
 		if err := rows.Scan(
 			&provider.ID,
 			&provider.UserID,
@@ -90,6 +125,17 @@ func (r *AIProviderRepository) GetByUserID(userID string) ([]models.AIProvider,
 			&selectedModel,
 			&provider.IsDefault,
 			&provider.IsEnabled,
+			&provider.RequestsPerMinute,
+			&provider.TimeoutSeconds,
+			&provider.NoAuth,
+			&provider.SupportsStructuredOutput,
+			&provider.DefaultTemperature,
+			&provider.DefaultMaxTokens,
+			&metadata,
+			&embeddingModel,
+			&embeddingDimension,
+			&fallbackProviderID,
+			&provider.SystemPrompt,
 			&provider.CreatedAt,
 			&provider.UpdatedAt,
 		); err != nil {
@@ -98,6 +144,11 @@ func (r *AIProviderRepository) GetByUserID(userID string) ([]models.AIProvider,
 		if selectedModel.Valid {
 			provider.SelectedModel = &selectedModel.String
 		}
+		provider.Metadata = decodeProviderMetadata(metadata)
+		scanEmbeddingConfig(&provider, embeddingModel, embeddingDimension)
+		if fallbackProviderID.Valid {
+			provider.FallbackProviderID = &fallbackProviderID.String
+		}
 		providers = append(providers, provider)
 	}
 	return providers, nil
@@ -105,11 +156,12 @@ func (r *AIProviderRepository) GetByUserID(userID string) ([]models.AIProvider,
 
 func (r *AIProviderRepository) GetDefaultByUserID(userID string) (*models.AIProvider, error) {
 	query := `
-		SELECT id, user_id, name, provider_type, base_url, api_key_encrypted, selected_model, is_default, is_enabled, created_at, updated_at
+		SELECT id, user_id, name, provider_type, base_url, api_key_encrypted, selected_model, is_default, is_enabled, requests_per_minute, timeout_seconds, no_auth, supports_structured_output, default_temperature, default_max_tokens, metadata, embedding_model, embedding_dimension, fallback_provider_id, system_prompt, created_at, updated_at
 		FROM ai_providers WHERE user_id = ? AND is_default = 1 AND is_enabled = 1 LIMIT 1
 	`
 	var provider models.AIProvider
-	var selectedModel sql.NullString
+	var selectedModel, metadata, embeddingModel, fallbackProviderID sql.NullString
+	var embeddingDimension sql.NullInt64
 	err := r.db.QueryRow(query, userID).Scan(
 		&provider.ID,
 		&provider.UserID,
@@ -120,6 +172,17 @@ func (r *AIProviderRepository) GetDefaultByUserID(userID string) (*models.AIProv
 		&selectedModel,
 		&provider.IsDefault,
 		&provider.IsEnabled,
+		&provider.RequestsPerMinute,
+		&provider.TimeoutSeconds,
+		&provider.NoAuth,
+		&provider.SupportsStructuredOutput,
+		&provider.DefaultTemperature,
+		&provider.DefaultMaxTokens,
+		&metadata,
+		&embeddingModel,
+		&embeddingDimension,
+		&fallbackProviderID,
+		&provider.SystemPrompt,
 		&provider.CreatedAt,
 		&provider.UpdatedAt,
 	)
@@ -129,13 +192,33 @@ func (r *AIProviderRepository) GetDefaultByUserID(userID string) (*models.AIProv
 	if selectedModel.Valid {
 		provider.SelectedModel = &selectedModel.String
 	}
+	provider.Metadata = decodeProviderMetadata(metadata)
+	scanEmbeddingConfig(&provider, embeddingModel, embeddingDimension)
+	if fallbackProviderID.Valid {
+		provider.FallbackProviderID = &fallbackProviderID.String
+	}
 	return &provider, nil
 }
 
+// GetEmbeddingProviderByUserID returns the user's enabled provider with an embedding
+// model configured, preferring the default provider
+func (r *AIProviderRepository) GetEmbeddingProviderByUserID(userID string) (*models.AIProvider, error) {
+	query := `
+		SELECT id FROM ai_providers
+		WHERE user_id = ? AND is_enabled = 1 AND embedding_model IS NOT NULL AND embedding_model != ''
+		ORDER BY is_default DESC, created_at DESC LIMIT 1
+	`
+	var id string
+	if err := r.db.QueryRow(query, userID).Scan(&id); err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
 func (r *AIProviderRepository) Update(provider *models.AIProvider) error {
 	query := `
 		UPDATE ai_providers
-		SET name = ?, base_url = ?, api_key_encrypted = ?, selected_model = ?, is_default = ?, is_enabled = ?, updated_at = ?
+		SET name = ?, base_url = ?, api_key_encrypted = ?, selected_model = ?, is_default = ?, is_enabled = ?, requests_per_minute = ?, timeout_seconds = ?, no_auth = ?, supports_structured_output = ?, default_temperature = ?, default_max_tokens = ?, embedding_model = ?, embedding_dimension = ?, fallback_provider_id = ?, system_prompt = ?, updated_at = ?
 		WHERE id = ?
 	`
 	_, err := r.db.Exec(query,
@@ -145,6 +228,16 @@ func (r *AIProviderRepository) Update(provider *models.AIProvider) error {
 		provider.SelectedModel,
 		provider.IsDefault,
 		provider.IsEnabled,
+		provider.RequestsPerMinute,
+		provider.TimeoutSeconds,
+		provider.NoAuth,
+		provider.SupportsStructuredOutput,
+		provider.DefaultTemperature,
+		provider.DefaultMaxTokens,
+		provider.EmbeddingModel,
+		provider.EmbeddingDimension,
+		provider.FallbackProviderID,
+		provider.SystemPrompt,
 		time.Now(),
 		provider.ID,
 	)
@@ -170,16 +263,26 @@ func (r *AIProviderRepository) SaveModels(providerID string, models []models.AIP
 
 	// Insert new models
 	for _, model := range models {
-		query := `INSERT INTO ai_provider_models (id, provider_id, model_id, model_name, created_at) VALUES (?, ?, ?, ?, ?)`
-		if _, err := r.db.Exec(query, model.ID, model.ProviderID, model.ModelID, model.ModelName, model.CreatedAt); err != nil {
+		capabilities := model.Capabilities
+		if capabilities == nil {
+			capabilities = []string{}
+		}
+		encoded, err := json.Marshal(capabilities)
+		if err != nil {
+			return err
+		}
+		query := `INSERT INTO ai_provider_models (id, provider_id, model_id, model_name, capabilities, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+		if _, err := r.db.Exec(query, model.ID, model.ProviderID, model.ModelID, model.ModelName, string(encoded), model.CreatedAt); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// GetModelsByProviderID returns a provider's cached models. Capabilities is nil for
+// models cached before capabilities were recorded.
 func (r *AIProviderRepository) GetModelsByProviderID(providerID string) ([]models.AIProviderModel, error) {
-	query := `SELECT id, provider_id, model_id, model_name, created_at FROM ai_provider_models WHERE provider_id = ? ORDER BY model_name`
+	query := `SELECT id, provider_id, model_id, model_name, capabilities, created_at FROM ai_provider_models WHERE provider_id = ? ORDER BY model_name`
 	rows, err := r.db.Query(query, providerID)
 	if err != nil {
 		return nil, err
@@ -189,10 +292,129 @@ func (r *AIProviderRepository) GetModelsByProviderID(providerID string) ([]model
 	var providerModels []models.AIProviderModel
 	for rows.Next() {
 		var model models.AIProviderModel
-		if err := rows.Scan(&model.ID, &model.ProviderID, &model.ModelID, &model.ModelName, &model.CreatedAt); err != nil {
+		var capabilities sql.NullString
+		if err := rows.Scan(&model.ID, &model.ProviderID, &model.ModelID, &model.ModelName, &capabilities, &model.CreatedAt); err != nil {
 			return nil, err
 		}
+		if capabilities.Valid {
+			model.Capabilities = []string{}
+			json.Unmarshal([]byte(capabilities.String), &model.Capabilities)
+		}
 		providerModels = append(providerModels, model)
 	}
 	return providerModels, nil
 }
+
+// UpdateMetadata replaces a provider's metadata (e.g. a persistent assistant thread ID)
+func (r *AIProviderRepository) UpdateMetadata(id string, metadata map[string]string) error {
+	encoded, err := encodeProviderMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(`UPDATE ai_providers SET metadata = ?, updated_at = ? WHERE id = ?`, encoded, time.Now(), id)
+	return err
+}
+
+// scanEmbeddingConfig copies the nullable embedding columns onto a provider
+func scanEmbeddingConfig(provider *models.AIProvider, model sql.NullString, dimension sql.NullInt64) {
+	if model.Valid && model.String != "" {
+		provider.EmbeddingModel = &model.String
+	}
+	if dimension.Valid {
+		d := int(dimension.Int64)
+		provider.EmbeddingDimension = &d
+	}
+}
+
+func encodeProviderMetadata(metadata map[string]string) (*string, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	encoded := string(data)
+	return &encoded, nil
+}
+
+func decodeProviderMetadata(metadata sql.NullString) map[string]string {
+	if !metadata.Valid || metadata.String == "" {
+		return nil
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal([]byte(metadata.String), &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// encryptedColumns are the columns holding secrets encrypted with the ENCRYPTION_KEY
+var encryptedColumns = []struct{ table, column string }{
+	{"ai_providers", "api_key_encrypted"},
+	{"inbound_webhook_sources", "secret_encrypted"},
+}
+
+// ReEncryptSecrets rewrites every provider's API key and every inbound webhook
+// source's secret with reencrypt in a single transaction. If any secret fails,
+// nothing is written and the failures are returned.
+func (r *AIProviderRepository) ReEncryptSecrets(reencrypt func(ciphertext string) (string, error)) (int, []models.KeyRotationFailure, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	type encryptedSecret struct{ table, column, id, ciphertext string }
+	var secrets []encryptedSecret
+	for _, encrypted := range encryptedColumns {
+		rows, err := tx.Query("SELECT id, " + encrypted.column + " FROM " + encrypted.table)
+		if err != nil {
+			return 0, nil, err
+		}
+		for rows.Next() {
+			secret := encryptedSecret{table: encrypted.table, column: encrypted.column}
+			if err := rows.Scan(&secret.id, &secret.ciphertext); err != nil {
+				rows.Close()
+				return 0, nil, err
+			}
+			secrets = append(secrets, secret)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	failures := []models.KeyRotationFailure{}
+	rotated := make([]encryptedSecret, 0, len(secrets))
+	for _, secret := range secrets {
+		ciphertext, err := reencrypt(secret.ciphertext)
+		if err != nil {
+			failure := models.KeyRotationFailure{Error: err.Error()}
+			if secret.table == "ai_providers" {
+				failure.ProviderID = secret.id
+			} else {
+				failure.WebhookSourceID = secret.id
+			}
+			failures = append(failures, failure)
+			continue
+		}
+		secret.ciphertext = ciphertext
+		rotated = append(rotated, secret)
+	}
+	if len(failures) > 0 {
+		return 0, failures, nil
+	}
+
+	for _, secret := range rotated {
+		if _, err := tx.Exec("UPDATE "+secret.table+" SET "+secret.column+" = ? WHERE id = ?", secret.ciphertext, secret.id); err != nil {
+			return 0, nil, fmt.Errorf("failed to update %s of %s row %s: %w", secret.column, secret.table, secret.id, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return len(rotated), nil, nil
+}
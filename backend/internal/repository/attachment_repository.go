@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) Create(attachment *models.Attachment) error {
+	attachment.ID = uuid.New().String()
+	attachment.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO attachments (id, memory_id, storage_key, mime_type, size_bytes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, attachment.ID, attachment.MemoryID, attachment.StorageKey, attachment.MimeType, attachment.SizeBytes, attachment.CreatedAt)
+
+	return err
+}
+
+func (r *AttachmentRepository) GetByID(id string) (*models.Attachment, error) {
+	attachment := &models.Attachment{}
+
+	err := r.db.QueryRow(`
+		SELECT id, memory_id, storage_key, mime_type, size_bytes, created_at
+		FROM attachments WHERE id = ?
+	`, id).Scan(&attachment.ID, &attachment.MemoryID, &attachment.StorageKey, &attachment.MimeType, &attachment.SizeBytes, &attachment.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+// GetStorageKeysByMemoryIDs returns the storage keys of the memories' attachments
+func (r *AttachmentRepository) GetStorageKeysByMemoryIDs(memoryIDs []string) ([]string, error) {
+	if len(memoryIDs) == 0 {
+		return []string{}, nil
+	}
+
+	args := make([]interface{}, 0, len(memoryIDs))
+	for _, id := range memoryIDs {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(memoryIDs)), ",")
+
+	return r.queryStorageKeys(`SELECT storage_key FROM attachments WHERE memory_id IN (`+placeholders+`)`, args...)
+}
+
+// GetStorageKeysByUserID returns the storage keys of the attachments of all the user's memories
+func (r *AttachmentRepository) GetStorageKeysByUserID(userID string) ([]string, error) {
+	return r.queryStorageKeys(`
+		SELECT a.storage_key FROM attachments a
+		JOIN memories m ON m.id = a.memory_id
+		WHERE m.user_id = ?
+	`, userID)
+}
+
+func (r *AttachmentRepository) queryStorageKeys(query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Create(entry *models.AuditLogEntry) error {
+	entry.ID = uuid.New().String()
+	entry.CreatedAt = time.Now()
+
+	if entry.Metadata == nil {
+		entry.Metadata = map[string]string{}
+	}
+	metadataJSON, err := json.Marshal(entry.Metadata)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO audit_log (id, user_id, action, metadata, ip_address, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, entry.ID, entry.UserID, entry.Action, string(metadataJSON), entry.IPAddress, entry.CreatedAt)
+
+	return err
+}
+
+func (r *AuditRepository) GetByUserID(userID string, limit, offset int) ([]models.AuditLogEntry, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, user_id, action, metadata, ip_address, created_at
+		FROM audit_log
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+// GetByActions returns the latest entries of any user with one of the actions, newest
+// first
+func (r *AuditRepository) GetByActions(actions []string, limit int) ([]models.AuditLogEntry, error) {
+	if len(actions) == 0 {
+		return []models.AuditLogEntry{}, nil
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	args := make([]interface{}, 0, len(actions)+1)
+	for _, action := range actions {
+		args = append(args, action)
+	}
+	args = append(args, limit)
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(actions)), ",")
+
+	rows, err := r.db.Query(`
+		SELECT id, user_id, action, metadata, ip_address, created_at
+		FROM audit_log
+		WHERE action IN (`+placeholders+`)
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanAuditEntries(rows)
+}
+
+func scanAuditEntries(rows *sql.Rows) ([]models.AuditLogEntry, error) {
+	defer rows.Close()
+
+	entries := []models.AuditLogEntry{}
+	for rows.Next() {
+		entry := models.AuditLogEntry{}
+		var metadataJSON string
+		var ipAddress sql.NullString
+
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Action, &metadataJSON, &ipAddress, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(metadataJSON), &entry.Metadata); err != nil {
+			entry.Metadata = map[string]string{}
+		}
+		if ipAddress.Valid {
+			entry.IPAddress = &ipAddress.String
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// DeleteOlderThan removes audit log entries created before the given time
+func (r *AuditRepository) DeleteOlderThan(before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM audit_log WHERE created_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+type BackgroundJobRepository struct {
+	db *sql.DB
+}
+
+func NewBackgroundJobRepository(db *sql.DB) *BackgroundJobRepository {
+	return &BackgroundJobRepository{db: db}
+}
+
+// EnsureJob adds a job with its default schedule, enabled, unless it's already stored
+func (r *BackgroundJobRepository) EnsureJob(name, cronExpression string) error {
+	now := time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO background_jobs (id, job_name, cron_expression, is_enabled, created_at, updated_at)
+		VALUES (?, ?, ?, 1, ?, ?)
+		ON CONFLICT(job_name) DO NOTHING
+	`, uuid.New().String(), name, cronExpression, now, now)
+	return err
+}
+
+// GetAll returns every job, by name
+func (r *BackgroundJobRepository) GetAll() ([]models.BackgroundJob, error) {
+	rows, err := r.db.Query(`
+		SELECT id, job_name, cron_expression, is_enabled, last_run_at, last_duration_ms, last_error
+		FROM background_jobs
+		ORDER BY job_name ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.BackgroundJob{}
+	for rows.Next() {
+		var job models.BackgroundJob
+		if err := rows.Scan(&job.ID, &job.Name, &job.CronExpression, &job.IsEnabled,
+			&job.LastRunAt, &job.LastDurationMS, &job.LastError); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *BackgroundJobRepository) GetByName(name string) (*models.BackgroundJob, error) {
+	job := &models.BackgroundJob{}
+	err := r.db.QueryRow(`
+		SELECT id, job_name, cron_expression, is_enabled, last_run_at, last_duration_ms, last_error
+		FROM background_jobs WHERE job_name = ?
+	`, name).Scan(&job.ID, &job.Name, &job.CronExpression, &job.IsEnabled,
+		&job.LastRunAt, &job.LastDurationMS, &job.LastError)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// UpdateSchedule stores a job's cron expression and whether it runs
+func (r *BackgroundJobRepository) UpdateSchedule(name, cronExpression string, isEnabled bool) error {
+	_, err := r.db.Exec(`
+		UPDATE background_jobs SET cron_expression = ?, is_enabled = ?, updated_at = ? WHERE job_name = ?
+	`, cronExpression, isEnabled, time.Now(), name)
+	return err
+}
+
+// RecordRun stores the outcome of a run; lastError is nil for a successful run
+func (r *BackgroundJobRepository) RecordRun(name string, startedAt time.Time, durationMS int64, lastError *string) error {
+	_, err := r.db.Exec(`
+		UPDATE background_jobs SET last_run_at = ?, last_duration_ms = ?, last_error = ? WHERE job_name = ?
+	`, startedAt, durationMS, lastError, name)
+	return err
+}
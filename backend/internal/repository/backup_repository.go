@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/todomyday/backend/internal/database"
+)
+
+// BackupRepository takes snapshots of the live database
+type BackupRepository struct {
+	db *sql.DB
+}
+
+func NewBackupRepository(db *sql.DB) *BackupRepository {
+	return &BackupRepository{db: db}
+}
+
+// Backup writes a consistent copy of the database to path, which must not exist yet
+func (r *BackupRepository) Backup(path string) error {
+	return database.Backup(r.db, path)
+}
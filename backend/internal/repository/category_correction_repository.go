@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+// CategoryCorrectionRepository stores the category changes users make to memories
+type CategoryCorrectionRepository struct {
+	db *sql.DB
+}
+
+func NewCategoryCorrectionRepository(db *sql.DB) *CategoryCorrectionRepository {
+	return &CategoryCorrectionRepository{db: db}
+}
+
+// Create records a correction
+func (r *CategoryCorrectionRepository) Create(correction *models.CategoryCorrection) error {
+	correction.ID = uuid.New().String()
+	correction.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO category_corrections (id, user_id, content_hash, original_category, corrected_category, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, correction.ID, correction.UserID, correction.ContentHash, correction.OriginalCategory, correction.CorrectedCategory, correction.CreatedAt)
+	return err
+}
+
+// GetTopCategory returns the category the user has most often corrected memories with
+// the content hash to, and how many times; the most recent wins a tie. The category is
+// empty if there are no corrections.
+func (r *CategoryCorrectionRepository) GetTopCategory(userID, contentHash string) (string, int, error) {
+	var category string
+	var count int
+	err := r.db.QueryRow(`
+		SELECT corrected_category, COUNT(*)
+		FROM category_corrections
+		WHERE user_id = ? AND content_hash = ?
+		GROUP BY corrected_category
+		ORDER BY COUNT(*) DESC, MAX(created_at) DESC
+		LIMIT 1
+	`, userID, contentHash).Scan(&category, &count)
+	if err == sql.ErrNoRows {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return category, count, nil
+}
@@ -23,9 +23,9 @@ func (r *ChatRepository) CreateThread(thread *models.ChatThread) error {
 	thread.UpdatedAt = time.Now()
 
 	_, err := r.db.Exec(`
-		INSERT INTO chat_threads (id, user_id, created_at, updated_at)
-		VALUES (?, ?, ?, ?)
-	`, thread.ID, thread.UserID, thread.CreatedAt, thread.UpdatedAt)
+		INSERT INTO chat_threads (id, user_id, title, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, thread.ID, thread.UserID, thread.Title, thread.CreatedAt, thread.UpdatedAt)
 
 	return err
 }
@@ -33,11 +33,12 @@ func (r *ChatRepository) CreateThread(thread *models.ChatThread) error {
 // GetThreadByID returns a thread by ID
 func (r *ChatRepository) GetThreadByID(threadID string) (*models.ChatThread, error) {
 	thread := &models.ChatThread{}
+	var title sql.NullString
 
 	err := r.db.QueryRow(`
-		SELECT id, user_id, created_at, updated_at
+		SELECT id, user_id, title, created_at, updated_at
 		FROM chat_threads WHERE id = ?
-	`, threadID).Scan(&thread.ID, &thread.UserID, &thread.CreatedAt, &thread.UpdatedAt)
+	`, threadID).Scan(&thread.ID, &thread.UserID, &title, &thread.CreatedAt, &thread.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -46,13 +47,17 @@ func (r *ChatRepository) GetThreadByID(threadID string) (*models.ChatThread, err
 		return nil, err
 	}
 
+	if title.Valid {
+		thread.Title = &title.String
+	}
+
 	return thread, nil
 }
 
 // GetThreadsByUserID returns all threads for a user
 func (r *ChatRepository) GetThreadsByUserID(userID string) ([]models.ChatThread, error) {
 	rows, err := r.db.Query(`
-		SELECT id, user_id, created_at, updated_at
+		SELECT id, user_id, title, created_at, updated_at
 		FROM chat_threads
 		WHERE user_id = ?
 		ORDER BY updated_at DESC
@@ -65,9 +70,13 @@ func (r *ChatRepository) GetThreadsByUserID(userID string) ([]models.ChatThread,
 	var threads []models.ChatThread
 	for rows.Next() {
 		var thread models.ChatThread
-		if err := rows.Scan(&thread.ID, &thread.UserID, &thread.CreatedAt, &thread.UpdatedAt); err != nil {
+		var title sql.NullString
+		if err := rows.Scan(&thread.ID, &thread.UserID, &title, &thread.CreatedAt, &thread.UpdatedAt); err != nil {
 			return nil, err
 		}
+		if title.Valid {
+			thread.Title = &title.String
+		}
 		threads = append(threads, thread)
 	}
 
@@ -77,14 +86,15 @@ func (r *ChatRepository) GetThreadsByUserID(userID string) ([]models.ChatThread,
 // GetActiveThreadByUserID returns the most recently updated thread for a user
 func (r *ChatRepository) GetActiveThreadByUserID(userID string) (*models.ChatThread, error) {
 	thread := &models.ChatThread{}
+	var title sql.NullString
 
 	err := r.db.QueryRow(`
-		SELECT id, user_id, created_at, updated_at
+		SELECT id, user_id, title, created_at, updated_at
 		FROM chat_threads
 		WHERE user_id = ?
 		ORDER BY updated_at DESC
 		LIMIT 1
-	`, userID).Scan(&thread.ID, &thread.UserID, &thread.CreatedAt, &thread.UpdatedAt)
+	`, userID).Scan(&thread.ID, &thread.UserID, &title, &thread.CreatedAt, &thread.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -93,6 +103,10 @@ func (r *ChatRepository) GetActiveThreadByUserID(userID string) (*models.ChatThr
 		return nil, err
 	}
 
+	if title.Valid {
+		thread.Title = &title.String
+	}
+
 	return thread, nil
 }
 
@@ -106,6 +120,24 @@ func (r *ChatRepository) UpdateThread(threadID string) error {
 	return err
 }
 
+// UpdateThreadTitle sets a thread's title without touching updated_at,
+// so renaming doesn't change which thread is active
+func (r *ChatRepository) UpdateThreadTitle(threadID, title string) error {
+	_, err := r.db.Exec("UPDATE chat_threads SET title = ? WHERE id = ?", title, threadID)
+	return err
+}
+
+// SetThreadTitleIfEmpty sets a generated title only if the thread has none yet,
+// so it never overwrites a title the user set manually. Returns whether it was set.
+func (r *ChatRepository) SetThreadTitleIfEmpty(threadID, title string) (bool, error) {
+	result, err := r.db.Exec("UPDATE chat_threads SET title = ? WHERE id = ? AND (title IS NULL OR title = '')", title, threadID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
 // DeleteThread deletes a thread (cascade will delete messages)
 func (r *ChatRepository) DeleteThread(threadID string) error {
 	_, err := r.db.Exec("DELETE FROM chat_threads WHERE id = ?", threadID)
@@ -118,9 +150,9 @@ func (r *ChatRepository) CreateMessage(message *models.ChatMessage) error {
 	message.CreatedAt = time.Now()
 
 	_, err := r.db.Exec(`
-		INSERT INTO chat_messages (id, thread_id, role, content, mode, sources, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, message.ID, message.ThreadID, message.Role, message.Content, message.Mode, message.Sources, message.CreatedAt)
+		INSERT INTO chat_messages (id, thread_id, role, content, mode, sources, is_partial, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, message.ID, message.ThreadID, message.Role, message.Content, message.Mode, message.Sources, message.IsPartial, message.CreatedAt)
 
 	if err != nil {
 		return err
@@ -133,7 +165,7 @@ func (r *ChatRepository) CreateMessage(message *models.ChatMessage) error {
 // GetMessagesByThreadID returns all messages for a thread
 func (r *ChatRepository) GetMessagesByThreadID(threadID string) ([]models.ChatMessage, error) {
 	rows, err := r.db.Query(`
-		SELECT id, thread_id, role, content, mode, sources, created_at
+		SELECT id, thread_id, role, content, mode, sources, is_partial, created_at
 		FROM chat_messages
 		WHERE thread_id = ?
 		ORDER BY created_at ASC
@@ -148,7 +180,7 @@ func (r *ChatRepository) GetMessagesByThreadID(threadID string) ([]models.ChatMe
 		var message models.ChatMessage
 		var mode, sources sql.NullString
 
-		if err := rows.Scan(&message.ID, &message.ThreadID, &message.Role, &message.Content, &mode, &sources, &message.CreatedAt); err != nil {
+		if err := rows.Scan(&message.ID, &message.ThreadID, &message.Role, &message.Content, &mode, &sources, &message.IsPartial, &message.CreatedAt); err != nil {
 			return nil, err
 		}
 
@@ -1,14 +1,25 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/todomyday/backend/internal/models"
 )
 
+// The highlight marks and ellipsis of the snippets returned by Search
+const (
+	ftsMarkOpen  = "<mark>"
+	ftsMarkClose = "</mark>"
+	ftsEllipsis  = "..."
+)
+
 // FTSRepository handles full-text search using SQLite FTS5
 type FTSRepository struct {
 	db *sql.DB
@@ -19,12 +30,41 @@ func NewFTSRepository(db *sql.DB) *FTSRepository {
 	return &FTSRepository{db: db}
 }
 
-// InitFTSTables creates the FTS5 virtual tables if they don't exist
-func (r *FTSRepository) InitFTSTables() error {
-	// Create FTS5 virtual table for content search
-	// This indexes todos and memories for keyword search
-	ftsSchema := `
-	-- FTS5 table for full-text search across all content
+// Tokenizers content_fts can be built with. unicode61 splits text into words on
+// whitespace and punctuation; porter also stems English words on top of it, so "running"
+// matches "run". trigram indexes every run of three characters, so any substring
+// matches and CJK text, which has no spaces between words, becomes searchable, but
+// terms shorter than three characters match nothing.
+const (
+	FTSTokenizerPorter          = "porter"
+	FTSTokenizerUnicode61       = "unicode61"
+	FTSTokenizerTrigram         = "trigram"
+	FTSTokenizerPorterUnicode61 = "porter unicode61"
+
+	DefaultFTSTokenizer = FTSTokenizerPorterUnicode61
+)
+
+// FTSTokenizers are the accepted FTS_TOKENIZER values
+var FTSTokenizers = []string{FTSTokenizerPorter, FTSTokenizerUnicode61, FTSTokenizerTrigram, FTSTokenizerPorterUnicode61}
+
+// ftsTokenizerSetting is the system_settings key recording the tokenizer content_fts
+// was built with. Tables from before it was recorded were built with the default.
+const ftsTokenizerSetting = "fts_tokenizer"
+
+// NormalizeFTSTokenizer lowercases tokenizer and collapses its spaces, returning an
+// error if it isn't one of FTSTokenizers
+func NormalizeFTSTokenizer(tokenizer string) (string, error) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(tokenizer)), " ")
+	if !slices.Contains(FTSTokenizers, normalized) {
+		return "", fmt.Errorf("invalid FTS tokenizer %q (want one of %q)", tokenizer, FTSTokenizers)
+	}
+	return normalized, nil
+}
+
+// ftsTableSchema creates content_fts, which indexes todos and memories for keyword
+// search, with the tokenizer
+func ftsTableSchema(tokenizer string) string {
+	return fmt.Sprintf(`
 	CREATE VIRTUAL TABLE IF NOT EXISTS content_fts USING fts5(
 		content_id,
 		content_type,
@@ -33,9 +73,11 @@ func (r *FTSRepository) InitFTSTables() error {
 		content,
 		tags,
 		category,
-		tokenize='porter unicode61'
-	);
+		tokenize='%s'
+	);`, tokenizer)
+}
 
+const ftsTriggersSchema = `
 	-- Triggers to keep FTS in sync with todos
 	CREATE TRIGGER IF NOT EXISTS todos_ai AFTER INSERT ON todos BEGIN
 		INSERT INTO content_fts(content_id, content_type, user_id, title, content, tags, category)
@@ -69,49 +111,153 @@ func (r *FTSRepository) InitFTSTables() error {
 	END;
 	`
 
-	_, err := r.db.Exec(ftsSchema)
+// InitFTSTables creates the FTS5 virtual tables if they don't exist, building a new
+// table with the tokenizer. An existing table keeps the tokenizer it was built with;
+// see GetTokenizer and RebuildWithTokenizer.
+func (r *FTSRepository) InitFTSTables(tokenizer string) error {
+	tokenizer, err := NormalizeFTSTokenizer(tokenizer)
 	if err != nil {
+		return err
+	}
+
+	var existing int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'content_fts'").Scan(&existing); err != nil {
+		return fmt.Errorf("failed to check for FTS table: %w", err)
+	}
+
+	if _, err := r.db.Exec(ftsTableSchema(tokenizer) + ftsTriggersSchema); err != nil {
 		return fmt.Errorf("failed to create FTS tables: %w", err)
 	}
+	if existing == 0 {
+		if err := recordFTSTokenizer(r.db, tokenizer); err != nil {
+			return err
+		}
+	}
 
 	log.Printf("[FTS] Initialized FTS5 tables and triggers")
 	return nil
 }
 
-// PopulateFTSFromExisting populates FTS table from existing todos and memories
+// GetTokenizer returns the tokenizer content_fts was built with
+func (r *FTSRepository) GetTokenizer() (string, error) {
+	var tokenizer string
+	err := r.db.QueryRow("SELECT value FROM system_settings WHERE key = ?", ftsTokenizerSetting).Scan(&tokenizer)
+	if err == sql.ErrNoRows {
+		return DefaultFTSTokenizer, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read FTS tokenizer: %w", err)
+	}
+	return tokenizer, nil
+}
+
+// RebuildWithTokenizer drops content_fts and builds it again with the tokenizer, since
+// FTS5 tables can't be altered, then repopulates it from existing todos and memories.
+// It runs in one transaction, so searches keep using the old index until it's done.
+func (r *FTSRepository) RebuildWithTokenizer(tokenizer string) error {
+	tokenizer, err := NormalizeFTSTokenizer(tokenizer)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DROP TABLE IF EXISTS content_fts"); err != nil {
+		return fmt.Errorf("failed to drop FTS table: %w", err)
+	}
+	if _, err := tx.Exec(ftsTableSchema(tokenizer) + ftsTriggersSchema); err != nil {
+		return fmt.Errorf("failed to create FTS tables: %w", err)
+	}
+	count, err := populateFTS(tx)
+	if err != nil {
+		return err
+	}
+	if err := recordFTSTokenizer(tx, tokenizer); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit FTS rebuild: %w", err)
+	}
+	log.Printf("[FTS] Rebuilt FTS table with tokenizer %q and %d documents", tokenizer, count)
+
+	return nil
+}
+
+// execer is a *sql.DB or *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func recordFTSTokenizer(db execer, tokenizer string) error {
+	_, err := db.Exec(`
+		INSERT INTO system_settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, ftsTokenizerSetting, tokenizer, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record FTS tokenizer: %w", err)
+	}
+	return nil
+}
+
+// PopulateFTSFromExisting populates FTS table from existing todos and memories. It
+// runs in one transaction, so writes made meanwhile wait rather than being indexed twice
+// by their triggers and the bulk insert.
 func (r *FTSRepository) PopulateFTSFromExisting() error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// Clear existing FTS data
-	_, err := r.db.Exec("DELETE FROM content_fts")
+	_, err = tx.Exec("DELETE FROM content_fts")
 	if err != nil {
 		return fmt.Errorf("failed to clear FTS table: %w", err)
 	}
 
+	count, err := populateFTS(tx)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit FTS population: %w", err)
+	}
+	log.Printf("[FTS] Populated FTS table with %d documents", count)
+
+	return nil
+}
+
+// populateFTS indexes every todo and unarchived memory into an empty content_fts,
+// returning the number of documents indexed
+func populateFTS(tx *sql.Tx) (int, error) {
 	// Populate from todos
-	_, err = r.db.Exec(`
+	_, err := tx.Exec(`
 		INSERT INTO content_fts(content_id, content_type, user_id, title, content, tags, category)
 		SELECT id, 'todo', user_id, title, COALESCE(description, ''), tags, ''
 		FROM todos
 	`)
 	if err != nil {
-		return fmt.Errorf("failed to populate FTS from todos: %w", err)
+		return 0, fmt.Errorf("failed to populate FTS from todos: %w", err)
 	}
 
 	// Populate from memories
-	_, err = r.db.Exec(`
+	_, err = tx.Exec(`
 		INSERT INTO content_fts(content_id, content_type, user_id, title, content, tags, category)
 		SELECT id, 'memory', user_id, COALESCE(url_title, ''), content, '', category
 		FROM memories WHERE is_archived = 0
 	`)
 	if err != nil {
-		return fmt.Errorf("failed to populate FTS from memories: %w", err)
+		return 0, fmt.Errorf("failed to populate FTS from memories: %w", err)
 	}
 
 	// Get count
 	var count int
-	r.db.QueryRow("SELECT COUNT(*) FROM content_fts").Scan(&count)
-	log.Printf("[FTS] Populated FTS table with %d documents", count)
-
-	return nil
+	tx.QueryRow("SELECT COUNT(*) FROM content_fts").Scan(&count)
+	return count, nil
 }
 
 // FTSResult represents a single FTS search result
@@ -162,7 +308,7 @@ func (r *FTSRepository) Search(userID, query string, contentTypes []string, limi
 			tags,
 			category,
 			rank,
-			snippet(content_fts, 3, '<mark>', '</mark>', '...', 32) as snippet
+			snippet(content_fts, -1, '<mark>', '</mark>', '...', 32) as snippet
 		FROM content_fts
 		WHERE %s
 		ORDER BY rank
@@ -230,12 +376,139 @@ func (r *FTSRepository) SearchWithHighlights(userID, query string, contentTypes
 			Score:      score,
 			MatchType:  "keyword",
 			Highlights: []string{fts.Snippet},
+			Spans:      highlightSpans(fts.Snippet, fts.Content),
 		})
 	}
 
 	return results, nil
 }
 
+// highlightSpans locates the <mark>ed words of a snippet of content in content itself.
+// The snippet is usually found in content as it is, once its marks and the ellipses
+// around it are removed; otherwise each marked word is searched for after the previous
+// one.
+func highlightSpans(snippet, content string) []models.HighlightSpan {
+	// Remove the marks, keeping the byte ranges they enclosed
+	var plain strings.Builder
+	var marked [][2]int
+	for rest := snippet; rest != ""; {
+		start := strings.Index(rest, ftsMarkOpen)
+		if start < 0 {
+			plain.WriteString(rest)
+			break
+		}
+		plain.WriteString(rest[:start])
+		rest = rest[start+len(ftsMarkOpen):]
+		end := strings.Index(rest, ftsMarkClose)
+		if end < 0 {
+			end = len(rest)
+		}
+		marked = append(marked, [2]int{plain.Len(), plain.Len() + end})
+		plain.WriteString(rest[:end])
+		rest = strings.TrimPrefix(rest[end:], ftsMarkClose)
+	}
+	if len(marked) == 0 {
+		return nil
+	}
+
+	// The fragment with the ellipses FTS5 adds when it doesn't reach an end of content
+	fragment := plain.String()
+	offset := 0
+	if !strings.Contains(content, fragment) {
+		if trimmed := strings.TrimPrefix(fragment, ftsEllipsis); len(trimmed) < len(fragment) {
+			fragment, offset = trimmed, len(ftsEllipsis)
+		}
+		fragment = strings.TrimSuffix(fragment, ftsEllipsis)
+	}
+
+	byteSpans := make([][2]int, 0, len(marked))
+	if base := strings.Index(content, fragment); base >= 0 && fragment != "" {
+		for _, m := range marked {
+			start, end := base+m[0]-offset, base+m[1]-offset
+			if start >= base && end <= base+len(fragment) {
+				byteSpans = append(byteSpans, [2]int{start, end})
+			}
+		}
+	} else {
+		from := 0
+		for _, m := range marked {
+			word := plain.String()[m[0]:m[1]]
+			i := strings.Index(content[from:], word)
+			if word == "" || i < 0 {
+				continue
+			}
+			byteSpans = append(byteSpans, [2]int{from + i, from + i + len(word)})
+			from += i + len(word)
+		}
+	}
+
+	// Byte offsets to character offsets
+	spans := make([]models.HighlightSpan, 0, len(byteSpans))
+	for _, s := range byteSpans {
+		start := utf8.RuneCountInString(content[:s[0]])
+		spans = append(spans, models.HighlightSpan{
+			Start: start,
+			End:   start + utf8.RuneCountInString(content[s[0]:s[1]]),
+		})
+	}
+	return spans
+}
+
+// Suggest returns distinct titles starting with prefix, most frequent first.
+// Only the title column is matched; the prefix is quoted so FTS5 operators in
+// user input are treated as literal text.
+func (r *FTSRepository) Suggest(ctx context.Context, userID, prefix string, contentTypes []string, limit int) ([]string, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+
+	ftsQuery := prepareFTSPrefixQuery(prefix)
+	if ftsQuery == "" {
+		return []string{}, nil
+	}
+
+	whereClause := "content_fts MATCH ? AND user_id = ? AND title != ''"
+	args := []interface{}{"title : (" + ftsQuery + ")", userID}
+
+	if len(contentTypes) > 0 {
+		placeholders := make([]string, len(contentTypes))
+		for i, ct := range contentTypes {
+			placeholders[i] = "?"
+			args = append(args, ct)
+		}
+		whereClause += fmt.Sprintf(" AND content_type IN (%s)", strings.Join(placeholders, ","))
+	}
+
+	args = append(args, limit)
+
+	sqlQuery := fmt.Sprintf(`
+		SELECT MIN(title), COUNT(*) AS frequency
+		FROM content_fts
+		WHERE %s
+		GROUP BY LOWER(title)
+		ORDER BY frequency DESC, MIN(title)
+		LIMIT ?
+	`, whereClause)
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("FTS suggest failed: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []string{}
+	for rows.Next() {
+		var title string
+		var frequency int
+		if err := rows.Scan(&title, &frequency); err != nil {
+			return nil, fmt.Errorf("failed to scan FTS suggestion: %w", err)
+		}
+		suggestions = append(suggestions, title)
+	}
+
+	return suggestions, rows.Err()
+}
+
 // GetDocumentCount returns the number of documents in the FTS index
 func (r *FTSRepository) GetDocumentCount() (int, error) {
 	var count int
@@ -299,3 +572,72 @@ func prepareFTSQuery(query string) string {
 
 	return strings.Join(parts, " ")
 }
+
+// prepareFTSPrefixQuery turns user input into an FTS5 prefix query: every word
+// becomes a quoted string (embedded quotes doubled) and the last one gets the
+// prefix wildcard, so "buy gro" becomes "buy" "gro"*. Quoting neutralizes
+// operators like *, NEAR, OR and column filters in the input.
+func prepareFTSPrefixQuery(prefix string) string {
+	words := strings.Fields(prefix)
+	if len(words) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(words))
+	for i, word := range words {
+		parts[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+	parts[len(parts)-1] += "*"
+
+	return strings.Join(parts, " ")
+}
+
+// FTSHealthReport is the result of an FTS index health check. ExpectedCount is
+// the number of todos plus unarchived memories, and Drift is how far IndexedCount
+// is from it as a fraction of ExpectedCount.
+type FTSHealthReport struct {
+	CheckedAt      time.Time `json:"checked_at"`
+	IntegrityOK    bool      `json:"integrity_ok"`
+	IntegrityError string    `json:"integrity_error,omitempty"`
+	IndexedCount   int       `json:"indexed_count"`
+	ExpectedCount  int       `json:"expected_count"`
+	Drift          float64   `json:"drift"`
+	// Rebuilt is set when the check led to the index being repopulated
+	Rebuilt bool `json:"rebuilt"`
+}
+
+// HealthCheck runs the FTS5 integrity check and compares the number of indexed
+// documents with the number of todos and unarchived memories. A failed integrity
+// check is reported rather than returned as an error.
+func (r *FTSRepository) HealthCheck(ctx context.Context) (*FTSHealthReport, error) {
+	report := &FTSHealthReport{CheckedAt: time.Now(), IntegrityOK: true}
+
+	if _, err := r.db.ExecContext(ctx, "INSERT INTO content_fts(content_fts) VALUES('integrity-check')"); err != nil {
+		report.IntegrityOK = false
+		report.IntegrityError = err.Error()
+	}
+
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM content_fts").Scan(&report.IndexedCount); err != nil {
+		return nil, fmt.Errorf("failed to count FTS documents: %w", err)
+	}
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM todos) + (SELECT COUNT(*) FROM memories WHERE is_archived = 0)
+	`).Scan(&report.ExpectedCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count indexable documents: %w", err)
+	}
+
+	diff := report.IndexedCount - report.ExpectedCount
+	if diff < 0 {
+		diff = -diff
+	}
+	switch {
+	case report.ExpectedCount > 0:
+		report.Drift = float64(diff) / float64(report.ExpectedCount)
+	case diff > 0:
+		report.Drift = 1
+	}
+
+	return report, nil
+}
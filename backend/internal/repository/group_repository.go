@@ -2,6 +2,9 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -26,22 +29,22 @@ func (r *GroupRepository) Create(group *models.Group) error {
 	}
 
 	_, err := r.db.Exec(`
-		INSERT INTO groups (id, user_id, name, color_code, is_default, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, group.ID, group.UserID, group.Name, group.ColorCode, group.IsDefault, group.CreatedAt, group.UpdatedAt)
+		INSERT INTO groups (id, user_id, name, color_code, color_dark, is_default, parent_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, group.ID, group.UserID, group.Name, group.ColorCode, nullIfEmpty(group.ColorDark), group.IsDefault, group.ParentID, group.CreatedAt, group.UpdatedAt)
 
 	return err
 }
 
 func (r *GroupRepository) GetByID(id string) (*models.Group, error) {
 	group := &models.Group{}
-	var userID sql.NullString
-	var isDefault int
+	var userID, parentID sql.NullString
+	var isDefault, isArchived int
 
 	err := r.db.QueryRow(`
-		SELECT id, user_id, name, color_code, is_default, created_at, updated_at
+		SELECT id, user_id, name, color_code, COALESCE(color_dark, ''), is_default, is_archived, parent_id, created_at, updated_at
 		FROM groups WHERE id = ?
-	`, id).Scan(&group.ID, &userID, &group.Name, &group.ColorCode, &isDefault, &group.CreatedAt, &group.UpdatedAt)
+	`, id).Scan(&group.ID, &userID, &group.Name, &group.ColorCode, &group.ColorDark, &isDefault, &isArchived, &parentID, &group.CreatedAt, &group.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -53,31 +56,109 @@ func (r *GroupRepository) GetByID(id string) (*models.Group, error) {
 	if userID.Valid {
 		group.UserID = &userID.String
 	}
+	if parentID.Valid {
+		group.ParentID = &parentID.String
+	}
 	group.IsDefault = isDefault == 1
+	group.IsArchived = isArchived == 1
 
 	return group, nil
 }
 
-func (r *GroupRepository) GetAllByUserID(userID string) ([]models.Group, error) {
+// GetByIDs returns the groups with the given IDs that the user owns or that are
+// default groups, in no particular order
+func (r *GroupRepository) GetByIDs(userID string, ids []string) ([]models.Group, error) {
+	if len(ids) == 0 {
+		return []models.Group{}, nil
+	}
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, userID)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+
+	rows, err := r.db.Query(`
+		SELECT id, user_id, name, color_code, COALESCE(color_dark, ''), is_default, is_archived, parent_id, created_at, updated_at
+		FROM groups
+		WHERE (user_id = ? OR is_default = 1) AND id IN (`+placeholders+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanGroups(rows)
+}
+
+// GetAllByUserID returns the user's groups and the default groups, each with its
+// direct sub-groups in Children. Archived groups are left out unless includeArchived is set.
+func (r *GroupRepository) GetAllByUserID(userID string, includeArchived bool) ([]models.Group, error) {
 	// Get both user's groups and default groups
+	query := `
+		SELECT id, user_id, name, color_code, COALESCE(color_dark, ''), is_default, is_archived, parent_id, created_at, updated_at
+		FROM groups
+		WHERE (user_id = ? OR is_default = 1)`
+	if !includeArchived {
+		query += " AND is_archived = 0"
+	}
+	query += " ORDER BY is_default DESC, created_at ASC"
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups, err := scanGroups(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach sub-groups from the same listing so archived children follow includeArchived
+	for i := range groups {
+		for _, child := range groups {
+			if child.ParentID != nil && *child.ParentID == groups[i].ID {
+				groups[i].Children = append(groups[i].Children, child)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// GetChildren returns the user's direct sub-groups of a group, including archived ones
+func (r *GroupRepository) GetChildren(userID, parentID string) ([]models.Group, error) {
 	rows, err := r.db.Query(`
-		SELECT id, user_id, name, color_code, is_default, created_at, updated_at
+		SELECT id, user_id, name, color_code, COALESCE(color_dark, ''), is_default, is_archived, parent_id, created_at, updated_at
 		FROM groups
-		WHERE user_id = ? OR is_default = 1
-		ORDER BY is_default DESC, created_at ASC
-	`, userID)
+		WHERE parent_id = ? AND user_id = ?
+		ORDER BY created_at ASC
+	`, parentID, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanGroups(rows)
+}
+
+// HasChildren reports whether the user has any sub-groups under a group
+func (r *GroupRepository) HasChildren(userID, parentID string) (bool, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM groups WHERE parent_id = ? AND user_id = ?", parentID, userID).Scan(&count)
+	return count > 0, err
+}
+
+func scanGroups(rows *sql.Rows) ([]models.Group, error) {
 	groups := []models.Group{}
 	for rows.Next() {
 		group := models.Group{}
-		var uid sql.NullString
-		var isDefault int
+		var uid, parentID sql.NullString
+		var isDefault, isArchived int
 
-		err := rows.Scan(&group.ID, &uid, &group.Name, &group.ColorCode, &isDefault, &group.CreatedAt, &group.UpdatedAt)
+		err := rows.Scan(&group.ID, &uid, &group.Name, &group.ColorCode, &group.ColorDark, &isDefault, &isArchived, &parentID, &group.CreatedAt, &group.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -85,7 +166,11 @@ func (r *GroupRepository) GetAllByUserID(userID string) ([]models.Group, error)
 		if uid.Valid {
 			group.UserID = &uid.String
 		}
+		if parentID.Valid {
+			group.ParentID = &parentID.String
+		}
 		group.IsDefault = isDefault == 1
+		group.IsArchived = isArchived == 1
 
 		groups = append(groups, group)
 	}
@@ -116,6 +201,47 @@ func (r *GroupRepository) Update(id string, updates map[string]interface{}) erro
 	return err
 }
 
+// GetTodosByGroupID returns the user's todos in a group, in position order
+func (r *GroupRepository) GetTodosByGroupID(groupID, userID string) ([]models.Todo, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, original_priority, priority_locked_until, created_at, updated_at
+		FROM todos
+		WHERE group_id = ? AND user_id = ?
+		ORDER BY CAST(position AS INTEGER) ASC, created_at ASC
+	`, groupID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTodos(rows)
+}
+
+// DeleteMovingTodos deletes a non-default group in the same transaction as moving its
+// todos to targetGroupID, with the positions and tags already set on todos
+func (r *GroupRepository) DeleteMovingTodos(id, targetGroupID string, todos []models.Todo) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, todo := range todos {
+		tagsJSON, _ := json.Marshal(todo.Tags)
+		if _, err := tx.Exec(`
+			UPDATE todos SET group_id = ?, position = ?, tags = ?, updated_at = ? WHERE id = ? AND group_id = ?
+		`, targetGroupID, todo.Position, string(tagsJSON), now, todo.ID, id); err != nil {
+			return fmt.Errorf("failed to move todo %s: %w", todo.ID, err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM groups WHERE id = ? AND is_default = 0", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (r *GroupRepository) Delete(id string) error {
 	// Only allow deleting non-default groups
 	_, err := r.db.Exec("DELETE FROM groups WHERE id = ? AND is_default = 0", id)
@@ -131,9 +257,65 @@ func (r *GroupRepository) DeleteAllCustomByUserID(userID string) (int64, error)
 	return result.RowsAffected()
 }
 
+// Import inserts groups keeping their IDs, skipping any whose ID already exists.
+// Returns how many were inserted.
+func (r *GroupRepository) Import(groups []models.Group) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO groups (id, user_id, name, color_code, color_dark, is_default, is_archived, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, g := range groups {
+		if g.ColorCode == "" {
+			g.ColorCode = "#4F46E5"
+		}
+		result, err := stmt.Exec(g.ID, g.UserID, g.Name, g.ColorCode, nullIfEmpty(g.ColorDark), g.IsArchived, g.CreatedAt, g.UpdatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to import group %s: %w", g.ID, err)
+		}
+		n, _ := result.RowsAffected()
+		inserted += int(n)
+	}
+
+	// Link sub-groups once every group in the batch exists, and only to a parent of the same user
+	for _, g := range groups {
+		if g.ParentID == nil {
+			continue
+		}
+		if _, err := tx.Exec(`
+			UPDATE groups SET parent_id = ?
+			WHERE id = ? AND user_id = ? AND parent_id IS NULL
+			AND EXISTS (SELECT 1 FROM groups p WHERE p.id = ? AND (p.user_id = ? OR p.is_default = 1))
+		`, *g.ParentID, g.ID, g.UserID, *g.ParentID, g.UserID); err != nil {
+			return 0, fmt.Errorf("failed to link imported group %s: %w", g.ID, err)
+		}
+	}
+
+	return inserted, tx.Commit()
+}
+
 // CountCustomByUserID returns the count of custom (non-default) groups for a user
 func (r *GroupRepository) CountCustomByUserID(userID string) (int, error) {
 	var count int
 	err := r.db.QueryRow("SELECT COUNT(*) FROM groups WHERE user_id = ? AND is_default = 0", userID).Scan(&count)
 	return count, err
 }
+
+// nullIfEmpty stores an empty optional column as NULL
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
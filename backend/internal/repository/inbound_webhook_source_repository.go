@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+type InboundWebhookSourceRepository struct {
+	db *sql.DB
+}
+
+func NewInboundWebhookSourceRepository(db *sql.DB) *InboundWebhookSourceRepository {
+	return &InboundWebhookSourceRepository{db: db}
+}
+
+const inboundWebhookSourceColumns = `id, name, user_id, secret_encrypted, rules, is_enabled, created_at, updated_at`
+
+func (r *InboundWebhookSourceRepository) Create(source *models.InboundWebhookSource) error {
+	rules, err := json.Marshal(source.Rules)
+	if err != nil {
+		return err
+	}
+
+	source.ID = uuid.New().String()
+	source.CreatedAt = time.Now()
+	source.UpdatedAt = source.CreatedAt
+
+	_, err = r.db.Exec(`
+		INSERT INTO inbound_webhook_sources (id, name, user_id, secret_encrypted, rules, is_enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, source.ID, source.Name, source.UserID, source.SecretEncrypted, string(rules), source.IsEnabled, source.CreatedAt, source.UpdatedAt)
+
+	return err
+}
+
+func (r *InboundWebhookSourceRepository) GetByID(id string) (*models.InboundWebhookSource, error) {
+	return r.getOne(`SELECT `+inboundWebhookSourceColumns+` FROM inbound_webhook_sources WHERE id = ?`, id)
+}
+
+func (r *InboundWebhookSourceRepository) GetByName(name string) (*models.InboundWebhookSource, error) {
+	return r.getOne(`SELECT `+inboundWebhookSourceColumns+` FROM inbound_webhook_sources WHERE name = ?`, name)
+}
+
+func (r *InboundWebhookSourceRepository) getOne(query string, arg string) (*models.InboundWebhookSource, error) {
+	source, err := scanInboundWebhookSource(r.db.QueryRow(query, arg))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func (r *InboundWebhookSourceRepository) GetAll() ([]models.InboundWebhookSource, error) {
+	rows, err := r.db.Query(`SELECT ` + inboundWebhookSourceColumns + ` FROM inbound_webhook_sources ORDER BY name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sources := []models.InboundWebhookSource{}
+	for rows.Next() {
+		source, err := scanInboundWebhookSource(rows)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, *source)
+	}
+
+	return sources, rows.Err()
+}
+
+// Update saves the source's user, encrypted secret, rules and enabled flag
+func (r *InboundWebhookSourceRepository) Update(source *models.InboundWebhookSource) error {
+	rules, err := json.Marshal(source.Rules)
+	if err != nil {
+		return err
+	}
+
+	source.UpdatedAt = time.Now()
+	_, err = r.db.Exec(`
+		UPDATE inbound_webhook_sources
+		SET user_id = ?, secret_encrypted = ?, rules = ?, is_enabled = ?, updated_at = ?
+		WHERE id = ?
+	`, source.UserID, source.SecretEncrypted, string(rules), source.IsEnabled, source.UpdatedAt, source.ID)
+
+	return err
+}
+
+func (r *InboundWebhookSourceRepository) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM inbound_webhook_sources WHERE id = ?", id)
+	return err
+}
+
+func scanInboundWebhookSource(row interface{ Scan(...interface{}) error }) (*models.InboundWebhookSource, error) {
+	source := &models.InboundWebhookSource{}
+	var rules string
+
+	if err := row.Scan(&source.ID, &source.Name, &source.UserID, &source.SecretEncrypted, &rules,
+		&source.IsEnabled, &source.CreatedAt, &source.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(rules), &source.Rules); err != nil {
+		return nil, fmt.Errorf("invalid rules of inbound webhook source %s: %w", source.Name, err)
+	}
+
+	return source, nil
+}
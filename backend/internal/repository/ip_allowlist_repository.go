@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+type IPAllowlistRepository struct {
+	db *sql.DB
+}
+
+func NewIPAllowlistRepository(db *sql.DB) *IPAllowlistRepository {
+	return &IPAllowlistRepository{db: db}
+}
+
+func (r *IPAllowlistRepository) Create(entry *models.IPAllowlistEntry) error {
+	entry.ID = uuid.New().String()
+	entry.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO ip_allowlist (id, user_id, cidr, label, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, entry.ID, entry.UserID, entry.CIDR, entry.Label, entry.CreatedAt)
+
+	return err
+}
+
+func (r *IPAllowlistRepository) GetByID(id string) (*models.IPAllowlistEntry, error) {
+	entry := &models.IPAllowlistEntry{}
+
+	err := r.db.QueryRow(`
+		SELECT id, user_id, cidr, COALESCE(label, ''), created_at
+		FROM ip_allowlist WHERE id = ?
+	`, id).Scan(&entry.ID, &entry.UserID, &entry.CIDR, &entry.Label, &entry.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (r *IPAllowlistRepository) GetAllByUserID(userID string) ([]models.IPAllowlistEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, cidr, COALESCE(label, ''), created_at
+		FROM ip_allowlist
+		WHERE user_id = ?
+		ORDER BY created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.IPAllowlistEntry{}
+	for rows.Next() {
+		entry := models.IPAllowlistEntry{}
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.CIDR, &entry.Label, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (r *IPAllowlistRepository) Update(id string, updates map[string]interface{}) error {
+	query := "UPDATE ip_allowlist SET "
+	args := []interface{}{}
+	first := true
+
+	for key, value := range updates {
+		if !first {
+			query += ", "
+		}
+		query += key + " = ?"
+		args = append(args, value)
+		first = false
+	}
+
+	query += " WHERE id = ?"
+	args = append(args, id)
+
+	_, err := r.db.Exec(query, args...)
+	return err
+}
+
+func (r *IPAllowlistRepository) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM ip_allowlist WHERE id = ?", id)
+	return err
+}
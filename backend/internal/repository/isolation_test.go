@@ -0,0 +1,684 @@
+package repository_test
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/todomyday/backend/internal/crypto"
+	"github.com/todomyday/backend/internal/database"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+	"github.com/todomyday/backend/internal/services"
+)
+
+// isolationRows are the IDs of one user's seeded rows
+type isolationRows struct {
+	userID, memoryID, todoID, blockerID, groupID, allowlistID, blocklistID, promptID string
+	feedID, todoTemplateID, threadID, providerID, savedSearchID                      string
+}
+
+// isolationFixture is a database holding the same kinds of rows for two users, with
+// the repositories and services that read and write them
+type isolationFixture struct {
+	db              *sql.DB
+	owner, intruder isolationRows
+
+	memoryRepo        *repository.MemoryRepository
+	todoRepo          *repository.TodoRepository
+	groupRepo         *repository.GroupRepository
+	ftsRepo           *repository.FTSRepository
+	allowlistRepo     *repository.IPAllowlistRepository
+	blocklistRepo     *repository.KeywordBlocklistRepository
+	promptRepo        *repository.PromptTemplateRepository
+	feedRepo          *repository.RSSFeedRepository
+	todoTemplateRepo  *repository.TodoTemplateRepository
+	chatRepo          *repository.ChatRepository
+	providerRepo      *repository.AIProviderRepository
+	searchHistoryRepo *repository.SearchHistoryRepository
+
+	memories      *services.MemoryService
+	todos         *services.TodoService
+	groups        *services.GroupService
+	allowlist     *services.IPAllowlistService
+	blocklist     *services.BlocklistService
+	prompts       *services.PromptTemplateService
+	feeds         *services.RSSFeedService
+	todoTemplates *services.TodoTemplateService
+	chat          *services.ChatService
+	providers     *services.AIProviderService
+	searchHistory *services.SearchHistoryService
+	shares        *services.ShareService
+}
+
+func newIsolationFixture(t *testing.T) *isolationFixture {
+	t.Helper()
+	db, err := database.Connect(filepath.Join(t.TempDir(), "test.db"), database.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	f := &isolationFixture{
+		db:                db,
+		memoryRepo:        repository.NewMemoryRepository(db),
+		todoRepo:          repository.NewTodoRepository(db),
+		groupRepo:         repository.NewGroupRepository(db),
+		ftsRepo:           repository.NewFTSRepository(db),
+		allowlistRepo:     repository.NewIPAllowlistRepository(db),
+		blocklistRepo:     repository.NewKeywordBlocklistRepository(db),
+		promptRepo:        repository.NewPromptTemplateRepository(db),
+		feedRepo:          repository.NewRSSFeedRepository(db),
+		todoTemplateRepo:  repository.NewTodoTemplateRepository(db),
+		chatRepo:          repository.NewChatRepository(db),
+		providerRepo:      repository.NewAIProviderRepository(db),
+		searchHistoryRepo: repository.NewSearchHistoryRepository(db),
+	}
+	if err := f.ftsRepo.InitFTSTables(repository.DefaultFTSTokenizer); err != nil {
+		t.Fatalf("InitFTSTables: %v", err)
+	}
+
+	userRepo := repository.NewUserRepository(db)
+	f.memories = services.NewMemoryService(f.memoryRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	f.todos = services.NewTodoService(f.todoRepo, f.groupRepo, userRepo, nil, nil, nil, nil, nil, nil)
+	f.groups = services.NewGroupService(f.groupRepo, f.todoRepo)
+	f.allowlist = services.NewIPAllowlistService(f.allowlistRepo, nil)
+	f.blocklist = services.NewBlocklistService(f.blocklistRepo, nil)
+	f.prompts = services.NewPromptTemplateService(f.promptRepo)
+	f.feeds = services.NewRSSFeedService(f.feedRepo, f.memoryRepo, f.memories, nil)
+	f.todoTemplates = services.NewTodoTemplateService(f.todoTemplateRepo, f.todos)
+	f.chat = services.NewChatService(f.chatRepo, nil, nil)
+	f.providers = services.NewAIProviderService(f.providerRepo, crypto.NewEncryptor("test-key"), nil)
+	f.searchHistory = services.NewSearchHistoryService(f.searchHistoryRepo, false)
+	f.shares = services.NewShareService(repository.NewShareTokenRepository(db), f.memoryRepo, "https://app.example.com")
+
+	f.owner = f.seed(t, userRepo, "owner@example.com")
+	f.intruder = f.seed(t, userRepo, "intruder@example.com")
+	return f
+}
+
+// seed creates a user and one row of each kind for them. Both users' rows match the
+// same searches and filters, so a query missing its user_id condition returns both.
+func (f *isolationFixture) seed(t *testing.T, userRepo *repository.UserRepository, email string) isolationRows {
+	t.Helper()
+	check := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("failed to seed %s: %v", email, err)
+		}
+	}
+
+	user := &models.User{Email: email}
+	check(userRepo.Create(user))
+	rows := isolationRows{userID: user.ID}
+
+	memory := &models.Memory{UserID: user.ID, Content: "Lighthouse keeper's logbook", Category: "Lighthouse"}
+	check(f.memoryRepo.Create(memory))
+	rows.memoryID = memory.ID
+	_, err := f.shares.Create(user.ID, memory.ID, &models.ShareCreateRequest{})
+	check(err)
+
+	group, err := f.groups.Create(user.ID, &models.GroupCreateRequest{Name: "Coast"})
+	check(err)
+	rows.groupID = group.ID
+
+	todo := &models.Todo{UserID: user.ID, GroupID: &group.ID, Title: "Paint the lighthouse", Priority: models.PriorityLow}
+	check(f.todoRepo.Create(todo))
+	rows.todoID = todo.ID
+	blocker := &models.Todo{UserID: user.ID, GroupID: &group.ID, Title: "Buy lighthouse paint", Priority: models.PriorityLow}
+	check(f.todoRepo.Create(blocker))
+	rows.blockerID = blocker.ID
+	check(f.todoRepo.AddDependency(blocker.ID, todo.ID))
+
+	entry, err := f.allowlist.Create(user.ID, &models.IPAllowlistCreateRequest{CIDR: "10.0.0.0/8"}, "")
+	check(err)
+	rows.allowlistID = entry.ID
+
+	pattern, err := f.blocklist.Create(user.ID, &models.BlocklistPatternCreateRequest{Pattern: "password"})
+	check(err)
+	rows.blocklistID = pattern.ID
+
+	prompt, err := f.prompts.Create(user.ID, &models.PromptTemplateCreateRequest{TemplateName: models.PromptTemplateMemoryCategorization, TemplateBody: "Categorize: {{.Content}}"})
+	check(err)
+	rows.promptID = prompt.ID
+
+	feed := &models.SavedRSSFeed{UserID: user.ID, URL: "https://lighthouses.example.com/feed.xml", MaxItems: 10}
+	check(f.feedRepo.Save(feed))
+	rows.feedID = feed.ID
+
+	template, err := f.todoTemplates.Create(user.ID, &models.TodoTemplateCreateRequest{Name: "Lamp check", Tasks: []models.TodoTemplateTask{{Title: "Polish the lens"}}})
+	check(err)
+	rows.todoTemplateID = template.ID
+
+	thread, err := f.chat.CreateThread(user.ID)
+	check(err)
+	rows.threadID = thread.ID
+
+	provider, err := f.providers.Create(user.ID, &models.AIProviderCreate{Name: "OpenAI", ProviderType: models.ProviderTypeOpenAI, BaseURL: "https://api.openai.com/v1", APIKey: "sk-0123456789"}, "")
+	check(err)
+	rows.providerID = provider.ID
+
+	saved, err := f.searchHistory.CreateSaved(user.ID, &models.SavedSearchCreateRequest{Name: "Lighthouses", Query: "lighthouse"})
+	check(err)
+	rows.savedSearchID = saved.ID
+	check(f.searchHistoryRepo.Create(&models.SearchHistoryEntry{UserID: user.ID, Query: "lighthouse", ResultCount: 1}))
+
+	return rows
+}
+
+// snapshot returns every row the user owns, directly through a user_id column or
+// through a reference to such a row, in a comparable form
+func (f *isolationFixture) snapshot(t *testing.T, userID string) []string {
+	t.Helper()
+
+	tables := f.queryStrings(t, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '%_fts%' AND name != 'users'
+	`)
+	owned := map[string]bool{}
+	for _, table := range tables {
+		if len(f.queryStrings(t, "SELECT name FROM pragma_table_info(?) WHERE name = 'user_id'", table)) > 0 {
+			owned[table] = true
+		}
+	}
+
+	var rows []string
+	for _, table := range tables {
+		if owned[table] {
+			rows = append(rows, f.dumpRows(t, table, "SELECT * FROM "+table+" WHERE user_id = ?", userID)...)
+			continue
+		}
+
+		fkRows, err := f.db.Query(`SELECT "from", "table", "to" FROM pragma_foreign_key_list(?)`, table)
+		if err != nil {
+			t.Fatalf("failed to read foreign keys of %s: %v", table, err)
+		}
+		var conditions []string
+		for fkRows.Next() {
+			var from, parent string
+			var to sql.NullString
+			if err := fkRows.Scan(&from, &parent, &to); err != nil {
+				t.Fatal(err)
+			}
+			if owned[parent] {
+				column := "id"
+				if to.Valid {
+					column = to.String
+				}
+				conditions = append(conditions, fmt.Sprintf("%s IN (SELECT %s FROM %s WHERE user_id = ?)", from, column, parent))
+			}
+		}
+		fkRows.Close()
+		if len(conditions) == 0 {
+			continue
+		}
+		args := make([]interface{}, len(conditions))
+		for i := range args {
+			args[i] = userID
+		}
+		rows = append(rows, f.dumpRows(t, table, "SELECT * FROM "+table+" WHERE "+strings.Join(conditions, " OR "), args...)...)
+	}
+
+	sort.Strings(rows)
+	return rows
+}
+
+func (f *isolationFixture) queryStrings(t *testing.T, query string, args ...interface{}) []string {
+	t.Helper()
+	rows, err := f.db.Query(query, args...)
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	defer rows.Close()
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+func (f *isolationFixture) dumpRows(t *testing.T, table, query string, args ...interface{}) []string {
+	t.Helper()
+	rows, err := f.db.Query(query, args...)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", table, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dump []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			t.Fatal(err)
+		}
+		fields := make([]string, len(columns))
+		for i, column := range columns {
+			value := values[i]
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			fields[i] = fmt.Sprintf("%s=%v", column, value)
+		}
+		dump = append(dump, table+": "+strings.Join(fields, " "))
+	}
+	return dump
+}
+
+func TestListingsOnlyReturnTheUsersRows(t *testing.T) {
+	f := newIsolationFixture(t)
+
+	todoIDs := func(todos []models.Todo, err error) ([]string, error) {
+		ids := make([]string, len(todos))
+		for i, todo := range todos {
+			ids[i] = todo.ID
+		}
+		return ids, err
+	}
+	memoryIDs := func(memories []models.Memory, err error) ([]string, error) {
+		ids := make([]string, len(memories))
+		for i, memory := range memories {
+			ids[i] = memory.ID
+		}
+		return ids, err
+	}
+	groupIDs := func(groups []models.Group, err error) ([]string, error) {
+		ids := make([]string, len(groups))
+		for i, group := range groups {
+			ids[i] = group.ID
+		}
+		return ids, err
+	}
+
+	tests := []struct {
+		name string
+		list func(u isolationRows) ([]string, error)
+		id   func(u isolationRows) string // the row of u's that the listing must hold
+	}{
+		{"MemoryRepository.GetAllByUserID", func(u isolationRows) ([]string, error) {
+			return memoryIDs(f.memoryRepo.GetAllByUserID(u.userID, models.MemorySortManual, 100, 0))
+		}, func(u isolationRows) string { return u.memoryID }},
+		{"MemoryRepository.GetAfterCursor", func(u isolationRows) ([]string, error) {
+			return memoryIDs(f.memoryRepo.GetAfterCursor(u.userID, nil, 100))
+		}, func(u isolationRows) string { return u.memoryID }},
+		{"MemoryRepository.GetByCategory", func(u isolationRows) ([]string, error) {
+			return memoryIDs(f.memoryRepo.GetByCategory(u.userID, "Lighthouse", 100, 0))
+		}, func(u isolationRows) string { return u.memoryID }},
+		{"MemoryRepository.Search", func(u isolationRows) ([]string, error) {
+			return memoryIDs(f.memoryRepo.Search(u.userID, &models.MemorySearchRequest{Query: "lighthouse", Limit: 100}))
+		}, func(u isolationRows) string { return u.memoryID }},
+		{"MemoryService.Search", func(u isolationRows) ([]string, error) {
+			return memoryIDs(f.memories.Search(u.userID, &models.MemorySearchRequest{Query: "lighthouse", Limit: 100}))
+		}, func(u isolationRows) string { return u.memoryID }},
+		{"MemoryRepository.GetIDsByFilter", func(u isolationRows) ([]string, error) {
+			return f.memoryRepo.GetIDsByFilter(u.userID, models.BulkDeleteFilter{Category: "Lighthouse"})
+		}, func(u isolationRows) string { return u.memoryID }},
+		{"FTSRepository.Search", func(u isolationRows) ([]string, error) {
+			results, err := f.ftsRepo.Search(u.userID, "lighthouse", nil, 100)
+			ids := make([]string, len(results))
+			for i, result := range results {
+				ids[i] = result.ContentID
+			}
+			return ids, err
+		}, func(u isolationRows) string { return u.memoryID }},
+		{"FTSRepository.SearchWithHighlights", func(u isolationRows) ([]string, error) {
+			results, err := f.ftsRepo.SearchWithHighlights(u.userID, "lighthouse", nil, 100)
+			ids := make([]string, len(results))
+			for i, result := range results {
+				ids[i] = result.Document.ContentID
+			}
+			return ids, err
+		}, func(u isolationRows) string { return u.todoID }},
+		{"TodoRepository.GetAllByUserID", func(u isolationRows) ([]string, error) {
+			return todoIDs(f.todoRepo.GetAllByUserID(u.userID, true))
+		}, func(u isolationRows) string { return u.todoID }},
+		{"TodoRepository.GetByIDs", func(u isolationRows) ([]string, error) {
+			return todoIDs(f.todoRepo.GetByIDs(u.userID, []string{f.owner.todoID, f.intruder.todoID}))
+		}, func(u isolationRows) string { return u.todoID }},
+		{"TodoService.GetAll", func(u isolationRows) ([]string, error) {
+			return todoIDs(f.todos.GetAll(u.userID, &models.TodoFilterRequest{}))
+		}, func(u isolationRows) string { return u.todoID }},
+		{"GroupRepository.GetAllByUserID", func(u isolationRows) ([]string, error) {
+			return groupIDs(f.groupRepo.GetAllByUserID(u.userID, true))
+		}, func(u isolationRows) string { return u.groupID }},
+		{"GroupRepository.GetByIDs", func(u isolationRows) ([]string, error) {
+			return groupIDs(f.groupRepo.GetByIDs(u.userID, []string{f.owner.groupID, f.intruder.groupID}))
+		}, func(u isolationRows) string { return u.groupID }},
+		{"GroupRepository.GetTodosByGroupID", func(u isolationRows) ([]string, error) {
+			// Each user asks for both groups' todos
+			var ids []string
+			for _, groupID := range []string{f.owner.groupID, f.intruder.groupID} {
+				todos, err := todoIDs(f.groupRepo.GetTodosByGroupID(groupID, u.userID))
+				if err != nil {
+					return nil, err
+				}
+				ids = append(ids, todos...)
+			}
+			return ids, nil
+		}, func(u isolationRows) string { return u.todoID }},
+		{"IPAllowlistRepository.GetAllByUserID", func(u isolationRows) ([]string, error) {
+			entries, err := f.allowlistRepo.GetAllByUserID(u.userID)
+			ids := make([]string, len(entries))
+			for i, entry := range entries {
+				ids[i] = entry.ID
+			}
+			return ids, err
+		}, func(u isolationRows) string { return u.allowlistID }},
+		{"KeywordBlocklistRepository.GetAllByUserID", func(u isolationRows) ([]string, error) {
+			patterns, err := f.blocklistRepo.GetAllByUserID(u.userID)
+			ids := make([]string, len(patterns))
+			for i, pattern := range patterns {
+				ids[i] = pattern.ID
+			}
+			return ids, err
+		}, func(u isolationRows) string { return u.blocklistID }},
+		{"PromptTemplateRepository.GetAllByUserID", func(u isolationRows) ([]string, error) {
+			templates, err := f.promptRepo.GetAllByUserID(u.userID)
+			ids := make([]string, len(templates))
+			for i, template := range templates {
+				ids[i] = template.ID
+			}
+			return ids, err
+		}, func(u isolationRows) string { return u.promptID }},
+		{"RSSFeedRepository.GetAllByUserID", func(u isolationRows) ([]string, error) {
+			feeds, err := f.feedRepo.GetAllByUserID(u.userID)
+			ids := make([]string, len(feeds))
+			for i, feed := range feeds {
+				ids[i] = feed.ID
+			}
+			return ids, err
+		}, func(u isolationRows) string { return u.feedID }},
+		{"TodoTemplateRepository.GetAllByUserID", func(u isolationRows) ([]string, error) {
+			templates, err := f.todoTemplateRepo.GetAllByUserID(u.userID)
+			ids := make([]string, len(templates))
+			for i, template := range templates {
+				ids[i] = template.ID
+			}
+			return ids, err
+		}, func(u isolationRows) string { return u.todoTemplateID }},
+		{"ChatRepository.GetThreadsByUserID", func(u isolationRows) ([]string, error) {
+			threads, err := f.chatRepo.GetThreadsByUserID(u.userID)
+			ids := make([]string, len(threads))
+			for i, thread := range threads {
+				ids[i] = thread.ID
+			}
+			return ids, err
+		}, func(u isolationRows) string { return u.threadID }},
+		{"AIProviderRepository.GetByUserID", func(u isolationRows) ([]string, error) {
+			providers, err := f.providerRepo.GetByUserID(u.userID)
+			ids := make([]string, len(providers))
+			for i, provider := range providers {
+				ids[i] = provider.ID
+			}
+			return ids, err
+		}, func(u isolationRows) string { return u.providerID }},
+		{"SearchHistoryRepository.GetSavedByUserID", func(u isolationRows) ([]string, error) {
+			searches, err := f.searchHistoryRepo.GetSavedByUserID(u.userID)
+			ids := make([]string, len(searches))
+			for i, search := range searches {
+				ids[i] = search.ID
+			}
+			return ids, err
+		}, func(u isolationRows) string { return u.savedSearchID }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, users := range [][2]isolationRows{{f.owner, f.intruder}, {f.intruder, f.owner}} {
+				self, other := users[0], users[1]
+				ids, err := tt.list(self)
+				if err != nil {
+					t.Fatalf("listing as %s: %v", self.userID, err)
+				}
+				if want := tt.id(self); !slices.Contains(ids, want) {
+					t.Errorf("listing as %s misses their row %s", self.userID, want)
+				}
+				if leaked := tt.id(other); slices.Contains(ids, leaked) {
+					t.Errorf("listing as %s returned %s's row %s", self.userID, other.userID, leaked)
+				}
+			}
+		})
+	}
+}
+
+func TestGetByIDRefusesOtherUsersRows(t *testing.T) {
+	f := newIsolationFixture(t)
+
+	tests := []struct {
+		name string
+		// get looks up of's row as the user as
+		get func(as, of isolationRows) (bool, error)
+	}{
+		{"MemoryService.GetByID", func(as, of isolationRows) (bool, error) {
+			memory, err := f.memories.GetByID(as.userID, of.memoryID)
+			return memory != nil, err
+		}},
+		{"MemoryService.GetRelated", func(as, of isolationRows) (bool, error) {
+			_, err := f.memories.GetRelated(as.userID, of.memoryID, 5)
+			return err == nil, nil
+		}},
+		{"TodoService.GetByID", func(as, of isolationRows) (bool, error) {
+			todo, err := f.todos.GetByID(as.userID, of.todoID)
+			return todo != nil, err
+		}},
+		{"TodoService.GetBlockers", func(as, of isolationRows) (bool, error) {
+			_, err := f.todos.GetBlockers(as.userID, of.todoID)
+			return err == nil, nil
+		}},
+		{"TodoService.GetBlocking", func(as, of isolationRows) (bool, error) {
+			_, err := f.todos.GetBlocking(as.userID, of.blockerID)
+			return err == nil, nil
+		}},
+		{"GroupService.GetByID", func(as, of isolationRows) (bool, error) {
+			group, err := f.groups.GetByID(as.userID, of.groupID)
+			return group != nil, err
+		}},
+		{"GroupService.GetByIDs", func(as, of isolationRows) (bool, error) {
+			groups, err := f.groups.GetByIDs(as.userID, []string{of.groupID})
+			return groups[of.groupID] != nil, err
+		}},
+		{"GroupService.GetTodos", func(as, of isolationRows) (bool, error) {
+			_, err := f.groups.GetTodos(as.userID, of.groupID)
+			return err == nil, nil
+		}},
+		{"PromptTemplateService.GetByID", func(as, of isolationRows) (bool, error) {
+			template, err := f.prompts.GetByID(as.userID, of.promptID)
+			return err == nil && template != nil, nil
+		}},
+		{"TodoTemplateService.GetByID", func(as, of isolationRows) (bool, error) {
+			template, err := f.todoTemplates.GetByID(as.userID, of.todoTemplateID)
+			return template != nil, err
+		}},
+		{"ChatService.GetThreadWithMessages", func(as, of isolationRows) (bool, error) {
+			_, err := f.chat.GetThreadWithMessages(as.userID, of.threadID)
+			return err == nil, nil
+		}},
+		{"AIProviderService.GetByID", func(as, of isolationRows) (bool, error) {
+			_, err := f.providers.GetByID(of.providerID, as.userID)
+			return err == nil, nil
+		}},
+		{"AIProviderService.GetModels", func(as, of isolationRows) (bool, error) {
+			_, err := f.providers.GetModels(of.providerID, as.userID)
+			return err == nil, nil
+		}},
+		{"SearchHistoryService.GetSavedByID", func(as, of isolationRows) (bool, error) {
+			_, err := f.searchHistory.GetSavedByID(as.userID, of.savedSearchID)
+			return err == nil, nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if found, err := tt.get(f.owner, f.owner); err != nil || !found {
+				t.Errorf("owner's lookup = %v, %v; want their row", found, err)
+			}
+			if found, err := tt.get(f.intruder, f.owner); err != nil || found {
+				t.Errorf("another user's lookup = %v, %v; want nothing", found, err)
+			}
+		})
+	}
+}
+
+func TestWritesLeaveOtherUsersRowsAlone(t *testing.T) {
+	newName := "Renamed"
+	tests := []struct {
+		name string
+		// write changes of's rows as the user as
+		write func(f *isolationFixture, as, of isolationRows) error
+		// refused is whether the write must fail rather than quietly match nothing
+		refused bool
+	}{
+		{"MemoryService.Update", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.memories.Update(as.userID, of.memoryID, &models.MemoryUpdateRequest{Category: &newName})
+			return err
+		}, true},
+		{"MemoryService.Delete", func(f *isolationFixture, as, of isolationRows) error {
+			return f.memories.Delete(as.userID, of.memoryID, "")
+		}, true},
+		{"MemoryService.BulkDelete", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.memories.BulkDelete(as.userID, models.BulkDeleteFilter{Category: "Lighthouse"}, "")
+			return err
+		}, false},
+		{"MemoryService.BulkArchive", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.memories.BulkArchive(as.userID, models.BulkArchiveFilter{Category: "Lighthouse"})
+			return err
+		}, false},
+		{"MemoryRepository.DeleteAllByUserID", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.memoryRepo.DeleteAllByUserID(as.userID)
+			return err
+		}, false},
+		{"ShareService.Revoke", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.shares.Revoke(as.userID, of.memoryID)
+			return err
+		}, true},
+		{"TodoService.Update", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.todos.Update(as.userID, of.todoID, &models.TodoUpdateRequest{Title: &newName})
+			return err
+		}, true},
+		{"TodoService.Delete", func(f *isolationFixture, as, of isolationRows) error {
+			return f.todos.Delete(as.userID, of.todoID, "")
+		}, true},
+		{"TodoService.RemoveDependency", func(f *isolationFixture, as, of isolationRows) error {
+			return f.todos.RemoveDependency(as.userID, of.blockerID, of.todoID)
+		}, true},
+		{"TodoService.BulkUpdatePriority", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.todos.BulkUpdatePriority(as.userID, []string{of.todoID}, models.PriorityHigh)
+			return err
+		}, true},
+		{"TodoRepository.UpdatePriorityBulk", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.todoRepo.UpdatePriorityBulk(as.userID, []string{of.todoID}, models.PriorityHigh)
+			return err
+		}, false},
+		{"TodoRepository.DeleteAllByUserID", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.todoRepo.DeleteAllByUserID(as.userID)
+			return err
+		}, false},
+		{"GroupService.Update", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.groups.Update(as.userID, of.groupID, &models.GroupUpdateRequest{Name: &newName})
+			return err
+		}, true},
+		{"GroupService.Archive", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.groups.Archive(as.userID, of.groupID)
+			return err
+		}, true},
+		{"GroupService.Delete", func(f *isolationFixture, as, of isolationRows) error {
+			return f.groups.Delete(as.userID, of.groupID)
+		}, true},
+		{"GroupRepository.DeleteAllCustomByUserID", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.groupRepo.DeleteAllCustomByUserID(as.userID)
+			return err
+		}, false},
+		{"IPAllowlistService.Update", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.allowlist.Update(as.userID, of.allowlistID, &models.IPAllowlistUpdateRequest{Label: &newName}, "")
+			return err
+		}, true},
+		{"IPAllowlistService.Delete", func(f *isolationFixture, as, of isolationRows) error {
+			return f.allowlist.Delete(as.userID, of.allowlistID, "")
+		}, true},
+		{"BlocklistService.Update", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.blocklist.Update(as.userID, of.blocklistID, &models.BlocklistPatternUpdateRequest{Pattern: &newName})
+			return err
+		}, true},
+		{"BlocklistService.Delete", func(f *isolationFixture, as, of isolationRows) error {
+			return f.blocklist.Delete(as.userID, of.blocklistID)
+		}, true},
+		{"PromptTemplateService.Update", func(f *isolationFixture, as, of isolationRows) error {
+			body := "{{.Content}}"
+			_, err := f.prompts.Update(as.userID, of.promptID, &models.PromptTemplateUpdateRequest{TemplateBody: &body})
+			return err
+		}, true},
+		{"PromptTemplateService.Delete", func(f *isolationFixture, as, of isolationRows) error {
+			return f.prompts.Delete(as.userID, of.promptID)
+		}, true},
+		{"RSSFeedService.Delete", func(f *isolationFixture, as, of isolationRows) error {
+			return f.feeds.Delete(as.userID, of.feedID)
+		}, true},
+		{"TodoTemplateService.Update", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.todoTemplates.Update(as.userID, of.todoTemplateID, &models.TodoTemplateUpdateRequest{Name: &newName})
+			return err
+		}, true},
+		{"TodoTemplateService.Delete", func(f *isolationFixture, as, of isolationRows) error {
+			return f.todoTemplates.Delete(as.userID, of.todoTemplateID)
+		}, true},
+		{"ChatService.UpdateThreadTitle", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.chat.UpdateThreadTitle(as.userID, of.threadID, newName)
+			return err
+		}, true},
+		{"ChatService.DeleteThread", func(f *isolationFixture, as, of isolationRows) error {
+			return f.chat.DeleteThread(as.userID, of.threadID)
+		}, true},
+		{"AIProviderService.Update", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.providers.Update(of.providerID, as.userID, &models.AIProviderUpdate{Name: &newName}, "")
+			return err
+		}, true},
+		{"AIProviderService.Delete", func(f *isolationFixture, as, of isolationRows) error {
+			return f.providers.Delete(of.providerID, as.userID)
+		}, true},
+		{"SearchHistoryService.DeleteSaved", func(f *isolationFixture, as, of isolationRows) error {
+			return f.searchHistory.DeleteSaved(as.userID, of.savedSearchID)
+		}, true},
+		{"SearchHistoryRepository.DeleteByUserID", func(f *isolationFixture, as, of isolationRows) error {
+			_, err := f.searchHistoryRepo.DeleteByUserID(as.userID)
+			return err
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newIsolationFixture(t)
+			before := f.snapshot(t, f.owner.userID)
+
+			err := tt.write(f, f.intruder, f.owner)
+			if tt.refused && err == nil {
+				t.Error("writing another user's row succeeded")
+			}
+			if after := f.snapshot(t, f.owner.userID); !slices.Equal(before, after) {
+				t.Errorf("another user's write changed the owner's rows:\nbefore %v\nafter  %v", before, after)
+			}
+
+			// The same write by the owner does change their rows, so the check above
+			// isn't vacuous
+			if err := tt.write(f, f.owner, f.owner); err != nil {
+				t.Fatalf("owner's write failed: %v", err)
+			}
+			if after := f.snapshot(t, f.owner.userID); slices.Equal(before, after) {
+				t.Error("owner's write left their rows unchanged")
+			}
+		})
+	}
+}
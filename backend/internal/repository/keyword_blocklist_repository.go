@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+type KeywordBlocklistRepository struct {
+	db *sql.DB
+}
+
+func NewKeywordBlocklistRepository(db *sql.DB) *KeywordBlocklistRepository {
+	return &KeywordBlocklistRepository{db: db}
+}
+
+func (r *KeywordBlocklistRepository) Create(pattern *models.BlocklistPattern) error {
+	pattern.ID = uuid.New().String()
+	pattern.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO keyword_blocklist (id, user_id, pattern, is_regex, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, pattern.ID, pattern.UserID, pattern.Pattern, pattern.IsRegex, pattern.CreatedAt)
+
+	return err
+}
+
+func (r *KeywordBlocklistRepository) GetByID(id string) (*models.BlocklistPattern, error) {
+	pattern := &models.BlocklistPattern{}
+
+	err := r.db.QueryRow(`
+		SELECT id, user_id, pattern, is_regex, created_at
+		FROM keyword_blocklist WHERE id = ?
+	`, id).Scan(&pattern.ID, &pattern.UserID, &pattern.Pattern, &pattern.IsRegex, &pattern.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pattern, nil
+}
+
+func (r *KeywordBlocklistRepository) GetAllByUserID(userID string) ([]models.BlocklistPattern, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, pattern, is_regex, created_at
+		FROM keyword_blocklist
+		WHERE user_id = ?
+		ORDER BY created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	patterns := []models.BlocklistPattern{}
+	for rows.Next() {
+		pattern := models.BlocklistPattern{}
+		if err := rows.Scan(&pattern.ID, &pattern.UserID, &pattern.Pattern, &pattern.IsRegex, &pattern.CreatedAt); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
+}
+
+func (r *KeywordBlocklistRepository) Update(pattern *models.BlocklistPattern) error {
+	_, err := r.db.Exec(`
+		UPDATE keyword_blocklist SET pattern = ?, is_regex = ? WHERE id = ?
+	`, pattern.Pattern, pattern.IsRegex, pattern.ID)
+	return err
+}
+
+func (r *KeywordBlocklistRepository) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM keyword_blocklist WHERE id = ?", id)
+	return err
+}
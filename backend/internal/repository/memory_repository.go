@@ -2,13 +2,19 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+	"math"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/database"
 	"github.com/todomyday/backend/internal/models"
 )
 
+// readingWordsPerMinute is the reading speed assumed for reading time estimates
+const readingWordsPerMinute = 200
+
 type MemoryRepository struct {
 	db *sql.DB
 }
@@ -29,23 +35,26 @@ func (r *MemoryRepository) Create(memory *models.Memory) error {
 	if memory.Position == "" {
 		memory.Position = "1000"
 	}
+	if memory.ScrapeMode == "" {
+		memory.ScrapeMode = models.ScrapeModeFull
+	}
 	_, err := r.db.Exec(`
-		INSERT INTO memories (id, user_id, content, summary, category, url, url_title, url_content, is_archived, position, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, memory.ID, memory.UserID, memory.Content, memory.Summary, memory.Category, memory.URL, memory.URLTitle, memory.URLContent, memory.IsArchived, memory.Position, memory.CreatedAt, memory.UpdatedAt)
+		INSERT INTO memories (id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, memory.ID, memory.UserID, memory.Content, memory.Summary, memory.Category, memory.URL, memory.URLTitle, memory.URLContent, memory.IsArchived, memory.IsPinned, memory.AIProcessingFailed, memory.ContentLanguage, memory.Position, memory.LastScrapedAt, memory.GeneratedTitle, memory.ScrapeMode, memory.ThumbnailURL, memory.SummarySource, memory.CreatedAt, memory.UpdatedAt)
 
 	return err
 }
 
 func (r *MemoryRepository) GetByID(id string) (*models.Memory, error) {
 	memory := &models.Memory{}
-	var summary, url, urlTitle, urlContent sql.NullString
-	var isArchived int
+	var summary, url, urlTitle, urlContent, contentLanguage sql.NullString
+	var isArchived, isPinned, aiProcessingFailed int
 
 	err := r.db.QueryRow(`
-		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, position, created_at, updated_at
+		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at
 		FROM memories WHERE id = ?
-	`, id).Scan(&memory.ID, &memory.UserID, &memory.Content, &summary, &memory.Category, &url, &urlTitle, &urlContent, &isArchived, &memory.Position, &memory.CreatedAt, &memory.UpdatedAt)
+	`, id).Scan(&memory.ID, &memory.UserID, &memory.Content, &summary, &memory.Category, &url, &urlTitle, &urlContent, &isArchived, &isPinned, &aiProcessingFailed, &contentLanguage, &memory.Position, &memory.LastScrapedAt, &memory.GeneratedTitle, &memory.ScrapeMode, &memory.ThumbnailURL, &memory.SummarySource, &memory.CreatedAt, &memory.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -67,20 +76,27 @@ func (r *MemoryRepository) GetByID(id string) (*models.Memory, error) {
 		memory.URLContent = &urlContent.String
 	}
 	memory.IsArchived = isArchived == 1
+	memory.IsPinned = isPinned == 1
+	memory.AIProcessingFailed = aiProcessingFailed == 1
+	if contentLanguage.Valid {
+		memory.ContentLanguage = &contentLanguage.String
+	}
 
 	return memory, nil
 }
 
-func (r *MemoryRepository) GetAllByUserID(userID string, limit, offset int) ([]models.Memory, error) {
+// GetAllByUserID returns a page of the user's unarchived memories in the given sort
+// mode (models.MemorySort*), pinned memories first
+func (r *MemoryRepository) GetAllByUserID(userID, sortMode string, limit, offset int) ([]models.Memory, error) {
 	if limit <= 0 {
 		limit = 50
 	}
 
 	rows, err := r.db.Query(`
-		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, position, created_at, updated_at
+		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at
 		FROM memories
 		WHERE user_id = ? AND is_archived = 0
-		ORDER BY CAST(position AS INTEGER) ASC, created_at DESC
+		ORDER BY `+memoryOrderBy(sortMode)+`
 		LIMIT ? OFFSET ?
 	`, userID, limit, offset)
 	if err != nil {
@@ -91,13 +107,70 @@ func (r *MemoryRepository) GetAllByUserID(userID string, limit, offset int) ([]m
 	return r.scanMemories(rows)
 }
 
+// GetAfterCursor returns up to limit of the user's unarchived memories, newest first,
+// that come after cursor (from the start if it's nil). Unlike an OFFSET, the position
+// is found through the (user_id, creation time, id) index.
+func (r *MemoryRepository) GetAfterCursor(userID string, cursor *models.PageCursor, limit int) ([]models.Memory, error) {
+	query := `
+		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at
+		FROM memories
+		WHERE user_id = ? AND is_archived = 0`
+	args := []interface{}{userID}
+	if cursor != nil {
+		query += ` AND ` + afterCursorCondition
+		args = append(args, cursorArgs(cursor)...)
+	}
+	query += ` ORDER BY ` + createdAtKey + ` DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanMemories(rows)
+}
+
+// createdAtKey orders rows by creation time, which comparing the created_at text
+// doesn't once the server's UTC offset has changed. Rows created in the same
+// millisecond are ordered by id.
+var createdAtKey = database.SortableTimestamp("created_at")
+
+// afterCursorCondition matches the rows after a cursor in createdAtKey DESC, id DESC
+// order, with the arguments from cursorArgs. This still holds once the cursor's row
+// is deleted.
+var afterCursorCondition = `(` + createdAtKey + ` < julianday(?) OR (` + createdAtKey + ` = julianday(?) AND id < ?))`
+
+func cursorArgs(cursor *models.PageCursor) []interface{} {
+	createdAt := database.SortableTimestampParam(cursor.CreatedAt)
+	return []interface{}{createdAt, createdAt, cursor.ID}
+}
+
+// memoryOrderBy returns the ORDER BY clause for a memory sort mode, falling back to
+// manual (drag-and-drop) order for unknown modes
+func memoryOrderBy(sortMode string) string {
+	switch sortMode {
+	case models.MemorySortNewest:
+		return "is_pinned DESC, created_at DESC"
+	case models.MemorySortUpdated:
+		return "is_pinned DESC, updated_at DESC"
+	case models.MemorySortAlphabetical:
+		return "is_pinned DESC, content COLLATE NOCASE ASC"
+	case models.MemorySortCategory:
+		return "is_pinned DESC, category ASC, created_at DESC"
+	default:
+		return "is_pinned DESC, CAST(position AS INTEGER) ASC, created_at DESC"
+	}
+}
+
 func (r *MemoryRepository) GetByCategory(userID, category string, limit, offset int) ([]models.Memory, error) {
 	if limit <= 0 {
 		limit = 50
 	}
 
 	rows, err := r.db.Query(`
-		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, position, created_at, updated_at
+		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at
 		FROM memories
 		WHERE user_id = ? AND category = ? AND is_archived = 0
 		ORDER BY CAST(position AS INTEGER) ASC, created_at DESC
@@ -113,7 +186,7 @@ func (r *MemoryRepository) GetByCategory(userID, category string, limit, offset
 
 func (r *MemoryRepository) Search(userID string, req *models.MemorySearchRequest) ([]models.Memory, error) {
 	query := `
-		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, position, created_at, updated_at
+		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at
 		FROM memories
 		WHERE user_id = ? AND is_archived = 0
 	`
@@ -140,6 +213,15 @@ func (r *MemoryRepository) Search(userID string, req *models.MemorySearchRequest
 		args = append(args, *req.DateTo)
 	}
 
+	if len(req.LanguageFilter) > 0 {
+		placeholders := make([]string, len(req.LanguageFilter))
+		for i, lang := range req.LanguageFilter {
+			placeholders[i] = "?"
+			args = append(args, strings.ToLower(strings.TrimSpace(lang)))
+		}
+		query += fmt.Sprintf(" AND content_language IN (%s)", strings.Join(placeholders, ","))
+	}
+
 	query += " ORDER BY CAST(position AS INTEGER) ASC, created_at DESC"
 
 	limit := req.Limit
@@ -160,7 +242,7 @@ func (r *MemoryRepository) Search(userID string, req *models.MemorySearchRequest
 
 func (r *MemoryRepository) GetByDateRange(userID string, from, to time.Time) ([]models.Memory, error) {
 	rows, err := r.db.Query(`
-		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, position, created_at, updated_at
+		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at
 		FROM memories
 		WHERE user_id = ? AND is_archived = 0 AND created_at >= ? AND created_at <= ?
 		ORDER BY CAST(position AS INTEGER) ASC, created_at DESC
@@ -173,6 +255,9 @@ func (r *MemoryRepository) GetByDateRange(userID string, from, to time.Time) ([]
 	return r.scanMemories(rows)
 }
 
+// Update applies updates to a memory. When they touch content or summary, the memory
+// as it was is first saved as a revision, in the same transaction, and revisions
+// beyond MaxMemoryRevisions are pruned.
 func (r *MemoryRepository) Update(id string, updates map[string]interface{}) error {
 	updates["updated_at"] = time.Now()
 
@@ -192,10 +277,130 @@ func (r *MemoryRepository) Update(id string, updates map[string]interface{}) err
 	query += " WHERE id = ?"
 	args = append(args, id)
 
-	_, err := r.db.Exec(query, args...)
+	_, hasContent := updates["content"]
+	_, hasSummary := updates["summary"]
+	if !hasContent && !hasSummary {
+		_, err := r.db.Exec(query, args...)
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.saveRevision(tx, id, updates); err != nil {
+		return fmt.Errorf("failed to save memory revision: %w", err)
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// saveRevision snapshots a memory's current content and summary before updates are
+// applied, unless they leave both unchanged, then prunes the oldest revisions
+func (r *MemoryRepository) saveRevision(tx *sql.Tx, id string, updates map[string]interface{}) error {
+	var userID, content string
+	var summary sql.NullString
+	err := tx.QueryRow("SELECT user_id, content, summary FROM memories WHERE id = ?", id).Scan(&userID, &content, &summary)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	if newContent, ok := updates["content"]; ok && fmt.Sprint(newContent) != content {
+		changed = true
+	}
+	if newSummary, ok := updates["summary"]; ok && !sameNullableString(newSummary, summary) {
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO memory_revisions (id, memory_id, content, summary, changed_at, changed_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, uuid.New().String(), id, content, summary, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM memory_revisions
+		WHERE memory_id = ? AND id NOT IN (
+			SELECT id FROM memory_revisions WHERE memory_id = ?
+			ORDER BY changed_at DESC, rowid DESC
+			LIMIT ?
+		)
+	`, id, id, models.MaxMemoryRevisions)
 	return err
 }
 
+// sameNullableString reports whether an update value (a string, *string or nil) equals
+// a nullable column value
+func sameNullableString(value interface{}, current sql.NullString) bool {
+	switch v := value.(type) {
+	case nil:
+		return !current.Valid
+	case *string:
+		if v == nil {
+			return !current.Valid
+		}
+		return current.Valid && *v == current.String
+	case string:
+		return current.Valid && v == current.String
+	}
+	return false
+}
+
+// GetRevisions returns a memory's revisions, newest first
+func (r *MemoryRepository) GetRevisions(memoryID string, limit int) ([]models.MemoryRevision, error) {
+	rows, err := r.db.Query(`
+		SELECT id, memory_id, content, summary, changed_at, changed_by
+		FROM memory_revisions
+		WHERE memory_id = ?
+		ORDER BY changed_at DESC, rowid DESC
+		LIMIT ?
+	`, memoryID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.MemoryRevision{}
+	for rows.Next() {
+		var revision models.MemoryRevision
+		if err := rows.Scan(&revision.ID, &revision.MemoryID, &revision.Content, &revision.Summary, &revision.ChangedAt, &revision.ChangedBy); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// GetRevision returns one of a memory's revisions, or nil if it doesn't exist
+func (r *MemoryRepository) GetRevision(memoryID, revisionID string) (*models.MemoryRevision, error) {
+	var revision models.MemoryRevision
+	err := r.db.QueryRow(`
+		SELECT id, memory_id, content, summary, changed_at, changed_by
+		FROM memory_revisions
+		WHERE id = ? AND memory_id = ?
+	`, revisionID, memoryID).Scan(&revision.ID, &revision.MemoryID, &revision.Content, &revision.Summary, &revision.ChangedAt, &revision.ChangedBy)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
 func (r *MemoryRepository) Delete(id string) error {
 	_, err := r.db.Exec("DELETE FROM memories WHERE id = ?", id)
 	return err
@@ -210,6 +415,338 @@ func (r *MemoryRepository) DeleteAllByUserID(userID string) (int64, error) {
 	return result.RowsAffected()
 }
 
+// GetPageIncludingArchived returns a page of all the user's memories, archived or
+// not, in a stable order for paging through the full set
+func (r *MemoryRepository) GetPageIncludingArchived(userID string, limit, offset int) ([]models.Memory, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at
+		FROM memories
+		WHERE user_id = ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT ? OFFSET ?
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanMemories(rows)
+}
+
+// CreateBatch inserts new memories in one transaction, assigning IDs and timestamps as
+// Create does. Failed inserts are returned keyed by their index in memories. With
+// stopOnError the first failure rolls the whole batch back; otherwise the failed rows
+// are skipped and the rest committed.
+func (r *MemoryRepository) CreateBatch(memories []*models.Memory, stopOnError bool) (map[int]error, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO memories (id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	failed := map[int]error{}
+	now := time.Now()
+	for i, m := range memories {
+		m.ID = uuid.New().String()
+		m.CreatedAt = now
+		m.UpdatedAt = now
+		if m.Category == "" {
+			m.Category = "Uncategorized"
+		}
+		if m.Position == "" {
+			m.Position = "1000"
+		}
+		if m.ScrapeMode == "" {
+			m.ScrapeMode = models.ScrapeModeFull
+		}
+		if _, err := stmt.Exec(m.ID, m.UserID, m.Content, m.Summary, m.Category, m.URL, m.URLTitle, m.URLContent, m.IsArchived, m.IsPinned, m.AIProcessingFailed, m.ContentLanguage, m.Position, m.LastScrapedAt, m.GeneratedTitle, m.ScrapeMode, m.ThumbnailURL, m.SummarySource, m.CreatedAt, m.UpdatedAt); err != nil {
+			failed[i] = err
+			if stopOnError {
+				return failed, nil
+			}
+		}
+	}
+
+	return failed, tx.Commit()
+}
+
+// Import inserts memories keeping their IDs, skipping any whose ID already exists.
+// Returns how many were inserted.
+func (r *MemoryRepository) Import(memories []models.Memory) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO memories (id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, m := range memories {
+		if m.Category == "" {
+			m.Category = "Uncategorized"
+		}
+		if m.Position == "" {
+			m.Position = "1000"
+		}
+		if m.ScrapeMode == "" {
+			m.ScrapeMode = models.ScrapeModeFull
+		}
+		result, err := stmt.Exec(m.ID, m.UserID, m.Content, m.Summary, m.Category, m.URL, m.URLTitle, m.URLContent, m.IsArchived, m.IsPinned, m.AIProcessingFailed, m.ContentLanguage, m.Position, m.LastScrapedAt, m.GeneratedTitle, m.ScrapeMode, m.ThumbnailURL, m.SummarySource, m.CreatedAt, m.UpdatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to import memory %s: %w", m.ID, err)
+		}
+		n, _ := result.RowsAffected()
+		inserted += int(n)
+	}
+
+	return inserted, tx.Commit()
+}
+
+// ImportCategories inserts user categories keeping their IDs, skipping any whose ID
+// or name already exists for the user. Returns how many were inserted.
+func (r *MemoryRepository) ImportCategories(categories []models.MemoryCategory) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO memory_categories (id, user_id, name, color_code, icon, is_system, created_at)
+		VALUES (?, ?, ?, ?, ?, 0, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, c := range categories {
+		if c.ColorCode == "" {
+			c.ColorCode = "#6366F1"
+		}
+		result, err := stmt.Exec(c.ID, c.UserID, c.Name, c.ColorCode, c.Icon, c.CreatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to import category %s: %w", c.ID, err)
+		}
+		n, _ := result.RowsAffected()
+		inserted += int(n)
+	}
+
+	return inserted, tx.Commit()
+}
+
+// GetIDsByFilter returns the IDs of a user's memories matching a bulk delete filter
+func (r *MemoryRepository) GetIDsByFilter(userID string, filter models.BulkDeleteFilter) ([]string, error) {
+	where, args := bulkDeleteWhere(userID, filter)
+	rows, err := r.db.Query("SELECT id FROM memories WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DeleteByFilter deletes a user's memories matching a bulk delete filter
+func (r *MemoryRepository) DeleteByFilter(userID string, filter models.BulkDeleteFilter) (int64, error) {
+	where, args := bulkDeleteWhere(userID, filter)
+	result, err := r.db.Exec("DELETE FROM memories WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func bulkDeleteWhere(userID string, filter models.BulkDeleteFilter) (string, []interface{}) {
+	where := "user_id = ?"
+	args := []interface{}{userID}
+
+	if filter.Category != "" {
+		where += " AND category = ?"
+		args = append(args, filter.Category)
+	}
+	if filter.BeforeDate != "" {
+		// created_at text starts with YYYY-MM-DD, so a bare date compares lexically
+		where += " AND created_at < ?"
+		args = append(args, filter.BeforeDate)
+	}
+	return where, args
+}
+
+// SetArchivedByFilter archives (or unarchives) the user's memories matching the filter
+// in a single statement, skipping ones already in the target state. cutoff, when set,
+// is a YYYY-MM-DD date that matching memories must be created before.
+func (r *MemoryRepository) SetArchivedByFilter(userID string, filter models.BulkArchiveFilter, cutoff string, archived bool) (int64, error) {
+	target, current := 0, 1
+	if archived {
+		target, current = 1, 0
+	}
+
+	where := "user_id = ? AND is_archived = ?"
+	args := []interface{}{target, time.Now(), userID, current}
+
+	if filter.Category != "" {
+		where += " AND category = ?"
+		args = append(args, filter.Category)
+	}
+	if cutoff != "" {
+		// created_at text starts with YYYY-MM-DD, so a bare date compares lexically
+		where += " AND created_at < ?"
+		args = append(args, cutoff)
+	}
+	if !filter.IncludePinned {
+		where += " AND is_pinned = 0"
+	}
+
+	result, err := r.db.Exec("UPDATE memories SET is_archived = ?, updated_at = ? WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ReplaceLinks replaces a memory's related-memory links with the given ones, keyed by
+// the related memory's ID with the similarity as value. Links are symmetric, so each
+// pair is stored once with the smaller ID first.
+func (r *MemoryRepository) ReplaceLinks(memoryID string, links map[string]float64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM memory_links WHERE memory_id_a = ? OR memory_id_b = ?", memoryID, memoryID); err != nil {
+		return err
+	}
+
+	for relatedID, similarity := range links {
+		a, b := memoryID, relatedID
+		if b < a {
+			a, b = b, a
+		}
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO memory_links (memory_id_a, memory_id_b, similarity, created_at)
+			VALUES (?, ?, ?, ?)
+		`, a, b, similarity, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to link memory %s to %s: %w", memoryID, relatedID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetRelated returns the unarchived memories linked to a memory, most similar first
+func (r *MemoryRepository) GetRelated(memoryID string, limit int) ([]models.Memory, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	rows, err := r.db.Query(`
+		SELECT m.id, m.user_id, m.content, m.summary, m.category, m.url, m.url_title, m.url_content, m.is_archived, m.is_pinned, m.ai_processing_failed, m.content_language, m.position, m.last_scraped_at, m.generated_title, m.scrape_mode, m.thumbnail_url, m.summary_source, m.created_at, m.updated_at
+		FROM memory_links l
+		JOIN memories m ON m.id = CASE WHEN l.memory_id_a = ? THEN l.memory_id_b ELSE l.memory_id_a END
+		WHERE (l.memory_id_a = ? OR l.memory_id_b = ?) AND m.is_archived = 0
+		ORDER BY l.similarity DESC
+		LIMIT ?
+	`, memoryID, memoryID, memoryID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanMemories(rows)
+}
+
+// UpdateURLContent saves freshly scraped URL details. updated_at is left alone, as
+// the memory itself wasn't edited.
+func (r *MemoryRepository) UpdateURLContent(id string, urlTitle, urlContent, thumbnailURL *string, scrapedAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE memories SET url_title = ?, url_content = ?, thumbnail_url = ?, last_scraped_at = ? WHERE id = ?
+	`, urlTitle, urlContent, thumbnailURL, scrapedAt, id)
+	return err
+}
+
+// UpdateGeneratedTitle saves an AI-generated display title, leaving updated_at alone
+func (r *MemoryRepository) UpdateGeneratedTitle(id, title string) error {
+	_, err := r.db.Exec(`UPDATE memories SET generated_title = ? WHERE id = ?`, title, id)
+	return err
+}
+
+// MarkIndexed records when the user's memories among ids were last indexed for RAG,
+// leaving updated_at alone
+func (r *MemoryRepository) MarkIndexed(userID string, ids []string, indexedAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	where, args := idsWhere(userID, ids)
+	_, err := r.db.Exec("UPDATE memories SET last_indexed_at = ? WHERE "+where, append([]interface{}{indexedAt}, args...)...)
+	return err
+}
+
+// CountStale returns how many of the user's unarchived memories were never indexed
+// for RAG or have changed since they last were
+func (r *MemoryRepository) CountStale(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM memories
+		WHERE user_id = ? AND is_archived = 0 AND (last_indexed_at IS NULL OR updated_at > last_indexed_at)
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// GetStaleURLMemories returns unarchived memories with a URL that hasn't been scraped
+// since before cutoff, least recently scraped first. Memories saved without scraping
+// are left out.
+func (r *MemoryRepository) GetStaleURLMemories(cutoff time.Time, limit int) ([]models.Memory, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, content, summary, category, url, url_title, url_content, is_archived, is_pinned, ai_processing_failed, content_language, position, last_scraped_at, generated_title, scrape_mode, thumbnail_url, summary_source, created_at, updated_at
+		FROM memories
+		WHERE url IS NOT NULL AND url != '' AND is_archived = 0 AND scrape_mode != 'none' AND (last_scraped_at IS NULL OR last_scraped_at < ?)
+		ORDER BY last_scraped_at ASC
+		LIMIT ?
+	`, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanMemories(rows)
+}
+
+// ExistsByURL reports whether the user already has a memory (archived or not) for a URL
+func (r *MemoryRepository) ExistsByURL(userID, url string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM memories WHERE user_id = ? AND url = ?)", userID, url).Scan(&exists)
+	return exists, err
+}
+
 // CountByUserID returns the count of memories for a user
 func (r *MemoryRepository) CountByUserID(userID string) (int, error) {
 	var count int
@@ -217,6 +754,39 @@ func (r *MemoryRepository) CountByUserID(userID string) (int, error) {
 	return count, err
 }
 
+// CountUnarchivedByUserID returns the count of a user's unarchived memories
+func (r *MemoryRepository) CountUnarchivedByUserID(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM memories WHERE user_id = ? AND is_archived = 0", userID).Scan(&count)
+	return count, err
+}
+
+// GetUnarchivedContents returns the ID and content of each of the user's unarchived memories
+func (r *MemoryRepository) GetUnarchivedContents(userID string) ([]models.Memory, error) {
+	rows, err := r.db.Query("SELECT id, content FROM memories WHERE user_id = ? AND is_archived = 0", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var memories []models.Memory
+	for rows.Next() {
+		memory := models.Memory{UserID: userID}
+		if err := rows.Scan(&memory.ID, &memory.Content); err != nil {
+			return nil, err
+		}
+		memories = append(memories, memory)
+	}
+	return memories, rows.Err()
+}
+
+// CountPinnedByUserID returns the count of pinned memories for a user, including archived ones
+func (r *MemoryRepository) CountPinnedByUserID(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM memories WHERE user_id = ? AND is_pinned = 1", userID).Scan(&count)
+	return count, err
+}
+
 // Categories
 
 func (r *MemoryRepository) GetCategories(userID string) ([]models.MemoryCategory, error) {
@@ -369,6 +939,42 @@ func (r *MemoryRepository) GetStats(userID string) (*models.MemoryStats, error)
 	}
 	stats.ByCategory = categoryStats
 
+	// Content length analytics. Words are estimated by counting spaces and newlines.
+	var totalWords int
+	var avgChars float64
+	err = r.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(LENGTH(content)), 0),
+			COALESCE(AVG(LENGTH(content)), 0),
+			COALESCE(SUM(
+				LENGTH(TRIM(content)) - LENGTH(REPLACE(REPLACE(TRIM(content), ' ', ''), char(10), '')) +
+				CASE WHEN TRIM(content) = '' THEN 0 ELSE 1 END
+			), 0),
+			COALESCE(SUM(CASE WHEN url IS NOT NULL AND url != '' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN summary IS NOT NULL AND summary != '' THEN 1 ELSE 0 END), 0)
+		FROM memories WHERE user_id = ? AND is_archived = 0
+	`, userID).Scan(&stats.TotalCharacters, &avgChars, &totalWords, &stats.URLCount, &stats.WithSummaryCount)
+	if err != nil {
+		return nil, err
+	}
+	stats.AvgCharactersPerMemory = int(math.Round(avgChars))
+	stats.EstimatedReadingTimeMinutes = (totalWords + readingWordsPerMinute - 1) / readingWordsPerMinute
+
+	// Longest memory
+	longest := &models.MemoryLengthStats{}
+	err = r.db.QueryRow(`
+		SELECT id, LENGTH(content) FROM memories
+		WHERE user_id = ? AND is_archived = 0
+		ORDER BY LENGTH(content) DESC
+		LIMIT 1
+	`, userID).Scan(&longest.ID, &longest.ContentLength)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		stats.LongestMemory = longest
+	}
+
 	return stats, nil
 }
 
@@ -413,15 +1019,22 @@ func (r *MemoryRepository) UpdatePositions(memories []models.MemoryPosition) err
 	return tx.Commit()
 }
 
+// ResetPositions renumbers the user's memories PositionResetStep apart in their
+// current order, in one transaction, and returns how many there are. updated_at is
+// left alone since the memories themselves don't change.
+func (r *MemoryRepository) ResetPositions(userID string) (int, error) {
+	return resetPositions(r.db, "memories", "CAST(position AS INTEGER) ASC, created_at DESC", userID)
+}
+
 // Helper function to scan memory rows
 func (r *MemoryRepository) scanMemories(rows *sql.Rows) ([]models.Memory, error) {
 	memories := []models.Memory{}
 	for rows.Next() {
 		memory := models.Memory{}
-		var summary, url, urlTitle, urlContent sql.NullString
-		var isArchived int
+		var summary, url, urlTitle, urlContent, contentLanguage sql.NullString
+		var isArchived, isPinned, aiProcessingFailed int
 
-		err := rows.Scan(&memory.ID, &memory.UserID, &memory.Content, &summary, &memory.Category, &url, &urlTitle, &urlContent, &isArchived, &memory.Position, &memory.CreatedAt, &memory.UpdatedAt)
+		err := rows.Scan(&memory.ID, &memory.UserID, &memory.Content, &summary, &memory.Category, &url, &urlTitle, &urlContent, &isArchived, &isPinned, &aiProcessingFailed, &contentLanguage, &memory.Position, &memory.LastScrapedAt, &memory.GeneratedTitle, &memory.ScrapeMode, &memory.ThumbnailURL, &memory.SummarySource, &memory.CreatedAt, &memory.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -439,6 +1052,11 @@ func (r *MemoryRepository) scanMemories(rows *sql.Rows) ([]models.Memory, error)
 			memory.URLContent = &urlContent.String
 		}
 		memory.IsArchived = isArchived == 1
+		memory.IsPinned = isPinned == 1
+		memory.AIProcessingFailed = aiProcessingFailed == 1
+		if contentLanguage.Valid {
+			memory.ContentLanguage = &contentLanguage.String
+		}
 
 		memories = append(memories, memory)
 	}
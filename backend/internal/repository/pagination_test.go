@@ -0,0 +1,162 @@
+package repository_test
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/todomyday/backend/internal/database"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// paginationCreatedAt are creation times written the ways created_at ends up stored,
+// oldest first. The first two are 05:30Z and 06:10Z in the hour that repeats when
+// daylight saving time ends, whose text sorts them the other way round.
+var paginationCreatedAt = []interface{}{
+	time.Date(2025, 11, 2, 1, 30, 0, 0, time.FixedZone("EDT", -4*3600)),
+	"2025-11-02 05:50:00", // CURRENT_TIMESTAMP
+	time.Date(2025, 11, 2, 1, 10, 0, 0, time.FixedZone("EST", -5*3600)),
+	time.Date(2025, 11, 2, 2, 0, 0, 0, time.FixedZone("EST", -5*3600)),
+	time.Date(2025, 11, 2, 7, 0, 0, 500000000, time.UTC),
+	time.Date(2025, 11, 2, 8, 0, 0, 500000000, time.FixedZone("CET", 3600)),
+	time.Date(2025, 11, 2, 7, 0, 0, 512345678, time.UTC),
+	time.Date(2025, 11, 2, 7, 0, 1, 0, time.UTC),
+}
+
+// newPaginationDB returns a database with a user whose todos and memories were
+// created at paginationCreatedAt, and their IDs newest first, ties by ID
+func newPaginationDB(t *testing.T) (db *sql.DB, userID string, todoIDs, memoryIDs []string) {
+	t.Helper()
+	db, err := database.Connect(filepath.Join(t.TempDir(), "test.db"), database.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	user := &models.User{Email: "pages@example.com"}
+	if err := repository.NewUserRepository(db).Create(user); err != nil {
+		t.Fatal(err)
+	}
+	todoRepo := repository.NewTodoRepository(db)
+	memoryRepo := repository.NewMemoryRepository(db)
+	for i, createdAt := range paginationCreatedAt {
+		todo := &models.Todo{UserID: user.ID, Title: fmt.Sprintf("Todo %d", i), Priority: models.PriorityMedium, Status: models.StatusPending}
+		if err := todoRepo.Create(todo); err != nil {
+			t.Fatal(err)
+		}
+		memory := &models.Memory{UserID: user.ID, Content: fmt.Sprintf("Memory %d", i), Category: "Notes"}
+		if err := memoryRepo.Create(memory); err != nil {
+			t.Fatal(err)
+		}
+		for table, id := range map[string]string{"todos": todo.ID, "memories": memory.ID} {
+			if _, err := db.Exec("UPDATE "+table+" SET created_at = ? WHERE id = ?", createdAt, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+		todoIDs = append(todoIDs, todo.ID)
+		memoryIDs = append(memoryIDs, memory.ID)
+	}
+
+	// The fifth and sixth rows were created at the same instant
+	for _, ids := range [][]string{todoIDs, memoryIDs} {
+		if ids[4] > ids[5] {
+			ids[4], ids[5] = ids[5], ids[4]
+		}
+		slices.Reverse(ids)
+	}
+	return db, user.ID, todoIDs, memoryIDs
+}
+
+func TestCursorPagesReturnAllItemsWithoutGaps(t *testing.T) {
+	db, userID, todoIDs, memoryIDs := newPaginationDB(t)
+	todoRepo := repository.NewTodoRepository(db)
+	memoryRepo := repository.NewMemoryRepository(db)
+
+	// page returns the IDs of a page and the cursor after its last item
+	tests := []struct {
+		name string
+		want []string
+		page func(cursor *models.PageCursor, limit int) ([]string, *models.PageCursor, error)
+	}{
+		{"todos", todoIDs, func(cursor *models.PageCursor, limit int) ([]string, *models.PageCursor, error) {
+			todos, err := todoRepo.GetAfterCursor(userID, cursor, limit, false)
+			if err != nil || len(todos) == 0 {
+				return nil, nil, err
+			}
+			ids := make([]string, len(todos))
+			for i, todo := range todos {
+				ids[i] = todo.ID
+			}
+			last := todos[len(todos)-1]
+			return ids, &models.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID}, nil
+		}},
+		{"memories", memoryIDs, func(cursor *models.PageCursor, limit int) ([]string, *models.PageCursor, error) {
+			memories, err := memoryRepo.GetAfterCursor(userID, cursor, limit)
+			if err != nil || len(memories) == 0 {
+				return nil, nil, err
+			}
+			ids := make([]string, len(memories))
+			for i, memory := range memories {
+				ids[i] = memory.ID
+			}
+			last := memories[len(memories)-1]
+			return ids, &models.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID}, nil
+		}},
+	}
+
+	for _, tt := range tests {
+		for _, limit := range []int{1, 2, 3, len(paginationCreatedAt)} {
+			t.Run(fmt.Sprintf("%s %d per page", tt.name, limit), func(t *testing.T) {
+				var got []string
+				var cursor *models.PageCursor
+				for range len(tt.want) + 1 {
+					ids, next, err := tt.page(cursor, limit)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(ids) == 0 {
+						break
+					}
+					got = append(got, ids...)
+					cursor = next
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("pages returned\n%v\nwant\n%v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestCursorPagesUseCreationTimeIndex(t *testing.T) {
+	db, userID, _, _ := newPaginationDB(t)
+
+	for _, table := range []string{"todos", "memories"} {
+		cursor := database.SortableTimestampParam(time.Now())
+		rows, err := db.Query("EXPLAIN QUERY PLAN SELECT id FROM "+table+" WHERE user_id = ? AND ("+
+			database.SortableTimestamp("created_at")+" < julianday(?)) ORDER BY "+
+			database.SortableTimestamp("created_at")+" DESC, id DESC LIMIT 10", userID, cursor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var plan []string
+		for rows.Next() {
+			var id, parent, notUsed int
+			var detail string
+			if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+				t.Fatal(err)
+			}
+			plan = append(plan, detail)
+		}
+		rows.Close()
+
+		if joined := strings.Join(plan, "; "); !strings.Contains(joined, "idx_"+table+"_user_created_at") || strings.Contains(joined, "TEMP B-TREE") {
+			t.Errorf("%s plan = %s, want a scan of idx_%s_user_created_at without sorting", table, joined, table)
+		}
+	}
+}
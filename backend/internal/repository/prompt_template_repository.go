@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+type PromptTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewPromptTemplateRepository(db *sql.DB) *PromptTemplateRepository {
+	return &PromptTemplateRepository{db: db}
+}
+
+func (r *PromptTemplateRepository) Create(template *models.PromptTemplate) error {
+	template.ID = uuid.New().String()
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO prompt_templates (id, user_id, template_name, template_body, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, template.ID, template.UserID, template.TemplateName, template.TemplateBody, template.IsActive, template.CreatedAt, template.UpdatedAt)
+
+	return err
+}
+
+func (r *PromptTemplateRepository) GetByID(id string) (*models.PromptTemplate, error) {
+	template := &models.PromptTemplate{}
+	var isActive int
+
+	err := r.db.QueryRow(`
+		SELECT id, user_id, template_name, template_body, is_active, created_at, updated_at
+		FROM prompt_templates WHERE id = ?
+	`, id).Scan(&template.ID, &template.UserID, &template.TemplateName, &template.TemplateBody, &isActive, &template.CreatedAt, &template.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	template.IsActive = isActive == 1
+	return template, nil
+}
+
+func (r *PromptTemplateRepository) GetAllByUserID(userID string) ([]models.PromptTemplate, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, template_name, template_body, is_active, created_at, updated_at
+		FROM prompt_templates
+		WHERE user_id = ?
+		ORDER BY template_name ASC, created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []models.PromptTemplate{}
+	for rows.Next() {
+		template := models.PromptTemplate{}
+		var isActive int
+
+		if err := rows.Scan(&template.ID, &template.UserID, &template.TemplateName, &template.TemplateBody, &isActive, &template.CreatedAt, &template.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		template.IsActive = isActive == 1
+		templates = append(templates, template)
+	}
+
+	return templates, nil
+}
+
+// GetActiveByName returns the user's active template for the given name
+func (r *PromptTemplateRepository) GetActiveByName(userID, name string) (*models.PromptTemplate, error) {
+	template := &models.PromptTemplate{}
+	var isActive int
+
+	err := r.db.QueryRow(`
+		SELECT id, user_id, template_name, template_body, is_active, created_at, updated_at
+		FROM prompt_templates
+		WHERE user_id = ? AND template_name = ? AND is_active = 1
+		ORDER BY updated_at DESC
+		LIMIT 1
+	`, userID, name).Scan(&template.ID, &template.UserID, &template.TemplateName, &template.TemplateBody, &isActive, &template.CreatedAt, &template.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	template.IsActive = isActive == 1
+	return template, nil
+}
+
+func (r *PromptTemplateRepository) Update(id string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+
+	query := "UPDATE prompt_templates SET "
+	args := []interface{}{}
+	first := true
+
+	for key, value := range updates {
+		if !first {
+			query += ", "
+		}
+		query += key + " = ?"
+		args = append(args, value)
+		first = false
+	}
+
+	query += " WHERE id = ?"
+	args = append(args, id)
+
+	_, err := r.db.Exec(query, args...)
+	return err
+}
+
+// DeactivateByName clears the active flag on all of a user's templates with the given name
+func (r *PromptTemplateRepository) DeactivateByName(userID, name string) error {
+	_, err := r.db.Exec("UPDATE prompt_templates SET is_active = 0 WHERE user_id = ? AND template_name = ?", userID, name)
+	return err
+}
+
+func (r *PromptTemplateRepository) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM prompt_templates WHERE id = ?", id)
+	return err
+}
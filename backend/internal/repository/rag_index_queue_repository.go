@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+type RAGIndexQueueRepository struct {
+	db *sql.DB
+}
+
+func NewRAGIndexQueueRepository(db *sql.DB) *RAGIndexQueueRepository {
+	return &RAGIndexQueueRepository{db: db}
+}
+
+// Enqueue adds a todo or memory to the retry queue. If it's already queued, pending or
+// dead, the entry starts over with the new error and no retries counted.
+func (r *RAGIndexQueueRepository) Enqueue(item *models.IndexQueueItem) error {
+	item.ID = uuid.New().String()
+	item.Status = models.IndexQueuePending
+	item.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO rag_index_queue (id, content_type, content_id, user_id, attempt_count, last_error, next_attempt_at, status, created_at)
+		VALUES (?, ?, ?, ?, 0, ?, ?, ?, ?)
+		ON CONFLICT(content_type, content_id) DO UPDATE SET
+			attempt_count = 0,
+			last_error = excluded.last_error,
+			next_attempt_at = excluded.next_attempt_at,
+			status = excluded.status
+	`, item.ID, item.ContentType, item.ContentID, item.UserID, item.LastError, item.NextAttemptAt, item.Status, item.CreatedAt)
+	return err
+}
+
+// GetDue returns up to limit pending entries with fewer than maxAttempts retries whose
+// next attempt is due at now, oldest due first
+func (r *RAGIndexQueueRepository) GetDue(now time.Time, maxAttempts, limit int) ([]models.IndexQueueItem, error) {
+	rows, err := r.db.Query(`
+		SELECT id, content_type, content_id, user_id, attempt_count, last_error, next_attempt_at, status, created_at
+		FROM rag_index_queue
+		WHERE status = ? AND attempt_count < ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC
+		LIMIT ?
+	`, models.IndexQueuePending, maxAttempts, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanIndexQueueItems(rows)
+}
+
+// GetByUserID returns the user's queue entries, pending and dead, newest first
+func (r *RAGIndexQueueRepository) GetByUserID(userID string) ([]models.IndexQueueItem, error) {
+	rows, err := r.db.Query(`
+		SELECT id, content_type, content_id, user_id, attempt_count, last_error, next_attempt_at, status, created_at
+		FROM rag_index_queue
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanIndexQueueItems(rows)
+}
+
+// RecordFailure stores the outcome of a failed retry
+func (r *RAGIndexQueueRepository) RecordFailure(id string, attemptCount int, lastError string, nextAttemptAt time.Time, status string) error {
+	_, err := r.db.Exec(`
+		UPDATE rag_index_queue SET attempt_count = ?, last_error = ?, next_attempt_at = ?, status = ? WHERE id = ?
+	`, attemptCount, lastError, nextAttemptAt, status, id)
+	return err
+}
+
+// Delete removes an entry once its content is indexed or gone
+func (r *RAGIndexQueueRepository) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM rag_index_queue WHERE id = ?", id)
+	return err
+}
+
+func scanIndexQueueItems(rows *sql.Rows) ([]models.IndexQueueItem, error) {
+	items := []models.IndexQueueItem{}
+	for rows.Next() {
+		var item models.IndexQueueItem
+		if err := rows.Scan(&item.ID, &item.ContentType, &item.ContentID, &item.UserID, &item.AttemptCount,
+			&item.LastError, &item.NextAttemptAt, &item.Status, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+type RSSFeedRepository struct {
+	db *sql.DB
+}
+
+func NewRSSFeedRepository(db *sql.DB) *RSSFeedRepository {
+	return &RSSFeedRepository{db: db}
+}
+
+// Save stores a feed for the user, updating max_items if the URL is already saved
+func (r *RSSFeedRepository) Save(feed *models.SavedRSSFeed) error {
+	feed.ID = uuid.New().String()
+	feed.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO saved_rss_feeds (id, user_id, url, max_items, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, url) DO UPDATE SET max_items = excluded.max_items
+	`, feed.ID, feed.UserID, feed.URL, feed.MaxItems, feed.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	// On conflict the existing row keeps its ID
+	return r.db.QueryRow(`
+		SELECT id, last_fetched_at, created_at FROM saved_rss_feeds WHERE user_id = ? AND url = ?
+	`, feed.UserID, feed.URL).Scan(&feed.ID, &feed.LastFetchedAt, &feed.CreatedAt)
+}
+
+func (r *RSSFeedRepository) GetByID(id string) (*models.SavedRSSFeed, error) {
+	feed := &models.SavedRSSFeed{}
+
+	err := r.db.QueryRow(`
+		SELECT id, user_id, url, max_items, last_fetched_at, created_at
+		FROM saved_rss_feeds WHERE id = ?
+	`, id).Scan(&feed.ID, &feed.UserID, &feed.URL, &feed.MaxItems, &feed.LastFetchedAt, &feed.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+func (r *RSSFeedRepository) GetAllByUserID(userID string) ([]models.SavedRSSFeed, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, url, max_items, last_fetched_at, created_at
+		FROM saved_rss_feeds
+		WHERE user_id = ?
+		ORDER BY created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRSSFeeds(rows)
+}
+
+// GetAll returns every saved feed, for the background import
+func (r *RSSFeedRepository) GetAll() ([]models.SavedRSSFeed, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, url, max_items, last_fetched_at, created_at
+		FROM saved_rss_feeds
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRSSFeeds(rows)
+}
+
+func (r *RSSFeedRepository) UpdateLastFetched(id string, fetchedAt time.Time) error {
+	_, err := r.db.Exec("UPDATE saved_rss_feeds SET last_fetched_at = ? WHERE id = ?", fetchedAt, id)
+	return err
+}
+
+func (r *RSSFeedRepository) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM saved_rss_feeds WHERE id = ?", id)
+	return err
+}
+
+func scanRSSFeeds(rows *sql.Rows) ([]models.SavedRSSFeed, error) {
+	feeds := []models.SavedRSSFeed{}
+	for rows.Next() {
+		var feed models.SavedRSSFeed
+		if err := rows.Scan(&feed.ID, &feed.UserID, &feed.URL, &feed.MaxItems, &feed.LastFetchedAt, &feed.CreatedAt); err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds, rows.Err()
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+// SearchHistoryRepository stores users' recent and saved searches
+type SearchHistoryRepository struct {
+	db *sql.DB
+}
+
+func NewSearchHistoryRepository(db *sql.DB) *SearchHistoryRepository {
+	return &SearchHistoryRepository{db: db}
+}
+
+// Create records a search, dropping the user's oldest entries beyond
+// models.MaxSearchHistoryEntries
+func (r *SearchHistoryRepository) Create(entry *models.SearchHistoryEntry) error {
+	entry.ID = uuid.New().String()
+	entry.CreatedAt = time.Now()
+
+	contentTypes, err := marshalContentTypes(entry.ContentTypes)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO search_history (id, user_id, query, content_types, result_count, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, entry.ID, entry.UserID, entry.Query, contentTypes, entry.ResultCount, entry.CreatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		DELETE FROM search_history
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM search_history WHERE user_id = ? ORDER BY created_at DESC LIMIT ?
+		)
+	`, entry.UserID, entry.UserID, models.MaxSearchHistoryEntries); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetByUserID returns the user's most recent searches, newest first
+func (r *SearchHistoryRepository) GetByUserID(userID string, limit int) ([]models.SearchHistoryEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, query, content_types, result_count, created_at
+		FROM search_history
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.SearchHistoryEntry{}
+	for rows.Next() {
+		var entry models.SearchHistoryEntry
+		var contentTypes string
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Query, &contentTypes, &entry.ResultCount, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.ContentTypes = unmarshalContentTypes(contentTypes)
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// DeleteByUserID clears the user's search history and returns how many entries were removed
+func (r *SearchHistoryRepository) DeleteByUserID(userID string) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM search_history WHERE user_id = ?", userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *SearchHistoryRepository) CreateSaved(search *models.SavedSearch) error {
+	search.ID = uuid.New().String()
+	search.CreatedAt = time.Now()
+
+	contentTypes, err := marshalContentTypes(search.ContentTypes)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO saved_searches (id, user_id, name, query, content_types, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, search.ID, search.UserID, search.Name, search.Query, contentTypes, search.CreatedAt)
+	return err
+}
+
+// GetSavedByID returns a saved search, or nil if it doesn't exist
+func (r *SearchHistoryRepository) GetSavedByID(id string) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	var contentTypes string
+	err := r.db.QueryRow(`
+		SELECT id, user_id, name, query, content_types, created_at
+		FROM saved_searches WHERE id = ?
+	`, id).Scan(&search.ID, &search.UserID, &search.Name, &search.Query, &contentTypes, &search.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	search.ContentTypes = unmarshalContentTypes(contentTypes)
+	return &search, nil
+}
+
+// GetSavedByUserID returns the user's saved searches ordered by name
+func (r *SearchHistoryRepository) GetSavedByUserID(userID string) ([]models.SavedSearch, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, name, query, content_types, created_at
+		FROM saved_searches
+		WHERE user_id = ?
+		ORDER BY name COLLATE NOCASE ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	searches := []models.SavedSearch{}
+	for rows.Next() {
+		var search models.SavedSearch
+		var contentTypes string
+		if err := rows.Scan(&search.ID, &search.UserID, &search.Name, &search.Query, &contentTypes, &search.CreatedAt); err != nil {
+			return nil, err
+		}
+		search.ContentTypes = unmarshalContentTypes(contentTypes)
+		searches = append(searches, search)
+	}
+
+	return searches, rows.Err()
+}
+
+// DeleteSaved deletes one of the user's saved searches, reporting whether it existed
+func (r *SearchHistoryRepository) DeleteSaved(id, userID string) (bool, error) {
+	result, err := r.db.Exec("DELETE FROM saved_searches WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// marshalContentTypes stores a content type filter as a JSON array ("[]" for none)
+func marshalContentTypes(contentTypes []string) (string, error) {
+	if contentTypes == nil {
+		contentTypes = []string{}
+	}
+	data, err := json.Marshal(contentTypes)
+	return string(data), err
+}
+
+func unmarshalContentTypes(data string) []string {
+	contentTypes := []string{}
+	json.Unmarshal([]byte(data), &contentTypes)
+	return contentTypes
+}
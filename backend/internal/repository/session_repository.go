@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+type SessionRepository struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Touch records activity on a session, creating it the first time it's seen.
+// A revoked session, or one belonging to another user, is left as it is.
+func (r *SessionRepository) Touch(session *models.Session) error {
+	now := time.Now()
+	session.LastActive = now
+	session.CreatedAt = now
+
+	_, err := r.db.Exec(`
+		INSERT INTO sessions (id, user_id, device_fingerprint, ip_address, user_agent, last_active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			device_fingerprint = excluded.device_fingerprint,
+			ip_address = excluded.ip_address,
+			user_agent = excluded.user_agent,
+			last_active = excluded.last_active
+		WHERE sessions.revoked_at IS NULL AND sessions.user_id = excluded.user_id
+	`, session.ID, session.UserID, session.DeviceFingerprint, session.IPAddress, session.UserAgent, session.LastActive, session.CreatedAt)
+
+	return err
+}
+
+func (r *SessionRepository) GetByID(id string) (*models.Session, error) {
+	session := &models.Session{}
+
+	err := r.db.QueryRow(`
+		SELECT id, user_id, device_fingerprint, ip_address, user_agent, last_active, created_at, refresh_token_hash, revoked_at
+		FROM sessions WHERE id = ?
+	`, id).Scan(&session.ID, &session.UserID, &session.DeviceFingerprint, &session.IPAddress, &session.UserAgent,
+		&session.LastActive, &session.CreatedAt, &session.RefreshTokenHash, &session.RevokedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// GetActiveByUserID returns the user's sessions that haven't been revoked, most recently active first
+func (r *SessionRepository) GetActiveByUserID(userID string) ([]models.Session, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, device_fingerprint, ip_address, user_agent, last_active, created_at, refresh_token_hash, revoked_at
+		FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL
+		ORDER BY last_active DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session := models.Session{}
+		if err := rows.Scan(&session.ID, &session.UserID, &session.DeviceFingerprint, &session.IPAddress, &session.UserAgent,
+			&session.LastActive, &session.CreatedAt, &session.RefreshTokenHash, &session.RevokedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepository) Revoke(id string) error {
+	_, err := r.db.Exec("UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL", time.Now(), id)
+	return err
+}
+
+// RevokeAllExcept revokes all of the user's active sessions other than keepID and returns how many were revoked
+func (r *SessionRepository) RevokeAllExcept(userID, keepID string) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE sessions SET revoked_at = ?
+		WHERE user_id = ? AND id != ? AND revoked_at IS NULL
+	`, time.Now(), userID, keepID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+type ShareTokenRepository struct {
+	db *sql.DB
+}
+
+func NewShareTokenRepository(db *sql.DB) *ShareTokenRepository {
+	return &ShareTokenRepository{db: db}
+}
+
+func (r *ShareTokenRepository) Create(share *models.ShareToken) error {
+	share.CreatedAt = time.Now()
+	_, err := r.db.Exec(`
+		INSERT INTO share_tokens (token, memory_id, expires_at, view_count, max_views, created_at)
+		VALUES (?, ?, ?, 0, ?, ?)
+	`, share.Token, share.MemoryID, share.ExpiresAt, share.MaxViews, share.CreatedAt)
+	return err
+}
+
+// RecordView counts a view of a share link and returns the shared memory's ID. It
+// returns "" without counting the view when the token doesn't exist, has expired or
+// has used up its views; the check and increment are one statement, so concurrent
+// views can't exceed max_views.
+func (r *ShareTokenRepository) RecordView(token string, now time.Time) (string, error) {
+	var memoryID string
+	err := r.db.QueryRow(`
+		UPDATE share_tokens SET view_count = view_count + 1
+		WHERE token = ? AND expires_at > ? AND (max_views IS NULL OR view_count < max_views)
+		RETURNING memory_id
+	`, token, now).Scan(&memoryID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return memoryID, err
+}
+
+// DeleteByMemoryID revokes every share link of a memory
+func (r *ShareTokenRepository) DeleteByMemoryID(memoryID string) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM share_tokens WHERE memory_id = ?", memoryID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteExpired removes share links that expired before now or have used up their views
+func (r *ShareTokenRepository) DeleteExpired(now time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM share_tokens WHERE expires_at <= ? OR (max_views IS NOT NULL AND view_count >= max_views)
+	`, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
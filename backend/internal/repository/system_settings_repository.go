@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/todomyday/backend/internal/database"
+	"github.com/todomyday/backend/internal/models"
+)
+
+// SystemSettingsRepository stores instance-wide settings as key/value pairs
+type SystemSettingsRepository struct {
+	db *sql.DB
+}
+
+func NewSystemSettingsRepository(db *sql.DB) *SystemSettingsRepository {
+	return &SystemSettingsRepository{db: db}
+}
+
+// Get returns a setting's value, or nil if it has never been set
+func (r *SystemSettingsRepository) Get(key string) (*string, error) {
+	var value string
+	err := r.db.QueryRow("SELECT value FROM system_settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// Set creates or replaces a setting
+func (r *SystemSettingsRepository) Set(key, value string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO system_settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, value, time.Now())
+	return err
+}
+
+// GetPragmas reads the PRAGMAs in effect on one pooled connection. Every connection
+// is opened with the same settings, so any of them is representative.
+func (r *SystemSettingsRepository) GetPragmas() (*models.DatabasePragmas, error) {
+	ctx := context.Background()
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var pragmas models.DatabasePragmas
+	var foreignKeys, synchronous int
+	for _, pragma := range []struct {
+		name string
+		dest interface{}
+	}{
+		{"journal_mode", &pragmas.JournalMode},
+		{"foreign_keys", &foreignKeys},
+		{"cache_size", &pragmas.CacheSize},
+		{"synchronous", &synchronous},
+		{"busy_timeout", &pragmas.BusyTimeoutMS},
+		{"wal_autocheckpoint", &pragmas.WALAutocheckpoint},
+	} {
+		if err := conn.QueryRowContext(ctx, "PRAGMA "+pragma.name).Scan(pragma.dest); err != nil {
+			return nil, err
+		}
+	}
+
+	pragmas.ForeignKeys = foreignKeys == 1
+	// PRAGMA synchronous reports the mode's index rather than its name
+	if synchronous >= 0 && synchronous < len(database.SynchronousModes) {
+		pragmas.Synchronous = database.SynchronousModes[synchronous]
+	}
+	return &pragmas, nil
+}
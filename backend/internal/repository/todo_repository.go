@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -39,24 +41,119 @@ func (r *TodoRepository) Create(todo *models.Todo) error {
 	tagsJSON, _ := json.Marshal(todo.Tags)
 
 	_, err := r.db.Exec(`
-		INSERT INTO todos (id, user_id, group_id, title, description, due_date, priority, status, position, tags, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, todo.ID, todo.UserID, todo.GroupID, todo.Title, todo.Description, todo.DueDate, todo.Priority, todo.Status, todo.Position, string(tagsJSON), todo.CreatedAt, todo.UpdatedAt)
+		INSERT INTO todos (id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, todo.ID, todo.UserID, todo.GroupID, todo.Title, todo.Description, todo.DueDate, todo.Priority, todo.Status, todo.Position, string(tagsJSON), todo.StoryPoints, todo.EstimatedDuration, todo.CreatedAt, todo.UpdatedAt)
 
 	return err
 }
 
+// CreateBatch inserts all todos in a single transaction; none are stored if any insert fails
+func (r *TodoRepository) CreateBatch(todos []*models.Todo) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO todos (id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, todo := range todos {
+		todo.ID = uuid.New().String()
+		todo.CreatedAt = now
+		todo.UpdatedAt = now
+
+		if todo.Priority == "" {
+			todo.Priority = models.PriorityMedium
+		}
+		if todo.Status == "" {
+			todo.Status = models.StatusPending
+		}
+		if todo.Position == "" {
+			todo.Position = "1000"
+		}
+		if todo.Tags == nil {
+			todo.Tags = []string{}
+		}
+
+		tagsJSON, _ := json.Marshal(todo.Tags)
+
+		if _, err := stmt.Exec(todo.ID, todo.UserID, todo.GroupID, todo.Title, todo.Description, todo.DueDate, todo.Priority, todo.Status, todo.Position, string(tagsJSON), todo.StoryPoints, todo.EstimatedDuration, todo.CreatedAt, todo.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to create todo %q: %w", todo.Title, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Import inserts todos keeping their IDs, skipping any whose ID already exists.
+// Returns how many were inserted.
+func (r *TodoRepository) Import(todos []models.Todo) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT OR IGNORE INTO todos (id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	inserted := 0
+	for _, t := range todos {
+		if t.Priority == "" {
+			t.Priority = models.PriorityMedium
+		}
+		if t.Status == "" {
+			t.Status = models.StatusPending
+		}
+		if t.Position == "" {
+			t.Position = "1000"
+		}
+		if t.Tags == nil {
+			t.Tags = []string{}
+		}
+
+		tagsJSON, _ := json.Marshal(t.Tags)
+
+		result, err := stmt.Exec(t.ID, t.UserID, t.GroupID, t.Title, t.Description, t.DueDate, t.Priority, t.Status, t.Position, string(tagsJSON), t.StoryPoints, t.EstimatedDuration, t.CreatedAt, t.UpdatedAt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to import todo %s: %w", t.ID, err)
+		}
+		n, _ := result.RowsAffected()
+		inserted += int(n)
+	}
+
+	return inserted, tx.Commit()
+}
+
 func (r *TodoRepository) GetByID(id string) (*models.Todo, error) {
 	todo := &models.Todo{}
 	var tagsJSON string
 	var groupID sql.NullString
 	var description sql.NullString
 	var dueDate sql.NullString
+	var storyPoints sql.NullInt64
+	var estimatedDuration sql.NullString
+	var originalPriority sql.NullString
 
 	err := r.db.QueryRow(`
-		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, created_at, updated_at
+		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, original_priority, priority_locked_until, created_at, updated_at
 		FROM todos WHERE id = ?
-	`, id).Scan(&todo.ID, &todo.UserID, &groupID, &todo.Title, &description, &dueDate, &todo.Priority, &todo.Status, &todo.Position, &tagsJSON, &todo.CreatedAt, &todo.UpdatedAt)
+	`, id).Scan(&todo.ID, &todo.UserID, &groupID, &todo.Title, &description, &dueDate, &todo.Priority, &todo.Status, &todo.Position, &tagsJSON, &storyPoints, &estimatedDuration, &originalPriority, &todo.PriorityLockedUntil, &todo.CreatedAt, &todo.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -74,6 +171,17 @@ func (r *TodoRepository) GetByID(id string) (*models.Todo, error) {
 	if dueDate.Valid {
 		todo.DueDate = &dueDate.String
 	}
+	if storyPoints.Valid {
+		points := int(storyPoints.Int64)
+		todo.StoryPoints = &points
+	}
+	if estimatedDuration.Valid {
+		todo.EstimatedDuration = &estimatedDuration.String
+	}
+	if originalPriority.Valid {
+		priority := models.Priority(originalPriority.String)
+		todo.OriginalPriority = &priority
+	}
 
 	json.Unmarshal([]byte(tagsJSON), &todo.Tags)
 	if todo.Tags == nil {
@@ -83,16 +191,237 @@ func (r *TodoRepository) GetByID(id string) (*models.Todo, error) {
 	return todo, nil
 }
 
-func (r *TodoRepository) GetAllByUserID(userID string) ([]models.Todo, error) {
+// GetAllByUserID returns the user's todos. Todos in archived groups are left out
+// unless includeArchivedGroups is set; ungrouped todos are always included.
+func (r *TodoRepository) GetAllByUserID(userID string, includeArchivedGroups bool) ([]models.Todo, error) {
+	query := `
+		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, original_priority, priority_locked_until, created_at, updated_at
+		FROM todos WHERE user_id = ?`
+	if !includeArchivedGroups {
+		query += " AND (group_id IS NULL OR group_id NOT IN (SELECT id FROM groups WHERE is_archived = 1))"
+	}
+	query += " ORDER BY CAST(position AS INTEGER) ASC, created_at ASC"
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTodos(rows)
+}
+
+// GetAfterCursor returns up to limit of the user's todos, newest first, that come after
+// cursor (from the start if it's nil), using the (user_id, creation time, id) index
+func (r *TodoRepository) GetAfterCursor(userID string, cursor *models.PageCursor, limit int, includeArchivedGroups bool) ([]models.Todo, error) {
+	query := `
+		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, original_priority, priority_locked_until, created_at, updated_at
+		FROM todos WHERE user_id = ?`
+	args := []interface{}{userID}
+	if !includeArchivedGroups {
+		query += " AND (group_id IS NULL OR group_id NOT IN (SELECT id FROM groups WHERE is_archived = 1))"
+	}
+	if cursor != nil {
+		query += " AND " + afterCursorCondition
+		args = append(args, cursorArgs(cursor)...)
+	}
+	query += " ORDER BY " + createdAtKey + " DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTodos(rows)
+}
+
+// GetFiltered returns the user's todos matching the filter. Tags are matched
+// case-insensitively: with TagOpAnd a todo must carry every tag, otherwise any one.
+func (r *TodoRepository) GetFiltered(userID string, filter *models.TodoFilterRequest) ([]models.Todo, error) {
+	query := `
+		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, original_priority, priority_locked_until, created_at, updated_at
+		FROM todos WHERE user_id = ?`
+	args := []interface{}{userID}
+
+	if !filter.IncludeArchivedGroups {
+		query += " AND (group_id IS NULL OR group_id NOT IN (SELECT id FROM groups WHERE is_archived = 1))"
+	}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.Priority != "" {
+		query += " AND priority = ?"
+		args = append(args, filter.Priority)
+	}
+	if filter.GroupID != "" {
+		query += " AND group_id = ?"
+		args = append(args, filter.GroupID)
+	}
+
+	if len(filter.Tags) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(filter.Tags)), ",")
+		tagArgs := make([]interface{}, len(filter.Tags))
+		for i, tag := range filter.Tags {
+			tagArgs[i] = strings.ToLower(tag)
+		}
+
+		if filter.TagOp == models.TagOpAnd {
+			query += `
+			AND id IN (
+				SELECT t.id FROM todos t, json_each(t.tags) j
+				WHERE t.user_id = ? AND LOWER(j.value) IN (` + placeholders + `)
+				GROUP BY t.id
+				HAVING COUNT(DISTINCT LOWER(j.value)) = ?
+			)`
+			args = append(args, userID)
+			args = append(args, tagArgs...)
+			args = append(args, len(filter.Tags))
+		} else {
+			query += `
+			AND EXISTS (
+				SELECT 1 FROM json_each(todos.tags) j
+				WHERE LOWER(j.value) IN (` + placeholders + `)
+			)`
+			args = append(args, tagArgs...)
+		}
+	}
+
+	query += " ORDER BY " + TodoOrderBy(filter.Sort, filter.Order)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTodos(rows)
+}
+
+// todoSortColumns maps sort keys to the expressions they order by. Priority ranks
+// high first and titles compare case-insensitively.
+var todoSortColumns = map[string]string{
+	models.TodoSortPosition:  "CAST(position AS INTEGER)",
+	models.TodoSortDueDate:   "due_date",
+	models.TodoSortCreatedAt: "created_at",
+	models.TodoSortPriority:  "CASE priority WHEN 'high' THEN 0 WHEN 'medium' THEN 1 ELSE 2 END",
+	models.TodoSortTitle:     "title COLLATE NOCASE",
+}
+
+// IsTodoSortKey reports whether sort is a key GetFiltered can order by
+func IsTodoSortKey(sort string) bool {
+	_, ok := todoSortColumns[sort]
+	return ok
+}
+
+// TodoOrderBy returns the ORDER BY clause for a sort key and order. Unknown keys
+// fall back to position; ties are broken by creation time so pages are stable.
+func TodoOrderBy(sort, order string) string {
+	column, ok := todoSortColumns[sort]
+	if !ok {
+		column = todoSortColumns[models.TodoSortPosition]
+	}
+	direction := "ASC"
+	if order == models.SortOrderDesc {
+		direction = "DESC"
+	}
+
+	clause := column + " " + direction
+	if sort == models.TodoSortDueDate {
+		clause = "due_date IS NULL, " + clause
+	}
+	if sort != models.TodoSortCreatedAt {
+		clause += ", created_at ASC"
+	}
+	return clause
+}
+
+// GetByIDs returns the user's todos among ids; IDs owned by other users are ignored
+func (r *TodoRepository) GetByIDs(userID string, ids []string) ([]models.Todo, error) {
+	where, args := idsWhere(userID, ids)
 	rows, err := r.db.Query(`
-		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, created_at, updated_at
-		FROM todos WHERE user_id = ? ORDER BY position ASC
+		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, original_priority, priority_locked_until, created_at, updated_at
+		FROM todos WHERE `+where+` ORDER BY position ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTodos(rows)
+}
+
+// CountOwned returns how many of ids are todos belonging to the user
+func (r *TodoRepository) CountOwned(userID string, ids []string) (int, error) {
+	where, args := idsWhere(userID, ids)
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM todos WHERE "+where, args...).Scan(&count)
+	return count, err
+}
+
+// UpdatePriorityBulk sets the priority of the user's todos among ids in one statement
+func (r *TodoRepository) UpdatePriorityBulk(userID string, ids []string, priority models.Priority) (int64, error) {
+	where, args := idsWhere(userID, ids)
+	args = append([]interface{}{priority, time.Now()}, args...)
+	result, err := r.db.Exec("UPDATE todos SET priority = ?, updated_at = ? WHERE "+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetDueByUserID returns the user's pending todos that have a due date
+func (r *TodoRepository) GetDueByUserID(userID string) ([]models.Todo, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, original_priority, priority_locked_until, created_at, updated_at
+		FROM todos
+		WHERE user_id = ? AND status = 'pending' AND due_date IS NOT NULL AND due_date != ''
 	`, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanTodos(rows)
+}
+
+// SetAutoPriority changes a todo's priority on behalf of auto-priority, keeping the
+// priority it had before auto-priority first changed it in original_priority
+func (r *TodoRepository) SetAutoPriority(id string, priority models.Priority) error {
+	_, err := r.db.Exec(`
+		UPDATE todos SET original_priority = COALESCE(original_priority, priority), priority = ?, updated_at = ?
+		WHERE id = ?
+	`, priority, time.Now(), id)
+	return err
+}
+
+// RestoreOriginalPriorities puts back the priorities auto-priority changed on the
+// user's todos and returns how many were restored
+func (r *TodoRepository) RestoreOriginalPriorities(userID string) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE todos SET priority = original_priority, original_priority = NULL, updated_at = ?
+		WHERE user_id = ? AND original_priority IS NOT NULL
+	`, time.Now(), userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// idsWhere builds "id IN (...) AND user_id = ?" with its arguments
+func idsWhere(userID string, ids []string) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, 0, len(ids)+1)
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	args = append(args, userID)
+	return fmt.Sprintf("id IN (%s) AND user_id = ?", strings.Join(placeholders, ",")), args
+}
+
+func scanTodos(rows *sql.Rows) ([]models.Todo, error) {
 	todos := []models.Todo{}
 	for rows.Next() {
 		todo := models.Todo{}
@@ -100,8 +429,11 @@ func (r *TodoRepository) GetAllByUserID(userID string) ([]models.Todo, error) {
 		var groupID sql.NullString
 		var description sql.NullString
 		var dueDate sql.NullString
+		var storyPoints sql.NullInt64
+		var estimatedDuration sql.NullString
+		var originalPriority sql.NullString
 
-		err := rows.Scan(&todo.ID, &todo.UserID, &groupID, &todo.Title, &description, &dueDate, &todo.Priority, &todo.Status, &todo.Position, &tagsJSON, &todo.CreatedAt, &todo.UpdatedAt)
+		err := rows.Scan(&todo.ID, &todo.UserID, &groupID, &todo.Title, &description, &dueDate, &todo.Priority, &todo.Status, &todo.Position, &tagsJSON, &storyPoints, &estimatedDuration, &originalPriority, &todo.PriorityLockedUntil, &todo.CreatedAt, &todo.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -115,6 +447,17 @@ func (r *TodoRepository) GetAllByUserID(userID string) ([]models.Todo, error) {
 		if dueDate.Valid {
 			todo.DueDate = &dueDate.String
 		}
+		if storyPoints.Valid {
+			points := int(storyPoints.Int64)
+			todo.StoryPoints = &points
+		}
+		if estimatedDuration.Valid {
+			todo.EstimatedDuration = &estimatedDuration.String
+		}
+		if originalPriority.Valid {
+			priority := models.Priority(originalPriority.String)
+			todo.OriginalPriority = &priority
+		}
 
 		json.Unmarshal([]byte(tagsJSON), &todo.Tags)
 		if todo.Tags == nil {
@@ -177,6 +520,79 @@ func (r *TodoRepository) CountByUserID(userID string) (int, error) {
 	return count, err
 }
 
+// MarkIndexed records when the user's todos among ids were last indexed for RAG,
+// leaving updated_at alone
+func (r *TodoRepository) MarkIndexed(userID string, ids []string, indexedAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	where, args := idsWhere(userID, ids)
+	_, err := r.db.Exec("UPDATE todos SET last_indexed_at = ? WHERE "+where, append([]interface{}{indexedAt}, args...)...)
+	return err
+}
+
+// CountStale returns how many of the user's todos were never indexed for RAG or have
+// changed since they last were
+func (r *TodoRepository) CountStale(userID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM todos
+		WHERE user_id = ? AND (last_indexed_at IS NULL OR updated_at > last_indexed_at)
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// groupStatsColumns aggregates todo counts and story points for GroupStats. julianday() parses the
+// ISO-8601 due dates (with or without time and offset) so they compare as instants.
+const groupStatsColumns = `
+	COUNT(*),
+	COALESCE(SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(CASE WHEN status = 'pending' AND due_date IS NOT NULL AND due_date != ''
+		AND julianday(due_date) < julianday('now') THEN 1 ELSE 0 END), 0),
+	COALESCE(SUM(story_points), 0)
+`
+
+// GetStatsByGroup returns todo counts for each of a user's groups, keyed by group ID.
+// Ungrouped todos are excluded and groups without todos are absent from the map.
+func (r *TodoRepository) GetStatsByGroup(userID string) (map[string]models.GroupStats, error) {
+	rows, err := r.db.Query(`
+		SELECT group_id,`+groupStatsColumns+`
+		FROM todos
+		WHERE user_id = ? AND group_id IS NOT NULL
+		GROUP BY group_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]models.GroupStats)
+	for rows.Next() {
+		var groupID string
+		var s models.GroupStats
+		if err := rows.Scan(&groupID, &s.Total, &s.Completed, &s.Pending, &s.Overdue, &s.StoryPoints); err != nil {
+			return nil, err
+		}
+		stats[groupID] = s
+	}
+	return stats, rows.Err()
+}
+
+// GetStatsForGroup returns todo counts for a single group of a user
+func (r *TodoRepository) GetStatsForGroup(userID, groupID string) (*models.GroupStats, error) {
+	var s models.GroupStats
+	err := r.db.QueryRow(`
+		SELECT`+groupStatsColumns+`
+		FROM todos
+		WHERE user_id = ? AND group_id = ?
+	`, userID, groupID).Scan(&s.Total, &s.Completed, &s.Pending, &s.Overdue, &s.StoryPoints)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
 func (r *TodoRepository) GetMaxPosition(userID string) (int, error) {
 	var maxPos sql.NullInt64
 	err := r.db.QueryRow(`
@@ -216,3 +632,206 @@ func (r *TodoRepository) UpdatePositions(todos []models.TodoPosition) error {
 
 	return tx.Commit()
 }
+
+// ResetPositions renumbers the user's todos PositionResetStep apart in their current
+// order, in one transaction, and returns how many there are. updated_at is left
+// alone since the todos themselves don't change.
+func (r *TodoRepository) ResetPositions(userID string) (int, error) {
+	return resetPositions(r.db, "todos", "CAST(position AS INTEGER) ASC, created_at ASC", userID)
+}
+
+// resetPositions renumbers the user's rows of table in order for ResetPositions
+func resetPositions(db *sql.DB, table, order, userID string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM "+table+" WHERE user_id = ? ORDER BY "+order, userID)
+	if err != nil {
+		return 0, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare("UPDATE " + table + " SET position = ? WHERE id = ?")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for i, id := range ids {
+		if _, err := stmt.Exec(strconv.Itoa((i+1)*models.PositionResetStep), id); err != nil {
+			return 0, fmt.Errorf("failed to reset position of %s: %w", id, err)
+		}
+	}
+
+	return len(ids), tx.Commit()
+}
+
+// AddDependency records that blockerID blocks blockedID; adding an existing dependency is a no-op
+func (r *TodoRepository) AddDependency(blockerID, blockedID string) error {
+	_, err := r.db.Exec(`
+		INSERT OR IGNORE INTO todo_dependencies (blocker_id, blocked_id, created_at) VALUES (?, ?, ?)
+	`, blockerID, blockedID, time.Now())
+	return err
+}
+
+// RemoveDependency deletes a dependency and reports whether it existed
+func (r *TodoRepository) RemoveDependency(blockerID, blockedID string) (bool, error) {
+	result, err := r.db.Exec("DELETE FROM todo_dependencies WHERE blocker_id = ? AND blocked_id = ?", blockerID, blockedID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetBlockerIDs returns the IDs of the todos blocking todoID
+func (r *TodoRepository) GetBlockerIDs(todoID string) ([]string, error) {
+	return r.queryIDs("SELECT blocker_id FROM todo_dependencies WHERE blocked_id = ? ORDER BY created_at", todoID)
+}
+
+// GetBlockedIDs returns the IDs of the todos todoID blocks
+func (r *TodoRepository) GetBlockedIDs(todoID string) ([]string, error) {
+	return r.queryIDs("SELECT blocked_id FROM todo_dependencies WHERE blocker_id = ? ORDER BY created_at", todoID)
+}
+
+func (r *TodoRepository) queryIDs(query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetBlockers returns the todos blocking todoID
+func (r *TodoRepository) GetBlockers(todoID string) ([]models.Todo, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, original_priority, priority_locked_until, created_at, updated_at
+		FROM todos WHERE id IN (SELECT blocker_id FROM todo_dependencies WHERE blocked_id = ?)
+		ORDER BY position ASC
+	`, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTodos(rows)
+}
+
+// GetBlocking returns the todos todoID blocks
+func (r *TodoRepository) GetBlocking(todoID string) ([]models.Todo, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, original_priority, priority_locked_until, created_at, updated_at
+		FROM todos WHERE id IN (SELECT blocked_id FROM todo_dependencies WHERE blocker_id = ?)
+		ORDER BY position ASC
+	`, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTodos(rows)
+}
+
+// GetUnblockedBy returns the pending todos blocked by todoID that have no other
+// pending blockers, i.e. the ones completing todoID left free to start
+func (r *TodoRepository) GetUnblockedBy(todoID string) ([]models.Todo, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, group_id, title, description, due_date, priority, status, position, tags, story_points, estimated_duration, original_priority, priority_locked_until, created_at, updated_at
+		FROM todos t
+		WHERE t.status = 'pending'
+			AND t.id IN (SELECT blocked_id FROM todo_dependencies WHERE blocker_id = ?)
+			AND NOT EXISTS (
+				SELECT 1 FROM todo_dependencies d JOIN todos b ON b.id = d.blocker_id
+				WHERE d.blocked_id = t.id AND b.status != 'completed'
+			)
+		ORDER BY position ASC
+	`, todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTodos(rows)
+}
+
+// RecordCompletion counts one more todo completed by the user on date (YYYY-MM-DD)
+func (r *TodoRepository) RecordCompletion(userID, date string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO todo_completions (user_id, completed_date, count) VALUES (?, ?, 1)
+		ON CONFLICT(user_id, completed_date) DO UPDATE SET count = count + 1
+	`, userID, date)
+	return err
+}
+
+// GetCompletionDates returns every date (YYYY-MM-DD) the user completed a todo on, oldest first
+func (r *TodoRepository) GetCompletionDates(userID string) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT CAST(completed_date AS TEXT) FROM todo_completions
+		WHERE user_id = ? AND count > 0
+		ORDER BY completed_date ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []string
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		dates = append(dates, date)
+	}
+	return dates, rows.Err()
+}
+
+// GetCompletionCounts returns the number of todos the user completed on each date
+// from from to to inclusive (YYYY-MM-DD), keyed by date. Dates without completions
+// are left out.
+func (r *TodoRepository) GetCompletionCounts(userID, from, to string) (map[string]int, error) {
+	rows, err := r.db.Query(`
+		SELECT CAST(completed_date AS TEXT), count FROM todo_completions
+		WHERE user_id = ? AND completed_date BETWEEN ? AND ?
+	`, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var date string
+		var count int
+		if err := rows.Scan(&date, &count); err != nil {
+			return nil, err
+		}
+		counts[date] = count
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+)
+
+type TodoTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewTodoTemplateRepository(db *sql.DB) *TodoTemplateRepository {
+	return &TodoTemplateRepository{db: db}
+}
+
+func (r *TodoTemplateRepository) Create(template *models.TodoTemplate) error {
+	template.ID = uuid.New().String()
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = time.Now()
+
+	if template.Tasks == nil {
+		template.Tasks = []models.TodoTemplateTask{}
+	}
+
+	tasksJSON, err := json.Marshal(template.Tasks)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		INSERT INTO todo_templates (id, user_id, name, description, tasks, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, template.ID, template.UserID, template.Name, template.Description, string(tasksJSON), template.CreatedAt, template.UpdatedAt)
+
+	return err
+}
+
+func (r *TodoTemplateRepository) GetByID(id string) (*models.TodoTemplate, error) {
+	template := &models.TodoTemplate{}
+	var userID sql.NullString
+	var tasksJSON string
+
+	err := r.db.QueryRow(`
+		SELECT id, user_id, name, description, tasks, created_at, updated_at
+		FROM todo_templates WHERE id = ?
+	`, id).Scan(&template.ID, &userID, &template.Name, &template.Description, &tasksJSON, &template.CreatedAt, &template.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fillTodoTemplate(template, userID, tasksJSON)
+	return template, nil
+}
+
+// GetAllByUserID returns the system templates followed by the user's own templates
+func (r *TodoTemplateRepository) GetAllByUserID(userID string) ([]models.TodoTemplate, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, name, description, tasks, created_at, updated_at
+		FROM todo_templates
+		WHERE user_id = ? OR user_id IS NULL
+		ORDER BY user_id IS NOT NULL, name ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []models.TodoTemplate{}
+	for rows.Next() {
+		template := models.TodoTemplate{}
+		var userID sql.NullString
+		var tasksJSON string
+
+		if err := rows.Scan(&template.ID, &userID, &template.Name, &template.Description, &tasksJSON, &template.CreatedAt, &template.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		fillTodoTemplate(&template, userID, tasksJSON)
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+func (r *TodoTemplateRepository) Update(id string, updates map[string]interface{}) error {
+	if tasks, ok := updates["tasks"].([]models.TodoTemplateTask); ok {
+		tasksJSON, err := json.Marshal(tasks)
+		if err != nil {
+			return err
+		}
+		updates["tasks"] = string(tasksJSON)
+	}
+	updates["updated_at"] = time.Now()
+
+	query := "UPDATE todo_templates SET "
+	args := []interface{}{}
+	first := true
+
+	for key, value := range updates {
+		if !first {
+			query += ", "
+		}
+		query += key + " = ?"
+		args = append(args, value)
+		first = false
+	}
+
+	query += " WHERE id = ?"
+	args = append(args, id)
+
+	_, err := r.db.Exec(query, args...)
+	return err
+}
+
+func (r *TodoTemplateRepository) Delete(id string) error {
+	_, err := r.db.Exec("DELETE FROM todo_templates WHERE id = ?", id)
+	return err
+}
+
+// fillTodoTemplate sets the fields derived from a scanned row's nullable user_id and tasks JSON
+func fillTodoTemplate(template *models.TodoTemplate, userID sql.NullString, tasksJSON string) {
+	if userID.Valid {
+		template.UserID = &userID.String
+	}
+	template.IsSystem = !userID.Valid
+
+	if err := json.Unmarshal([]byte(tasksJSON), &template.Tasks); err != nil || template.Tasks == nil {
+		template.Tasks = []models.TodoTemplateTask{}
+	}
+}
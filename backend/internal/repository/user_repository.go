@@ -20,13 +20,16 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 func (r *UserRepository) Create(user *models.User) error {
 	user.ID = uuid.New().String()
 	user.Theme = "light"
+	if user.Timezone == "" {
+		user.Timezone = "UTC"
+	}
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
 	_, err := r.db.Exec(`
-		INSERT INTO users (id, supabase_id, email, password_hash, full_name, theme, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, user.ID, user.SupabaseID, user.Email, user.PasswordHash, user.FullName, user.Theme, user.CreatedAt, user.UpdatedAt)
+		INSERT INTO users (id, supabase_id, oidc_sub, email, password_hash, full_name, theme, timezone, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, user.ID, user.SupabaseID, user.OIDCSub, user.Email, user.PasswordHash, user.FullName, user.Theme, user.Timezone, user.CreatedAt, user.UpdatedAt)
 
 	return err
 }
@@ -34,9 +37,9 @@ func (r *UserRepository) Create(user *models.User) error {
 func (r *UserRepository) GetByID(id string) (*models.User, error) {
 	user := &models.User{}
 	err := r.db.QueryRow(`
-		SELECT id, supabase_id, email, password_hash, full_name, theme, created_at, updated_at
+		SELECT id, supabase_id, oidc_sub, email, password_hash, full_name, theme, COALESCE(timezone, 'UTC'), COALESCE(email_digest_enabled, 0), last_digest_sent_at, created_at, updated_at
 		FROM users WHERE id = ?
-	`, id).Scan(&user.ID, &user.SupabaseID, &user.Email, &user.PasswordHash, &user.FullName, &user.Theme, &user.CreatedAt, &user.UpdatedAt)
+	`, id).Scan(&user.ID, &user.SupabaseID, &user.OIDCSub, &user.Email, &user.PasswordHash, &user.FullName, &user.Theme, &user.Timezone, &user.EmailDigestEnabled, &user.LastDigestSentAt, &user.CreatedAt, &user.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -51,9 +54,9 @@ func (r *UserRepository) GetByID(id string) (*models.User, error) {
 func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 	user := &models.User{}
 	err := r.db.QueryRow(`
-		SELECT id, supabase_id, email, password_hash, full_name, theme, created_at, updated_at
+		SELECT id, supabase_id, email, password_hash, full_name, theme, COALESCE(timezone, 'UTC'), COALESCE(email_digest_enabled, 0), last_digest_sent_at, created_at, updated_at
 		FROM users WHERE email = ?
-	`, email).Scan(&user.ID, &user.SupabaseID, &user.Email, &user.PasswordHash, &user.FullName, &user.Theme, &user.CreatedAt, &user.UpdatedAt)
+	`, email).Scan(&user.ID, &user.SupabaseID, &user.Email, &user.PasswordHash, &user.FullName, &user.Theme, &user.Timezone, &user.EmailDigestEnabled, &user.LastDigestSentAt, &user.CreatedAt, &user.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -68,9 +71,9 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 func (r *UserRepository) GetBySupabaseID(supabaseID string) (*models.User, error) {
 	user := &models.User{}
 	err := r.db.QueryRow(`
-		SELECT id, supabase_id, email, password_hash, full_name, theme, created_at, updated_at
+		SELECT id, supabase_id, email, password_hash, full_name, theme, COALESCE(timezone, 'UTC'), COALESCE(email_digest_enabled, 0), last_digest_sent_at, created_at, updated_at
 		FROM users WHERE supabase_id = ?
-	`, supabaseID).Scan(&user.ID, &user.SupabaseID, &user.Email, &user.PasswordHash, &user.FullName, &user.Theme, &user.CreatedAt, &user.UpdatedAt)
+	`, supabaseID).Scan(&user.ID, &user.SupabaseID, &user.Email, &user.PasswordHash, &user.FullName, &user.Theme, &user.Timezone, &user.EmailDigestEnabled, &user.LastDigestSentAt, &user.CreatedAt, &user.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -129,6 +132,67 @@ func (r *UserRepository) CreateOrUpdateFromSupabase(user *models.User) error {
 	return r.Create(user)
 }
 
+func (r *UserRepository) GetByOIDCSub(oidcSub string) (*models.User, error) {
+	user := &models.User{}
+	err := r.db.QueryRow(`
+		SELECT id, supabase_id, oidc_sub, email, password_hash, full_name, theme, COALESCE(timezone, 'UTC'), COALESCE(email_digest_enabled, 0), last_digest_sent_at, created_at, updated_at
+		FROM users WHERE oidc_sub = ?
+	`, oidcSub).Scan(&user.ID, &user.SupabaseID, &user.OIDCSub, &user.Email, &user.PasswordHash, &user.FullName, &user.Theme, &user.Timezone, &user.EmailDigestEnabled, &user.LastDigestSentAt, &user.CreatedAt, &user.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CreateOrUpdateFromOIDC upserts a user signing in with OIDC: by oidc_sub, then by
+// email (linking an existing account to the provider), else as a new passwordless user
+func (r *UserRepository) CreateOrUpdateFromOIDC(user *models.User) error {
+	if user.OIDCSub == nil {
+		return fmt.Errorf("oidc_sub is required")
+	}
+
+	existing, err := r.GetByOIDCSub(*user.OIDCSub)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		updates := map[string]interface{}{
+			"email":      user.Email,
+			"updated_at": time.Now(),
+		}
+		if user.FullName != nil {
+			updates["full_name"] = user.FullName
+		}
+		return r.Update(existing.ID, updates)
+	}
+
+	existingByEmail, err := r.GetByEmail(user.Email)
+	if err != nil {
+		return err
+	}
+
+	if existingByEmail != nil {
+		updates := map[string]interface{}{
+			"oidc_sub":   user.OIDCSub,
+			"updated_at": time.Now(),
+		}
+		if existingByEmail.FullName == nil && user.FullName != nil {
+			updates["full_name"] = user.FullName
+		}
+		return r.Update(existingByEmail.ID, updates)
+	}
+
+	// OIDC users have no local password
+	user.PasswordHash = nil
+	return r.Create(user)
+}
+
 func (r *UserRepository) Update(id string, updates map[string]interface{}) error {
 	updates["updated_at"] = time.Now()
 
@@ -151,3 +215,182 @@ func (r *UserRepository) Update(id string, updates map[string]interface{}) error
 	_, err := r.db.Exec(query, args...)
 	return err
 }
+
+// GetSortMode returns the user's memory sort mode, or "" if the user doesn't exist
+func (r *UserRepository) GetSortMode(id string) (string, error) {
+	var sortMode sql.NullString
+	err := r.db.QueryRow("SELECT sort_mode FROM users WHERE id = ?", id).Scan(&sortMode)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return sortMode.String, err
+}
+
+// SetSortMode stores the user's memory sort mode
+func (r *UserRepository) SetSortMode(id, sortMode string) error {
+	_, err := r.db.Exec("UPDATE users SET sort_mode = ?, updated_at = ? WHERE id = ?", sortMode, time.Now(), id)
+	return err
+}
+
+// GetDailyGoal returns the number of todos the user aims to complete each day, or 0
+// if the user doesn't exist
+func (r *UserRepository) GetDailyGoal(id string) (int, error) {
+	var dailyGoal sql.NullInt64
+	err := r.db.QueryRow("SELECT daily_goal FROM users WHERE id = ?", id).Scan(&dailyGoal)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return int(dailyGoal.Int64), err
+}
+
+// SetDailyGoal stores the number of todos the user aims to complete each day
+func (r *UserRepository) SetDailyGoal(id string, dailyGoal int) error {
+	_, err := r.db.Exec("UPDATE users SET daily_goal = ?, updated_at = ? WHERE id = ?", dailyGoal, time.Now(), id)
+	return err
+}
+
+// GetPreferences returns the user's stored preferences, keyed by preference_key
+func (r *UserRepository) GetPreferences(id string) (map[string]string, error) {
+	rows, err := r.db.Query("SELECT preference_key, preference_value FROM user_preferences WHERE user_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		preferences[key] = value
+	}
+	return preferences, rows.Err()
+}
+
+// SetPreferences stores preferences for the user in one transaction, replacing the
+// values of keys already set
+func (r *UserRepository) SetPreferences(id string, preferences map[string]string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for key, value := range preferences {
+		if _, err := tx.Exec(`
+			INSERT INTO user_preferences (user_id, preference_key, preference_value) VALUES (?, ?, ?)
+			ON CONFLICT(user_id, preference_key) DO UPDATE SET preference_value = excluded.preference_value
+		`, id, key, value); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDigestRecipients returns the users who have opted into the weekly digest email
+func (r *UserRepository) GetDigestRecipients() ([]models.User, error) {
+	rows, err := r.db.Query(`
+		SELECT id, supabase_id, email, password_hash, full_name, theme, COALESCE(timezone, 'UTC'), COALESCE(email_digest_enabled, 0), last_digest_sent_at, created_at, updated_at
+		FROM users WHERE email_digest_enabled = 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.SupabaseID, &user.Email, &user.PasswordHash, &user.FullName, &user.Theme, &user.Timezone,
+			&user.EmailDigestEnabled, &user.LastDigestSentAt, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// GetAutoPriorityUsers returns the users who have turned on auto-priority
+func (r *UserRepository) GetAutoPriorityUsers() ([]models.User, error) {
+	rows, err := r.db.Query(`
+		SELECT id, supabase_id, email, password_hash, full_name, theme, COALESCE(timezone, 'UTC'), COALESCE(email_digest_enabled, 0), last_digest_sent_at, created_at, updated_at
+		FROM users
+		WHERE id IN (SELECT user_id FROM user_preferences WHERE preference_key = ? AND preference_value = 'true')
+	`, models.PreferenceAutoPriority)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.SupabaseID, &user.Email, &user.PasswordHash, &user.FullName, &user.Theme, &user.Timezone,
+			&user.EmailDigestEnabled, &user.LastDigestSentAt, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// MarkDigestSent records when the user's weekly digest email was sent. It doesn't
+// touch updated_at, since the user didn't change anything.
+func (r *UserRepository) MarkDigestSent(id string, sentAt time.Time) error {
+	_, err := r.db.Exec("UPDATE users SET last_digest_sent_at = ? WHERE id = ?", sentAt, id)
+	return err
+}
+
+// DeleteWithAllData deletes a user and everything they own in a single transaction.
+// Rows are deleted explicitly, children before their parents, rather than relying on
+// ON DELETE CASCADE, so every table holding user data must be listed here.
+func (r *UserRepository) DeleteWithAllData(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		"DELETE FROM attachments WHERE memory_id IN (SELECT id FROM memories WHERE user_id = ?)",
+		"DELETE FROM memory_links WHERE memory_id_a IN (SELECT id FROM memories WHERE user_id = ?1) OR memory_id_b IN (SELECT id FROM memories WHERE user_id = ?1)",
+		"DELETE FROM memory_revisions WHERE memory_id IN (SELECT id FROM memories WHERE user_id = ?)",
+		"DELETE FROM share_tokens WHERE memory_id IN (SELECT id FROM memories WHERE user_id = ?)",
+		"DELETE FROM memories WHERE user_id = ?",
+		"DELETE FROM todo_dependencies WHERE blocker_id IN (SELECT id FROM todos WHERE user_id = ?1) OR blocked_id IN (SELECT id FROM todos WHERE user_id = ?1)",
+		"DELETE FROM todos WHERE user_id = ?",
+		"DELETE FROM groups WHERE user_id = ?",
+		"DELETE FROM memory_categories WHERE user_id = ?",
+		"DELETE FROM memory_digests WHERE user_id = ?",
+		"DELETE FROM chat_messages WHERE thread_id IN (SELECT id FROM chat_threads WHERE user_id = ?)",
+		"DELETE FROM chat_threads WHERE user_id = ?",
+		"DELETE FROM ai_provider_models WHERE provider_id IN (SELECT id FROM ai_providers WHERE user_id = ?)",
+		"DELETE FROM ai_providers WHERE user_id = ?",
+		"DELETE FROM prompt_templates WHERE user_id = ?",
+		"DELETE FROM audit_log WHERE user_id = ?",
+		"DELETE FROM search_history WHERE user_id = ?",
+		"DELETE FROM saved_searches WHERE user_id = ?",
+		"DELETE FROM todo_completions WHERE user_id = ?",
+		"DELETE FROM user_preferences WHERE user_id = ?",
+		"DELETE FROM rag_index_queue WHERE user_id = ?",
+		"DELETE FROM ip_allowlist WHERE user_id = ?",
+		"DELETE FROM todo_templates WHERE user_id = ?",
+		"DELETE FROM saved_rss_feeds WHERE user_id = ?",
+		"DELETE FROM sessions WHERE user_id = ?",
+		"DELETE FROM category_corrections WHERE user_id = ?",
+		"DELETE FROM inbound_webhook_sources WHERE user_id = ?",
+		"DELETE FROM keyword_blocklist WHERE user_id = ?",
+		"DELETE FROM users WHERE id = ?",
+	}
+
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt, userID); err != nil {
+			return fmt.Errorf("failed to delete user data: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
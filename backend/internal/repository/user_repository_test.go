@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/database"
+	"github.com/todomyday/backend/internal/models"
+)
+
+// seedValues overrides columns whose CHECK constraints a placeholder would fail
+var seedValues = map[string]interface{}{
+	"ai_providers.provider_type":     "openai",
+	"chat_messages.role":             "user",
+	"prompt_templates.template_name": "todo_processing",
+	"todos.priority":                 "medium",
+	"todos.status":                   "pending",
+}
+
+type seedColumn struct {
+	name, colType   string
+	notNull, hasDef bool
+}
+
+type seedForeignKey struct {
+	from, table string
+}
+
+// seedUserData inserts one row owned by userID into every table that holds user data:
+// tables with a user_id column, and tables referencing a row seeded for the user. It
+// returns the seeded tables.
+func seedUserData(t *testing.T, db *sql.DB, userID string) []string {
+	t.Helper()
+
+	rows, err := db.Query(`
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name NOT LIKE '%_fts%' AND name != 'users'
+	`)
+	if err != nil {
+		t.Fatalf("failed to list tables: %v", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	seededIDs := map[string]string{"users": userID}
+	var seeded []string
+	for progress := true; progress; {
+		progress = false
+		for _, table := range tables {
+			if _, done := seededIDs[table]; done {
+				continue
+			}
+			columns, foreignKeys := seedSchema(t, db, table)
+
+			ready, ownsData := true, false
+			values := map[string]interface{}{}
+			for _, fk := range foreignKeys {
+				parentID, ok := seededIDs[fk.table]
+				if !ok {
+					ready = false
+					break
+				}
+				values[fk.from] = parentID
+				ownsData = true
+			}
+			if !ready {
+				continue
+			}
+
+			names := make([]string, 0, len(columns))
+			args := make([]interface{}, 0, len(columns))
+			for _, col := range columns {
+				value, set := values[col.name]
+				switch {
+				case set:
+				case col.name == "user_id":
+					value, ownsData = userID, true
+				case seedValues[table+"."+col.name] != nil:
+					value = seedValues[table+"."+col.name]
+				case col.name == "id":
+					value = uuid.New().String()
+				case !col.notNull || col.hasDef:
+					continue
+				case strings.Contains(col.colType, "INT") || strings.Contains(col.colType, "BOOL") || strings.Contains(col.colType, "REAL"):
+					value = 0
+				case strings.Contains(col.colType, "DATE"):
+					value = time.Now()
+				default:
+					value = fmt.Sprintf("%s-%s-%s", table, col.name, uuid.New().String())
+				}
+				names = append(names, col.name)
+				args = append(args, value)
+			}
+			if !ownsData {
+				seededIDs[table] = ""
+				continue
+			}
+
+			query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(names, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", "))
+			if _, err := db.Exec(query, args...); err != nil {
+				t.Fatalf("failed to seed %s: %v", table, err)
+			}
+			for i, name := range names {
+				if name == "id" {
+					seededIDs[table] = fmt.Sprint(args[i])
+				}
+			}
+			if _, ok := seededIDs[table]; !ok {
+				seededIDs[table] = ""
+			}
+			seeded = append(seeded, table)
+			progress = true
+		}
+	}
+	return seeded
+}
+
+func seedSchema(t *testing.T, db *sql.DB, table string) ([]seedColumn, []seedForeignKey) {
+	t.Helper()
+
+	rows, err := db.Query("SELECT name, type, \"notnull\", dflt_value FROM pragma_table_info(?)", table)
+	if err != nil {
+		t.Fatalf("failed to read columns of %s: %v", table, err)
+	}
+	var columns []seedColumn
+	for rows.Next() {
+		var col seedColumn
+		var def sql.NullString
+		if err := rows.Scan(&col.name, &col.colType, &col.notNull, &def); err != nil {
+			t.Fatal(err)
+		}
+		col.colType = strings.ToUpper(col.colType)
+		col.hasDef = def.Valid
+		columns = append(columns, col)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`SELECT "from", "table" FROM pragma_foreign_key_list(?)`, table)
+	if err != nil {
+		t.Fatalf("failed to read foreign keys of %s: %v", table, err)
+	}
+	var foreignKeys []seedForeignKey
+	for rows.Next() {
+		var fk seedForeignKey
+		if err := rows.Scan(&fk.from, &fk.table); err != nil {
+			t.Fatal(err)
+		}
+		// Self references like groups.parent_id stay empty
+		if fk.table != table {
+			foreignKeys = append(foreignKeys, fk)
+		}
+	}
+	rows.Close()
+
+	return columns, foreignKeys
+}
+
+func TestDeleteWithAllDataRemovesEveryTable(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := database.Connect(dbPath, database.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Delete over a connection without foreign keys, so the test checks the statements
+	// rather than ON DELETE CASCADE
+	noForeignKeys, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer noForeignKeys.Close()
+
+	repo := NewUserRepository(db)
+	deleted := &models.User{Email: "deleted@example.com"}
+	kept := &models.User{Email: "kept@example.com"}
+	for _, user := range []*models.User{deleted, kept} {
+		if err := repo.Create(user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+
+	countRows := func(table string) int {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatalf("failed to count %s: %v", table, err)
+		}
+		return count
+	}
+
+	// Some tables start with built-in rows, like the default groups
+	builtIn := map[string]int{}
+	tables := seedUserData(t, db, deleted.ID)
+	for _, table := range tables {
+		builtIn[table] = countRows(table) - 1
+	}
+	seedUserData(t, db, kept.ID)
+
+	if err := NewUserRepository(noForeignKeys).DeleteWithAllData(deleted.ID); err != nil {
+		t.Fatalf("DeleteWithAllData: %v", err)
+	}
+
+	// Each table was seeded with one row per user, so only the kept user's is left
+	for _, table := range tables {
+		if count := countRows(table) - builtIn[table]; count != 1 {
+			t.Errorf("%s has %d user rows after deleting one of two users, want 1", table, count)
+		}
+	}
+
+	if user, err := repo.GetByID(deleted.ID); err != nil || user != nil {
+		t.Errorf("GetByID(deleted) = %v, %v; want nil, nil", user, err)
+	}
+	if user, err := repo.GetByID(kept.ID); err != nil || user == nil {
+		t.Errorf("GetByID(kept) = %v, %v; want the user", user, err)
+	}
+}
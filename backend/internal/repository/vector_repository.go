@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,10 +24,52 @@ type EmbeddingService interface {
 	IsConfigured() bool
 }
 
-// VectorRepository handles vector storage and similarity search using chromem-go
+// ErrEmbeddingDimensionMismatch is returned when a model's embeddings don't have the configured size
+var ErrEmbeddingDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// ErrUnsupportedContentType is returned when a document's content type has no collection
+var ErrUnsupportedContentType = errors.New("content type is not stored in the vector index")
+
+// Default collection names for each content type
+const (
+	TodosCollectionName    = "todos_collection"
+	MemoriesCollectionName = "memories_collection"
+)
+
+// indexedContentTypes lists the content types stored in the vector index, each in its own
+// collection and, when persisted, its own directory under the persist path
+var indexedContentTypes = []models.ContentType{models.ContentTypeTodo, models.ContentTypeMemory}
+
+// partitionDirs names each content type's directory under the persist path
+var partitionDirs = map[models.ContentType]string{
+	models.ContentTypeTodo:   "todos",
+	models.ContentTypeMemory: "memories",
+}
+
+// legacyCollectionName is the single shared collection used before documents were
+// split by content type. migrateLegacyDocuments moves its documents out on startup.
+const legacyCollectionName = "documents"
+
+// userCollectionPrefix names per-user collections as "user_<userID>_dim<dimension>"
+const userCollectionPrefix = "user_"
+
+// deleteBatchSize caps the number of document IDs passed to a single chromem Delete call
+const deleteBatchSize = 100
+
+// UserEmbedding is a user's own embedding model. Documents embedded with a dimension
+// other than the repository default are kept in separate per-user collections,
+// since vectors of different sizes can't be compared.
+type UserEmbedding struct {
+	Service   EmbeddingService
+	Dimension int
+}
+
+// VectorRepository handles vector storage and similarity search using chromem-go.
+// Each content type has its own database and collection, so user-scoped searches
+// only scan the types they ask for.
 type VectorRepository struct {
-	db              *chromem.DB
-	collection      *chromem.Collection
+	dbs             map[models.ContentType]*chromem.DB
+	collections     map[models.ContentType]*chromem.Collection
 	persistPath     string
 	embeddingFn     chromem.EmbeddingFunc
 	embeddingSvc    EmbeddingService
@@ -33,25 +77,50 @@ type VectorRepository struct {
 	dimension       int
 	lastIndexed     *time.Time
 	documentMap     map[string]*models.Document // In-memory cache for quick lookups
+	userCollections map[string]*userCollection  // Per-user collections for custom dimensions, keyed by user ID
+}
+
+// userCollection holds a user's custom-dimension collections, one per content type
+type userCollection struct {
+	collections map[models.ContentType]*chromem.Collection
+	dimension   int
 }
 
 // VectorConfig holds configuration for the vector repository
 type VectorConfig struct {
 	PersistPath string
 	Dimension   int
+	// CollectionNames overrides the collection name for a content type
+	CollectionNames map[models.ContentType]string
 }
 
-// NewVectorRepository creates a new vector repository with chromem-go
+// collectionName returns the configured collection name for a content type
+func (cfg VectorConfig) collectionName(contentType models.ContentType) string {
+	if name, ok := cfg.CollectionNames[contentType]; ok && name != "" {
+		return name
+	}
+	if contentType == models.ContentTypeTodo {
+		return TodosCollectionName
+	}
+	return MemoriesCollectionName
+}
+
+// NewVectorRepository creates a new vector repository with chromem-go. With a persist
+// path, each content type's collection is stored under <path>/<type> (e.g. vectors/todos)
+// and documents left in the pre-partitioning database at <path> are moved over.
 func NewVectorRepository(cfg VectorConfig, embeddingSvc EmbeddingService) (*VectorRepository, error) {
 	if cfg.Dimension <= 0 {
 		cfg.Dimension = models.DimensionDefault
 	}
 
 	repo := &VectorRepository{
-		persistPath:  cfg.PersistPath,
-		dimension:    cfg.Dimension,
-		documentMap:  make(map[string]*models.Document),
-		embeddingSvc: embeddingSvc,
+		dbs:             make(map[models.ContentType]*chromem.DB),
+		collections:     make(map[models.ContentType]*chromem.Collection),
+		persistPath:     cfg.PersistPath,
+		dimension:       cfg.Dimension,
+		documentMap:     make(map[string]*models.Document),
+		embeddingSvc:    embeddingSvc,
+		userCollections: make(map[string]*userCollection),
 	}
 
 	// Create the embedding function adapter for chromem-go (uses passage type for indexing)
@@ -59,94 +128,348 @@ func NewVectorRepository(cfg VectorConfig, embeddingSvc EmbeddingService) (*Vect
 		return embeddingSvc.EmbedPassage(ctx, text)
 	}
 
-	// Initialize chromem-go database
-	var db *chromem.DB
-	var err error
+	for _, contentType := range indexedContentTypes {
+		var db *chromem.DB
+		var err error
 
-	if cfg.PersistPath != "" {
-		// Ensure directory exists
-		dir := filepath.Dir(cfg.PersistPath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create vector db directory: %w", err)
+		if cfg.PersistPath != "" {
+			// Create persistent database (chromem creates the directory)
+			path := filepath.Join(cfg.PersistPath, partitionDirs[contentType])
+			db, err = chromem.NewPersistentDB(path, false)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create persistent vector db for %s: %w", contentType, err)
+			}
+			log.Printf("[VectorRepo] Created persistent vector database at: %s", path)
+		} else {
+			// Create in-memory database
+			db = chromem.NewDB()
 		}
+		repo.dbs[contentType] = db
 
-		// Create persistent database
-		db, err = chromem.NewPersistentDB(cfg.PersistPath, false)
+		// Get or create the content type's collection
+		collection, err := db.GetOrCreateCollection(cfg.collectionName(contentType), nil, repo.embeddingFn)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create persistent vector db: %w", err)
+			return nil, fmt.Errorf("failed to create %s collection: %w", contentType, err)
 		}
-		log.Printf("[VectorRepo] Created persistent vector database at: %s", cfg.PersistPath)
-	} else {
-		// Create in-memory database
-		db = chromem.NewDB()
+		repo.collections[contentType] = collection
+
+		// Pick up per-user collections persisted by earlier runs
+		for name, c := range db.ListCollections() {
+			if userID, dimension, ok := parseUserCollectionName(name); ok {
+				uc, ok := repo.userCollections[userID]
+				if !ok {
+					uc = &userCollection{collections: make(map[models.ContentType]*chromem.Collection), dimension: dimension}
+					repo.userCollections[userID] = uc
+				}
+				uc.collections[contentType] = c
+			}
+		}
+	}
+	if cfg.PersistPath == "" {
 		log.Printf("[VectorRepo] Created in-memory vector database")
 	}
 
-	repo.db = db
+	if cfg.PersistPath != "" {
+		if err := repo.migrateLegacyDocuments(context.Background(), cfg.PersistPath); err != nil {
+			return nil, fmt.Errorf("failed to migrate vector documents: %w", err)
+		}
+	}
+
+	log.Printf("[VectorRepo] Initialized with dimension=%d, todos=%d, memories=%d, user collections=%d",
+		cfg.Dimension, repo.collections[models.ContentTypeTodo].Count(), repo.collections[models.ContentTypeMemory].Count(), len(repo.userCollections))
 
-	// Get or create the main collection
-	collection, err := db.GetOrCreateCollection("documents", nil, repo.embeddingFn)
+	return repo, nil
+}
+
+// migrateLegacyDocuments moves documents from the single database used before
+// partitioning (the "documents" collection and per-user collections stored directly
+// under persistPath) into the per-content-type collections, then deletes the legacy
+// collections. Embeddings are copied as-is, so nothing is re-embedded. A collection
+// whose vectors don't match its expected dimension is left in place and logged.
+func (r *VectorRepository) migrateLegacyDocuments(ctx context.Context, persistPath string) error {
+	// The partition directories have no collection files of their own, so chromem skips them here
+	legacy, err := chromem.NewPersistentDB(persistPath, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create collection: %w", err)
+		return fmt.Errorf("failed to open legacy vector db: %w", err)
 	}
-	repo.collection = collection
 
-	log.Printf("[VectorRepo] Initialized with dimension=%d, collection count=%d", cfg.Dimension, collection.Count())
+	for name, collection := range legacy.ListCollections() {
+		userID := ""
+		dimension := r.dimension
+		if name != legacyCollectionName {
+			var ok bool
+			userID, dimension, ok = parseUserCollectionName(name)
+			if !ok {
+				continue
+			}
+		}
 
-	return repo, nil
+		count := collection.Count()
+		if count > 0 {
+			// chromem has no way to list documents, so read them all back with a query
+			// that matches everything
+			probe := make([]float32, dimension)
+			probe[0] = 1
+			results, err := collection.QueryEmbedding(ctx, probe, count, nil, nil)
+			if err != nil {
+				log.Printf("[VectorRepo] Skipping legacy collection %s, couldn't read documents: %v", name, err)
+				continue
+			}
+
+			byType := make(map[models.ContentType][]chromem.Document)
+			for _, result := range results {
+				contentType := models.ContentType(result.Metadata["content_type"])
+				if _, ok := r.collections[contentType]; !ok {
+					log.Printf("[VectorRepo] Dropping legacy document %s with unsupported content type %q", result.ID, contentType)
+					continue
+				}
+				byType[contentType] = append(byType[contentType], chromem.Document{
+					ID:        result.ID,
+					Metadata:  result.Metadata,
+					Embedding: result.Embedding,
+					Content:   result.Content,
+				})
+			}
+
+			for contentType, docs := range byType {
+				target := r.collections[contentType]
+				if userID != "" {
+					if target, err = r.collectionForUser(userID, dimension, contentType); err != nil {
+						return err
+					}
+				}
+				if err := target.AddDocuments(ctx, docs, runtime()); err != nil {
+					return fmt.Errorf("failed to move legacy documents to %s collection: %w", contentType, err)
+				}
+			}
+		}
+
+		if err := legacy.DeleteCollection(name); err != nil {
+			return fmt.Errorf("failed to delete legacy collection %s: %w", name, err)
+		}
+		log.Printf("[VectorRepo] Migrated %d documents from legacy collection %s", count, name)
+	}
+
+	return nil
+}
+
+// collectionFor returns the shared collection for a content type
+func (r *VectorRepository) collectionFor(contentType models.ContentType) (*chromem.Collection, error) {
+	collection, ok := r.collections[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+	return collection, nil
+}
+
+// requestedContentTypes maps content type filters to the indexed types they select.
+// No filter selects every type; unknown types are ignored.
+func requestedContentTypes(contentTypes []string) []models.ContentType {
+	if len(contentTypes) == 0 {
+		return indexedContentTypes
+	}
+	selected := make([]models.ContentType, 0, len(contentTypes))
+	for _, contentType := range indexedContentTypes {
+		for _, ct := range contentTypes {
+			if ct == string(contentType) {
+				selected = append(selected, contentType)
+				break
+			}
+		}
+	}
+	return selected
 }
 
-// Add adds a document to the vector store
+// Add adds a document to its content type's collection
 func (r *VectorRepository) Add(ctx context.Context, doc *models.Document) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	collection, err := r.collectionFor(doc.ContentType)
+	if err != nil {
+		return err
+	}
+
 	if doc.ID == "" {
 		doc.ID = uuid.New().String()
 	}
 	doc.CreatedAt = time.Now()
 	doc.UpdatedAt = time.Now()
 
-	// Prepare content for embedding
-	content := prepareContentForEmbedding(doc)
+	chromemDoc := newChromemDocument(doc)
 
-	// Build metadata map
-	metadata := make(map[string]string)
-	metadata["content_type"] = string(doc.ContentType)
-	metadata["content_id"] = doc.ContentID
-	metadata["user_id"] = doc.UserID
-	metadata["title"] = doc.Title
-	metadata["created_at"] = doc.CreatedAt.Format(time.RFC3339)
+	// Add to collection (chromem-go will generate the embedding using passage type)
+	err = collection.AddDocument(ctx, chromemDoc)
+	if err != nil {
+		return fmt.Errorf("failed to add document: %w", err)
+	}
 
-	// Add custom metadata
-	for k, v := range doc.Metadata {
-		metadata[k] = v
+	// Cache the document
+	r.documentMap[doc.ID] = doc
+
+	now := time.Now()
+	r.lastIndexed = &now
+
+	log.Printf("[VectorRepo] Added document: id=%s, type=%s, content_id=%s", doc.ID, doc.ContentType, doc.ContentID)
+	return nil
+}
+
+// AddForUser adds a document embedded with the user's own model. A nil embedding
+// falls back to Add with the global model. The embedding is generated before the
+// write lock is taken, so a slow embedding call doesn't hold up searches.
+func (r *VectorRepository) AddForUser(ctx context.Context, doc *models.Document, embedding *UserEmbedding) error {
+	if embedding == nil {
+		return r.Add(ctx, doc)
 	}
 
-	// Create chromem document
-	chromemDoc := chromem.Document{
-		ID:       doc.ID,
-		Content:  content,
-		Metadata: metadata,
+	vector, err := embedding.Service.EmbedPassage(ctx, prepareContentForEmbedding(doc))
+	if err != nil {
+		return fmt.Errorf("failed to generate embedding: %w", err)
 	}
+	return r.AddEmbedded(ctx, doc, vector, embedding.Dimension)
+}
 
-	// Add to collection (chromem-go will generate the embedding using passage type)
-	err := r.collection.AddDocument(ctx, chromemDoc)
+// AddEmbedded adds a document with a passage embedding generated by the caller. A
+// dimension of 0 means the vector came from the global model and belongs in the shared
+// collection; otherwise it goes to the user's collections for that dimension, as with
+// AddForUser.
+func (r *VectorRepository) AddEmbedded(ctx context.Context, doc *models.Document, vector []float32, dimension int) error {
+	if dimension > 0 && len(vector) != dimension {
+		return fmt.Errorf("%w: model returned %d dimensions, provider is configured for %d",
+			ErrEmbeddingDimensionMismatch, len(vector), dimension)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	collection, err := r.collectionFor(doc.ContentType)
 	if err != nil {
+		return err
+	}
+	if dimension > 0 {
+		if collection, err = r.collectionForUser(doc.UserID, dimension, doc.ContentType); err != nil {
+			return err
+		}
+	}
+
+	if doc.ID == "" {
+		doc.ID = uuid.New().String()
+	}
+	doc.CreatedAt = time.Now()
+	doc.UpdatedAt = time.Now()
+
+	chromemDoc := newChromemDocument(doc)
+	chromemDoc.Embedding = vector
+
+	if err := collection.AddDocument(ctx, chromemDoc); err != nil {
 		return fmt.Errorf("failed to add document: %w", err)
 	}
 
-	// Cache the document
 	r.documentMap[doc.ID] = doc
 
 	now := time.Now()
 	r.lastIndexed = &now
 
-	log.Printf("[VectorRepo] Added document: id=%s, type=%s, content_id=%s", doc.ID, doc.ContentType, doc.ContentID)
+	log.Printf("[VectorRepo] Added document with precomputed embedding: id=%s, type=%s, content_id=%s, dim=%d",
+		doc.ID, doc.ContentType, doc.ContentID, len(vector))
 	return nil
 }
 
-// AddBatch adds multiple documents to the vector store
+// MarkIndexed records now as the last time the index was brought up to date, even if
+// nothing new was added, and returns it
+func (r *VectorRepository) MarkIndexed() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.lastIndexed = &now
+	return now
+}
+
+// collectionForUser returns the collection holding a user's vectors of the given dimension
+// and content type. The shared collection is used for the default dimension; otherwise a
+// per-user collection is created. Per-user collections left over from a different dimension
+// can't be queried alongside the new vectors, so they are dropped and the user's documents
+// need re-indexing. Callers must hold the write lock.
+func (r *VectorRepository) collectionForUser(userID string, dimension int, contentType models.ContentType) (*chromem.Collection, error) {
+	existing, ok := r.userCollections[userID]
+	if ok && existing.dimension == dimension {
+		if collection, ok := existing.collections[contentType]; ok {
+			return collection, nil
+		}
+	} else if ok {
+		log.Printf("[VectorRepo] Embedding dimension changed for user=%s (%d -> %d), dropping stale collections",
+			userID, existing.dimension, dimension)
+		if err := r.deleteUserCollections(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	if dimension == r.dimension {
+		return r.collectionFor(contentType)
+	}
+
+	// Documents are always added with precomputed embeddings, so no embedding function is needed
+	collection, err := r.dbs[contentType].GetOrCreateCollection(userCollectionName(userID, dimension), nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user collection: %w", err)
+	}
+
+	uc, ok := r.userCollections[userID]
+	if !ok {
+		uc = &userCollection{collections: make(map[models.ContentType]*chromem.Collection), dimension: dimension}
+		r.userCollections[userID] = uc
+	}
+	uc.collections[contentType] = collection
+	log.Printf("[VectorRepo] Created %s collection for user=%s with dimension=%d", contentType, userID, dimension)
+	return collection, nil
+}
+
+// deleteUserCollections drops all of a user's custom-dimension collections. Callers must hold the write lock.
+func (r *VectorRepository) deleteUserCollections(userID string) error {
+	uc, ok := r.userCollections[userID]
+	if !ok {
+		return nil
+	}
+	for contentType := range uc.collections {
+		if err := r.dbs[contentType].DeleteCollection(userCollectionName(userID, uc.dimension)); err != nil {
+			return fmt.Errorf("failed to delete user collection: %w", err)
+		}
+	}
+	delete(r.userCollections, userID)
+	return nil
+}
+
+// collectionsForUser returns the shared collection for the content type plus the
+// user's custom-dimension collection, if any. Callers must hold a lock.
+func (r *VectorRepository) collectionsForUser(userID string, contentType models.ContentType) []*chromem.Collection {
+	var collections []*chromem.Collection
+	if collection, ok := r.collections[contentType]; ok {
+		collections = append(collections, collection)
+	}
+	if uc, ok := r.userCollections[userID]; ok {
+		if collection, ok := uc.collections[contentType]; ok {
+			collections = append(collections, collection)
+		}
+	}
+	return collections
+}
+
+// allCollections returns every shared and per-user collection. Callers must hold a lock.
+func (r *VectorRepository) allCollections() []*chromem.Collection {
+	var collections []*chromem.Collection
+	for _, contentType := range indexedContentTypes {
+		collections = append(collections, r.collections[contentType])
+	}
+	for _, uc := range r.userCollections {
+		for _, collection := range uc.collections {
+			collections = append(collections, collection)
+		}
+	}
+	return collections
+}
+
+// AddBatch adds multiple documents to the vector store, each to its content type's collection
 func (r *VectorRepository) AddBatch(ctx context.Context, docs []*models.Document) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -155,40 +478,28 @@ func (r *VectorRepository) AddBatch(ctx context.Context, docs []*models.Document
 		return nil
 	}
 
-	chromemDocs := make([]chromem.Document, len(docs))
+	byType := make(map[models.ContentType][]chromem.Document)
+	for _, doc := range docs {
+		if _, err := r.collectionFor(doc.ContentType); err != nil {
+			return err
+		}
 
-	for i, doc := range docs {
 		if doc.ID == "" {
 			doc.ID = uuid.New().String()
 		}
 		doc.CreatedAt = time.Now()
 		doc.UpdatedAt = time.Now()
 
-		content := prepareContentForEmbedding(doc)
-
-		metadata := make(map[string]string)
-		metadata["content_type"] = string(doc.ContentType)
-		metadata["content_id"] = doc.ContentID
-		metadata["user_id"] = doc.UserID
-		metadata["title"] = doc.Title
-		metadata["created_at"] = doc.CreatedAt.Format(time.RFC3339)
-
-		for k, v := range doc.Metadata {
-			metadata[k] = v
-		}
+		byType[doc.ContentType] = append(byType[doc.ContentType], newChromemDocument(doc))
+	}
 
-		chromemDocs[i] = chromem.Document{
-			ID:       doc.ID,
-			Content:  content,
-			Metadata: metadata,
+	for contentType, chromemDocs := range byType {
+		if err := r.collections[contentType].AddDocuments(ctx, chromemDocs, runtime()); err != nil {
+			return fmt.Errorf("failed to add documents batch: %w", err)
 		}
-
-		r.documentMap[doc.ID] = doc
 	}
-
-	err := r.collection.AddDocuments(ctx, chromemDocs, runtime())
-	if err != nil {
-		return fmt.Errorf("failed to add documents batch: %w", err)
+	for _, doc := range docs {
+		r.documentMap[doc.ID] = doc
 	}
 
 	now := time.Now()
@@ -198,22 +509,83 @@ func (r *VectorRepository) AddBatch(ctx context.Context, docs []*models.Document
 	return nil
 }
 
-// Search performs similarity search using query-optimized embedding
+// Search performs similarity search using query-optimized embedding. A content_type
+// filter limits the search to that type's collection; otherwise every type is searched.
 func (r *VectorRepository) Search(ctx context.Context, query string, limit int, filters map[string]string) ([]models.SearchResult, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	var contentTypes []string
+	if ct, ok := filters["content_type"]; ok {
+		contentTypes = []string{ct}
+	}
+
+	var collections []*chromem.Collection
+	for _, contentType := range requestedContentTypes(contentTypes) {
+		collections = append(collections, r.collections[contentType])
+	}
+
+	return r.searchCollections(ctx, collections, r.embeddingSvc, 0, query, limit, filters)
+}
+
+// searchCollections embeds the query once and runs a similarity search against each
+// collection, merging the results by score. When dimension is set, the query embedding
+// is checked against it before searching. Callers must hold the read lock.
+func (r *VectorRepository) searchCollections(ctx context.Context, collections []*chromem.Collection, embeddingSvc EmbeddingService, dimension int, query string, limit int, filters map[string]string) ([]models.SearchResult, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 
-	// Clamp limit to collection count to avoid chromem-go error
-	collectionCount := r.collection.Count()
-	if collectionCount == 0 {
+	// Skip embedding the query when there's nothing to search
+	nonEmpty := make([]*chromem.Collection, 0, len(collections))
+	for _, collection := range collections {
+		if collection.Count() > 0 {
+			nonEmpty = append(nonEmpty, collection)
+		}
+	}
+	if len(nonEmpty) == 0 {
 		return []models.SearchResult{}, nil
 	}
-	if limit > collectionCount {
-		limit = collectionCount
+
+	// Generate query embedding using query-optimized embedding type
+	queryEmbedding, err := embeddingSvc.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+	}
+	if dimension > 0 && len(queryEmbedding) != dimension {
+		return nil, fmt.Errorf("%w: model returned %d dimensions, provider is configured for %d",
+			ErrEmbeddingDimensionMismatch, len(queryEmbedding), dimension)
+	}
+
+	searchResults := []models.SearchResult{}
+	for _, collection := range nonEmpty {
+		results, err := r.queryCollection(ctx, collection, queryEmbedding, limit, filters)
+		if err != nil {
+			return nil, err
+		}
+		searchResults = append(searchResults, results...)
+	}
+
+	if len(nonEmpty) > 1 {
+		sort.Slice(searchResults, func(i, j int) bool {
+			return searchResults[i].Score > searchResults[j].Score
+		})
+		if len(searchResults) > limit {
+			searchResults = searchResults[:limit]
+		}
+	}
+
+	return searchResults, nil
+}
+
+// queryCollection finds the documents in one collection nearest to an embedding
+func (r *VectorRepository) queryCollection(ctx context.Context, collection *chromem.Collection, embedding []float32, limit int, filters map[string]string) ([]models.SearchResult, error) {
+	// Clamp limit to collection count to avoid chromem-go error
+	if limit > collection.Count() {
+		limit = collection.Count()
+	}
+	if limit <= 0 {
+		return []models.SearchResult{}, nil
 	}
 
 	// Build where filter for chromem-go
@@ -222,67 +594,101 @@ func (r *VectorRepository) Search(ctx context.Context, query string, limit int,
 		whereFilter = filters
 	}
 
-	// Generate query embedding using query-optimized embedding type
-	queryEmbedding, err := r.embeddingSvc.EmbedQuery(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
-	}
-
-	// Perform the query using pre-computed query embedding
-	results, err := r.collection.QueryEmbedding(ctx, queryEmbedding, limit, whereFilter, nil)
+	results, err := collection.QueryEmbedding(ctx, embedding, limit, whereFilter, nil)
 	if err != nil {
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
 
 	searchResults := make([]models.SearchResult, 0, len(results))
 	for _, result := range results {
-		doc := r.reconstructDocument(result)
 		searchResults = append(searchResults, models.SearchResult{
-			Document:  doc,
+			Document:  r.reconstructDocument(result),
 			Score:     float64(result.Similarity),
 			MatchType: "vector",
 		})
 	}
-
 	return searchResults, nil
 }
 
 // SearchByUser searches documents for a specific user
 func (r *VectorRepository) SearchByUser(ctx context.Context, userID, query string, limit int, contentTypes []string) ([]models.SearchResult, error) {
+	return r.SearchByUserWithEmbedding(ctx, userID, query, limit, contentTypes, nil)
+}
+
+// SearchByUserWithEmbedding searches a user's documents using their own embedding
+// model, or the global one when embedding is nil. Only the collections for the
+// requested content types are queried; no content types means all of them.
+func (r *VectorRepository) SearchByUserWithEmbedding(ctx context.Context, userID, query string, limit int, contentTypes []string, embedding *UserEmbedding) ([]models.SearchResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	embeddingSvc := r.embeddingSvc
+	dimension := 0
+	if embedding != nil {
+		embeddingSvc = embedding.Service
+		dimension = embedding.Dimension
+	}
+
+	var collections []*chromem.Collection
+	for _, contentType := range requestedContentTypes(contentTypes) {
+		if collection := r.userCollectionFor(userID, dimension, contentType); collection != nil {
+			collections = append(collections, collection)
+		}
+	}
+
 	filters := map[string]string{
 		"user_id": userID,
 	}
+	return r.searchCollections(ctx, collections, embeddingSvc, dimension, query, limit, filters)
+}
 
-	// Note: chromem-go doesn't support OR filters natively
-	// For multiple content types, we need to do multiple queries
-	if len(contentTypes) == 1 {
-		filters["content_type"] = contentTypes[0]
-		return r.Search(ctx, query, limit, filters)
+// userCollectionFor returns the collection holding the user's vectors of the given
+// content type and dimension (0 means the default), or nil when nothing has been
+// indexed at that dimension yet. Callers must hold a lock.
+func (r *VectorRepository) userCollectionFor(userID string, dimension int, contentType models.ContentType) *chromem.Collection {
+	if dimension <= 0 || dimension == r.dimension {
+		return r.collections[contentType]
+	}
+	uc, ok := r.userCollections[userID]
+	if !ok || uc.dimension != dimension {
+		return nil
 	}
+	return uc.collections[contentType]
+}
 
-	// For multiple content types, query each and merge
-	if len(contentTypes) > 1 {
-		var allResults []models.SearchResult
-		for _, ct := range contentTypes {
-			filters["content_type"] = ct
-			results, err := r.Search(ctx, query, limit, filters)
-			if err != nil {
-				return nil, err
-			}
-			allResults = append(allResults, results...)
+// SearchByUserVector finds a user's documents nearest to a precomputed embedding.
+// dimension selects the collection the same way as UserEmbedding; 0 means the default.
+// An empty contentType searches every type.
+func (r *VectorRepository) SearchByUserVector(ctx context.Context, userID string, vector []float32, dimension int, limit int, contentType models.ContentType) ([]models.SearchResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var contentTypes []string
+	if contentType != "" {
+		contentTypes = []string{string(contentType)}
+	}
+
+	filters := map[string]string{"user_id": userID}
+	searchResults := []models.SearchResult{}
+	for _, ct := range requestedContentTypes(contentTypes) {
+		collection := r.userCollectionFor(userID, dimension, ct)
+		if collection == nil {
+			continue
 		}
-		// Sort by score and limit
-		sort.Slice(allResults, func(i, j int) bool {
-			return allResults[i].Score > allResults[j].Score
-		})
-		if len(allResults) > limit {
-			allResults = allResults[:limit]
+		results, err := r.queryCollection(ctx, collection, vector, limit, filters)
+		if err != nil {
+			return nil, err
 		}
-		return allResults, nil
+		searchResults = append(searchResults, results...)
 	}
 
-	// No content type filter
-	return r.Search(ctx, query, limit, filters)
+	sort.Slice(searchResults, func(i, j int) bool {
+		return searchResults[i].Score > searchResults[j].Score
+	})
+	if len(searchResults) > limit {
+		searchResults = searchResults[:limit]
+	}
+	return searchResults, nil
 }
 
 // Delete removes a document by ID
@@ -290,9 +696,10 @@ func (r *VectorRepository) Delete(ctx context.Context, id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	err := r.collection.Delete(ctx, nil, nil, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete document: %w", err)
+	for _, collection := range r.allCollections() {
+		if err := collection.Delete(ctx, nil, nil, id); err != nil {
+			return fmt.Errorf("failed to delete document: %w", err)
+		}
 	}
 
 	delete(r.documentMap, id)
@@ -305,17 +712,29 @@ func (r *VectorRepository) DeleteByContentID(ctx context.Context, contentType mo
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	collection, err := r.collectionFor(contentType)
+	if err != nil {
+		return err
+	}
+
 	// Use chromem's WHERE metadata filter to delete directly from the collection
 	// This bypasses the need for documentMap, ensuring deletion works even if cache is empty
 	whereMetadata := map[string]string{
-		"content_type": string(contentType),
-		"content_id":   contentID,
+		"content_id": contentID,
 	}
 
-	// Delete from chromem collection using metadata filter
-	if err := r.collection.Delete(ctx, whereMetadata, nil); err != nil {
-		log.Printf("[VectorRepo] Error deleting documents with metadata filter: %v", err)
-		return err
+	// Delete from the type's shared collection and any per-user collections
+	collections := []*chromem.Collection{collection}
+	for _, uc := range r.userCollections {
+		if c, ok := uc.collections[contentType]; ok {
+			collections = append(collections, c)
+		}
+	}
+	for _, c := range collections {
+		if err := c.Delete(ctx, whereMetadata, nil); err != nil {
+			log.Printf("[VectorRepo] Error deleting documents with metadata filter: %v", err)
+			return err
+		}
 	}
 
 	// Also clean up documentMap cache (if entries exist)
@@ -338,16 +757,21 @@ func (r *VectorRepository) DeleteByUser(ctx context.Context, userID string, cont
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if _, err := r.collectionFor(contentType); err != nil {
+		return err
+	}
+
 	// Use chromem's WHERE metadata filter to delete
 	whereMetadata := map[string]string{
-		"user_id":      userID,
-		"content_type": string(contentType),
+		"user_id": userID,
 	}
 
-	// Delete from chromem collection
-	if err := r.collection.Delete(ctx, whereMetadata, nil); err != nil {
-		log.Printf("[VectorRepo] Error deleting user documents: %v", err)
-		return err
+	// Delete from the type's collections
+	for _, collection := range r.collectionsForUser(userID, contentType) {
+		if err := collection.Delete(ctx, whereMetadata, nil); err != nil {
+			log.Printf("[VectorRepo] Error deleting user documents: %v", err)
+			return err
+		}
 	}
 
 	// Clean up documentMap cache
@@ -365,6 +789,70 @@ func (r *VectorRepository) DeleteByUser(ctx context.Context, userID string, cont
 	return nil
 }
 
+// DeleteByUserAndFilter removes a user's documents whose content IDs are in contentIDs.
+// Cached documents are deleted by ID in batches; content IDs missing from the cache
+// (e.g. after a restart) fall back to a metadata filter delete.
+func (r *VectorRepository) DeleteByUserAndFilter(ctx context.Context, userID string, contentType models.ContentType, contentIDs []string) error {
+	wanted := make(map[string]bool, len(contentIDs))
+	for _, id := range contentIDs {
+		wanted[id] = true
+	}
+
+	r.mu.RLock()
+	var docIDs []string
+	found := make(map[string]bool)
+	for id, doc := range r.documentMap {
+		if doc.UserID == userID && doc.ContentType == contentType && wanted[doc.ContentID] {
+			docIDs = append(docIDs, id)
+			found[doc.ContentID] = true
+		}
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.collectionFor(contentType); err != nil {
+		return err
+	}
+	collections := r.collectionsForUser(userID, contentType)
+
+	for start := 0; start < len(docIDs); start += deleteBatchSize {
+		end := min(start+deleteBatchSize, len(docIDs))
+		batch := docIDs[start:end]
+		for _, collection := range collections {
+			if err := collection.Delete(ctx, nil, nil, batch...); err != nil {
+				log.Printf("[VectorRepo] Error deleting document batch: %v", err)
+				return err
+			}
+		}
+		for _, id := range batch {
+			delete(r.documentMap, id)
+		}
+	}
+
+	uncached := 0
+	for _, contentID := range contentIDs {
+		if found[contentID] {
+			continue
+		}
+		whereMetadata := map[string]string{
+			"user_id":    userID,
+			"content_id": contentID,
+		}
+		for _, collection := range collections {
+			if err := collection.Delete(ctx, whereMetadata, nil); err != nil {
+				log.Printf("[VectorRepo] Error deleting documents with metadata filter: %v", err)
+				return err
+			}
+		}
+		uncached++
+	}
+
+	log.Printf("[VectorRepo] Bulk deleted documents for user=%s type=%s (cached: %d, uncached: %d)", userID, contentType, len(docIDs), uncached)
+	return nil
+}
+
 // DeleteAllByUser removes ALL documents for a user (all content types)
 func (r *VectorRepository) DeleteAllByUser(ctx context.Context, userID string) error {
 	r.mu.Lock()
@@ -374,8 +862,14 @@ func (r *VectorRepository) DeleteAllByUser(ctx context.Context, userID string) e
 		"user_id": userID,
 	}
 
-	if err := r.collection.Delete(ctx, whereMetadata, nil); err != nil {
-		log.Printf("[VectorRepo] Error deleting all user documents: %v", err)
+	for _, contentType := range indexedContentTypes {
+		if err := r.collections[contentType].Delete(ctx, whereMetadata, nil); err != nil {
+			log.Printf("[VectorRepo] Error deleting all user documents: %v", err)
+			return err
+		}
+	}
+	if err := r.deleteUserCollections(userID); err != nil {
+		log.Printf("[VectorRepo] Error deleting user collection: %v", err)
 		return err
 	}
 
@@ -407,28 +901,58 @@ func (r *VectorRepository) GetByContentID(contentType models.ContentType, conten
 	return nil
 }
 
-// Count returns the number of documents in the collection
+// Count returns the number of documents across all collections
 func (r *VectorRepository) Count() int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	return r.collection.Count()
+	return r.countAll()
+}
+
+// countAll counts documents across the shared and per-user collections. Callers must hold the read lock.
+func (r *VectorRepository) countAll() int {
+	count := 0
+	for _, collection := range r.allCollections() {
+		count += collection.Count()
+	}
+	return count
+}
+
+// countByContentType counts documents in each content type's shared and per-user
+// collections. Callers must hold the read lock.
+func (r *VectorRepository) countByContentType() map[string]int {
+	counts := make(map[string]int, len(indexedContentTypes))
+	for _, contentType := range indexedContentTypes {
+		counts[string(contentType)] = r.collections[contentType].Count()
+	}
+	for _, uc := range r.userCollections {
+		for contentType, collection := range uc.collections {
+			counts[string(contentType)] += collection.Count()
+		}
+	}
+	return counts
 }
 
-// GetStats returns statistics about the vector index
+// GetStats returns statistics about the vector index. Without a user, the per-type
+// counts come from each collection; for a user they come from the document cache.
 func (r *VectorRepository) GetStats(userID string) *models.IndexStats {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	stats := &models.IndexStats{
-		TotalDocuments: r.collection.Count(),
+		TotalDocuments: r.countAll(),
 		ByContentType:  make(map[string]int),
 		ByUser:         make(map[string]int),
 		LastIndexedAt:  r.lastIndexed,
 	}
+	if userID == "" {
+		stats.ByContentType = r.countByContentType()
+	}
 
 	for _, doc := range r.documentMap {
 		if userID == "" || doc.UserID == userID {
-			stats.ByContentType[string(doc.ContentType)]++
+			if userID != "" {
+				stats.ByContentType[string(doc.ContentType)]++
+			}
 			stats.ByUser[doc.UserID]++
 		}
 	}
@@ -445,6 +969,47 @@ func (r *VectorRepository) Close() error {
 
 // Helper functions
 
+// newChromemDocument converts a document to chromem's format with its metadata flattened
+func newChromemDocument(doc *models.Document) chromem.Document {
+	metadata := make(map[string]string)
+	metadata["content_type"] = string(doc.ContentType)
+	metadata["content_id"] = doc.ContentID
+	metadata["user_id"] = doc.UserID
+	metadata["title"] = doc.Title
+	metadata["created_at"] = doc.CreatedAt.Format(time.RFC3339)
+
+	// Add custom metadata
+	for k, v := range doc.Metadata {
+		metadata[k] = v
+	}
+
+	return chromem.Document{
+		ID:       doc.ID,
+		Content:  prepareContentForEmbedding(doc),
+		Metadata: metadata,
+	}
+}
+
+func userCollectionName(userID string, dimension int) string {
+	return fmt.Sprintf("%s%s_dim%d", userCollectionPrefix, userID, dimension)
+}
+
+// parseUserCollectionName reverses userCollectionName
+func parseUserCollectionName(name string) (string, int, bool) {
+	if !strings.HasPrefix(name, userCollectionPrefix) {
+		return "", 0, false
+	}
+	idx := strings.LastIndex(name, "_dim")
+	if idx <= len(userCollectionPrefix) {
+		return "", 0, false
+	}
+	dimension, err := strconv.Atoi(name[idx+len("_dim"):])
+	if err != nil || dimension <= 0 {
+		return "", 0, false
+	}
+	return name[len(userCollectionPrefix):idx], dimension, true
+}
+
 func prepareContentForEmbedding(doc *models.Document) string {
 	var parts []string
 
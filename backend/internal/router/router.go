@@ -1,9 +1,12 @@
 package router
 
 import (
-	"github.com/gin-contrib/cors"
+	"log"
+
 	"github.com/gin-gonic/gin"
+	"github.com/todomyday/backend/internal/graph"
 	"github.com/todomyday/backend/internal/handlers"
+	"github.com/todomyday/backend/internal/metrics"
 	"github.com/todomyday/backend/internal/middleware"
 	"github.com/todomyday/backend/internal/repository"
 	"github.com/todomyday/backend/internal/services"
@@ -22,32 +25,91 @@ func Setup(
 	uploadJobService *services.UploadJobService,
 	visionService *services.VisionService,
 	chatService *services.ChatService,
-	allowedOrigins []string,
+	scraperService *services.ScraperService,
+	promptTemplateService *services.PromptTemplateService,
+	auditService *services.AuditService,
+	searchService *services.SearchService,
+	searchHistoryService *services.SearchHistoryService,
+	ipAllowlistService *services.IPAllowlistService,
+	blocklistService *services.BlocklistService,
+	attachmentService *services.AttachmentService,
+	todoTemplateService *services.TodoTemplateService,
+	rssFeedService *services.RSSFeedService,
+	systemSettingsService *services.SystemSettingsService,
+	backupService *services.BackupService,
+	userPreferencesService *services.UserPreferencesService,
+	sessionService *services.SessionService,
+	healthService *services.HealthService,
+	shareService *services.ShareService,
+	authService *services.AuthService,
+	oidcService *services.OIDCService,
+	impersonationService *services.ImpersonationService,
+	jobScheduler *services.JobScheduler,
+	webhookIngestionService *services.WebhookIngestionService,
+	dailyBriefingService *services.DailyBriefingService,
+	corsMiddleware *middleware.DynamicCORS,
+	trustedProxies []string,
+	adminSecret string,
 ) *gin.Engine {
 	r := gin.Default()
 
-	// Configure CORS
-	corsConfig := cors.Config{
-		AllowOrigins:     allowedOrigins,
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
+	// Only the configured proxies may set the client IP through X-Forwarded-For,
+	// otherwise any caller could pick the IP the allowlist and rate limits see
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		log.Printf("[Router] Invalid trusted proxies, trusting none: %v", err)
+		r.SetTrustedProxies(nil)
 	}
-	r.Use(cors.New(corsConfig))
 
-	// Health check
-	r.GET("/health", handlers.HealthCheck)
+	// CORS origins can be changed at runtime through the admin API
+	r.Use(corsMiddleware.Handler())
+	r.Use(middleware.TracingMiddleware())
+	// Handlers and middleware record errors with c.Error; this sends them as APIErrors
+	r.Use(middleware.ErrorHandler())
+
+	// Health and readiness checks
+	healthHandler := handlers.NewHealthHandler(healthService)
+	r.GET("/health", healthHandler.Health)
+	r.GET("/ready", healthHandler.Ready)
 
 	// Create handlers
-	authHandler := handlers.NewAuthHandler(userRepo)
+	authHandler := handlers.NewAuthHandler(userRepo, sessionService, oidcService)
 	todoHandler := handlers.NewTodoHandler(todoService)
-	groupHandler := handlers.NewGroupHandler(groupService)
+	groupHandler := handlers.NewGroupHandler(groupService, todoService)
 	aiProviderHandler := handlers.NewAIProviderHandler(aiProviderService)
-	memoryHandler := handlers.NewMemoryHandler(memoryService, fileParserService, uploadJobService, visionService)
+	memoryHandler := handlers.NewMemoryHandler(memoryService, fileParserService, uploadJobService, visionService, attachmentService)
 	ragHandler := handlers.NewRAGHandler(ragService)
 	userDataHandler := handlers.NewUserDataHandler(userDataService)
 	chatHandler := handlers.NewChatHandler(chatService)
+	promptTemplateHandler := handlers.NewPromptTemplateHandler(promptTemplateService)
+	todoTemplateHandler := handlers.NewTodoTemplateHandler(todoTemplateService)
+	rssFeedHandler := handlers.NewRSSFeedHandler(rssFeedService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	scraperHandler := handlers.NewScraperHandler(scraperService)
+	searchHandler := handlers.NewSearchHandler(searchService, searchHistoryService, ragService)
+	ipAllowlistHandler := handlers.NewIPAllowlistHandler(ipAllowlistService)
+	blocklistHandler := handlers.NewBlocklistHandler(blocklistService)
+	userPreferencesHandler := handlers.NewUserPreferencesHandler(userPreferencesService)
+	shareHandler := handlers.NewShareHandler(shareService)
+	webhookHandler := handlers.NewWebhookHandler(webhookIngestionService)
+	dailyBriefingHandler := handlers.NewDailyBriefingHandler(dailyBriefingService)
+	adminHandler := handlers.NewAdminHandler(aiProviderService, systemSettingsService, searchService, backupService, jobScheduler, impersonationService, corsMiddleware)
+	graphQLHandler := handlers.NewGraphQLHandler(graph.NewResolver(todoService, memoryService, ragService, groupService))
+
+	// User auth for the protected routes and the GraphQL API
+	userAuth := []gin.HandlerFunc{
+		middleware.AuthMiddleware(supabaseAuthService, authService, sessionService),
+		middleware.IPAllowlistMiddleware(ipAllowlistService),
+		middleware.ImpersonationMiddleware(impersonationService),
+	}
+	adminAuth := []gin.HandlerFunc{
+		middleware.RejectImpersonationMiddleware(impersonationService),
+		middleware.AdminSecretMiddleware(adminSecret),
+	}
+
+	// GraphQL API for integrations, over the same services as the REST API. The
+	// playground is for admins only.
+	r.POST(handlers.GraphQLEndpoint, append(userAuth, graphQLHandler.Query)...)
+	r.GET(handlers.GraphQLEndpoint+"/playground", append(adminAuth, graphQLHandler.Playground)...)
 
 	// API routes
 	api := r.Group("/api")
@@ -57,29 +119,90 @@ func Setup(
 		{
 			// Register and Login are now handled by Supabase on the frontend
 			auth.POST("/logout", authHandler.Logout)
+
+			// OIDC single sign-on, issuing app tokens
+			auth.GET("/oidc/providers", authHandler.OIDCProviders)
+			auth.GET("/oidc/initiate", authHandler.OIDCInitiate)
+			auth.GET("/oidc/callback", authHandler.OIDCCallback)
+		}
+
+		// Scraper health (public, for ops dashboards)
+		api.GET("/scraper/health", scraperHandler.Health)
+
+		// Shared memories (public, the token is the credential)
+		api.GET("/shared/:token", shareHandler.GetShared)
+
+		// Inbound webhooks (public, signed with the source's secret)
+		api.POST("/webhooks/inbound/:source", middleware.ValidateWebhookSignature(webhookIngestionService), webhookHandler.ReceiveInbound)
+
+		// Admin routes (ADMIN_SECRET header, not user auth). Impersonation tokens are
+		// refused even alongside the secret.
+		admin := api.Group("/admin")
+		admin.Use(adminAuth...)
+		{
+			admin.POST("/rotate-encryption-key", adminHandler.RotateEncryptionKey)
+			admin.GET("/settings/allowed-origins", adminHandler.GetAllowedOrigins)
+			admin.PUT("/settings/allowed-origins", adminHandler.UpdateAllowedOrigins)
+			admin.GET("/settings/embedding-ensemble", adminHandler.GetEmbeddingEnsemble)
+			admin.PUT("/settings/embedding-ensemble", adminHandler.UpdateEmbeddingEnsemble)
+			admin.GET("/fts/health", adminHandler.GetFTSHealth)
+			admin.GET("/db/pragmas", adminHandler.GetDatabasePragmas)
+			admin.GET("/jobs", adminHandler.GetJobs)
+			admin.PUT("/jobs/:name", adminHandler.UpdateJob)
+			admin.GET("/backup", adminHandler.Backup)
+			admin.POST("/restore", adminHandler.Restore)
+			admin.POST("/impersonate", adminHandler.Impersonate)
+			admin.GET("/impersonation-log", adminHandler.GetImpersonationLog)
+			admin.GET("/webhook-sources", webhookHandler.GetSources)
+			admin.POST("/webhook-sources", webhookHandler.CreateSource)
+			admin.PUT("/webhook-sources/:id", webhookHandler.UpdateSource)
+			admin.DELETE("/webhook-sources/:id", webhookHandler.DeleteSource)
 		}
 
 		// Protected routes
 		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware(supabaseAuthService))
+		protected.Use(userAuth...)
 		{
 			// Auth - get current user
 			protected.GET("/auth/me", authHandler.Me)
+			protected.PATCH("/auth/me", authHandler.UpdateMe)
+			protected.GET("/auth/sessions", authHandler.GetSessions)
+			protected.DELETE("/auth/sessions", authHandler.RevokeOtherSessions)
+			protected.DELETE("/auth/sessions/:id", authHandler.RevokeSession)
+			protected.DELETE("/auth/account", userDataHandler.DeleteAccount)
 
 			// Todos
 			protected.GET("/todos", todoHandler.GetAll)
 			protected.POST("/todos", todoHandler.Create)
+			protected.GET("/todos/streak", todoHandler.GetStreak)
+			protected.GET("/todos/streak/calendar", todoHandler.GetStreakCalendar)
 			protected.GET("/todos/:id", todoHandler.GetByID)
 			protected.PUT("/todos/:id", todoHandler.Update)
+			protected.PUT("/todos/:id/estimate", todoHandler.SetEstimate)
+			protected.POST("/todos/:id/reindex", ragHandler.ReindexTodo)
+			protected.GET("/todos/:id/blockers", todoHandler.GetBlockers)
+			protected.GET("/todos/:id/blocking", todoHandler.GetBlocking)
+			protected.POST("/todos/:id/dependencies", todoHandler.AddDependency)
+			protected.DELETE("/todos/:id/dependencies/:blockerID", todoHandler.RemoveDependency)
 			protected.DELETE("/todos/:id", todoHandler.Delete)
 			protected.PUT("/todos/reorder", todoHandler.Reorder)
+			protected.POST("/todos/reset-positions", todoHandler.ResetPositions)
+			protected.PUT("/todos/bulk/priority", todoHandler.BulkUpdatePriority)
+			protected.POST("/todos/sync-tags", todoHandler.SyncTags)
 
 			// Groups
 			protected.GET("/groups", groupHandler.GetAll)
 			protected.POST("/groups", groupHandler.Create)
 			protected.GET("/groups/:id", groupHandler.GetByID)
+			protected.GET("/groups/:id/stats", groupHandler.GetStats)
+			protected.GET("/groups/:id/children", groupHandler.GetChildren)
+			protected.GET("/groups/:id/color-preview", groupHandler.GetColorPreview)
+			protected.GET("/groups/:id/todos", groupHandler.GetTodos)
+			protected.PUT("/groups/:id/todos/reorder", groupHandler.ReorderTodos)
 			protected.PUT("/groups/:id", groupHandler.Update)
 			protected.DELETE("/groups/:id", groupHandler.Delete)
+			protected.PUT("/groups/:id/archive", groupHandler.Archive)
+			protected.PUT("/groups/:id/unarchive", groupHandler.Unarchive)
 
 			// AI Providers
 			protected.GET("/ai-providers", aiProviderHandler.GetAll)
@@ -94,42 +217,131 @@ func Setup(
 			// Memories
 			protected.GET("/memories", memoryHandler.GetAll)
 			protected.POST("/memories", memoryHandler.Create)
+			protected.POST("/memories/batch", memoryHandler.CreateBatch)
 			protected.POST("/memories/upload", memoryHandler.UploadMemoryFile)
 			protected.POST("/memories/upload-image", memoryHandler.UploadImage)
+			protected.POST("/memories/import/vault", memoryHandler.ImportVault)
+			protected.POST("/memories/import/rss", rssFeedHandler.Import)
+			protected.GET("/memories/rss-feeds", rssFeedHandler.GetAll)
+			protected.DELETE("/memories/rss-feeds/:id", rssFeedHandler.Delete)
 			protected.GET("/memories/upload/jobs/:job_id", memoryHandler.GetUploadJobStatus)
 			protected.GET("/memories/categories", memoryHandler.GetCategories)
 			protected.GET("/memories/category/:category", memoryHandler.GetByCategory)
 			protected.GET("/memories/stats", memoryHandler.GetStats)
+			protected.GET("/memories/keywords", memoryHandler.GetKeywords)
 			protected.POST("/memories/search", memoryHandler.Search)
 			protected.PUT("/memories/reorder", memoryHandler.Reorder)
+			protected.POST("/memories/reset-positions", memoryHandler.ResetPositions)
 			protected.GET("/memories/digest", memoryHandler.GetDigest)
 			protected.POST("/memories/digest/generate", memoryHandler.GenerateDigest)
 			protected.POST("/memories/web-search", memoryHandler.WebSearch)
+			protected.DELETE("/memories/bulk", memoryHandler.BulkDelete)
+			protected.POST("/memories/bulk/archive", memoryHandler.BulkArchive)
+			protected.POST("/memories/bulk/unarchive", memoryHandler.BulkUnarchive)
 			protected.GET("/memories/:id", memoryHandler.GetByID)
 			protected.PUT("/memories/:id", memoryHandler.Update)
 			protected.DELETE("/memories/:id", memoryHandler.Delete)
 			protected.POST("/memories/:id/to-todo", memoryHandler.ConvertToTodo)
+			protected.POST("/memories/:id/clone", memoryHandler.Clone)
+			protected.GET("/memories/:id/related", memoryHandler.GetRelated)
+			protected.GET("/memories/:id/preview", memoryHandler.GetPreview)
+			protected.POST("/memories/:id/pin", memoryHandler.Pin)
+			protected.POST("/memories/:id/refresh-url", memoryHandler.RefreshURL)
+			protected.POST("/memories/:id/generate-title", memoryHandler.GenerateTitle)
+			protected.POST("/memories/:id/reindex", ragHandler.ReindexMemory)
+			protected.GET("/memories/:id/revisions", memoryHandler.GetRevisions)
+			protected.POST("/memories/:id/share", shareHandler.Create)
+			protected.DELETE("/memories/:id/share", shareHandler.Revoke)
+			protected.POST("/memories/:id/revisions/:revisionID/restore", memoryHandler.RestoreRevision)
+			protected.DELETE("/memories/:id/pin", memoryHandler.Unpin)
+			protected.GET("/memories/:id/attachments/:attachmentID", memoryHandler.GetAttachment)
 
 			// RAG - Search & Q&A
 			protected.POST("/rag/search", ragHandler.Search)
 			protected.POST("/rag/ask", ragHandler.Ask)
 			protected.POST("/rag/index", ragHandler.IndexAll)
 			protected.GET("/rag/stats", ragHandler.GetStats)
+			protected.GET("/rag/queue", ragHandler.GetQueue)
+
+			// Search autocomplete
+			protected.GET("/search/suggest", searchHandler.Suggest)
+
+			// Search history & saved searches
+			protected.GET("/search/history", searchHandler.GetHistory)
+			protected.DELETE("/search/history", searchHandler.ClearHistory)
+			protected.GET("/search/saved", searchHandler.GetSaved)
+			protected.POST("/search/saved", searchHandler.CreateSaved)
+			protected.DELETE("/search/saved/:id", searchHandler.DeleteSaved)
+			protected.POST("/search/saved/:id/run", searchHandler.RunSaved)
 
 			// User Data Management
 			protected.GET("/user/data/stats", userDataHandler.GetDataStats)
 			protected.POST("/user/data/clear-memories", userDataHandler.ClearMemories)
 			protected.POST("/user/data/clear-all", userDataHandler.ClearAllData)
 
+			// JSON export / import of the full dataset
+			protected.GET("/export/json", userDataHandler.ExportJSON)
+			protected.GET("/export/memories.csv", memoryHandler.ExportCSV)
+			protected.POST("/import/json", userDataHandler.ImportJSON)
+
 			// Chat Threads
 			protected.GET("/chat/threads/active", chatHandler.GetActiveThread)
 			protected.GET("/chat/threads", chatHandler.GetAllThreads)
 			protected.GET("/chat/threads/:id", chatHandler.GetThread)
 			protected.POST("/chat/threads", chatHandler.CreateThread)
 			protected.POST("/chat/threads/:id/messages", chatHandler.AddMessage)
+			protected.POST("/chat/threads/:id/ask", chatHandler.Ask)
+			protected.POST("/chat/threads/:id/ask/stream", chatHandler.AskStream)
+			protected.PATCH("/chat/threads/:id/title", chatHandler.UpdateThreadTitle)
 			protected.DELETE("/chat/threads/:id", chatHandler.DeleteThread)
+
+			// Prompt Templates
+			protected.GET("/prompt-templates", promptTemplateHandler.GetAll)
+			protected.POST("/prompt-templates", promptTemplateHandler.Create)
+			protected.GET("/prompt-templates/:id", promptTemplateHandler.GetByID)
+			protected.PUT("/prompt-templates/:id", promptTemplateHandler.Update)
+			protected.DELETE("/prompt-templates/:id", promptTemplateHandler.Delete)
+
+			// Todo Templates
+			protected.GET("/todo-templates", todoTemplateHandler.GetAll)
+			protected.POST("/todo-templates", todoTemplateHandler.Create)
+			protected.GET("/todo-templates/:id", todoTemplateHandler.GetByID)
+			protected.PUT("/todo-templates/:id", todoTemplateHandler.Update)
+			protected.DELETE("/todo-templates/:id", todoTemplateHandler.Delete)
+			protected.POST("/todo-templates/:id/apply", todoTemplateHandler.Apply)
+
+			// Daily Briefing
+			protected.GET("/daily-briefing", dailyBriefingHandler.Get)
+
+			// Audit Log
+			protected.GET("/audit-log", auditHandler.GetAll)
+
+			// IP Allowlist
+			protected.GET("/settings/ip-allowlist", ipAllowlistHandler.GetAll)
+			protected.POST("/settings/ip-allowlist", ipAllowlistHandler.Create)
+			protected.PUT("/settings/ip-allowlist/:id", ipAllowlistHandler.Update)
+			protected.DELETE("/settings/ip-allowlist/:id", ipAllowlistHandler.Delete)
+			protected.GET("/settings/blocklist", blocklistHandler.GetAll)
+			protected.POST("/settings/blocklist", blocklistHandler.Create)
+			protected.PUT("/settings/blocklist/:id", blocklistHandler.Update)
+			protected.DELETE("/settings/blocklist/:id", blocklistHandler.Delete)
+			protected.GET("/settings/memory-sort", userPreferencesHandler.GetMemorySort)
+			protected.PUT("/settings/memory-sort", userPreferencesHandler.UpdateMemorySort)
+			protected.GET("/settings/daily-goal", userPreferencesHandler.GetDailyGoal)
+			protected.PUT("/settings/daily-goal", userPreferencesHandler.UpdateDailyGoal)
+			protected.GET("/settings/preferences", userPreferencesHandler.GetPreferences)
+			protected.PUT("/settings/preferences", userPreferencesHandler.UpdatePreferences)
 		}
 	}
 
 	return r
 }
+
+// SetupMetrics builds the engine for the Prometheus endpoint. It runs on its own
+// port without auth, so that port should only be reachable from the scraper.
+func SetupMetrics() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	return r
+}
@@ -2,12 +2,19 @@ package services
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/todomyday/backend/internal/crypto"
@@ -15,19 +22,55 @@ import (
 	"github.com/todomyday/backend/internal/repository"
 )
 
+var (
+	ErrInvalidEmbeddingDimension = errors.New("embedding_dimension must be a positive integer no greater than 4096 and is required with embedding_model")
+	ErrEmbeddingNotSupported     = errors.New("embedding models are only supported for OpenAI-compatible providers")
+	ErrKeyRotationFailed         = errors.New("some API keys could not be re-encrypted; no keys were changed")
+	ErrInvalidFallbackProvider   = errors.New("fallback_provider_id must be another of your AI providers")
+	ErrSystemPromptTooLong       = fmt.Errorf("system_prompt may be at most %d characters", models.MaxSystemPromptLength)
+	ErrAIProviderNotFound        = newCodedError(models.ErrCodeNotFound, "provider not found")
+)
+
 type AIProviderService struct {
-	repo      *repository.AIProviderRepository
-	encryptor *crypto.Encryptor
+	repo *repository.AIProviderRepository
+	// keyMu guards encryptor. Create and Update hold it for reading until the key is
+	// stored, so a rotation can't miss a key written with the old encryptor.
+	keyMu        sync.RWMutex
+	encryptor    *crypto.Encryptor
+	auditService *AuditService
+	piiScrubber  *PIIScrubber
 }
 
-func NewAIProviderService(repo *repository.AIProviderRepository, encryptor *crypto.Encryptor) *AIProviderService {
+func NewAIProviderService(repo *repository.AIProviderRepository, encryptor *crypto.Encryptor, auditService *AuditService) *AIProviderService {
 	return &AIProviderService{
-		repo:      repo,
-		encryptor: encryptor,
+		repo:         repo,
+		encryptor:    encryptor,
+		auditService: auditService,
 	}
 }
 
-func (s *AIProviderService) Create(userID string, input *models.AIProviderCreate) (*models.AIProvider, error) {
+// SetPIIScrubber makes todo and memory processing with users' providers scrub
+// personal data first
+func (s *AIProviderService) SetPIIScrubber(scrubber *PIIScrubber) {
+	s.piiScrubber = scrubber
+}
+
+func (s *AIProviderService) Create(userID string, input *models.AIProviderCreate, ipAddress string) (*models.AIProvider, error) {
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+
+	var embeddingModel *string
+	if input.EmbeddingModel != nil && *input.EmbeddingModel != "" {
+		embeddingModel = input.EmbeddingModel
+	}
+	if err := validateEmbeddingConfig(input.ProviderType, embeddingModel, input.EmbeddingDimension); err != nil {
+		return nil, err
+	}
+	systemPrompt := strings.TrimSpace(input.SystemPrompt)
+	if utf8.RuneCountInString(systemPrompt) > models.MaxSystemPromptLength {
+		return nil, ErrSystemPromptTooLong
+	}
+
 	// Encrypt the API key
 	encryptedKey, err := s.encryptor.Encrypt(input.APIKey)
 	if err != nil {
@@ -41,20 +84,46 @@ func (s *AIProviderService) Create(userID string, input *models.AIProviderCreate
 		}
 	}
 
+	rpm := input.RequestsPerMinute
+	if rpm <= 0 {
+		rpm = models.DefaultRequestsPerMinute
+	}
+
+	timeoutSeconds := input.TimeoutSeconds
+	if timeoutSeconds <= 0 {
+		timeoutSeconds = models.DefaultTimeoutSeconds
+	}
+
 	provider := &models.AIProvider{
-		ID:              uuid.New().String(),
-		UserID:          userID,
-		Name:            input.Name,
-		ProviderType:    input.ProviderType,
-		BaseURL:         input.BaseURL,
-		APIKeyEncrypted: encryptedKey,
-		IsDefault:       input.IsDefault,
-		IsEnabled:       true,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
-	}
-
-	if err := s.repo.Create(provider); err != nil {
+		ID:                       uuid.New().String(),
+		UserID:                   userID,
+		Name:                     input.Name,
+		ProviderType:             input.ProviderType,
+		BaseURL:                  input.BaseURL,
+		APIKeyEncrypted:          encryptedKey,
+		IsDefault:                input.IsDefault,
+		IsEnabled:                true,
+		RequestsPerMinute:        rpm,
+		TimeoutSeconds:           min(timeoutSeconds, models.MaxTimeoutSeconds),
+		NoAuth:                   input.NoAuth,
+		SupportsStructuredOutput: input.SupportsStructuredOutput,
+		DefaultTemperature:       models.DefaultTemperature,
+		DefaultMaxTokens:         models.DefaultMaxTokens,
+		EmbeddingModel:           embeddingModel,
+		EmbeddingDimension:       input.EmbeddingDimension,
+		SystemPrompt:             systemPrompt,
+		CreatedAt:                time.Now(),
+		UpdatedAt:                time.Now(),
+	}
+
+	err = s.repo.Create(provider)
+	s.auditService.Log(userID, models.AuditActionAPIKeyChanged, map[string]string{
+		"provider_id":   provider.ID,
+		"provider_type": string(provider.ProviderType),
+		"operation":     "create",
+		"success":       strconv.FormatBool(err == nil),
+	}, ipAddress)
+	if err != nil {
 		return nil, err
 	}
 
@@ -69,14 +138,17 @@ func (s *AIProviderService) GetByID(id, userID string) (*models.AIProvider, erro
 		return nil, err
 	}
 	if provider.UserID != userID {
-		return nil, fmt.Errorf("provider not found")
+		return nil, ErrAIProviderNotFound
 	}
 
 	// Decrypt API key to create masked version
-	apiKey, err := s.encryptor.Decrypt(provider.APIKeyEncrypted)
+	apiKey, err := s.decrypt(provider.APIKeyEncrypted)
 	if err == nil {
 		provider.APIKeyMasked = crypto.MaskAPIKey(apiKey)
 	}
+
+	remaining := providerRateLimiter.Remaining(provider.ID, provider.RequestsPerMinute)
+	provider.RateLimitRemaining = &remaining
 	return provider, nil
 }
 
@@ -88,7 +160,7 @@ func (s *AIProviderService) GetByUserID(userID string) ([]models.AIProvider, err
 
 	// Add masked keys
 	for i := range providers {
-		apiKey, err := s.encryptor.Decrypt(providers[i].APIKeyEncrypted)
+		apiKey, err := s.decrypt(providers[i].APIKeyEncrypted)
 		if err == nil {
 			providers[i].APIKeyMasked = crypto.MaskAPIKey(apiKey)
 		}
@@ -104,13 +176,106 @@ func (s *AIProviderService) GetDefaultByUserID(userID string) (*models.AIProvide
 	return provider, nil
 }
 
-func (s *AIProviderService) Update(id, userID string, input *models.AIProviderUpdate) (*models.AIProvider, error) {
+// GetFallbackChain returns the user's default provider followed by its fallbacks, in
+// the order to try them. Disabled providers are skipped, and at most
+// models.MaxFallbackDepth links are followed, so a cycle ends there. The env-configured
+// AI service isn't part of the chain; callers use it as the implicit last resort.
+func (s *AIProviderService) GetFallbackChain(userID string) ([]*models.AIProvider, error) {
+	provider, err := s.repo.GetDefaultByUserID(userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []*models.AIProvider{provider}
+	visited := map[string]bool{provider.ID: true}
+	for depth := 0; depth < models.MaxFallbackDepth && provider.FallbackProviderID != nil; depth++ {
+		next, err := s.repo.GetByID(*provider.FallbackProviderID)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return chain, err
+		}
+		if next.UserID != userID || visited[next.ID] {
+			break
+		}
+		visited[next.ID] = true
+		if next.IsEnabled {
+			chain = append(chain, next)
+		}
+		provider = next
+	}
+	return chain, nil
+}
+
+// WithFallback calls attempt with the configuration of each provider in the user's
+// fallback chain that has a model selected, stopping at the first that succeeds, and
+// returns that configuration. Failed attempts are logged with the provider's name.
+// When every attempt fails it returns the last configuration tried and its error; a
+// user without usable providers gets nil and no error.
+func (s *AIProviderService) WithFallback(userID string, attempt func(config *AIProviderConfig) error) (*AIProviderConfig, error) {
+	chain, err := s.GetFallbackChain(userID)
+	if err != nil {
+		log.Printf("[AIProviderService] Failed to load fallback chain for user %s: %v", userID, err)
+	}
+
+	var lastConfig *AIProviderConfig
+	var lastErr error
+	for _, provider := range chain {
+		if provider.SelectedModel == nil {
+			continue
+		}
+		config, err := s.ProviderConfig(provider, userID)
+		if err != nil {
+			log.Printf("[AIProviderService] Skipping provider %q: %v", provider.Name, err)
+			continue
+		}
+		if err := attempt(config); err != nil {
+			log.Printf("[AIProviderService] Provider %q failed: %v", provider.Name, err)
+			lastConfig, lastErr = config, err
+			continue
+		}
+		return config, nil
+	}
+	return lastConfig, lastErr
+}
+
+// GetEmbeddingProvider returns the user's provider configured for embeddings, if any
+func (s *AIProviderService) GetEmbeddingProvider(userID string) (*models.AIProvider, error) {
+	return s.repo.GetEmbeddingProviderByUserID(userID)
+}
+
+// EmbeddingServiceFor returns an embedding service for a provider's embedding model,
+// whoever owns the provider, for members of the embedding ensemble
+func (s *AIProviderService) EmbeddingServiceFor(id string) (*EmbeddingService, error) {
+	provider, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load provider %s: %w", id, err)
+	}
+	if provider.EmbeddingModel == nil || provider.EmbeddingDimension == nil {
+		return nil, fmt.Errorf("provider %s has no embedding model", id)
+	}
+
+	apiKey, err := s.GetDecryptedAPIKey(provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt API key of provider %s: %w", id, err)
+	}
+	return NewUserEmbeddingService(provider, apiKey), nil
+}
+
+func (s *AIProviderService) Update(id, userID string, input *models.AIProviderUpdate, ipAddress string) (*models.AIProvider, error) {
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+
 	provider, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
 	if provider.UserID != userID {
-		return nil, fmt.Errorf("provider not found")
+		return nil, ErrAIProviderNotFound
 	}
 
 	if input.Name != nil {
@@ -140,8 +305,70 @@ func (s *AIProviderService) Update(id, userID string, input *models.AIProviderUp
 	if input.IsEnabled != nil {
 		provider.IsEnabled = *input.IsEnabled
 	}
+	if input.RequestsPerMinute != nil {
+		provider.RequestsPerMinute = *input.RequestsPerMinute
+	}
+	if input.TimeoutSeconds != nil && *input.TimeoutSeconds > 0 {
+		provider.TimeoutSeconds = min(*input.TimeoutSeconds, models.MaxTimeoutSeconds)
+	}
+	if input.NoAuth != nil {
+		provider.NoAuth = *input.NoAuth
+	}
+	if input.SupportsStructuredOutput != nil {
+		provider.SupportsStructuredOutput = *input.SupportsStructuredOutput
+	}
+	if input.DefaultTemperature != nil {
+		provider.DefaultTemperature = math.Max(0, math.Min(*input.DefaultTemperature, models.MaxTemperature))
+	}
+	if input.DefaultMaxTokens != nil {
+		provider.DefaultMaxTokens = max(1, min(*input.DefaultMaxTokens, models.MaxResponseTokens))
+	}
+	if input.EmbeddingModel != nil {
+		if *input.EmbeddingModel == "" {
+			provider.EmbeddingModel = nil
+			provider.EmbeddingDimension = nil
+		} else {
+			provider.EmbeddingModel = input.EmbeddingModel
+		}
+	}
+	if input.EmbeddingDimension != nil {
+		provider.EmbeddingDimension = input.EmbeddingDimension
+	}
+	if err := validateEmbeddingConfig(provider.ProviderType, provider.EmbeddingModel, provider.EmbeddingDimension); err != nil {
+		return nil, err
+	}
+	if input.SystemPrompt != nil {
+		systemPrompt := strings.TrimSpace(*input.SystemPrompt)
+		if utf8.RuneCountInString(systemPrompt) > models.MaxSystemPromptLength {
+			return nil, ErrSystemPromptTooLong
+		}
+		provider.SystemPrompt = systemPrompt
+	}
+	if input.FallbackProviderID != nil {
+		if *input.FallbackProviderID == "" {
+			provider.FallbackProviderID = nil
+		} else {
+			if *input.FallbackProviderID == provider.ID {
+				return nil, ErrInvalidFallbackProvider
+			}
+			fallback, err := s.repo.GetByID(*input.FallbackProviderID)
+			if err != nil || fallback.UserID != userID {
+				return nil, ErrInvalidFallbackProvider
+			}
+			provider.FallbackProviderID = input.FallbackProviderID
+		}
+	}
 
-	if err := s.repo.Update(provider); err != nil {
+	err = s.repo.Update(provider)
+	if input.APIKey != nil {
+		s.auditService.Log(userID, models.AuditActionAPIKeyChanged, map[string]string{
+			"provider_id":   provider.ID,
+			"provider_type": string(provider.ProviderType),
+			"operation":     "update",
+			"success":       strconv.FormatBool(err == nil),
+		}, ipAddress)
+	}
+	if err != nil {
 		return nil, err
 	}
 
@@ -153,25 +380,49 @@ func (s *AIProviderService) Update(id, userID string, input *models.AIProviderUp
 	return provider, nil
 }
 
+// validateEmbeddingConfig checks an embedding model/dimension pair. A dimension alone
+// is rejected too, since it is meaningless without the model it describes.
+func validateEmbeddingConfig(providerType models.ProviderType, model *string, dimension *int) error {
+	if model == nil {
+		if dimension != nil {
+			return ErrInvalidEmbeddingDimension
+		}
+		return nil
+	}
+
+	switch providerType {
+	case models.ProviderTypeOpenAI, models.ProviderTypeCustom, models.ProviderTypeAssistant, models.ProviderTypeOllama:
+	default:
+		return ErrEmbeddingNotSupported
+	}
+
+	if dimension == nil || *dimension <= 0 || *dimension > models.MaxEmbeddingDimension {
+		return ErrInvalidEmbeddingDimension
+	}
+	return nil
+}
+
 func (s *AIProviderService) Delete(id, userID string) error {
 	provider, err := s.repo.GetByID(id)
 	if err != nil {
 		return err
 	}
 	if provider.UserID != userID {
-		return fmt.Errorf("provider not found")
+		return ErrAIProviderNotFound
 	}
 	return s.repo.Delete(id)
 }
 
 func (s *AIProviderService) TestConnection(input *models.TestConnectionRequest) (*models.TestConnectionResponse, error) {
 	switch input.ProviderType {
-	case models.ProviderTypeOpenAI, models.ProviderTypeCustom:
-		return s.testOpenAICompatible(input.BaseURL, input.APIKey)
+	case models.ProviderTypeOpenAI, models.ProviderTypeCustom, models.ProviderTypeOllama:
+		return s.testOpenAICompatible(input)
 	case models.ProviderTypeAnthropic:
 		return s.testAnthropic(input.BaseURL, input.APIKey)
 	case models.ProviderTypeGoogle:
 		return s.testGoogle(input.BaseURL, input.APIKey)
+	case models.ProviderTypeAssistant:
+		return s.testOpenAIAssistants(input.BaseURL, input.APIKey)
 	default:
 		return &models.TestConnectionResponse{
 			Success: false,
@@ -180,8 +431,20 @@ func (s *AIProviderService) TestConnection(input *models.TestConnectionRequest)
 	}
 }
 
-func (s *AIProviderService) testOpenAICompatible(baseURL, apiKey string) (*models.TestConnectionResponse, error) {
-	url := strings.TrimSuffix(baseURL, "/") + "/models"
+func (s *AIProviderService) testOpenAICompatible(input *models.TestConnectionRequest) (*models.TestConnectionResponse, error) {
+	// Ollama lists its models at /api/tags rather than /models. Custom providers are
+	// probed too, since they were the way to add an Ollama server before it had a type.
+	if input.ProviderType == models.ProviderTypeOllama || input.ProviderType == models.ProviderTypeCustom {
+		if modelIDs, err := fetchOllamaModels(input.BaseURL); err == nil {
+			return &models.TestConnectionResponse{
+				Success: true,
+				Message: "Connection successful",
+				Models:  modelIDs,
+			}, nil
+		}
+	}
+
+	url := strings.TrimSuffix(input.BaseURL, "/") + "/models"
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -191,7 +454,9 @@ func (s *AIProviderService) testOpenAICompatible(baseURL, apiKey string) (*model
 		}, nil
 	}
 
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+	if sendsAuthHeader(input.ProviderType, input.NoAuth) {
+		req.Header.Set("Authorization", "Bearer "+input.APIKey)
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{Timeout: 30 * time.Second}
@@ -375,17 +640,82 @@ func (s *AIProviderService) testGoogle(baseURL, apiKey string) (*models.TestConn
 	}, nil
 }
 
+// testOpenAIAssistants lists the account's assistants; their IDs are offered as "models"
+func (s *AIProviderService) testOpenAIAssistants(baseURL, apiKey string) (*models.TestConnectionResponse, error) {
+	url := strings.TrimSuffix(baseURL, "/") + "/assistants?limit=100"
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return &models.TestConnectionResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to create request: %v", err),
+		}, nil
+	}
+
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("OpenAI-Beta", "assistants=v2")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &models.TestConnectionResponse{
+			Success: false,
+			Message: fmt.Sprintf("Connection failed: %v", err),
+		}, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 401 {
+		return &models.TestConnectionResponse{
+			Success: false,
+			Message: "Invalid API key",
+		}, nil
+	}
+
+	if resp.StatusCode != 200 {
+		body, _ := io.ReadAll(resp.Body)
+		return &models.TestConnectionResponse{
+			Success: false,
+			Message: fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(body)),
+		}, nil
+	}
+
+	var assistantsResp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&assistantsResp); err != nil {
+		return &models.TestConnectionResponse{
+			Success: false,
+			Message: fmt.Sprintf("Failed to parse response: %v", err),
+		}, nil
+	}
+
+	assistantIDs := make([]string, 0, len(assistantsResp.Data))
+	for _, a := range assistantsResp.Data {
+		assistantIDs = append(assistantIDs, a.ID)
+	}
+
+	return &models.TestConnectionResponse{
+		Success: true,
+		Message: "Connection successful",
+		Models:  assistantIDs,
+	}, nil
+}
+
 func (s *AIProviderService) FetchAndSaveModels(id, userID string) ([]models.AIProviderModel, error) {
 	provider, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
 	if provider.UserID != userID {
-		return nil, fmt.Errorf("provider not found")
+		return nil, ErrAIProviderNotFound
 	}
 
 	// Decrypt API key
-	apiKey, err := s.encryptor.Decrypt(provider.APIKeyEncrypted)
+	apiKey, err := s.decrypt(provider.APIKeyEncrypted)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt API key: %w", err)
 	}
@@ -395,6 +725,7 @@ func (s *AIProviderService) FetchAndSaveModels(id, userID string) ([]models.AIPr
 		ProviderType: provider.ProviderType,
 		BaseURL:      provider.BaseURL,
 		APIKey:       apiKey,
+		NoAuth:       provider.NoAuth,
 	})
 	if err != nil {
 		return nil, err
@@ -407,11 +738,12 @@ func (s *AIProviderService) FetchAndSaveModels(id, userID string) ([]models.AIPr
 	providerModels := make([]models.AIProviderModel, len(testResult.Models))
 	for i, modelID := range testResult.Models {
 		providerModels[i] = models.AIProviderModel{
-			ID:         uuid.New().String(),
-			ProviderID: provider.ID,
-			ModelID:    modelID,
-			ModelName:  modelID,
-			CreatedAt:  time.Now(),
+			ID:           uuid.New().String(),
+			ProviderID:   provider.ID,
+			ModelID:      modelID,
+			ModelName:    modelID,
+			Capabilities: DetectModelCapabilities(modelID),
+			CreatedAt:    time.Now(),
 		}
 	}
 
@@ -429,13 +761,185 @@ func (s *AIProviderService) GetModels(id, userID string) ([]models.AIProviderMod
 		return nil, err
 	}
 	if provider.UserID != userID {
-		return nil, fmt.Errorf("provider not found")
+		return nil, ErrAIProviderNotFound
 	}
 
-	return s.repo.GetModelsByProviderID(id)
+	providerModels, err := s.repo.GetModelsByProviderID(id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range providerModels {
+		if providerModels[i].Capabilities == nil {
+			providerModels[i].Capabilities = DetectModelCapabilities(providerModels[i].ModelID)
+		}
+	}
+	return providerModels, nil
+}
+
+// ProviderConfig returns the call configuration for a provider: its decrypted key,
+// selected model, limits and response defaults, plus any assistant settings.
+// Callers add per-call settings such as Ctx.
+func (s *AIProviderService) ProviderConfig(provider *models.AIProvider, userID string) (*AIProviderConfig, error) {
+	apiKey, err := s.GetDecryptedAPIKey(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	temperature := provider.DefaultTemperature
+	config := &AIProviderConfig{
+		ProviderType:             provider.ProviderType,
+		BaseURL:                  provider.BaseURL,
+		APIKey:                   apiKey,
+		MaxTokens:                provider.DefaultMaxTokens,
+		Temperature:              &temperature,
+		ProviderID:               provider.ID,
+		RequestsPerMinute:        provider.RequestsPerMinute,
+		Timeout:                  providerTimeout(provider),
+		NoAuth:                   provider.NoAuth,
+		SupportsStructuredOutput: provider.SupportsStructuredOutput,
+		SystemPrompt:             provider.SystemPrompt,
+		UserID:                   userID,
+		PIIScrubber:              s.piiScrubber,
+	}
+	if provider.SelectedModel != nil {
+		config.Model = *provider.SelectedModel
+	}
+	s.ApplyAssistantConfig(provider, config)
+	return config, nil
 }
 
 // GetDecryptedAPIKey returns the decrypted API key for a provider
 func (s *AIProviderService) GetDecryptedAPIKey(provider *models.AIProvider) (string, error) {
-	return s.encryptor.Decrypt(provider.APIKeyEncrypted)
+	return s.decrypt(provider.APIKeyEncrypted)
+}
+
+// decrypt decrypts an API key with the current encryptor
+func (s *AIProviderService) decrypt(ciphertext string) (string, error) {
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+	return s.encryptor.Decrypt(ciphertext)
+}
+
+// StoreEncryptedSecret encrypts a secret of another feature, like an inbound webhook
+// source's, with the API key encryptor and stores it with store. Like Create, it
+// holds keyMu until the secret is stored, so RotateEncryptionKey covers it.
+func (s *AIProviderService) StoreEncryptedSecret(plaintext string, store func(ciphertext string) error) error {
+	s.keyMu.RLock()
+	defer s.keyMu.RUnlock()
+
+	ciphertext, err := s.encryptor.Encrypt(plaintext)
+	if err != nil {
+		return err
+	}
+	return store(ciphertext)
+}
+
+// DecryptSecret decrypts a secret stored with StoreEncryptedSecret
+func (s *AIProviderService) DecryptSecret(ciphertext string) (string, error) {
+	return s.decrypt(ciphertext)
+}
+
+// RotateEncryptionKey re-encrypts every provider's API key and inbound webhook
+// secret with newKey in one transaction, then switches the service to the new key.
+// ENCRYPTION_KEY must be updated to match before the next restart. If any secret
+// fails to re-encrypt, nothing changes and the failures are returned with
+// ErrKeyRotationFailed.
+func (s *AIProviderService) RotateEncryptionKey(newKey string) (int, []models.KeyRotationFailure, error) {
+	s.keyMu.Lock()
+	defer s.keyMu.Unlock()
+
+	newEncryptor := crypto.NewEncryptor(newKey)
+	rotated, failures, err := s.repo.ReEncryptSecrets(func(ciphertext string) (string, error) {
+		return s.encryptor.ReEncrypt(ciphertext, newEncryptor)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(failures) > 0 {
+		log.Printf("[AIProvider] Encryption key rotation rolled back: %d secrets failed to re-encrypt", len(failures))
+		return 0, failures, ErrKeyRotationFailed
+	}
+
+	s.encryptor = newEncryptor
+	log.Printf("[AIProvider] Rotated encryption key for %d secrets - update ENCRYPTION_KEY before restarting", rotated)
+	return rotated, nil, nil
+}
+
+// sendsAuthHeader reports whether calls to a provider carry the bearer token.
+// Ollama doesn't check it, and NoAuth turns it off for any other local server.
+func sendsAuthHeader(providerType models.ProviderType, noAuth bool) bool {
+	return !noAuth && providerType != models.ProviderTypeOllama
+}
+
+// ollamaRootURL strips the OpenAI-compatible /v1 suffix from an Ollama base URL,
+// leaving the server root that its native /api endpoints hang off
+func ollamaRootURL(baseURL string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/v1")
+}
+
+// fetchOllamaModels lists the models pulled on an Ollama server via GET /api/tags
+func fetchOllamaModels(baseURL string) ([]string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(ollamaRootURL(baseURL) + "/api/tags")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama tags error: %s", resp.Status)
+	}
+
+	var tagsResp struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tagsResp); err != nil {
+		return nil, err
+	}
+	if tagsResp.Models == nil {
+		return nil, fmt.Errorf("not an ollama tags response")
+	}
+
+	modelIDs := make([]string, 0, len(tagsResp.Models))
+	for _, m := range tagsResp.Models {
+		modelIDs = append(modelIDs, m.Name)
+	}
+	return modelIDs, nil
+}
+
+// providerTimeout converts a provider's timeout setting for AIProviderConfig
+func providerTimeout(provider *models.AIProvider) time.Duration {
+	return time.Duration(provider.TimeoutSeconds) * time.Second
+}
+
+// ApplyAssistantConfig gives an assistant provider's config its persistent thread
+// and saves newly created threads back to the provider's metadata
+func (s *AIProviderService) ApplyAssistantConfig(provider *models.AIProvider, config *AIProviderConfig) {
+	if provider.ProviderType != models.ProviderTypeAssistant {
+		return
+	}
+
+	config.ExtraParams = map[string]string{}
+	for k, v := range provider.Metadata {
+		config.ExtraParams[k] = v
+	}
+
+	providerID := provider.ID
+	config.OnThreadCreated = func(threadID string) {
+		current, err := s.repo.GetByID(providerID)
+		if err != nil {
+			log.Printf("[AIProviderService] Failed to load provider %s to save assistant thread: %v", providerID, err)
+			return
+		}
+		metadata := current.Metadata
+		if metadata == nil {
+			metadata = map[string]string{}
+		}
+		metadata[AssistantThreadIDKey] = threadID
+		if err := s.repo.UpdateMetadata(providerID, metadata); err != nil {
+			log.Printf("[AIProviderService] Failed to save assistant thread for provider %s: %v", providerID, err)
+		}
+	}
 }
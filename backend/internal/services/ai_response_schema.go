@@ -0,0 +1,221 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/todomyday/backend/internal/metrics"
+	"github.com/todomyday/backend/internal/models"
+)
+
+// JSON schemas the AI responses are validated against
+const (
+	todoResponseSchema = `{
+  "type": "object",
+  "required": ["title", "tags"],
+  "properties": {
+    "title": {"type": "string"},
+    "tags": {"type": "array", "items": {"type": "string"}}
+  }
+}`
+
+	memoryResponseSchema = `{
+  "type": "object",
+  "required": ["category"],
+  "properties": {
+    "summary": {"type": "string"},
+    "category": {"enum": ["Websites", "Food", "Movies", "Books", "Ideas", "Places", "Products", "People", "Learnings", "Quotes", "Uncategorized"]}
+  }
+}`
+
+	memoryToolArgsSchema = `{
+  "type": "object",
+  "required": ["category"],
+  "properties": {
+    "summary": {"type": "string"},
+    "category": {"enum": ["Websites", "Food", "Movies", "Books", "Ideas", "Places", "Products", "People", "Learnings", "Quotes", "Uncategorized"]},
+    "has_url": {"type": "boolean"},
+    "url": {"type": "string"}
+  }
+}`
+
+	// Story points are checked against the Fibonacci scale after validation, so an
+	// off-scale answer becomes "unknown" rather than a retry
+	effortResponseSchema = `{
+  "type": "object",
+  "required": ["story_points"],
+  "properties": {
+    "story_points": {"type": ["number", "string", "null"]},
+    "estimated_duration": {"type": ["string", "null"]}
+  }
+}`
+)
+
+var (
+	// ErrInvalidAIResponse is returned when an AI response still fails schema validation after a retry
+	ErrInvalidAIResponse = errors.New("AI response did not match the expected schema")
+	// ErrInvalidResponseSchema is returned, before anything is sent, when a schema can't be used for structured output
+	ErrInvalidResponseSchema = errors.New("invalid structured output schema")
+)
+
+// structuredOutputModelPrefixes are OpenAI models known to accept a json_schema response_format
+var structuredOutputModelPrefixes = []string{"gpt-4o", "gpt-4.1", "gpt-5", "o1", "o3", "o4"}
+
+// compiledSchemas caches compiled schemas by their source
+var compiledSchemas sync.Map
+
+// ValidateAIResponse checks that payload is JSON matching schema
+func ValidateAIResponse(schema string, payload []byte) error {
+	compiled, err := compileSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return compiled.Validate(value)
+}
+
+func compileSchema(schema string) (*jsonschema.Schema, error) {
+	if cached, ok := compiledSchemas.Load(schema); ok {
+		return cached.(*jsonschema.Schema), nil
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("mrbrain://ai-response.json", strings.NewReader(schema)); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	compiled, err := compiler.Compile("mrbrain://ai-response.json")
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	compiledSchemas.Store(schema, compiled)
+	return compiled, nil
+}
+
+// extractJSONObject returns the outermost {...} in an AI response, which models
+// often wrap in markdown or prose, or the trimmed response if there isn't one
+func extractJSONObject(content string) []byte {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start != -1 && end > start {
+		return []byte(content[start : end+1])
+	}
+	return []byte(strings.TrimSpace(content))
+}
+
+// usesStructuredOutput reports whether config's provider can be sent the response schema
+func usesStructuredOutput(config *AIProviderConfig) bool {
+	switch config.ProviderType {
+	case models.ProviderTypeAnthropic, models.ProviderTypeGoogle, models.ProviderTypeAssistant:
+		return false
+	}
+	if config.TextResponse {
+		return false
+	}
+	if config.SupportsStructuredOutput {
+		return true
+	}
+
+	model := strings.ToLower(config.Model)
+	for _, prefix := range structuredOutputModelPrefixes {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// strictResponseSchema turns a response schema into the strict form structured output
+// requires: an object schema that lists every property as required and allows no others.
+// Responses that satisfy it also satisfy the original schema.
+func strictResponseSchema(schema string) (map[string]interface{}, error) {
+	if _, err := compileSchema(schema); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponseSchema, err)
+	}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &parsed); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponseSchema, err)
+	}
+	if parsed["type"] != "object" {
+		return nil, fmt.Errorf("%w: top level must be an object", ErrInvalidResponseSchema)
+	}
+	properties, ok := parsed["properties"].(map[string]interface{})
+	if !ok || len(properties) == 0 {
+		return nil, fmt.Errorf("%w: no properties", ErrInvalidResponseSchema)
+	}
+
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+	sort.Strings(required)
+
+	parsed["required"] = required
+	parsed["additionalProperties"] = false
+	return parsed, nil
+}
+
+// callProviderForJSON sends prompt to the provider and returns the JSON object in its
+// response once it validates against schema. An invalid response is logged, counted
+// under kind and retried once with the schema spelled out in the prompt; if that also
+// fails ErrInvalidAIResponse is returned. Provider errors are returned as they are.
+//
+// Providers with structured output are sent the schema itself, so their response is
+// taken as it is rather than dug out of surrounding prose.
+func callProviderForJSON(config *AIProviderConfig, prompt, schema, kind string) ([]byte, error) {
+	structured := usesStructuredOutput(config)
+	if structured {
+		responseSchema, err := strictResponseSchema(schema)
+		if err != nil {
+			return nil, err
+		}
+		scoped := *config
+		scoped.responseSchema = responseSchema
+		config = &scoped
+	}
+
+	for attempt := 1; ; attempt++ {
+		content, err := callProviderWithHistory(config, nil, prompt)
+		if err != nil {
+			return nil, err
+		}
+
+		payload := []byte(strings.TrimSpace(content))
+		if !structured {
+			payload = extractJSONObject(content)
+		}
+		err = ValidateAIResponse(schema, payload)
+		if err == nil {
+			return payload, nil
+		}
+
+		log.Printf("[AI] Invalid %s response (attempt %d): %v; raw response: %s", kind, attempt, err, content)
+		metrics.AIInvalidResponseTotal.WithLabelValues(kind).Inc()
+		if attempt == 2 {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAIResponse, err)
+		}
+
+		prompt = withSchemaReminder(prompt, schema)
+	}
+}
+
+// withSchemaReminder amends a prompt whose response failed validation with the schema it must follow
+func withSchemaReminder(prompt, schema string) string {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(schema)); err == nil {
+		schema = compact.String()
+	}
+	return prompt + "\n\nYour previous response was not valid. Respond with ONLY a JSON object that validates against this JSON schema:\n" + schema
+}
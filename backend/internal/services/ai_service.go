@@ -2,23 +2,30 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/todomyday/backend/internal/metrics"
 	"github.com/todomyday/backend/internal/models"
+	"golang.org/x/time/rate"
 )
 
 // AIService handles AI processing with a default configuration (from env)
 type AIService struct {
-	baseURL string
-	apiKey  string
-	model   string
-	client  *http.Client
+	baseURL     string
+	apiKey      string
+	model       string
+	client      *http.Client
+	piiScrubber *PIIScrubber
 }
 
 // AIProviderConfig holds provider configuration for processing
@@ -27,6 +34,149 @@ type AIProviderConfig struct {
 	BaseURL      string
 	APIKey       string
 	Model        string
+	MaxTokens    int  // Overrides the default response token limit when set, capped at models.MaxResponseTokens
+	TextResponse bool // Disables JSON response mode for free-form text prompts
+	// Temperature overrides models.DefaultTemperature when set, capped at [0, models.MaxTemperature]
+	Temperature *float64
+	// SystemPrompt is sent ahead of every call's messages as the system instruction
+	SystemPrompt string
+
+	// Rate limiting: calls sharing a ProviderID share one token bucket
+	ProviderID        string
+	RequestsPerMinute int
+	// Ctx cancels both the wait for a rate limit slot and the HTTP request (defaults to Background)
+	Ctx context.Context
+	// Timeout bounds each HTTP call to the provider (defaults to models.DefaultTimeoutSeconds)
+	Timeout time.Duration
+	// UserID is recorded on trace spans for the call
+	UserID string
+	// NoAuth omits the Authorization header on OpenAI-compatible calls
+	NoAuth bool
+	// DetectLanguage tells todo and memory categorization prompts the language of non-English input
+	DetectLanguage bool
+	// PIIScrubber, when set, keeps personal data in todos and memories from reaching the provider
+	PIIScrubber *PIIScrubber
+	// CategoryHistory, when set, categorizes memories like ones UserID has corrected
+	// often enough without calling the provider
+	CategoryHistory *PersonalCategoryModel
+	// Prompts, when set, replaces the built-in memory categorization and weekly digest
+	// prompts with UserID's active templates
+	Prompts *PromptTemplateService
+	// SupportsStructuredOutput sends the expected JSON schema as a strict response_format
+	// on OpenAI-compatible calls; well-known OpenAI models are detected by name without it
+	SupportsStructuredOutput bool
+	// responseSchema is the strict schema for this call's response, set by callProviderForJSON
+	responseSchema map[string]interface{}
+
+	// ExtraParams carries provider-specific settings, e.g. the assistant "thread_id"
+	ExtraParams map[string]string
+	// OnThreadCreated is called when an assistant call starts a new thread, so it can be persisted
+	OnThreadCreated func(threadID string)
+	// OnToken makes callProviderWithHistory stream the reply, receiving each piece of text
+	// as it arrives. Providers without streaming support pass the whole reply at once.
+	OnToken func(token string)
+}
+
+// defaultProviderID is the rate limit key for the env-configured AI service
+const defaultProviderID = "env-default"
+
+// ProviderRateLimiter keeps a token bucket per AI provider so concurrent users
+// sharing one API key stay under the provider's requests-per-minute limit
+type ProviderRateLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// providerRateLimiter is shared by all AI provider calls in the process
+var providerRateLimiter = NewProviderRateLimiter()
+
+func NewProviderRateLimiter() *ProviderRateLimiter {
+	return &ProviderRateLimiter{
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// limiter returns the provider's limiter, creating it or applying a changed rpm
+func (l *ProviderRateLimiter) limiter(providerID string, rpm int) *rate.Limiter {
+	if rpm <= 0 {
+		rpm = models.DefaultRequestsPerMinute
+	}
+	limit := rate.Limit(float64(rpm) / 60)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lim, ok := l.limiters[providerID]
+	if !ok {
+		lim = rate.NewLimiter(limit, rpm)
+		l.limiters[providerID] = lim
+	} else if lim.Limit() != limit {
+		lim.SetLimit(limit)
+		lim.SetBurst(rpm)
+	}
+	return lim
+}
+
+// Wait blocks until the provider has a free slot or ctx is done
+func (l *ProviderRateLimiter) Wait(ctx context.Context, providerID string, rpm int) error {
+	return l.limiter(providerID, rpm).Wait(ctx)
+}
+
+// Remaining returns the current number of whole requests left in the provider's bucket
+func (l *ProviderRateLimiter) Remaining(providerID string, rpm int) int {
+	return int(l.limiter(providerID, rpm).Tokens())
+}
+
+// requestContext returns the config's context, defaulting to Background
+func (c *AIProviderConfig) requestContext() context.Context {
+	if c.Ctx != nil {
+		return c.Ctx
+	}
+	return context.Background()
+}
+
+// timeout returns the config's HTTP timeout, defaulted and capped at models.MaxTimeoutSeconds
+func (c *AIProviderConfig) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return models.DefaultTimeoutSeconds * time.Second
+	}
+	if c.Timeout > models.MaxTimeoutSeconds*time.Second {
+		return models.MaxTimeoutSeconds * time.Second
+	}
+	return c.Timeout
+}
+
+// setAuthHeader sets the bearer token unless the provider doesn't take one
+func (c *AIProviderConfig) setAuthHeader(req *http.Request) {
+	if sendsAuthHeader(c.ProviderType, c.NoAuth) {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+}
+
+// httpClient returns a client that applies the provider's timeout
+func (c *AIProviderConfig) httpClient() *http.Client {
+	return &http.Client{Timeout: c.timeout()}
+}
+
+// warnIfSlow logs a call that used more than 80% of the provider's timeout,
+// a hint that the timeout should be raised before requests start failing
+func (c *AIProviderConfig) warnIfSlow(start time.Time) {
+	elapsed := time.Since(start)
+	if timeout := c.timeout(); elapsed > timeout*8/10 {
+		slog.WarnContext(c.requestContext(), "Slow AI provider response", "provider_type", c.ProviderType, "elapsed", elapsed.Round(time.Millisecond), "timeout", timeout)
+	}
+}
+
+// waitForRateLimit blocks until the config's provider may make another request
+func waitForRateLimit(config *AIProviderConfig) error {
+	providerID := config.ProviderID
+	if providerID == "" {
+		providerID = defaultProviderID
+	}
+	if err := providerRateLimiter.Wait(config.requestContext(), providerID, config.RequestsPerMinute); err != nil {
+		return fmt.Errorf("rate limit wait cancelled: %w", err)
+	}
+	return nil
 }
 
 type chatRequest struct {
@@ -36,10 +186,18 @@ type chatRequest struct {
 	Temperature    float64           `json:"temperature"`
 	ResponseFormat *responseFormat   `json:"response_format,omitempty"`
 	Thinking       *thinkingConfig   `json:"thinking,omitempty"`
+	Stream         bool              `json:"stream,omitempty"`
 }
 
 type responseFormat struct {
-	Type string `json:"type"`
+	Type       string            `json:"type"`
+	JSONSchema *jsonSchemaFormat `json:"json_schema,omitempty"`
+}
+
+type jsonSchemaFormat struct {
+	Name   string                 `json:"name"`
+	Strict bool                   `json:"strict"`
+	Schema map[string]interface{} `json:"schema"`
 }
 
 type thinkingConfig struct {
@@ -59,13 +217,19 @@ type chatResponse struct {
 		} `json:"message"`
 		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
 }
 
 // Anthropic-specific types
 type anthropicRequest struct {
-	Model     string             `json:"model"`
-	MaxTokens int                `json:"max_tokens"`
-	Messages  []anthropicMessage `json:"messages"`
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
 }
 
 type anthropicMessage struct {
@@ -77,6 +241,10 @@ type anthropicResponse struct {
 	Content []struct {
 		Text string `json:"text"`
 	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
 }
 
 // Google-specific types
@@ -86,6 +254,7 @@ type googleRequest struct {
 }
 
 type googleContent struct {
+	Role  string       `json:"role,omitempty"`
 	Parts []googlePart `json:"parts"`
 }
 
@@ -106,6 +275,10 @@ type googleResponse struct {
 			} `json:"parts"`
 		} `json:"content"`
 	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
 }
 
 type aiResult struct {
@@ -241,6 +414,12 @@ func NewAIService(baseURL, apiKey, model string) *AIService {
 	}
 }
 
+// SetPIIScrubber makes todo and memory processing with the env configuration scrub
+// personal data first
+func (s *AIService) SetPIIScrubber(scrubber *PIIScrubber) {
+	s.piiScrubber = scrubber
+}
+
 func (s *AIService) IsConfigured() bool {
 	return s.baseURL != "" && s.apiKey != "" && s.model != ""
 }
@@ -252,24 +431,31 @@ func (s *AIService) ProcessTodo(title string) (*AIProcessedTodo, error) {
 	}
 
 	config := &AIProviderConfig{
-		ProviderType: models.ProviderTypeOpenAI, // Default is OpenAI-compatible
-		BaseURL:      s.baseURL,
-		APIKey:       s.apiKey,
-		Model:        s.model,
+		ProviderType:   models.ProviderTypeOpenAI, // Default is OpenAI-compatible
+		BaseURL:        s.baseURL,
+		APIKey:         s.apiKey,
+		Model:          s.model,
+		DetectLanguage: true,
+		PIIScrubber:    s.piiScrubber,
 	}
 
-	return ProcessTodoWithProvider(title, config)
+	return ProcessTodoWithProvider(title, config, nil, "")
 }
 
-// ProcessTodoWithProvider processes a todo title using a specific provider configuration
-func ProcessTodoWithProvider(title string, config *AIProviderConfig) (*AIProcessedTodo, error) {
+// ProcessTodoWithProvider processes a todo title using a specific provider configuration.
+// If the user has an active todo_processing prompt template it is used instead of the built-in prompt.
+func ProcessTodoWithProvider(title string, config *AIProviderConfig, prompts *PromptTemplateService, userID string) (*AIProcessedTodo, error) {
 	if config == nil || config.BaseURL == "" || config.APIKey == "" || config.Model == "" {
-		log.Printf("[AI] Skipping - no valid config (baseURL=%s, model=%s)", config.BaseURL, config.Model)
+		slog.Debug("AI skipping todo processing - no valid config")
 		return &AIProcessedTodo{Title: title, Tags: []string{}}, nil
 	}
 
-	log.Printf("[AI] Processing todo: %q", title)
-	log.Printf("[AI] Using provider: %s, model: %s, baseURL: %s", config.ProviderType, config.Model, config.BaseURL)
+	ctx := config.requestContext()
+	slog.DebugContext(ctx, "AI processing todo", "title", title,
+		"provider_type", config.ProviderType, "model", config.Model, "base_url", config.BaseURL)
+
+	// Personal data is replaced with placeholders before it leaves the server
+	input, replacements := config.PIIScrubber.Scrub(title)
 
 	// Simple prompt - frontend handles date parsing now
 	prompt := fmt.Sprintf(`You are a todo assistant. Clean the following todo input and extract tags.
@@ -281,53 +467,105 @@ INSTRUCTIONS:
 2. tags: Extract 1-5 relevant tags (lowercase, single words like "shopping", "work", "health", "meeting", "errand")
 
 Respond with ONLY valid JSON (no markdown, no code blocks, no explanation):
-{"title": "cleaned title", "tags": ["tag1", "tag2"]}`, title)
-
-	log.Printf("[AI] Prompt: %s", prompt)
+{"title": "cleaned title", "tags": ["tag1", "tag2"]}`, input)
 
-	var content string
-	var err error
-
-	switch config.ProviderType {
-	case models.ProviderTypeAnthropic:
-		content, err = callAnthropic(config, prompt)
-	case models.ProviderTypeGoogle:
-		content, err = callGoogle(config, prompt)
-	default:
-		// OpenAI-compatible (openai, custom)
-		content, err = callOpenAICompatible(config, prompt)
+	if custom, ok := prompts.RenderActive(userID, models.PromptTemplateTodoProcessing, map[string]string{"Title": input}); ok {
+		prompt = custom
+	}
+	if config.DetectLanguage {
+		prompt = withLanguageHint(prompt, input)
 	}
 
+	slog.DebugContext(ctx, "AI todo prompt", "prompt", prompt)
+
+	content, err := callProviderForJSON(config, prompt, todoResponseSchema, metrics.AIResponseTodo)
 	if err != nil {
-		log.Printf("[AI] Error from provider: %v", err)
+		slog.WarnContext(ctx, "AI todo processing failed", "error", err)
 		return &AIProcessedTodo{Title: title, Tags: []string{}}, err
 	}
 
-	log.Printf("[AI] Raw response: %s", content)
+	slog.DebugContext(ctx, "AI todo raw response", "response", string(content))
 
-	result, err := parseAIResponse(title, content)
+	result, err := parseAIResponse(input, content)
 	if err != nil {
-		log.Printf("[AI] Parse error: %v", err)
+		slog.WarnContext(ctx, "AI todo response could not be parsed", "error", err)
 		return &AIProcessedTodo{Title: title, Tags: []string{}}, err
 	}
+	if len(replacements) > 0 {
+		if keepsPlaceholders(result.Title, replacements) {
+			result.Title = config.PIIScrubber.Restore(result.Title, replacements)
+		} else {
+			// The AI dropped or rewrote a scrubbed value; keep the title as typed
+			result.Title = title
+		}
+	}
 
-	log.Printf("[AI] Result - title: %q, tags: %v", result.Title, result.Tags)
+	slog.DebugContext(ctx, "AI todo result", "title", result.Title, "tags", result.Tags)
 	return result, nil
 }
 
+// callProviderWithHistory sends prompt as the latest user turn after the given
+// conversation history, dispatching on the provider type
+func callProviderWithHistory(config *AIProviderConfig, history []chatMessage, prompt string) (string, error) {
+	messages := make([]chatMessage, 0, len(history)+1)
+	messages = append(messages, history...)
+	messages = append(messages, chatMessage{Role: "user", Content: prompt})
+
+	var result string
+	var err error
+	switch config.ProviderType {
+	case models.ProviderTypeAssistant:
+		// The assistant thread keeps its own conversation history
+		result, err = callAssistant(config, prompt)
+	case models.ProviderTypeAnthropic:
+		result, err = callAnthropicMessages(config, messages)
+	case models.ProviderTypeGoogle:
+		result, err = callGoogleMessages(config, messages)
+	default:
+		if config.OnToken != nil {
+			return callOpenAICompatibleStream(config, messages)
+		}
+		return callOpenAICompatibleMessages(config, messages)
+	}
+
+	if err == nil && config.OnToken != nil {
+		config.OnToken(result)
+	}
+	return result, err
+}
+
 func callOpenAICompatible(config *AIProviderConfig, prompt string) (string, error) {
-	// Build request
-	reqBody := chatRequest{
-		Model: config.Model,
-		Messages: []chatMessage{
-			{Role: "user", Content: prompt},
-		},
-		MaxTokens:   500,
-		Temperature: 0.3,
+	return callOpenAICompatibleMessages(config, []chatMessage{{Role: "user", Content: prompt}})
+}
+
+func callOpenAICompatibleMessages(config *AIProviderConfig, messages []chatMessage) (result string, err error) {
+	ctx, span := startAISpan(config, "ai.openai_compatible")
+	callStart := time.Now()
+	defer func() {
+		endSpan(span, err)
+		observeAIRequest(config, callStart, err)
+	}()
+
+	if err := waitForRateLimit(config); err != nil {
+		return "", err
 	}
 
-	// Add response_format for OpenAI
-	if strings.Contains(config.BaseURL, "openai.com") {
+	// Build request
+	reqBody := chatRequest{
+		Model:       config.Model,
+		Messages:    withSystemPrompt(config, messages),
+		MaxTokens:   maxTokensOrDefault(config, models.DefaultMaxTokens),
+		Temperature: temperatureOrDefault(config),
+	}
+
+	// Add response_format: the expected schema when the model supports structured
+	// output, otherwise plain JSON mode for OpenAI
+	if config.responseSchema != nil && !config.TextResponse {
+		reqBody.ResponseFormat = &responseFormat{
+			Type:       "json_schema",
+			JSONSchema: &jsonSchemaFormat{Name: "response", Strict: true, Schema: config.responseSchema},
+		}
+	} else if strings.Contains(config.BaseURL, "openai.com") && !config.TextResponse {
 		reqBody.ResponseFormat = &responseFormat{Type: "json_object"}
 	}
 
@@ -340,34 +578,33 @@ func callOpenAICompatible(config *AIProviderConfig, prompt string) (string, erro
 	}
 
 	url := strings.TrimSuffix(config.BaseURL, "/") + "/chat/completions"
-	log.Printf("[AI-HTTP] >>> Request URL: %s", url)
-	log.Printf("[AI-HTTP] >>> Request body: %s", string(jsonBody))
+	slog.DebugContext(ctx, "AI request", "url", url, "body", string(jsonBody))
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return "", err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+config.APIKey)
+	config.setAuthHeader(req)
 
 	keyPreview := config.APIKey
 	if len(keyPreview) > 10 {
 		keyPreview = keyPreview[:10] + "..."
 	}
-	log.Printf("[AI-HTTP] >>> API key: %s", keyPreview)
+	slog.DebugContext(ctx, "AI request key", "api_key_prefix", keyPreview)
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	start := time.Now()
+	defer config.warnIfSlow(start)
+	resp, err := config.httpClient().Do(req)
 	if err != nil {
-		log.Printf("[AI-HTTP] !!! HTTP error: %v", err)
+		slog.WarnContext(ctx, "AI request failed", "url", url, "error", err)
 		return "", err
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
-	log.Printf("[AI-HTTP] <<< Response status: %d", resp.StatusCode)
-	log.Printf("[AI-HTTP] <<< Response body: %s", string(body))
+	slog.DebugContext(ctx, "AI response", "status", resp.StatusCode, "body", string(body))
 
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("AI API error: %s - %s", resp.Status, string(body))
@@ -375,12 +612,13 @@ func callOpenAICompatible(config *AIProviderConfig, prompt string) (string, erro
 
 	var chatResp chatResponse
 	if err := json.Unmarshal(body, &chatResp); err != nil {
-		log.Printf("[AI-HTTP] !!! JSON decode error: %v", err)
+		slog.WarnContext(ctx, "AI response could not be decoded", "error", err)
 		return "", err
 	}
+	recordTokens(span, chatResp.Usage.PromptTokens, chatResp.Usage.CompletionTokens)
 
 	if len(chatResp.Choices) == 0 {
-		log.Printf("[AI-HTTP] !!! No choices in response")
+		slog.WarnContext(ctx, "AI response has no choices")
 		return "", fmt.Errorf("no response from AI")
 	}
 
@@ -388,39 +626,72 @@ func callOpenAICompatible(config *AIProviderConfig, prompt string) (string, erro
 	content := strings.TrimSpace(chatResp.Choices[0].Message.Content)
 	reasoning := strings.TrimSpace(chatResp.Choices[0].Message.ReasoningContent)
 
-	log.Printf("[AI-HTTP] <<< Finish reason: %s", chatResp.Choices[0].FinishReason)
-	log.Printf("[AI-HTTP] <<< Content: %s", content)
-	log.Printf("[AI-HTTP] <<< Reasoning (first 200 chars): %.200s", reasoning)
+	slog.DebugContext(ctx, "AI response content", "finish_reason", chatResp.Choices[0].FinishReason,
+		"content", content, "reasoning", truncateString(reasoning, 200))
 
 	// If content is empty but reasoning has JSON, try to extract it
 	if content == "" && reasoning != "" {
-		log.Printf("[AI-HTTP] <<< Content empty, searching for JSON in reasoning_content")
+		slog.DebugContext(ctx, "AI content empty, searching for JSON in reasoning_content")
 		// Look for JSON object in reasoning
 		start := strings.Index(reasoning, `{"title"`)
 		if start != -1 {
 			end := strings.Index(reasoning[start:], "}")
 			if end != -1 {
 				content = reasoning[start : start+end+1]
-				log.Printf("[AI-HTTP] <<< Extracted JSON from reasoning: %s", content)
+				slog.DebugContext(ctx, "AI extracted JSON from reasoning", "content", content)
 			}
 		}
 	}
 
 	if content == "" {
-		log.Printf("[AI-HTTP] !!! No usable content found")
+		slog.WarnContext(ctx, "AI response has no usable content")
 		return "", fmt.Errorf("no content in AI response")
 	}
 
 	return content, nil
 }
 
+// withSystemPrompt prepends the provider's system prompt, if any, to messages
+func withSystemPrompt(config *AIProviderConfig, messages []chatMessage) []chatMessage {
+	if config.SystemPrompt == "" {
+		return messages
+	}
+	return append([]chatMessage{{Role: "system", Content: config.SystemPrompt}}, messages...)
+}
+
 func callAnthropic(config *AIProviderConfig, prompt string) (string, error) {
+	return callAnthropicMessages(config, []chatMessage{{Role: "user", Content: prompt}})
+}
+
+func callAnthropicMessages(config *AIProviderConfig, messages []chatMessage) (result string, err error) {
+	ctx, span := startAISpan(config, "ai.anthropic")
+	callStart := time.Now()
+	defer func() {
+		endSpan(span, err)
+		observeAIRequest(config, callStart, err)
+	}()
+
+	if err := waitForRateLimit(config); err != nil {
+		return "", err
+	}
+
 	reqBody := anthropicRequest{
 		Model:     config.Model,
-		MaxTokens: 200,
-		Messages: []anthropicMessage{
-			{Role: "user", Content: prompt},
-		},
+		MaxTokens: maxTokensOrDefault(config, 200),
+		System:    config.SystemPrompt,
+	}
+	// Left to Anthropic's default unless configured; its scale tops out at 1
+	if config.Temperature != nil {
+		temperature := math.Min(temperatureOrDefault(config), 1)
+		reqBody.Temperature = &temperature
+	}
+
+	// Anthropic requires the conversation to start with a user turn
+	for _, m := range messages {
+		if len(reqBody.Messages) == 0 && m.Role != "user" {
+			continue
+		}
+		reqBody.Messages = append(reqBody.Messages, anthropicMessage{Role: m.Role, Content: m.Content})
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -429,7 +700,7 @@ func callAnthropic(config *AIProviderConfig, prompt string) (string, error) {
 	}
 
 	url := strings.TrimSuffix(config.BaseURL, "/") + "/messages"
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return "", err
 	}
@@ -438,8 +709,9 @@ func callAnthropic(config *AIProviderConfig, prompt string) (string, error) {
 	req.Header.Set("x-api-key", config.APIKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	start := time.Now()
+	defer config.warnIfSlow(start)
+	resp, err := config.httpClient().Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -454,6 +726,7 @@ func callAnthropic(config *AIProviderConfig, prompt string) (string, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&anthropicResp); err != nil {
 		return "", err
 	}
+	recordTokens(span, anthropicResp.Usage.InputTokens, anthropicResp.Usage.OutputTokens)
 
 	if len(anthropicResp.Content) == 0 {
 		return "", fmt.Errorf("no response from Anthropic")
@@ -463,20 +736,49 @@ func callAnthropic(config *AIProviderConfig, prompt string) (string, error) {
 }
 
 func callGoogle(config *AIProviderConfig, prompt string) (string, error) {
+	return callGoogleMessages(config, []chatMessage{{Role: "user", Content: prompt}})
+}
+
+func callGoogleMessages(config *AIProviderConfig, messages []chatMessage) (result string, err error) {
+	ctx, span := startAISpan(config, "ai.google")
+	callStart := time.Now()
+	defer func() {
+		endSpan(span, err)
+		observeAIRequest(config, callStart, err)
+	}()
+
+	if err := waitForRateLimit(config); err != nil {
+		return "", err
+	}
+
 	reqBody := googleRequest{
-		Contents: []googleContent{
-			{
-				Parts: []googlePart{
-					{Text: prompt},
-				},
-			},
-		},
 		GenerationConfig: googleGenConfig{
-			MaxOutputTokens: 200,
-			Temperature:     0.3,
+			MaxOutputTokens: maxTokensOrDefault(config, 200),
+			Temperature:     temperatureOrDefault(config),
 		},
 	}
 
+	// generateContent takes only user and model turns, so the system prompt opens the
+	// conversation as a user turn the model has acknowledged
+	if config.SystemPrompt != "" {
+		reqBody.Contents = append(reqBody.Contents,
+			googleContent{Role: "user", Parts: []googlePart{{Text: config.SystemPrompt}}},
+			googleContent{Role: "model", Parts: []googlePart{{Text: "Understood."}}},
+		)
+	}
+
+	// Google names the assistant role "model"
+	for _, m := range messages {
+		role := m.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		reqBody.Contents = append(reqBody.Contents, googleContent{
+			Role:  role,
+			Parts: []googlePart{{Text: m.Content}},
+		})
+	}
+
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return "", err
@@ -487,15 +789,16 @@ func callGoogle(config *AIProviderConfig, prompt string) (string, error) {
 		config.Model,
 		config.APIKey,
 	)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return "", err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	start := time.Now()
+	defer config.warnIfSlow(start)
+	resp, err := config.httpClient().Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -510,6 +813,7 @@ func callGoogle(config *AIProviderConfig, prompt string) (string, error) {
 	if err := json.NewDecoder(resp.Body).Decode(&googleResp); err != nil {
 		return "", err
 	}
+	recordTokens(span, googleResp.UsageMetadata.PromptTokenCount, googleResp.UsageMetadata.CandidatesTokenCount)
 
 	if len(googleResp.Candidates) == 0 || len(googleResp.Candidates[0].Content.Parts) == 0 {
 		return "", fmt.Errorf("no response from Google")
@@ -529,17 +833,36 @@ type urlSummaryResult struct {
 	Summary string `json:"summary"`
 }
 
-// ProcessMemoryWithProvider analyzes memory content and returns categorization + summary
+// categoryFromHistory returns the category the user's corrections give content, or nil
+// if the provider has to be asked
+func categoryFromHistory(content string, config *AIProviderConfig) *models.AIProcessedMemory {
+	category := config.CategoryHistory.GetCategoryFromHistory(config.UserID, content)
+	if category == nil {
+		return nil
+	}
+	slog.DebugContext(config.requestContext(), "AI skipping memory processing - category known from corrections", "category", *category)
+	return &models.AIProcessedMemory{Category: *category}
+}
+
+// ProcessMemoryWithProvider analyzes memory content and returns categorization + summary.
+// If the user has an active memory_categorization prompt template it is used instead of the built-in prompt.
 func ProcessMemoryWithProvider(content string, config *AIProviderConfig) (*models.AIProcessedMemory, error) {
 	if config == nil || config.BaseURL == "" || config.APIKey == "" || config.Model == "" {
-		log.Printf("[AI-Memory] Skipping - no valid config")
+		slog.Debug("AI skipping memory processing - no valid config")
 		return &models.AIProcessedMemory{
 			Summary:  "",
 			Category: "Uncategorized",
 		}, nil
 	}
+	if result := categoryFromHistory(content, config); result != nil {
+		return result, nil
+	}
 
-	log.Printf("[AI-Memory] Processing memory: %q", content)
+	ctx := config.requestContext()
+	slog.DebugContext(ctx, "AI processing memory", "content", content)
+
+	// Personal data is replaced with placeholders before it leaves the server
+	input, replacements := config.PIIScrubber.Scrub(content)
 
 	prompt := fmt.Sprintf(`You are a personal memory organizer. Analyze this note/memory and categorize it.
 
@@ -561,56 +884,37 @@ INSTRUCTIONS:
    - Uncategorized (if nothing else fits)
 
 Respond with ONLY valid JSON (no markdown, no code blocks):
-{"summary": "", "category": "Category Name"}`, content)
+{"summary": "", "category": "Category Name"}`, input)
 
-	var respContent string
-	var err error
-
-	switch config.ProviderType {
-	case models.ProviderTypeAnthropic:
-		respContent, err = callAnthropic(config, prompt)
-	case models.ProviderTypeGoogle:
-		respContent, err = callGoogle(config, prompt)
-	default:
-		respContent, err = callOpenAICompatible(config, prompt)
+	if custom, ok := config.Prompts.RenderActive(config.UserID, models.PromptTemplateMemoryCategorization, map[string]string{"Content": input}); ok {
+		prompt = custom
+	}
+	if config.DetectLanguage {
+		prompt = withLanguageHint(prompt, input)
 	}
 
+	respContent, err := callProviderForJSON(config, prompt, memoryResponseSchema, metrics.AIResponseMemory)
+	if errors.Is(err, ErrInvalidAIResponse) {
+		slog.WarnContext(ctx, "AI memory response invalid, falling back to defaults", "error", err)
+		return &models.AIProcessedMemory{Category: "Uncategorized", ProcessingFailed: true}, nil
+	}
 	if err != nil {
-		log.Printf("[AI-Memory] Error: %v", err)
+		slog.WarnContext(ctx, "AI memory processing failed", "error", err)
 		return &models.AIProcessedMemory{Category: "Uncategorized"}, err
 	}
 
-	log.Printf("[AI-Memory] Raw response: %s", respContent)
+	slog.DebugContext(ctx, "AI memory raw response", "response", string(respContent))
 
 	var result memoryAIResult
-	if err := json.Unmarshal([]byte(respContent), &result); err != nil {
-		// Try to extract JSON
-		start := strings.Index(respContent, "{")
-		end := strings.LastIndex(respContent, "}")
-		if start != -1 && end != -1 && end > start {
-			if err := json.Unmarshal([]byte(respContent[start:end+1]), &result); err != nil {
-				return &models.AIProcessedMemory{Category: "Uncategorized"}, err
-			}
-		} else {
-			return &models.AIProcessedMemory{Category: "Uncategorized"}, err
-		}
-	}
-
-	// Validate category
-	validCategories := map[string]bool{
-		"Websites": true, "Food": true, "Movies": true, "Books": true,
-		"Ideas": true, "Places": true, "Products": true, "People": true,
-		"Learnings": true, "Quotes": true, "Uncategorized": true,
-	}
-
-	if !validCategories[result.Category] {
-		result.Category = "Uncategorized"
+	if err := json.Unmarshal(respContent, &result); err != nil {
+		return &models.AIProcessedMemory{Category: "Uncategorized"}, err
 	}
 
-	log.Printf("[AI-Memory] Result - summary: %q, category: %s", result.Summary, result.Category)
+	slog.DebugContext(ctx, "AI memory result", "summary", result.Summary, "category", result.Category)
 
+	// Only the summary gets the real values back; the category is one of a fixed list
 	return &models.AIProcessedMemory{
-		Summary:  result.Summary,
+		Summary:  config.PIIScrubber.Restore(result.Summary, replacements),
 		Category: result.Category,
 	}, nil
 }
@@ -639,6 +943,8 @@ Respond with ONLY valid JSON:
 	var err error
 
 	switch config.ProviderType {
+	case models.ProviderTypeAssistant:
+		respContent, err = callAssistant(config, prompt)
 	case models.ProviderTypeAnthropic:
 		respContent, err = callAnthropic(config, prompt)
 	case models.ProviderTypeGoogle:
@@ -670,7 +976,8 @@ Respond with ONLY valid JSON:
 	}, nil
 }
 
-// GenerateWeeklyDigestWithProvider creates a summary of the week's memories
+// GenerateWeeklyDigestWithProvider creates a summary of the week's memories.
+// If the user has an active weekly_digest prompt template it is used instead of the built-in prompt.
 func GenerateWeeklyDigestWithProvider(memories []models.Memory, config *AIProviderConfig) (string, error) {
 	if config == nil || config.BaseURL == "" || config.APIKey == "" || config.Model == "" {
 		return "", fmt.Errorf("AI not configured")
@@ -702,10 +1009,16 @@ Create a brief, friendly weekly digest that:
 
 Keep the digest to 3-4 short paragraphs. Be specific and reference actual items.`, memoryList.String())
 
+	if custom, ok := config.Prompts.RenderActive(config.UserID, models.PromptTemplateWeeklyDigest, map[string]string{"Memories": memoryList.String()}); ok {
+		prompt = custom
+	}
+
 	var respContent string
 	var err error
 
 	switch config.ProviderType {
+	case models.ProviderTypeAssistant:
+		respContent, err = callAssistant(config, prompt)
 	case models.ProviderTypeAnthropic:
 		respContent, err = callAnthropic(config, prompt)
 	case models.ProviderTypeGoogle:
@@ -721,21 +1034,131 @@ Keep the digest to 3-4 short paragraphs. Be specific and reference actual items.
 	return strings.TrimSpace(respContent), nil
 }
 
-func parseAIResponse(originalTitle, content string) (*AIProcessedTodo, error) {
-	// Try to parse the JSON response
+// GenerateThreadTitleWithProvider produces a short title for a chat thread from its first message
+func GenerateThreadTitleWithProvider(message string, config *AIProviderConfig) (string, error) {
+	if config == nil || config.BaseURL == "" || config.APIKey == "" || config.Model == "" {
+		return "", fmt.Errorf("AI not configured")
+	}
+
+	// Titles only need the gist of the message
+	if len(message) > 1000 {
+		message = message[:1000]
+	}
+
+	prompt := fmt.Sprintf("Summarize this in 5 words: %s\n\nRespond with ONLY the summary, no quotes or punctuation.", message)
+
+	titleConfig := *config
+	titleConfig.MaxTokens = 10
+	titleConfig.TextResponse = true
+
+	var respContent string
+	var err error
+
+	switch config.ProviderType {
+	case models.ProviderTypeAssistant:
+		respContent, err = callAssistant(&titleConfig, prompt)
+	case models.ProviderTypeAnthropic:
+		respContent, err = callAnthropic(&titleConfig, prompt)
+	case models.ProviderTypeGoogle:
+		respContent, err = callGoogle(&titleConfig, prompt)
+	default:
+		respContent, err = callOpenAICompatible(&titleConfig, prompt)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Trim(strings.TrimSpace(respContent), "\"'."), nil
+}
+
+// GenerateMemoryTitle produces a display title for a long memory. The AI is asked for
+// at most models.MaxGeneratedTitleLength characters, and longer replies are cut to fit.
+func GenerateMemoryTitle(content string, config *AIProviderConfig) (string, error) {
+	if config == nil || config.BaseURL == "" || config.APIKey == "" || config.Model == "" {
+		return "", fmt.Errorf("AI not configured")
+	}
+
+	// The opening of a note is enough to title it
+	if runes := []rune(content); len(runes) > 2000 {
+		content = string(runes[:2000])
+	}
+
+	prompt := fmt.Sprintf(`Write a concise title for this note, at most %d characters.
+Respond with ONLY the title, no quotes or trailing punctuation.
+
+Note:
+%s`, models.MaxGeneratedTitleLength, content)
+
+	titleConfig := *config
+	titleConfig.MaxTokens = 30
+	titleConfig.TextResponse = true
+
+	var respContent string
+	var err error
+
+	switch config.ProviderType {
+	case models.ProviderTypeAssistant:
+		respContent, err = callAssistant(&titleConfig, prompt)
+	case models.ProviderTypeAnthropic:
+		respContent, err = callAnthropic(&titleConfig, prompt)
+	case models.ProviderTypeGoogle:
+		respContent, err = callGoogle(&titleConfig, prompt)
+	default:
+		respContent, err = callOpenAICompatible(&titleConfig, prompt)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	title := truncateMemoryTitle(strings.Trim(strings.TrimSpace(respContent), "\"'."))
+	if title == "" {
+		return "", fmt.Errorf("AI returned an empty title")
+	}
+	return title, nil
+}
+
+// truncateMemoryTitle keeps the first line of title and cuts it to
+// models.MaxGeneratedTitleLength characters, at a word boundary when there is one
+func truncateMemoryTitle(title string) string {
+	if i := strings.IndexByte(title, '\n'); i >= 0 {
+		title = title[:i]
+	}
+	title = strings.TrimSpace(title)
+
+	runes := []rune(title)
+	if len(runes) <= models.MaxGeneratedTitleLength {
+		return title
+	}
+	cut := string(runes[:models.MaxGeneratedTitleLength])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:-")
+}
+
+func maxTokensOrDefault(config *AIProviderConfig, fallback int) int {
+	if config.MaxTokens > 0 {
+		return min(config.MaxTokens, models.MaxResponseTokens)
+	}
+	return fallback
+}
+
+// temperatureOrDefault returns the configured temperature capped at
+// [0, models.MaxTemperature], or models.DefaultTemperature when unset
+func temperatureOrDefault(config *AIProviderConfig) float64 {
+	if config.Temperature == nil {
+		return models.DefaultTemperature
+	}
+	return math.Max(0, math.Min(*config.Temperature, models.MaxTemperature))
+}
+
+// parseAIResponse decodes a todo response that has already passed schema validation
+func parseAIResponse(originalTitle string, content []byte) (*AIProcessedTodo, error) {
 	var result aiResult
-	if err := json.Unmarshal([]byte(content), &result); err != nil {
-		// If JSON parsing fails, try to extract JSON from the response
-		start := strings.Index(content, "{")
-		end := strings.LastIndex(content, "}")
-		if start != -1 && end != -1 && end > start {
-			jsonStr := content[start : end+1]
-			if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-				return nil, err
-			}
-		} else {
-			return nil, fmt.Errorf("invalid AI response format")
-		}
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, err
 	}
 
 	// Validate and clean the result
@@ -782,14 +1205,13 @@ type WebSearchFunctionResult struct {
 	Category string `json:"category"`
 }
 
-// callOpenAIWithTools makes an API call with function calling enabled
+// callOpenAIWithTools makes an API call with function calling enabled, asking the
+// AI to process content as a memory
 func callOpenAIWithTools(config *AIProviderConfig, content string, tools []Tool) (*chatResponseWithTools, error) {
-	reqBody := chatRequestWithTools{
-		Model: config.Model,
-		Messages: []chatMessage{
-			{
-				Role: "user",
-				Content: fmt.Sprintf(`Analyze this memory/note and take the appropriate action.
+	return callOpenAIWithToolMessages(config, []chatMessage{
+		{
+			Role: "user",
+			Content: fmt.Sprintf(`Analyze this memory/note and take the appropriate action.
 
 Content: "%s"
 
@@ -799,12 +1221,26 @@ Instructions:
 3. Otherwise, use categorize_memory to categorize the note with a summary and category.
 
 Choose the most appropriate function based on the content.`, content),
-			},
 		},
+	}, tools)
+}
+
+// callOpenAIWithToolMessages sends messages with tools the AI may call
+func callOpenAIWithToolMessages(config *AIProviderConfig, messages []chatMessage, tools []Tool) (result *chatResponseWithTools, err error) {
+	callStart := time.Now()
+	defer func() { observeAIRequest(config, callStart, err) }()
+
+	if err := waitForRateLimit(config); err != nil {
+		return nil, err
+	}
+
+	reqBody := chatRequestWithTools{
+		Model:       config.Model,
+		Messages:    withSystemPrompt(config, messages),
 		Tools:       tools,
 		ToolChoice:  "auto",
-		MaxTokens:   500,
-		Temperature: 0.3,
+		MaxTokens:   maxTokensOrDefault(config, models.DefaultMaxTokens),
+		Temperature: temperatureOrDefault(config),
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -813,28 +1249,28 @@ Choose the most appropriate function based on the content.`, content),
 	}
 
 	url := strings.TrimSuffix(config.BaseURL, "/") + "/chat/completions"
-	log.Printf("[AI-FunctionCall] >>> Request URL: %s", url)
-	log.Printf("[AI-FunctionCall] >>> Request body: %s", string(jsonBody))
+	ctx := config.requestContext()
+	slog.DebugContext(ctx, "AI tool request", "url", url, "body", string(jsonBody))
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+config.APIKey)
+	config.setAuthHeader(req)
 
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	start := time.Now()
+	defer config.warnIfSlow(start)
+	resp, err := config.httpClient().Do(req)
 	if err != nil {
-		log.Printf("[AI-FunctionCall] !!! HTTP error: %v", err)
+		slog.WarnContext(ctx, "AI tool request failed", "url", url, "error", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
-	log.Printf("[AI-FunctionCall] <<< Response status: %d", resp.StatusCode)
-	log.Printf("[AI-FunctionCall] <<< Response body: %s", string(body))
+	slog.DebugContext(ctx, "AI tool response", "status", resp.StatusCode, "body", string(body))
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("AI API error: %s - %s", resp.Status, string(body))
@@ -842,7 +1278,7 @@ Choose the most appropriate function based on the content.`, content),
 
 	var chatResp chatResponseWithTools
 	if err := json.Unmarshal(body, &chatResp); err != nil {
-		log.Printf("[AI-FunctionCall] !!! JSON decode error: %v", err)
+		slog.WarnContext(ctx, "AI tool response could not be decoded", "error", err)
 		return nil, err
 	}
 
@@ -851,26 +1287,47 @@ Choose the most appropriate function based on the content.`, content),
 
 // ProcessMemoryWithFunctionCalling uses OpenAI-compatible function calling for a 2-step AI process
 // Step 1: AI analyzes content, returns category/summary and detects URLs
-// Step 2: If URL detected, scrape and summarize with scraped content
-func ProcessMemoryWithFunctionCalling(content string, config *AIProviderConfig, scraper *ScraperService) (*models.AIProcessedMemory, *models.URLSummary, error) {
+// Step 2: If URL detected, scrape and summarize with scraped content. scrapeMode can
+// limit this to the page's metadata (models.ScrapeModeMetadataOnly), with no second AI
+// call, or skip it (models.ScrapeModeNone).
+func ProcessMemoryWithFunctionCalling(content string, config *AIProviderConfig, scraper *ScraperService, scrapeMode string) (*models.AIProcessedMemory, *models.URLSummary, error) {
 	if config == nil || config.BaseURL == "" || config.APIKey == "" || config.Model == "" {
-		log.Printf("[AI-FunctionCall] Skipping - no valid config")
+		slog.Debug("AI skipping memory function calling - no valid config")
 		return &models.AIProcessedMemory{Category: "Uncategorized"}, nil, nil
 	}
+	// Memories with a link still go to the provider, which also summarizes the page
+	if !strings.Contains(content, "http://") && !strings.Contains(content, "https://") {
+		if result := categoryFromHistory(content, config); result != nil {
+			return result, nil, nil
+		}
+	}
+
+	// Assistants don't expose chat-completions tool calling, and a user's own
+	// categorization prompt replaces the tool-calling one
+	if config.ProviderType == models.ProviderTypeAssistant || config.Prompts.HasActive(config.UserID, models.PromptTemplateMemoryCategorization) {
+		result, err := ProcessMemoryWithProvider(content, config)
+		return result, nil, err
+	}
 
-	log.Printf("[AI-FunctionCall] Processing memory with function calling: %q", content)
+	ctx := config.requestContext()
+	slog.DebugContext(ctx, "AI processing memory with function calling", "content", content)
 
-	// Step 1: Call AI with function calling to get category and detect URL
-	resp, err := callOpenAIWithTools(config, content, memoryProcessingTools)
+	// Step 1: Call AI with function calling to get category and detect URL. Personal
+	// data is replaced with placeholders before it leaves the server.
+	toolInput, replacements := config.PIIScrubber.Scrub(content)
+	if config.DetectLanguage {
+		toolInput = withLanguageHint(toolInput, toolInput)
+	}
+	resp, err := callOpenAIWithTools(config, toolInput, memoryProcessingTools)
 	if err != nil {
-		log.Printf("[AI-FunctionCall] Error: %v", err)
-		// Fall back to regular processing
-		fallback, _ := ProcessMemoryWithProvider(content, config)
-		return fallback, nil, nil
+		slog.WarnContext(ctx, "AI function calling failed, falling back to regular processing", "error", err)
+		// Fall back to regular processing; if the provider fails that too, report it
+		fallback, err := ProcessMemoryWithProvider(content, config)
+		return fallback, nil, err
 	}
 
 	if len(resp.Choices) == 0 {
-		log.Printf("[AI-FunctionCall] No choices in response")
+		slog.WarnContext(ctx, "AI function calling response has no choices")
 		return &models.AIProcessedMemory{Category: "Uncategorized"}, nil, nil
 	}
 
@@ -878,7 +1335,7 @@ func ProcessMemoryWithFunctionCalling(content string, config *AIProviderConfig,
 
 	// Check if we got tool calls
 	if len(choice.Message.ToolCalls) == 0 {
-		log.Printf("[AI-FunctionCall] No tool calls, falling back to regular processing")
+		slog.DebugContext(ctx, "AI made no tool calls, falling back to regular processing")
 		fallback, _ := ProcessMemoryWithProvider(content, config)
 		return fallback, nil, nil
 	}
@@ -897,25 +1354,31 @@ func ProcessMemoryWithFunctionCalling(content string, config *AIProviderConfig,
 	for _, toolCall := range choice.Message.ToolCalls {
 		switch toolCall.Function.Name {
 		case "categorize_memory":
-			log.Printf("[AI-FunctionCall] Got categorize_memory call: %s", toolCall.Function.Arguments)
+			slog.DebugContext(ctx, "AI called categorize_memory", "arguments", toolCall.Function.Arguments)
+			if err := ValidateAIResponse(memoryToolArgsSchema, []byte(toolCall.Function.Arguments)); err != nil {
+				slog.WarnContext(ctx, "AI categorize_memory arguments invalid, falling back to regular processing", "error", err)
+				metrics.AIInvalidResponseTotal.WithLabelValues(metrics.AIResponseMemoryTool).Inc()
+				fallback, _ := ProcessMemoryWithProvider(content, config)
+				return fallback, nil, nil
+			}
+
 			var result FunctionCallResult
 			if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &result); err != nil {
-				log.Printf("[AI-FunctionCall] Failed to parse function arguments: %v", err)
+				slog.WarnContext(ctx, "AI categorize_memory arguments could not be parsed", "error", err)
 				continue
 			}
 
-			if !validCategories[result.Category] {
-				result.Category = "Uncategorized"
-			}
-
 			memoryResult = &models.AIProcessedMemory{
-				Summary:  result.Summary,
+				Summary:  config.PIIScrubber.Restore(result.Summary, replacements),
 				Category: result.Category,
 			}
 
 			// Step 2: If URL was detected and we have a scraper, scrape and summarize
-			if result.HasURL && result.URL != "" && scraper != nil {
-				log.Printf("[AI-FunctionCall] Step 2: Scraping detected URL: %s", result.URL)
+			if result.HasURL && result.URL != "" && scraper != nil && scrapeMode == models.ScrapeModeMetadataOnly {
+				slog.DebugContext(ctx, "AI fetching metadata of detected URL", "url", result.URL)
+				urlSummary = scraper.MetadataSummary(result.URL)
+			} else if result.HasURL && result.URL != "" && scraper != nil && scrapeMode != models.ScrapeModeNone {
+				slog.DebugContext(ctx, "AI scraping detected URL", "url", result.URL)
 
 				scraped, err := scraper.ScrapeURL(result.URL)
 				if err == nil && scraped != nil && scraped.Content != "" {
@@ -926,17 +1389,18 @@ func ProcessMemoryWithFunctionCalling(content string, config *AIProviderConfig,
 					} else if urlSummary.Title == "" {
 						urlSummary.Title = scraped.Title
 					}
+					urlSummary.ThumbnailURL = scraped.ImageURL
 				}
 			}
 
-			log.Printf("[AI-FunctionCall] Result - summary: %q, category: %s, hasURL: %v",
-				result.Summary, result.Category, result.HasURL)
+			slog.DebugContext(ctx, "AI memory function calling result",
+				"summary", result.Summary, "category", result.Category, "has_url", result.HasURL)
 
 		case "web_search":
-			log.Printf("[AI-FunctionCall] Got web_search call: %s", toolCall.Function.Arguments)
+			slog.DebugContext(ctx, "AI called web_search", "arguments", toolCall.Function.Arguments)
 			var searchArgs WebSearchFunctionResult
 			if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &searchArgs); err != nil {
-				log.Printf("[AI-FunctionCall] Failed to parse web_search arguments: %v", err)
+				slog.WarnContext(ctx, "AI web_search arguments could not be parsed", "error", err)
 				continue
 			}
 
@@ -950,10 +1414,10 @@ func ProcessMemoryWithFunctionCalling(content string, config *AIProviderConfig,
 
 			// Execute web search via SearXNG
 			if scraper != nil {
-				log.Printf("[AI-FunctionCall] Executing web search for: %s", searchArgs.Query)
+				slog.DebugContext(ctx, "AI executing web search", "query", searchArgs.Query)
 				webResults, err := scraper.SearchWeb(searchArgs.Query)
 				if err != nil {
-					log.Printf("[AI-FunctionCall] Web search error: %v", err)
+					slog.WarnContext(ctx, "AI web search failed", "query", searchArgs.Query, "error", err)
 				} else if len(webResults) > 0 {
 					// Format search results
 					var sb strings.Builder
@@ -972,7 +1436,7 @@ func ProcessMemoryWithFunctionCalling(content string, config *AIProviderConfig,
 %s`, searchArgs.Query, rawResults)
 					summary, err := callOpenAICompatible(config, summaryPrompt)
 					if err != nil {
-						log.Printf("[AI-FunctionCall] Failed to summarize search results: %v", err)
+						slog.WarnContext(ctx, "AI failed to summarize search results", "error", err)
 						summary = rawResults[:min(500, len(rawResults))]
 					}
 					memoryResult.Summary = summary
@@ -983,14 +1447,14 @@ func ProcessMemoryWithFunctionCalling(content string, config *AIProviderConfig,
 						Summary: rawResults,
 					}
 
-					log.Printf("[AI-FunctionCall] Web search completed - found %d results, summary: %q",
-						len(webResults), summary[:min(100, len(summary))])
+					slog.DebugContext(ctx, "AI web search complete",
+						"results", len(webResults), "summary", truncateString(summary, 100))
 				} else {
-					log.Printf("[AI-FunctionCall] Web search returned no results")
+					slog.DebugContext(ctx, "AI web search returned no results", "query", searchArgs.Query)
 					memoryResult.Summary = fmt.Sprintf("No search results found for '%s'", searchArgs.Query)
 				}
 			} else {
-				log.Printf("[AI-FunctionCall] Web search requested but scraper service not available")
+				slog.WarnContext(ctx, "AI requested web search but the scraper service is not available")
 				memoryResult.Summary = "Web search is not configured"
 			}
 		}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+// streamChunk is one server-sent event of an OpenAI-compatible streaming response
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+// callOpenAICompatibleStream sends messages with "stream": true and passes each content
+// delta to config.OnToken as it arrives. It returns the assembled reply; if the stream
+// breaks off (e.g. the request context is cancelled) the text received so far is
+// returned along with the error.
+func callOpenAICompatibleStream(config *AIProviderConfig, messages []chatMessage) (result string, err error) {
+	ctx, span := startAISpan(config, "ai.openai_compatible.stream")
+	callStart := time.Now()
+	defer func() {
+		endSpan(span, err)
+		observeAIRequest(config, callStart, err)
+	}()
+
+	if err := waitForRateLimit(config); err != nil {
+		return "", err
+	}
+
+	reqBody := chatRequest{
+		Model:       config.Model,
+		Messages:    withSystemPrompt(config, messages),
+		MaxTokens:   maxTokensOrDefault(config, models.DefaultMaxTokens),
+		Temperature: temperatureOrDefault(config),
+		Stream:      true,
+		Thinking:    &thinkingConfig{Type: "disabled"},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	url := strings.TrimSuffix(config.BaseURL, "/") + "/chat/completions"
+	log.Printf("[AI-HTTP] >>> Stream request URL: %s", url)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	config.setAuthHeader(req)
+
+	resp, err := config.httpClient().Do(req)
+	if err != nil {
+		log.Printf("[AI-HTTP] !!! HTTP error: %v", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("AI API error: %s - %s", resp.Status, string(body))
+	}
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			log.Printf("[AI-HTTP] !!! Skipping malformed stream chunk: %v", err)
+			continue
+		}
+		if chunk.Usage != nil {
+			recordTokens(span, chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if config.OnToken != nil {
+				config.OnToken(choice.Delta.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return content.String(), fmt.Errorf("AI stream interrupted: %w", err)
+	}
+	// A cancelled request can also surface as a clean EOF
+	if err := ctx.Err(); err != nil {
+		return content.String(), fmt.Errorf("AI stream interrupted: %w", err)
+	}
+
+	if strings.TrimSpace(content.String()) == "" {
+		return "", fmt.Errorf("no content in AI response")
+	}
+	return content.String(), nil
+}
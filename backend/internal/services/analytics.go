@@ -0,0 +1,174 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+const (
+	// KeywordCacheTTL is how long a user's keyword cloud is reused. The cache key
+	// includes the memory count, so adding or deleting a memory recomputes it.
+	KeywordCacheTTL = time.Hour
+	// DefaultKeywordLimit and MaxKeywordLimit bound the keywords returned
+	DefaultKeywordLimit = 50
+	MaxKeywordLimit     = 200
+	// DefaultKeywordMinFrequency is how often a word must occur to be a keyword
+	DefaultKeywordMinFrequency = 2
+	// minKeywordLength drops one-letter tokens
+	minKeywordLength = 2
+	// keywordCacheMaxEntries bounds the cache; expired entries are swept when it fills
+	keywordCacheMaxEntries = 1000
+)
+
+// keywordStopwords are common English words that say nothing about a memory
+var keywordStopwords = makeStopwordSet(`a about above after again against all also am an and any are aren't as at
+be because been before being below between both but by can can't cannot could couldn't did didn't do does doesn't
+doing don't down during each even ever every few for from further get gets got had hadn't has hasn't have haven't
+having he he'd he'll he's her here here's hers herself him himself his how how's however i i'd i'll i'm i've if in
+into is isn't it it's its itself just let's like make many may me might more most much must mustn't my myself need
+no nor not now of off often on once one only or other ought our ours ourselves out over own really same say says
+shan't she she'd she'll she's should shouldn't since so some still such than that that's the their theirs them
+themselves then there there's these they they'd they'll they're they've this those though through thus to too
+under until up upon us use used using very via was wasn't we we'd we'll we're we've were weren't what what's when
+when's where where's whether which while who who's whom whose why why's will with within without won't would
+wouldn't yet you you'd you'll you're you've your yours yourself yourselves http https www com`)
+
+func makeStopwordSet(words string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, word := range strings.Fields(words) {
+		set[word] = struct{}{}
+	}
+	return set
+}
+
+// ComputeKeywordTFIDF ranks the words of the memories by TF-IDF, summed over the
+// memories each word occurs in, and returns the top limit of those occurring at least
+// minFreq times in all. Stopwords and numbers are skipped.
+func ComputeKeywordTFIDF(memories []models.Memory, limit int, minFreq int) []models.KeywordScore {
+	type termStats struct {
+		frequency int
+		documents int
+		tfSum     float64 // sum of the word's term frequency in each memory
+	}
+
+	terms := make(map[string]*termStats)
+	documents := 0
+	for _, memory := range memories {
+		tokens := tokenizeKeywords(memory.Content)
+		if len(tokens) == 0 {
+			continue
+		}
+		documents++
+
+		counts := make(map[string]int)
+		for _, token := range tokens {
+			counts[token]++
+		}
+		for term, count := range counts {
+			stats, ok := terms[term]
+			if !ok {
+				stats = &termStats{}
+				terms[term] = stats
+			}
+			stats.frequency += count
+			stats.documents++
+			stats.tfSum += float64(count) / float64(len(tokens))
+		}
+	}
+
+	keywords := make([]models.KeywordScore, 0, len(terms))
+	for term, stats := range terms {
+		if stats.frequency < minFreq {
+			continue
+		}
+		// Smoothed, so a word in every memory still scores above zero
+		idf := math.Log(float64(1+documents)/float64(1+stats.documents)) + 1
+		keywords = append(keywords, models.KeywordScore{
+			Keyword:       term,
+			Score:         math.Round(stats.tfSum*idf*10000) / 10000,
+			Frequency:     stats.frequency,
+			DocumentCount: stats.documents,
+		})
+	}
+
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Score != keywords[j].Score {
+			return keywords[i].Score > keywords[j].Score
+		}
+		return keywords[i].Keyword < keywords[j].Keyword
+	})
+	if limit > 0 && len(keywords) > limit {
+		keywords = keywords[:limit]
+	}
+	return keywords
+}
+
+// tokenizeKeywords lowercases text and splits it on whitespace and punctuation, keeping
+// apostrophes inside words so contractions match the stopword list
+func tokenizeKeywords(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		token := strings.Trim(strings.ReplaceAll(field, "’", "'"), "'")
+		if _, stop := keywordStopwords[token]; stop {
+			continue
+		}
+		// Possessives count as the word itself
+		token = strings.TrimSuffix(token, "'s")
+		if _, stop := keywordStopwords[token]; stop || utf8.RuneCountInString(token) < minKeywordLength || isNumber(token) {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+func isNumber(token string) bool {
+	for _, r := range token {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetKeywords returns the keywords of the user's unarchived memories for a tag cloud
+func (s *MemoryService) GetKeywords(userID string, limit, minFreq int) ([]models.KeywordScore, error) {
+	if limit <= 0 {
+		limit = DefaultKeywordLimit
+	}
+	if limit > MaxKeywordLimit {
+		limit = MaxKeywordLimit
+	}
+	if minFreq <= 0 {
+		minFreq = DefaultKeywordMinFrequency
+	}
+
+	count, err := s.memoryRepo.CountUnarchivedByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("keywords:%s:%d:%d:%d", userID, count, limit, minFreq)
+	if keywords, ok := s.keywordCache.Get(key); ok {
+		return keywords, nil
+	}
+
+	memories, err := s.memoryRepo.GetUnarchivedContents(userID)
+	if err != nil {
+		return nil, err
+	}
+	keywords := ComputeKeywordTFIDF(memories, limit, minFreq)
+
+	s.keywordCache.Set(key, keywords)
+	return keywords, nil
+}
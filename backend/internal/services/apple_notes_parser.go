@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// appleNotesChecklist maps the private-use characters Apple Notes exports checklist
+// items with to the checkbox symbols they stand for
+var appleNotesChecklist = strings.NewReplacer("\uf702", "☑", "\uf700", "☐")
+
+// htmlLineBreaks turns line breaks in the HTML source into spaces, since only block
+// elements break lines when rendered
+var htmlLineBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// parseAppleNote extracts a note exported from Apple Notes as HTML. The <title> is the
+// heading, falling back to filename, and the text of <div class="note-body">, or of the
+// <body> when there is none, is the content. Each note is a single section.
+func parseAppleNote(filename string, content []byte) ([]ParsedMemorySection, error) {
+	doc, err := html.Parse(bytes.NewReader(content))
+	if err != nil {
+		return nil, &FileUploadError{
+			Code:    "parse_error",
+			Message: "Failed to parse HTML note",
+		}
+	}
+
+	var title, noteBody, body *html.Node
+	var find func(n *html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case n.Data == "title" && title == nil:
+				title = n
+			case n.Data == "body" && body == nil:
+				body = n
+			case n.Data == "div" && noteBody == nil && hasClass(n, "note-body"):
+				noteBody = n
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+	find(doc)
+
+	heading := filename
+	if title != nil {
+		if text := strings.Join(strings.Fields(appleNotesChecklist.Replace(nodeText(title))), " "); text != "" {
+			heading = text
+		}
+	}
+
+	root := noteBody
+	if root == nil {
+		root = body
+	}
+	text := ""
+	if root != nil {
+		text = appleNotesChecklist.Replace(htmlNoteText(root))
+	}
+	if text == "" {
+		return nil, &FileUploadError{
+			Code:    "empty_file",
+			Message: "Note is empty",
+		}
+	}
+
+	return []ParsedMemorySection{
+		{
+			Content: text,
+			Heading: heading,
+			Order:   0,
+			Source:  SectionSourceAppleNotes,
+		},
+	}, nil
+}
+
+// htmlNoteText returns the text of n with one line per block element. Apple Notes
+// writes every line of a note as its own <div>, and blank lines as <div><br></div>.
+func htmlNoteText(n *html.Node) string {
+	var sb strings.Builder
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "head", "title":
+				return
+			case "li":
+				sb.WriteString("- ")
+			}
+		}
+
+		if n.Type == html.TextNode {
+			sb.WriteString(htmlLineBreaks.Replace(n.Data))
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "li", "blockquote", "br", "tr":
+				sb.WriteString("\n")
+			}
+		}
+	}
+	walk(n)
+
+	// Collapse whitespace in each line and keep at most one blank line between paragraphs
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(sb.String(), "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, attr := range n.Attr {
+		if attr.Key == "class" {
+			for _, c := range strings.Fields(attr.Val) {
+				if c == class {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// AttachmentURLExpiry is how long a pre-signed attachment URL stays valid
+const AttachmentURLExpiry = time.Hour
+
+var ErrAttachmentNotFound = newCodedError(models.ErrCodeNotFound, "attachment not found")
+
+type AttachmentService struct {
+	repo       *repository.AttachmentRepository
+	memoryRepo *repository.MemoryRepository
+	storage    StorageService
+}
+
+// NewAttachmentService creates the service; a nil storage leaves attachments disabled
+func NewAttachmentService(repo *repository.AttachmentRepository, memoryRepo *repository.MemoryRepository, storage StorageService) *AttachmentService {
+	return &AttachmentService{
+		repo:       repo,
+		memoryRepo: memoryRepo,
+		storage:    storage,
+	}
+}
+
+func (s *AttachmentService) IsConfigured() bool {
+	return s != nil && s.storage != nil
+}
+
+// Upload stores the file and returns its storage key, to be linked to a memory with Link
+func (s *AttachmentService) Upload(ctx context.Context, userID, mimeType string, data []byte) (string, error) {
+	key := fmt.Sprintf("memories/%s/%s", userID, uuid.New().String())
+	if err := s.storage.Put(ctx, key, mimeType, data); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Link records an uploaded file as an attachment of a memory
+func (s *AttachmentService) Link(memoryID, storageKey, mimeType string, sizeBytes int64) (*models.Attachment, error) {
+	attachment := &models.Attachment{
+		MemoryID:   memoryID,
+		StorageKey: storageKey,
+		MimeType:   mimeType,
+		SizeBytes:  sizeBytes,
+	}
+	if err := s.repo.Create(attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// GetURL returns a pre-signed download URL for one of the user's memory attachments
+func (s *AttachmentService) GetURL(ctx context.Context, userID, memoryID, attachmentID string) (string, time.Time, error) {
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if memory == nil || memory.UserID != userID {
+		return "", time.Time{}, ErrAttachmentNotFound
+	}
+
+	attachment, err := s.repo.GetByID(attachmentID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if attachment == nil || attachment.MemoryID != memoryID {
+		return "", time.Time{}, ErrAttachmentNotFound
+	}
+
+	expiresAt := time.Now().Add(AttachmentURLExpiry)
+	url, err := s.storage.PresignedURL(ctx, attachment.StorageKey, AttachmentURLExpiry)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return url, expiresAt, nil
+}
+
+// MemoryAttachmentKeys returns the storage keys of the memories' attachments, to be
+// passed to DeleteObjects once the memories are deleted. It returns none when
+// attachments are disabled.
+func (s *AttachmentService) MemoryAttachmentKeys(memoryIDs ...string) ([]string, error) {
+	if !s.IsConfigured() {
+		return nil, nil
+	}
+	return s.repo.GetStorageKeysByMemoryIDs(memoryIDs)
+}
+
+// UserAttachmentKeys returns the storage keys of the attachments of all the user's
+// memories, to be passed to DeleteObjects once the account is deleted
+func (s *AttachmentService) UserAttachmentKeys(userID string) ([]string, error) {
+	if !s.IsConfigured() {
+		return nil, nil
+	}
+	return s.repo.GetStorageKeysByUserID(userID)
+}
+
+// DeleteObjects removes stored files, e.g. those of deleted memories or an upload
+// whose memory couldn't be created. Failures are logged rather than returned, since
+// the rows referencing the files are already gone.
+func (s *AttachmentService) DeleteObjects(ctx context.Context, keys ...string) {
+	if !s.IsConfigured() {
+		return
+	}
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("[AttachmentService] Failed to delete stored object %s: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memoryStorage is a StorageService keeping objects in memory
+type memoryStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{objects: make(map[string][]byte)}
+}
+
+func (s *memoryStorage) Put(_ context.Context, key, _ string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+func (s *memoryStorage) PresignedURL(_ context.Context, key string, _ time.Duration) (string, error) {
+	return "https://storage.example.com/" + key, nil
+}
+
+func (s *memoryStorage) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *memoryStorage) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.objects[key]
+	return ok
+}
+
+// newAttachedMemory creates a memory for the user with an uploaded attachment and
+// returns the memory and the attachment's storage key
+func newAttachedMemory(t *testing.T, db *sql.DB, attachments *AttachmentService, userID, category string) (*models.Memory, string) {
+	t.Helper()
+	memory := &models.Memory{UserID: userID, Content: "Photo of a whiteboard", Category: category}
+	if err := repository.NewMemoryRepository(db).Create(memory); err != nil {
+		t.Fatalf("failed to create memory: %v", err)
+	}
+	key, err := attachments.Upload(context.Background(), userID, "image/png", []byte("png"))
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if _, err := attachments.Link(memory.ID, key, "image/png", 3); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	return memory, key
+}
+
+func TestDeletingMemoriesDeletesAttachmentObjects(t *testing.T) {
+	tests := []struct {
+		name   string
+		delete func(t *testing.T, memories *MemoryService, userData *UserDataService, user *models.User, memory *models.Memory) error
+	}{
+		{"memory delete", func(t *testing.T, memories *MemoryService, _ *UserDataService, user *models.User, memory *models.Memory) error {
+			return memories.Delete(user.ID, memory.ID, "")
+		}},
+		{"bulk delete", func(t *testing.T, memories *MemoryService, _ *UserDataService, user *models.User, _ *models.Memory) error {
+			_, err := memories.BulkDelete(user.ID, models.BulkDeleteFilter{Category: "Ideas"}, "")
+			return err
+		}},
+		{"clear memories", func(t *testing.T, _ *MemoryService, userData *UserDataService, user *models.User, _ *models.Memory) error {
+			_, err := userData.ClearAllMemories(user.ID)
+			return err
+		}},
+		{"clear all data", func(t *testing.T, _ *MemoryService, userData *UserDataService, user *models.User, _ *models.Memory) error {
+			_, err := userData.ClearAllData(user.ID, "")
+			return err
+		}},
+		{"account deletion", func(t *testing.T, _ *MemoryService, userData *UserDataService, user *models.User, _ *models.Memory) error {
+			return userData.DeleteAccount(user.ID, "correct horse", time.Time{})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			userRepo := repository.NewUserRepository(db)
+			hash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+			if err != nil {
+				t.Fatal(err)
+			}
+			passwordHash := string(hash)
+			user := &models.User{Email: "owner@example.com", PasswordHash: &passwordHash}
+			if err := userRepo.Create(user); err != nil {
+				t.Fatalf("failed to create user: %v", err)
+			}
+			other := newTestUser(t, db, "other@example.com")
+
+			storage := newMemoryStorage()
+			memoryRepo := repository.NewMemoryRepository(db)
+			attachments := NewAttachmentService(repository.NewAttachmentRepository(db), memoryRepo, storage)
+			memories := NewMemoryService(memoryRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, attachments)
+			userData := NewUserDataService(userRepo, memoryRepo, repository.NewTodoRepository(db), repository.NewGroupRepository(db), nil, nil, nil, nil, &SupabaseAuthService{}, nil, attachments)
+
+			memory, key := newAttachedMemory(t, db, attachments, user.ID, "Ideas")
+			_, otherKey := newAttachedMemory(t, db, attachments, other.ID, "Ideas")
+
+			if err := tt.delete(t, memories, userData, user, memory); err != nil {
+				t.Fatalf("delete failed: %v", err)
+			}
+			if storage.has(key) {
+				t.Error("the deleted memory's attachment is still stored")
+			}
+			if !storage.has(otherKey) {
+				t.Error("another user's attachment was deleted")
+			}
+		})
+	}
+}
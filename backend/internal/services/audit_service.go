@@ -0,0 +1,76 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// AuditRetention is how long audit log entries are kept
+const AuditRetention = 90 * 24 * time.Hour
+
+// AuditService records sensitive operations per user
+type AuditService struct {
+	repo *repository.AuditRepository
+}
+
+// NewAuditService creates a new audit service and starts the nightly purge job
+func NewAuditService(repo *repository.AuditRepository) *AuditService {
+	service := &AuditService{repo: repo}
+
+	// Start purge goroutine to remove entries past the retention window
+	go service.purgeOldEntries()
+
+	return service
+}
+
+// Log records an action for a user. Safe to call on a nil service.
+func (s *AuditService) Log(userID, action string, metadata map[string]string, ip string) error {
+	if s == nil {
+		return nil
+	}
+
+	entry := &models.AuditLogEntry{
+		UserID:   userID,
+		Action:   action,
+		Metadata: metadata,
+	}
+	if ip != "" {
+		entry.IPAddress = &ip
+	}
+
+	if err := s.repo.Create(entry); err != nil {
+		log.Printf("[AuditService] Failed to log %s for user %s: %v", action, userID, err)
+		return err
+	}
+	return nil
+}
+
+// GetByUserID returns the user's own audit log, newest first
+func (s *AuditService) GetByUserID(userID string, limit, offset int) ([]models.AuditLogEntry, error) {
+	return s.repo.GetByUserID(userID, limit, offset)
+}
+
+// GetByActions returns the latest entries of any user with one of the actions, newest first
+func (s *AuditService) GetByActions(actions []string, limit int) ([]models.AuditLogEntry, error) {
+	return s.repo.GetByActions(actions, limit)
+}
+
+// purgeOldEntries deletes entries older than the retention window once a day
+func (s *AuditService) purgeOldEntries() {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		deleted, err := s.repo.DeleteOlderThan(time.Now().Add(-AuditRetention))
+		if err != nil {
+			log.Printf("[AuditService] Failed to purge old entries: %v", err)
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("[AuditService] Purged %d audit entries older than 90 days", deleted)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/repository"
 	"golang.org/x/crypto/bcrypt"
@@ -15,9 +16,22 @@ var (
 	ErrEmailExists        = errors.New("email already exists")
 )
 
+// AppTokenIssuer is the iss of tokens the app signs itself, telling them apart from
+// Supabase tokens
+const AppTokenIssuer = "todomyday"
+
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	// SessionID identifies the login the token was issued for, like a Supabase session_id
+	SessionID string `json:"session_id"`
+	// ImpersonatedBy is set on tokens issued to an admin acting as the user, with the
+	// reason they gave
+	ImpersonatedBy      string `json:"impersonated_by,omitempty"`
+	ImpersonationReason string `json:"impersonation_reason,omitempty"`
+	// AuthTime is when the user last authenticated at the OIDC provider, which can be
+	// earlier than the token's iat when the provider signed them in from its own session
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -62,7 +76,7 @@ func (s *AuthService) Register(req *models.RegisterRequest) (*models.User, strin
 	}
 
 	// Generate token
-	token, err := s.generateToken(user)
+	token, err := s.generateToken(user, time.Now())
 	if err != nil {
 		return nil, "", err
 	}
@@ -88,7 +102,7 @@ func (s *AuthService) Login(req *models.LoginRequest) (*models.User, string, err
 	}
 
 	// Generate token
-	token, err := s.generateToken(user)
+	token, err := s.generateToken(user, time.Now())
 	if err != nil {
 		return nil, "", err
 	}
@@ -99,7 +113,7 @@ func (s *AuthService) Login(req *models.LoginRequest) (*models.User, string, err
 func (s *AuthService) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return s.jwtSecret, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(AppTokenIssuer), jwt.WithExpirationRequired())
 
 	if err != nil {
 		return nil, err
@@ -116,11 +130,21 @@ func (s *AuthService) GetCurrentUser(userID string) (*models.User, error) {
 	return s.userRepo.GetByID(userID)
 }
 
-func (s *AuthService) generateToken(user *models.User) (string, error) {
+// IssueToken signs an app token for the user, starting a new session. authTime is
+// when they authenticated, which account deletion checks for SSO users.
+func (s *AuthService) IssueToken(user *models.User, authTime time.Time) (string, error) {
+	return s.generateToken(user, authTime)
+}
+
+func (s *AuthService) generateToken(user *models.User, authTime time.Time) (string, error) {
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
+		UserID:    user.ID,
+		Email:     user.Email,
+		SessionID: uuid.New().String(),
+		AuthTime:  jwt.NewNumericDate(authTime),
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    AppTokenIssuer,
+			Subject:   user.ID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.jwtExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -130,6 +154,30 @@ func (s *AuthService) generateToken(user *models.User) (string, error) {
 	return token.SignedString(s.jwtSecret)
 }
 
+// IssueImpersonationToken signs a token letting impersonatedBy act as the user for
+// expiry. It has no session, so it doesn't show up in the user's session list.
+func (s *AuthService) IssueImpersonationToken(user *models.User, impersonatedBy, reason string, expiry time.Duration) (string, time.Time, error) {
+	expiresAt := time.Now().Add(expiry)
+	claims := &Claims{
+		UserID:              user.ID,
+		Email:               user.Email,
+		ImpersonatedBy:      impersonatedBy,
+		ImpersonationReason: reason,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    AppTokenIssuer,
+			Subject:   user.ID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
 func (s *AuthService) GetJWTExpiry() time.Duration {
 	return s.jwtExpiry
 }
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+const (
+	// AutoPrioritySchedule is the default cron schedule of the auto-priority job
+	AutoPrioritySchedule = "0 1 * * *"
+	// AutoPriorityHighWithin and AutoPriorityMediumWithin are how close a due date
+	// must be for auto-priority to make a todo high or medium priority; todos due
+	// later are low priority
+	AutoPriorityHighWithin   = 24 * time.Hour
+	AutoPriorityMediumWithin = 3 * 24 * time.Hour
+)
+
+// ErrPriorityAutoManaged is returned when a todo's priority is set by hand while
+// auto-priority manages it. Setting priority_locked_until lifts that for the todo.
+var ErrPriorityAutoManaged = errors.New("priority is managed by auto-priority; set priority_locked_until to change it")
+
+// autoPriorityDueLayouts are the due date formats auto-priority understands
+var autoPriorityDueLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// AutoPriorityFor returns the priority of a todo due at dueDate as of now: high when
+// it is due within a day (or overdue), medium within three days and low after that.
+// Due dates without an offset are in loc, and a bare date means the end of that day.
+// ok is false when dueDate can't be parsed.
+func AutoPriorityFor(dueDate string, now time.Time, loc *time.Location) (models.Priority, bool) {
+	due, ok := parseAutoPriorityDue(dueDate, loc)
+	if !ok {
+		return "", false
+	}
+
+	switch until := due.Sub(now); {
+	case until < AutoPriorityHighWithin:
+		return models.PriorityHigh, true
+	case until <= AutoPriorityMediumWithin:
+		return models.PriorityMedium, true
+	default:
+		return models.PriorityLow, true
+	}
+}
+
+func parseAutoPriorityDue(dueDate string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range autoPriorityDueLayouts {
+		if due, err := time.ParseInLocation(layout, dueDate, loc); err == nil {
+			return due, true
+		}
+	}
+	if day, err := time.ParseInLocation("2006-01-02", dueDate, loc); err == nil {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), true
+	}
+	return time.Time{}, false
+}
+
+// priorityLocked reports whether the todo's priority is locked against auto-priority at now
+func priorityLocked(todo *models.Todo, now time.Time) bool {
+	return todo.PriorityLockedUntil != nil && todo.PriorityLockedUntil.After(now)
+}
+
+// ApplyAutoPriorities updates the priorities of the pending todos of every user who
+// has turned on auto-priority. A user whose todos fail is logged and skipped.
+func (s *TodoService) ApplyAutoPriorities(ctx context.Context) error {
+	users, err := s.userRepo.GetAutoPriorityUsers()
+	if err != nil {
+		return fmt.Errorf("failed to load auto-priority users: %w", err)
+	}
+
+	for _, user := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := s.applyAutoPriority(user.ID, user.Timezone); err != nil {
+			log.Printf("[TodoService] Failed to apply auto-priority for user %s: %v", user.ID, err)
+		}
+	}
+	return nil
+}
+
+// ApplyAutoPriority updates the priorities of the user's pending todos from their due
+// dates, skipping locked ones, and returns how many changed
+func (s *TodoService) ApplyAutoPriority(userID string) (int, error) {
+	return s.applyAutoPriority(userID, s.userTimezone(userID))
+}
+
+func (s *TodoService) applyAutoPriority(userID, timezone string) (int, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	todos, err := s.todoRepo.GetDueByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	changed := 0
+	for i := range todos {
+		todo := &todos[i]
+		if priorityLocked(todo, now) {
+			continue
+		}
+		priority, ok := AutoPriorityFor(*todo.DueDate, now, loc)
+		if !ok || priority == todo.Priority {
+			continue
+		}
+		if err := s.todoRepo.SetAutoPriority(todo.ID, priority); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+
+	if changed > 0 {
+		s.invalidateTodoCache(userID)
+	}
+	return changed, nil
+}
+
+// RestoreOriginalPriorities gives the user's todos back the priorities they had
+// before auto-priority changed them, for when the user turns it off
+func (s *TodoService) RestoreOriginalPriorities(userID string) error {
+	restored, err := s.todoRepo.RestoreOriginalPriorities(userID)
+	if err != nil {
+		return err
+	}
+	if restored > 0 {
+		s.invalidateTodoCache(userID)
+	}
+	return nil
+}
+
+// checkManualPriority returns ErrPriorityAutoManaged when req changes the priority of
+// a todo auto-priority manages, i.e. the user has it on and the todo isn't locked by
+// req or already. Sending the todo's current priority back, as clients editing other
+// fields do, isn't a change.
+func (s *TodoService) checkManualPriority(userID string, todo *models.Todo, req *models.TodoUpdateRequest) error {
+	if req.Priority == nil || *req.Priority == todo.Priority || !s.preferences.AutoPriority(userID) {
+		return nil
+	}
+
+	lockedUntil := todo.PriorityLockedUntil
+	if req.PriorityLockedUntil != nil {
+		lockedUntil = req.PriorityLockedUntil
+	}
+	if lockedUntil != nil && lockedUntil.After(time.Now()) {
+		return nil
+	}
+	return ErrPriorityAutoManaged
+}
+
+// checkManualBulkPriority returns ErrPriorityAutoManaged when setting priority would
+// change any unlocked todo of ids while the user has auto-priority on
+func (s *TodoService) checkManualBulkPriority(userID string, ids []string, priority models.Priority) error {
+	if !s.preferences.AutoPriority(userID) {
+		return nil
+	}
+
+	todos, err := s.todoRepo.GetByIDs(userID, ids)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range todos {
+		if todos[i].Priority != priority && !priorityLocked(&todos[i], now) {
+			return ErrPriorityAutoManaged
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// newAutoPriorityTodos returns a TodoService for a user with auto-priority set to
+// enabled, and a medium priority todo of theirs without a due date
+func newAutoPriorityTodos(t *testing.T, enabled bool) (*TodoService, *models.User, *models.Todo) {
+	t.Helper()
+	db := newTestDB(t)
+	user := newTestUser(t, db, "auto-priority@example.com")
+	userRepo := repository.NewUserRepository(db)
+	todoRepo := repository.NewTodoRepository(db)
+
+	preferences := NewUserPreferencesService(userRepo)
+	if _, err := preferences.UpdatePreferences(user.ID, &models.UserPreferencesUpdateRequest{AutoPriority: &enabled}); err != nil {
+		t.Fatalf("UpdatePreferences: %v", err)
+	}
+	todos := NewTodoService(todoRepo, repository.NewGroupRepository(db), userRepo, nil, nil, nil, nil, nil, preferences)
+
+	todo := &models.Todo{UserID: user.ID, Title: "Renew passport", Priority: models.PriorityMedium, Status: models.StatusPending}
+	if err := todoRepo.Create(todo); err != nil {
+		t.Fatalf("failed to create todo: %v", err)
+	}
+	return todos, user, todo
+}
+
+func TestAutoPriorityManualUpdates(t *testing.T) {
+	priority := func(p models.Priority) *models.Priority { return &p }
+	at := func(d time.Duration) *time.Time { v := time.Now().Add(d); return &v }
+	title := "Renew passport this week"
+
+	tests := []struct {
+		name        string
+		autoOn      bool
+		lockedUntil *time.Time // set on the todo before the update
+		req         models.TodoUpdateRequest
+		wantErr     error
+	}{
+		{name: "auto-priority off", req: models.TodoUpdateRequest{Priority: priority(models.PriorityHigh)}},
+		{name: "other fields", autoOn: true, req: models.TodoUpdateRequest{Title: &title}},
+		{name: "unchanged priority sent back", autoOn: true, req: models.TodoUpdateRequest{Title: &title, Priority: priority(models.PriorityMedium)}},
+		{name: "changed priority", autoOn: true, req: models.TodoUpdateRequest{Priority: priority(models.PriorityHigh)}, wantErr: ErrPriorityAutoManaged},
+		{name: "changed priority with a lock", autoOn: true, req: models.TodoUpdateRequest{Priority: priority(models.PriorityHigh), PriorityLockedUntil: at(time.Hour)}},
+		{name: "changed priority with an ended lock", autoOn: true, req: models.TodoUpdateRequest{Priority: priority(models.PriorityHigh), PriorityLockedUntil: at(-time.Hour)}, wantErr: ErrPriorityAutoManaged},
+		{name: "changed priority of a locked todo", autoOn: true, lockedUntil: at(time.Hour), req: models.TodoUpdateRequest{Priority: priority(models.PriorityHigh)}},
+		{name: "changed priority of a todo whose lock ended", autoOn: true, lockedUntil: at(-time.Hour), req: models.TodoUpdateRequest{Priority: priority(models.PriorityHigh)}, wantErr: ErrPriorityAutoManaged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, user, todo := newAutoPriorityTodos(t, tt.autoOn)
+			if tt.lockedUntil != nil {
+				if err := todos.todoRepo.Update(todo.ID, map[string]interface{}{"priority_locked_until": *tt.lockedUntil}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			updated, err := todos.Update(user.ID, todo.ID, &tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, err := todos.todoRepo.GetByID(todo.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantPriority := todo.Priority
+			if tt.wantErr == nil && tt.req.Priority != nil {
+				wantPriority = *tt.req.Priority
+			}
+			if stored.Priority != wantPriority {
+				t.Errorf("priority = %s, want %s", stored.Priority, wantPriority)
+			}
+			if tt.wantErr == nil && tt.req.Title != nil && updated.Title != *tt.req.Title {
+				t.Errorf("title = %q, want %q", updated.Title, *tt.req.Title)
+			}
+		})
+	}
+}
+
+func TestAutoPriorityBulkUpdates(t *testing.T) {
+	tests := []struct {
+		name     string
+		autoOn   bool
+		locked   bool // whether the second todo is locked
+		priority models.Priority
+		wantErr  error
+	}{
+		{name: "auto-priority off", priority: models.PriorityHigh},
+		{name: "unchanged priority", autoOn: true, priority: models.PriorityMedium},
+		{name: "changed priority", autoOn: true, priority: models.PriorityHigh, wantErr: ErrPriorityAutoManaged},
+		{name: "one todo changed and unlocked", autoOn: true, locked: true, priority: models.PriorityLow, wantErr: ErrPriorityAutoManaged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			todos, user, first := newAutoPriorityTodos(t, tt.autoOn)
+			second := &models.Todo{UserID: user.ID, Title: "Book flights", Priority: models.PriorityMedium, Status: models.StatusPending}
+			if err := todos.todoRepo.Create(second); err != nil {
+				t.Fatal(err)
+			}
+			if tt.locked {
+				if err := todos.todoRepo.Update(second.ID, map[string]interface{}{"priority_locked_until": time.Now().Add(time.Hour)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := todos.BulkUpdatePriority(user.ID, []string{first.ID, second.ID}, tt.priority)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BulkUpdatePriority = %v, want %v", err, tt.wantErr)
+			}
+
+			want := tt.priority
+			if tt.wantErr != nil {
+				want = models.PriorityMedium
+			}
+			for _, id := range []string{first.ID, second.ID} {
+				stored, err := todos.todoRepo.GetByID(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if stored.Priority != want {
+					t.Errorf("todo %s priority = %s, want %s", id, stored.Priority, want)
+				}
+			}
+		})
+	}
+
+	t.Run("all changed todos locked", func(t *testing.T) {
+		todos, user, todo := newAutoPriorityTodos(t, true)
+		if err := todos.todoRepo.Update(todo.ID, map[string]interface{}{"priority_locked_until": time.Now().Add(time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := todos.BulkUpdatePriority(user.ID, []string{todo.ID}, models.PriorityHigh); err != nil {
+			t.Fatalf("BulkUpdatePriority = %v, want nil", err)
+		}
+	})
+}
+
+// Sending back the priority auto-priority set, e.g. while renaming the todo, keeps
+// the original priority to restore when auto-priority is turned off
+func TestAutoPriorityUnchangedPriorityKeepsOriginal(t *testing.T) {
+	todos, user, todo := newAutoPriorityTodos(t, false)
+	todos.preferences.SetTodoService(todos)
+	dueDate := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+	if err := todos.todoRepo.Update(todo.ID, map[string]interface{}{"due_date": dueDate}); err != nil {
+		t.Fatal(err)
+	}
+
+	on, off := true, false
+	if _, err := todos.preferences.UpdatePreferences(user.ID, &models.UserPreferencesUpdateRequest{AutoPriority: &on}); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := todos.todoRepo.GetByID(todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Priority != models.PriorityHigh {
+		t.Fatalf("priority after turning auto-priority on = %s, want high", stored.Priority)
+	}
+
+	title := "Renew passport today"
+	if _, err := todos.Update(user.ID, todo.ID, &models.TodoUpdateRequest{Title: &title, Priority: &stored.Priority}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if _, err := todos.preferences.UpdatePreferences(user.ID, &models.UserPreferencesUpdateRequest{AutoPriority: &off}); err != nil {
+		t.Fatal(err)
+	}
+	stored, err = todos.todoRepo.GetByID(todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Priority != models.PriorityMedium || stored.Title != title {
+		t.Errorf("todo after turning auto-priority off = %s %q, want medium %q", stored.Priority, stored.Title, title)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/todomyday/backend/internal/database"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+var (
+	ErrInvalidBackup     = database.ErrInvalidBackup
+	ErrRestoreInProgress = errors.New("a restore is already in progress")
+)
+
+// BackupService downloads and restores whole-database backups for self-hosters
+type BackupService struct {
+	repo   *repository.BackupRepository
+	dbPath string
+	// onRestore is handed a validated database file staged next to the live one;
+	// it stops the server, swaps the file in and restarts
+	onRestore func(staged string)
+	restoring atomic.Bool
+}
+
+func NewBackupService(repo *repository.BackupRepository, dbPath string, onRestore func(staged string)) *BackupService {
+	return &BackupService{
+		repo:      repo,
+		dbPath:    dbPath,
+		onRestore: onRestore,
+	}
+}
+
+// CreateBackup snapshots the database to a temporary file and returns its path and
+// download name. The caller must call cleanup once the file has been sent.
+func (s *BackupService) CreateBackup() (path, filename string, cleanup func(), err error) {
+	dir, err := os.MkdirTemp("", "mrbrain-backup-")
+	if err != nil {
+		return "", "", nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	filename = fmt.Sprintf("mrbrain-backup-%s.db", time.Now().Format("20060102-150405"))
+	path = filepath.Join(dir, filename)
+	if err := s.repo.Backup(path); err != nil {
+		cleanup()
+		return "", "", nil, err
+	}
+	return path, filename, cleanup, nil
+}
+
+// Restore validates an uploaded database and hands it over to be swapped in for the
+// live one, which restarts the server. Nothing changes if the upload is invalid.
+func (s *BackupService) Restore(upload io.Reader) error {
+	if !s.restoring.CompareAndSwap(false, true) {
+		return ErrRestoreInProgress
+	}
+
+	staged, err := s.stage(upload)
+	if err != nil {
+		s.restoring.Store(false)
+		return err
+	}
+
+	log.Printf("[Backup] Restoring database from uploaded backup; the server will restart")
+	s.onRestore(staged)
+	return nil
+}
+
+// stage writes the upload beside the live database, so it can later be renamed over
+// it, and checks that it is a sound database
+func (s *BackupService) stage(upload io.Reader) (string, error) {
+	file, err := os.CreateTemp(filepath.Dir(s.dbPath), ".restore-*.db")
+	if err != nil {
+		return "", err
+	}
+	staged := file.Name()
+
+	_, err = io.Copy(file, upload)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = database.ValidateBackup(staged)
+	}
+	if err != nil {
+		os.Remove(staged)
+		return "", err
+	}
+	return staged, nil
+}
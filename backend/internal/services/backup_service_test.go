@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/todomyday/backend/internal/database"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "live.db")
+	db, err := database.Connect(dbPath, database.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	user := newTestUser(t, db, "backup@example.com")
+	memory := &models.Memory{UserID: user.ID, Content: "Passport expires in March", Category: "Documents"}
+	if err := repository.NewMemoryRepository(db).Create(memory); err != nil {
+		t.Fatalf("failed to create memory: %v", err)
+	}
+
+	var staged string
+	service := NewBackupService(repository.NewBackupRepository(db), dbPath, func(s string) { staged = s })
+
+	path, filename, cleanup, err := service.CreateBackup()
+	if err != nil {
+		t.Fatalf("CreateBackup: %v", err)
+	}
+	defer cleanup()
+	if filepath.Ext(filename) != ".db" {
+		t.Errorf("filename = %q, want a .db file", filename)
+	}
+	backup, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes made after the backup are undone by restoring it
+	if err := repository.NewMemoryRepository(db).Delete(memory.ID); err != nil {
+		t.Fatalf("failed to delete memory: %v", err)
+	}
+	newTestUser(t, db, "after-backup@example.com")
+
+	if err := service.Restore(bytes.NewReader(backup)); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if staged == "" {
+		t.Fatal("Restore didn't hand over a staged database")
+	}
+	if err := service.Restore(bytes.NewReader(backup)); !errors.Is(err, ErrRestoreInProgress) {
+		t.Errorf("second Restore = %v, want ErrRestoreInProgress", err)
+	}
+
+	db.Close()
+	if err := database.ReplaceFile(staged, dbPath); err != nil {
+		t.Fatalf("ReplaceFile: %v", err)
+	}
+	restored, err := database.Connect(dbPath, database.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to open restored database: %v", err)
+	}
+	t.Cleanup(func() { restored.Close() })
+
+	got, err := repository.NewMemoryRepository(restored).GetByID(memory.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Content != memory.Content || got.UserID != user.ID {
+		t.Errorf("restored memory = %+v, want %q owned by %s", got, memory.Content, user.ID)
+	}
+	if after, err := repository.NewUserRepository(restored).GetByEmail("after-backup@example.com"); err != nil || after != nil {
+		t.Errorf("user created after the backup = %+v, %v; want it gone", after, err)
+	}
+}
+
+func TestRestoreRejectsInvalidBackups(t *testing.T) {
+	otherDB := func(t *testing.T) []byte {
+		path := filepath.Join(t.TempDir(), "other.db")
+		db, err := sql.Open("sqlite", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		if _, err := db.Exec("CREATE TABLE notes (id TEXT PRIMARY KEY)"); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name   string
+		upload func(t *testing.T) []byte
+	}{
+		{"empty file", func(t *testing.T) []byte { return nil }},
+		{"not a database", func(t *testing.T) []byte { return []byte("definitely not sqlite") }},
+		{"another app's database", otherDB},
+		{"truncated database", func(t *testing.T) []byte { return otherDB(t)[:100] }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "live.db")
+			db, err := database.Connect(dbPath, database.DefaultConfig())
+			if err != nil {
+				t.Fatalf("failed to open database: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+
+			restored := false
+			service := NewBackupService(repository.NewBackupRepository(db), dbPath, func(string) { restored = true })
+
+			err = service.Restore(bytes.NewReader(tt.upload(t)))
+			if !errors.Is(err, ErrInvalidBackup) {
+				t.Errorf("Restore = %v, want ErrInvalidBackup", err)
+			}
+			if restored {
+				t.Error("an invalid backup was handed over to be restored")
+			}
+
+			// The staged file is removed and another restore may be attempted
+			entries, _ := filepath.Glob(filepath.Join(filepath.Dir(dbPath), ".restore-*"))
+			if len(entries) != 0 {
+				t.Errorf("staged files left behind: %v", entries)
+			}
+			if err := service.Restore(bytes.NewReader(tt.upload(t))); errors.Is(err, ErrRestoreInProgress) {
+				t.Error("a failed restore left the service locked")
+			}
+		})
+	}
+}
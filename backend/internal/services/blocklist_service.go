@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/todomyday/backend/internal/metrics"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// blocklistCacheTTL is how long a user's compiled blocklist is reused. It is checked
+// on every memory save, and edits invalidate it immediately.
+const blocklistCacheTTL = 60 * time.Second
+
+// blocklistCacheMaxEntries bounds the cache; expired entries are swept when it fills
+const blocklistCacheMaxEntries = 10000
+
+// systemBlocklistIDPrefix starts the IDs of system patterns, which are numbered in
+// SYSTEM_BLOCKLIST_PATTERNS order
+const systemBlocklistIDPrefix = "system-"
+
+var (
+	ErrInvalidBlocklistPattern  = errors.New("invalid blocklist pattern")
+	ErrBlocklistPatternNotFound = newCodedError(models.ErrCodeNotFound, "blocklist pattern not found")
+	ErrSystemBlocklistPattern   = errors.New("system blocklist patterns can't be changed")
+)
+
+// blocklistMatcher is a compiled blocklist pattern
+type blocklistMatcher struct {
+	pattern string
+	// keyword is the lowercased pattern of a keyword, re the compiled pattern of a regex
+	keyword string
+	re      *regexp.Regexp
+}
+
+// matches reports whether content, or lower (content lowercased), contains the pattern
+func (m blocklistMatcher) matches(content, lower string) bool {
+	if m.re != nil {
+		return m.re.MatchString(content)
+	}
+	return strings.Contains(lower, m.keyword)
+}
+
+// BlocklistService keeps memory content matching a user's or the system's blocklist
+// from being stored
+type BlocklistService struct {
+	repo   *repository.KeywordBlocklistRepository
+	system []models.BlocklistPattern
+	// systemMatchers are the compiled system patterns; invalid ones are left out
+	systemMatchers []blocklistMatcher
+
+	// Compiled user patterns keyed by user ID
+	cache *ttlCache[[]blocklistMatcher]
+}
+
+// NewBlocklistService creates the service with the system patterns, keywords or
+// /regular expressions/. Invalid system regexes are logged and ignored.
+func NewBlocklistService(repo *repository.KeywordBlocklistRepository, systemPatterns []string) *BlocklistService {
+	s := &BlocklistService{
+		repo:  repo,
+		cache: newTTLCache[[]blocklistMatcher](metrics.CacheBlocklist, blocklistCacheTTL, blocklistCacheMaxEntries),
+	}
+
+	for i, raw := range systemPatterns {
+		pattern := models.BlocklistPattern{ID: systemBlocklistIDPrefix + strconv.Itoa(i+1), Pattern: raw, IsSystem: true}
+		if len(raw) > 2 && strings.HasPrefix(raw, "/") && strings.HasSuffix(raw, "/") {
+			pattern.Pattern = raw[1 : len(raw)-1]
+			pattern.IsRegex = true
+		}
+
+		matcher, err := compileBlocklistPattern(pattern.Pattern, pattern.IsRegex)
+		if err != nil {
+			log.Printf("[Blocklist] Ignoring system pattern %d: %v", i+1, err)
+			continue
+		}
+		s.system = append(s.system, pattern)
+		s.systemMatchers = append(s.systemMatchers, matcher)
+	}
+
+	return s
+}
+
+// compileBlocklistPattern checks and compiles a pattern. Both kinds ignore case.
+func compileBlocklistPattern(pattern string, isRegex bool) (blocklistMatcher, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return blocklistMatcher{}, fmt.Errorf("%w: pattern is empty", ErrInvalidBlocklistPattern)
+	}
+	if !isRegex {
+		return blocklistMatcher{pattern: pattern, keyword: strings.ToLower(pattern)}, nil
+	}
+
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return blocklistMatcher{}, fmt.Errorf("%w: %v", ErrInvalidBlocklistPattern, err)
+	}
+	return blocklistMatcher{pattern: pattern, re: re}, nil
+}
+
+// Check returns a *models.ContentBlockedError naming the first system or user pattern
+// content matches, or nil if it matches none
+func (s *BlocklistService) Check(userID, content string) error {
+	if s == nil {
+		return nil
+	}
+
+	matchers, err := s.matchers(userID)
+	if err != nil {
+		return fmt.Errorf("failed to load blocklist: %w", err)
+	}
+
+	lower := strings.ToLower(content)
+	for _, matchers := range [][]blocklistMatcher{s.systemMatchers, matchers} {
+		for _, matcher := range matchers {
+			if matcher.matches(content, lower) {
+				return &models.ContentBlockedError{Pattern: matcher.pattern}
+			}
+		}
+	}
+	return nil
+}
+
+// GetAll returns the system patterns followed by the user's own
+func (s *BlocklistService) GetAll(userID string) ([]models.BlocklistPattern, error) {
+	patterns, err := s.repo.GetAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]models.BlocklistPattern{}, s.system...), patterns...), nil
+}
+
+func (s *BlocklistService) Create(userID string, req *models.BlocklistPatternCreateRequest) (*models.BlocklistPattern, error) {
+	if _, err := compileBlocklistPattern(req.Pattern, req.IsRegex); err != nil {
+		return nil, err
+	}
+
+	pattern := &models.BlocklistPattern{
+		UserID:  userID,
+		Pattern: strings.TrimSpace(req.Pattern),
+		IsRegex: req.IsRegex,
+	}
+	if err := s.repo.Create(pattern); err != nil {
+		return nil, err
+	}
+
+	s.invalidate(userID)
+	return pattern, nil
+}
+
+func (s *BlocklistService) Update(userID, patternID string, req *models.BlocklistPatternUpdateRequest) (*models.BlocklistPattern, error) {
+	pattern, err := s.getOwn(userID, patternID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Pattern != nil {
+		pattern.Pattern = strings.TrimSpace(*req.Pattern)
+	}
+	if req.IsRegex != nil {
+		pattern.IsRegex = *req.IsRegex
+	}
+	if _, err := compileBlocklistPattern(pattern.Pattern, pattern.IsRegex); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(pattern); err != nil {
+		return nil, err
+	}
+
+	s.invalidate(userID)
+	return pattern, nil
+}
+
+func (s *BlocklistService) Delete(userID, patternID string) error {
+	if _, err := s.getOwn(userID, patternID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(patternID); err != nil {
+		return err
+	}
+
+	s.invalidate(userID)
+	return nil
+}
+
+// getOwn returns one of the user's patterns, refusing system ones
+func (s *BlocklistService) getOwn(userID, patternID string) (*models.BlocklistPattern, error) {
+	if strings.HasPrefix(patternID, systemBlocklistIDPrefix) {
+		return nil, ErrSystemBlocklistPattern
+	}
+
+	pattern, err := s.repo.GetByID(patternID)
+	if err != nil {
+		return nil, err
+	}
+	if pattern == nil || pattern.UserID != userID {
+		return nil, ErrBlocklistPatternNotFound
+	}
+	return pattern, nil
+}
+
+// matchers returns the user's compiled patterns, cached for blocklistCacheTTL
+func (s *BlocklistService) matchers(userID string) ([]blocklistMatcher, error) {
+	if matchers, ok := s.cache.Get(userID); ok {
+		return matchers, nil
+	}
+
+	patterns, err := s.repo.GetAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Patterns are validated when saved, but rows from before a validation change or
+	// restored from a backup may not compile; those are logged and skipped
+	matchers := make([]blocklistMatcher, 0, len(patterns))
+	for _, pattern := range patterns {
+		matcher, err := compileBlocklistPattern(pattern.Pattern, pattern.IsRegex)
+		if err != nil {
+			log.Printf("[Blocklist] Ignoring pattern %s of user %s: %v", pattern.ID, userID, err)
+			continue
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	s.cache.Set(userID, matchers)
+	return matchers, nil
+}
+
+func (s *BlocklistService) invalidate(userID string) {
+	s.cache.Delete(userID)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+func TestBlocklistCheck(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db, "blocklist@example.com")
+	other := newTestUser(t, db, "other@example.com")
+	repo := repository.NewKeywordBlocklistRepository(db)
+	blocklist := NewBlocklistService(repo, []string{"Project Nightingale", `/\bsk-[a-z0-9]{8,}\b/`, "/([unclosed/"})
+
+	for _, req := range []models.BlocklistPatternCreateRequest{
+		{Pattern: "Acme Merger"},
+		{Pattern: `\d{3}-\d{2}-\d{4}`, IsRegex: true},
+	} {
+		if _, err := blocklist.Create(user.ID, &req); err != nil {
+			t.Fatalf("Create %q: %v", req.Pattern, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		userID      string
+		content     string
+		wantPattern string // "" when the content isn't blocked
+	}{
+		{"keyword", user.ID, "Notes on the Acme Merger call", "Acme Merger"},
+		{"keyword in another case", user.ID, "notes on the ACME merger call", "Acme Merger"},
+		{"regex", user.ID, "My SSN is 123-45-6789", `\d{3}-\d{2}-\d{4}`},
+		{"regex not matching", user.ID, "Call 555-0100 tomorrow", ""},
+		{"system keyword in another case", user.ID, "project NIGHTINGALE kickoff", "Project Nightingale"},
+		{"system regex in another case", user.ID, "key SK-ABCDEF1234", `\bsk-[a-z0-9]{8,}\b`},
+		{"another user's pattern", other.ID, "Notes on the Acme Merger call", ""},
+		{"system pattern for another user", other.ID, "Project Nightingale", "Project Nightingale"},
+		{"nothing matching", user.ID, "Buy milk", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := blocklist.Check(tt.userID, tt.content)
+			if tt.wantPattern == "" {
+				if err != nil {
+					t.Fatalf("Check = %v, want nil", err)
+				}
+				return
+			}
+
+			var blocked *models.ContentBlockedError
+			if !errors.As(err, &blocked) {
+				t.Fatalf("Check = %v, want a ContentBlockedError", err)
+			}
+			if blocked.Pattern != tt.wantPattern {
+				t.Errorf("matched pattern = %q, want %q", blocked.Pattern, tt.wantPattern)
+			}
+		})
+	}
+}
+
+// The error names the pattern but never the text that matched it
+func TestBlocklistErrorDoesNotEchoContent(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db, "blocklist@example.com")
+	blocklist := NewBlocklistService(repository.NewKeywordBlocklistRepository(db), nil)
+	if _, err := blocklist.Create(user.ID, &models.BlocklistPatternCreateRequest{Pattern: `\d{3}-\d{2}-\d{4}`, IsRegex: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := blocklist.Check(user.ID, "My SSN is 123-45-6789")
+	var apiErr models.APIErrorer
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Check = %v, want an API error", err)
+	}
+	body, _ := json.Marshal(apiErr.APIError())
+	for _, sent := range []string{err.Error(), string(body)} {
+		if strings.Contains(sent, "123-45-6789") {
+			t.Errorf("error %s echoes the matched text", sent)
+		}
+	}
+}
+
+// A stored regex that doesn't compile is skipped rather than failing every check
+func TestBlocklistSkipsInvalidStoredPatterns(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db, "blocklist@example.com")
+	repo := repository.NewKeywordBlocklistRepository(db)
+	blocklist := NewBlocklistService(repo, nil)
+
+	if err := repo.Create(&models.BlocklistPattern{UserID: user.ID, Pattern: "([unclosed", IsRegex: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blocklist.Create(user.ID, &models.BlocklistPatternCreateRequest{Pattern: "Acme Merger"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := blocklist.Check(user.ID, "Buy milk"); err != nil {
+		t.Errorf("Check of unmatched content = %v, want nil", err)
+	}
+	var blocked *models.ContentBlockedError
+	if err := blocklist.Check(user.ID, "acme merger notes"); !errors.As(err, &blocked) {
+		t.Errorf("Check of matching content = %v, want a ContentBlockedError", err)
+	}
+}
@@ -1,19 +1,57 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"log"
+	"strings"
+	"time"
 
+	"github.com/todomyday/backend/internal/metrics"
 	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/repository"
 )
 
+// MaxThreadTitleLength caps both generated and manually set thread titles
+const MaxThreadTitleLength = 100
+
+// Conversation history limits for Ask: at most ChatHistoryMaxMessages prior
+// messages are loaded, then trimmed from the oldest end to ChatHistoryMaxTokens
+const (
+	ChatHistoryMaxMessages = 20
+	ChatHistoryMaxTokens   = 2000
+)
+
+// threadTitleRequestTTL is how long starting a thread's title generation keeps
+// another from starting. It outlasts the longest AI call (models.MaxTimeoutSeconds),
+// and once the title is stored that stops later generations instead.
+const threadTitleRequestTTL = 10 * time.Minute
+
+// threadTitleRequestMaxEntries bounds the title requests; expired ones are swept when it fills
+const threadTitleRequestMaxEntries = 10000
+
+var (
+	// ErrThreadNotFound is returned for threads that don't exist or aren't the user's
+	ErrThreadNotFound   = newCodedError(models.ErrCodeNotFound, "thread not found")
+	ErrEmptyThreadTitle = newCodedError(models.ErrCodeValidation, "title cannot be empty")
+)
+
 type ChatService struct {
-	chatRepo *repository.ChatRepository
+	chatRepo          *repository.ChatRepository
+	aiProviderService *AIProviderService
+	ragService        *RAGService
+
+	// Threads for which title generation has been triggered, by thread ID
+	titleRequested *ttlCache[struct{}]
 }
 
-func NewChatService(chatRepo *repository.ChatRepository) *ChatService {
+func NewChatService(chatRepo *repository.ChatRepository, aiProviderService *AIProviderService, ragService *RAGService) *ChatService {
 	return &ChatService{
-		chatRepo: chatRepo,
+		chatRepo:          chatRepo,
+		aiProviderService: aiProviderService,
+		ragService:        ragService,
+		titleRequested:    newTTLCache[struct{}](metrics.CacheThreadTitles, threadTitleRequestTTL, threadTitleRequestMaxEntries),
 	}
 }
 
@@ -50,11 +88,8 @@ func (s *ChatService) GetThreadWithMessages(userID, threadID string) (*models.Ch
 	if err != nil {
 		return nil, err
 	}
-	if thread == nil {
-		return nil, fmt.Errorf("thread not found")
-	}
-	if thread.UserID != userID {
-		return nil, fmt.Errorf("unauthorized")
+	if thread == nil || thread.UserID != userID {
+		return nil, ErrThreadNotFound
 	}
 
 	// Get messages
@@ -87,11 +122,8 @@ func (s *ChatService) AddMessage(userID, threadID string, req *models.ChatMessag
 	if err != nil {
 		return nil, err
 	}
-	if thread == nil {
-		return nil, fmt.Errorf("thread not found")
-	}
-	if thread.UserID != userID {
-		return nil, fmt.Errorf("unauthorized")
+	if thread == nil || thread.UserID != userID {
+		return nil, ErrThreadNotFound
 	}
 
 	message := &models.ChatMessage{
@@ -106,9 +138,275 @@ func (s *ChatService) AddMessage(userID, threadID string, req *models.ChatMessag
 		return nil, err
 	}
 
+	if message.Role == "user" {
+		s.generateTitleAsync(thread)
+	}
+
 	return message, nil
 }
 
+// Ask answers a question within a thread, using the thread's recent messages as
+// conversation history. Both the question and the reply are stored in the thread.
+func (s *ChatService) Ask(ctx context.Context, threadID, userID string, req *models.ChatAskRequest) (*models.ChatAskResponse, error) {
+	return s.ask(ctx, threadID, userID, req, nil)
+}
+
+// AskStream is Ask with the reply passed to onToken as the AI streams it. The reply
+// is only stored once complete, unless ctx is cancelled mid-stream (the client went
+// away), in which case the text streamed so far is stored as a partial message.
+func (s *ChatService) AskStream(ctx context.Context, threadID, userID string, req *models.ChatAskRequest, onToken func(token string)) (*models.ChatAskResponse, error) {
+	return s.ask(ctx, threadID, userID, req, onToken)
+}
+
+func (s *ChatService) ask(ctx context.Context, threadID, userID string, req *models.ChatAskRequest, onToken func(token string)) (*models.ChatAskResponse, error) {
+	if s.ragService == nil {
+		return nil, fmt.Errorf("RAG service not configured")
+	}
+
+	// Verify thread belongs to user
+	thread, err := s.chatRepo.GetThreadByID(threadID)
+	if err != nil {
+		return nil, err
+	}
+	if thread == nil || thread.UserID != userID {
+		return nil, ErrThreadNotFound
+	}
+
+	// Load history before storing the new question so it isn't sent twice
+	messages, err := s.chatRepo.GetMessagesByThreadID(threadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) > ChatHistoryMaxMessages {
+		messages = messages[len(messages)-ChatHistoryMaxMessages:]
+	}
+	history := trimHistoryToTokenLimit(messages, ChatHistoryMaxTokens)
+
+	var mode *string
+	if req.Mode != "" {
+		m := string(req.Mode)
+		mode = &m
+	}
+
+	userMessage := &models.ChatMessage{
+		ThreadID: threadID,
+		Role:     "user",
+		Content:  req.Question,
+		Mode:     mode,
+	}
+	if err := s.chatRepo.CreateMessage(userMessage); err != nil {
+		return nil, err
+	}
+	s.generateTitleAsync(thread)
+
+	// Retrieval always runs on the new question; history only shapes the answer
+	askReq := &models.AskRequest{
+		Question:     req.Question,
+		ContentTypes: req.ContentTypes,
+		MaxContext:   req.MaxContext,
+		Mode:         req.Mode,
+		AllowActions: req.AllowActions,
+		History:      history,
+	}
+	var streamed strings.Builder
+	if onToken != nil {
+		askReq.OnToken = func(token string) {
+			streamed.WriteString(token)
+			onToken(token)
+		}
+	}
+
+	askResp, err := s.ragService.Ask(ctx, userID, askReq)
+	if err != nil {
+		if onToken != nil && ctx.Err() != nil && streamed.Len() > 0 {
+			s.savePartialReply(threadID, mode, streamed.String())
+		}
+		return nil, err
+	}
+	// Replies that never reach the AI (e.g. nothing relevant found) arrive in one piece
+	if onToken != nil && streamed.Len() == 0 {
+		onToken(askResp.Answer)
+	}
+
+	assistantMessage := &models.ChatMessage{
+		ThreadID: threadID,
+		Role:     "assistant",
+		Content:  askResp.Answer,
+		Mode:     mode,
+	}
+	if len(askResp.Sources) > 0 {
+		if sourcesJSON, err := json.Marshal(askResp.Sources); err == nil {
+			sources := string(sourcesJSON)
+			assistantMessage.Sources = &sources
+		}
+	}
+	if err := s.chatRepo.CreateMessage(assistantMessage); err != nil {
+		return nil, err
+	}
+
+	return &models.ChatAskResponse{
+		UserMessage:      userMessage,
+		AssistantMessage: assistantMessage,
+		Sources:          askResp.Sources,
+		TimeTaken:        askResp.TimeTaken,
+		CreatedTodo:      askResp.CreatedTodo,
+	}, nil
+}
+
+// savePartialReply stores the part of a streamed reply the client received before
+// disconnecting, flagged so it isn't mistaken for a complete answer
+func (s *ChatService) savePartialReply(threadID string, mode *string, content string) {
+	message := &models.ChatMessage{
+		ThreadID:  threadID,
+		Role:      "assistant",
+		Content:   content,
+		Mode:      mode,
+		IsPartial: true,
+	}
+	if err := s.chatRepo.CreateMessage(message); err != nil {
+		log.Printf("[Chat] Failed to save partial reply in thread %s: %v", threadID, err)
+	}
+}
+
+// trimHistoryToTokenLimit keeps the most recent messages whose combined token
+// count fits within maxTokens, returned oldest first
+func trimHistoryToTokenLimit(messages []models.ChatMessage, maxTokens int) []models.ChatMessage {
+	counter := GetTokenCounter()
+
+	total := 0
+	start := len(messages)
+	for i := len(messages) - 1; i >= 0; i-- {
+		tokens := counter.CountTokens(messages[i].Content)
+		if total+tokens > maxTokens {
+			break
+		}
+		total += tokens
+		start = i
+	}
+
+	return messages[start:]
+}
+
+// generateTitleAsync generates a title in the background if the thread doesn't have one yet
+func (s *ChatService) generateTitleAsync(thread *models.ChatThread) {
+	if thread.Title != nil && *thread.Title != "" {
+		return
+	}
+
+	go func(threadID string) {
+		if err := s.GenerateThreadTitle(threadID); err != nil {
+			log.Printf("[ChatService] Failed to generate title for thread %s: %v", threadID, err)
+		}
+	}(thread.ID)
+}
+
+// GenerateThreadTitle summarizes the thread's first user message into a short title.
+// It runs at most once per thread; later calls for the same thread are no-ops.
+func (s *ChatService) GenerateThreadTitle(threadID string) error {
+	if !s.titleRequested.Add(threadID, struct{}{}) {
+		return nil
+	}
+
+	thread, err := s.chatRepo.GetThreadByID(threadID)
+	if err != nil {
+		return err
+	}
+	if thread == nil {
+		return ErrThreadNotFound
+	}
+	if thread.Title != nil && *thread.Title != "" {
+		return nil
+	}
+
+	messages, err := s.chatRepo.GetMessagesByThreadID(threadID)
+	if err != nil {
+		return err
+	}
+
+	var firstMessage string
+	for _, m := range messages {
+		if m.Role == "user" {
+			firstMessage = m.Content
+			break
+		}
+	}
+	if firstMessage == "" {
+		// Nothing to summarize yet, allow a later message to trigger generation
+		s.titleRequested.Delete(threadID)
+		return nil
+	}
+
+	title := s.generateTitle(thread.UserID, firstMessage)
+
+	if _, err := s.chatRepo.SetThreadTitleIfEmpty(threadID, title); err != nil {
+		return err
+	}
+	// The stored title stops later generations from here on
+	s.titleRequested.Delete(threadID)
+
+	log.Printf("[ChatService] Generated title for thread %s: %q", threadID, title)
+	return nil
+}
+
+// generateTitle asks the user's AI provider for a title, falling back to the
+// first few words of the message when no provider is available
+func (s *ChatService) generateTitle(userID, message string) string {
+	if s.aiProviderService != nil {
+		provider, err := s.aiProviderService.GetDefaultByUserID(userID)
+		if err == nil && provider != nil && provider.SelectedModel != nil {
+			config, err := s.aiProviderService.ProviderConfig(provider, userID)
+			if err == nil {
+				title, err := GenerateThreadTitleWithProvider(message, config)
+				if err == nil && title != "" {
+					return truncateThreadTitle(title)
+				}
+				log.Printf("[ChatService] AI title generation failed, using fallback: %v", err)
+			}
+		}
+	}
+
+	words := strings.Fields(message)
+	if len(words) > 5 {
+		words = words[:5]
+	}
+	return truncateThreadTitle(strings.Join(words, " "))
+}
+
+// UpdateThreadTitle sets a thread's title manually
+func (s *ChatService) UpdateThreadTitle(userID, threadID, title string) (*models.ChatThread, error) {
+	// Verify thread belongs to user
+	thread, err := s.chatRepo.GetThreadByID(threadID)
+	if err != nil {
+		return nil, err
+	}
+	if thread == nil || thread.UserID != userID {
+		return nil, ErrThreadNotFound
+	}
+
+	title = truncateThreadTitle(strings.TrimSpace(title))
+	if title == "" {
+		return nil, ErrEmptyThreadTitle
+	}
+
+	// A manual title should never be replaced by generation
+	s.titleRequested.Set(threadID, struct{}{})
+
+	if err := s.chatRepo.UpdateThreadTitle(threadID, title); err != nil {
+		return nil, err
+	}
+
+	thread.Title = &title
+	return thread, nil
+}
+
+func truncateThreadTitle(title string) string {
+	runes := []rune(title)
+	if len(runes) > MaxThreadTitleLength {
+		return strings.TrimSpace(string(runes[:MaxThreadTitleLength]))
+	}
+	return title
+}
+
 // DeleteThread deletes a thread (and all its messages via cascade)
 func (s *ChatService) DeleteThread(userID, threadID string) error {
 	// Verify thread belongs to user
@@ -116,13 +414,16 @@ func (s *ChatService) DeleteThread(userID, threadID string) error {
 	if err != nil {
 		return err
 	}
-	if thread == nil {
-		return fmt.Errorf("thread not found")
+	if thread == nil || thread.UserID != userID {
+		return ErrThreadNotFound
 	}
-	if thread.UserID != userID {
-		return fmt.Errorf("unauthorized")
+
+	if err := s.chatRepo.DeleteThread(threadID); err != nil {
+		return err
 	}
 
-	return s.chatRepo.DeleteThread(threadID)
+	s.titleRequested.Delete(threadID)
+
+	return nil
 }
 
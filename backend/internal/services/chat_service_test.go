@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/todomyday/backend/internal/crypto"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// newTitleTestChat returns a ChatService whose user has an AI provider served by
+// a mock that counts title requests and holds each one until release is closed
+func newTitleTestChat(t *testing.T) (chat *ChatService, chatRepo *repository.ChatRepository, userID string, calls *atomic.Int32, started <-chan struct{}, release chan struct{}) {
+	t.Helper()
+	calls = new(atomic.Int32)
+	startedCh := make(chan struct{}, 10)
+	release = make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		startedCh <- struct{}{}
+		<-release
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "Weekend trip plans"}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	db := newTestDB(t)
+	user := newTestUser(t, db, "chat@example.com")
+	providers := NewAIProviderService(repository.NewAIProviderRepository(db), crypto.NewEncryptor("test-key"), nil)
+	provider, err := providers.Create(user.ID, &models.AIProviderCreate{Name: "Mock", ProviderType: models.ProviderTypeOpenAI, BaseURL: server.URL, APIKey: "key", IsDefault: true}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	selected := "model"
+	if _, err := providers.Update(provider.ID, user.ID, &models.AIProviderUpdate{SelectedModel: &selected}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	chatRepo = repository.NewChatRepository(db)
+	return NewChatService(chatRepo, providers, nil), chatRepo, user.ID, calls, startedCh, release
+}
+
+func TestGenerateThreadTitleDebounce(t *testing.T) {
+	chat, chatRepo, userID, calls, started, release := newTitleTestChat(t)
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	chat.titleRequested.now = func() time.Time { return now }
+
+	thread := &models.ChatThread{UserID: userID}
+	if err := chatRepo.CreateThread(thread); err != nil {
+		t.Fatal(err)
+	}
+	if err := chatRepo.CreateMessage(&models.ChatMessage{ThreadID: thread.ID, Role: "user", Content: "Help me plan a trip for the weekend"}); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- chat.GenerateThreadTitle(thread.ID) }()
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("title generation didn't call the AI")
+	}
+
+	// While the first generation is in flight, repeats within the TTL are no-ops
+	for _, elapsed := range []time.Duration{0, threadTitleRequestTTL - time.Second} {
+		now = now.Add(elapsed)
+		if err := chat.GenerateThreadTitle(thread.ID); err != nil {
+			t.Fatalf("repeat after %s: %v", elapsed, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("AI called %d times while a generation was in flight, want 1", n)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("GenerateThreadTitle: %v", err)
+	}
+	stored, err := chatRepo.GetThreadByID(thread.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Title == nil || *stored.Title != "Weekend trip plans" {
+		t.Errorf("title = %v, want %q", stored.Title, "Weekend trip plans")
+	}
+	if n := chat.titleRequested.Len(); n != 0 {
+		t.Errorf("%d title requests still tracked after the title was stored, want 0", n)
+	}
+
+	// Past the TTL the stored title keeps the thread from being retitled
+	now = now.Add(2 * threadTitleRequestTTL)
+	if err := chat.GenerateThreadTitle(thread.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("AI called %d times after the title was stored, want 1", n)
+	}
+}
+
+// A thread with no user message yet isn't debounced, so its first message can
+// still trigger generation
+func TestGenerateThreadTitleWaitsForUserMessage(t *testing.T) {
+	chat, chatRepo, userID, calls, _, release := newTitleTestChat(t)
+	close(release)
+
+	thread := &models.ChatThread{UserID: userID}
+	if err := chatRepo.CreateThread(thread); err != nil {
+		t.Fatal(err)
+	}
+	if err := chat.GenerateThreadTitle(thread.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("AI called %d times for a thread without messages, want 0", n)
+	}
+
+	if err := chatRepo.CreateMessage(&models.ChatMessage{ThreadID: thread.ID, Role: "user", Content: "Help me plan a trip for the weekend"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := chat.GenerateThreadTitle(thread.ID); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("AI called %d times after the first message, want 1", n)
+	}
+}
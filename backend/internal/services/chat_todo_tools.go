@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+// Chat action tools for function calling, offered when an ask allows actions
+var chatTodoTools = []Tool{
+	{
+		Type: "function",
+		Function: ToolFunction{
+			Name:        "create_todo",
+			Description: "Create a todo for the user when their message asks for a task to be added or remembered",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Short, actionable todo title without the date, e.g. 'Call mom'",
+					},
+					"priority": map[string]interface{}{
+						"type":        "string",
+						"description": "Priority, only if the user implies one",
+						"enum":        []string{"low", "medium", "high"},
+					},
+					"due_date": map[string]interface{}{
+						"type":        "string",
+						"description": "When it is due, as YYYY-MM-DD or as the user phrased it (e.g. 'tomorrow', 'next friday'). Omit if not mentioned.",
+					},
+					"group_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the list/group to add it to, only if the user names one",
+					},
+				},
+				"required": []string{"title"},
+			},
+		},
+	},
+}
+
+// CreateTodoFunctionResult holds the parsed arguments of a create_todo call
+type CreateTodoFunctionResult struct {
+	Title     string `json:"title"`
+	Priority  string `json:"priority"`
+	DueDate   string `json:"due_date"`
+	GroupName string `json:"group_name"`
+}
+
+// todoCommandVerbs are imperative verbs that open a request to track a task
+var todoCommandVerbs = map[string]bool{
+	"add": true, "create": true, "remind": true, "schedule": true, "make": true, "set": true,
+	"plan": true, "call": true, "email": true, "text": true, "buy": true, "book": true,
+	"pay": true, "send": true, "write": true, "finish": true, "fix": true, "submit": true,
+	"review": true, "pick": true, "clean": true, "order": true, "renew": true, "cancel": true,
+	"prepare": true, "follow": true, "contact": true, "get": true, "todo": true,
+}
+
+var isoDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// looksLikeTodoCommand reports whether a chat message reads as an instruction
+// ("add a todo to ...", "remind me to ...", "buy milk tomorrow") rather than a question
+func looksLikeTodoCommand(message string) bool {
+	message = strings.TrimSpace(message)
+	if strings.HasSuffix(message, "?") {
+		return false
+	}
+
+	words := strings.Fields(strings.ToLower(message))
+	if len(words) > 0 && strings.Trim(words[0], ",!.") == "please" {
+		words = words[1:]
+	}
+	if len(words) < 2 {
+		return false
+	}
+	return todoCommandVerbs[strings.Trim(words[0], ",!.:")]
+}
+
+// createTodoFromChat offers the AI the create_todo tool for message and creates the
+// todo it asks for. Returns a nil todo when the AI chose not to create one, or tool
+// calling isn't available, so the message is answered as usual.
+func (s *RAGService) createTodoFromChat(ctx context.Context, userID, message string) (*models.Todo, error) {
+	config := s.aiConfig(ctx, userID, nil)
+	// Assistants don't expose chat-completions tool calling
+	if config == nil || config.ProviderType == models.ProviderTypeAssistant {
+		return nil, nil
+	}
+
+	messages := []chatMessage{
+		{
+			Role: "system",
+			Content: fmt.Sprintf(`You help the user manage their todo list. Today is %s.
+If the message asks for a task to be added, tracked or remembered, call create_todo.
+Otherwise reply normally without calling any function.`, time.Now().Format("Monday, 2006-01-02")),
+		},
+		{Role: "user", Content: message},
+	}
+
+	resp, err := callOpenAIWithToolMessages(config, messages, chatTodoTools)
+	if err != nil {
+		slog.WarnContext(ctx, "RAG create_todo tool call failed, answering normally", "error", err)
+		return nil, nil
+	}
+	if len(resp.Choices) == 0 {
+		return nil, nil
+	}
+
+	for _, toolCall := range resp.Choices[0].Message.ToolCalls {
+		if toolCall.Function.Name != "create_todo" {
+			continue
+		}
+		slog.DebugContext(ctx, "RAG got create_todo call", "arguments", toolCall.Function.Arguments)
+
+		var args CreateTodoFunctionResult
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil || strings.TrimSpace(args.Title) == "" {
+			slog.WarnContext(ctx, "RAG create_todo arguments invalid, answering normally", "error", err)
+			return nil, nil
+		}
+		return s.todoService.Create(userID, s.todoRequestFromTool(userID, &args))
+	}
+	return nil, nil
+}
+
+// todoRequestFromTool turns create_todo arguments into a create request. A due date
+// the AI didn't resolve to YYYY-MM-DD is left in the title for TodoService to parse
+// in the user's timezone.
+func (s *RAGService) todoRequestFromTool(userID string, args *CreateTodoFunctionResult) *models.TodoCreateRequest {
+	req := &models.TodoCreateRequest{Title: strings.TrimSpace(args.Title)}
+
+	switch priority := models.Priority(strings.ToLower(args.Priority)); priority {
+	case models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+		req.Priority = priority
+	}
+
+	if dueDate := strings.TrimSpace(args.DueDate); dueDate != "" {
+		if isoDatePattern.MatchString(dueDate) {
+			req.DueDate = &dueDate
+		} else {
+			req.Title += " " + dueDate
+		}
+	}
+
+	if args.GroupName != "" {
+		group, err := s.todoService.GroupByName(userID, args.GroupName)
+		if err != nil {
+			slog.Warn("RAG failed to look up group for create_todo", "user_id", userID, "group", args.GroupName, "error", err)
+		} else if group != nil {
+			req.GroupID = &group.ID
+		}
+	}
+	return req
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// A Wednesday, half a minute past midnight
+	start := time.Date(2025, 1, 1, 0, 0, 30, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time // zero when the schedule never fires
+	}{
+		{"every minute", "* * * * *", start, at(1, 1, 0, 1)},
+		{"minute value", "5 * * * *", start, at(1, 1, 0, 5)},
+		{"minute step", "*/15 * * * *", start, at(1, 1, 0, 15)},
+		{"step from a value", "5/20 * * * *", at(1, 1, 0, 5), at(1, 1, 0, 25)},
+		{"list", "30 2 1,15 * *", at(1, 1, 3, 0), at(1, 15, 2, 30)},
+		{"range with a step", "0 9-17/4 * * *", at(1, 1, 10, 0), at(1, 1, 13, 0)},
+		{"end of a range with a step", "0 9-17/4 * * *", at(1, 1, 13, 0), at(1, 1, 17, 0)},
+		{"weekday range by name", "0 0 * * mon-fri", time.Date(2025, 1, 3, 0, 0, 30, 0, time.UTC), at(1, 6, 0, 0)},
+		{"month by name", "0 12 * JUN *", start, at(6, 1, 12, 0)},
+		{"7 is Sunday", "0 0 * * 7", start, at(1, 5, 0, 0)},
+		{"question mark day", "0 0 ? * 0", start, at(1, 5, 0, 0)},
+		{"day of month only", "0 0 13 * *", start, at(1, 13, 0, 0)},
+		{"day of week only", "0 0 * * 5", start, at(1, 3, 0, 0)},
+		{"either day field when both are set", "0 0 13 * 5", at(1, 4, 0, 0), at(1, 10, 0, 0)},
+		{"day of month when both are set", "0 0 13 * 5", at(1, 11, 0, 0), at(1, 13, 0, 0)},
+		{"leap day", "0 0 29 2 *", start, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"day that never comes", "0 0 31 2 *", start, time.Time{}},
+		{"@hourly", "@hourly", start, at(1, 1, 1, 0)},
+		{"@daily", "@daily", start, at(1, 2, 0, 0)},
+		{"@weekly", "@weekly", start, at(1, 5, 0, 0)},
+		{"@monthly", "@monthly", start, at(2, 1, 0, 0)},
+		{"@yearly", "@yearly", start, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"descriptor in another case", "@Daily", start, at(1, 2, 0, 0)},
+		{"@every", "@every 90m", start, start.Add(90 * time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("parseCron(%q): %v", tt.expr, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from.Format(time.RFC3339), got.Format(time.RFC3339), tt.want.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestParseCronRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"* * * smarch *",
+		"@fortnightly",
+		"@every soon",
+		"@every 500ms",
+	} {
+		if _, err := parseCron(expr); !errors.Is(err, ErrInvalidCronExpression) {
+			t.Errorf("parseCron(%q) = %v, want ErrInvalidCronExpression", expr, err)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/todomyday/backend/internal/metrics"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+	"golang.org/x/sync/singleflight"
+)
+
+// dailyBriefingCacheTTL is how long a generated briefing is reused. Changing a todo
+// or memory invalidates the user's briefings sooner.
+const dailyBriefingCacheTTL = 6 * time.Hour
+
+// dailyBriefingCacheMaxEntries bounds the cache; expired entries are swept when it fills
+const dailyBriefingCacheMaxEntries = 5000
+
+// dailyBriefingMemoryDays is how many days back memories are included as context
+const dailyBriefingMemoryDays = 7
+
+// maxDailyBriefingItems limits the todos and memories each put in the prompt
+const maxDailyBriefingItems = 30
+
+var (
+	ErrBriefingAINotConfigured  = errors.New("AI is not configured")
+	ErrBriefingGenerationFailed = errors.New("failed to generate daily briefing")
+)
+
+// DailyBriefingService writes a plan for the user's day with their AI provider. The
+// app has no shared cache, so briefings are cached in process, keyed by
+// dailyBriefingCacheKey.
+type DailyBriefingService struct {
+	todoRepo    *repository.TodoRepository
+	memoryRepo  *repository.MemoryRepository
+	todoService *TodoService
+
+	cache *ttlCache[models.DailyBriefing]
+	// invalidations counts calls to Invalidate, for any user, so a briefing generated
+	// while one happened isn't cached
+	invalidations atomic.Uint64
+	// generating collapses concurrent generations of the same briefing into one
+	generating singleflight.Group
+}
+
+// NewDailyBriefingService creates the service. The todo service supplies the user's
+// timezone and AI configuration.
+func NewDailyBriefingService(todoRepo *repository.TodoRepository, memoryRepo *repository.MemoryRepository, todoService *TodoService) *DailyBriefingService {
+	return &DailyBriefingService{
+		todoRepo:    todoRepo,
+		memoryRepo:  memoryRepo,
+		todoService: todoService,
+		cache:       newTTLCache[models.DailyBriefing](metrics.CacheBriefing, dailyBriefingCacheTTL, dailyBriefingCacheMaxEntries),
+	}
+}
+
+func dailyBriefingCacheKey(userID, date string) string {
+	return "briefing:" + userID + ":" + date
+}
+
+// Get returns the user's briefing for today in their timezone, generating it when
+// there is no cached one. Concurrent requests for the same briefing share one
+// generation.
+func (s *DailyBriefingService) Get(userID string) (*models.DailyBriefing, error) {
+	loc, err := time.LoadLocation(s.todoService.userTimezone(userID))
+	if err != nil {
+		loc = time.UTC
+	}
+	now := time.Now().In(loc)
+	key := dailyBriefingCacheKey(userID, now.Format("2006-01-02"))
+
+	version := s.invalidations.Load()
+	if cached, ok := s.cache.Get(key); ok {
+		cached.Cached = true
+		return &cached, nil
+	}
+
+	// The version is part of the key, so a request after an invalidation doesn't
+	// join a generation that started before it
+	generated, err, _ := s.generating.Do(fmt.Sprintf("%s:%d", key, version), func() (interface{}, error) {
+		briefing, err := s.generate(userID, now, loc)
+		if err != nil {
+			return nil, err
+		}
+		if s.invalidations.Load() == version {
+			s.cache.Set(key, briefing)
+		}
+		return briefing, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	briefing := generated.(models.DailyBriefing)
+	return &briefing, nil
+}
+
+// generate writes the user's briefing for the day of now in loc
+func (s *DailyBriefingService) generate(userID string, now time.Time, loc *time.Location) (models.DailyBriefing, error) {
+	config := s.todoService.getAIConfig(userID)
+	if config == nil {
+		return models.DailyBriefing{}, ErrBriefingAINotConfigured
+	}
+
+	todos, err := s.briefingTodos(userID, now, loc)
+	if err != nil {
+		return models.DailyBriefing{}, err
+	}
+
+	// Memories are compared in server time, like the stored timestamps
+	memories, err := s.memoryRepo.GetByDateRange(userID, now.AddDate(0, 0, -dailyBriefingMemoryDays).Local(), now.Local())
+	if err != nil {
+		return models.DailyBriefing{}, err
+	}
+	sort.SliceStable(memories, func(i, j int) bool {
+		return memories[i].CreatedAt.After(memories[j].CreatedAt)
+	})
+	if len(memories) > maxDailyBriefingItems {
+		memories = memories[:maxDailyBriefingItems]
+	}
+
+	text, err := GenerateDailyBriefingWithProvider(todos, memories, now, config)
+	if err != nil {
+		return models.DailyBriefing{}, fmt.Errorf("%w: %v", ErrBriefingGenerationFailed, err)
+	}
+
+	briefing := models.DailyBriefing{
+		Date:     now.Format("2006-01-02"),
+		Briefing: text,
+		Sources: models.DailyBriefingSources{
+			TodoIDs:   make([]string, 0, len(todos)),
+			MemoryIDs: make([]string, 0, len(memories)),
+		},
+		GeneratedAt: time.Now(),
+	}
+	for _, todo := range todos {
+		briefing.Sources.TodoIDs = append(briefing.Sources.TodoIDs, todo.ID)
+	}
+	for _, memory := range memories {
+		briefing.Sources.MemoryIDs = append(briefing.Sources.MemoryIDs, memory.ID)
+	}
+	return briefing, nil
+}
+
+// briefingTodos returns the user's pending todos due by the end of today in loc,
+// overdue included, soonest first
+func (s *DailyBriefingService) briefingTodos(userID string, now time.Time, loc *time.Location) ([]models.Todo, error) {
+	due, err := s.todoRepo.GetDueByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	endOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+	dueAt := make(map[string]time.Time, len(due))
+	todos := make([]models.Todo, 0, len(due))
+	for _, todo := range due {
+		at, ok := parseAutoPriorityDue(*todo.DueDate, loc)
+		if !ok || !at.Before(endOfToday) {
+			continue
+		}
+		dueAt[todo.ID] = at
+		todos = append(todos, todo)
+	}
+
+	sort.SliceStable(todos, func(i, j int) bool {
+		return dueAt[todos[i].ID].Before(dueAt[todos[j].ID])
+	})
+	if len(todos) > maxDailyBriefingItems {
+		todos = todos[:maxDailyBriefingItems]
+	}
+	return todos, nil
+}
+
+// Invalidate drops the user's cached briefings, so the next one reflects a todo or
+// memory just changed. A briefing being generated meanwhile isn't cached.
+func (s *DailyBriefingService) Invalidate(userID string) {
+	if s == nil {
+		return
+	}
+	s.invalidations.Add(1)
+	s.cache.DeletePrefix(dailyBriefingCacheKey(userID, ""))
+}
+
+// GenerateDailyBriefingWithProvider writes a three-paragraph plan for the day of now
+// from the todos due and the recent memories
+func GenerateDailyBriefingWithProvider(todos []models.Todo, memories []models.Memory, now time.Time, config *AIProviderConfig) (string, error) {
+	if config == nil || config.BaseURL == "" || config.APIKey == "" || config.Model == "" {
+		return "", fmt.Errorf("AI not configured")
+	}
+
+	startOfToday := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var todoList strings.Builder
+	for _, todo := range todos {
+		overdue := ""
+		if at, ok := parseAutoPriorityDue(*todo.DueDate, now.Location()); ok && at.Before(startOfToday) {
+			overdue = ", overdue"
+		}
+		todoList.WriteString(fmt.Sprintf("- %s (priority: %s, due: %s%s)\n", todo.Title, todo.Priority, *todo.DueDate, overdue))
+	}
+	if todoList.Len() == 0 {
+		todoList.WriteString("(no todos due today)\n")
+	}
+
+	var memoryList strings.Builder
+	for _, memory := range memories {
+		content := memory.Content
+		if runes := []rune(content); len(runes) > 300 {
+			content = string(runes[:300]) + "..."
+		}
+		memoryList.WriteString(fmt.Sprintf("- [%s] %s\n", memory.Category, content))
+	}
+	if memoryList.Len() == 0 {
+		memoryList.WriteString("(no notes this week)\n")
+	}
+
+	prompt := fmt.Sprintf(`You are a personal assistant planning someone's day. Today is %s.
+
+Todos due today or overdue:
+%s
+Notes saved in the last week:
+%s
+Write a daily plan in exactly three short paragraphs, each starting with its heading on its own line:
+Today's priorities - what to get done today, most urgent first, calling out overdue items
+Relevant context from your notes - notes that bear on today's todos
+Suggested focus area - the one thing most worth focusing on and why
+
+Be specific and reference actual items. Don't invent todos or notes.`, now.Format("Monday, January 2, 2006"), todoList.String(), memoryList.String())
+
+	briefingConfig := *config
+	briefingConfig.TextResponse = true
+
+	var respContent string
+	var err error
+
+	switch config.ProviderType {
+	case models.ProviderTypeAssistant:
+		respContent, err = callAssistant(&briefingConfig, prompt)
+	case models.ProviderTypeAnthropic:
+		respContent, err = callAnthropic(&briefingConfig, prompt)
+	case models.ProviderTypeGoogle:
+		respContent, err = callGoogle(&briefingConfig, prompt)
+	default:
+		respContent, err = callOpenAICompatible(&briefingConfig, prompt)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(respContent), nil
+}
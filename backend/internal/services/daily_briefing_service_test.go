@@ -0,0 +1,91 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// TestDailyBriefingInvalidation checks a cached briefing is reused until the user's
+// todos or memories change or their data is cleared
+func TestDailyBriefingInvalidation(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "Plan for today"}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	db := newTestDB(t)
+	user := newTestUser(t, db, "briefing@example.com")
+	userRepo := repository.NewUserRepository(db)
+	todoRepo := repository.NewTodoRepository(db)
+	memoryRepo := repository.NewMemoryRepository(db)
+	groupRepo := repository.NewGroupRepository(db)
+
+	todos := NewTodoService(todoRepo, groupRepo, userRepo, NewAIService(server.URL, "key", "model"), nil, nil, nil, nil, NewUserPreferencesService(userRepo))
+	memories := NewMemoryService(memoryRepo, todoRepo, groupRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	userData := NewUserDataService(userRepo, memoryRepo, todoRepo, groupRepo, nil, nil, nil, nil, nil, nil, nil)
+	briefings := NewDailyBriefingService(todoRepo, memoryRepo, todos)
+	todos.SetDailyBriefingService(briefings)
+	memories.SetDailyBriefingService(briefings)
+	userData.SetDailyBriefingService(briefings)
+
+	dueDate := time.Now().UTC().Format(time.RFC3339)
+	todo := &models.Todo{UserID: user.ID, Title: "Call the bank", Priority: models.PriorityMedium, Status: models.StatusPending, DueDate: &dueDate}
+	if err := todoRepo.Create(todo); err != nil {
+		t.Fatal(err)
+	}
+	memory := &models.Memory{UserID: user.ID, Content: "The bank opens at nine", Category: "Notes"}
+	if err := memoryRepo.Create(memory); err != nil {
+		t.Fatal(err)
+	}
+
+	// get fetches the briefing and checks whether it was generated or cached
+	get := func(step string, wantGenerated bool) {
+		t.Helper()
+		before := calls.Load()
+		briefing, err := briefings.Get(user.ID)
+		if err != nil {
+			t.Fatalf("%s: Get: %v", step, err)
+		}
+		generated := calls.Load() > before
+		if generated != wantGenerated || briefing.Cached == wantGenerated {
+			t.Errorf("%s: generated = %v, cached = %v, want generated = %v", step, generated, briefing.Cached, wantGenerated)
+		}
+	}
+
+	get("first request", true)
+	get("second request", false)
+
+	high := models.PriorityHigh
+	if _, err := todos.Update(user.ID, todo.ID, &models.TodoUpdateRequest{Priority: &high}); err != nil {
+		t.Fatalf("Update todo: %v", err)
+	}
+	get("after updating a todo", true)
+	get("after updating a todo, again", false)
+
+	if err := memories.Delete(user.ID, memory.ID, ""); err != nil {
+		t.Fatalf("Delete memory: %v", err)
+	}
+	get("after deleting a memory", true)
+
+	if err := todos.Delete(user.ID, todo.ID, ""); err != nil {
+		t.Fatalf("Delete todo: %v", err)
+	}
+	get("after deleting a todo", true)
+
+	if _, err := userData.ClearAllData(user.ID, ""); err != nil {
+		t.Fatalf("ClearAllData: %v", err)
+	}
+	get("after clearing data", true)
+	get("after clearing data, again", false)
+}
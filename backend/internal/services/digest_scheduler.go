@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+const (
+	// DigestSchedule is the default cron schedule of the check for digest emails that are due
+	DigestSchedule = "*/15 * * * *"
+	// DigestSendWeekday and DigestSendHour are when digests go out, in each user's timezone
+	DigestSendWeekday = time.Sunday
+	DigestSendHour    = 9
+	// digestSendWindow is how long after its slot a digest is still sent (e.g. after
+	// downtime); past that the week is skipped rather than mailed days late
+	digestSendWindow = 24 * time.Hour
+)
+
+var digestEmailTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', sans-serif; color: #1f2937; max-width: 600px; margin: 0 auto; padding: 24px;">
+<h1 style="font-size: 20px; margin-bottom: 4px;">Your weekly digest</h1>
+<p style="color: #6b7280; margin-top: 0;">{{.Period}}</p>
+{{.Content}}
+<p style="color: #9ca3af; font-size: 12px; margin-top: 32px;">You're receiving this because weekly digest emails are turned on in your settings.</p>
+</body>
+</html>
+`))
+
+// DigestScheduler emails each opted-in user the digest of their past week
+type DigestScheduler struct {
+	userRepo      *repository.UserRepository
+	memoryService *MemoryService
+	emailService  *EmailService
+	now           func() time.Time
+}
+
+func NewDigestScheduler(userRepo *repository.UserRepository, memoryService *MemoryService, emailService *EmailService) *DigestScheduler {
+	return &DigestScheduler{
+		userRepo:      userRepo,
+		memoryService: memoryService,
+		emailService:  emailService,
+		now:           time.Now,
+	}
+}
+
+// SendDue emails the digest of the week just ended to every opted-in user whose send
+// slot has passed since their last digest email, and returns how many were sent.
+// last_digest_sent_at is what keeps a restart from sending a week twice.
+func (s *DigestScheduler) SendDue(ctx context.Context) (int, error) {
+	users, err := s.userRepo.GetDigestRecipients()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load digest recipients: %w", err)
+	}
+
+	now := s.now()
+	sent := 0
+	for i := range users {
+		if ctx.Err() != nil {
+			break
+		}
+		user := &users[i]
+		slot, due := dueDigestSlot(user, now)
+		if !due {
+			continue
+		}
+
+		if err := s.sendDigest(user, slot); err != nil {
+			log.Printf("[DigestScheduler] Failed to send digest to user %s: %v", user.ID, err)
+			continue
+		}
+		if err := s.userRepo.MarkDigestSent(user.ID, now); err != nil {
+			log.Printf("[DigestScheduler] Failed to record digest sent for user %s: %v", user.ID, err)
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		log.Printf("[DigestScheduler] Sent %d weekly digest emails", sent)
+	}
+	return sent, ctx.Err()
+}
+
+func (s *DigestScheduler) sendDigest(user *models.User, slot time.Time) error {
+	// The digest covers the week that ended as the slot began
+	digest, err := s.memoryService.GetOrGenerateDigestForWeek(user.ID, slot.AddDate(0, 0, -7), false)
+	if err != nil {
+		return err
+	}
+
+	msg, err := RenderDigestEmail(user, digest)
+	if err != nil {
+		return err
+	}
+	return s.emailService.Send(msg)
+}
+
+// dueDigestSlot returns the user's most recent send slot (DigestSendWeekday at
+// DigestSendHour in their timezone) and whether their digest for it is still to be sent
+func dueDigestSlot(user *models.User, now time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+
+	daysSince := (int(local.Weekday()) - int(DigestSendWeekday) + 7) % 7
+	slot := time.Date(local.Year(), local.Month(), local.Day()-daysSince, DigestSendHour, 0, 0, 0, loc)
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -7)
+	}
+
+	if local.Sub(slot) > digestSendWindow {
+		return slot, false
+	}
+	if user.LastDigestSentAt != nil && !user.LastDigestSentAt.Before(slot) {
+		return slot, false
+	}
+	return slot, true
+}
+
+// RenderDigestEmail builds the weekly digest email, with the digest's Markdown rendered
+// to sanitized HTML for the HTML part and kept as it is for the plain-text part
+func RenderDigestEmail(user *models.User, digest *models.MemoryDigest) (*EmailMessage, error) {
+	period := formatDigestPeriod(digest.WeekStart, digest.WeekEnd)
+
+	content, err := RenderMarkdown(digest.DigestContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var html bytes.Buffer
+	if err := digestEmailTemplate.Execute(&html, struct {
+		Period  string
+		Content template.HTML
+	}{
+		Period:  period,
+		Content: template.HTML(content), // already sanitized by RenderMarkdown
+	}); err != nil {
+		return nil, err
+	}
+
+	return &EmailMessage{
+		To:      user.Email,
+		Subject: "Your weekly digest: " + period,
+		Text:    fmt.Sprintf("Your weekly digest (%s)\n\n%s\n", period, digest.DigestContent),
+		HTML:    html.String(),
+	}, nil
+}
+
+// formatDigestPeriod turns a digest's YYYY-MM-DD bounds into e.g. "Jan 5 - Jan 11, 2025"
+func formatDigestPeriod(weekStart, weekEnd string) string {
+	start, err := time.Parse("2006-01-02", weekStart)
+	if err != nil {
+		return weekStart + " - " + weekEnd
+	}
+	end, err := time.Parse("2006-01-02", weekEnd)
+	if err != nil {
+		return weekStart + " - " + weekEnd
+	}
+	return start.Format("Jan 2") + " - " + end.Format("Jan 2, 2006")
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailMessage is a plain-text email with an HTML alternative
+type EmailMessage struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// sendMailFunc matches smtp.SendMail, so tests can swap in a fake server
+type sendMailFunc func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailService sends email through an SMTP server
+type EmailService struct {
+	host     string
+	port     int
+	user     string
+	pass     string
+	from     string
+	sendMail sendMailFunc
+}
+
+func NewEmailService(host string, port int, user, pass, from string) *EmailService {
+	return &EmailService{
+		host:     host,
+		port:     port,
+		user:     user,
+		pass:     pass,
+		from:     from,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// IsConfigured reports whether an SMTP server and sender address are set
+func (s *EmailService) IsConfigured() bool {
+	return s.host != "" && s.from != ""
+}
+
+// Send delivers msg as a multipart/alternative email. smtp.SendMail upgrades to
+// STARTTLS when the server offers it, which PLAIN auth requires for remote hosts.
+func (s *EmailService) Send(msg *EmailMessage) error {
+	if !s.IsConfigured() {
+		return fmt.Errorf("email not configured")
+	}
+
+	// SMTP_FROM may include a display name; the envelope takes just the address
+	sender, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+
+	body, err := s.buildMessage(msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.user != "" {
+		auth = smtp.PlainAuth("", s.user, s.pass, s.host)
+	}
+
+	addr := s.host + ":" + strconv.Itoa(s.port)
+	if err := s.sendMail(addr, auth, sender.Address, []string{msg.To}, body); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// buildMessage renders the RFC 5322 message, with the text and HTML bodies as
+// quoted-printable parts
+func (s *EmailService) buildMessage(msg *EmailMessage, date time.Time) ([]byte, error) {
+	// Header values come from user data; a line break would let it add headers
+	for _, value := range []string{msg.To, msg.Subject, s.from} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("invalid email header value")
+		}
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "From: %s\r\n", s.from)
+	fmt.Fprintf(&out, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&out, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&out, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	out.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&out, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
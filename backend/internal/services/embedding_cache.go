@@ -0,0 +1,76 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/todomyday/backend/internal/metrics"
+)
+
+const (
+	// EmbeddingCacheTTL is how long a cached embedding stays valid
+	EmbeddingCacheTTL = 24 * time.Hour
+	// embeddingCacheMaxEntries bounds memory use (~4KB per 1024-dim vector)
+	embeddingCacheMaxEntries = 10000
+	// embeddingCacheKeyPrefix namespaces embedding keys in the cache
+	embeddingCacheKeyPrefix = "cache:embed:"
+	// embeddingCacheStatsInterval is how many lookups pass between hit ratio logs
+	embeddingCacheStatsInterval = 100
+)
+
+// embeddingCache is an in-process TTL cache for embeddings keyed by content hash,
+// which logs its hit ratio
+type embeddingCache struct {
+	entries *ttlCache[[]float32]
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+func newEmbeddingCache() *embeddingCache {
+	return &embeddingCache{
+		entries: newTTLCache[[]float32](metrics.CacheEmbedding, EmbeddingCacheTTL, embeddingCacheMaxEntries),
+	}
+}
+
+// embeddingCacheKey builds the cache key for sanitized text. The model and input
+// type are part of the key so a model change never serves stale vectors.
+func embeddingCacheKey(model string, inputType InputType, text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return embeddingCacheKeyPrefix + model + ":" + string(inputType) + ":" + hex.EncodeToString(sum[:])
+}
+
+// get returns a cached embedding and records the hit or miss
+func (c *embeddingCache) get(key string) ([]float32, bool) {
+	embedding, ok := c.entries.Get(key)
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	c.logStats()
+	return embedding, ok
+}
+
+// set stores an embedding for EmbeddingCacheTTL
+func (c *embeddingCache) set(key string, embedding []float32) {
+	c.entries.Set(key, embedding)
+}
+
+// deletePrefix removes all keys with the given prefix and returns how many were removed
+func (c *embeddingCache) deletePrefix(prefix string) int {
+	return c.entries.DeletePrefix(prefix)
+}
+
+// logStats logs the hit ratio every embeddingCacheStatsInterval lookups
+func (c *embeddingCache) logStats() {
+	hits, misses := c.hits.Load(), c.misses.Load()
+	total := hits + misses
+	if total == 0 || total%embeddingCacheStatsInterval != 0 {
+		return
+	}
+	log.Printf("[Embedding] INFO cache stats: hits=%d misses=%d hit_ratio=%.2f entries=%d",
+		hits, misses, float64(hits)/float64(total), c.entries.Len())
+}
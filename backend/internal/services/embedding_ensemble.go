@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+// EnsembleMember is one embedding model of an EnsembleEmbeddingService
+type EnsembleMember struct {
+	// ID names the member in logs: "nim" or an AI provider ID
+	ID        string
+	Dimension int
+	Weight    float64
+	Embed     func(ctx context.Context, text string, inputType InputType) ([]float32, error)
+}
+
+// NewEnsembleMember makes an embedding service a member of an ensemble
+func NewEnsembleMember(id string, service *EmbeddingService, weight float64) EnsembleMember {
+	return EnsembleMember{
+		ID:        id,
+		Dimension: service.GetDimension(),
+		Weight:    weight,
+		Embed:     service.EmbedWithType,
+	}
+}
+
+// EnsembleEmbeddingService embeds text with several models at once and combines their
+// vectors, so search isn't limited by the blind spots of any one model. Each member's
+// vector is normalized and scaled by the square root of its weight, which makes the
+// cosine similarity of two concatenated vectors the weighted mean of the members'.
+// A member that fails is left out (zeros in concat mode) and logged; only when every
+// member fails does embedding fail.
+type EnsembleEmbeddingService struct {
+	mode    models.EmbeddingEnsembleMode
+	members []EnsembleMember
+}
+
+// NewEnsembleEmbeddingService creates an ensemble of members combined by mode.
+// Members without a weight get 1, and average mode needs members of one dimension.
+func NewEnsembleEmbeddingService(mode models.EmbeddingEnsembleMode, members ...EnsembleMember) (*EnsembleEmbeddingService, error) {
+	if mode == "" {
+		mode = models.EnsembleModeConcat
+	}
+	if mode != models.EnsembleModeConcat && mode != models.EnsembleModeAverage {
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidEmbeddingEnsemble, mode)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("%w: at least one provider is required", ErrInvalidEmbeddingEnsemble)
+	}
+
+	for i := range members {
+		if members[i].Weight <= 0 {
+			members[i].Weight = 1
+		}
+		if mode == models.EnsembleModeAverage && members[i].Dimension != members[0].Dimension {
+			return nil, fmt.Errorf("%w: average mode needs one dimension, %s has %d and %s has %d",
+				ErrInvalidEmbeddingEnsemble, members[0].ID, members[0].Dimension, members[i].ID, members[i].Dimension)
+		}
+	}
+
+	return &EnsembleEmbeddingService{mode: mode, members: members}, nil
+}
+
+// LoadEmbeddingEnsemble builds the ensemble stored in system settings. The "nim"
+// provider is the global embedding service, which may be nil if NIM isn't set up;
+// other IDs are AI providers with an embedding model.
+func LoadEmbeddingEnsemble(settings *SystemSettingsService, nim *EmbeddingService, providers *AIProviderService) (*EnsembleEmbeddingService, error) {
+	config, err := settings.GetEmbeddingEnsemble()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, fmt.Errorf("%w: the %s system setting isn't set", ErrInvalidEmbeddingEnsemble, models.SettingEmbeddingEnsemble)
+	}
+
+	members := make([]EnsembleMember, 0, len(config.Providers))
+	for _, provider := range config.Providers {
+		if provider.ProviderID == models.EnsembleProviderNIM {
+			if nim == nil {
+				return nil, fmt.Errorf("%w: NIM embeddings aren't configured", ErrInvalidEmbeddingEnsemble)
+			}
+			members = append(members, NewEnsembleMember(provider.ProviderID, nim, provider.Weight))
+			continue
+		}
+
+		service, err := providers.EmbeddingServiceFor(provider.ProviderID)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, NewEnsembleMember(provider.ProviderID, service, provider.Weight))
+	}
+
+	return NewEnsembleEmbeddingService(config.Mode, members...)
+}
+
+// GetDimension returns the size of the ensemble's vectors: the sum of the members'
+// dimensions in concat mode, their shared dimension in average mode
+func (s *EnsembleEmbeddingService) GetDimension() int {
+	if s.mode == models.EnsembleModeAverage {
+		return s.members[0].Dimension
+	}
+	dimension := 0
+	for _, member := range s.members {
+		dimension += member.Dimension
+	}
+	return dimension
+}
+
+// GetModel describes the ensemble's members, for logs
+func (s *EnsembleEmbeddingService) GetModel() string {
+	ids := make([]string, len(s.members))
+	for i, member := range s.members {
+		ids[i] = member.ID
+	}
+	return fmt.Sprintf("ensemble(%s: %s)", s.mode, strings.Join(ids, ", "))
+}
+
+// IsConfigured returns true as an ensemble is only built from configured members
+func (s *EnsembleEmbeddingService) IsConfigured() bool {
+	return len(s.members) > 0
+}
+
+// EmbedPassage generates an ensemble embedding for a document passage
+func (s *EnsembleEmbeddingService) EmbedPassage(ctx context.Context, text string) ([]float32, error) {
+	return s.EmbedEnsemble(ctx, text, InputTypePassage)
+}
+
+// EmbedQuery generates an ensemble embedding for a search query
+func (s *EnsembleEmbeddingService) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return s.EmbedEnsemble(ctx, text, InputTypeQuery)
+}
+
+// EmbedEnsemble embeds text with every member concurrently and combines the vectors
+func (s *EnsembleEmbeddingService) EmbedEnsemble(ctx context.Context, text string, inputType InputType) ([]float32, error) {
+	vectors := make([][]float32, len(s.members))
+	errs := make([]error, len(s.members))
+
+	var wg sync.WaitGroup
+	for i, member := range s.members {
+		wg.Add(1)
+		go func(i int, member EnsembleMember) {
+			defer wg.Done()
+			vector, err := member.Embed(ctx, text, inputType)
+			if err == nil && len(vector) != member.Dimension {
+				err = fmt.Errorf("got %d dimensions, expected %d", len(vector), member.Dimension)
+			}
+			vectors[i], errs[i] = vector, err
+		}(i, member)
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			log.Printf("[EmbeddingEnsemble] Member %s failed, embedding without it: %v", s.members[i].ID, err)
+			failed++
+		}
+	}
+	if failed == len(s.members) {
+		return nil, fmt.Errorf("every ensemble member failed: %w", errors.Join(errs...))
+	}
+
+	if s.mode == models.EnsembleModeAverage {
+		return s.average(vectors), nil
+	}
+	return s.concat(vectors), nil
+}
+
+// concat joins the members' weighted vectors, leaving zeros where a member failed
+func (s *EnsembleEmbeddingService) concat(vectors [][]float32) []float32 {
+	combined := make([]float32, 0, s.GetDimension())
+	for i, member := range s.members {
+		if vectors[i] == nil {
+			combined = append(combined, make([]float32, member.Dimension)...)
+			continue
+		}
+		combined = append(combined, weighted(vectors[i], math.Sqrt(member.Weight))...)
+	}
+	return combined
+}
+
+// average returns the weighted mean of the vectors of the members that succeeded
+func (s *EnsembleEmbeddingService) average(vectors [][]float32) []float32 {
+	combined := make([]float32, s.GetDimension())
+	totalWeight := 0.0
+	for i, member := range s.members {
+		if vectors[i] == nil {
+			continue
+		}
+		for j, v := range weighted(vectors[i], member.Weight) {
+			combined[j] += v
+		}
+		totalWeight += member.Weight
+	}
+	for j := range combined {
+		combined[j] = float32(float64(combined[j]) / totalWeight)
+	}
+	return combined
+}
+
+// weighted returns vector normalized to unit length and scaled by scale
+func weighted(vector []float32, scale float64) []float32 {
+	norm := 0.0
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	norm = math.Sqrt(norm)
+
+	result := make([]float32, len(vector))
+	if norm == 0 {
+		return result
+	}
+	for i, v := range vector {
+		result[i] = float32(float64(v) / norm * scale)
+	}
+	return result
+}
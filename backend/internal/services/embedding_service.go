@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // InputType represents the type of input for NIM embeddings
@@ -37,13 +39,25 @@ type EmbeddingService struct {
 	// Rate limiting
 	mu              sync.Mutex
 	lastRequestTime time.Time
+
+	// Cache of embeddings for previously seen text
+	cache *embeddingCache
+
+	// omitInputType drops the NIM-specific input_type field, which OpenAI rejects
+	omitInputType bool
+	// ollama uses Ollama's native /api/embeddings endpoint; baseURL is the server root
+	ollama bool
+	// noAuth omits the Authorization header
+	noAuth bool
+	// providerType labels trace spans ("nim" for the global service)
+	providerType string
 }
 
 // NIM embedding request type
 type nimEmbeddingRequest struct {
 	Model          string `json:"model"`
 	Input          string `json:"input"`
-	InputType      string `json:"input_type"`
+	InputType      string `json:"input_type,omitempty"`
 	EncodingFormat string `json:"encoding_format"`
 }
 
@@ -86,7 +100,24 @@ func NewEmbeddingService(baseURL, apiKey, model string, rpmLimit, dimension int)
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		cache:        newEmbeddingCache(),
+		providerType: "nim",
+	}
+}
+
+// NewUserEmbeddingService creates an embedding service for a user's own provider.
+// OpenAI and assistant providers use the plain OpenAI request format; custom
+// providers are assumed to be NIM-compatible, and Ollama uses its native API.
+func NewUserEmbeddingService(provider *models.AIProvider, apiKey string) *EmbeddingService {
+	svc := NewEmbeddingService(provider.BaseURL, apiKey, *provider.EmbeddingModel, provider.RequestsPerMinute, *provider.EmbeddingDimension)
+	svc.omitInputType = provider.ProviderType != models.ProviderTypeCustom
+	svc.providerType = string(provider.ProviderType)
+	svc.noAuth = !sendsAuthHeader(provider.ProviderType, provider.NoAuth)
+	if provider.ProviderType == models.ProviderTypeOllama {
+		svc.ollama = true
+		svc.baseURL = ollamaRootURL(provider.BaseURL)
 	}
+	return svc
 }
 
 // IsConfigured returns true if the service is properly configured
@@ -136,7 +167,7 @@ func (s *EmbeddingService) EmbedPassage(ctx context.Context, text string) ([]flo
 }
 
 // EmbedWithType generates an embedding with the specified input type
-func (s *EmbeddingService) EmbedWithType(ctx context.Context, text string, inputType InputType) ([]float32, error) {
+func (s *EmbeddingService) EmbedWithType(ctx context.Context, text string, inputType InputType) (vector []float32, err error) {
 	if !s.IsConfigured() {
 		return nil, fmt.Errorf("embedding service not configured")
 	}
@@ -151,18 +182,45 @@ func (s *EmbeddingService) EmbedWithType(ctx context.Context, text string, input
 	// Truncate if too long
 	text = TruncateForEmbedding(text)
 
+	// Identical content re-indexed with the same model doesn't need another API call
+	cacheKey := embeddingCacheKey(s.model, inputType, text)
+	if embedding, ok := s.cache.get(cacheKey); ok {
+		return embedding, nil
+	}
+
+	ctx, span := tracer.Start(ctx, "embedding.embed",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			tracing.AttrProviderType.String(s.providerType),
+			tracing.AttrModel.String(s.model),
+		),
+	)
+	defer func() { endSpan(span, err) }()
+
 	// Enforce rate limiting
 	s.rateLimit()
 
 	log.Printf("[Embedding] Generating embedding using model %s (type: %s, len: %d)",
 		s.model, inputType, len(text))
 
+	if s.ollama {
+		embedding, err := s.embedOllama(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		s.cache.set(cacheKey, embedding)
+		return embedding, nil
+	}
+
 	reqBody := nimEmbeddingRequest{
 		Model:          s.model,
 		Input:          text,
 		InputType:      string(inputType),
 		EncodingFormat: "float",
 	}
+	if s.omitInputType {
+		reqBody.InputType = ""
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
@@ -176,7 +234,7 @@ func (s *EmbeddingService) EmbedWithType(ctx context.Context, text string, input
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	s.setAuthHeader(req)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -205,12 +263,77 @@ func (s *EmbeddingService) EmbedWithType(ctx context.Context, text string, input
 	}
 
 	embedding := embeddingResp.Data[0].Embedding
+	span.SetAttributes(tracing.AttrInputTokens.Int(embeddingResp.Usage.PromptTokens))
 	log.Printf("[Embedding] Successfully generated embedding (dimension: %d, tokens: %d)",
 		len(embedding), embeddingResp.Usage.TotalTokens)
 
+	s.cache.set(cacheKey, embedding)
+
 	return embedding, nil
 }
 
+// embedOllama requests an embedding from Ollama's /api/embeddings endpoint, which
+// takes a prompt and returns a single vector instead of an OpenAI-style data list
+func (s *EmbeddingService) embedOllama(ctx context.Context, text string) ([]float32, error) {
+	jsonBody, err := json.Marshal(map[string]string{
+		"model":  s.model,
+		"prompt": text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/api/embeddings", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.setAuthHeader(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("[Embedding] Ollama error response: %s", string(body))
+		return nil, fmt.Errorf("Ollama API error: %s - %s", resp.Status, string(body))
+	}
+
+	var embeddingResp struct {
+		Embedding []float32 `json:"embedding"`
+	}
+	if err := json.Unmarshal(body, &embeddingResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(embeddingResp.Embedding) == 0 {
+		return nil, fmt.Errorf("no embedding returned")
+	}
+
+	log.Printf("[Embedding] Successfully generated Ollama embedding (dimension: %d)", len(embeddingResp.Embedding))
+	return embeddingResp.Embedding, nil
+}
+
+// setAuthHeader sets the bearer token unless the provider doesn't take one
+func (s *EmbeddingService) setAuthHeader(req *http.Request) {
+	if !s.noAuth {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+}
+
+// FlushEmbeddingCache clears all cached embeddings, e.g. before a bulk re-index.
+// Cache keys are content hashes shared across users, so the whole cache is cleared.
+func (s *EmbeddingService) FlushEmbeddingCache(userID string) {
+	deleted := s.cache.deletePrefix(embeddingCacheKeyPrefix)
+	log.Printf("[Embedding] Flushed %d cached embeddings (requested by user %s)", deleted, userID)
+}
+
 // EmbedBatch generates embeddings for multiple texts (one at a time with rate limiting)
 func (s *EmbeddingService) EmbedBatch(ctx context.Context, texts []string, inputType InputType) ([][]float32, error) {
 	if !s.IsConfigured() {
@@ -238,7 +361,11 @@ func (s *EmbeddingService) EmbedBatch(ctx context.Context, texts []string, input
 func (s *EmbeddingService) HealthCheck() bool {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	return s.HealthCheckContext(ctx)
+}
 
+// HealthCheckContext is HealthCheck bounded by ctx instead of its own timeout
+func (s *EmbeddingService) HealthCheckContext(ctx context.Context) bool {
 	url := s.baseURL + "/models"
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// epubChapter is a single spine entry extracted from an EPUB
+type epubChapter struct {
+	Heading string
+	Text    string
+}
+
+// EPUB container/package document types (only the fields we need)
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+type epubEncryption struct {
+	EncryptedData []struct {
+		Method struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"EncryptionMethod"`
+	} `xml:"EncryptedData"`
+}
+
+// Font obfuscation algorithms are allowed in DRM-free EPUBs
+var epubFontObfuscationAlgorithms = map[string]bool{
+	"http://www.idpf.org/2008/embedding": true,
+	"http://ns.adobe.com/pdf/enc#RC":     true,
+}
+
+// parseEpubFile extracts chapters from an EPUB e-book, one section per chapter
+func (s *FileParserService) parseEpubFile(filename string, content []byte) ([]ParsedMemorySection, error) {
+	chapters, err := readEpubChapters(content)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := []ParsedMemorySection{}
+	for i, chapter := range chapters {
+		heading := chapter.Heading
+		if heading == "" {
+			heading = fmt.Sprintf("%s (Chapter %d)", filename, i+1)
+		}
+
+		if len(chapter.Text) <= maxCharsPerSection {
+			sections = append(sections, ParsedMemorySection{
+				Content: chapter.Text,
+				Heading: heading,
+				Order:   len(sections),
+			})
+			continue
+		}
+
+		// Split long chapters the same way as large PDFs
+		chunks := s.splitTextIntoChunks(chapter.Text, maxCharsPerSection)
+		for j, chunk := range chunks {
+			sections = append(sections, ParsedMemorySection{
+				Content: chunk,
+				Heading: fmt.Sprintf("%s (Part %d)", heading, j+1),
+				Order:   len(sections),
+			})
+		}
+	}
+
+	if len(sections) == 0 {
+		return nil, &FileUploadError{
+			Code:    "empty_file",
+			Message: "Could not extract text from EPUB",
+		}
+	}
+
+	return sections, nil
+}
+
+// readEpubChapters opens the EPUB archive and returns the non-empty chapters in spine order
+func readEpubChapters(content []byte) ([]epubChapter, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, &FileUploadError{
+			Code:    "parse_error",
+			Message: fmt.Sprintf("Failed to parse EPUB: %v", err),
+		}
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	if isEpubDRMProtected(files) {
+		return nil, &FileUploadError{
+			Code:    "drm_protected",
+			Message: "EPUB is DRM protected and cannot be imported",
+		}
+	}
+
+	// Locate the package document via META-INF/container.xml
+	var container epubContainer
+	if err := readEpubXML(files, "META-INF/container.xml", &container); err != nil || len(container.Rootfiles) == 0 {
+		return nil, &FileUploadError{
+			Code:    "parse_error",
+			Message: "Failed to parse EPUB: missing container.xml",
+		}
+	}
+
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := readEpubXML(files, opfPath, &pkg); err != nil {
+		return nil, &FileUploadError{
+			Code:    "parse_error",
+			Message: fmt.Sprintf("Failed to parse EPUB package: %v", err),
+		}
+	}
+
+	manifest := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		if item.MediaType == "application/xhtml+xml" || item.MediaType == "text/html" {
+			manifest[item.ID] = item.Href
+		}
+	}
+
+	baseDir := path.Dir(opfPath)
+	var chapters []epubChapter
+
+	for _, ref := range pkg.Spine {
+		href, ok := manifest[ref.IDRef]
+		if !ok {
+			continue
+		}
+
+		f, ok := files[path.Join(baseDir, href)]
+		if !ok {
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			continue // Skip chapters that fail to read
+		}
+
+		chapter := extractEpubChapter(data)
+		if chapter.Text == "" {
+			continue
+		}
+		chapters = append(chapters, chapter)
+	}
+
+	return chapters, nil
+}
+
+// isEpubDRMProtected reports whether the EPUB has encrypted content beyond font obfuscation
+func isEpubDRMProtected(files map[string]*zip.File) bool {
+	if _, ok := files["META-INF/rights.xml"]; ok {
+		return true
+	}
+
+	var enc epubEncryption
+	if err := readEpubXML(files, "META-INF/encryption.xml", &enc); err != nil {
+		return false
+	}
+	for _, data := range enc.EncryptedData {
+		if !epubFontObfuscationAlgorithms[data.Method.Algorithm] {
+			return true
+		}
+	}
+	return false
+}
+
+func readEpubXML(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%s not found", name)
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(data, v)
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, MaxPDFFileSize))
+}
+
+// extractEpubChapter strips HTML from a chapter document, using the first heading as its title
+func extractEpubChapter(data []byte) epubChapter {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return epubChapter{}
+	}
+
+	var chapter epubChapter
+	var sb strings.Builder
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "head":
+				return
+			case "h1", "h2", "h3":
+				if chapter.Heading == "" {
+					chapter.Heading = strings.Join(strings.Fields(nodeText(n)), " ")
+				}
+			}
+		}
+
+		if n.Type == html.TextNode {
+			if text := strings.Join(strings.Fields(n.Data), " "); text != "" {
+				sb.WriteString(text)
+				sb.WriteString(" ")
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+
+		// Preserve paragraph structure for chunking
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "p", "div", "h1", "h2", "h3", "h4", "h5", "h6", "li", "blockquote", "br":
+				sb.WriteString("\n\n")
+			}
+		}
+	}
+	walk(doc)
+
+	paragraphs := strings.Split(sb.String(), "\n\n")
+	var cleaned []string
+	for _, p := range paragraphs {
+		if p = strings.TrimSpace(p); p != "" {
+			cleaned = append(cleaned, p)
+		}
+	}
+	chapter.Text = strings.Join(cleaned, "\n\n")
+
+	return chapter
+}
+
+// nodeText returns the concatenated text of a node and its descendants
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+		sb.WriteString(" ")
+	}
+	return sb.String()
+}
@@ -0,0 +1,24 @@
+package services
+
+import "github.com/todomyday/backend/internal/models"
+
+// codedError is a service error that says how it is sent to API clients, so handlers
+// can record it with c.Error as is
+type codedError struct {
+	code    string
+	message string
+}
+
+// newCodedError returns an error sent to clients with code and message, for sentinel
+// errors whose message is safe to show
+func newCodedError(code, message string) error {
+	return &codedError{code: code, message: message}
+}
+
+func (e *codedError) Error() string {
+	return e.message
+}
+
+func (e *codedError) APIError() models.APIError {
+	return models.APIError{Code: e.code, Message: e.message}
+}
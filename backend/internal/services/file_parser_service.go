@@ -3,36 +3,58 @@ package services
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/ledongthuc/pdf"
+	"github.com/todomyday/backend/internal/models"
+	"gopkg.in/yaml.v3"
 )
 
 // FileParserService handles parsing of uploaded files
-type FileParserService struct{}
+type FileParserService struct {
+	visionService *VisionService
+	// OCRFallbackEnabled runs scanned (image-only) PDF pages through the vision model
+	OCRFallbackEnabled bool
+}
 
 // ParsedMemorySection represents a section extracted from a file
 type ParsedMemorySection struct {
 	Content string // The text content
 	Heading string // For MD: the heading text, for TXT: filename
 	Order   int    // Position in original file (for sorting)
+	Source  SectionSource
 }
 
+// SectionSource records where a section was imported from
+type SectionSource string
+
+const (
+	SectionSourceMarkdown   SectionSource = "markdown"
+	SectionSourcePDF        SectionSource = "pdf"
+	SectionSourceZip        SectionSource = "zip"         // a .md or .txt note in a ZIP archive
+	SectionSourceAppleNotes SectionSource = "apple_notes" // an Apple Notes HTML export in a ZIP archive
+)
+
 // FileMetadata contains metadata about parsed files
 type FileMetadata struct {
-	PageCount      int `json:"page_count,omitempty"`
-	ExtractedChars int `json:"extracted_chars,omitempty"`
-	KeyCount       int `json:"key_count,omitempty"`
-	ItemCount      int `json:"item_count,omitempty"`
+	PageCount      int  `json:"page_count,omitempty"`
+	ChapterCount   int  `json:"chapter_count,omitempty"`
+	ExtractedChars int  `json:"extracted_chars,omitempty"`
+	KeyCount       int  `json:"key_count,omitempty"`
+	ItemCount      int  `json:"item_count,omitempty"`
+	OCRUsed        bool `json:"ocr_used,omitempty"`
+	PagesOCR       int  `json:"pages_ocr,omitempty"`
 }
 
 // FileUploadError represents errors during file upload/parsing
 type FileUploadError struct {
-	Code    string // "invalid_type", "too_large", "empty_file", "parse_error"
+	Code    string // "invalid_type", "too_large", "too_many_files", "empty_file", "parse_error", "drm_protected"
 	Message string
 }
 
@@ -40,19 +62,39 @@ func (e *FileUploadError) Error() string {
 	return e.Message
 }
 
+// APIError sends the error as a validation error, with its Code as the reason
+func (e *FileUploadError) APIError() models.APIError {
+	return models.APIError{
+		Code:    models.ErrCodeValidation,
+		Message: e.Message,
+		Details: map[string]string{"reason": e.Code},
+	}
+}
+
 const (
 	// MaxFileSize is the maximum allowed file size (10 MB)
 	MaxFileSize = 10 * 1024 * 1024
-	// MaxPDFFileSize is the maximum allowed PDF file size (20 MB)
+	// MaxPDFFileSize is the maximum allowed PDF and EPUB file size (20 MB)
 	MaxPDFFileSize = 20 * 1024 * 1024
+	// MaxZipFileSize is the maximum allowed ZIP archive size (50 MB)
+	MaxZipFileSize = 50 * 1024 * 1024
+	// maxCharsPerSection caps section size for long documents (PDF, EPUB)
+	// to avoid overwhelming the AI processing
+	maxCharsPerSection = 10000
+	// MaxOCRPages caps the pages of a scanned PDF sent to the vision model, to control cost
+	MaxOCRPages = 10
 )
 
 // AllowedFileTypes lists the supported file extensions
-var AllowedFileTypes = []string{".txt", ".md", ".pdf", ".json"}
+var AllowedFileTypes = []string{".txt", ".md", ".pdf", ".json", ".yaml", ".yml", ".epub", ".zip"}
 
-// NewFileParserService creates a new FileParserService
-func NewFileParserService() *FileParserService {
-	return &FileParserService{}
+// NewFileParserService creates a new FileParserService. Scanned PDFs are OCRed with
+// visionService when it is configured.
+func NewFileParserService(visionService *VisionService) *FileParserService {
+	return &FileParserService{
+		visionService:      visionService,
+		OCRFallbackEnabled: visionService != nil && visionService.IsConfigured(),
+	}
 }
 
 // ValidateFile checks if the file type and size are valid
@@ -74,10 +116,13 @@ func (s *FileParserService) ValidateFile(filename string, size int64) error {
 		}
 	}
 
-	// Check file size (PDFs get larger limit)
+	// Check file size (PDFs, EPUBs and ZIP archives get larger limits)
 	maxSize := int64(MaxFileSize)
-	if ext == ".pdf" {
+	switch ext {
+	case ".pdf", ".epub":
 		maxSize = MaxPDFFileSize
+	case ".zip":
+		maxSize = MaxZipFileSize
 	}
 
 	if size > maxSize {
@@ -119,9 +164,16 @@ func (s *FileParserService) ParseFile(filename string, content []byte) ([]Parsed
 	case ".md":
 		return s.parseMarkdownFile(filename, content)
 	case ".pdf":
-		return s.parsePDFFile(filename, content)
+		sections, _, err := s.parsePDFFile(filename, content)
+		return sections, err
 	case ".json":
 		return s.parseJSONFile(filename, content)
+	case ".yaml", ".yml":
+		return s.parseYAMLFile(filename, content)
+	case ".epub":
+		return s.parseEpubFile(filename, content)
+	case ".zip":
+		return s.parseZipFile(filename, content)
 	default:
 		return nil, &FileUploadError{
 			Code:    "invalid_type",
@@ -172,6 +224,7 @@ func (s *FileParserService) parseMarkdownFile(filename string, content []byte) (
 				Content: trimmed,
 				Heading: filename,
 				Order:   0,
+				Source:  SectionSourceMarkdown,
 			},
 		}, nil
 	}
@@ -209,6 +262,7 @@ func (s *FileParserService) parseMarkdownFile(filename string, content []byte) (
 			Content: sectionContent,
 			Heading: headingText,
 			Order:   i,
+			Source:  SectionSourceMarkdown,
 		})
 	}
 
@@ -223,15 +277,17 @@ func (s *FileParserService) parseMarkdownFile(filename string, content []byte) (
 	return sections, nil
 }
 
-// parsePDFFile extracts text from a PDF document
-func (s *FileParserService) parsePDFFile(filename string, content []byte) ([]ParsedMemorySection, error) {
+// parsePDFFile extracts text from a PDF document. A scanned PDF has no text, so with
+// OCRFallbackEnabled its pages are read by the vision model instead; the number of
+// pages OCRed is returned.
+func (s *FileParserService) parsePDFFile(filename string, content []byte) ([]ParsedMemorySection, int, error) {
 	// Create a reader from the byte content
 	reader := bytes.NewReader(content)
 
 	// Parse PDF
 	pdfReader, err := pdf.NewReader(reader, int64(len(content)))
 	if err != nil {
-		return nil, &FileUploadError{
+		return nil, 0, &FileUploadError{
 			Code:    "parse_error",
 			Message: fmt.Sprintf("Failed to parse PDF: %v", err),
 		}
@@ -261,8 +317,13 @@ func (s *FileParserService) parsePDFFile(filename string, content []byte) ([]Par
 		}
 	}
 
+	pagesOCR := 0
+	if len(textParts) == 0 && numPages > 0 && s.OCRFallbackEnabled {
+		textParts, pagesOCR = s.ocrPDFPages(filename, content, pdfReader)
+	}
+
 	if len(textParts) == 0 {
-		return nil, &FileUploadError{
+		return nil, 0, &FileUploadError{
 			Code:    "empty_file",
 			Message: "Could not extract text from PDF",
 		}
@@ -273,7 +334,6 @@ func (s *FileParserService) parsePDFFile(filename string, content []byte) ([]Par
 
 	// For very large PDFs, split into multiple sections (one per ~5 pages)
 	// to avoid overwhelming the AI processing
-	const maxCharsPerSection = 10000
 	sections := []ParsedMemorySection{}
 
 	if len(fullText) <= maxCharsPerSection {
@@ -281,6 +341,7 @@ func (s *FileParserService) parsePDFFile(filename string, content []byte) ([]Par
 			Content: fullText,
 			Heading: fmt.Sprintf("%s (PDF)", filename),
 			Order:   0,
+			Source:  SectionSourcePDF,
 		})
 	} else {
 		// Split into chunks
@@ -290,11 +351,64 @@ func (s *FileParserService) parsePDFFile(filename string, content []byte) ([]Par
 				Content: chunk,
 				Heading: fmt.Sprintf("%s (Part %d)", filename, i+1),
 				Order:   i,
+				Source:  SectionSourcePDF,
 			})
 		}
 	}
 
-	return sections, nil
+	return sections, pagesOCR, nil
+}
+
+// ocrPDFPages reads the text of up to MaxOCRPages pages of a scanned PDF with the
+// vision model, returning the text of each page read and how many were read. Pages
+// whose image can't be decoded or read are skipped.
+func (s *FileParserService) ocrPDFPages(filename string, content []byte, pdfReader *pdf.Reader) ([]string, int) {
+	// Image streams are read straight from the file, so they'd still be encrypted
+	if !pdfReader.Trailer().Key("Encrypt").IsNull() {
+		log.Printf("[FileParser] Not OCRing encrypted PDF %s", filename)
+		return nil, 0
+	}
+
+	numPages := pdfReader.NumPage()
+	if numPages > MaxOCRPages {
+		log.Printf("[FileParser] Scanned PDF %s has %d pages, OCRing the first %d", filename, numPages, MaxOCRPages)
+		numPages = MaxOCRPages
+	}
+
+	var textParts []string
+	for pageNum := 1; pageNum <= numPages; pageNum++ {
+		page := pdfReader.Page(pageNum)
+		if page.V.IsNull() {
+			continue
+		}
+
+		pageImage, err := pdfPageImageJPEG(content, page)
+		if err != nil {
+			log.Printf("[FileParser] Skipping OCR of page %d of %s: %v", pageNum, filename, err)
+			continue
+		}
+		if pageImage == nil {
+			continue
+		}
+
+		result, err := s.visionService.ProcessImage(pageImage, "image/jpeg")
+		if err != nil {
+			log.Printf("[FileParser] OCR of page %d of %s failed: %v", pageNum, filename, err)
+			if errors.Is(err, ErrModelDoesNotSupportVision) {
+				break
+			}
+			continue
+		}
+
+		if text := strings.TrimSpace(result.Content); text != "" {
+			textParts = append(textParts, text)
+		}
+	}
+
+	if len(textParts) > 0 {
+		log.Printf("[FileParser] OCRed %d pages of scanned PDF %s", len(textParts), filename)
+	}
+	return textParts, len(textParts)
 }
 
 // cleanPDFPageText cleans extracted text from PDF artifacts
@@ -581,6 +695,26 @@ func (s *FileParserService) GetFileMetadata(filename string, content []byte) (*F
 			metadata.PageCount = pdfReader.NumPage()
 		}
 
+		// Parsed here rather than below, so a scanned PDF is only OCRed once
+		if sections, pagesOCR, err := s.parsePDFFile(filename, content); err == nil {
+			metadata.OCRUsed = pagesOCR > 0
+			metadata.PagesOCR = pagesOCR
+			for _, section := range sections {
+				metadata.ExtractedChars += len(section.Content)
+			}
+		}
+		return metadata, nil
+
+	case ".epub":
+		if chapters, err := readEpubChapters(content); err == nil {
+			metadata.ChapterCount = len(chapters)
+		}
+
+	case ".zip":
+		if result, err := s.ParseVaultZip(content); err == nil {
+			metadata.ItemCount = len(result.Sections)
+		}
+
 	case ".json":
 		var data interface{}
 		if err := json.Unmarshal(content, &data); err == nil {
@@ -591,6 +725,20 @@ func (s *FileParserService) GetFileMetadata(filename string, content []byte) (*F
 				metadata.ItemCount = len(v)
 			}
 		}
+
+	case ".yaml", ".yml":
+		if docs, err := decodeYAMLDocuments(content); err == nil && len(docs) > 0 {
+			root := docs[0]
+			if len(root.Content) > 0 {
+				root = resolveYAMLAlias(root.Content[0])
+			}
+			switch root.Kind {
+			case yaml.MappingNode:
+				metadata.KeyCount = len(root.Content) / 2
+			case yaml.SequenceNode:
+				metadata.ItemCount = len(root.Content)
+			}
+		}
 	}
 
 	// Parse to get extracted char count
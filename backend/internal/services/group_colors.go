@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Page backgrounds group colors are drawn on, matching the frontend's light and dark surfaces
+const (
+	GroupLightBackground = "#FFFFFF"
+	GroupDarkBackground  = "#111111"
+)
+
+// WCAGMinContrast is the WCAG 2.1 AA contrast ratio for normal-size text
+const WCAGMinContrast = 4.5
+
+var ErrInvalidGroupColor = errors.New("colors must be hex codes like #4F46E5")
+
+// rgbColor holds channels in 0..1
+type rgbColor struct {
+	r, g, b float64
+}
+
+// parseHexColor parses #RGB or #RRGGBB (the # is optional)
+func parseHexColor(color string) (rgbColor, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(color), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return rgbColor{}, fmt.Errorf("%w: %q", ErrInvalidGroupColor, color)
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgbColor{}, fmt.Errorf("%w: %q", ErrInvalidGroupColor, color)
+	}
+	return rgbColor{
+		r: float64(value>>16&0xFF) / 255,
+		g: float64(value>>8&0xFF) / 255,
+		b: float64(value&0xFF) / 255,
+	}, nil
+}
+
+// hex formats the color as uppercase #RRGGBB
+func (c rgbColor) hex() string {
+	channel := func(v float64) int { return int(math.Round(math.Max(0, math.Min(1, v)) * 255)) }
+	return fmt.Sprintf("#%02X%02X%02X", channel(c.r), channel(c.g), channel(c.b))
+}
+
+// hsl returns hue in degrees [0, 360) and saturation and lightness in 0..1
+func (c rgbColor) hsl() (h, s, l float64) {
+	maxC := math.Max(c.r, math.Max(c.g, c.b))
+	minC := math.Min(c.r, math.Min(c.g, c.b))
+	l = (maxC + minC) / 2
+	if maxC == minC {
+		return 0, 0, l
+	}
+
+	d := maxC - minC
+	if l > 0.5 {
+		s = d / (2 - maxC - minC)
+	} else {
+		s = d / (maxC + minC)
+	}
+	switch maxC {
+	case c.r:
+		h = math.Mod((c.g-c.b)/d+6, 6)
+	case c.g:
+		h = (c.b-c.r)/d + 2
+	default:
+		h = (c.r-c.g)/d + 4
+	}
+	return h * 60, s, l
+}
+
+func hslToRGB(h, s, l float64) rgbColor {
+	if s == 0 {
+		return rgbColor{l, l, l}
+	}
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+	h /= 360
+	return rgbColor{
+		r: hueToChannel(p, q, h+1.0/3),
+		g: hueToChannel(p, q, h),
+		b: hueToChannel(p, q, h-1.0/3),
+	}
+}
+
+func hueToChannel(p, q, t float64) float64 {
+	if t < 0 {
+		t++
+	}
+	if t > 1 {
+		t--
+	}
+	switch {
+	case t < 1.0/6:
+		return p + (q-p)*6*t
+	case t < 1.0/2:
+		return q
+	case t < 2.0/3:
+		return p + (q-p)*(2.0/3-t)*6
+	}
+	return p
+}
+
+// relativeLuminance is the WCAG 2.1 relative luminance of the color
+func (c rgbColor) relativeLuminance() float64 {
+	linear := func(v float64) float64 {
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.r) + 0.7152*linear(c.g) + 0.0722*linear(c.b)
+}
+
+// NormalizeHexColor validates a hex color and returns it as uppercase #RRGGBB
+func NormalizeHexColor(hex string) (string, error) {
+	color, err := parseHexColor(hex)
+	if err != nil {
+		return "", err
+	}
+	return color.hex(), nil
+}
+
+// DeriveDarkColor returns the dark-mode variant of a light-mode color: the same hue
+// and saturation with lightness inverted (L = 1 - L)
+func DeriveDarkColor(hex string) (string, error) {
+	color, err := parseHexColor(hex)
+	if err != nil {
+		return "", err
+	}
+	h, s, l := color.hsl()
+	return hslToRGB(h, s, 1-l).hex(), nil
+}
+
+// ContrastRatio returns the WCAG 2.1 contrast ratio of two hex colors, from 1 to 21
+func ContrastRatio(a, b string) (float64, error) {
+	colorA, err := parseHexColor(a)
+	if err != nil {
+		return 0, err
+	}
+	colorB, err := parseHexColor(b)
+	if err != nil {
+		return 0, err
+	}
+	lighter, darker := colorA.relativeLuminance(), colorB.relativeLuminance()
+	if darker > lighter {
+		lighter, darker = darker, lighter
+	}
+	return (lighter + 0.05) / (darker + 0.05), nil
+}
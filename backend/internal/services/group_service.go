@@ -1,19 +1,40 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/repository"
 )
 
+// MaxGroupDepth is how many levels groups can be nested. Top-level groups, including
+// the default groups, are at depth 0.
+const MaxGroupDepth = 3
+
+var (
+	ErrGroupNotFound        = newCodedError(models.ErrCodeNotFound, "group not found")
+	ErrDefaultGroupArchived = errors.New("default groups cannot be archived")
+	ErrParentGroupNotFound  = newCodedError(models.ErrCodeNotFound, "parent group not found")
+	ErrGroupTooDeep         = fmt.Errorf("groups can be nested at most %d levels deep", MaxGroupDepth)
+	// ErrGroupNotLeaf is returned when a todo is put in a group that has sub-groups
+	ErrGroupNotLeaf = errors.New("todos can only be added to groups without sub-groups")
+	// ErrTodoNotInGroup is returned when a group reorder names a todo from another group
+	ErrTodoNotInGroup = errors.New("todo does not belong to this group")
+)
+
 type GroupService struct {
 	groupRepo *repository.GroupRepository
+	todoRepo  *repository.TodoRepository
 }
 
-func NewGroupService(groupRepo *repository.GroupRepository) *GroupService {
+func NewGroupService(groupRepo *repository.GroupRepository, todoRepo *repository.TodoRepository) *GroupService {
 	return &GroupService{
 		groupRepo: groupRepo,
+		todoRepo:  todoRepo,
 	}
 }
 
@@ -21,19 +42,114 @@ func (s *GroupService) Create(userID string, req *models.GroupCreateRequest) (*m
 	group := &models.Group{
 		UserID:    &userID,
 		Name:      req.Name,
-		ColorCode: req.ColorCode,
 		IsDefault: false,
 	}
+	var err error
+	if group.ColorCode, err = normalizeOptionalColor(req.ColorCode); err != nil {
+		return nil, err
+	}
+	if group.ColorDark, err = normalizeOptionalColor(req.ColorDark); err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil && *req.ParentID != "" {
+		parent, err := s.GetByID(userID, *req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			return nil, ErrParentGroupNotFound
+		}
+
+		depth, err := s.depth(parent)
+		if err != nil {
+			return nil, err
+		}
+		if depth+1 >= MaxGroupDepth {
+			return nil, ErrGroupTooDeep
+		}
+		group.ParentID = &parent.ID
+	}
 
 	if err := s.groupRepo.Create(group); err != nil {
 		return nil, err
 	}
 
+	withDarkColor(group)
 	return group, nil
 }
 
-func (s *GroupService) GetAll(userID string) ([]models.Group, error) {
-	return s.groupRepo.GetAllByUserID(userID)
+func (s *GroupService) GetAll(userID string, includeArchived bool) ([]models.Group, error) {
+	groups, err := s.groupRepo.GetAllByUserID(userID, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.todoRepo.GetStatsByGroup(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range groups {
+		withDarkColor(&groups[i])
+		groupStats := stats[groups[i].ID]
+		groups[i].Stats = &groupStats
+		for j := range groups[i].Children {
+			withDarkColor(&groups[i].Children[j])
+			childStats := stats[groups[i].Children[j].ID]
+			groups[i].Children[j].Stats = &childStats
+		}
+	}
+
+	return groups, nil
+}
+
+// GetChildren returns the user's direct sub-groups of a group
+func (s *GroupService) GetChildren(userID, groupID string) ([]models.Group, error) {
+	group, err := s.GetByID(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+
+	children, err := s.groupRepo.GetChildren(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range children {
+		withDarkColor(&children[i])
+	}
+	return children, nil
+}
+
+// depth returns how many ancestors a group has
+func (s *GroupService) depth(group *models.Group) (int, error) {
+	depth := 0
+	// Bounded so a corrupt parent chain can't loop forever
+	for group.ParentID != nil && depth <= MaxGroupDepth {
+		parent, err := s.groupRepo.GetByID(*group.ParentID)
+		if err != nil {
+			return 0, err
+		}
+		if parent == nil {
+			break
+		}
+		group = parent
+		depth++
+	}
+	return depth, nil
+}
+
+// GetStats returns todo counts for a group, or nil if the user can't access it
+func (s *GroupService) GetStats(userID, groupID string) (*models.GroupStats, error) {
+	group, err := s.GetByID(userID, groupID)
+	if err != nil || group == nil {
+		return nil, err
+	}
+
+	return s.todoRepo.GetStatsForGroup(userID, groupID)
 }
 
 func (s *GroupService) GetByID(userID, groupID string) (*models.Group, error) {
@@ -47,12 +163,29 @@ func (s *GroupService) GetByID(userID, groupID string) (*models.Group, error) {
 
 	// Allow access if it's a default group or belongs to the user
 	if group.IsDefault || (group.UserID != nil && *group.UserID == userID) {
+		withDarkColor(group)
 		return group, nil
 	}
 
 	return nil, nil
 }
 
+// GetByIDs returns the groups with the given IDs the user can see, keyed by ID. IDs of
+// missing groups and other users' groups are left out.
+func (s *GroupService) GetByIDs(userID string, ids []string) (map[string]*models.Group, error) {
+	groups, err := s.groupRepo.GetByIDs(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.Group, len(groups))
+	for i := range groups {
+		withDarkColor(&groups[i])
+		byID[groups[i].ID] = &groups[i]
+	}
+	return byID, nil
+}
+
 func (s *GroupService) Update(userID, groupID string, req *models.GroupUpdateRequest) (*models.Group, error) {
 	// Verify ownership (can't update default groups)
 	group, err := s.groupRepo.GetByID(groupID)
@@ -69,7 +202,22 @@ func (s *GroupService) Update(userID, groupID string, req *models.GroupUpdateReq
 		updates["name"] = *req.Name
 	}
 	if req.ColorCode != nil {
-		updates["color_code"] = *req.ColorCode
+		colorCode, err := normalizeOptionalColor(*req.ColorCode)
+		if err != nil {
+			return nil, err
+		}
+		updates["color_code"] = colorCode
+	}
+	if req.ColorDark != nil {
+		colorDark, err := normalizeOptionalColor(*req.ColorDark)
+		if err != nil {
+			return nil, err
+		}
+		if colorDark == "" {
+			updates["color_dark"] = nil
+		} else {
+			updates["color_dark"] = colorDark
+		}
 	}
 
 	if len(updates) > 0 {
@@ -78,7 +226,7 @@ func (s *GroupService) Update(userID, groupID string, req *models.GroupUpdateReq
 		}
 	}
 
-	return s.groupRepo.GetByID(groupID)
+	return s.getWithDarkColor(groupID)
 }
 
 func (s *GroupService) Delete(userID, groupID string) error {
@@ -91,5 +239,168 @@ func (s *GroupService) Delete(userID, groupID string) error {
 		return fmt.Errorf("group not found or cannot be deleted")
 	}
 
-	return s.groupRepo.Delete(groupID)
+	todos, err := s.groupRepo.GetTodosByGroupID(groupID, userID)
+	if err != nil {
+		return err
+	}
+	if len(todos) == 0 {
+		return s.groupRepo.Delete(groupID)
+	}
+
+	// Rather than leaving the todos ungrouped, move them to the top of "Personal",
+	// keeping their order within the deleted group
+	personal, err := s.groupRepo.GetByID(models.DefaultPersonalGroupID)
+	if err != nil {
+		return err
+	}
+	if personal == nil {
+		return fmt.Errorf("default group %s not found", models.DefaultPersonalGroupID)
+	}
+
+	personalTag := GroupTag(personal.Name)
+	for i := range todos {
+		todos[i].Position = strconv.Itoa(i)
+		todos[i].Tags = replaceGroupTag(todos[i].Tags, personalTag)
+	}
+
+	return s.groupRepo.DeleteMovingTodos(groupID, personal.ID, todos)
+}
+
+// GetTodos returns the user's todos in a group, in position order
+func (s *GroupService) GetTodos(userID, groupID string) ([]models.Todo, error) {
+	group, err := s.GetByID(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+
+	return s.groupRepo.GetTodosByGroupID(groupID, userID)
+}
+
+// ValidateReorder checks that every todo in a reorder request is one of the user's
+// todos in the group
+func (s *GroupService) ValidateReorder(userID, groupID string, req *models.TodoReorderRequest) error {
+	todos, err := s.GetTodos(userID, groupID)
+	if err != nil {
+		return err
+	}
+
+	inGroup := make(map[string]bool, len(todos))
+	for _, todo := range todos {
+		inGroup[todo.ID] = true
+	}
+	for _, t := range req.Todos {
+		if !inGroup[t.ID] {
+			return fmt.Errorf("%w: %s", ErrTodoNotInGroup, t.ID)
+		}
+	}
+	return nil
+}
+
+// replaceGroupTag swaps any group tags in tags for tag
+func replaceGroupTag(tags []string, tag string) []string {
+	result := make([]string, 0, len(tags)+1)
+	for _, existing := range tags {
+		if !strings.HasPrefix(existing, GroupTagPrefix) {
+			result = append(result, existing)
+		}
+	}
+	return append(result, tag)
+}
+
+// Archive hides a group and its todos from default listings. Its todos stay reachable
+// by ID and through the include_archived filters.
+func (s *GroupService) Archive(userID, groupID string) (*models.Group, error) {
+	return s.setArchived(userID, groupID, true)
+}
+
+// Unarchive restores an archived group to default listings
+func (s *GroupService) Unarchive(userID, groupID string) (*models.Group, error) {
+	return s.setArchived(userID, groupID, false)
+}
+
+func (s *GroupService) setArchived(userID, groupID string, archived bool) (*models.Group, error) {
+	group, err := s.GetByID(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+	// Default groups are shared by every user, so archiving one would hide it for all
+	if group.IsDefault {
+		return nil, ErrDefaultGroupArchived
+	}
+
+	if group.IsArchived != archived {
+		updates := map[string]interface{}{"is_archived": 0}
+		if archived {
+			updates["is_archived"] = 1
+		}
+		if err := s.groupRepo.Update(groupID, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.getWithDarkColor(groupID)
+}
+
+// ColorPreview returns a group's light and dark colors with their contrast ratios
+// against the page backgrounds they're shown on
+func (s *GroupService) ColorPreview(userID, groupID string) (*models.GroupColorPreview, error) {
+	group, err := s.GetByID(userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+
+	contrastLight, err := ContrastRatio(group.ColorCode, GroupLightBackground)
+	if err != nil {
+		return nil, err
+	}
+	contrastDark, err := ContrastRatio(group.ColorDark, GroupDarkBackground)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GroupColorPreview{
+		ColorLight:    group.ColorCode,
+		ColorDark:     group.ColorDark,
+		ContrastLight: math.Round(contrastLight*100) / 100,
+		ContrastDark:  math.Round(contrastDark*100) / 100,
+	}, nil
+}
+
+func (s *GroupService) getWithDarkColor(groupID string) (*models.Group, error) {
+	group, err := s.groupRepo.GetByID(groupID)
+	if err != nil || group == nil {
+		return group, err
+	}
+	withDarkColor(group)
+	return group, nil
+}
+
+// withDarkColor fills in the dark-mode color derived from the group's color when
+// none was chosen
+func withDarkColor(group *models.Group) {
+	if group.ColorDark != "" {
+		return
+	}
+	if dark, err := DeriveDarkColor(group.ColorCode); err == nil {
+		group.ColorDark = dark
+	} else {
+		group.ColorDark = group.ColorCode
+	}
+}
+
+// normalizeOptionalColor validates a hex color, leaving an empty one empty
+func normalizeOptionalColor(color string) (string, error) {
+	if color == "" {
+		return "", nil
+	}
+	return NormalizeHexColor(color)
 }
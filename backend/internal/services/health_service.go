@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+// HealthCheckTimeout bounds each dependency check
+const HealthCheckTimeout = 2 * time.Second
+
+// HealthService reports the status of the app's dependencies and whether startup
+// work has finished
+type HealthService struct {
+	db               *sql.DB
+	ragService       *RAGService
+	embeddingService *EmbeddingService
+	scraperService   *ScraperService
+	ready            <-chan struct{}
+}
+
+// NewHealthService creates a health service. ready is closed once the app can serve
+// traffic; the RAG, embedding and scraper services may be nil when disabled.
+func NewHealthService(db *sql.DB, ragService *RAGService, embeddingService *EmbeddingService, scraperService *ScraperService, ready <-chan struct{}) *HealthService {
+	return &HealthService{
+		db:               db,
+		ragService:       ragService,
+		embeddingService: embeddingService,
+		scraperService:   scraperService,
+		ready:            ready,
+	}
+}
+
+// Check checks every dependency concurrently, each within HealthCheckTimeout
+func (s *HealthService) Check(ctx context.Context) *models.HealthResponse {
+	checks := map[string]func(context.Context) (bool, error){
+		models.DependencySQLite:    s.checkSQLite,
+		models.DependencyRAG:       s.checkRAG,
+		models.DependencyEmbedding: s.checkEmbedding,
+		models.DependencySearXNG:   s.checkSearXNG,
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		deps = make(map[string]models.DependencyHealth, len(checks))
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(context.Context) (bool, error)) {
+			defer wg.Done()
+			health := runHealthCheck(ctx, check)
+			mu.Lock()
+			deps[name] = health
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	status := models.HealthOK
+	for name, dep := range deps {
+		if dep.Status != models.DependencyDown {
+			continue
+		}
+		if name == models.DependencySQLite {
+			status = models.HealthError
+			break
+		}
+		status = models.HealthDegraded
+	}
+	return &models.HealthResponse{Status: status, Deps: deps}
+}
+
+// Ready reports whether startup work (populating the FTS index) has finished
+func (s *HealthService) Ready() bool {
+	select {
+	case <-s.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// runHealthCheck times check. A check returns false when its dependency isn't configured.
+func runHealthCheck(ctx context.Context, check func(context.Context) (bool, error)) models.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	enabled, err := check(ctx)
+	health := models.DependencyHealth{Status: models.DependencyOK, LatencyMS: time.Since(start).Milliseconds()}
+	switch {
+	case err != nil:
+		health.Status = models.DependencyDown
+		health.Error = err.Error()
+	case !enabled:
+		health.Status = models.DependencyDisabled
+	}
+	return health
+}
+
+func (s *HealthService) checkSQLite(ctx context.Context) (bool, error) {
+	var one int
+	if err := s.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (s *HealthService) checkRAG(ctx context.Context) (bool, error) {
+	if s.ragService == nil {
+		return false, nil
+	}
+	if !s.ragService.IsConfigured() {
+		return true, fmt.Errorf("RAG service not configured")
+	}
+	return true, nil
+}
+
+func (s *HealthService) checkEmbedding(ctx context.Context) (bool, error) {
+	if s.embeddingService == nil {
+		return false, nil
+	}
+	if !s.embeddingService.HealthCheckContext(ctx) {
+		return true, fmt.Errorf("embedding API unreachable")
+	}
+	return true, nil
+}
+
+func (s *HealthService) checkSearXNG(ctx context.Context) (bool, error) {
+	if s.scraperService == nil || len(s.scraperService.searxngURLs) == 0 {
+		return false, nil
+	}
+	return true, s.scraperService.PingSearXNG(ctx)
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/todomyday/backend/internal/database"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// newTestDB opens a migrated database in the test's temp directory
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := database.Connect(filepath.Join(t.TempDir(), "test.db"), database.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// newTestUser creates a user with the given email
+func newTestUser(t *testing.T, db *sql.DB, email string) *models.User {
+	t.Helper()
+	user := &models.User{Email: email}
+	if err := repository.NewUserRepository(db).Create(user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
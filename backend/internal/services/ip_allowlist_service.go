@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/todomyday/backend/internal/metrics"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// ipAllowlistCacheTTL is how long a user's parsed allowlist is reused. The list is
+// checked on every authenticated request, and edits invalidate it immediately.
+const ipAllowlistCacheTTL = 60 * time.Second
+
+// ipAllowlistCacheMaxEntries bounds the cache; expired entries are swept when it fills
+const ipAllowlistCacheMaxEntries = 10000
+
+var (
+	ErrInvalidCIDR            = errors.New("invalid CIDR or IP address")
+	ErrAllowlistEntryNotFound = newCodedError(models.ErrCodeNotFound, "allowlist entry not found")
+)
+
+type IPAllowlistService struct {
+	repo         *repository.IPAllowlistRepository
+	auditService *AuditService
+
+	// Parsed allowlists keyed by user ID
+	cache *ttlCache[[]*net.IPNet]
+}
+
+func NewIPAllowlistService(repo *repository.IPAllowlistRepository, auditService *AuditService) *IPAllowlistService {
+	return &IPAllowlistService{
+		repo:         repo,
+		auditService: auditService,
+		cache:        newTTLCache[[]*net.IPNet](metrics.CacheIPAllowlist, ipAllowlistCacheTTL, ipAllowlistCacheMaxEntries),
+	}
+}
+
+func (s *IPAllowlistService) Create(userID string, req *models.IPAllowlistCreateRequest, ipAddress string) (*models.IPAllowlistEntry, error) {
+	cidr, err := normalizeCIDR(req.CIDR)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.IPAllowlistEntry{
+		UserID: userID,
+		CIDR:   cidr,
+		Label:  strings.TrimSpace(req.Label),
+	}
+
+	if err := s.repo.Create(entry); err != nil {
+		return nil, err
+	}
+
+	s.invalidate(userID)
+	s.logChange(userID, entry, "create", ipAddress)
+	return entry, nil
+}
+
+func (s *IPAllowlistService) GetAll(userID string) ([]models.IPAllowlistEntry, error) {
+	return s.repo.GetAllByUserID(userID)
+}
+
+func (s *IPAllowlistService) Update(userID, entryID string, req *models.IPAllowlistUpdateRequest, ipAddress string) (*models.IPAllowlistEntry, error) {
+	entry, err := s.repo.GetByID(entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil || entry.UserID != userID {
+		return nil, ErrAllowlistEntryNotFound
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.CIDR != nil {
+		cidr, err := normalizeCIDR(*req.CIDR)
+		if err != nil {
+			return nil, err
+		}
+		updates["cidr"] = cidr
+	}
+	if req.Label != nil {
+		updates["label"] = strings.TrimSpace(*req.Label)
+	}
+
+	if len(updates) > 0 {
+		if err := s.repo.Update(entryID, updates); err != nil {
+			return nil, err
+		}
+		s.invalidate(userID)
+	}
+
+	entry, err = s.repo.GetByID(entryID)
+	if err != nil {
+		return nil, err
+	}
+	s.logChange(userID, entry, "update", ipAddress)
+	return entry, nil
+}
+
+func (s *IPAllowlistService) Delete(userID, entryID, ipAddress string) error {
+	entry, err := s.repo.GetByID(entryID)
+	if err != nil {
+		return err
+	}
+	if entry == nil || entry.UserID != userID {
+		return ErrAllowlistEntryNotFound
+	}
+
+	if err := s.repo.Delete(entryID); err != nil {
+		return err
+	}
+
+	s.invalidate(userID)
+	s.logChange(userID, entry, "delete", ipAddress)
+	return nil
+}
+
+// IsAllowed reports whether ip may access the user's account. Users without
+// allowlist entries are unrestricted.
+func (s *IPAllowlistService) IsAllowed(userID, ip string) (bool, error) {
+	networks, err := s.networks(userID)
+	if err != nil {
+		return false, err
+	}
+	return ipInNetworks(ip, networks), nil
+}
+
+// networks returns the user's parsed allowlist, cached for ipAllowlistCacheTTL
+func (s *IPAllowlistService) networks(userID string) ([]*net.IPNet, error) {
+	if networks, ok := s.cache.Get(userID); ok {
+		return networks, nil
+	}
+
+	entries, err := s.repo.GetAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if _, network, err := net.ParseCIDR(entry.CIDR); err == nil {
+			networks = append(networks, network)
+		}
+	}
+
+	s.cache.Set(userID, networks)
+	return networks, nil
+}
+
+func (s *IPAllowlistService) invalidate(userID string) {
+	s.cache.Delete(userID)
+}
+
+func (s *IPAllowlistService) logChange(userID string, entry *models.IPAllowlistEntry, operation, ipAddress string) {
+	s.auditService.Log(userID, models.AuditActionIPAllowlistChanged, map[string]string{
+		"entry_id":  entry.ID,
+		"cidr":      entry.CIDR,
+		"operation": operation,
+	}, ipAddress)
+}
+
+// ipInNetworks reports whether ip falls in any of the networks. An empty list
+// allows everything; an unparseable ip is never allowed by a non-empty list.
+func ipInNetworks(ip string, networks []*net.IPNet) bool {
+	if len(networks) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		// Contains handles IPv4-mapped IPv6 addresses (::ffff:10.0.0.1) against IPv4 networks
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeCIDR validates a CIDR range or bare IP address and returns it in
+// canonical form, with bare addresses widened to a single-host /32 or /128
+func normalizeCIDR(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", ErrInvalidCIDR
+		}
+		if strings.Contains(value, ":") {
+			value += "/128"
+		} else {
+			value += "/32"
+		}
+	}
+
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCIDR, value)
+	}
+	return network.String(), nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+func TestNormalizeCIDR(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "10.0.0.0/8", want: "10.0.0.0/8"},
+		{value: " 192.168.1.17/24 ", want: "192.168.1.0/24"},
+		{value: "203.0.113.5", want: "203.0.113.5/32"},
+		{value: "2001:db8::1", want: "2001:db8::1/128"},
+		{value: "2001:db8::/32", want: "2001:db8::/32"},
+		{value: "10.0.0.0/33", wantErr: true},
+		{value: "300.1.1.1", wantErr: true},
+		{value: "office", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := normalizeCIDR(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCIDR) {
+					t.Errorf("normalizeCIDR(%q) = %q, %v; want ErrInvalidCIDR", tt.value, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("normalizeCIDR(%q) = %q, %v; want %q", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPAllowlistIsAllowed(t *testing.T) {
+	tests := []struct {
+		name  string
+		cidrs []string
+		ip    string
+		want  bool
+	}{
+		{"empty allowlist allows everything", nil, "198.51.100.7", true},
+		{"inside range", []string{"10.0.0.0/8"}, "10.20.30.40", true},
+		{"outside range", []string{"10.0.0.0/8"}, "11.0.0.1", false},
+		{"range boundary", []string{"192.168.1.0/24"}, "192.168.1.255", true},
+		{"just past range boundary", []string{"192.168.1.0/24"}, "192.168.2.0", false},
+		{"single host", []string{"203.0.113.5"}, "203.0.113.5", true},
+		{"neighbour of single host", []string{"203.0.113.5"}, "203.0.113.6", false},
+		{"any of several entries", []string{"10.0.0.0/8", "203.0.113.5"}, "203.0.113.5", true},
+		{"ipv6 range", []string{"2001:db8::/32"}, "2001:db8:1::1", true},
+		{"ipv6 outside range", []string{"2001:db8::/32"}, "2001:db9::1", false},
+		{"ipv4-mapped ipv6 address", []string{"10.0.0.0/8"}, "::ffff:10.0.0.1", true},
+		{"unparseable ip", []string{"10.0.0.0/8"}, "not-an-ip", false},
+		{"empty ip", []string{"10.0.0.0/8"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			user := newTestUser(t, db, "allowlist@example.com")
+			service := NewIPAllowlistService(repository.NewIPAllowlistRepository(db), nil)
+			for _, cidr := range tt.cidrs {
+				if _, err := service.Create(user.ID, &models.IPAllowlistCreateRequest{CIDR: cidr}, ""); err != nil {
+					t.Fatalf("Create(%q): %v", cidr, err)
+				}
+			}
+
+			got, err := service.IsAllowed(user.ID, tt.ip)
+			if err != nil {
+				t.Fatalf("IsAllowed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsAllowed(%q) with %v = %v, want %v", tt.ip, tt.cidrs, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("edits take effect immediately", func(t *testing.T) {
+		db := newTestDB(t)
+		user := newTestUser(t, db, "allowlist@example.com")
+		service := NewIPAllowlistService(repository.NewIPAllowlistRepository(db), nil)
+		entry, err := service.Create(user.ID, &models.IPAllowlistCreateRequest{CIDR: "10.0.0.0/8"}, "")
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if allowed, _ := service.IsAllowed(user.ID, "203.0.113.5"); allowed {
+			t.Fatal("IP outside the allowlist was allowed")
+		}
+
+		cidr := "203.0.113.0/24"
+		if _, err := service.Update(user.ID, entry.ID, &models.IPAllowlistUpdateRequest{CIDR: &cidr}, ""); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if allowed, _ := service.IsAllowed(user.ID, "203.0.113.5"); !allowed {
+			t.Error("IP allowed by the updated entry was rejected")
+		}
+
+		if err := service.Delete(user.ID, entry.ID, ""); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if allowed, _ := service.IsAllowed(user.ID, "198.51.100.7"); !allowed {
+			t.Error("IP was rejected after the allowlist was emptied")
+		}
+	})
+}
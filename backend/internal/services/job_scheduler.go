@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+var ErrJobNotFound = newCodedError(models.ErrCodeNotFound, "background job not found")
+
+// JobFunc is one run of a background job. ctx is cancelled when the server stops.
+type JobFunc func(ctx context.Context) error
+
+// registeredJob is a job the scheduler can run
+type registeredJob struct {
+	run JobFunc
+	// running is held for the length of a run, so a rescheduled job can't overlap its
+	// previous run
+	running sync.Mutex
+}
+
+// cronEntry is the active schedule of one job: a goroutine waiting for the job's next
+// run until cancel is called
+type cronEntry struct {
+	schedule *cronSchedule
+	cancel   context.CancelFunc
+	next     atomic.Int64 // unix nanoseconds of the next run, 0 when none
+}
+
+// JobScheduler runs background jobs on cron schedules stored in the background_jobs
+// table. Operators can change a schedule or disable a job through the admin API and
+// the change applies at once, without a restart. Each job runs at most once at a time;
+// runs that come due while it's still running are skipped.
+type JobScheduler struct {
+	repo *repository.BackgroundJobRepository
+	// jobs holds the registered jobs; it is only written before Start
+	jobs map[string]*registeredJob
+	// entries holds the active *cronEntry of each scheduled job, by name
+	entries sync.Map
+
+	// mu serializes Start and Update, so the stored schedule and the active entry agree
+	mu  sync.Mutex
+	ctx context.Context
+}
+
+func NewJobScheduler(repo *repository.BackgroundJobRepository) *JobScheduler {
+	return &JobScheduler{
+		repo: repo,
+		jobs: make(map[string]*registeredJob),
+	}
+}
+
+// Register adds a job, storing defaultCron as its schedule the first time the job is
+// seen. Call it before Start. Jobs whose feature isn't set up aren't registered, so
+// they keep their stored settings but don't run.
+func (s *JobScheduler) Register(name, defaultCron string, run JobFunc) error {
+	if _, err := parseCron(defaultCron); err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	if err := s.repo.EnsureJob(name, defaultCron); err != nil {
+		return fmt.Errorf("failed to store job %s: %w", name, err)
+	}
+	s.jobs[name] = &registeredJob{run: run}
+	return nil
+}
+
+// Start schedules every registered, enabled job as stored. The jobs stop when ctx is
+// cancelled.
+func (s *JobScheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctx = ctx
+	jobs, err := s.repo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to load background jobs: %w", err)
+	}
+	for i := range jobs {
+		s.schedule(&jobs[i])
+	}
+	return nil
+}
+
+// GetAll returns every job with its schedule, last run and next run
+func (s *JobScheduler) GetAll() ([]models.BackgroundJob, error) {
+	jobs, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	for i := range jobs {
+		s.describe(&jobs[i])
+	}
+	return jobs, nil
+}
+
+// Update changes a job's cron expression or turns it on or off, rescheduling it at once
+func (s *JobScheduler) Update(name string, req *models.BackgroundJobUpdateRequest) (*models.BackgroundJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.repo.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+
+	if req.CronExpression != nil {
+		expr := strings.TrimSpace(*req.CronExpression)
+		if _, err := parseCron(expr); err != nil {
+			return nil, err
+		}
+		job.CronExpression = expr
+	}
+	if req.IsEnabled != nil {
+		job.IsEnabled = *req.IsEnabled
+	}
+	if err := s.repo.UpdateSchedule(name, job.CronExpression, job.IsEnabled); err != nil {
+		return nil, err
+	}
+	log.Printf("[JobScheduler] Job %s updated: schedule %q, enabled %v", name, job.CronExpression, job.IsEnabled)
+
+	if s.ctx != nil {
+		s.schedule(job)
+	}
+	s.describe(job)
+	return job, nil
+}
+
+// schedule replaces the job's active entry with one for its stored schedule, or just
+// stops it if the job is disabled or not registered
+func (s *JobScheduler) schedule(job *models.BackgroundJob) {
+	registered, ok := s.jobs[job.Name]
+	if !ok || !job.IsEnabled {
+		s.unschedule(job.Name)
+		return
+	}
+	schedule, err := parseCron(job.CronExpression)
+	if err != nil {
+		log.Printf("[JobScheduler] Not scheduling job %s: %v", job.Name, err)
+		s.unschedule(job.Name)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	entry := &cronEntry{schedule: schedule, cancel: cancel}
+	entry.next.Store(schedule.Next(time.Now()).UnixNano())
+	if old, loaded := s.entries.Swap(job.Name, entry); loaded {
+		old.(*cronEntry).cancel()
+	}
+	go s.loop(ctx, job.Name, registered, entry)
+}
+
+func (s *JobScheduler) unschedule(name string) {
+	if old, loaded := s.entries.LoadAndDelete(name); loaded {
+		old.(*cronEntry).cancel()
+	}
+}
+
+// loop waits for each of the entry's run times and runs the job, until the entry is
+// replaced or removed
+func (s *JobScheduler) loop(ctx context.Context, name string, job *registeredJob, entry *cronEntry) {
+	for {
+		next := entry.schedule.Next(time.Now())
+		if next.IsZero() {
+			log.Printf("[JobScheduler] Job %s never fires, not scheduling it", name)
+			entry.next.Store(0)
+			return
+		}
+		entry.next.Store(next.UnixNano())
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// A run isn't cut short by rescheduling, only by the server stopping
+		s.runJob(name, job)
+	}
+}
+
+func (s *JobScheduler) runJob(name string, job *registeredJob) {
+	if !job.running.TryLock() {
+		log.Printf("[JobScheduler] Skipping run of job %s, the previous run hasn't finished", name)
+		return
+	}
+	defer job.running.Unlock()
+
+	start := time.Now()
+	err := job.run(s.ctx)
+	duration := time.Since(start)
+
+	var lastError *string
+	if err != nil {
+		log.Printf("[JobScheduler] Job %s failed after %v: %v", name, duration, err)
+		message := err.Error()
+		lastError = &message
+	}
+	if err := s.repo.RecordRun(name, start, duration.Milliseconds(), lastError); err != nil {
+		log.Printf("[JobScheduler] Failed to record run of job %s: %v", name, err)
+	}
+}
+
+// describe fills in whether the job is registered here and when it runs next
+func (s *JobScheduler) describe(job *models.BackgroundJob) {
+	_, job.Available = s.jobs[job.Name]
+	job.NextRunAt = nil
+	if value, ok := s.entries.Load(job.Name); ok {
+		if next := value.(*cronEntry).next.Load(); next != 0 {
+			nextRunAt := time.Unix(0, next)
+			job.NextRunAt = &nextRunAt
+		}
+	}
+}
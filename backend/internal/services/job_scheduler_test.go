@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+func TestJobSchedulerDisablingStopsRuns(t *testing.T) {
+	scheduler := NewJobScheduler(repository.NewBackgroundJobRepository(newTestDB(t)))
+	runs := make(chan struct{}, 10)
+	if err := scheduler.Register("tick", "@every 1s", func(ctx context.Context) error {
+		runs <- struct{}{}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := scheduler.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-runs:
+	case <-time.After(3 * time.Second):
+		t.Fatal("enabled job didn't run")
+	}
+
+	disabled := false
+	job, err := scheduler.Update("tick", &models.BackgroundJobUpdateRequest{IsEnabled: &disabled})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if job.IsEnabled || job.NextRunAt != nil {
+		t.Errorf("disabled job = enabled %v, next run %v; want disabled with no next run", job.IsEnabled, job.NextRunAt)
+	}
+
+	select {
+	case <-runs:
+		t.Error("job ran after it was disabled")
+	case <-time.After(1500 * time.Millisecond):
+	}
+}
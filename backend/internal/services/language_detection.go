@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pemistahl/lingua-go"
+	"github.com/todomyday/backend/internal/models"
+)
+
+// languageDetectionSampleRunes is how much of the content is used to detect its language
+const languageDetectionSampleRunes = 200
+
+// languageDetector is built on first use. Detection is limited to common languages
+// in low accuracy mode, which keeps the loaded models small and each call well under
+// a millisecond on short samples.
+var languageDetector = sync.OnceValue(func() lingua.LanguageDetector {
+	return lingua.NewLanguageDetectorBuilder().
+		FromLanguages(
+			lingua.English, lingua.French, lingua.German, lingua.Spanish, lingua.Portuguese,
+			lingua.Italian, lingua.Dutch, lingua.Swedish, lingua.Polish, lingua.Russian,
+			lingua.Ukrainian, lingua.Turkish, lingua.Arabic, lingua.Hindi, lingua.Japanese,
+			lingua.Chinese, lingua.Korean, lingua.Vietnamese, lingua.Indonesian,
+		).
+		WithLowAccuracyMode().
+		Build()
+})
+
+// DetectLanguage returns the language of the start of content, or false if it
+// can't be determined reliably
+func DetectLanguage(content string) (lingua.Language, bool) {
+	sample := []rune(strings.TrimSpace(content))
+	if len(sample) == 0 {
+		return lingua.Unknown, false
+	}
+	if len(sample) > languageDetectionSampleRunes {
+		sample = sample[:languageDetectionSampleRunes]
+	}
+	return languageDetector().DetectLanguageOf(string(sample))
+}
+
+// DetectLanguageCode returns the lowercase ISO 639-1 code of content's language, or nil if unknown
+func DetectLanguageCode(content string) *string {
+	language, ok := DetectLanguage(content)
+	if !ok {
+		return nil
+	}
+	code := strings.ToLower(language.IsoCode639_1().String())
+	return &code
+}
+
+// memoryLanguage detects a memory's language from its content, or from the page
+// title when the content is nothing but a link
+func memoryLanguage(memory *models.Memory) *string {
+	sample := memory.Content
+	if url := ExtractURLFromText(sample); url != nil && memory.URLTitle != nil &&
+		strings.TrimSpace(strings.Replace(sample, *url, "", 1)) == "" {
+		sample = *memory.URLTitle
+	}
+	return DetectLanguageCode(sample)
+}
+
+// withLanguageHint prefixes a prompt about non-English content with its language,
+// so categories and summaries still come back in English
+func withLanguageHint(prompt, content string) string {
+	language, ok := DetectLanguage(content)
+	if !ok || language == lingua.English {
+		return prompt
+	}
+	return fmt.Sprintf("The following content is in %s. Please respond with category/summary in English:\n\n%s", language, prompt)
+}
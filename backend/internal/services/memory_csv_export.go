@@ -0,0 +1,161 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+var ErrInvalidExportFilter = errors.New("invalid export filter")
+
+// csvNewlines flattens line breaks so every memory stays on one CSV line
+var csvNewlines = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// csvFormulaPrefixes start cells that spreadsheets run as formulas
+const csvFormulaPrefixes = "=+-@\t"
+
+// csvCell makes value safe to open in a spreadsheet: line breaks become spaces and a
+// value that would run as a formula gets a leading ' so it's shown as text
+func csvCell(value string) string {
+	value = csvNewlines.Replace(value)
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// ExportCSV returns a reader streaming the user's unarchived memories as CSV, one
+// column per filter field. Memories are read in pages and encoded as the reader is
+// consumed, so the export is never held in memory. The reader is an io.Closer; close
+// it if you stop reading early.
+func (s *MemoryService) ExportCSV(userID string, filter models.ExportFilter) (io.Reader, error) {
+	fields := filter.Fields
+	if len(fields) == 0 {
+		fields = models.MemoryCSVFields
+	}
+	for _, field := range fields {
+		if !isMemoryCSVField(field) {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidExportFilter, field)
+		}
+	}
+
+	var from, to time.Time
+	var err error
+	if filter.From != "" {
+		if from, err = time.Parse("2006-01-02", filter.From); err != nil {
+			return nil, fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidExportFilter)
+		}
+	}
+	if filter.To != "" {
+		if to, err = time.Parse("2006-01-02", filter.To); err != nil {
+			return nil, fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidExportFilter)
+		}
+		// Inclusive of the whole day
+		to = to.AddDate(0, 0, 1)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.writeMemoriesCSV(pw, userID, filter.Category, fields, from, to))
+	}()
+	return pr, nil
+}
+
+// writeMemoriesCSV pages through the user's memories, writing the ones created in
+// [from, to) as CSV rows. A zero from or to leaves that end open.
+func (s *MemoryService) writeMemoriesCSV(w io.Writer, userID, category string, fields []string, from, to time.Time) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(fields); err != nil {
+		return err
+	}
+
+	row := make([]string, len(fields))
+	for offset := 0; ; offset += exportMemoryPageSize {
+		var memories []models.Memory
+		var err error
+		if category != "" {
+			memories, err = s.memoryRepo.GetByCategory(userID, category, exportMemoryPageSize, offset)
+		} else {
+			memories, err = s.memoryRepo.GetAllByUserID(userID, models.MemorySortNewest, exportMemoryPageSize, offset)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch memories: %w", err)
+		}
+
+		for i := range memories {
+			memory := &memories[i]
+			if !from.IsZero() && memory.CreatedAt.Before(from) {
+				continue
+			}
+			if !to.IsZero() && !memory.CreatedAt.Before(to) {
+				continue
+			}
+			for j, field := range fields {
+				row[j] = csvCell(memoryCSVValue(memory, field))
+			}
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return err
+		}
+
+		if len(memories) < exportMemoryPageSize {
+			return nil
+		}
+	}
+}
+
+func isMemoryCSVField(field string) bool {
+	for _, f := range models.MemoryCSVFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// memoryCSVValue returns one of models.MemoryCSVFields of memory as text; unset
+// optional fields are empty
+func memoryCSVValue(memory *models.Memory, field string) string {
+	optional := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+
+	switch field {
+	case "id":
+		return memory.ID
+	case "content":
+		return memory.Content
+	case "summary":
+		return optional(memory.Summary)
+	case "category":
+		return memory.Category
+	case "url":
+		return optional(memory.URL)
+	case "url_title":
+		return optional(memory.URLTitle)
+	case "generated_title":
+		return optional(memory.GeneratedTitle)
+	case "content_language":
+		return optional(memory.ContentLanguage)
+	case "is_pinned":
+		return strconv.FormatBool(memory.IsPinned)
+	case "created_at":
+		return memory.CreatedAt.UTC().Format(time.RFC3339)
+	case "updated_at":
+		return memory.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return ""
+}
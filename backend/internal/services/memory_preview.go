@@ -0,0 +1,62 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+const (
+	// MemoryPreviewCacheTTL is how long a rendered memory preview is reused
+	MemoryPreviewCacheTTL = 5 * time.Minute
+	// memoryPreviewCacheMaxEntries bounds the cache; expired entries are swept when it fills
+	memoryPreviewCacheMaxEntries = 2000
+)
+
+var (
+	markdownRenderer = goldmark.New(goldmark.WithRendererOptions(html.WithHardWraps(), html.WithXHTML()))
+	// previewPolicy strips scripts, event handlers and javascript: links from rendered notes
+	previewPolicy = bluemonday.UGCPolicy()
+)
+
+// RenderMarkdown converts Markdown to sanitized HTML that is safe to embed in the page
+func RenderMarkdown(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdownRenderer.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return previewPolicy.Sanitize(buf.String()), nil
+}
+
+// GetPreview renders one of the user's memories as sanitized HTML. Previews are
+// cached by memory and update time, so an edit always produces a fresh render.
+func (s *MemoryService) GetPreview(userID, memoryID string) (string, error) {
+	memory, err := s.GetByID(userID, memoryID)
+	if err != nil {
+		return "", err
+	}
+	if memory == nil {
+		return "", ErrMemoryNotFound
+	}
+
+	key := fmt.Sprintf("preview:%s:%d", memory.ID, memory.UpdatedAt.Unix())
+	if cached, ok := s.previewCache.Get(key); ok {
+		return cached, nil
+	}
+
+	rendered, err := RenderMarkdown(memory.Content)
+	if err != nil {
+		return "", err
+	}
+	s.previewCache.Set(key, rendered)
+	return rendered, nil
+}
+
+// invalidatePreview drops every cached render of a memory after its content changes
+func (s *MemoryService) invalidatePreview(memoryID string) {
+	s.previewCache.DeletePrefix("preview:" + memoryID + ":")
+}
@@ -2,43 +2,133 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/todomyday/backend/internal/metrics"
 	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/repository"
 )
 
+// MaxPinnedMemories is the maximum number of memories a user can pin
+const MaxPinnedMemories = 10
+
+// DefaultDuplicateThreshold is the cosine similarity at which an imported section
+// is considered a copy of an existing memory
+const DefaultDuplicateThreshold = 0.95
+
+const (
+	// RelatedMemoryThreshold is the minimum cosine similarity for two memories to be linked
+	RelatedMemoryThreshold = 0.75
+	// MaxRelatedMemoryLinks is how many related memories are linked when a memory is saved
+	MaxRelatedMemoryLinks = 3
+)
+
+var ErrPinLimitReached = errors.New("pinned memory limit reached")
+
+var (
+	ErrMemoryNotFound    = newCodedError(models.ErrCodeNotFound, "memory not found")
+	ErrMemoryHasNoURL    = errors.New("memory has no URL")
+	ErrScraperNotEnabled = errors.New("web scraping is not configured")
+	ErrURLScrapeFailed   = errors.New("failed to fetch URL")
+
+	ErrMemoryAINotConfigured = errors.New("AI is not configured")
+	ErrTitleGenerationFailed = errors.New("failed to generate title")
+
+	ErrRevisionNotFound = newCodedError(models.ErrCodeNotFound, "revision not found")
+)
+
+var (
+	ErrEmptyMemoryBatch    = errors.New("at least one memory is required")
+	ErrMemoryBatchTooLarge = fmt.Errorf("a batch may hold at most %d memories", models.MaxMemoryBatchSize)
+)
+
+var (
+	ErrEmptyBulkDeleteFilter = errors.New("category or before_date is required")
+	ErrInvalidBeforeDate     = errors.New("before_date must be formatted as YYYY-MM-DD")
+
+	ErrEmptyBulkArchiveFilter = errors.New("older_than_days or category is required")
+	ErrInvalidOlderThanDays   = errors.New("older_than_days must not be negative")
+)
+
 type MemoryService struct {
 	memoryRepo        *repository.MemoryRepository
 	todoRepo          *repository.TodoRepository
+	groupRepo         *repository.GroupRepository
 	aiService         *AIService
 	aiProviderService *AIProviderService
 	scraperService    *ScraperService
 	ragService        *RAGService
+	auditService      *AuditService
+	searchHistory     *SearchHistoryService
+	preferences       *UserPreferencesService
+	categoryModel     *PersonalCategoryModel
+	blocklist         *BlocklistService
+	attachments       *AttachmentService
+	briefings         *DailyBriefingService
+	promptTemplates   *PromptTemplateService
+
+	previewCache *ttlCache[string]
+	keywordCache *ttlCache[[]models.KeywordScore]
 }
 
 func NewMemoryService(
 	memoryRepo *repository.MemoryRepository,
 	todoRepo *repository.TodoRepository,
+	groupRepo *repository.GroupRepository,
 	aiService *AIService,
 	aiProviderService *AIProviderService,
 	scraperService *ScraperService,
 	ragService *RAGService,
+	auditService *AuditService,
+	searchHistory *SearchHistoryService,
+	preferences *UserPreferencesService,
+	categoryModel *PersonalCategoryModel,
+	blocklist *BlocklistService,
+	attachments *AttachmentService,
 ) *MemoryService {
 	return &MemoryService{
 		memoryRepo:        memoryRepo,
 		todoRepo:          todoRepo,
+		groupRepo:         groupRepo,
 		aiService:         aiService,
 		aiProviderService: aiProviderService,
 		scraperService:    scraperService,
 		ragService:        ragService,
+		auditService:      auditService,
+		searchHistory:     searchHistory,
+		preferences:       preferences,
+		categoryModel:     categoryModel,
+		blocklist:         blocklist,
+		attachments:       attachments,
+		previewCache:      newTTLCache[string](metrics.CachePreview, MemoryPreviewCacheTTL, memoryPreviewCacheMaxEntries),
+		keywordCache:      newTTLCache[[]models.KeywordScore](metrics.CacheKeywords, KeywordCacheTTL, keywordCacheMaxEntries),
 	}
 }
 
+// SetDailyBriefingService makes changing memories invalidate the user's cached daily briefing
+func (s *MemoryService) SetDailyBriefingService(briefings *DailyBriefingService) {
+	s.briefings = briefings
+}
+
+// SetPromptTemplateService makes memory categorization and weekly digests use the
+// user's active prompt templates
+func (s *MemoryService) SetPromptTemplateService(prompts *PromptTemplateService) {
+	s.promptTemplates = prompts
+}
+
 // Create processes and stores a new memory using 2-step AI function calling
 func (s *MemoryService) Create(userID string, req *models.MemoryCreateRequest) (*models.Memory, error) {
+	if err := s.blocklist.Check(userID, req.Content); err != nil {
+		return nil, err
+	}
+
 	log.Printf("[MemoryService] Creating memory for user %s: %q", userID, req.Content)
 
 	// Get max position for new memory
@@ -47,58 +137,97 @@ func (s *MemoryService) Create(userID string, req *models.MemoryCreateRequest) (
 		maxPos = 0
 	}
 
-	memory := &models.Memory{
-		UserID:   userID,
-		Content:  req.Content,
-		Category: "Uncategorized",
-		Position: fmt.Sprintf("%d", maxPos+1000),
+	memory := s.prepareMemory(userID, req.Content, req.ScrapeMode, fmt.Sprintf("%d", maxPos+1000))
+
+	// Store memory
+	if err := s.memoryRepo.Create(memory); err != nil {
+		return nil, err
 	}
+	metrics.MemoriesCreatedTotal.WithLabelValues(memory.Category).Inc()
+	s.briefings.Invalidate(userID)
 
-	// Get AI config
-	config := s.getAIConfig(userID)
+	// Async RAG indexing - fire and forget
+	if s.ragService != nil && s.ragService.IsConfigured() {
+		log.Printf("[MemoryService] Indexing memory %s to vector database (async)", memory.ID)
+		go func(m *models.Memory) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := s.ragService.IndexMemory(ctx, m); err != nil {
+				log.Printf("[MemoryService] Failed to index memory %s: %v", m.ID, err)
+				s.ragService.QueueIndexRetry(models.ContentTypeMemory, m.ID, m.UserID, err)
+			} else {
+				log.Printf("[MemoryService] Successfully indexed memory %s", m.ID)
+			}
+		}(memory)
+
+		go s.linkRelated(memory)
+	}
+
+	log.Printf("[MemoryService] Created memory %s with category %s", memory.ID, memory.Category)
+	return memory, nil
+}
+
+// prepareMemory builds a new memory from content: categorized and summarized by the
+// AI when one is configured, with any URL in it fetched as scrapeMode says (the whole
+// page by default). Nothing is stored.
+func (s *MemoryService) prepareMemory(userID, content, scrapeMode, position string) *models.Memory {
+	if scrapeMode == "" {
+		scrapeMode = models.ScrapeModeFull
+	}
+	memory := &models.Memory{
+		UserID:     userID,
+		Content:    content,
+		Category:   "Uncategorized",
+		Position:   position,
+		ScrapeMode: scrapeMode,
+	}
 
 	// Use function calling for 2-step AI processing
 	// Step 1: AI categorizes and detects URLs
 	// Step 2: If URL detected, AI scrapes and summarizes
+	// Users who turned AI processing off get their memory stored as typed
+	var config *AIProviderConfig
+	var memoryResult *models.AIProcessedMemory
+	var urlSummary *models.URLSummary
+	var err error
+	if s.preferences.AIProcessMemories(userID) {
+		config, memoryResult, urlSummary, err = s.processContent(userID, content, scrapeMode)
+	}
 	if config != nil {
-		memoryResult, urlSummary, err := ProcessMemoryWithFunctionCalling(
-			req.Content,
-			config,
-			s.scraperService,
-		)
-
 		if err == nil && memoryResult != nil {
 			memory.Category = memoryResult.Category
+			memory.AIProcessingFailed = memoryResult.ProcessingFailed
 			if memoryResult.Summary != "" {
-				memory.Summary = &memoryResult.Summary
+				source := models.SummarySourceAICategorize
+				memory.Summary, memory.SummarySource = &memoryResult.Summary, &source
 			}
 		}
 
 		// Apply URL summary if we got one from the 2-step process
 		if urlSummary != nil {
 			// Extract URL from content for storage
-			detectedURL := ExtractURLFromText(req.Content)
+			detectedURL := ExtractURLFromText(content)
 			if detectedURL != nil {
 				memory.URL = detectedURL
 			}
-			if urlSummary.Title != "" {
-				memory.URLTitle = &urlSummary.Title
-			}
-			if urlSummary.Summary != "" {
-				memory.URLContent = &urlSummary.Summary
-			}
+			applyURLSummary(memory, urlSummary)
 		} else {
 			// Fallback: Check for URL manually if function calling didn't detect one
-			detectedURL := ExtractURLFromText(req.Content)
+			detectedURL := ExtractURLFromText(content)
 			if detectedURL != nil {
 				memory.URL = detectedURL
 				log.Printf("[MemoryService] Fallback URL detection: %s", *detectedURL)
 
 				// Try to scrape if we have a scraper
-				if s.scraperService != nil {
+				if s.scraperService != nil && scrapeMode == models.ScrapeModeMetadataOnly {
+					applyURLSummary(memory, s.scraperService.MetadataSummary(*detectedURL))
+				} else if s.scraperService != nil && scrapeMode == models.ScrapeModeFull {
 					scraped, err := s.scraperService.ScrapeURL(*detectedURL)
 					if err == nil && scraped != nil {
 						memory.URLTitle = &scraped.Title
+						if scraped.ImageURL != "" {
+							memory.ThumbnailURL = &scraped.ImageURL
+						}
 						if config != nil && scraped.Content != "" {
 							urlSummaryResult, _ := SummarizeURLWithProvider(*detectedURL, scraped.Content, config)
 							if urlSummaryResult != nil {
@@ -115,38 +244,335 @@ func (s *MemoryService) Create(userID string, req *models.MemoryCreateRequest) (
 			}
 		}
 	} else {
-		// No AI config - just detect URL manually
-		detectedURL := ExtractURLFromText(req.Content)
+		// No AI config - just detect URL manually. Metadata needs no AI, so it's
+		// still fetched when asked for.
+		detectedURL := ExtractURLFromText(content)
 		if detectedURL != nil {
 			memory.URL = detectedURL
+			if s.scraperService != nil && scrapeMode == models.ScrapeModeMetadataOnly {
+				applyURLSummary(memory, s.scraperService.MetadataSummary(*detectedURL))
+			}
 		}
 	}
 
-	// Store memory
-	if err := s.memoryRepo.Create(memory); err != nil {
+	// Long notes without a page title get a short AI title to display
+	if config != nil && memory.URLTitle == nil && utf8.RuneCountInString(content) > models.GeneratedTitleMinContentLength {
+		if title, err := GenerateMemoryTitle(content, config); err != nil {
+			log.Printf("[MemoryService] Failed to generate title: %v", err)
+		} else {
+			memory.GeneratedTitle = &title
+		}
+	}
+
+	if memory.URLContent != nil || memory.ThumbnailURL != nil {
+		scrapedAt := time.Now()
+		memory.LastScrapedAt = &scrapedAt
+	}
+	memory.ContentLanguage = memoryLanguage(memory)
+	return memory
+}
+
+// applyURLSummary sets the memory's URL details to the non-empty ones of summary
+func applyURLSummary(memory *models.Memory, summary *models.URLSummary) {
+	if summary == nil {
+		return
+	}
+	if summary.Title != "" {
+		memory.URLTitle = &summary.Title
+	}
+	if summary.Summary != "" {
+		memory.URLContent = &summary.Summary
+	}
+	if summary.ThumbnailURL != "" {
+		memory.ThumbnailURL = &summary.ThumbnailURL
+	}
+}
+
+// validScrapeMode reports whether mode is a scrape mode or empty, for the default
+func validScrapeMode(mode string) bool {
+	switch mode {
+	case "", models.ScrapeModeFull, models.ScrapeModeMetadataOnly, models.ScrapeModeNone:
+		return true
+	}
+	return false
+}
+
+// GenerateTitle generates and stores a display title for an existing memory on
+// request, whatever its length, replacing any previous one
+func (s *MemoryService) GenerateTitle(userID, memoryID string) (*models.Memory, error) {
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
 		return nil, err
 	}
+	if memory == nil || memory.UserID != userID {
+		return nil, ErrMemoryNotFound
+	}
+
+	config := s.getAIConfig(userID)
+	if config == nil {
+		return nil, ErrMemoryAINotConfigured
+	}
+
+	title, err := GenerateMemoryTitle(memory.Content, config)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTitleGenerationFailed, err)
+	}
+	if err := s.memoryRepo.UpdateGeneratedTitle(memory.ID, title); err != nil {
+		return nil, err
+	}
+
+	memory.GeneratedTitle = &title
+	return memory, nil
+}
+
+// RefreshURL re-scrapes a memory's URL on request, with the user's AI provider
+func (s *MemoryService) RefreshURL(ctx context.Context, userID, memoryID string) (*models.Memory, error) {
+	memory, err := s.GetByID(userID, memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil {
+		return nil, ErrMemoryNotFound
+	}
+
+	if err := s.RefreshURLContent(ctx, memory, s.getAIConfig(userID)); err != nil {
+		return nil, err
+	}
+	return memory, nil
+}
+
+// RefreshURLContent re-scrapes the memory's URL, summarizes the page again and saves
+// the new url_title, url_content and thumbnail_url on memory, then re-indexes it.
+// The page summary also replaces the memory's summary, which described the old page,
+// unless the user wrote it (SummarySourceManual).
+// Without an AI config the page title is refreshed and the previous summary kept.
+// Memories saved with ScrapeModeMetadataOnly get their metadata fetched again instead;
+// those saved with ScrapeModeNone are scraped in full, as the refresh was asked for.
+func (s *MemoryService) RefreshURLContent(ctx context.Context, memory *models.Memory, config *AIProviderConfig) error {
+	if memory.URL == nil || *memory.URL == "" {
+		return ErrMemoryHasNoURL
+	}
+	if s.scraperService == nil {
+		return ErrScraperNotEnabled
+	}
+
+	urlTitle, urlContent, thumbnailURL := memory.URLTitle, memory.URLContent, memory.ThumbnailURL
+	if memory.ScrapeMode == models.ScrapeModeMetadataOnly {
+		metadata, err := s.scraperService.FetchMetadata(*memory.URL)
+		if err != nil {
+			return fmt.Errorf("%w %s: %v", ErrURLScrapeFailed, *memory.URL, err)
+		}
+		if metadata.Title != "" {
+			urlTitle = &metadata.Title
+		}
+		if metadata.Description != "" {
+			urlContent = &metadata.Description
+		}
+		if metadata.ImageURL != "" {
+			thumbnailURL = &metadata.ImageURL
+		}
+		return s.saveRefreshedURLContent(ctx, memory, urlTitle, urlContent, thumbnailURL, nil)
+	}
+
+	scraped, err := s.scraperService.ScrapeURL(*memory.URL)
+	if err != nil {
+		return fmt.Errorf("%w %s: %v", ErrURLScrapeFailed, *memory.URL, err)
+	}
+
+	if scraped.Title != "" {
+		urlTitle = &scraped.Title
+	}
+	if scraped.ImageURL != "" {
+		thumbnailURL = &scraped.ImageURL
+	}
+	var newSummary *string
+	if config != nil && scraped.Content != "" {
+		if config.Ctx == nil {
+			config.Ctx = ctx
+		}
+		summary, err := SummarizeURLWithProvider(*memory.URL, scraped.Content, config)
+		if err != nil {
+			return fmt.Errorf("failed to summarize %s: %w", *memory.URL, err)
+		}
+		if summary.Title != "" {
+			urlTitle = &summary.Title
+		}
+		if summary.Summary != "" {
+			urlContent = &summary.Summary
+			if memory.SummarySource == nil || *memory.SummarySource != models.SummarySourceManual {
+				newSummary = &summary.Summary
+			}
+		}
+	}
+
+	return s.saveRefreshedURLContent(ctx, memory, urlTitle, urlContent, thumbnailURL, newSummary)
+}
+
+// saveRefreshedURLContent stores the refreshed URL details of memory, and summary as
+// its summary when not nil, then re-indexes it
+func (s *MemoryService) saveRefreshedURLContent(ctx context.Context, memory *models.Memory, urlTitle, urlContent, thumbnailURL, summary *string) error {
+	scrapedAt := time.Now()
+	if err := s.memoryRepo.UpdateURLContent(memory.ID, urlTitle, urlContent, thumbnailURL, scrapedAt); err != nil {
+		return err
+	}
+	memory.URLTitle, memory.URLContent, memory.ThumbnailURL, memory.LastScrapedAt = urlTitle, urlContent, thumbnailURL, &scrapedAt
+
+	if summary != nil {
+		// Through Update, so the summary being replaced is kept as a revision
+		if err := s.memoryRepo.Update(memory.ID, map[string]interface{}{
+			"summary":        *summary,
+			"summary_source": models.SummarySourceURLSummary,
+		}); err != nil {
+			return err
+		}
+		source := models.SummarySourceURLSummary
+		memory.Summary, memory.SummarySource = summary, &source
+	}
 
-	// Async RAG indexing - fire and forget
 	if s.ragService != nil && s.ragService.IsConfigured() {
-		log.Printf("[MemoryService] Indexing memory %s to vector database (async)", memory.ID)
-		go func(m *models.Memory) {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := s.ragService.IndexMemory(ctx, memory); err != nil {
+			log.Printf("[MemoryService] Failed to re-index memory %s after URL refresh: %v", memory.ID, err)
+		}
+	}
+	return nil
+}
+
+// RefreshStaleURLs refreshes the URL content of up to limit memories last scraped more
+// than maxAge ago, oldest first, and returns how many were refreshed
+func (s *MemoryService) RefreshStaleURLs(ctx context.Context, maxAge time.Duration, limit int) (int, error) {
+	memories, err := s.memoryRepo.GetStaleURLMemories(time.Now().Add(-maxAge), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	refreshed := 0
+	for i := range memories {
+		if ctx.Err() != nil {
+			break
+		}
+		memory := &memories[i]
+		if err := s.RefreshURLContent(ctx, memory, s.getAIConfig(memory.UserID)); err != nil {
+			log.Printf("[MemoryService] Failed to refresh URL content for memory %s: %v", memory.ID, err)
+			// Record the attempt so a dead page waits for the next interval instead of
+			// taking a slot in every run
+			if err := s.memoryRepo.UpdateURLContent(memory.ID, memory.URLTitle, memory.URLContent, memory.ThumbnailURL, time.Now()); err != nil {
+				log.Printf("[MemoryService] Failed to record URL refresh attempt for memory %s: %v", memory.ID, err)
+			}
+			continue
+		}
+		refreshed++
+	}
+	return refreshed, nil
+}
+
+// CreateBatch creates several memories atomically. Each item gets the same processing
+// as Create, then all are inserted in one transaction. With stopOnError the first
+// invalid or failed item leaves the whole batch uncreated; otherwise failed items are
+// reported and the rest are created. The created memories are indexed together in
+// the background once committed.
+func (s *MemoryService) CreateBatch(userID string, reqs []models.MemoryCreateRequest, stopOnError bool) (*models.BatchCreateResult, error) {
+	if len(reqs) == 0 {
+		return nil, ErrEmptyMemoryBatch
+	}
+	if len(reqs) > models.MaxMemoryBatchSize {
+		return nil, ErrMemoryBatchTooLarge
+	}
+
+	result := &models.BatchCreateResult{
+		Created: []string{},
+		Failed:  []models.BatchCreateFailure{},
+	}
+
+	// Validate everything first, so a stop-on-error batch doesn't spend AI calls
+	// on items that will never be stored
+	valid := make([]int, 0, len(reqs))
+	for i, req := range reqs {
+		if strings.TrimSpace(req.Content) == "" {
+			result.Failed = append(result.Failed, models.BatchCreateFailure{Index: i, Error: "content is required"})
+			if stopOnError {
+				return result, nil
+			}
+			continue
+		}
+		if !validScrapeMode(req.ScrapeMode) {
+			result.Failed = append(result.Failed, models.BatchCreateFailure{Index: i, Error: "scrape_mode must be full, metadata_only or none"})
+			if stopOnError {
+				return result, nil
+			}
+			continue
+		}
+		if err := s.blocklist.Check(userID, req.Content); err != nil {
+			result.Failed = append(result.Failed, models.BatchCreateFailure{Index: i, Error: err.Error()})
+			if stopOnError {
+				return result, nil
+			}
+			continue
+		}
+		valid = append(valid, i)
+	}
+	if len(valid) == 0 {
+		return result, nil
+	}
+
+	maxPos, err := s.memoryRepo.GetMaxPosition(userID)
+	if err != nil {
+		maxPos = 0
+	}
+	memories := make([]*models.Memory, len(valid))
+	for n, i := range valid {
+		memories[n] = s.prepareMemory(userID, reqs[i].Content, reqs[i].ScrapeMode, fmt.Sprintf("%d", maxPos+1000*(n+1)))
+	}
+
+	insertErrs, err := s.memoryRepo.CreateBatch(memories, stopOnError)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]models.Memory, 0, len(memories))
+	for n, memory := range memories {
+		if insertErr, failed := insertErrs[n]; failed {
+			result.Failed = append(result.Failed, models.BatchCreateFailure{Index: valid[n], Error: insertErr.Error()})
+			continue
+		}
+		if stopOnError && len(insertErrs) > 0 {
+			continue // rolled back with the failed item
+		}
+		result.Created = append(result.Created, memory.ID)
+		created = append(created, *memory)
+		metrics.MemoriesCreatedTotal.WithLabelValues(memory.Category).Inc()
+	}
+	sort.Slice(result.Failed, func(a, b int) bool { return result.Failed[a].Index < result.Failed[b].Index })
+	if len(created) > 0 {
+		s.briefings.Invalidate(userID)
+	}
+
+	if len(created) > 0 && s.ragService != nil && s.ragService.IsConfigured() {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 			defer cancel()
-			if err := s.ragService.IndexMemory(ctx, m); err != nil {
-				log.Printf("[MemoryService] Failed to index memory %s: %v", m.ID, err)
-			} else {
-				log.Printf("[MemoryService] Successfully indexed memory %s", m.ID)
+			if err := s.ragService.IndexMemories(ctx, userID, created); err != nil {
+				log.Printf("[MemoryService] Failed to index %d batch-created memories: %v", len(created), err)
+				for i := range created {
+					s.ragService.QueueIndexRetry(models.ContentTypeMemory, created[i].ID, userID, err)
+				}
+				return
 			}
-		}(memory)
+			for i := range created {
+				s.linkRelated(&created[i])
+			}
+		}()
 	}
 
-	log.Printf("[MemoryService] Created memory %s with category %s", memory.ID, memory.Category)
-	return memory, nil
+	log.Printf("[MemoryService] Batch created %d memories for user %s (%d failed)", len(result.Created), userID, len(result.Failed))
+	return result, nil
 }
 
 // CreateWithCategory creates a memory with pre-determined category and summary (used by vision service)
 func (s *MemoryService) CreateWithCategory(userID string, req *models.MemoryCreateRequest, category, summary string) (*models.Memory, error) {
+	if err := s.blocklist.Check(userID, req.Content); err != nil {
+		return nil, err
+	}
+
 	log.Printf("[MemoryService] Creating memory with category for user %s: category=%s", userID, category)
 
 	// Get max position for new memory
@@ -163,13 +589,18 @@ func (s *MemoryService) CreateWithCategory(userID string, req *models.MemoryCrea
 	}
 
 	if summary != "" {
-		memory.Summary = &summary
+		source := models.SummarySourceAICategorize
+		memory.Summary, memory.SummarySource = &summary, &source
 	}
 
+	memory.ContentLanguage = memoryLanguage(memory)
+
 	// Store memory
 	if err := s.memoryRepo.Create(memory); err != nil {
 		return nil, err
 	}
+	metrics.MemoriesCreatedTotal.WithLabelValues(memory.Category).Inc()
+	s.briefings.Invalidate(userID)
 
 	// Async RAG indexing - fire and forget
 	if s.ragService != nil && s.ragService.IsConfigured() {
@@ -179,6 +610,7 @@ func (s *MemoryService) CreateWithCategory(userID string, req *models.MemoryCrea
 			defer cancel()
 			if err := s.ragService.IndexMemory(ctx, m); err != nil {
 				log.Printf("[MemoryService] Failed to index memory %s: %v", m.ID, err)
+				s.ragService.QueueIndexRetry(models.ContentTypeMemory, m.ID, m.UserID, err)
 			} else {
 				log.Printf("[MemoryService] Successfully indexed memory %s", m.ID)
 			}
@@ -195,34 +627,106 @@ func (s *MemoryService) getAIConfig(userID string) *AIProviderConfig {
 	if s.aiProviderService != nil {
 		provider, err := s.aiProviderService.GetDefaultByUserID(userID)
 		if err == nil && provider != nil && provider.SelectedModel != nil {
-			apiKey, err := s.aiProviderService.GetDecryptedAPIKey(provider)
+			config, err := s.aiProviderService.ProviderConfig(provider, userID)
 			if err == nil {
-				return &AIProviderConfig{
-					ProviderType: provider.ProviderType,
-					BaseURL:      provider.BaseURL,
-					APIKey:       apiKey,
-					Model:        *provider.SelectedModel,
-				}
+				config.DetectLanguage = true
+				return config
 			}
 		}
 	}
 
 	// Fall back to default AI service
+	return s.envAIConfig()
+}
+
+// envAIConfig returns the configuration of the env-configured AI service, or nil if
+// it isn't set up
+func (s *MemoryService) envAIConfig() *AIProviderConfig {
 	if s.aiService != nil && s.aiService.IsConfigured() {
 		return &AIProviderConfig{
-			ProviderType: models.ProviderTypeOpenAI,
-			BaseURL:      s.aiService.baseURL,
-			APIKey:       s.aiService.apiKey,
-			Model:        s.aiService.model,
+			ProviderType:   models.ProviderTypeOpenAI,
+			BaseURL:        s.aiService.baseURL,
+			APIKey:         s.aiService.apiKey,
+			Model:          s.aiService.model,
+			DetectLanguage: true,
+			PIIScrubber:    s.aiService.piiScrubber,
 		}
 	}
-
 	return nil
 }
 
+// processContent categorizes content, detecting and summarizing any URL as scrapeMode
+// says, with the user's AI providers in fallback order and then the env-configured AI
+// service as the last resort. Along with the results it returns the configuration that produced them,
+// or the last one tried if all failed, for the memory's follow-up AI calls; it is nil
+// when no AI is configured.
+func (s *MemoryService) processContent(userID, content, scrapeMode string) (*AIProviderConfig, *models.AIProcessedMemory, *models.URLSummary, error) {
+	var memoryResult *models.AIProcessedMemory
+	var urlSummary *models.URLSummary
+	attempt := func(config *AIProviderConfig) error {
+		config.DetectLanguage = true
+		config.UserID = userID
+		config.CategoryHistory = s.categoryModel
+		config.Prompts = s.promptTemplates
+		result, summary, err := ProcessMemoryWithFunctionCalling(content, config, s.scraperService, scrapeMode)
+		if err != nil {
+			return err
+		}
+		memoryResult, urlSummary = result, summary
+		return nil
+	}
+
+	var config *AIProviderConfig
+	var err error
+	if s.aiProviderService != nil {
+		config, err = s.aiProviderService.WithFallback(userID, attempt)
+		if config != nil && err == nil {
+			return config, memoryResult, urlSummary, nil
+		}
+	}
+
+	if envConfig := s.envAIConfig(); envConfig != nil {
+		if err := attempt(envConfig); err != nil {
+			log.Printf("[MemoryService] Env-configured AI service failed: %v", err)
+			return envConfig, nil, nil, err
+		}
+		return envConfig, memoryResult, urlSummary, nil
+	}
+	return config, nil, nil, err
+}
+
 // GetAll retrieves memories with pagination
+// GetAll returns a page of the user's memories in their chosen sort mode
 func (s *MemoryService) GetAll(userID string, limit, offset int) ([]models.Memory, error) {
-	return s.memoryRepo.GetAllByUserID(userID, limit, offset)
+	return s.memoryRepo.GetAllByUserID(userID, s.preferences.GetSortMode(userID), limit, offset)
+}
+
+// GetPage returns a page of the user's unarchived memories, newest first, after the
+// cursor token (the first page if it's empty)
+func (s *MemoryService) GetPage(userID, after string, limit int) (*models.MemoryPage, error) {
+	cursor, err := DecodePageCursor(after)
+	if err != nil {
+		return nil, err
+	}
+	limit = clampPageLimit(limit)
+
+	// One extra row tells whether there is a next page
+	memories, err := s.memoryRepo.GetAfterCursor(userID, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.MemoryPage{Memories: memories}
+	if len(memories) > limit {
+		page.Memories = memories[:limit]
+		page.HasMore = true
+		last := page.Memories[limit-1]
+		page.NextCursor = EncodePageCursor(last.CreatedAt, last.ID)
+	}
+	if page.Memories == nil {
+		page.Memories = []models.Memory{}
+	}
+	return page, nil
 }
 
 // GetByID retrieves a single memory
@@ -244,7 +748,16 @@ func (s *MemoryService) GetByCategory(userID, category string, limit, offset int
 
 // Search performs full-text search
 func (s *MemoryService) Search(userID string, req *models.MemorySearchRequest) ([]models.Memory, error) {
-	return s.memoryRepo.Search(userID, req)
+	memories, err := s.memoryRepo.Search(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Later pages of the same search aren't new searches
+	if req.Offset == 0 {
+		s.searchHistory.Record(userID, req.Query, []string{string(models.ContentTypeMemory)}, len(memories))
+	}
+	return memories, nil
 }
 
 // Update updates a memory
@@ -255,13 +768,30 @@ func (s *MemoryService) Update(userID, memoryID string, req *models.MemoryUpdate
 		return nil, err
 	}
 	if memory == nil || memory.UserID != userID {
-		return nil, fmt.Errorf("memory not found")
+		return nil, ErrMemoryNotFound
 	}
 
 	updates := make(map[string]interface{})
 
 	if req.Content != nil {
+		if err := s.blocklist.Check(userID, *req.Content); err != nil {
+			return nil, err
+		}
 		updates["content"] = *req.Content
+		updates["content_language"] = DetectLanguageCode(*req.Content)
+		if *req.Content != memory.Content {
+			// The old title described the old content; it can be regenerated on demand
+			updates["generated_title"] = nil
+		}
+	}
+	if req.Summary != nil {
+		// A summary set by hand is never regenerated from the URL
+		if *req.Summary == "" {
+			updates["summary"] = nil
+		} else {
+			updates["summary"] = *req.Summary
+		}
+		updates["summary_source"] = models.SummarySourceManual
 	}
 	if req.Category != nil {
 		updates["category"] = *req.Category
@@ -278,6 +808,10 @@ func (s *MemoryService) Update(userID, memoryID string, req *models.MemoryUpdate
 		if err := s.memoryRepo.Update(memoryID, updates); err != nil {
 			return nil, err
 		}
+		s.briefings.Invalidate(userID)
+	}
+	if req.Content != nil {
+		s.invalidatePreview(memoryID)
 	}
 
 	updatedMemory, err := s.memoryRepo.GetByID(memoryID)
@@ -285,6 +819,13 @@ func (s *MemoryService) Update(userID, memoryID string, req *models.MemoryUpdate
 		return nil, err
 	}
 
+	// A category changed by hand teaches the categorization of memories like this one
+	if s.categoryModel != nil && req.Category != nil && *req.Category != memory.Category && updatedMemory != nil {
+		if err := s.categoryModel.RecordCorrection(userID, updatedMemory.Content, memory.Category, *req.Category); err != nil {
+			log.Printf("[MemoryService] Failed to record category correction for memory %s: %v", memoryID, err)
+		}
+	}
+
 	// Async RAG re-indexing - fire and forget
 	if s.ragService != nil && s.ragService.IsConfigured() && updatedMemory != nil {
 		log.Printf("[MemoryService] Re-indexing updated memory %s to vector database (async)", updatedMemory.ID)
@@ -293,24 +834,138 @@ func (s *MemoryService) Update(userID, memoryID string, req *models.MemoryUpdate
 			defer cancel()
 			if err := s.ragService.IndexMemory(ctx, m); err != nil {
 				log.Printf("[MemoryService] Failed to re-index memory %s: %v", m.ID, err)
+				s.ragService.QueueIndexRetry(models.ContentTypeMemory, m.ID, m.UserID, err)
 			} else {
 				log.Printf("[MemoryService] Successfully re-indexed memory %s", m.ID)
 			}
 		}(updatedMemory)
+
+		if req.Content != nil && *req.Content != memory.Content {
+			go s.linkRelated(updatedMemory)
+		}
 	}
 
 	return updatedMemory, nil
 }
 
+// ListRevisions returns a memory's latest revisions, newest first, each with the
+// number of characters changed by the edit that replaced it
+func (s *MemoryService) ListRevisions(userID, memoryID string) ([]models.MemoryRevision, error) {
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil || memory.UserID != userID {
+		return nil, ErrMemoryNotFound
+	}
+
+	revisions, err := s.memoryRepo.GetRevisions(memoryID, models.MemoryRevisionListLimit)
+	if err != nil {
+		return nil, err
+	}
+	// Each revision was replaced by the next newer one, the newest by the current content
+	next := memory.Content
+	for i := range revisions {
+		revisions[i].DiffChars = diffChars(revisions[i].Content, next)
+		next = revisions[i].Content
+	}
+	return revisions, nil
+}
+
+// RestoreRevision puts a revision's content back on its memory. The content being
+// replaced is saved as a new revision, so a restore can itself be undone.
+func (s *MemoryService) RestoreRevision(userID, memoryID, revisionID string) (*models.Memory, error) {
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil || memory.UserID != userID {
+		return nil, ErrMemoryNotFound
+	}
+
+	revision, err := s.memoryRepo.GetRevision(memoryID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if revision == nil {
+		return nil, ErrRevisionNotFound
+	}
+
+	return s.Update(userID, memoryID, &models.MemoryUpdateRequest{Content: &revision.Content})
+}
+
+// diffChars approximates how many characters an edit from a to b changed: the length
+// of the longer string once their common prefix and suffix are removed
+func diffChars(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prefix := 0
+	for prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ra)-prefix && suffix < len(rb)-prefix && ra[len(ra)-1-suffix] == rb[len(rb)-1-suffix] {
+		suffix++
+	}
+	return max(len(ra), len(rb)) - prefix - suffix
+}
+
+// Pin marks a memory as pinned so it is listed ahead of unpinned memories
+func (s *MemoryService) Pin(userID, memoryID string) (*models.Memory, error) {
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil || memory.UserID != userID {
+		return nil, ErrMemoryNotFound
+	}
+	if memory.IsPinned {
+		return memory, nil
+	}
+
+	pinned, err := s.memoryRepo.CountPinnedByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if pinned >= MaxPinnedMemories {
+		return nil, ErrPinLimitReached
+	}
+
+	if err := s.memoryRepo.Update(memoryID, map[string]interface{}{"is_pinned": 1}); err != nil {
+		return nil, err
+	}
+
+	return s.memoryRepo.GetByID(memoryID)
+}
+
+// Unpin clears the pinned flag on a memory
+func (s *MemoryService) Unpin(userID, memoryID string) (*models.Memory, error) {
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil || memory.UserID != userID {
+		return nil, ErrMemoryNotFound
+	}
+	if !memory.IsPinned {
+		return memory, nil
+	}
+
+	if err := s.memoryRepo.Update(memoryID, map[string]interface{}{"is_pinned": 0}); err != nil {
+		return nil, err
+	}
+
+	return s.memoryRepo.GetByID(memoryID)
+}
+
 // Delete removes a memory
-func (s *MemoryService) Delete(userID, memoryID string) error {
+func (s *MemoryService) Delete(userID, memoryID, ipAddress string) error {
 	// Verify ownership
 	memory, err := s.memoryRepo.GetByID(memoryID)
 	if err != nil {
 		return err
 	}
 	if memory == nil || memory.UserID != userID {
-		return fmt.Errorf("memory not found")
+		return ErrMemoryNotFound
 	}
 
 	// Delete from RAG indexes FIRST (synchronously for reliability)
@@ -324,8 +979,185 @@ func (s *MemoryService) Delete(userID, memoryID string) error {
 		}
 	}
 
+	// Attachment rows go with the memory, so their files are looked up first
+	attachmentKeys, keysErr := s.attachments.MemoryAttachmentKeys(memoryID)
+	if keysErr != nil {
+		log.Printf("[MemoryService] Warning: Failed to look up attachments of memory %s: %v", memoryID, keysErr)
+	}
+
 	// Delete from database (FTS will be auto-deleted by SQLite trigger)
-	return s.memoryRepo.Delete(memoryID)
+	err = s.memoryRepo.Delete(memoryID)
+	s.invalidatePreview(memoryID)
+	if err == nil {
+		s.attachments.DeleteObjects(context.Background(), attachmentKeys...)
+		s.briefings.Invalidate(userID)
+	}
+
+	// Audit regardless of outcome so failed deletions are visible too
+	s.auditService.Log(userID, models.AuditActionMemoryDeleted, map[string]string{
+		"memory_id": memoryID,
+		"category":  memory.Category,
+		"success":   strconv.FormatBool(err == nil),
+	}, ipAddress)
+
+	return err
+}
+
+// BulkDelete removes every memory matching the filter and returns how many were deleted
+func (s *MemoryService) BulkDelete(userID string, filter models.BulkDeleteFilter, ipAddress string) (int, error) {
+	if filter.Category == "" && filter.BeforeDate == "" {
+		return 0, ErrEmptyBulkDeleteFilter
+	}
+	if filter.BeforeDate != "" {
+		if _, err := time.Parse("2006-01-02", filter.BeforeDate); err != nil {
+			return 0, ErrInvalidBeforeDate
+		}
+	}
+
+	// Collect affected IDs first so the indexes can be cleaned before the rows disappear
+	ids, err := s.memoryRepo.GetIDsByFilter(userID, filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if s.ragService != nil && s.ragService.IsConfigured() {
+		log.Printf("[MemoryService] Deleting %d memories from vector index for user %s", len(ids), userID)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := s.ragService.DeleteManyFromIndex(ctx, userID, models.ContentTypeMemory, ids); err != nil {
+			log.Printf("[MemoryService] Warning: Failed to delete memories from vector index: %v", err)
+			// Don't fail - continue with database deletion to prevent orphaned records
+		}
+	}
+
+	attachmentKeys, keysErr := s.attachments.MemoryAttachmentKeys(ids...)
+	if keysErr != nil {
+		log.Printf("[MemoryService] Warning: Failed to look up attachments of %d memories: %v", len(ids), keysErr)
+	}
+
+	// Delete from database (FTS will be auto-deleted by SQLite trigger)
+	deleted, err := s.memoryRepo.DeleteByFilter(userID, filter)
+	if err == nil {
+		s.attachments.DeleteObjects(context.Background(), attachmentKeys...)
+		s.briefings.Invalidate(userID)
+	}
+
+	s.auditService.Log(userID, models.AuditActionMemoryDeleted, map[string]string{
+		"bulk":        "true",
+		"category":    filter.Category,
+		"before_date": filter.BeforeDate,
+		"count":       strconv.FormatInt(deleted, 10),
+		"success":     strconv.FormatBool(err == nil),
+	}, ipAddress)
+
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
+
+// BulkArchive archives every non-archived memory matching the filter and returns how
+// many were archived. Archived memories are already excluded from listings, so the
+// vector and FTS indexes are left as they are.
+func (s *MemoryService) BulkArchive(userID string, filter models.BulkArchiveFilter) (int, error) {
+	return s.setArchivedByFilter(userID, filter, true)
+}
+
+// BulkUnarchive restores every archived memory matching the filter and returns how many
+// were restored. Pinned memories are always included since unarchiving them is harmless.
+func (s *MemoryService) BulkUnarchive(userID string, filter models.BulkArchiveFilter) (int, error) {
+	filter.IncludePinned = true
+	return s.setArchivedByFilter(userID, filter, false)
+}
+
+func (s *MemoryService) setArchivedByFilter(userID string, filter models.BulkArchiveFilter, archived bool) (int, error) {
+	if filter.OlderThanDays < 0 {
+		return 0, ErrInvalidOlderThanDays
+	}
+	if filter.OlderThanDays == 0 && filter.Category == "" {
+		return 0, ErrEmptyBulkArchiveFilter
+	}
+
+	var cutoff string
+	if filter.OlderThanDays > 0 {
+		cutoff = time.Now().AddDate(0, 0, -filter.OlderThanDays).Format("2006-01-02")
+	}
+
+	updated, err := s.memoryRepo.SetArchivedByFilter(userID, filter, cutoff, archived)
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("[MemoryService] Bulk set is_archived=%t on %d memories for user %s", archived, updated, userID)
+	return int(updated), nil
+}
+
+// Clone duplicates a memory as a new, unarchived memory at the end of the list
+func (s *MemoryService) Clone(userID, memoryID string, overrides *models.MemoryCloneOverrides) (*models.Memory, error) {
+	// Verify ownership
+	original, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil || original.UserID != userID {
+		return nil, ErrMemoryNotFound
+	}
+
+	maxPos, err := s.memoryRepo.GetMaxPosition(userID)
+	if err != nil {
+		maxPos = 0
+	}
+
+	clone := &models.Memory{
+		UserID:     userID,
+		Content:    original.Content,
+		Summary:    original.Summary,
+		Category:   original.Category,
+		URL:        original.URL,
+		URLTitle:   original.URLTitle,
+		URLContent: original.URLContent,
+		// A summary the user wrote stays theirs on the clone
+		SummarySource: original.SummarySource,
+		// Titled from the original content, so dropped below if the content is replaced
+		GeneratedTitle: original.GeneratedTitle,
+		IsArchived:     false,
+		// Clones start unpinned so duplicating can't exceed the pin limit
+		IsPinned: false,
+		Position: fmt.Sprintf("%d", maxPos+1000),
+	}
+
+	if overrides != nil {
+		if overrides.Content != nil && *overrides.Content != original.Content {
+			clone.Content = *overrides.Content
+			clone.GeneratedTitle = nil
+		}
+		if overrides.Category != nil {
+			clone.Category = *overrides.Category
+		}
+	}
+
+	if err := s.memoryRepo.Create(clone); err != nil {
+		return nil, err
+	}
+	metrics.MemoriesCreatedTotal.WithLabelValues(clone.Category).Inc()
+	s.briefings.Invalidate(userID)
+
+	// Async RAG indexing - fire and forget
+	if s.ragService != nil && s.ragService.IsConfigured() {
+		go func(m *models.Memory) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := s.ragService.IndexMemory(ctx, m); err != nil {
+				log.Printf("[MemoryService] Failed to index cloned memory %s: %v", m.ID, err)
+				s.ragService.QueueIndexRetry(models.ContentTypeMemory, m.ID, m.UserID, err)
+			}
+		}(clone)
+	}
+
+	log.Printf("[MemoryService] Cloned memory %s as %s", memoryID, clone.ID)
+	return clone, nil
 }
 
 // ConvertToTodo creates a todo from a memory
@@ -336,7 +1168,10 @@ func (s *MemoryService) ConvertToTodo(userID, memoryID string, req *models.Memor
 		return nil, err
 	}
 	if memory == nil || memory.UserID != userID {
-		return nil, fmt.Errorf("memory not found")
+		return nil, ErrMemoryNotFound
+	}
+	if err := checkTodoGroup(s.groupRepo, userID, req.GroupID); err != nil {
+		return nil, err
 	}
 
 	// Determine title and description
@@ -385,6 +1220,8 @@ func (s *MemoryService) ConvertToTodo(userID, memoryID string, req *models.Memor
 	if err := s.todoRepo.Create(todo); err != nil {
 		return nil, err
 	}
+	metrics.TodosCreatedTotal.Inc()
+	s.briefings.Invalidate(userID)
 
 	// Async RAG indexing for the new todo - fire and forget
 	if s.ragService != nil && s.ragService.IsConfigured() {
@@ -393,6 +1230,7 @@ func (s *MemoryService) ConvertToTodo(userID, memoryID string, req *models.Memor
 			defer cancel()
 			if err := s.ragService.IndexTodo(ctx, t); err != nil {
 				log.Printf("[MemoryService] Failed to index converted todo %s: %v", t.ID, err)
+				s.ragService.QueueIndexRetry(models.ContentTypeTodo, t.ID, t.UserID, err)
 			}
 		}(todo)
 	}
@@ -421,17 +1259,112 @@ func (s *MemoryService) Reorder(userID string, req *models.MemoryReorderRequest)
 	return s.memoryRepo.UpdatePositions(req.Memories)
 }
 
+// ResetPositions renumbers the user's memories 1000, 2000, ... keeping their order,
+// for when reordering has left positions large or uneven. Returns how many were
+// renumbered. Positions aren't part of the RAG index, so it's left as it is.
+func (s *MemoryService) ResetPositions(userID string) (int, error) {
+	return s.memoryRepo.ResetPositions(userID)
+}
+
 // GetStats returns memory statistics
 func (s *MemoryService) GetStats(userID string) (*models.MemoryStats, error) {
 	return s.memoryRepo.GetStats(userID)
 }
 
+// FindSemanticDuplicates maps the index of each section that is near-identical to an
+// existing memory (similarity >= threshold) to that memory's ID. Returns an empty map
+// when RAG isn't configured. Sections that fail to embed are treated as unique.
+func (s *MemoryService) FindSemanticDuplicates(ctx context.Context, userID string, sections []ParsedMemorySection, threshold float64) (map[int]string, error) {
+	duplicates := make(map[int]string)
+	if s.ragService == nil || !s.ragService.IsConfigured() {
+		return duplicates, nil
+	}
+	if threshold <= 0 {
+		threshold = DefaultDuplicateThreshold
+	}
+
+	for i, section := range sections {
+		sectionCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		memoryID, score, err := s.ragService.FindNearestMemory(sectionCtx, userID, section.Content)
+		cancel()
+		if err != nil {
+			log.Printf("[MemoryService] Duplicate check failed for section %d %q: %v", i, section.Heading, err)
+			continue
+		}
+		if memoryID == "" || score < threshold {
+			continue
+		}
+
+		// The index can briefly outlive a deleted memory
+		existing, err := s.memoryRepo.GetByID(memoryID)
+		if err != nil || existing == nil || existing.UserID != userID {
+			continue
+		}
+
+		log.Printf("[MemoryService] Section %d %q duplicates memory %s (similarity %.3f)", i, section.Heading, memoryID, score)
+		duplicates[i] = memoryID
+	}
+
+	return duplicates, nil
+}
+
+// GetRelated returns the memories auto-linked to one of the user's memories
+func (s *MemoryService) GetRelated(userID, memoryID string, limit int) ([]models.Memory, error) {
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil || memory.UserID != userID {
+		return nil, ErrMemoryNotFound
+	}
+	return s.memoryRepo.GetRelated(memoryID, limit)
+}
+
+// linkRelated replaces a memory's links with its nearest memories at or above
+// RelatedMemoryThreshold. It runs in the background after a save, so failures are only logged.
+func (s *MemoryService) linkRelated(m *models.Memory) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// One extra result in case the memory's own vector is already indexed
+	results, err := s.ragService.FindNearestMemories(ctx, m.UserID, m.Content, MaxRelatedMemoryLinks+1)
+	if err != nil {
+		log.Printf("[MemoryService] Failed to find related memories for %s: %v", m.ID, err)
+		return
+	}
+
+	links := make(map[string]float64, MaxRelatedMemoryLinks)
+	for _, result := range results {
+		relatedID := result.Document.ContentID
+		if relatedID == m.ID || result.Score < RelatedMemoryThreshold || len(links) == MaxRelatedMemoryLinks {
+			continue
+		}
+		// The index can briefly outlive a deleted memory
+		related, err := s.memoryRepo.GetByID(relatedID)
+		if err != nil || related == nil || related.UserID != m.UserID {
+			continue
+		}
+		links[relatedID] = result.Score
+	}
+
+	if err := s.memoryRepo.ReplaceLinks(m.ID, links); err != nil {
+		log.Printf("[MemoryService] Failed to store related memory links for %s: %v", m.ID, err)
+		return
+	}
+	log.Printf("[MemoryService] Linked memory %s to %d related memories", m.ID, len(links))
+}
+
 // GetOrGenerateDigest retrieves or creates weekly digest
 func (s *MemoryService) GetOrGenerateDigest(userID string, forceRegenerate bool) (*models.MemoryDigest, error) {
-	// Calculate current week start (Sunday)
-	now := time.Now()
-	weekday := int(now.Weekday())
-	weekStart := now.AddDate(0, 0, -weekday)
+	return s.GetOrGenerateDigestForWeek(userID, time.Now(), forceRegenerate)
+}
+
+// GetOrGenerateDigestForWeek retrieves or creates the digest for the week (starting
+// Sunday) containing day, in day's location
+func (s *MemoryService) GetOrGenerateDigestForWeek(userID string, day time.Time, forceRegenerate bool) (*models.MemoryDigest, error) {
+	// Calculate week start (Sunday)
+	weekday := int(day.Weekday())
+	weekStart := day.AddDate(0, 0, -weekday)
 	weekStart = time.Date(weekStart.Year(), weekStart.Month(), weekStart.Day(), 0, 0, 0, 0, weekStart.Location())
 	weekEnd := weekStart.AddDate(0, 0, 6)
 
@@ -443,8 +1376,8 @@ func (s *MemoryService) GetOrGenerateDigest(userID string, forceRegenerate bool)
 		}
 	}
 
-	// Get memories from this week
-	memories, err := s.memoryRepo.GetByDateRange(userID, weekStart, weekEnd.Add(24*time.Hour))
+	// Get memories from this week (compared in server time, like the stored timestamps)
+	memories, err := s.memoryRepo.GetByDateRange(userID, weekStart.Local(), weekEnd.Add(24*time.Hour).Local())
 	if err != nil {
 		return nil, err
 	}
@@ -454,6 +1387,8 @@ func (s *MemoryService) GetOrGenerateDigest(userID string, forceRegenerate bool)
 	if config == nil {
 		return nil, fmt.Errorf("AI not configured")
 	}
+	config.UserID = userID
+	config.Prompts = s.promptTemplates
 
 	digestContent, err := GenerateWeeklyDigestWithProvider(memories, config)
 	if err != nil {
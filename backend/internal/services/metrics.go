@@ -0,0 +1,32 @@
+package services
+
+import (
+	"time"
+
+	"github.com/todomyday/backend/internal/metrics"
+)
+
+// observeAIRequest records a provider call's outcome and latency
+func observeAIRequest(config *AIProviderConfig, start time.Time, err error) {
+	status := metrics.StatusSuccess
+	if err != nil {
+		status = metrics.StatusError
+	}
+	provider := string(config.ProviderType)
+	metrics.AIRequestsTotal.WithLabelValues(provider, config.Model, status).Inc()
+	metrics.AIRequestDuration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+}
+
+// observeCache counts a cache lookup as a hit or miss for the resource
+func observeCache(resource string, hit bool) {
+	if hit {
+		metrics.CacheHitsTotal.WithLabelValues(resource).Inc()
+	} else {
+		metrics.CacheMissesTotal.WithLabelValues(resource).Inc()
+	}
+}
+
+// observeSearch records how long a RAG search of the given type took
+func observeSearch(searchType string, start time.Time) {
+	metrics.RAGSearchDuration.WithLabelValues(searchType).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+// nonChatModelPatterns mark models that only embed, transcribe, speak, draw or
+// classify; they get no capabilities
+var nonChatModelPatterns = []string{"embed", "whisper", "tts", "dall-e", "moderation", "rerank"}
+
+// visionModelPatterns appear in the names of models that accept images
+var visionModelPatterns = []string{
+	"vision", "4v", "4.5v", "-vl", "vl-", "llava", "pixtral", "gpt-4o", "gpt-4.1", "gpt-4-turbo",
+	"gpt-5", "claude-3", "claude-sonnet-4", "claude-opus-4", "claude-haiku-4", "gemini",
+}
+
+// functionCallingModelPatterns appear in the names of models that accept tool definitions
+var functionCallingModelPatterns = []string{
+	"gpt-3.5-turbo", "gpt-4", "gpt-5", "o3", "o4", "claude-3", "claude-sonnet-4", "claude-opus-4",
+	"claude-haiku-4", "gemini", "glm-4", "mistral-large", "mistral-small", "qwen2.5", "qwen3",
+	"llama3.1", "llama-3.1", "llama3.2", "llama-3.2", "llama3.3", "llama-3.3", "command-r",
+}
+
+// DetectModelCapabilities guesses what a model supports from its name. Every chat
+// model is assumed to stream.
+func DetectModelCapabilities(modelID string) []string {
+	name := strings.ToLower(modelID)
+	capabilities := []string{}
+	if containsAny(name, nonChatModelPatterns) {
+		return capabilities
+	}
+
+	if containsAny(name, visionModelPatterns) {
+		capabilities = append(capabilities, models.ModelCapabilityVision)
+	}
+	if containsAny(name, functionCallingModelPatterns) {
+		capabilities = append(capabilities, models.ModelCapabilityFunctionCalling)
+	}
+	capabilities = append(capabilities, models.ModelCapabilityStreaming)
+	for _, prefix := range structuredOutputModelPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			capabilities = append(capabilities, models.ModelCapabilityStructuredOutput)
+			break
+		}
+	}
+	return capabilities
+}
+
+// ModelSupports reports whether a model, judged by its name, has capability
+func ModelSupports(modelID, capability string) bool {
+	model := models.AIProviderModel{Capabilities: DetectModelCapabilities(modelID)}
+	return model.HasCapability(capability)
+}
+
+func containsAny(s string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(s, pattern) {
+			return true
+		}
+	}
+	return false
+}
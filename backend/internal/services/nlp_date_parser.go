@@ -0,0 +1,289 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server-side counterpart of the frontend's dateParser.ts, for API clients that
+// send raw titles like "Call mom tomorrow at 5pm". Weekday semantics match the
+// frontend ("next friday" always skips the coming one). Purely numeric dates
+// such as "03/04" are deliberately not parsed since they are ambiguous between
+// US and European ordering.
+
+// dueDatePrefix swallows connector words so "Pay rent by friday" cleans to "Pay rent"
+const dueDatePrefix = `(?i)\b(?:(?:due|by|before)\s+)?`
+
+const dueDateMonths = `(jan(?:uary)?|feb(?:ruary)?|mar(?:ch)?|apr(?:il)?|may|june?|july?|aug(?:ust)?|sep(?:t(?:ember)?)?|oct(?:ober)?|nov(?:ember)?|dec(?:ember)?)`
+
+const dueDateWeekdays = `(monday|tuesday|wednesday|thursday|friday|saturday|sunday|mon|tue|tues|wed|thu|thur|thurs|fri|sat|sun)`
+
+var dueDateMonthMap = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+var dueDateWeekdayMap = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+var dueDateNumberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+}
+
+// dueDateTimeRegex matches an optional time directly after a date expression.
+// A bare number only counts as a time with "at", minutes, or am/pm ("tomorrow 3 apples" is not 3:00).
+var dueDateTimeRegex = regexp.MustCompile(`(?i)^\s+(at\s+)?(\d{1,2})(?::(\d{2}))?\s*(am|pm)?\b`)
+
+// dueDatePattern is a date expression and the function resolving it relative to now
+type dueDatePattern struct {
+	regex   *regexp.Regexp
+	resolve func(m []string, now time.Time) (time.Time, bool)
+}
+
+// Patterns are tried in order, most specific first
+var dueDatePatterns = []dueDatePattern{
+	// "june 15", "jun 15th", "june 15, 2027"
+	{
+		regex: regexp.MustCompile(dueDatePrefix + `(?:on\s+)?` + dueDateMonths + `\.?\s+(\d{1,2})(?:st|nd|rd|th)?(?:,?\s+(\d{4}))?\b`),
+		resolve: func(m []string, now time.Time) (time.Time, bool) {
+			day, _ := strconv.Atoi(m[2])
+			return resolveCalendarDate(now, dueDateMonthMap[strings.ToLower(m[1])[:3]], day, m[3])
+		},
+	},
+	// "15 june", "15th of june 2027"
+	{
+		regex: regexp.MustCompile(dueDatePrefix + `(?:on\s+)?(?:the\s+)?(\d{1,2})(?:st|nd|rd|th)?\s+(?:of\s+)?` + dueDateMonths + `(?:,?\s+(\d{4}))?\b`),
+		resolve: func(m []string, now time.Time) (time.Time, bool) {
+			day, _ := strconv.Atoi(m[1])
+			return resolveCalendarDate(now, dueDateMonthMap[strings.ToLower(m[2])[:3]], day, m[3])
+		},
+	},
+	{
+		regex: regexp.MustCompile(dueDatePrefix + `(?:the\s+)?day\s+after\s+tomorrow\b`),
+		resolve: func(m []string, now time.Time) (time.Time, bool) {
+			return startOfDay(now).AddDate(0, 0, 2), true
+		},
+	},
+	{
+		regex: regexp.MustCompile(dueDatePrefix + `(tomorrow|tmrw|tmr)\b`),
+		resolve: func(m []string, now time.Time) (time.Time, bool) {
+			return startOfDay(now).AddDate(0, 0, 1), true
+		},
+	},
+	{
+		regex: regexp.MustCompile(dueDatePrefix + `(today|tonight)\b`),
+		resolve: func(m []string, now time.Time) (time.Time, bool) {
+			day := startOfDay(now)
+			if strings.EqualFold(m[1], "tonight") {
+				return time.Date(day.Year(), day.Month(), day.Day(), 20, 0, 0, 0, now.Location()), true
+			}
+			return day, true
+		},
+	},
+	// "in 3 days", "in a week", "in two months"
+	{
+		regex: regexp.MustCompile(dueDatePrefix + `in\s+(\d{1,3}|an?|one|two|three|four|five|six|seven|eight|nine|ten)\s+(day|week|month)s?\b`),
+		resolve: func(m []string, now time.Time) (time.Time, bool) {
+			n, ok := dueDateNumberWords[strings.ToLower(m[1])]
+			if !ok {
+				n, _ = strconv.Atoi(m[1])
+			}
+			// Calendar arithmetic rather than 24h multiples keeps the wall-clock
+			// date correct across DST transitions
+			switch strings.ToLower(m[2]) {
+			case "week":
+				return startOfDay(now).AddDate(0, 0, 7*n), true
+			case "month":
+				return startOfDay(now).AddDate(0, n, 0), true
+			default:
+				return startOfDay(now).AddDate(0, 0, n), true
+			}
+		},
+	},
+	// "next week" is next Monday, "next month" the 1st of next month
+	{
+		regex: regexp.MustCompile(dueDatePrefix + `next\s+(week|month)\b`),
+		resolve: func(m []string, now time.Time) (time.Time, bool) {
+			day := startOfDay(now)
+			if strings.EqualFold(m[1], "month") {
+				return time.Date(day.Year(), day.Month()+1, 1, 0, 0, 0, 0, now.Location()), true
+			}
+			return nextWeekday(day, time.Monday, false), true
+		},
+	},
+	// "next friday", "this fri", "on monday", "coming tuesday"
+	{
+		regex: regexp.MustCompile(dueDatePrefix + `(next|this|on|coming)\s+` + dueDateWeekdays + `\b`),
+		resolve: func(m []string, now time.Time) (time.Time, bool) {
+			day := startOfDay(now)
+			target := dueDateWeekdayMap[strings.ToLower(m[2])[:3]]
+			switch strings.ToLower(m[1]) {
+			case "next":
+				return nextWeekday(day, target, true), true
+			case "this":
+				// "this friday" on a Friday means today
+				return day.AddDate(0, 0, (int(target)-int(day.Weekday())+7)%7), true
+			default:
+				return nextWeekday(day, target, false), true
+			}
+		},
+	},
+	// Standalone full weekday names; abbreviations need a prefix ("sat" is also a verb)
+	{
+		regex: regexp.MustCompile(dueDatePrefix + `(monday|tuesday|wednesday|thursday|friday|saturday|sunday)\b`),
+		resolve: func(m []string, now time.Time) (time.Time, bool) {
+			return nextWeekday(startOfDay(now), dueDateWeekdayMap[strings.ToLower(m[1])[:3]], false), true
+		},
+	},
+}
+
+// ParseDueDate finds a natural language date expression in text ("tomorrow",
+// "next friday", "in 3 days", "june 15"), resolves it in the user's timezone and
+// returns it as an ISO-8601 timestamp along with the text stripped of the expression.
+// Returns a nil date and the unchanged text when no date is found.
+func ParseDueDate(text string, userTimezone string) (*string, string, error) {
+	loc := time.UTC
+	if userTimezone != "" {
+		var err error
+		loc, err = time.LoadLocation(userTimezone)
+		if err != nil {
+			return nil, text, fmt.Errorf("invalid timezone %q: %w", userTimezone, err)
+		}
+	}
+
+	dueDate, cleaned, ok := parseDueDateAt(text, time.Now().In(loc))
+	if !ok {
+		return nil, text, nil
+	}
+
+	iso := dueDate.Format(time.RFC3339)
+	return &iso, cleaned, nil
+}
+
+// parseDueDateAt does the work of ParseDueDate relative to a fixed now
+func parseDueDateAt(text string, now time.Time) (time.Time, string, bool) {
+	for _, p := range dueDatePatterns {
+		for _, loc := range p.regex.FindAllStringSubmatchIndex(text, -1) {
+			m := make([]string, len(loc)/2)
+			for i := range m {
+				if loc[2*i] >= 0 {
+					m[i] = text[loc[2*i]:loc[2*i+1]]
+				}
+			}
+
+			date, ok := p.resolve(m, now)
+			if !ok {
+				continue // e.g. "feb 30" - try later matches and patterns
+			}
+
+			end := loc[1]
+			if t := dueDateTimeRegex.FindStringSubmatchIndex(text[end:]); t != nil {
+				if hour, minute, ok := parseDueDateTime(text[end:], t); ok {
+					date = time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, now.Location())
+					end += t[1]
+				}
+			}
+
+			cleaned := strings.Join(strings.Fields(text[:loc[0]]+" "+text[end:]), " ")
+			return date, cleaned, true
+		}
+	}
+	return time.Time{}, text, false
+}
+
+// parseDueDateTime extracts hour and minute from a dueDateTimeRegex match
+func parseDueDateTime(s string, idx []int) (int, int, bool) {
+	group := func(i int) string {
+		if idx[2*i] < 0 {
+			return ""
+		}
+		return s[idx[2*i]:idx[2*i+1]]
+	}
+
+	hasAt, minutes, meridiem := group(1) != "", group(3), strings.ToLower(group(4))
+	if !hasAt && minutes == "" && meridiem == "" {
+		return 0, 0, false
+	}
+
+	hour, _ := strconv.Atoi(group(2))
+	minute := 0
+	if minutes != "" {
+		minute, _ = strconv.Atoi(minutes)
+	}
+
+	switch meridiem {
+	case "am":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		if hour != 12 {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// resolveCalendarDate builds a month/day date. Without an explicit year, the next
+// occurrence on or after today is used, so "jan 5" written in December means next
+// year and "feb 29" means the next leap year.
+func resolveCalendarDate(now time.Time, month time.Month, day int, year string) (time.Time, bool) {
+	if year != "" {
+		y, _ := strconv.Atoi(year)
+		if day < 1 || day > daysIn(month, y) {
+			return time.Time{}, false
+		}
+		return time.Date(y, month, day, 0, 0, 0, 0, now.Location()), true
+	}
+
+	today := startOfDay(now)
+	for y := now.Year(); y <= now.Year()+8; y++ {
+		if day < 1 || day > daysIn(month, y) {
+			continue
+		}
+		if date := time.Date(y, month, day, 0, 0, 0, 0, now.Location()); !date.Before(today) {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// nextWeekday returns the next occurrence of target after day (never day itself).
+// With skipThisWeek, the coming occurrence is skipped as well, like "next friday" in the frontend.
+func nextWeekday(day time.Time, target time.Weekday, skipThisWeek bool) time.Time {
+	daysToAdd := int(target) - int(day.Weekday())
+	if daysToAdd <= 0 || skipThisWeek {
+		daysToAdd += 7
+	}
+	if skipThisWeek && daysToAdd <= 7 {
+		daysToAdd += 7
+	}
+	return day.AddDate(0, 0, daysToAdd)
+}
+
+// startOfDay returns midnight of t's date in t's location. On days where
+// midnight is skipped by DST, time.Date normalizes to the first valid instant.
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDueDate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, newYork)
+	}
+	// DST starts in New York on 2025-03-09 and ends on 2025-11-02
+	friday := at(2025, 6, 13, 10, 0)
+
+	tests := []struct {
+		name        string
+		text        string
+		now         time.Time
+		want        string // RFC 3339, "" when no date is found
+		wantCleaned string
+	}{
+		{"tomorrow into DST", "Call mom tomorrow", at(2025, 3, 8, 22, 0), "2025-03-09T00:00:00-05:00", "Call mom"},
+		{"tomorrow after DST starts", "Call mom tomorrow", at(2025, 3, 9, 10, 0), "2025-03-10T00:00:00-04:00", "Call mom"},
+		{"tomorrow with a time into DST", "Call mom tomorrow at 9am", at(2025, 3, 8, 22, 0), "2025-03-09T09:00:00-04:00", "Call mom"},
+		{"tomorrow out of DST", "Call mom tomorrow at 5pm", at(2025, 11, 1, 23, 30), "2025-11-02T17:00:00-05:00", "Call mom"},
+		{"in a day across DST", "Renew passport in 1 day", at(2025, 3, 8, 12, 0), "2025-03-09T00:00:00-05:00", "Renew passport"},
+		{"in a week across DST", "Renew passport in a week", at(2025, 3, 5, 12, 0), "2025-03-12T00:00:00-04:00", "Renew passport"},
+		{"next Friday on a Friday", "Pay rent by next friday", friday, "2025-06-27T00:00:00-04:00", "Pay rent"},
+		{"next Friday earlier in the week", "Pay rent next friday", at(2025, 6, 11, 10, 0), "2025-06-20T00:00:00-04:00", "Pay rent"},
+		{"this Friday on a Friday", "Pay rent this friday", friday, "2025-06-13T00:00:00-04:00", "Pay rent"},
+		{"bare Friday on a Friday", "Pay rent friday", friday, "2025-06-20T00:00:00-04:00", "Pay rent"},
+		{"June 15 before June 15", "Book flights june 15", at(2025, 6, 1, 10, 0), "2025-06-15T00:00:00-04:00", "Book flights"},
+		{"June 15 on June 15", "Book flights june 15", at(2025, 6, 15, 18, 0), "2025-06-15T00:00:00-04:00", "Book flights"},
+		{"June 15 after June 15", "Book flights june 15", at(2025, 6, 16, 10, 0), "2026-06-15T00:00:00-04:00", "Book flights"},
+		{"15th of June after June 15", "Book flights on the 15th of june", at(2025, 7, 1, 10, 0), "2026-06-15T00:00:00-04:00", "Book flights"},
+		{"June 15 with a past year", "Book flights june 15, 2024", at(2025, 7, 1, 10, 0), "2024-06-15T00:00:00-04:00", "Book flights"},
+		{"Feb 29 outside a leap year", "Celebrate feb 29", at(2025, 3, 1, 10, 0), "2028-02-29T00:00:00-05:00", "Celebrate"},
+		{"impossible date", "Celebrate feb 30", at(2025, 3, 1, 10, 0), "", "Celebrate feb 30"},
+		{"number that isn't a time", "Buy tomorrow 3 apples", friday, "2025-06-14T00:00:00-04:00", "Buy 3 apples"},
+		{"no date", "Buy milk", friday, "", "Buy milk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, cleaned, ok := parseDueDateAt(tt.text, tt.now)
+			got := ""
+			if ok {
+				got = date.Format(time.RFC3339)
+			}
+			if got != tt.want {
+				t.Errorf("parseDueDateAt(%q, %s) date = %q, want %q", tt.text, tt.now.Format(time.RFC3339), got, tt.want)
+			}
+			if cleaned != tt.wantCleaned {
+				t.Errorf("parseDueDateAt(%q) cleaned = %q, want %q", tt.text, cleaned, tt.wantCleaned)
+			}
+		})
+	}
+}
+
+func TestParseDueDateTimezone(t *testing.T) {
+	date, cleaned, err := ParseDueDate("Call mom tomorrow", "America/New_York")
+	if err != nil {
+		t.Fatalf("ParseDueDate: %v", err)
+	}
+	if date == nil || cleaned != "Call mom" {
+		t.Fatalf("ParseDueDate = %v, %q; want a date and %q", date, cleaned, "Call mom")
+	}
+	parsed, err := time.Parse(time.RFC3339, *date)
+	if err != nil {
+		t.Fatalf("date %q isn't RFC 3339: %v", *date, err)
+	}
+	newYork, _ := time.LoadLocation("America/New_York")
+	if local := parsed.In(newYork); local.Hour() != 0 || local.Minute() != 0 {
+		t.Errorf("date %q isn't midnight in New York", *date)
+	}
+
+	if _, text, err := ParseDueDate("Call mom tomorrow", "Mars/Olympus_Mons"); err == nil || !strings.Contains(err.Error(), "invalid timezone") || text != "Call mom tomorrow" {
+		t.Errorf("ParseDueDate with an unknown timezone = %q, %v; want the text back and an invalid timezone error", text, err)
+	}
+}
@@ -0,0 +1,541 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+const (
+	// OIDCProviderID identifies the configured provider in the providers list
+	OIDCProviderID = "default"
+	// OIDCStateTTL is how long a login started with InitiateLogin can be completed
+	OIDCStateTTL = 10 * time.Minute
+	// oidcMaxPendingLogins caps the logins waiting for a callback, so the public
+	// initiate endpoint can't grow the state store without bound
+	oidcMaxPendingLogins = 10000
+	// oidcHTTPTimeout bounds each request to the provider
+	oidcHTTPTimeout = 10 * time.Second
+	// oidcKeysRefetchInterval limits refetching the provider's keys when an ID token
+	// names a key we don't have
+	oidcKeysRefetchInterval = time.Minute
+	// oidcMaxResponseBytes caps what's read from the provider's endpoints
+	oidcMaxResponseBytes = 1 << 20
+)
+
+var (
+	ErrOIDCNotConfigured    = errors.New("OIDC single sign-on is not configured")
+	ErrOIDCInvalidState     = errors.New("invalid or expired OIDC login state")
+	ErrOIDCTooManyLogins    = errors.New("too many pending OIDC logins, try again later")
+	ErrOIDCCodeRejected     = errors.New("OIDC provider rejected the authorization code")
+	ErrOIDCInvalidToken     = errors.New("invalid OIDC ID token")
+	ErrOIDCEmailNotVerified = errors.New("OIDC provider returned no verified email")
+)
+
+// oidcSigningMethods are the ID token algorithms accepted. HMAC is left out so a token
+// can only be signed with one of the provider's published keys.
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// oidcDiscovery is the part of the provider's /.well-known/openid-configuration we use
+type oidcDiscovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	TokenAuthMethods      []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+type oidcIDTokenClaims struct {
+	Email         string    `json:"email"`
+	EmailVerified *oidcBool `json:"email_verified"`
+	Name          string    `json:"name"`
+	Nonce         string    `json:"nonce"`
+	// AuthTime is when the user authenticated at the provider
+	AuthTime *jwt.NumericDate `json:"auth_time"`
+	jwt.RegisteredClaims
+}
+
+// oidcBool is a boolean claim that some providers send as a string ("true")
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		*b = oidcBool(v)
+	case string:
+		*b = oidcBool(v == "true")
+	default:
+		return fmt.Errorf("invalid boolean claim %s", data)
+	}
+	return nil
+}
+
+// oidcLogin is a login waiting for the provider's callback
+type oidcLogin struct {
+	nonce     string
+	expiresAt time.Time
+}
+
+// OIDCService signs users in through an OpenID Connect provider with the authorization
+// code flow, issuing app tokens once the provider's ID token is verified
+type OIDCService struct {
+	providerURL  string
+	providerName string
+	clientID     string
+	clientSecret string
+	redirectURI  string
+	userRepo     *repository.UserRepository
+	authService  *AuthService
+	httpClient   *http.Client
+
+	// Discovery document and signing keys by key ID, fetched on first use
+	providerMu    sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+
+	// Pending logins by state
+	loginsMu sync.Mutex
+	logins   map[string]oidcLogin
+}
+
+// NewOIDCService creates an OIDC service for the provider whose issuer URL is
+// providerURL. The provider must redirect back to redirectURI, which is registered
+// with it for clientID.
+func NewOIDCService(providerURL, providerName, clientID, clientSecret, redirectURI string, userRepo *repository.UserRepository, authService *AuthService) *OIDCService {
+	return &OIDCService{
+		providerURL:  strings.TrimRight(providerURL, "/"),
+		providerName: providerName,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURI:  redirectURI,
+		userRepo:     userRepo,
+		authService:  authService,
+		httpClient:   &http.Client{Timeout: oidcHTTPTimeout},
+		logins:       make(map[string]oidcLogin),
+	}
+}
+
+// Providers lists the configured OIDC providers; empty when OIDC is off
+func (s *OIDCService) Providers() []models.OIDCProvider {
+	if s == nil {
+		return []models.OIDCProvider{}
+	}
+	return []models.OIDCProvider{{
+		ID:          OIDCProviderID,
+		Name:        s.providerName,
+		Issuer:      s.providerURL,
+		InitiateURL: "/api/auth/oidc/initiate",
+	}}
+}
+
+// InitiateLogin starts a login, returning the provider URL to send the user to. The
+// state and nonce are kept for OIDCStateTTL, until the callback completes the login.
+// reauth asks the provider to authenticate the user again rather than reuse its own
+// session, as account deletion requires of SSO users.
+func (s *OIDCService) InitiateLogin(ctx context.Context, reauth bool) (*models.OIDCInitiateResponse, error) {
+	if s == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+	discovery, err := s.provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := randomOIDCValue()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomOIDCValue()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveLogin(state, nonce); err != nil {
+		return nil, err
+	}
+
+	authURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OIDC authorization endpoint: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", s.clientID)
+	query.Set("redirect_uri", s.redirectURI)
+	query.Set("scope", "openid email profile")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	if reauth {
+		query.Set("prompt", "login")
+		query.Set("max_age", "0")
+	}
+	authURL.RawQuery = query.Encode()
+
+	return &models.OIDCInitiateResponse{AuthURL: authURL.String(), State: state}, nil
+}
+
+// CompleteLogin finishes a login started by InitiateLogin: it exchanges the code for an
+// ID token, verifies the token against the provider's keys, finds or creates the user
+// by the token's email and issues an app token. Each state can be used once.
+func (s *OIDCService) CompleteLogin(ctx context.Context, code, state string) (*models.OIDCLoginResponse, error) {
+	if s == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+	nonce, ok := s.takeLogin(state)
+	if !ok {
+		return nil, ErrOIDCInvalidState
+	}
+	discovery, err := s.provider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := s.exchangeCode(ctx, discovery, code)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.verifyIDToken(ctx, discovery, rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.syncUser(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// auth_time is required when max_age is sent; otherwise without it the login
+	// itself is the best known authentication time
+	authTime := time.Now()
+	if claims.AuthTime != nil {
+		authTime = claims.AuthTime.Time
+	}
+	token, err := s.authService.IssueToken(user, authTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue token: %w", err)
+	}
+	return &models.OIDCLoginResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresIn: int64(s.authService.GetJWTExpiry().Seconds()),
+		User:      user.ToResponse(),
+	}, nil
+}
+
+func (s *OIDCService) saveLogin(state, nonce string) error {
+	s.loginsMu.Lock()
+	defer s.loginsMu.Unlock()
+
+	now := time.Now()
+	for key, login := range s.logins {
+		if now.After(login.expiresAt) {
+			delete(s.logins, key)
+		}
+	}
+	if len(s.logins) >= oidcMaxPendingLogins {
+		return ErrOIDCTooManyLogins
+	}
+	s.logins[state] = oidcLogin{nonce: nonce, expiresAt: now.Add(OIDCStateTTL)}
+	return nil
+}
+
+// takeLogin removes and returns the nonce of a pending, unexpired login
+func (s *OIDCService) takeLogin(state string) (string, bool) {
+	s.loginsMu.Lock()
+	defer s.loginsMu.Unlock()
+
+	login, ok := s.logins[state]
+	if !ok {
+		return "", false
+	}
+	delete(s.logins, state)
+	if time.Now().After(login.expiresAt) {
+		return "", false
+	}
+	return login.nonce, true
+}
+
+// provider returns the provider's discovery document, fetching it on first use
+func (s *OIDCService) provider(ctx context.Context) (*oidcDiscovery, error) {
+	s.providerMu.Lock()
+	defer s.providerMu.Unlock()
+
+	if s.discovery != nil {
+		return s.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := s.getJSON(ctx, s.providerURL+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document: %w", err)
+	}
+	// The issuer must match the URL it was discovered from (OpenID Connect Discovery 4.3)
+	if strings.TrimRight(discovery.Issuer, "/") != s.providerURL {
+		return nil, fmt.Errorf("OIDC discovery issuer %q doesn't match OIDC_PROVIDER_URL %q", discovery.Issuer, s.providerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery document is missing an endpoint")
+	}
+	s.discovery = &discovery
+	return s.discovery, nil
+}
+
+// exchangeCode trades an authorization code for the raw ID token at the token endpoint
+func (s *OIDCService) exchangeCode(ctx context.Context, discovery *oidcDiscovery, code string) (string, error) {
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {s.redirectURI},
+	}
+	// client_secret_basic is the default; use client_secret_post only for providers
+	// that don't support it
+	usePost := len(discovery.TokenAuthMethods) > 0 &&
+		!slices.Contains(discovery.TokenAuthMethods, "client_secret_basic") &&
+		slices.Contains(discovery.TokenAuthMethods, "client_secret_post")
+	if usePost {
+		form.Set("client_id", s.clientID)
+		form.Set("client_secret", s.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !usePost {
+		// RFC 6749 2.3.1: credentials are form-encoded before basic auth
+		req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call OIDC token endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseBytes)).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to parse OIDC token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("%w: %s %s", ErrOIDCCodeRejected, body.Error, body.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("OIDC token endpoint returned status %d: %s", resp.StatusCode, body.Error)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: token response has no id_token", ErrOIDCInvalidToken)
+	}
+	return body.IDToken, nil
+}
+
+// verifyIDToken checks the ID token's signature against the provider's keys and its
+// issuer, audience, expiry and nonce
+func (s *OIDCService) verifyIDToken(ctx context.Context, discovery *oidcDiscovery, rawIDToken, nonce string) (*oidcIDTokenClaims, error) {
+	token, err := jwt.ParseWithClaims(rawIDToken, &oidcIDTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.signingKey(ctx, discovery.JWKSURI, kid)
+	},
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(s.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCInvalidToken, err)
+	}
+
+	claims, ok := token.Claims.(*oidcIDTokenClaims)
+	if !ok || !token.Valid {
+		return nil, ErrOIDCInvalidToken
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCInvalidToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrOIDCInvalidToken)
+	}
+	return claims, nil
+}
+
+// signingKey returns the provider key with the given ID, refetching the key set (at
+// most every oidcKeysRefetchInterval) when the provider has rotated to a key we don't
+// have. A token without a key ID is accepted only while the provider has a single key.
+func (s *OIDCService) signingKey(ctx context.Context, jwksURI, kid string) (interface{}, error) {
+	s.providerMu.Lock()
+	defer s.providerMu.Unlock()
+
+	lookup := func() interface{} {
+		if kid == "" && len(s.keys) == 1 {
+			for _, key := range s.keys {
+				return key
+			}
+		}
+		return s.keys[kid]
+	}
+
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	if s.keys != nil && time.Since(s.keysFetchedAt) < oidcKeysRefetchInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := s.fetchKeys(ctx, jwksURI)
+	if err != nil {
+		return nil, err
+	}
+	s.keys = keys
+	s.keysFetchedAt = time.Now()
+
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// fetchKeys fetches the provider's JWKS, keeping its RSA and EC signing keys by key ID
+func (s *OIDCService) fetchKeys(ctx context.Context, jwksURI string) (map[string]interface{}, error) {
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := s.getJSON(ctx, jwksURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		switch jwk.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+			if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+				continue
+			}
+			keys[jwk.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "EC":
+			var curve elliptic.Curve
+			switch jwk.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+			y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[jwk.Kid] = &ecdsa.PublicKey{
+				Curve: curve,
+				X:     new(big.Int).SetBytes(x),
+				Y:     new(big.Int).SetBytes(y),
+			}
+		}
+	}
+	return keys, nil
+}
+
+// syncUser finds or creates the user the ID token is for, linking an existing account
+// with the same email to the provider. The email must be explicitly verified: a
+// provider that leaves out email_verified could otherwise take over any account.
+func (s *OIDCService) syncUser(claims *oidcIDTokenClaims) (*models.User, error) {
+	email := strings.TrimSpace(claims.Email)
+	if email == "" || claims.EmailVerified == nil || !*claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	sub := claims.Subject
+	user := &models.User{
+		OIDCSub: &sub,
+		Email:   email,
+	}
+	if name := strings.TrimSpace(claims.Name); name != "" {
+		user.FullName = &name
+	}
+	if err := s.userRepo.CreateOrUpdateFromOIDC(user); err != nil {
+		return nil, fmt.Errorf("failed to sync OIDC user: %w", err)
+	}
+
+	synced, err := s.userRepo.GetByOIDCSub(sub)
+	if err != nil {
+		return nil, err
+	}
+	if synced == nil {
+		return nil, fmt.Errorf("OIDC user %s not found after sync", sub)
+	}
+	return synced, nil
+}
+
+func (s *OIDCService) getJSON(ctx context.Context, endpoint string, into interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcMaxResponseBytes)).Decode(into)
+}
+
+// randomOIDCValue returns 32 random bytes, base64url-encoded, for a state or nonce
+func randomOIDCValue() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate OIDC state: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
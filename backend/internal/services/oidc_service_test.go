@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+func TestOIDCSyncUserRequiresVerifiedEmail(t *testing.T) {
+	db := newTestDB(t)
+	existing := newTestUser(t, db, "owner@example.com")
+	userRepo := repository.NewUserRepository(db)
+	service := NewOIDCService("https://idp.example.com", "SSO", "client", "", "https://app.example.com/callback", userRepo, nil)
+
+	verified, unverified := oidcBool(true), oidcBool(false)
+	tests := []struct {
+		name          string
+		emailVerified *oidcBool
+		wantErr       error
+	}{
+		{"claim missing", nil, ErrOIDCEmailNotVerified},
+		{"claim false", &unverified, ErrOIDCEmailNotVerified},
+		{"claim true", &verified, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &oidcIDTokenClaims{
+				Email:            existing.Email,
+				EmailVerified:    tt.emailVerified,
+				RegisteredClaims: jwt.RegisteredClaims{Subject: "sub-" + tt.name},
+			}
+			user, err := service.syncUser(claims)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("syncUser error = %v, want %v", err, tt.wantErr)
+			}
+
+			linked, err := userRepo.GetByOIDCSub(claims.Subject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != nil {
+				if linked != nil {
+					t.Errorf("account was linked to %s despite the unverified email", claims.Subject)
+				}
+				return
+			}
+			if user.ID != existing.ID || linked == nil || linked.ID != existing.ID {
+				t.Errorf("verified login wasn't linked to the existing account")
+			}
+		})
+	}
+}
+
+// testOIDCProvider is an OIDC provider serving discovery, its published RSA keys and a
+// token endpoint that returns whatever ID token the test set last
+type testOIDCProvider struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey // published, by key ID
+	idToken string
+}
+
+func newTestOIDCProvider(t *testing.T) *testOIDCProvider {
+	t.Helper()
+	p := &testOIDCProvider{keys: map[string]*rsa.PrivateKey{"key-1": newTestRSAKey(t)}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		keys := []map[string]string{}
+		for kid, key := range p.keys {
+			keys = append(keys, map[string]string{
+				"kid": kid,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken, "token_type": "Bearer"})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func newTestRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// claims returns valid ID token claims for a login with the given nonce
+func (p *testOIDCProvider) claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            p.server.URL,
+		"aud":            "client",
+		"sub":            "sso-user",
+		"email":          "sso@example.com",
+		"email_verified": true,
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+}
+
+// sign signs claims with key under the key ID kid
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// login runs a login against service, with the provider returning the ID token
+// idToken makes for the login's nonce
+func (p *testOIDCProvider) login(t *testing.T, service *OIDCService, reauth bool, idToken func(nonce string) string) (*models.OIDCLoginResponse, error) {
+	t.Helper()
+	initiated, err := service.InitiateLogin(context.Background(), reauth)
+	if err != nil {
+		t.Fatalf("InitiateLogin: %v", err)
+	}
+	authURL, err := url.Parse(initiated.AuthURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.mu.Lock()
+	p.idToken = idToken(authURL.Query().Get("nonce"))
+	p.mu.Unlock()
+	return service.CompleteLogin(context.Background(), "code", initiated.State)
+}
+
+func newTestOIDCService(t *testing.T, p *testOIDCProvider, db *sql.DB) *OIDCService {
+	userRepo := repository.NewUserRepository(db)
+	return NewOIDCService(p.server.URL, "SSO", "client", "secret", "https://app.example.com/callback", userRepo, NewAuthService(userRepo, "app-secret", time.Hour))
+}
+
+func TestOIDCVerifiesIDTokens(t *testing.T) {
+	p := newTestOIDCProvider(t)
+	db := newTestDB(t)
+	publishedKey := p.keys["key-1"]
+	otherKey := newTestRSAKey(t)
+
+	tests := []struct {
+		name    string
+		idToken func(nonce string) string
+		wantErr error
+	}{
+		{"valid", func(nonce string) string {
+			return sign(t, jwt.SigningMethodRS256, "key-1", publishedKey, p.claims(nonce))
+		}, nil},
+		{"unknown key ID", func(nonce string) string {
+			return sign(t, jwt.SigningMethodRS256, "key-2", otherKey, p.claims(nonce))
+		}, ErrOIDCInvalidToken},
+		{"published key ID, other key", func(nonce string) string {
+			return sign(t, jwt.SigningMethodRS256, "key-1", otherKey, p.claims(nonce))
+		}, ErrOIDCInvalidToken},
+		{"alg none", func(nonce string) string {
+			return sign(t, jwt.SigningMethodNone, "key-1", jwt.UnsafeAllowNoneSignatureType, p.claims(nonce))
+		}, ErrOIDCInvalidToken},
+		{"HS256 with the public key as secret", func(nonce string) string {
+			return sign(t, jwt.SigningMethodHS256, "key-1", publishedKey.N.Bytes(), p.claims(nonce))
+		}, ErrOIDCInvalidToken},
+		{"wrong audience", func(nonce string) string {
+			claims := p.claims(nonce)
+			claims["aud"] = "another-client"
+			return sign(t, jwt.SigningMethodRS256, "key-1", publishedKey, claims)
+		}, ErrOIDCInvalidToken},
+		{"wrong issuer", func(nonce string) string {
+			claims := p.claims(nonce)
+			claims["iss"] = "https://evil.example.com"
+			return sign(t, jwt.SigningMethodRS256, "key-1", publishedKey, claims)
+		}, ErrOIDCInvalidToken},
+		{"expired", func(nonce string) string {
+			claims := p.claims(nonce)
+			claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			return sign(t, jwt.SigningMethodRS256, "key-1", publishedKey, claims)
+		}, ErrOIDCInvalidToken},
+		{"no expiry", func(nonce string) string {
+			claims := p.claims(nonce)
+			delete(claims, "exp")
+			return sign(t, jwt.SigningMethodRS256, "key-1", publishedKey, claims)
+		}, ErrOIDCInvalidToken},
+		{"nonce mismatch", func(nonce string) string {
+			return sign(t, jwt.SigningMethodRS256, "key-1", publishedKey, p.claims(nonce+"x"))
+		}, ErrOIDCInvalidToken},
+		{"no subject", func(nonce string) string {
+			claims := p.claims(nonce)
+			delete(claims, "sub")
+			return sign(t, jwt.SigningMethodRS256, "key-1", publishedKey, claims)
+		}, ErrOIDCInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := p.login(t, newTestOIDCService(t, p, db), false, tt.idToken)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompleteLogin = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (resp.Token == "" || resp.User.Email != "sso@example.com") {
+				t.Errorf("CompleteLogin = %+v, want a token for sso@example.com", resp)
+			}
+		})
+	}
+}
+
+func TestOIDCKeyRotation(t *testing.T) {
+	p := newTestOIDCProvider(t)
+	service := newTestOIDCService(t, p, newTestDB(t))
+	oldKey, newKey := p.keys["key-1"], newTestRSAKey(t)
+
+	signedBy := func(kid string, key *rsa.PrivateKey) func(string) string {
+		return func(nonce string) string { return sign(t, jwt.SigningMethodRS256, kid, key, p.claims(nonce)) }
+	}
+	if _, err := p.login(t, service, false, signedBy("key-1", oldKey)); err != nil {
+		t.Fatalf("login before rotation: %v", err)
+	}
+
+	p.mu.Lock()
+	p.keys = map[string]*rsa.PrivateKey{"key-2": newKey}
+	p.mu.Unlock()
+
+	// Keys are refetched for an unknown key ID at most every oidcKeysRefetchInterval
+	if _, err := p.login(t, service, false, signedBy("key-2", newKey)); !errors.Is(err, ErrOIDCInvalidToken) {
+		t.Errorf("login right after rotation = %v, want ErrOIDCInvalidToken until the keys may be refetched", err)
+	}
+	service.providerMu.Lock()
+	service.keysFetchedAt = time.Now().Add(-oidcKeysRefetchInterval)
+	service.providerMu.Unlock()
+
+	if _, err := p.login(t, service, false, signedBy("key-2", newKey)); err != nil {
+		t.Errorf("login with the new key: %v", err)
+	}
+	if _, err := p.login(t, service, false, signedBy("key-1", oldKey)); !errors.Is(err, ErrOIDCInvalidToken) {
+		t.Errorf("login with the retired key = %v, want ErrOIDCInvalidToken", err)
+	}
+}
+
+func TestOIDCReauthenticationForAccountDeletion(t *testing.T) {
+	p := newTestOIDCProvider(t)
+	key := p.keys["key-1"]
+
+	tests := []struct {
+		name     string
+		authTime time.Time // auth_time claim of the ID token; zero leaves it out
+		wantErr  error
+	}{
+		{name: "fresh sign-in", authTime: time.Now().Add(-time.Minute)},
+		{name: "no auth_time claim"},
+		{name: "provider session too old", authTime: time.Now().Add(-time.Hour), wantErr: ErrReauthenticationRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			service := newTestOIDCService(t, p, db)
+
+			initiated, err := service.InitiateLogin(context.Background(), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(initiated.AuthURL, "prompt=login") || !strings.Contains(initiated.AuthURL, "max_age=0") {
+				t.Errorf("reauth URL %s doesn't make the provider authenticate again", initiated.AuthURL)
+			}
+
+			resp, err := p.login(t, service, true, func(nonce string) string {
+				claims := p.claims(nonce)
+				if !tt.authTime.IsZero() {
+					claims["auth_time"] = tt.authTime.Unix()
+				}
+				return sign(t, jwt.SigningMethodRS256, "key-1", key, claims)
+			})
+			if err != nil {
+				t.Fatalf("CompleteLogin: %v", err)
+			}
+			claims, err := service.authService.ValidateToken(resp.Token)
+			if err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+			if claims.AuthTime == nil {
+				t.Fatal("app token has no auth_time")
+			}
+
+			userRepo := repository.NewUserRepository(db)
+			userData := NewUserDataService(userRepo, repository.NewMemoryRepository(db), repository.NewTodoRepository(db), repository.NewGroupRepository(db), nil, nil, nil, nil, &SupabaseAuthService{}, nil, nil)
+			err = userData.DeleteAccount(resp.User.ID, "", claims.AuthTime.Time)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteAccount = %v, want %v", err, tt.wantErr)
+			}
+
+			user, err := userRepo.GetByID(resp.User.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (user == nil) != (tt.wantErr == nil) {
+				t.Errorf("user after DeleteAccount = %+v, deleted should be %v", user, tt.wantErr == nil)
+			}
+		})
+	}
+
+	t.Run("password users still need their password", func(t *testing.T) {
+		db := newTestDB(t)
+		user := newTestUser(t, db, "password@example.com")
+		userRepo := repository.NewUserRepository(db)
+		userData := NewUserDataService(userRepo, repository.NewMemoryRepository(db), repository.NewTodoRepository(db), repository.NewGroupRepository(db), nil, nil, nil, nil, &SupabaseAuthService{}, nil, nil)
+		if err := userData.DeleteAccount(user.ID, "", time.Now()); !errors.Is(err, ErrInvalidPassword) {
+			t.Errorf("DeleteAccount without a password = %v, want ErrInvalidPassword", err)
+		}
+	})
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// AssistantRunTimeout bounds how long we wait for an assistant run to finish
+	AssistantRunTimeout = 30 * time.Second
+	// assistantPollInterval is the delay between run status checks
+	assistantPollInterval = 500 * time.Millisecond
+	// AssistantThreadIDKey is the provider metadata / ExtraParams key for the persistent thread
+	AssistantThreadIDKey = "thread_id"
+)
+
+// errAssistantThreadNotFound means a stored thread was deleted on OpenAI's side
+var errAssistantThreadNotFound = errors.New("assistant thread not found")
+
+// assistantThreadLocks serializes runs per thread - OpenAI rejects a new run
+// while another is active on the same thread
+var assistantThreadLocks sync.Map
+
+type assistantRun struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	LastError *struct {
+		Message string `json:"message"`
+	} `json:"last_error"`
+}
+
+type assistantMessageList struct {
+	Data []struct {
+		Role    string `json:"role"`
+		Content []struct {
+			Type string `json:"type"`
+			Text struct {
+				Value string `json:"value"`
+			} `json:"text"`
+		} `json:"content"`
+	} `json:"data"`
+}
+
+// callAssistant sends a prompt through the assistant configured in config,
+// reusing the persistent thread from ExtraParams when there is one
+func callAssistant(config *AIProviderConfig, prompt string) (string, error) {
+	return callOpenAIAssistant(config, "", prompt)
+}
+
+// callOpenAIAssistant runs an OpenAI Assistant (config.Model is the assistant ID) on a
+// thread and returns the text of its reply. threadID, then config.ExtraParams["thread_id"],
+// selects an existing thread; otherwise a new one is created and reported through
+// config.OnThreadCreated so it can be persisted.
+func callOpenAIAssistant(config *AIProviderConfig, threadID, prompt string) (string, error) {
+	if err := waitForRateLimit(config); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(config.requestContext(), AssistantRunTimeout)
+	defer cancel()
+
+	if threadID == "" {
+		threadID = config.ExtraParams[AssistantThreadIDKey]
+	}
+
+	threadID, unlock, err := prepareAssistantThread(ctx, config, threadID, prompt)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	var run assistantRun
+	if err := assistantRequest(ctx, config, "POST", "/threads/"+threadID+"/runs", map[string]string{
+		"assistant_id": config.Model,
+	}, &run); err != nil {
+		return "", fmt.Errorf("failed to start assistant run: %w", err)
+	}
+
+	// Poll until the run reaches a terminal state
+	for run.Status == "queued" || run.Status == "in_progress" || run.Status == "cancelling" {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("assistant run %s did not complete: %w", run.ID, ctx.Err())
+		case <-time.After(assistantPollInterval):
+		}
+
+		if err := assistantRequest(ctx, config, "GET", "/threads/"+threadID+"/runs/"+run.ID, nil, &run); err != nil {
+			return "", fmt.Errorf("failed to check assistant run: %w", err)
+		}
+	}
+
+	if run.Status != "completed" {
+		if run.LastError != nil && run.LastError.Message != "" {
+			return "", fmt.Errorf("assistant run %s: %s", run.Status, run.LastError.Message)
+		}
+		return "", fmt.Errorf("assistant run ended with status %s", run.Status)
+	}
+
+	var messages assistantMessageList
+	path := "/threads/" + threadID + "/messages?order=desc&limit=1&run_id=" + run.ID
+	if err := assistantRequest(ctx, config, "GET", path, nil, &messages); err != nil {
+		return "", fmt.Errorf("failed to fetch assistant reply: %w", err)
+	}
+
+	for _, msg := range messages.Data {
+		if msg.Role != "assistant" {
+			continue
+		}
+		var sb strings.Builder
+		for _, part := range msg.Content {
+			if part.Type == "text" {
+				sb.WriteString(part.Text.Value)
+			}
+		}
+		if content := strings.TrimSpace(sb.String()); content != "" {
+			return content, nil
+		}
+	}
+
+	return "", fmt.Errorf("no content in assistant response")
+}
+
+// prepareAssistantThread adds the prompt to the thread (creating one if needed, or if the
+// stored one was deleted) and returns it locked for the run
+func prepareAssistantThread(ctx context.Context, config *AIProviderConfig, threadID, prompt string) (string, func(), error) {
+	if threadID != "" {
+		unlock := lockAssistantThread(threadID)
+		err := addAssistantMessage(ctx, config, threadID, prompt)
+		if err == nil {
+			return threadID, unlock, nil
+		}
+		unlock()
+		if !errors.Is(err, errAssistantThreadNotFound) {
+			return "", nil, err
+		}
+		log.Printf("[AI-Assistant] Thread %s no longer exists, starting a new one", threadID)
+	}
+
+	threadID, err := createAssistantThread(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+	if config.OnThreadCreated != nil {
+		config.OnThreadCreated(threadID)
+	}
+
+	unlock := lockAssistantThread(threadID)
+	if err := addAssistantMessage(ctx, config, threadID, prompt); err != nil {
+		unlock()
+		return "", nil, err
+	}
+	return threadID, unlock, nil
+}
+
+func lockAssistantThread(threadID string) func() {
+	lock, _ := assistantThreadLocks.LoadOrStore(threadID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	return lock.(*sync.Mutex).Unlock
+}
+
+func createAssistantThread(ctx context.Context, config *AIProviderConfig) (string, error) {
+	var thread struct {
+		ID string `json:"id"`
+	}
+	if err := assistantRequest(ctx, config, "POST", "/threads", map[string]interface{}{}, &thread); err != nil {
+		return "", fmt.Errorf("failed to create assistant thread: %w", err)
+	}
+	if thread.ID == "" {
+		return "", fmt.Errorf("failed to create assistant thread: empty thread ID")
+	}
+	return thread.ID, nil
+}
+
+func addAssistantMessage(ctx context.Context, config *AIProviderConfig, threadID, prompt string) error {
+	err := assistantRequest(ctx, config, "POST", "/threads/"+threadID+"/messages", map[string]string{
+		"role":    "user",
+		"content": prompt,
+	}, nil)
+	if err != nil && !errors.Is(err, errAssistantThreadNotFound) {
+		return fmt.Errorf("failed to add assistant message: %w", err)
+	}
+	return err
+}
+
+// assistantRequest performs an Assistants API call, decoding the JSON response into out when non-nil
+func assistantRequest(ctx context.Context, config *AIProviderConfig, method, path string, body interface{}, out interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewBuffer(jsonBody)
+	}
+
+	url := strings.TrimSuffix(config.BaseURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+config.APIKey)
+	req.Header.Set("OpenAI-Beta", "assistants=v2")
+
+	client := &http.Client{Timeout: AssistantRunTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound && strings.HasPrefix(path, "/threads/") {
+		return errAssistantThreadNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("AI API error: %s - %s", resp.Status, string(respBody))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
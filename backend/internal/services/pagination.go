@@ -0,0 +1,46 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// EncodePageCursor returns the token for the page after the item created at createdAt
+// with the given ID
+func EncodePageCursor(createdAt time.Time, id string) string {
+	data, _ := json.Marshal(models.PageCursor{CreatedAt: createdAt.UTC(), ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePageCursor parses a cursor token; an empty token is the first page (nil)
+func DecodePageCursor(token string) (*models.PageCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor models.PageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" || cursor.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// clampPageLimit applies the default and maximum page size
+func clampPageLimit(limit int) int {
+	if limit <= 0 {
+		return models.DefaultPageLimit
+	}
+	if limit > models.MaxPageLimit {
+		return models.MaxPageLimit
+	}
+	return limit
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// maxPDFImagePixels bounds the size of a page image decoded for OCR
+	maxPDFImagePixels = 40_000_000
+	// pdfOCRJPEGQuality is the quality page images are re-encoded at for OCR
+	pdfOCRJPEGQuality = 85
+)
+
+var (
+	pdfImageSubtypeRegex = regexp.MustCompile(`/Subtype\s*/Image\b`)
+	pdfWidthRegex        = regexp.MustCompile(`/Width\s+(\d+)\b`)
+	pdfHeightRegex       = regexp.MustCompile(`/Height\s+(\d+)\b`)
+)
+
+// pdfPageImageJPEG returns the page as a JPEG for OCR. A scanned page is one large
+// image, so the largest image drawn on the page is used; nil means it has none that
+// can be decoded. raw is the whole PDF file, since image streams are read from it
+// directly: ledongthuc/pdf only decodes the filters text streams use.
+func pdfPageImageJPEG(raw []byte, page pdf.Page) (jpegData []byte, err error) {
+	// The PDF reader panics on malformed objects
+	defer func() {
+		if r := recover(); r != nil {
+			jpegData, err = nil, fmt.Errorf("malformed page: %v", r)
+		}
+	}()
+
+	img, ok := largestPDFImage(page.Resources(), 0)
+	if !ok {
+		return nil, nil
+	}
+	data := findPDFImageStream(raw, img.Key("Length").Int64(), img.Key("Width").Int64(), img.Key("Height").Int64())
+	if data == nil {
+		return nil, fmt.Errorf("image stream not found")
+	}
+	return decodePDFImage(img, data)
+}
+
+// largestPDFImage finds the largest image XObject in resources, looking one level into
+// form XObjects, which some scanners wrap page images in
+func largestPDFImage(resources pdf.Value, depth int) (pdf.Value, bool) {
+	var best pdf.Value
+	var bestArea int64
+	xobjects := resources.Key("XObject")
+	for _, name := range xobjects.Keys() {
+		xobject := xobjects.Key(name)
+		switch xobject.Key("Subtype").Name() {
+		case "Image":
+			if area := xobject.Key("Width").Int64() * xobject.Key("Height").Int64(); area > bestArea {
+				best, bestArea = xobject, area
+			}
+		case "Form":
+			if depth > 0 {
+				continue
+			}
+			if img, ok := largestPDFImage(xobject.Key("Resources"), depth+1); ok {
+				if area := img.Key("Width").Int64() * img.Key("Height").Int64(); area > bestArea {
+					best, bestArea = img, area
+				}
+			}
+		}
+	}
+	return best, bestArea > 0
+}
+
+// findPDFImageStream returns the undecoded data of the image stream with the given
+// length and dimensions, or nil if raw has none
+func findPDFImageStream(raw []byte, length, width, height int64) []byte {
+	if length <= 0 {
+		return nil
+	}
+	for _, loc := range pdfImageSubtypeRegex.FindAllIndex(raw, -1) {
+		// The image's dictionary runs from its "obj" keyword to its "stream" keyword
+		objStart := bytes.LastIndex(raw[:loc[0]], []byte("obj"))
+		if objStart < 0 || bytes.HasSuffix(raw[:objStart], []byte("end")) {
+			continue
+		}
+		streamOffset := bytes.Index(raw[loc[1]:], []byte("stream"))
+		if streamOffset < 0 {
+			continue
+		}
+		streamStart := loc[1] + streamOffset
+		dict := raw[objStart:streamStart]
+		if !pdfDictHasInt(dict, pdfWidthRegex, width) || !pdfDictHasInt(dict, pdfHeightRegex, height) {
+			continue
+		}
+
+		// The data starts after the end of the "stream" line
+		dataStart := streamStart + len("stream")
+		if bytes.HasPrefix(raw[dataStart:], []byte("\r\n")) {
+			dataStart += 2
+		} else if bytes.HasPrefix(raw[dataStart:], []byte("\n")) {
+			dataStart++
+		}
+		dataEnd := dataStart + int(length)
+		if dataEnd > len(raw) || !bytes.HasPrefix(bytes.TrimLeft(raw[dataEnd:], "\r\n \t"), []byte("endstream")) {
+			continue
+		}
+		return raw[dataStart:dataEnd]
+	}
+	return nil
+}
+
+func pdfDictHasInt(dict []byte, re *regexp.Regexp, want int64) bool {
+	match := re.FindSubmatch(dict)
+	if match == nil {
+		return false
+	}
+	got, err := strconv.ParseInt(string(match[1]), 10, 64)
+	return err == nil && got == want
+}
+
+// decodePDFImage returns the image stream data as a JPEG. JPEG (DCTDecode) images are
+// returned as they are; raw and Flate-compressed 1- or 8-bit grayscale, RGB and CMYK
+// images are decoded and re-encoded.
+func decodePDFImage(img pdf.Value, data []byte) ([]byte, error) {
+	var filters []string
+	var params pdf.Value
+	switch filter := img.Key("Filter"); filter.Kind() {
+	case pdf.Name:
+		filters = []string{filter.Name()}
+		params = img.Key("DecodeParms")
+	case pdf.Array:
+		for i := 0; i < filter.Len(); i++ {
+			filters = append(filters, filter.Index(i).Name())
+		}
+		if filter.Len() > 0 {
+			params = img.Key("DecodeParms").Index(filter.Len() - 1)
+		}
+	}
+
+	switch {
+	case len(filters) == 1 && filters[0] == "DCTDecode":
+		return data, nil
+	case len(filters) == 0:
+	case len(filters) == 1 && filters[0] == "FlateDecode":
+	default:
+		return nil, fmt.Errorf("unsupported image filter %v", filters)
+	}
+
+	width := int(img.Key("Width").Int64())
+	height := int(img.Key("Height").Int64())
+	bits := int(img.Key("BitsPerComponent").Int64())
+	components := pdfColorComponents(img.Key("ColorSpace"))
+	if width <= 0 || height <= 0 || width*height > maxPDFImagePixels {
+		return nil, fmt.Errorf("unsupported image size %dx%d", width, height)
+	}
+	if components == 0 || (bits != 8 && !(bits == 1 && components == 1)) {
+		return nil, fmt.Errorf("unsupported image format: %d components at %d bits", components, bits)
+	}
+
+	rowBytes := (width*components*bits + 7) / 8
+	pixels := data
+	if len(filters) == 1 {
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress image: %w", err)
+		}
+		defer zr.Close()
+
+		// PNG predictors add a filter byte to every row
+		predicted := params.Key("Predictor").Int64() >= 10
+		size := rowBytes * height
+		if predicted {
+			size += height
+		}
+		pixels = make([]byte, size)
+		if _, err := io.ReadFull(zr, pixels); err != nil {
+			return nil, fmt.Errorf("failed to decompress image: %w", err)
+		}
+		if predicted {
+			if pixels, err = unfilterPNGRows(pixels, rowBytes, (components*bits+7)/8, height); err != nil {
+				return nil, err
+			}
+		}
+	} else if len(pixels) < rowBytes*height {
+		return nil, fmt.Errorf("image data is truncated")
+	}
+
+	var decoded image.Image
+	switch {
+	case bits == 1:
+		gray := image.NewGray(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			row := pixels[y*rowBytes:]
+			for x := 0; x < width; x++ {
+				if row[x/8]&(0x80>>(x%8)) != 0 {
+					gray.Pix[y*gray.Stride+x] = 0xff
+				}
+			}
+		}
+		decoded = gray
+	case components == 1:
+		decoded = &image.Gray{Pix: pixels[:rowBytes*height], Stride: rowBytes, Rect: image.Rect(0, 0, width, height)}
+	case components == 3:
+		rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+		for i, j := 0, 0; i < width*height; i, j = i+1, j+3 {
+			rgba.Pix[4*i], rgba.Pix[4*i+1], rgba.Pix[4*i+2], rgba.Pix[4*i+3] = pixels[j], pixels[j+1], pixels[j+2], 0xff
+		}
+		decoded = rgba
+	default:
+		decoded = &image.CMYK{Pix: pixels[:rowBytes*height], Stride: rowBytes, Rect: image.Rect(0, 0, width, height)}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, decoded, &jpeg.Options{Quality: pdfOCRJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode page image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfColorComponents returns the number of color components of a device or ICC color
+// space, or 0 for color spaces that aren't supported
+func pdfColorComponents(colorSpace pdf.Value) int {
+	name := colorSpace.Name()
+	if colorSpace.Kind() == pdf.Array && colorSpace.Len() > 0 {
+		name = colorSpace.Index(0).Name()
+	}
+	switch name {
+	case "DeviceGray", "CalGray":
+		return 1
+	case "DeviceRGB", "CalRGB":
+		return 3
+	case "DeviceCMYK":
+		return 4
+	case "ICCBased":
+		if n := colorSpace.Index(1).Key("N").Int64(); n == 1 || n == 3 || n == 4 {
+			return int(n)
+		}
+	}
+	return 0
+}
+
+// unfilterPNGRows reverses the PNG row filters applied by a PNG predictor, returning
+// the rows without their filter bytes
+func unfilterPNGRows(data []byte, rowBytes, bytesPerPixel, height int) ([]byte, error) {
+	out := make([]byte, rowBytes*height)
+	prev := make([]byte, rowBytes)
+	for y := 0; y < height; y++ {
+		filter := data[y*(rowBytes+1)]
+		in := data[y*(rowBytes+1)+1 : (y+1)*(rowBytes+1)]
+		row := out[y*rowBytes : (y+1)*rowBytes]
+		for i := range row {
+			var left, upLeft byte
+			if i >= bytesPerPixel {
+				left, upLeft = row[i-bytesPerPixel], prev[i-bytesPerPixel]
+			}
+			up := prev[i]
+			switch filter {
+			case 0:
+				row[i] = in[i]
+			case 1:
+				row[i] = in[i] + left
+			case 2:
+				row[i] = in[i] + up
+			case 3:
+				row[i] = in[i] + byte((int(left)+int(up))/2)
+			case 4:
+				row[i] = in[i] + paeth(left, up, upLeft)
+			default:
+				return nil, fmt.Errorf("invalid PNG row filter %d", filter)
+			}
+		}
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// PersonalCategoryModel learns from the categories users give their memories by hand.
+// It isn't machine learning: corrections are counted by a hash of the start of the
+// memory's content, and once a user has corrected memories starting the same way to
+// one category often enough, new ones get that category without asking the AI.
+type PersonalCategoryModel struct {
+	repo *repository.CategoryCorrectionRepository
+}
+
+func NewPersonalCategoryModel(repo *repository.CategoryCorrectionRepository) *PersonalCategoryModel {
+	return &PersonalCategoryModel{repo: repo}
+}
+
+// CategoryContentHash hashes the first models.CategorySnippetLength characters of
+// content, lowercased and with runs of whitespace collapsed, so memories differing only
+// in case or spacing hash the same. The full SHA-256 is kept, so memories starting
+// differently practically never collide.
+func CategoryContentHash(content string) string {
+	snippet := []rune(strings.ToLower(strings.Join(strings.Fields(content), " ")))
+	if len(snippet) > models.CategorySnippetLength {
+		snippet = snippet[:models.CategorySnippetLength]
+	}
+	sum := sha256.Sum256([]byte(string(snippet)))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordCorrection stores that the user changed a memory with content from the
+// original category to the corrected one
+func (m *PersonalCategoryModel) RecordCorrection(userID, content, original, corrected string) error {
+	return m.repo.Create(&models.CategoryCorrection{
+		UserID:            userID,
+		ContentHash:       CategoryContentHash(content),
+		OriginalCategory:  original,
+		CorrectedCategory: corrected,
+	})
+}
+
+// GetCategoryFromHistory returns the category the user has corrected memories starting
+// like contentSnippet to at least models.MinCategoryCorrections times, or nil if there
+// is no such category. A nil model never has one.
+func (m *PersonalCategoryModel) GetCategoryFromHistory(userID, contentSnippet string) *string {
+	if m == nil || userID == "" || strings.TrimSpace(contentSnippet) == "" {
+		return nil
+	}
+	category, count, err := m.repo.GetTopCategory(userID, CategoryContentHash(contentSnippet))
+	if err != nil {
+		log.Printf("[PersonalCategoryModel] Failed to look up category corrections for user %s: %v", userID, err)
+		return nil
+	}
+	if count < models.MinCategoryCorrections {
+		return nil
+	}
+	return &category
+}
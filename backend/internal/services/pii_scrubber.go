@@ -0,0 +1,156 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// piiPattern finds one kind of personal data; valid, if set, rejects look-alike matches
+type piiPattern struct {
+	label string
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+// PIIScrubber replaces personal data in text sent to AI providers with placeholders
+// such as [EMAIL_1], so the provider never sees it, and puts the real values back in
+// the response afterwards. A nil *PIIScrubber leaves text unchanged.
+type PIIScrubber struct {
+	patterns []piiPattern
+}
+
+// NewPIIScrubber creates a scrubber for email addresses, credit card numbers, US
+// social security numbers, IP addresses and phone numbers
+func NewPIIScrubber() *PIIScrubber {
+	return &PIIScrubber{
+		// Order matters: earlier patterns claim their text first, so a card number
+		// isn't taken for a phone number
+		patterns: []piiPattern{
+			{
+				label: "EMAIL",
+				re:    regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`),
+			},
+			{
+				label: "CARD",
+				re:    regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+				valid: isCardNumber,
+			},
+			{
+				label: "SSN",
+				re:    regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+				valid: isSSN,
+			},
+			{
+				label: "IP",
+				re:    regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|\b[0-9A-Fa-f]{1,4}(?::[0-9A-Fa-f]{0,4}){2,7}\b`),
+				valid: isIPAddress,
+			},
+			{
+				label: "PHONE",
+				re: regexp.MustCompile(`\+\d{1,3}[ .-]?(?:\(\d{1,4}\)|\d{1,4})(?:[ .-]?\d{2,4}){2,4}\b` +
+					`|\(\d{3}\) ?\d{3}[ .-]\d{4}\b` +
+					`|\b\d{3}[.-]\d{3}[.-]\d{4}\b`),
+			},
+		},
+	}
+}
+
+// Scrub replaces the personal data in text with numbered placeholders, returning the
+// scrubbed text and the original value of each placeholder. The same value always
+// gets the same placeholder.
+func (s *PIIScrubber) Scrub(text string) (scrubbed string, replacements map[string]string) {
+	if s == nil {
+		return text, nil
+	}
+
+	replacements = make(map[string]string)
+	placeholders := make(map[string]string) // value -> placeholder
+	counts := make(map[string]int)          // label -> placeholders so far
+	scrubbed = text
+	for _, pattern := range s.patterns {
+		scrubbed = pattern.re.ReplaceAllStringFunc(scrubbed, func(match string) string {
+			if pattern.valid != nil && !pattern.valid(match) {
+				return match
+			}
+			if placeholder, ok := placeholders[match]; ok {
+				return placeholder
+			}
+			counts[pattern.label]++
+			placeholder := fmt.Sprintf("[%s_%d]", pattern.label, counts[pattern.label])
+			placeholders[match] = placeholder
+			replacements[placeholder] = match
+			return placeholder
+		})
+	}
+	return scrubbed, replacements
+}
+
+// Restore puts the original values back in place of the placeholders in scrubbed
+func (s *PIIScrubber) Restore(scrubbed string, replacements map[string]string) string {
+	if len(replacements) == 0 {
+		return scrubbed
+	}
+
+	// Sorted so the result doesn't depend on map order
+	placeholders := make([]string, 0, len(replacements))
+	for placeholder := range replacements {
+		placeholders = append(placeholders, placeholder)
+	}
+	sort.Strings(placeholders)
+
+	pairs := make([]string, 0, 2*len(placeholders))
+	for _, placeholder := range placeholders {
+		pairs = append(pairs, placeholder, replacements[placeholder])
+	}
+	return strings.NewReplacer(pairs...).Replace(scrubbed)
+}
+
+// keepsPlaceholders reports whether text still has every placeholder, i.e. the AI
+// didn't drop or rewrite any of the scrubbed values
+func keepsPlaceholders(text string, replacements map[string]string) bool {
+	for placeholder := range replacements {
+		if !strings.Contains(text, placeholder) {
+			return false
+		}
+	}
+	return true
+}
+
+// isCardNumber reports whether match is 13-19 digits passing the Luhn check
+func isCardNumber(match string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(match)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// isSSN rejects numbers that are never issued as social security numbers: area 000,
+// 666 or 900-999, group 00 or serial 0000
+func isSSN(match string) bool {
+	area, group, serial := match[0:3], match[4:6], match[7:11]
+	return area != "000" && area != "666" && area[0] != '9' && group != "00" && serial != "0000"
+}
+
+// isIPAddress reports whether match is an IPv4 or IPv6 address. IPv6 matches must have
+// a digit, so hex words such as "add::" aren't taken for addresses.
+func isIPAddress(match string) bool {
+	if net.ParseIP(match) == nil {
+		return false
+	}
+	return strings.Contains(match, ".") || strings.ContainsAny(match, "0123456789")
+}
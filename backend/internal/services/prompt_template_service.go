@@ -0,0 +1,288 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// MaxRenderedPromptLength is the maximum length of a rendered prompt template
+const MaxRenderedPromptLength = 4000
+
+var (
+	ErrUnknownPromptTemplate  = errors.New("unknown template name")
+	ErrPromptTemplateNotFound = newCodedError(models.ErrCodeNotFound, "template not found")
+	ErrInvalidPromptTemplate  = errors.New("invalid template")
+	ErrPromptTooLong          = fmt.Errorf("rendered prompt exceeds %d characters", MaxRenderedPromptLength)
+)
+
+// validPromptTemplateNames lists the prompts users may override
+var validPromptTemplateNames = map[string]bool{
+	models.PromptTemplateTodoProcessing:       true,
+	models.PromptTemplateMemoryCategorization: true,
+	models.PromptTemplateWeeklyDigest:         true,
+}
+
+// promptTemplateSampleVars are used to validate templates before saving
+var promptTemplateSampleVars = map[string]string{
+	"Title":    "Sample title",
+	"Content":  "Sample content",
+	"Memories": "Sample memories",
+}
+
+// disallowedPromptTemplateFuncs can produce output out of proportion to the
+// template, so they're rejected along with loops and nested templates
+var disallowedPromptTemplateFuncs = map[string]bool{
+	"call":    true,
+	"print":   true,
+	"printf":  true,
+	"println": true,
+}
+
+type PromptTemplateService struct {
+	repo *repository.PromptTemplateRepository
+}
+
+func NewPromptTemplateService(repo *repository.PromptTemplateRepository) *PromptTemplateService {
+	return &PromptTemplateService{repo: repo}
+}
+
+func (s *PromptTemplateService) Create(userID string, req *models.PromptTemplateCreateRequest) (*models.PromptTemplate, error) {
+	if !validPromptTemplateNames[req.TemplateName] {
+		return nil, ErrUnknownPromptTemplate
+	}
+	if _, err := s.Render(req.TemplateBody, promptTemplateSampleVars); err != nil {
+		return nil, err
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	// Only one template per name can be active at a time
+	if isActive {
+		if err := s.repo.DeactivateByName(userID, req.TemplateName); err != nil {
+			return nil, err
+		}
+	}
+
+	pt := &models.PromptTemplate{
+		UserID:       userID,
+		TemplateName: req.TemplateName,
+		TemplateBody: req.TemplateBody,
+		IsActive:     isActive,
+	}
+
+	if err := s.repo.Create(pt); err != nil {
+		return nil, err
+	}
+
+	return pt, nil
+}
+
+func (s *PromptTemplateService) GetAll(userID string) ([]models.PromptTemplate, error) {
+	return s.repo.GetAllByUserID(userID)
+}
+
+func (s *PromptTemplateService) GetByID(userID, templateID string) (*models.PromptTemplate, error) {
+	pt, err := s.repo.GetByID(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if pt == nil || pt.UserID != userID {
+		return nil, nil
+	}
+	return pt, nil
+}
+
+// GetActive returns the user's active template for a name, or nil if none is set
+func (s *PromptTemplateService) GetActive(userID, name string) (*models.PromptTemplate, error) {
+	return s.repo.GetActiveByName(userID, name)
+}
+
+func (s *PromptTemplateService) Update(userID, templateID string, req *models.PromptTemplateUpdateRequest) (*models.PromptTemplate, error) {
+	pt, err := s.repo.GetByID(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if pt == nil || pt.UserID != userID {
+		return nil, ErrPromptTemplateNotFound
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.TemplateBody != nil {
+		if _, err := s.Render(*req.TemplateBody, promptTemplateSampleVars); err != nil {
+			return nil, err
+		}
+		updates["template_body"] = *req.TemplateBody
+	}
+	if req.IsActive != nil {
+		if *req.IsActive {
+			if err := s.repo.DeactivateByName(userID, pt.TemplateName); err != nil {
+				return nil, err
+			}
+			updates["is_active"] = 1
+		} else {
+			updates["is_active"] = 0
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := s.repo.Update(templateID, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.GetByID(templateID)
+}
+
+func (s *PromptTemplateService) Delete(userID, templateID string) error {
+	pt, err := s.repo.GetByID(templateID)
+	if err != nil {
+		return err
+	}
+	if pt == nil || pt.UserID != userID {
+		return ErrPromptTemplateNotFound
+	}
+	return s.repo.Delete(templateID)
+}
+
+// Render executes a template body with the given variables.
+// Variables are passed as data, never parsed as template source, so user
+// content containing {{ }} is rendered literally.
+func (s *PromptTemplateService) Render(body string, vars map[string]string) (string, error) {
+	tmpl, err := template.New("prompt").Option("missingkey=zero").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+	// Disallow {{define}} and {{block}} so a template can't redefine itself
+	if len(tmpl.Templates()) > 1 {
+		return "", fmt.Errorf("%w: nested template definitions are not allowed", ErrInvalidPromptTemplate)
+	}
+
+	if err := checkPromptTemplateNode(tmpl.Tree.Root); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+
+	// Stop rendering as soon as the output is too long rather than building it all
+	w := &promptWriter{}
+	if err := tmpl.Execute(w, vars); err != nil {
+		if errors.Is(err, ErrPromptTooLong) {
+			return "", ErrPromptTooLong
+		}
+		return "", fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+	return w.sb.String(), nil
+}
+
+// checkPromptTemplateNode allows text, comments, {{if}} and actions, and rejects
+// anything that can loop, recurse or call functions with unbounded output
+func checkPromptTemplateNode(node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkPromptTemplateNode(child); err != nil {
+				return err
+			}
+		}
+	case *parse.TextNode, *parse.CommentNode:
+	case *parse.ActionNode:
+		return checkPromptTemplatePipe(n.Pipe)
+	case *parse.IfNode:
+		if err := checkPromptTemplatePipe(n.Pipe); err != nil {
+			return err
+		}
+		if err := checkPromptTemplateNode(n.List); err != nil {
+			return err
+		}
+		return checkPromptTemplateNode(n.ElseList)
+	case *parse.RangeNode:
+		return errors.New("{{range}} is not allowed")
+	case *parse.WithNode:
+		return errors.New("{{with}} is not allowed")
+	case *parse.TemplateNode:
+		return errors.New("{{template}} is not allowed")
+	default:
+		return fmt.Errorf("unsupported template action %q", node.String())
+	}
+	return nil
+}
+
+func checkPromptTemplatePipe(pipe *parse.PipeNode) error {
+	if pipe == nil {
+		return nil
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.IdentifierNode:
+				if disallowedPromptTemplateFuncs[a.Ident] {
+					return fmt.Errorf("%s is not allowed", a.Ident)
+				}
+			case *parse.PipeNode:
+				if err := checkPromptTemplatePipe(a); err != nil {
+					return err
+				}
+			case *parse.ChainNode:
+				if p, ok := a.Node.(*parse.PipeNode); ok {
+					if err := checkPromptTemplatePipe(p); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// promptWriter collects rendered output and fails once it passes MaxRenderedPromptLength
+type promptWriter struct {
+	sb strings.Builder
+}
+
+func (w *promptWriter) Write(p []byte) (int, error) {
+	if w.sb.Len()+len(p) > MaxRenderedPromptLength {
+		return 0, ErrPromptTooLong
+	}
+	return w.sb.Write(p)
+}
+
+// HasActive reports whether the user has an active template for a name
+func (s *PromptTemplateService) HasActive(userID, name string) bool {
+	if s == nil || userID == "" {
+		return false
+	}
+	pt, err := s.GetActive(userID, name)
+	return err == nil && pt != nil
+}
+
+// RenderActive renders the user's active template for a name.
+// Returns false when no custom template exists or it fails to render, so callers can fall back.
+func (s *PromptTemplateService) RenderActive(userID, name string, vars map[string]string) (string, bool) {
+	if s == nil || userID == "" {
+		return "", false
+	}
+
+	pt, err := s.GetActive(userID, name)
+	if err != nil || pt == nil {
+		return "", false
+	}
+
+	rendered, err := s.Render(pt.TemplateBody, vars)
+	if err != nil {
+		log.Printf("[PromptTemplateService] Failed to render %s template %s: %v", name, pt.ID, err)
+		return "", false
+	}
+	return rendered, true
+}
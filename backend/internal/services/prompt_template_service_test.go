@@ -0,0 +1,164 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// newPromptCapturingProvider starts an OpenAI-compatible server that records the
+// last user prompt and replies with reply
+func newPromptCapturingProvider(t *testing.T, reply string) (*AIProviderConfig, *string) {
+	t.Helper()
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+		for _, message := range req.Messages {
+			if message.Role == "user" {
+				prompt = message.Content
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return &AIProviderConfig{ProviderType: models.ProviderTypeOpenAI, BaseURL: server.URL, APIKey: "key", Model: "model"}, &prompt
+}
+
+func TestActivePromptTemplatesReplaceBuiltInPrompts(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db, "prompts@example.com")
+	prompts := NewPromptTemplateService(repository.NewPromptTemplateRepository(db))
+
+	for name, body := range map[string]string{
+		models.PromptTemplateMemoryCategorization: "CUSTOM CATEGORIZE: {{.Content}}",
+		models.PromptTemplateWeeklyDigest:         "CUSTOM DIGEST:\n{{.Memories}}",
+	} {
+		if _, err := prompts.Create(user.ID, &models.PromptTemplateCreateRequest{TemplateName: name, TemplateBody: body}); err != nil {
+			t.Fatalf("Create %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		run        func(config *AIProviderConfig) error
+		reply      string
+		wantPrompt string
+	}{
+		{
+			name: "memory categorization",
+			run: func(config *AIProviderConfig) error {
+				_, err := ProcessMemoryWithProvider("Dune by Frank Herbert", config)
+				return err
+			},
+			reply:      `{"summary": "", "category": "Books"}`,
+			wantPrompt: "CUSTOM CATEGORIZE: Dune by Frank Herbert",
+		},
+		{
+			name: "memory categorization with function calling",
+			run: func(config *AIProviderConfig) error {
+				_, _, err := ProcessMemoryWithFunctionCalling("Dune by Frank Herbert", config, nil, models.ScrapeModeNone)
+				return err
+			},
+			reply:      `{"summary": "", "category": "Books"}`,
+			wantPrompt: "CUSTOM CATEGORIZE: Dune by Frank Herbert",
+		},
+		{
+			name: "weekly digest",
+			run: func(config *AIProviderConfig) error {
+				_, err := GenerateWeeklyDigestWithProvider([]models.Memory{{Category: "Books", Content: "Dune"}}, config)
+				return err
+			},
+			reply:      "A week of reading.",
+			wantPrompt: "CUSTOM DIGEST:\n- [Books] Dune\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, prompt := newPromptCapturingProvider(t, tt.reply)
+			config.UserID = user.ID
+			config.Prompts = prompts
+			if err := tt.run(config); err != nil {
+				t.Fatalf("AI call failed: %v", err)
+			}
+			if *prompt != tt.wantPrompt {
+				t.Errorf("prompt = %q, want %q", *prompt, tt.wantPrompt)
+			}
+
+			// Without the service the built-in prompt is used
+			config, prompt = newPromptCapturingProvider(t, tt.reply)
+			config.UserID = user.ID
+			if err := tt.run(config); err != nil {
+				t.Fatalf("AI call failed: %v", err)
+			}
+			if strings.HasPrefix(*prompt, "CUSTOM") {
+				t.Errorf("prompt without templates = %q, want the built-in prompt", *prompt)
+			}
+		})
+	}
+}
+
+func TestRenderPromptTemplate(t *testing.T) {
+	service := NewPromptTemplateService(nil)
+	vars := map[string]string{"Title": "Groceries", "Content": "{{.Title}}"}
+
+	tests := []struct {
+		name    string
+		body    string
+		want    string
+		wantErr error
+	}{
+		{name: "variables", body: "Title: {{.Title}}", want: "Title: Groceries"},
+		{name: "variables are not parsed", body: "{{.Content}}", want: "{{.Title}}"},
+		{name: "missing variable", body: "[{{.Memories}}]", want: "[]"},
+		{name: "conditional", body: "{{if .Title}}has title{{else}}untitled{{end}}", want: "has title"},
+		{name: "comment", body: "{{/* note */}}{{.Title}}", want: "Groceries"},
+		{name: "huge range", body: "{{range 100000000}}x{{end}}", wantErr: ErrInvalidPromptTemplate},
+		{name: "range over a variable", body: "{{range .Title}}x{{end}}", wantErr: ErrInvalidPromptTemplate},
+		{name: "huge printf padding", body: `{{printf "%0999999999d" 1}}`, wantErr: ErrInvalidPromptTemplate},
+		{name: "print in a pipeline", body: `{{.Title | print}}`, wantErr: ErrInvalidPromptTemplate},
+		{name: "printf in a condition", body: `{{if (printf "%0999999999d" 1)}}x{{end}}`, wantErr: ErrInvalidPromptTemplate},
+		{name: "with", body: "{{with .Title}}{{.}}{{end}}", wantErr: ErrInvalidPromptTemplate},
+		{name: "call", body: "{{call .Title}}", wantErr: ErrInvalidPromptTemplate},
+		{name: "define", body: `{{define "x"}}y{{end}}{{template "x"}}`, wantErr: ErrInvalidPromptTemplate},
+		{name: "block", body: `{{block "x" .}}y{{end}}`, wantErr: ErrInvalidPromptTemplate},
+		{name: "syntax error", body: "{{.Title", wantErr: ErrInvalidPromptTemplate},
+		{name: "too long", body: strings.Repeat("{{.Title}}", MaxRenderedPromptLength), wantErr: ErrPromptTooLong},
+		{name: "at the limit", body: strings.Repeat("x", MaxRenderedPromptLength), want: strings.Repeat("x", MaxRenderedPromptLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.Render(tt.body, vars)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Render = %q, %v; want %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Render = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+const (
+	// RAGRetrySchedule is the default cron schedule of the check for due retries
+	RAGRetrySchedule = "*/5 * * * *"
+	// ragRetryBatchSize caps the retries made per check
+	ragRetryBatchSize = 100
+	// ragRetryTimeout bounds a single retry, like the original background attempt
+	ragRetryTimeout = 10 * time.Second
+)
+
+// RAGRetryBackoff is the wait before each retry of a failed index; once every retry has
+// failed the entry is marked dead
+var RAGRetryBackoff = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// RAGRetryService retries background RAG indexing that failed, so a todo or memory
+// doesn't stay out of search because of one network error or restart
+type RAGRetryService struct {
+	queueRepo  *repository.RAGIndexQueueRepository
+	todoRepo   *repository.TodoRepository
+	memoryRepo *repository.MemoryRepository
+	ragService *RAGService
+}
+
+func NewRAGRetryService(queueRepo *repository.RAGIndexQueueRepository, todoRepo *repository.TodoRepository, memoryRepo *repository.MemoryRepository, ragService *RAGService) *RAGRetryService {
+	return &RAGRetryService{
+		queueRepo:  queueRepo,
+		todoRepo:   todoRepo,
+		memoryRepo: memoryRepo,
+		ragService: ragService,
+	}
+}
+
+// Enqueue records a failed index of a todo or memory, to be retried after the first backoff
+func (s *RAGRetryService) Enqueue(contentType models.ContentType, contentID, userID string, indexErr error) {
+	item := &models.IndexQueueItem{
+		ContentType:   contentType,
+		ContentID:     contentID,
+		UserID:        userID,
+		LastError:     indexErr.Error(),
+		NextAttemptAt: time.Now().Add(RAGRetryBackoff[0]),
+	}
+	if err := s.queueRepo.Enqueue(item); err != nil {
+		log.Printf("[RAGRetryService] Failed to queue %s %s for retry: %v", contentType, contentID, err)
+	}
+}
+
+// GetQueue returns the user's queued and dead index entries
+func (s *RAGRetryService) GetQueue(userID string) ([]models.IndexQueueItem, error) {
+	return s.queueRepo.GetByUserID(userID)
+}
+
+// RetryDue retries every entry whose next attempt is due. Entries that index, or whose
+// content was deleted meanwhile, are removed; the rest wait for the next backoff or,
+// out of retries, are marked dead.
+func (s *RAGRetryService) RetryDue(ctx context.Context) error {
+	items, err := s.queueRepo.GetDue(time.Now(), len(RAGRetryBackoff), ragRetryBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to fetch due retries: %w", err)
+	}
+
+	for i := range items {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.retry(ctx, &items[i])
+	}
+	return nil
+}
+
+func (s *RAGRetryService) retry(ctx context.Context, item *models.IndexQueueItem) {
+	ctx, cancel := context.WithTimeout(ctx, ragRetryTimeout)
+	defer cancel()
+
+	found, err := s.index(ctx, item)
+	if err == nil {
+		if !found {
+			log.Printf("[RAGRetryService] %s %s no longer exists, dropping it from the queue", item.ContentType, item.ContentID)
+		}
+		if err := s.queueRepo.Delete(item.ID); err != nil {
+			log.Printf("[RAGRetryService] Failed to remove queue entry %s: %v", item.ID, err)
+		}
+		return
+	}
+
+	attempts := item.AttemptCount + 1
+	status := models.IndexQueuePending
+	nextAttemptAt := time.Now()
+	if attempts >= len(RAGRetryBackoff) {
+		status = models.IndexQueueDead
+		log.Printf("[RAGRetryService] Giving up on indexing %s %s after %d retries: %v", item.ContentType, item.ContentID, attempts, err)
+	} else {
+		nextAttemptAt = nextAttemptAt.Add(RAGRetryBackoff[attempts])
+	}
+	if err := s.queueRepo.RecordFailure(item.ID, attempts, err.Error(), nextAttemptAt, status); err != nil {
+		log.Printf("[RAGRetryService] Failed to update queue entry %s: %v", item.ID, err)
+	}
+}
+
+// index re-indexes the entry's content, reporting false if it no longer exists
+func (s *RAGRetryService) index(ctx context.Context, item *models.IndexQueueItem) (bool, error) {
+	switch item.ContentType {
+	case models.ContentTypeTodo:
+		todo, err := s.todoRepo.GetByID(item.ContentID)
+		if err != nil || todo == nil {
+			return false, err
+		}
+		return true, s.ragService.IndexTodo(ctx, todo)
+	case models.ContentTypeMemory:
+		memory, err := s.memoryRepo.GetByID(item.ContentID)
+		if err != nil || memory == nil {
+			return false, err
+		}
+		return true, s.ragService.IndexMemory(ctx, memory)
+	}
+	return false, nil
+}
@@ -4,14 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/todomyday/backend/internal/logging"
+	"github.com/todomyday/backend/internal/metrics"
 	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/repository"
+	"github.com/todomyday/backend/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RAGService provides Retrieval-Augmented Generation capabilities
@@ -24,8 +30,35 @@ type RAGService struct {
 	aiService        *AIService
 	aiProviderSvc    *AIProviderService
 	scraperService   *ScraperService
+	searchHistory    *SearchHistoryService
+	// todoService carries out todo actions from Ask; set after construction because
+	// TodoService itself indexes through the RAG service
+	todoService *TodoService
+	// retryService queues background indexing that failed; set after construction
+	// because it indexes through the RAG service
+	retryService *RAGRetryService
+
+	// ensemble replaces embeddingService as the global embedder when EMBEDDING_ENSEMBLE
+	// is on; the vector repository embeds with it too
+	ensemble *EnsembleEmbeddingService
+
+	// embeddingWorkers is the size of IndexAllForUser's worker pool
+	embeddingWorkers int
+
+	// Embedding services for users' own embedding models, keyed by provider ID
+	userEmbeddersMu sync.Mutex
+	userEmbedders   map[string]*userEmbedder
 }
 
+// userEmbedder caches a provider's embedding service until the provider changes
+type userEmbedder struct {
+	updatedAt time.Time
+	service   *EmbeddingService
+}
+
+// DefaultEmbeddingWorkers is the worker pool size when EMBEDDING_WORKERS isn't set
+const DefaultEmbeddingWorkers = 4
+
 // RAGConfig holds configuration for the RAG service
 type RAGConfig struct {
 	VectorPersistPath  string
@@ -42,7 +75,12 @@ func NewRAGService(
 	aiService *AIService,
 	aiProviderSvc *AIProviderService,
 	scraperService *ScraperService,
+	searchHistory *SearchHistoryService,
 ) *RAGService {
+	if vectorRepo != nil {
+		metrics.SetVectorDocumentsSource(vectorRepo.Count)
+	}
+
 	return &RAGService{
 		vectorRepo:       vectorRepo,
 		ftsRepo:          ftsRepo,
@@ -52,7 +90,51 @@ func NewRAGService(
 		aiService:        aiService,
 		aiProviderSvc:    aiProviderSvc,
 		scraperService:   scraperService,
+		searchHistory:    searchHistory,
+		userEmbedders:    make(map[string]*userEmbedder),
+	}
+}
+
+// SetTodoService lets Ask create todos when a request allows actions
+func (s *RAGService) SetTodoService(todoService *TodoService) {
+	s.todoService = todoService
+}
+
+// SetEmbeddingEnsemble makes the ensemble embed everything that isn't embedded with
+// a user's own model. The vector repository must be created with it as well.
+func (s *RAGService) SetEmbeddingEnsemble(ensemble *EnsembleEmbeddingService) {
+	s.ensemble = ensemble
+}
+
+// globalEmbedder returns the embedder for users without their own embedding model
+func (s *RAGService) globalEmbedder() repository.EmbeddingService {
+	if s.ensemble != nil {
+		return s.ensemble
 	}
+	return s.embeddingService
+}
+
+// SetRetryService makes failed background indexing get retried rather than dropped
+func (s *RAGService) SetRetryService(retryService *RAGRetryService) {
+	s.retryService = retryService
+}
+
+// QueueIndexRetry records that background indexing of a todo or memory failed, so it
+// is retried later. Without a retry service the failure is only logged.
+func (s *RAGService) QueueIndexRetry(contentType models.ContentType, contentID, userID string, indexErr error) {
+	if s == nil || s.retryService == nil {
+		return
+	}
+	s.retryService.Enqueue(contentType, contentID, userID, indexErr)
+}
+
+// GetIndexQueue returns the user's todos and memories waiting for an index retry, and
+// those that ran out of retries
+func (s *RAGService) GetIndexQueue(userID string) ([]models.IndexQueueItem, error) {
+	if s.retryService == nil {
+		return []models.IndexQueueItem{}, nil
+	}
+	return s.retryService.GetQueue(userID)
 }
 
 // IsConfigured returns true if RAG service is properly configured
@@ -64,10 +146,27 @@ func (s *RAGService) IsConfigured() bool {
 // Hybrid Search
 // ==========================================
 
-// Search performs hybrid search combining vector similarity and keyword matching
+// Search performs hybrid search combining vector similarity and keyword matching,
+// and records it in the user's search history
 func (s *RAGService) Search(ctx context.Context, userID string, req *models.SearchRequest) (*models.SearchResponse, error) {
+	resp, err := s.search(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.searchHistory.Record(userID, req.Query, req.ContentTypes, resp.TotalCount)
+	return resp, nil
+}
+
+// search runs a hybrid search without recording it, for retrieval the user didn't ask for directly
+func (s *RAGService) search(ctx context.Context, userID string, req *models.SearchRequest) (*models.SearchResponse, error) {
 	startTime := time.Now()
 
+	ctx = logging.WithUserID(ctx, userID)
+	ctx, span := tracer.Start(ctx, "rag.search", trace.WithAttributes(tracing.AttrUserID.String(userID)))
+	defer span.End()
+	defer observeSearch(metrics.SearchHybrid, startTime)
+
 	if req.Limit <= 0 {
 		req.Limit = 10
 	}
@@ -75,8 +174,8 @@ func (s *RAGService) Search(ctx context.Context, userID string, req *models.Sear
 		req.VectorWeight = 0.7 // Default: favor vector search
 	}
 
-	log.Printf("[RAG] Hybrid search: user=%s, query=%q, limit=%d, vector_weight=%.2f",
-		userID, req.Query, req.Limit, req.VectorWeight)
+	slog.InfoContext(ctx, "RAG hybrid search",
+		"query", req.Query, "limit", req.Limit, "vector_weight", req.VectorWeight)
 
 	var vectorResults, keywordResults []models.SearchResult
 	var vecErr, ftsErr error
@@ -87,7 +186,13 @@ func (s *RAGService) Search(ctx context.Context, userID string, req *models.Sear
 	// Vector search
 	go func() {
 		if s.vectorRepo != nil && s.embeddingService.IsConfigured() {
-			vectorResults, vecErr = s.vectorRepo.SearchByUser(ctx, userID, req.Query, req.Limit*2, req.ContentTypes)
+			vecStart := time.Now()
+			vecErr = traceDB(ctx, "rag.vector_search", func(ctx context.Context) error {
+				var err error
+				vectorResults, err = s.vectorRepo.SearchByUserWithEmbedding(ctx, userID, req.Query, req.Limit*2, req.ContentTypes, s.userEmbedding(userID))
+				return err
+			})
+			observeSearch(metrics.SearchVector, vecStart)
 		}
 		done <- true
 	}()
@@ -95,7 +200,13 @@ func (s *RAGService) Search(ctx context.Context, userID string, req *models.Sear
 	// Keyword search
 	go func() {
 		if s.ftsRepo != nil {
-			keywordResults, ftsErr = s.ftsRepo.SearchWithHighlights(userID, req.Query, req.ContentTypes, req.Limit*2)
+			ftsStart := time.Now()
+			ftsErr = traceDB(ctx, "rag.keyword_search", func(context.Context) error {
+				var err error
+				keywordResults, err = s.ftsRepo.SearchWithHighlights(userID, req.Query, req.ContentTypes, req.Limit*2)
+				return err
+			})
+			observeSearch(metrics.SearchKeyword, ftsStart)
 		}
 		done <- true
 	}()
@@ -105,10 +216,10 @@ func (s *RAGService) Search(ctx context.Context, userID string, req *models.Sear
 	<-done
 
 	if vecErr != nil {
-		log.Printf("[RAG] Vector search error: %v", vecErr)
+		slog.WarnContext(ctx, "RAG vector search failed", "error", vecErr)
 	}
 	if ftsErr != nil {
-		log.Printf("[RAG] Keyword search error: %v", ftsErr)
+		slog.WarnContext(ctx, "RAG keyword search failed", "error", ftsErr)
 	}
 
 	// Filter vector results by cosine similarity BEFORE RRF
@@ -130,8 +241,8 @@ func (s *RAGService) Search(ctx context.Context, userID string, req *models.Sear
 			}
 		}
 
-		log.Printf("[RAG] Vector filter: %d→%d results (top_sim=%.4f, threshold=%.4f)",
-			len(vectorResults), len(filteredVec), topSim, minSimThreshold)
+		slog.DebugContext(ctx, "RAG vector filter",
+			"before", len(vectorResults), "after", len(filteredVec), "top_similarity", topSim, "threshold", minSimThreshold)
 		vectorResults = filteredVec
 	}
 
@@ -145,6 +256,7 @@ func (s *RAGService) Search(ctx context.Context, userID string, req *models.Sear
 
 	// Enrich results with full document data
 	enriched := s.enrichSearchResults(ctx, userID, combined)
+	span.SetAttributes(attribute.Int("rag.result_count", len(enriched)))
 
 	return &models.SearchResponse{
 		Results:    enriched,
@@ -191,6 +303,7 @@ func (s *RAGService) reciprocalRankFusion(vectorResults, keywordResults []models
 			// Merge highlights
 			if len(result.Highlights) > 0 {
 				existing.Highlights = append(existing.Highlights, result.Highlights...)
+				existing.Spans = append(existing.Spans, result.Spans...)
 			}
 		} else {
 			r := result
@@ -213,7 +326,8 @@ func (s *RAGService) reciprocalRankFusion(vectorResults, keywordResults []models
 	return combined
 }
 
-// enrichSearchResults adds full document data to search results
+// enrichSearchResults adds full document data to search results, dropping any whose
+// todo or memory belongs to someone other than userID
 func (s *RAGService) enrichSearchResults(ctx context.Context, userID string, results []models.SearchResult) []models.SearchResult {
 	enriched := make([]models.SearchResult, 0, len(results))
 
@@ -221,6 +335,10 @@ func (s *RAGService) enrichSearchResults(ctx context.Context, userID string, res
 		switch result.Document.ContentType {
 		case models.ContentTypeTodo:
 			if todo, _ := s.todoRepo.GetByID(result.Document.ContentID); todo != nil {
+				// The indexes are searched by user, but never hand out another user's row
+				if todo.UserID != userID {
+					continue
+				}
 				result.Document.Title = todo.Title
 				if todo.Description != nil {
 					result.Document.Content = *todo.Description
@@ -243,6 +361,9 @@ func (s *RAGService) enrichSearchResults(ctx context.Context, userID string, res
 
 		case models.ContentTypeMemory:
 			if memory, _ := s.memoryRepo.GetByID(result.Document.ContentID); memory != nil {
+				if memory.UserID != userID {
+					continue
+				}
 				result.Document.Content = memory.Content
 				if memory.URLTitle != nil {
 					result.Document.Title = *memory.URLTitle
@@ -270,9 +391,13 @@ func (s *RAGService) enrichSearchResults(ctx context.Context, userID string, res
 // ==========================================
 
 // Ask answers a question using RAG with multiple modes
-func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequest) (*models.AskResponse, error) {
+func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequest) (resp *models.AskResponse, err error) {
 	startTime := time.Now()
 
+	ctx = logging.WithUserID(ctx, userID)
+	ctx, span := tracer.Start(ctx, "rag.ask", trace.WithAttributes(tracing.AttrUserID.String(userID)))
+	defer func() { endSpan(span, err) }()
+
 	if req.MaxContext <= 0 {
 		req.MaxContext = 5
 	}
@@ -282,7 +407,24 @@ func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequ
 		req.Mode = models.AskModeMemories
 	}
 
-	log.Printf("[RAG] Ask: user=%s, question=%q, mode=%s", userID, req.Question, req.Mode)
+	slog.InfoContext(ctx, "RAG ask", "question", req.Question, "mode", req.Mode)
+
+	// "Add a todo to call mom tomorrow" is an instruction rather than a question
+	if req.AllowActions && s.todoService != nil && looksLikeTodoCommand(req.Question) {
+		todo, err := s.createTodoFromChat(ctx, userID, req.Question)
+		if err != nil {
+			return nil, err
+		}
+		if todo != nil {
+			return &models.AskResponse{
+				Answer:      fmt.Sprintf("Created todo: %s", todo.Title),
+				Sources:     []models.SearchResult{},
+				Question:    req.Question,
+				TimeTaken:   float64(time.Since(startTime).Milliseconds()),
+				CreatedTodo: todo,
+			}, nil
+		}
+	}
 
 	var contextStr string
 	var sources []models.SearchResult
@@ -296,7 +438,7 @@ func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequ
 		// Web search + scrape top results
 		webCtx, webSources, err := s.getInternetContext(ctx, req.Question)
 		if err != nil {
-			log.Printf("[RAG] Internet search error: %v", err)
+			slog.WarnContext(ctx, "RAG internet search failed", "error", err)
 			return &models.AskResponse{
 				Answer:    "I couldn't search the internet. Please check if web search is configured.",
 				Sources:   []models.SearchResult{},
@@ -316,15 +458,15 @@ func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequ
 
 		// Step 1: Get memories context
 		memCtx, memSources := s.getMemoriesContext(ctx, userID, req)
-		log.Printf("[RAG Hybrid] Step 1: Got %d memory sources", len(memSources))
+		slog.InfoContext(ctx, "RAG hybrid step 1: memory sources", "count", len(memSources))
 
 		// Step 2: Generate smart search queries using LLM
 		searchQueries, err := s.generateSearchQueries(ctx, userID, req.Question, memCtx)
 		if err != nil {
-			log.Printf("[RAG Hybrid] Step 2: Query generation failed: %v, using original question", err)
+			slog.WarnContext(ctx, "RAG hybrid step 2: query generation failed, using original question", "error", err)
 			searchQueries = []string{req.Question}
 		} else {
-			log.Printf("[RAG Hybrid] Step 2: Generated queries: %v", searchQueries)
+			slog.InfoContext(ctx, "RAG hybrid step 2: generated queries", "queries", searchQueries)
 		}
 
 		// Step 3: Web search with each generated query (max 3)
@@ -338,7 +480,7 @@ func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequ
 
 			webCtx, webSrcs, err := s.getInternetContext(ctx, query)
 			if err != nil {
-				log.Printf("[RAG Hybrid] Step 3: Web search failed for query '%s': %v", query, err)
+				slog.WarnContext(ctx, "RAG hybrid step 3: web search failed", "query", query, "error", err)
 				continue
 			}
 
@@ -347,7 +489,7 @@ func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequ
 				webSources = append(webSources, webSrcs...)
 			}
 		}
-		log.Printf("[RAG Hybrid] Step 3: Got %d web sources from %d queries", len(webSources), len(searchQueries))
+		slog.InfoContext(ctx, "RAG hybrid step 3: web sources", "count", len(webSources), "queries", len(searchQueries))
 
 		// Step 4: Build combined context
 		if memCtx != "" && allWebCtx.Len() > 0 {
@@ -367,11 +509,11 @@ func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequ
 	}
 
 	// Generate answer based on mode
+	span.SetAttributes(attribute.String("rag.mode", string(req.Mode)), attribute.Int("rag.source_count", len(sources)))
 	var answer string
-	var err error
 	switch req.Mode {
 	case models.AskModeLLM:
-		answer, err = s.generateDirectAnswer(ctx, userID, req.Question)
+		answer, err = s.generateDirectAnswer(ctx, userID, req.Question, req.History, req.OnToken)
 	case models.AskModeInternet:
 		if contextStr == "" {
 			return &models.AskResponse{
@@ -381,7 +523,7 @@ func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequ
 				TimeTaken: float64(time.Since(startTime).Milliseconds()),
 			}, nil
 		}
-		answer, err = s.generateInternetAnswer(ctx, userID, req.Question, contextStr)
+		answer, err = s.generateInternetAnswer(ctx, userID, req.Question, contextStr, req.History, req.OnToken)
 	case models.AskModeHybrid:
 		if contextStr == "" && len(sources) == 0 {
 			return &models.AskResponse{
@@ -401,7 +543,7 @@ func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequ
 				hasMemorySources = true
 			}
 		}
-		answer, err = s.generateHybridAnswer(ctx, userID, req.Question, contextStr, hasMemorySources, hasWebSources)
+		answer, err = s.generateHybridAnswer(ctx, userID, req.Question, contextStr, hasMemorySources, hasWebSources, req.History, req.OnToken)
 	default: // memories mode
 		if contextStr == "" && len(sources) == 0 {
 			return &models.AskResponse{
@@ -411,7 +553,7 @@ func (s *RAGService) Ask(ctx context.Context, userID string, req *models.AskRequ
 				TimeTaken: float64(time.Since(startTime).Milliseconds()),
 			}, nil
 		}
-		answer, err = s.generateAnswer(ctx, userID, req.Question, contextStr)
+		answer, err = s.generateAnswer(ctx, userID, req.Question, contextStr, req.History, req.OnToken)
 	}
 
 	if err != nil {
@@ -435,9 +577,9 @@ func (s *RAGService) getMemoriesContext(ctx context.Context, userID string, req
 		VectorWeight: 0.7,
 	}
 
-	searchResp, err := s.Search(ctx, userID, searchReq)
+	searchResp, err := s.search(ctx, userID, searchReq)
 	if err != nil {
-		log.Printf("[RAG] Memory search error: %v", err)
+		slog.WarnContext(ctx, "RAG memory search failed", "error", err)
 		return "", nil
 	}
 
@@ -508,7 +650,7 @@ func (s *RAGService) getInternetContext(ctx context.Context, question string) (s
 
 		scraped, err := s.scraperService.ScrapeURL(result.URL)
 		if err != nil {
-			log.Printf("[RAG] Failed to scrape %s: %v", result.URL, err)
+			slog.WarnContext(ctx, "RAG failed to scrape result", "url", result.URL, "error", err)
 			continue
 		}
 
@@ -577,7 +719,7 @@ Break it down into specific, searchable topics.
 Return ONLY a JSON array of strings, no other text: ["query1", "query2"]`, question)
 	}
 
-	response, err := s.callAIProvider(ctx, userID, prompt)
+	response, err := s.callAIProvider(ctx, userID, prompt, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -592,29 +734,29 @@ Return ONLY a JSON array of strings, no other text: ["query1", "query2"]`, quest
 	if startIdx != -1 && endIdx != -1 && endIdx > startIdx {
 		jsonStr := response[startIdx : endIdx+1]
 		if err := json.Unmarshal([]byte(jsonStr), &queries); err == nil && len(queries) > 0 {
-			log.Printf("[RAG] Generated search queries: %v", queries)
+			slog.DebugContext(ctx, "RAG generated search queries", "queries", queries)
 			return queries, nil
 		}
 	}
 
 	// Fallback: return original question if JSON parsing fails
-	log.Printf("[RAG] Failed to parse search queries, using original question")
+	slog.WarnContext(ctx, "RAG failed to parse search queries, using original question")
 	return []string{question}, nil
 }
 
 // generateDirectAnswer generates an answer directly from LLM without context
-func (s *RAGService) generateDirectAnswer(ctx context.Context, userID, question string) (string, error) {
+func (s *RAGService) generateDirectAnswer(ctx context.Context, userID, question string, history []models.ChatMessage, onToken func(string)) (string, error) {
 	prompt := fmt.Sprintf(`You are a helpful assistant. Please answer the following question directly and helpfully.
 
 QUESTION: %s
 
 ANSWER:`, question)
 
-	return s.callAIProvider(ctx, userID, prompt)
+	return s.callAIProvider(ctx, userID, prompt, history, onToken)
 }
 
 // generateAnswer uses AI to answer the question based on memories context
-func (s *RAGService) generateAnswer(ctx context.Context, userID, question, contextStr string) (string, error) {
+func (s *RAGService) generateAnswer(ctx context.Context, userID, question, contextStr string, history []models.ChatMessage, onToken func(string)) (string, error) {
 	prompt := fmt.Sprintf(`You are a helpful assistant answering questions about a user's personal data (todos and memories).
 
 Based on the following context from the user's data, answer their question concisely and helpfully.
@@ -634,11 +776,11 @@ INSTRUCTIONS:
 
 ANSWER:`, contextStr, question)
 
-	return s.callAIProvider(ctx, userID, prompt)
+	return s.callAIProvider(ctx, userID, prompt, history, onToken)
 }
 
 // generateInternetAnswer uses AI to answer based on web search results
-func (s *RAGService) generateInternetAnswer(ctx context.Context, userID, question, contextStr string) (string, error) {
+func (s *RAGService) generateInternetAnswer(ctx context.Context, userID, question, contextStr string, history []models.ChatMessage, onToken func(string)) (string, error) {
 	prompt := fmt.Sprintf(`You are a helpful assistant answering questions using information from web search results.
 
 Based on the following web search results, answer the user's question comprehensively.
@@ -658,11 +800,11 @@ INSTRUCTIONS:
 
 ANSWER:`, contextStr, question)
 
-	return s.callAIProvider(ctx, userID, prompt)
+	return s.callAIProvider(ctx, userID, prompt, history, onToken)
 }
 
 // generateHybridAnswer uses AI to answer combining personal data and web results
-func (s *RAGService) generateHybridAnswer(ctx context.Context, userID, question, contextStr string, hasMemories, hasWeb bool) (string, error) {
+func (s *RAGService) generateHybridAnswer(ctx context.Context, userID, question, contextStr string, hasMemories, hasWeb bool, history []models.ChatMessage, onToken func(string)) (string, error) {
 	var sourceDescription string
 	if hasMemories && hasWeb {
 		sourceDescription = "your personal memories/todos AND targeted web research"
@@ -696,143 +838,390 @@ INSTRUCTIONS:
 
 ANSWER:`, sourceDescription, contextStr, question)
 
-	return s.callAIProvider(ctx, userID, prompt)
+	return s.callAIProvider(ctx, userID, prompt, history, onToken)
 }
 
-// callAIProvider calls the configured AI provider with the given prompt
-func (s *RAGService) callAIProvider(ctx context.Context, userID, prompt string) (string, error) {
+// callAIProvider calls the configured AI provider with the given prompt,
+// preceded by any prior conversation turns. A non-nil onToken streams the reply.
+func (s *RAGService) callAIProvider(ctx context.Context, userID, prompt string, history []models.ChatMessage, onToken func(string)) (string, error) {
+	turns := make([]chatMessage, 0, len(history))
+	for _, m := range history {
+		turns = append(turns, chatMessage{Role: m.Role, Content: m.Content})
+	}
 
+	config := s.aiConfig(ctx, userID, onToken)
+	if config == nil {
+		return "", fmt.Errorf("no AI service configured")
+	}
+	return callProviderWithHistory(config, turns, prompt)
+}
+
+// aiConfig returns the config for the user's default AI provider, falling back to
+// the server's AI service, or nil if neither is configured
+func (s *RAGService) aiConfig(ctx context.Context, userID string, onToken func(string)) *AIProviderConfig {
 	// Try to use user's configured AI provider first
 	if s.aiProviderSvc != nil {
 		provider, err := s.aiProviderSvc.GetDefaultByUserID(userID)
 		if err == nil && provider != nil {
-			apiKey, err := s.aiProviderSvc.GetDecryptedAPIKey(provider)
+			config, err := s.aiProviderSvc.ProviderConfig(provider, userID)
 			if err == nil {
-				model := os.Getenv("OPENAI_MODEL")
-				if provider.SelectedModel != nil {
-					model = *provider.SelectedModel
-				}
-				config := &AIProviderConfig{
-					ProviderType: provider.ProviderType,
-					BaseURL:      provider.BaseURL,
-					APIKey:       apiKey,
-					Model:        model,
-				}
-				switch config.ProviderType {
-				case models.ProviderTypeAnthropic:
-					return callAnthropic(config, prompt)
-				case models.ProviderTypeGoogle:
-					return callGoogle(config, prompt)
-				default:
-					return callOpenAICompatible(config, prompt)
+				if provider.SelectedModel == nil {
+					config.Model = os.Getenv("OPENAI_MODEL")
 				}
+				config.Ctx = ctx
+				config.OnToken = onToken
+				return config
 			}
 		}
 	}
 
 	// Fall back to default AI service
 	if s.aiService != nil && s.aiService.IsConfigured() {
-		config := &AIProviderConfig{
+		return &AIProviderConfig{
 			ProviderType: models.ProviderTypeOpenAI,
 			BaseURL:      s.aiService.baseURL,
 			APIKey:       s.aiService.apiKey,
 			Model:        s.aiService.model,
+			Ctx:          ctx,
+			UserID:       userID,
+			OnToken:      onToken,
 		}
-		return callOpenAICompatible(config, prompt)
 	}
 
-	return "", fmt.Errorf("no AI service configured")
+	return nil
 }
 
 // ==========================================
 // Indexing
 // ==========================================
 
-// IndexAllForUser indexes all todos and memories for a user
+// IndexAllForUser indexes the user's todos and memories that aren't in the index
+// yet. Documents are embedded by a pool of embeddingWorkers workers; the embedding
+// service's rate limiter still paces them, but requests overlap instead of waiting
+// on each other's round trips.
 func (s *RAGService) IndexAllForUser(ctx context.Context, userID string) (*models.IndexResponse, error) {
 	startTime := time.Now()
-	var indexed, skipped, errors int
+	var docs []*models.Document
+	skipped := 0
 
-	log.Printf("[RAG] Starting full index for user: %s", userID)
+	ctx = logging.WithUserID(ctx, userID)
+	slog.InfoContext(ctx, "RAG starting full index")
 
-	// Index todos
-	todos, err := s.todoRepo.GetAllByUserID(userID)
+	todos, err := s.todoRepo.GetAllByUserID(userID, true)
 	if err != nil {
-		log.Printf("[RAG] Error fetching todos: %v", err)
-	} else {
-		for _, todo := range todos {
-			// Check if already indexed
-			if s.vectorRepo.GetByContentID(models.ContentTypeTodo, todo.ID) != nil {
-				skipped++
-				continue
-			}
+		slog.ErrorContext(ctx, "RAG failed to fetch todos for indexing", "error", err)
+	}
+	for i := range todos {
+		if s.vectorRepo.GetByContentID(models.ContentTypeTodo, todos[i].ID) != nil {
+			skipped++
+			continue
+		}
+		docs = append(docs, s.todoToDocument(&todos[i]))
+	}
 
-			doc := s.todoToDocument(&todo)
-			if err := s.vectorRepo.Add(ctx, doc); err != nil {
-				log.Printf("[RAG] Error indexing todo %s: %v", todo.ID, err)
-				errors++
-			} else {
-				indexed++
-			}
+	memories, err := s.memoryRepo.GetAllByUserID(userID, models.MemorySortManual, 1000, 0)
+	if err != nil {
+		slog.ErrorContext(ctx, "RAG failed to fetch memories for indexing", "error", err)
+	}
+	for i := range memories {
+		if s.vectorRepo.GetByContentID(models.ContentTypeMemory, memories[i].ID) != nil {
+			skipped++
+			continue
 		}
+		docs = append(docs, s.memoryToDocument(&memories[i]))
+	}
+
+	embedder := s.globalEmbedder()
+	dimension := 0
+	if embedding := s.userEmbedding(userID); embedding != nil {
+		embedder = embedding.Service
+		dimension = embedding.Dimension
 	}
 
-	// Index memories
-	memories, err := s.memoryRepo.GetAllByUserID(userID, 1000, 0)
+	progress := models.IndexResponse{Skipped: skipped, Total: len(docs) + skipped}
+	indexedIDs := make(map[models.ContentType][]string)
+	for result := range s.embedDocuments(ctx, embedder, docs) {
+		if result.err == nil {
+			result.err = s.vectorRepo.AddEmbedded(ctx, result.doc, result.vector, dimension)
+		}
+		if result.err != nil {
+			slog.WarnContext(ctx, "RAG failed to index document",
+				"content_type", result.doc.ContentType, "content_id", result.doc.ContentID, "error", result.err)
+			progress.Errors++
+		} else {
+			progress.Indexed++
+			indexedIDs[result.doc.ContentType] = append(indexedIDs[result.doc.ContentType], result.doc.ContentID)
+		}
+
+		if done := progress.Indexed + progress.Errors; done%indexProgressInterval == 0 && done < len(docs) {
+			progress.TimeTaken = float64(time.Since(startTime).Milliseconds())
+			slog.InfoContext(ctx, "RAG indexing progress", "progress", progress)
+		}
+	}
+
+	for contentType, ids := range indexedIDs {
+		s.markIndexed(ctx, userID, contentType, ids)
+	}
+	lastIndexedAt := s.vectorRepo.MarkIndexed()
+	progress.LastIndexedAt = &lastIndexedAt
+	progress.TimeTaken = float64(time.Since(startTime).Milliseconds())
+
+	slog.InfoContext(ctx, "RAG indexing complete", "indexed", progress.Indexed, "skipped", progress.Skipped, "errors", progress.Errors)
+	return &progress, nil
+}
+
+// markIndexed records that the user's todos or memories among ids were just indexed,
+// so they no longer count as stale. A failure is only logged; the index itself is fine.
+func (s *RAGService) markIndexed(ctx context.Context, userID string, contentType models.ContentType, ids []string) {
+	var err error
+	switch contentType {
+	case models.ContentTypeTodo:
+		if s.todoRepo != nil {
+			err = s.todoRepo.MarkIndexed(userID, ids, time.Now())
+		}
+	case models.ContentTypeMemory:
+		if s.memoryRepo != nil {
+			err = s.memoryRepo.MarkIndexed(userID, ids, time.Now())
+		}
+	}
 	if err != nil {
-		log.Printf("[RAG] Error fetching memories: %v", err)
-	} else {
-		for _, memory := range memories {
-			// Check if already indexed
-			if s.vectorRepo.GetByContentID(models.ContentTypeMemory, memory.ID) != nil {
-				skipped++
-				continue
-			}
+		slog.WarnContext(ctx, "RAG failed to record indexing time", "content_type", contentType, "error", err)
+	}
+}
 
-			doc := s.memoryToDocument(&memory)
-			if err := s.vectorRepo.Add(ctx, doc); err != nil {
-				log.Printf("[RAG] Error indexing memory %s: %v", memory.ID, err)
-				errors++
-			} else {
-				indexed++
+// indexProgressInterval is how many documents IndexAllForUser handles between progress logs
+const indexProgressInterval = 50
+
+// embeddedDocument is a document with its passage embedding, or the error embedding it
+type embeddedDocument struct {
+	doc    *models.Document
+	vector []float32
+	err    error
+}
+
+// embedDocuments embeds docs on a pool of embeddingWorkers goroutines. The returned
+// channel yields every document once, in completion order, and is closed when all are
+// done. Documents not yet started when ctx is cancelled come back with its error.
+func (s *RAGService) embedDocuments(ctx context.Context, embedder repository.EmbeddingService, docs []*models.Document) <-chan embeddedDocument {
+	jobs := make(chan *models.Document, len(docs))
+	for _, doc := range docs {
+		jobs <- doc
+	}
+	close(jobs)
+
+	results := make(chan embeddedDocument, len(docs))
+	var wg sync.WaitGroup
+	for i := 0; i < min(s.workers(), len(docs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for doc := range jobs {
+				if err := ctx.Err(); err != nil {
+					results <- embeddedDocument{doc: doc, err: err}
+					continue
+				}
+				vector, err := embedder.EmbedPassage(ctx, PrepareDocumentText(doc))
+				results <- embeddedDocument{doc: doc, vector: vector, err: err}
 			}
-		}
+		}()
 	}
 
-	log.Printf("[RAG] Indexing complete: indexed=%d, skipped=%d, errors=%d", indexed, skipped, errors)
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
 
-	return &models.IndexResponse{
-		Indexed:   indexed,
-		Skipped:   skipped,
-		Errors:    errors,
-		TimeTaken: float64(time.Since(startTime).Milliseconds()),
-	}, nil
+// SetEmbeddingWorkers sets how many documents IndexAllForUser embeds at once
+func (s *RAGService) SetEmbeddingWorkers(workers int) {
+	s.embeddingWorkers = workers
+}
+
+func (s *RAGService) workers() int {
+	if s.embeddingWorkers > 0 {
+		return s.embeddingWorkers
+	}
+	return DefaultEmbeddingWorkers
 }
 
 // IndexTodo indexes a single todo
-func (s *RAGService) IndexTodo(ctx context.Context, todo *models.Todo) error {
+func (s *RAGService) IndexTodo(ctx context.Context, todo *models.Todo) (err error) {
 	if !s.IsConfigured() {
 		return nil // Silently skip if not configured
 	}
 
+	ctx, span := tracer.Start(ctx, "rag.index_todo", trace.WithAttributes(tracing.AttrUserID.String(todo.UserID)))
+	defer func() { endSpan(span, err) }()
+
 	// Delete existing if present
 	s.vectorRepo.DeleteByContentID(ctx, models.ContentTypeTodo, todo.ID)
 
 	doc := s.todoToDocument(todo)
-	return s.vectorRepo.Add(ctx, doc)
+	if err := s.vectorRepo.AddForUser(ctx, doc, s.userEmbedding(todo.UserID)); err != nil {
+		return err
+	}
+	s.markIndexed(ctx, todo.UserID, models.ContentTypeTodo, []string{todo.ID})
+	return nil
+}
+
+// IndexTodos re-indexes several of a user's todos, batching the embeddings when the
+// user has no embedding model of their own
+func (s *RAGService) IndexTodos(ctx context.Context, userID string, todos []models.Todo) (err error) {
+	if !s.IsConfigured() || len(todos) == 0 {
+		return nil
+	}
+
+	ctx, span := tracer.Start(ctx, "rag.index_todos", trace.WithAttributes(tracing.AttrUserID.String(userID)))
+	defer func() { endSpan(span, err) }()
+
+	ids := make([]string, len(todos))
+	docs := make([]*models.Document, len(todos))
+	for i := range todos {
+		ids[i] = todos[i].ID
+		docs[i] = s.todoToDocument(&todos[i])
+	}
+
+	// Delete existing if present
+	if err := s.vectorRepo.DeleteByUserAndFilter(ctx, userID, models.ContentTypeTodo, ids); err != nil {
+		return err
+	}
+
+	// Per-user collections embed one document at a time
+	if embedding := s.userEmbedding(userID); embedding != nil {
+		for _, doc := range docs {
+			if err := s.vectorRepo.AddForUser(ctx, doc, embedding); err != nil {
+				return err
+			}
+		}
+	} else if err := s.vectorRepo.AddBatch(ctx, docs); err != nil {
+		return err
+	}
+	s.markIndexed(ctx, userID, models.ContentTypeTodo, ids)
+	return nil
 }
 
 // IndexMemory indexes a single memory
-func (s *RAGService) IndexMemory(ctx context.Context, memory *models.Memory) error {
+func (s *RAGService) IndexMemory(ctx context.Context, memory *models.Memory) (err error) {
 	if !s.IsConfigured() {
 		return nil // Silently skip if not configured
 	}
 
+	ctx, span := tracer.Start(ctx, "rag.index_memory", trace.WithAttributes(tracing.AttrUserID.String(memory.UserID)))
+	defer func() { endSpan(span, err) }()
+
 	// Delete existing if present
 	s.vectorRepo.DeleteByContentID(ctx, models.ContentTypeMemory, memory.ID)
 
 	doc := s.memoryToDocument(memory)
-	return s.vectorRepo.Add(ctx, doc)
+	if err := s.vectorRepo.AddForUser(ctx, doc, s.userEmbedding(memory.UserID)); err != nil {
+		return err
+	}
+	s.markIndexed(ctx, memory.UserID, models.ContentTypeMemory, []string{memory.ID})
+	return nil
+}
+
+// IndexMemories indexes a batch of the user's memories
+func (s *RAGService) IndexMemories(ctx context.Context, userID string, memories []models.Memory) (err error) {
+	if !s.IsConfigured() || len(memories) == 0 {
+		return nil
+	}
+
+	ctx, span := tracer.Start(ctx, "rag.index_memories", trace.WithAttributes(tracing.AttrUserID.String(userID)))
+	defer func() { endSpan(span, err) }()
+
+	ids := make([]string, len(memories))
+	docs := make([]*models.Document, len(memories))
+	for i := range memories {
+		ids[i] = memories[i].ID
+		docs[i] = s.memoryToDocument(&memories[i])
+	}
+
+	// Delete existing if present
+	if err := s.vectorRepo.DeleteByUserAndFilter(ctx, userID, models.ContentTypeMemory, ids); err != nil {
+		return err
+	}
+
+	// Per-user collections embed one document at a time
+	if embedding := s.userEmbedding(userID); embedding != nil {
+		for _, doc := range docs {
+			if err := s.vectorRepo.AddForUser(ctx, doc, embedding); err != nil {
+				return err
+			}
+		}
+	} else if err := s.vectorRepo.AddBatch(ctx, docs); err != nil {
+		return err
+	}
+	s.markIndexed(ctx, userID, models.ContentTypeMemory, ids)
+	return nil
+}
+
+// FindNearestMemory embeds text as a passage and returns the user's most similar
+// indexed memory with its cosine similarity. Returns an empty ID if nothing is indexed.
+func (s *RAGService) FindNearestMemory(ctx context.Context, userID, text string) (string, float64, error) {
+	results, err := s.FindNearestMemories(ctx, userID, text, 1)
+	if err != nil || len(results) == 0 {
+		return "", 0, err
+	}
+	return results[0].Document.ContentID, results[0].Score, nil
+}
+
+// FindNearestMemories embeds text as a passage and returns up to limit of the user's
+// indexed memories, most similar first, scored by cosine similarity
+func (s *RAGService) FindNearestMemories(ctx context.Context, userID, text string, limit int) ([]models.SearchResult, error) {
+	if !s.IsConfigured() {
+		return nil, nil
+	}
+
+	embedder := s.globalEmbedder()
+	dimension := 0
+	if embedding := s.userEmbedding(userID); embedding != nil {
+		embedder = embedding.Service
+		dimension = embedding.Dimension
+	}
+
+	vector, err := embedder.EmbedPassage(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.vectorRepo.SearchByUserVector(ctx, userID, vector, dimension, limit, models.ContentTypeMemory)
+}
+
+// userEmbedding returns the user's own embedding model from their AI provider settings,
+// or nil to use the global one. Services are reused per provider so rate limiting and
+// caching carry across calls, and rebuilt whenever the provider is updated.
+func (s *RAGService) userEmbedding(userID string) *repository.UserEmbedding {
+	if s.aiProviderSvc == nil {
+		return nil
+	}
+
+	provider, err := s.aiProviderSvc.GetEmbeddingProvider(userID)
+	if err != nil || provider == nil || provider.EmbeddingModel == nil || provider.EmbeddingDimension == nil {
+		return nil
+	}
+
+	s.userEmbeddersMu.Lock()
+	defer s.userEmbeddersMu.Unlock()
+
+	cached, ok := s.userEmbedders[provider.ID]
+	if !ok || !cached.updatedAt.Equal(provider.UpdatedAt) {
+		apiKey, err := s.aiProviderSvc.GetDecryptedAPIKey(provider)
+		if err != nil {
+			slog.Warn("RAG failed to decrypt API key for embedding provider", "user_id", userID, "provider_id", provider.ID, "error", err)
+			return nil
+		}
+		cached = &userEmbedder{
+			updatedAt: provider.UpdatedAt,
+			service:   NewUserEmbeddingService(provider, apiKey),
+		}
+		s.userEmbedders[provider.ID] = cached
+	}
+
+	return &repository.UserEmbedding{
+		Service:   cached.service,
+		Dimension: *provider.EmbeddingDimension,
+	}
 }
 
 // DeleteFromIndex removes a document from the index
@@ -843,6 +1232,14 @@ func (s *RAGService) DeleteFromIndex(ctx context.Context, contentType models.Con
 	return s.vectorRepo.DeleteByContentID(ctx, contentType, contentID)
 }
 
+// DeleteManyFromIndex removes several of a user's documents from the index
+func (s *RAGService) DeleteManyFromIndex(ctx context.Context, userID string, contentType models.ContentType, contentIDs []string) error {
+	if !s.IsConfigured() {
+		return nil
+	}
+	return s.vectorRepo.DeleteByUserAndFilter(ctx, userID, contentType, contentIDs)
+}
+
 // ==========================================
 // Helpers
 // ==========================================
@@ -921,5 +1318,60 @@ func (s *RAGService) GetStats(userID string) *models.IndexStats {
 			ByUser:         make(map[string]int),
 		}
 	}
-	return s.vectorRepo.GetStats(userID)
+	stats := s.vectorRepo.GetStats(userID)
+	stats.StaleCount = s.staleCount(userID)
+	return stats
+}
+
+// staleCount counts the user's todos and memories whose index entry is missing or out
+// of date; a count that can't be read is left out
+func (s *RAGService) staleCount(userID string) int {
+	total := 0
+	if count, err := s.todoRepo.CountStale(userID); err != nil {
+		slog.Warn("RAG failed to count stale todos", "user_id", userID, "error", err)
+	} else {
+		total += count
+	}
+	if count, err := s.memoryRepo.CountStale(userID); err != nil {
+		slog.Warn("RAG failed to count stale memories", "user_id", userID, "error", err)
+	} else {
+		total += count
+	}
+	return total
+}
+
+// ReindexTodo indexes one of the user's todos now, rather than in the background as
+// edits do, so a stale index entry can be fixed on demand
+func (s *RAGService) ReindexTodo(ctx context.Context, userID, todoID string) (*models.ReindexResponse, error) {
+	todo, err := s.todoRepo.GetByID(todoID)
+	if err != nil {
+		return nil, err
+	}
+	if todo == nil || todo.UserID != userID {
+		return nil, ErrTodoNotFound
+	}
+
+	start := time.Now()
+	if err := s.IndexTodo(ctx, todo); err != nil {
+		return nil, err
+	}
+	return &models.ReindexResponse{Indexed: true, ElapsedMS: time.Since(start).Milliseconds()}, nil
+}
+
+// ReindexMemory indexes one of the user's memories now, rather than in the background
+// as edits do, so a stale index entry can be fixed on demand
+func (s *RAGService) ReindexMemory(ctx context.Context, userID, memoryID string) (*models.ReindexResponse, error) {
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil || memory.UserID != userID {
+		return nil, ErrMemoryNotFound
+	}
+
+	start := time.Now()
+	if err := s.IndexMemory(ctx, memory); err != nil {
+		return nil, err
+	}
+	return &models.ReindexResponse{Indexed: true, ElapsedMS: time.Since(start).Milliseconds()}, nil
 }
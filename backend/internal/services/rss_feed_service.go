@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// RSSFeedImportSchedule is the default cron schedule of the background import of saved feeds
+const RSSFeedImportSchedule = "0 */6 * * *"
+
+var ErrRSSFeedNotFound = newCodedError(models.ErrCodeNotFound, "feed not found")
+
+// RSSFeedService imports feed entries as memories, once on request or periodically
+// for saved feeds. Each entry goes through MemoryService.Create, so linked pages are
+// scraped and categorized like any memory containing a URL.
+type RSSFeedService struct {
+	feedRepo       *repository.RSSFeedRepository
+	memoryRepo     *repository.MemoryRepository
+	memoryService  *MemoryService
+	scraperService *ScraperService
+}
+
+// NewRSSFeedService creates the service; ImportSavedFeeds is run as a background job
+func NewRSSFeedService(feedRepo *repository.RSSFeedRepository, memoryRepo *repository.MemoryRepository, memoryService *MemoryService, scraperService *ScraperService) *RSSFeedService {
+	// Fetching feeds doesn't need SearXNG, so work without a configured scraper
+	if scraperService == nil {
+		scraperService = NewScraperService(nil)
+	}
+
+	service := &RSSFeedService{
+		feedRepo:       feedRepo,
+		memoryRepo:     memoryRepo,
+		memoryService:  memoryService,
+		scraperService: scraperService,
+	}
+
+	return service
+}
+
+// Import creates a memory for each of the feed's latest items whose URL the user
+// hasn't saved yet. With req.Save the feed is also stored for background imports.
+func (s *RSSFeedService) Import(userID string, req *models.RSSImportRequest) (*models.RSSImportResult, error) {
+	maxItems := clampRSSItems(req.MaxItems)
+
+	result, err := s.importFeed(userID, req.URL, maxItems)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Save {
+		feed := &models.SavedRSSFeed{UserID: userID, URL: req.URL, MaxItems: maxItems}
+		if err := s.feedRepo.Save(feed); err != nil {
+			return nil, err
+		}
+		if err := s.feedRepo.UpdateLastFetched(feed.ID, time.Now()); err != nil {
+			log.Printf("[RSSFeedService] Failed to record fetch of feed %s: %v", feed.ID, err)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *RSSFeedService) GetAll(userID string) ([]models.SavedRSSFeed, error) {
+	return s.feedRepo.GetAllByUserID(userID)
+}
+
+func (s *RSSFeedService) Delete(userID, feedID string) error {
+	feed, err := s.feedRepo.GetByID(feedID)
+	if err != nil {
+		return err
+	}
+	if feed == nil || feed.UserID != userID {
+		return ErrRSSFeedNotFound
+	}
+	return s.feedRepo.Delete(feedID)
+}
+
+func (s *RSSFeedService) importFeed(userID, feedURL string, maxItems int) (*models.RSSImportResult, error) {
+	items, err := s.scraperService.FetchRSS(feedURL, maxItems)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.RSSImportResult{Errors: []models.RSSImportItemError{}}
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		// Compare URLs the way MemoryService.Create will store them
+		itemURL := ExtractURLFromText(item.URL)
+		if itemURL == nil {
+			result.Failed++
+			result.Errors = append(result.Errors, models.RSSImportItemError{URL: item.URL, Error: "item link is not an http(s) URL"})
+			continue
+		}
+		if seen[*itemURL] {
+			result.Skipped++
+			continue
+		}
+		seen[*itemURL] = true
+
+		exists, err := s.memoryRepo.ExistsByURL(userID, *itemURL)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			result.Skipped++
+			continue
+		}
+
+		content := *itemURL
+		if item.Title != "" {
+			content = item.Title + "\n" + *itemURL
+		}
+		if _, err := s.memoryService.Create(userID, &models.MemoryCreateRequest{Content: content}); err != nil {
+			result.Failed++
+			result.Errors = append(result.Errors, models.RSSImportItemError{URL: *itemURL, Error: err.Error()})
+			continue
+		}
+		result.Imported++
+	}
+
+	log.Printf("[RSSFeedService] Imported feed %s for user %s: %d imported, %d skipped, %d failed",
+		feedURL, userID, result.Imported, result.Skipped, result.Failed)
+	return result, nil
+}
+
+// ImportSavedFeeds imports every saved feed. A feed that fails is logged and skipped.
+func (s *RSSFeedService) ImportSavedFeeds(ctx context.Context) error {
+	feeds, err := s.feedRepo.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to load saved feeds: %w", err)
+	}
+
+	for _, feed := range feeds {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := s.importFeed(feed.UserID, feed.URL, clampRSSItems(feed.MaxItems)); err != nil {
+			log.Printf("[RSSFeedService] Failed to import feed %s for user %s: %v", feed.URL, feed.UserID, err)
+			continue
+		}
+		if err := s.feedRepo.UpdateLastFetched(feed.ID, time.Now()); err != nil {
+			log.Printf("[RSSFeedService] Failed to record fetch of feed %s: %v", feed.ID, err)
+		}
+	}
+	return nil
+}
+
+func clampRSSItems(maxItems int) int {
+	if maxItems <= 0 {
+		return models.DefaultRSSImportItems
+	}
+	if maxItems > models.MaxRSSImportItems {
+		return models.MaxRSSImportItems
+	}
+	return maxItems
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -9,26 +11,68 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/mmcdole/gofeed"
+	"github.com/todomyday/backend/internal/models"
 	"golang.org/x/net/html"
 )
 
+// SearXNGHealthCheckInterval is how often each SearXNG instance is pinged
+const SearXNGHealthCheckInterval = 30 * time.Second
+
+// ErrSearXNGUnavailable is returned when every configured SearXNG instance is unhealthy
+var ErrSearXNGUnavailable = errors.New("all SearXNG instances unavailable")
+
+var (
+	ErrInvalidFeedURL = errors.New("feed URL must be an http(s) URL")
+	ErrInvalidFeed    = errors.New("could not parse RSS or Atom feed")
+)
+
+// maxFeedSize bounds how much of a feed document is read
+const maxFeedSize = 5 * 1024 * 1024
+
+// maxPageHeadSize bounds how much of a page is read for its metadata. Reading stops
+// at the end of the page's <head> well before this on most pages.
+const maxPageHeadSize = 256 * 1024
+
 type ScraperService struct {
 	client       *http.Client
+	healthClient *http.Client
 	searxngURLs  []string
 	currentIndex uint64
+
+	// Per-instance health, keyed by URL (values are SearXNGInstanceStatus)
+	health sync.Map
+}
+
+// SearXNGInstanceStatus is the last known health of a SearXNG instance
+type SearXNGInstanceStatus struct {
+	URL         string     `json:"url"`
+	Healthy     bool       `json:"healthy"`
+	LastChecked *time.Time `json:"last_checked"`
+	Error       string     `json:"error,omitempty"`
 }
 
 type ScrapedContent struct {
 	URL         string
 	Title       string
 	Description string
+	ImageURL    string // og:image, resolved against URL
 	Content     string
 	Error       error
 }
 
+// PageMetadata is what a page says about itself in its <head>, preferring its Open
+// Graph tags
+type PageMetadata struct {
+	Title       string
+	Description string
+	ImageURL    string // og:image, resolved against the page URL
+}
+
 type searxngResponse struct {
 	Results []struct {
 		Title   string `json:"title"`
@@ -38,36 +82,141 @@ type searxngResponse struct {
 }
 
 func NewScraperService(searxngURLs []string) *ScraperService {
-	return &ScraperService{
+	service := &ScraperService{
 		client: &http.Client{
 			Timeout: 15 * time.Second,
 		},
+		healthClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
 		searxngURLs:  searxngURLs,
 		currentIndex: 0,
 	}
+
+	// Instances are assumed healthy until the first check says otherwise
+	for _, u := range searxngURLs {
+		service.health.Store(u, SearXNGInstanceStatus{URL: u, Healthy: true})
+	}
+
+	if len(searxngURLs) > 0 {
+		go service.runHealthChecks()
+	}
+
+	return service
+}
+
+// runHealthChecks pings every instance immediately and then on each interval
+func (s *ScraperService) runHealthChecks() {
+	s.checkAllInstances()
+
+	ticker := time.NewTicker(SearXNGHealthCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.checkAllInstances()
+	}
+}
+
+func (s *ScraperService) checkAllInstances() {
+	for _, u := range s.searxngURLs {
+		s.checkInstance(u)
+	}
+}
+
+// checkInstance pings an instance's /healthz endpoint and records the result
+func (s *ScraperService) checkInstance(baseURL string) {
+	now := time.Now()
+	status := SearXNGInstanceStatus{URL: baseURL, Healthy: true, LastChecked: &now}
+
+	resp, err := s.healthClient.Get(strings.TrimSuffix(baseURL, "/") + "/healthz")
+	if err != nil {
+		status.Healthy = false
+		status.Error = err.Error()
+	} else {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			status.Healthy = false
+			status.Error = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		}
+	}
+
+	if previous, ok := s.health.Load(baseURL); ok && previous.(SearXNGInstanceStatus).Healthy != status.Healthy {
+		if status.Healthy {
+			log.Printf("[Scraper] SearXNG instance %s is healthy again", baseURL)
+		} else {
+			log.Printf("[Scraper] Warning: SearXNG instance %s is unhealthy: %s", baseURL, status.Error)
+		}
+	}
+
+	s.health.Store(baseURL, status)
 }
 
-// getNextSearXNGURL returns the next URL in round-robin fashion
-func (s *ScraperService) getNextSearXNGURL() string {
+// PingSearXNG checks the first configured instance's /healthz endpoint directly,
+// without waiting for the next background check
+func (s *ScraperService) PingSearXNG(ctx context.Context) error {
 	if len(s.searxngURLs) == 0 {
-		return ""
+		return fmt.Errorf("SearXNG not configured")
 	}
-	index := atomic.AddUint64(&s.currentIndex, 1) - 1
-	return s.searxngURLs[index%uint64(len(s.searxngURLs))]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(s.searxngURLs[0], "/")+"/healthz", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.healthClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *ScraperService) isHealthy(baseURL string) bool {
+	status, ok := s.health.Load(baseURL)
+	return !ok || status.(SearXNGInstanceStatus).Healthy
+}
+
+// HealthStatus returns the last known health of every configured instance
+func (s *ScraperService) HealthStatus() []SearXNGInstanceStatus {
+	statuses := make([]SearXNGInstanceStatus, 0, len(s.searxngURLs))
+	for _, u := range s.searxngURLs {
+		if status, ok := s.health.Load(u); ok {
+			statuses = append(statuses, status.(SearXNGInstanceStatus))
+		}
+	}
+	return statuses
+}
+
+// getNextSearXNGURL returns the next healthy URL in round-robin fashion
+func (s *ScraperService) getNextSearXNGURL() (string, error) {
+	if len(s.searxngURLs) == 0 {
+		return "", fmt.Errorf("SearXNG not configured")
+	}
+
+	count := uint64(len(s.searxngURLs))
+	for i := uint64(0); i < count; i++ {
+		index := atomic.AddUint64(&s.currentIndex, 1) - 1
+		u := s.searxngURLs[index%count]
+		if s.isHealthy(u) {
+			return u, nil
+		}
+	}
+
+	log.Printf("[Scraper] Warning: all %d SearXNG instances are unhealthy", count)
+	return "", ErrSearXNGUnavailable
 }
 
 // ScrapeURL fetches and extracts content from a URL
 func (s *ScraperService) ScrapeURL(targetURL string) (*ScrapedContent, error) {
 	log.Printf("[Scraper] Fetching URL: %s", targetURL)
 
-	req, err := http.NewRequest("GET", targetURL, nil)
+	req, err := s.newPageRequest(http.MethodGet, targetURL)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; TodoMyDay/1.0)")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-
 	resp, err := s.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -100,6 +249,7 @@ func (s *ScraperService) ScrapeURL(targetURL string) (*ScrapedContent, error) {
 	// Extract title and content
 	result.Title = extractTitle(doc)
 	result.Description = extractMetaDescription(doc)
+	result.ImageURL = resolvePageURL(targetURL, extractMetaProperty(doc, "og:image"))
 	result.Content = extractMainContent(doc)
 
 	log.Printf("[Scraper] Extracted - Title: %s, Content length: %d", result.Title, len(result.Content))
@@ -107,17 +257,237 @@ func (s *ScraperService) ScrapeURL(targetURL string) (*ScrapedContent, error) {
 	return result, nil
 }
 
+// FetchMetadata reads only the title and Open Graph tags of a page. A HEAD request
+// checks the content type first, so PDFs, images and other non-HTML URLs are never
+// downloaded; an image is its own thumbnail. For HTML pages the body is read only up
+// to the end of its <head>.
+func (s *ScraperService) FetchMetadata(targetURL string) (*PageMetadata, error) {
+	log.Printf("[Scraper] Fetching metadata: %s", targetURL)
+
+	head, err := s.newPageRequest(http.MethodHead, targetURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(head)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	// Servers that don't support HEAD are asked with a GET instead
+	if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+		}
+		if metadata, ok := nonHTMLMetadata(targetURL, resp.Header.Get("Content-Type")); ok {
+			return metadata, nil
+		}
+	}
+
+	get, err := s.newPageRequest(http.MethodGet, targetURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err = s.client.Do(get)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+	if metadata, ok := nonHTMLMetadata(targetURL, resp.Header.Get("Content-Type")); ok {
+		return metadata, nil
+	}
+
+	metadata := parseHeadMetadata(io.LimitReader(resp.Body, maxPageHeadSize))
+	metadata.ImageURL = resolvePageURL(targetURL, metadata.ImageURL)
+	return metadata, nil
+}
+
+// MetadataSummary returns a page's title and description as a URL summary, without
+// reading the page or asking the AI to summarize it; nil if the metadata couldn't be
+// fetched
+func (s *ScraperService) MetadataSummary(targetURL string) *models.URLSummary {
+	metadata, err := s.FetchMetadata(targetURL)
+	if err != nil {
+		log.Printf("[Scraper] Failed to fetch metadata of %s: %v", targetURL, err)
+		return nil
+	}
+	return &models.URLSummary{
+		Title:        metadata.Title,
+		Summary:      metadata.Description,
+		ThumbnailURL: metadata.ImageURL,
+	}
+}
+
+func (s *ScraperService) newPageRequest(method, targetURL string) (*http.Request, error) {
+	req, err := http.NewRequest(method, targetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; TodoMyDay/1.0)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	return req, nil
+}
+
+// nonHTMLMetadata returns the metadata of a URL whose content type isn't HTML, which
+// is all there is to know without downloading it; ok is false for HTML or an unknown
+// content type
+func nonHTMLMetadata(targetURL, contentType string) (metadata *PageMetadata, ok bool) {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+		return nil, false
+	}
+	metadata = &PageMetadata{}
+	if strings.HasPrefix(mediaType, "image/") {
+		metadata.ImageURL = targetURL
+	}
+	return metadata, true
+}
+
+// parseHeadMetadata reads the <title> and meta tags of an HTML document, stopping at
+// the end of its <head>
+func parseHeadMetadata(r io.Reader) *PageMetadata {
+	metadata := &PageMetadata{}
+	var title, description string
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return finishMetadata(metadata, title, description)
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" {
+				return finishMetadata(metadata, title, description)
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			switch token.Data {
+			case "body":
+				return finishMetadata(metadata, title, description)
+			case "title":
+				if title == "" && z.Next() == html.TextToken {
+					title = strings.TrimSpace(html.UnescapeString(string(z.Text())))
+				}
+			case "meta":
+				var key, content string
+				for _, attr := range token.Attr {
+					switch attr.Key {
+					case "property", "name":
+						key = strings.ToLower(attr.Val)
+					case "content":
+						content = strings.TrimSpace(attr.Val)
+					}
+				}
+				switch key {
+				case "og:title":
+					metadata.Title = content
+				case "og:description":
+					metadata.Description = content
+				case "og:image":
+					metadata.ImageURL = content
+				case "description":
+					description = content
+				}
+			}
+		}
+	}
+}
+
+// finishMetadata falls back to the page's <title> and meta description for Open Graph
+// tags it doesn't have
+func finishMetadata(metadata *PageMetadata, title, description string) *PageMetadata {
+	if metadata.Title == "" {
+		metadata.Title = title
+	}
+	if metadata.Description == "" {
+		metadata.Description = description
+	}
+	return metadata
+}
+
+// resolvePageURL resolves a URL found on the page at pageURL, such as a relative
+// og:image; empty if ref is empty or invalid
+func resolvePageURL(pageURL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	resolved, err := base.Parse(ref)
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return ""
+	}
+	return resolved.String()
+}
+
+// FetchRSS downloads an RSS or Atom feed and returns up to maxItems of its entries
+// in feed order. Entries without a link are dropped.
+func (s *ScraperService) FetchRSS(feedURL string, maxItems int) ([]models.RSSItem, error) {
+	parsed, err := url.Parse(feedURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, ErrInvalidFeedURL
+	}
+
+	log.Printf("[Scraper] Fetching feed: %s", feedURL)
+
+	req, err := http.NewRequest("GET", feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; TodoMyDay/1.0)")
+	req.Header.Set("Accept", "application/rss+xml,application/atom+xml,application/xml;q=0.9,*/*;q=0.8")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
+	}
+
+	feed, err := gofeed.NewParser().Parse(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFeed, err)
+	}
+
+	items := make([]models.RSSItem, 0, maxItems)
+	for _, item := range feed.Items {
+		if len(items) >= maxItems {
+			break
+		}
+		if item.Link == "" {
+			continue
+		}
+		items = append(items, models.RSSItem{
+			Title:       strings.TrimSpace(item.Title),
+			URL:         strings.TrimSpace(item.Link),
+			Description: item.Description,
+			PublishedAt: item.PublishedParsed,
+		})
+	}
+
+	log.Printf("[Scraper] Feed %s: %d items", feedURL, len(items))
+	return items, nil
+}
+
 // SearchWeb uses SearXNG to search the web
 func (s *ScraperService) SearchWeb(query string) ([]struct {
 	Title   string `json:"title"`
 	URL     string `json:"url"`
 	Snippet string `json:"snippet"`
 }, error) {
-	if len(s.searxngURLs) == 0 {
-		return nil, fmt.Errorf("SearXNG not configured")
+	baseURL, err := s.getNextSearXNGURL()
+	if err != nil {
+		return nil, err
 	}
 
-	baseURL := s.getNextSearXNGURL()
 	searchURL := fmt.Sprintf("%s/search?q=%s&format=json",
 		strings.TrimSuffix(baseURL, "/"),
 		url.QueryEscape(query),
@@ -226,6 +596,32 @@ func extractMetaDescription(n *html.Node) string {
 	return ""
 }
 
+// extractMetaProperty returns the content of the first <meta property="..."> tag with
+// the given property, such as an Open Graph tag
+func extractMetaProperty(n *html.Node, property string) string {
+	if n.Type == html.ElementNode && n.Data == "meta" {
+		var matches bool
+		var content string
+		for _, attr := range n.Attr {
+			if attr.Key == "property" && strings.EqualFold(attr.Val, property) {
+				matches = true
+			}
+			if attr.Key == "content" {
+				content = strings.TrimSpace(attr.Val)
+			}
+		}
+		if matches && content != "" {
+			return content
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if content := extractMetaProperty(c, property); content != "" {
+			return content
+		}
+	}
+	return ""
+}
+
 func extractMainContent(n *html.Node) string {
 	var content strings.Builder
 	extractTextContent(n, &content)
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"log"
+	"strings"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+var ErrSavedSearchNotFound = newCodedError(models.ErrCodeNotFound, "saved search not found")
+
+// SearchHistoryService records the searches users run and manages their saved searches
+type SearchHistoryService struct {
+	repo *repository.SearchHistoryRepository
+	// recordEmpty keeps searches that found nothing (SEARCH_HISTORY_EMPTY)
+	recordEmpty bool
+}
+
+func NewSearchHistoryService(repo *repository.SearchHistoryRepository, recordEmpty bool) *SearchHistoryService {
+	return &SearchHistoryService{
+		repo:        repo,
+		recordEmpty: recordEmpty,
+	}
+}
+
+// Record adds a search to the user's history in the background, so a slow or
+// failed write never holds up the search itself
+func (s *SearchHistoryService) Record(userID, query string, contentTypes []string, resultCount int) {
+	if s == nil {
+		return
+	}
+	query = strings.TrimSpace(query)
+	if query == "" || (resultCount == 0 && !s.recordEmpty) {
+		return
+	}
+
+	entry := &models.SearchHistoryEntry{
+		UserID:       userID,
+		Query:        query,
+		ContentTypes: contentTypes,
+		ResultCount:  resultCount,
+	}
+	go func() {
+		if err := s.repo.Create(entry); err != nil {
+			log.Printf("[SearchHistory] Failed to record search for user %s: %v", userID, err)
+		}
+	}()
+}
+
+// GetHistory returns the user's latest searches, newest first
+func (s *SearchHistoryService) GetHistory(userID string, limit int) ([]models.SearchHistoryEntry, error) {
+	if limit <= 0 {
+		limit = models.DefaultSearchHistoryLimit
+	}
+	if limit > models.MaxSearchHistoryLimit {
+		limit = models.MaxSearchHistoryLimit
+	}
+	return s.repo.GetByUserID(userID, limit)
+}
+
+// ClearHistory deletes the user's whole search history and returns how many entries it held
+func (s *SearchHistoryService) ClearHistory(userID string) (int64, error) {
+	return s.repo.DeleteByUserID(userID)
+}
+
+func (s *SearchHistoryService) CreateSaved(userID string, req *models.SavedSearchCreateRequest) (*models.SavedSearch, error) {
+	search := &models.SavedSearch{
+		UserID:       userID,
+		Name:         strings.TrimSpace(req.Name),
+		Query:        strings.TrimSpace(req.Query),
+		ContentTypes: req.ContentTypes,
+	}
+	if err := s.repo.CreateSaved(search); err != nil {
+		return nil, err
+	}
+	if search.ContentTypes == nil {
+		search.ContentTypes = []string{}
+	}
+	return search, nil
+}
+
+func (s *SearchHistoryService) GetSaved(userID string) ([]models.SavedSearch, error) {
+	return s.repo.GetSavedByUserID(userID)
+}
+
+// GetSavedByID returns one of the user's saved searches
+func (s *SearchHistoryService) GetSavedByID(userID, id string) (*models.SavedSearch, error) {
+	search, err := s.repo.GetSavedByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if search == nil || search.UserID != userID {
+		return nil, ErrSavedSearchNotFound
+	}
+	return search, nil
+}
+
+func (s *SearchHistoryService) DeleteSaved(userID, id string) error {
+	deleted, err := s.repo.DeleteSaved(id, userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/todomyday/backend/internal/metrics"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+const (
+	// SuggestCacheTTL is how long autocomplete results are reused
+	SuggestCacheTTL = 60 * time.Second
+	// SuggestTimeout bounds how long a suggestion query may take
+	SuggestTimeout = 50 * time.Millisecond
+	// MaxSuggestLimit caps the number of suggestions per request
+	MaxSuggestLimit = 20
+	// suggestCacheMaxEntries bounds the cache; expired entries are swept when it fills
+	suggestCacheMaxEntries = 5000
+
+	// FTSHealthCheckSchedule is the default cron schedule of the FTS consistency check
+	FTSHealthCheckSchedule = "0 4 * * *"
+	// FTSMaxDrift is how far the indexed document count may drift from the
+	// number of todos and memories, as a fraction, before the index is rebuilt
+	FTSMaxDrift = 0.05
+)
+
+// SearchService serves search-bar features backed by the FTS index
+type SearchService struct {
+	ftsRepo *repository.FTSRepository
+
+	cache *ttlCache[[]string]
+
+	healthMu   sync.RWMutex
+	lastHealth *repository.FTSHealthReport
+}
+
+func NewSearchService(ftsRepo *repository.FTSRepository) *SearchService {
+	return &SearchService{
+		ftsRepo: ftsRepo,
+		cache:   newTTLCache[[]string](metrics.CacheSuggest, SuggestCacheTTL, suggestCacheMaxEntries),
+	}
+}
+
+// Suggest returns autocomplete suggestions for a prefix. Results are cached per
+// user, prefix and filters. A query that exceeds SuggestTimeout yields no
+// suggestions rather than holding up typing in the search bar.
+func (s *SearchService) Suggest(ctx context.Context, userID, prefix string, contentTypes []string, limit int) ([]string, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" || s.ftsRepo == nil {
+		return []string{}, nil
+	}
+	if limit <= 0 {
+		limit = 5
+	}
+	if limit > MaxSuggestLimit {
+		limit = MaxSuggestLimit
+	}
+
+	key := fmt.Sprintf("suggest:%s:%s:%s:%d", userID, strings.ToLower(prefix), strings.Join(contentTypes, ","), limit)
+	if suggestions, ok := s.cache.Get(key); ok {
+		return suggestions, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, SuggestTimeout)
+	defer cancel()
+
+	suggestions, err := s.ftsRepo.Suggest(ctx, userID, prefix, contentTypes, limit)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			log.Printf("[SearchService] Suggest timed out after %v for prefix %q", SuggestTimeout, prefix)
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	s.cache.Set(key, suggestions)
+	return suggestions, nil
+}
+
+// CheckFTSHealth checks the FTS index and rebuilds it from the todos and memories
+// tables when the integrity check fails or the document count has drifted more than
+// FTSMaxDrift. The report is kept for LastFTSHealth.
+func (s *SearchService) CheckFTSHealth(ctx context.Context) (*repository.FTSHealthReport, error) {
+	if s.ftsRepo == nil {
+		return nil, fmt.Errorf("FTS is not configured")
+	}
+
+	report, err := s.ftsRepo.HealthCheck(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !report.IntegrityOK || report.Drift > FTSMaxDrift {
+		log.Printf("[SearchService] Warning: FTS index is inconsistent (integrity ok: %v %s, indexed %d, expected %d), rebuilding",
+			report.IntegrityOK, report.IntegrityError, report.IndexedCount, report.ExpectedCount)
+		if err := s.ftsRepo.PopulateFTSFromExisting(); err != nil {
+			return nil, fmt.Errorf("failed to rebuild FTS index: %w", err)
+		}
+		report.Rebuilt = true
+	}
+
+	s.healthMu.Lock()
+	s.lastHealth = report
+	s.healthMu.Unlock()
+
+	return report, nil
+}
+
+// LastFTSHealth returns the most recent FTS health check, or nil if none has run
+func (s *SearchService) LastFTSHealth() *repository.FTSHealthReport {
+	s.healthMu.RLock()
+	defer s.healthMu.RUnlock()
+	return s.lastHealth
+}
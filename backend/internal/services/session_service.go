@@ -0,0 +1,203 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/todomyday/backend/internal/metrics"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+const (
+	// SessionTouchInterval is how often a session's last_active is written; requests
+	// in between only check the in-memory record
+	SessionTouchInterval = 5 * time.Minute
+	// sessionCacheMaxEntries bounds the touch cache; stale entries are swept when it fills
+	sessionCacheMaxEntries = 10000
+)
+
+var (
+	ErrSessionNotFound = newCodedError(models.ErrCodeNotFound, "session not found")
+	// ErrSessionRevoked is returned for requests made with a revoked session's token
+	ErrSessionRevoked = errors.New("session has been revoked")
+)
+
+type sessionCacheEntry struct {
+	userID  string
+	revoked bool
+}
+
+type SessionService struct {
+	repo *repository.SessionRepository
+
+	// Sessions touched in the last SessionTouchInterval. Revoked sessions are
+	// persisted, so dropping them from the cache only costs a lookup.
+	cache *ttlCache[sessionCacheEntry]
+}
+
+func NewSessionService(repo *repository.SessionRepository) *SessionService {
+	return &SessionService{
+		repo:  repo,
+		cache: newTTLCache[sessionCacheEntry](metrics.CacheSessions, SessionTouchInterval, sessionCacheMaxEntries),
+	}
+}
+
+// Touch records a request made with a session, creating the session the first time
+// it's seen and updating last_active at most once per SessionTouchInterval. It returns
+// ErrSessionRevoked if the session has been revoked. Tokens without a session ID
+// (legacy tokens) aren't tracked.
+func (s *SessionService) Touch(userID, sessionID, ipAddress, userAgent string) error {
+	if sessionID == "" {
+		return nil
+	}
+
+	if cached, ok := s.cache.Get(sessionID); ok {
+		if cached.revoked {
+			return ErrSessionRevoked
+		}
+		return nil
+	}
+
+	err := s.repo.Touch(&models.Session{
+		ID:                sessionID,
+		UserID:            userID,
+		DeviceFingerprint: deviceFingerprint(userAgent),
+		IPAddress:         ipAddress,
+		UserAgent:         userAgent,
+	})
+	if err != nil {
+		return err
+	}
+
+	session, err := s.repo.GetByID(sessionID)
+	if err != nil {
+		return err
+	}
+	revoked := session == nil || session.RevokedAt != nil || session.UserID != userID
+
+	s.cache.Set(sessionID, sessionCacheEntry{userID: userID, revoked: revoked})
+
+	if revoked {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// GetAll returns the user's active sessions, flagging the one with currentSessionID
+func (s *SessionService) GetAll(userID, currentSessionID string) ([]models.SessionResponse, error) {
+	sessions, err := s.repo.GetActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, models.SessionResponse{
+			ID:         session.ID,
+			Device:     describeDevice(session.UserAgent),
+			IPAddress:  maskIP(session.IPAddress),
+			LastActive: session.LastActive,
+			CreatedAt:  session.CreatedAt,
+			IsCurrent:  session.ID == currentSessionID,
+		})
+	}
+	return responses, nil
+}
+
+// Revoke revokes one of the user's sessions; its tokens are rejected from the next request
+func (s *SessionService) Revoke(userID, sessionID string) error {
+	session, err := s.repo.GetByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+
+	if err := s.repo.Revoke(sessionID); err != nil {
+		return err
+	}
+
+	s.cache.Set(sessionID, sessionCacheEntry{userID: userID, revoked: true})
+	log.Printf("[SessionService] Revoked session %s for user %s", sessionID, userID)
+	return nil
+}
+
+// RevokeOthers revokes all of the user's sessions except currentSessionID and returns how many were revoked
+func (s *SessionService) RevokeOthers(userID, currentSessionID string) (int64, error) {
+	revoked, err := s.repo.RevokeAllExcept(userID, currentSessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Forget the user's other sessions so their next request re-reads the revocation
+	s.cache.DeleteFunc(func(id string, entry sessionCacheEntry) bool {
+		return entry.userID == userID && id != currentSessionID
+	})
+
+	log.Printf("[SessionService] Revoked %d other sessions for user %s", revoked, userID)
+	return revoked, nil
+}
+
+// deviceFingerprint identifies a device by a short hash of its user agent
+func deviceFingerprint(userAgent string) string {
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:8])
+}
+
+// describeDevice turns a user agent into a short label like "Chrome on macOS"
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	}
+
+	platform := "unknown OS"
+	switch {
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os x") || strings.Contains(ua, "macintosh"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	return browser + " on " + platform
+}
+
+// maskIP hides the host part of an address: the last octet of an IPv4 address
+// or everything after the /64 prefix of an IPv6 one
+func maskIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return strings.Join(strings.Split(v4.String(), ".")[:3], ".") + ".xxx"
+	}
+
+	prefix := parsed.Mask(net.CIDRMask(64, 128)).String()
+	return strings.TrimSuffix(prefix, "::") + "::xxxx"
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+const (
+	// ShareTokenBytes is the number of random bytes in a share token
+	ShareTokenBytes = 32
+	// ShareTokenPruneSchedule is the default cron schedule for deleting expired and
+	// used-up share links
+	ShareTokenPruneSchedule = "0 3 * * *"
+)
+
+var ErrShareNotFound = newCodedError(models.ErrCodeNotFound, "share link not found or expired")
+
+// ShareService creates and resolves public read-only links to single memories
+type ShareService struct {
+	shareRepo  *repository.ShareTokenRepository
+	memoryRepo *repository.MemoryRepository
+	publicURL  string
+}
+
+// NewShareService creates a share service. Links point at publicURL + "/shared/<token>".
+func NewShareService(shareRepo *repository.ShareTokenRepository, memoryRepo *repository.MemoryRepository, publicURL string) *ShareService {
+	return &ShareService{
+		shareRepo:  shareRepo,
+		memoryRepo: memoryRepo,
+		publicURL:  publicURL,
+	}
+}
+
+// Create makes a new share link for one of the user's memories
+func (s *ShareService) Create(userID, memoryID string, req *models.ShareCreateRequest) (*models.ShareLink, error) {
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil || memory.UserID != userID {
+		return nil, ErrMemoryNotFound
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	days := models.DefaultShareExpiryDays
+	if req.ExpiresInDays != nil {
+		days = max(1, min(*req.ExpiresInDays, models.MaxShareExpiryDays))
+	}
+	share := &models.ShareToken{
+		Token:     token,
+		MemoryID:  memoryID,
+		ExpiresAt: time.Now().Add(time.Duration(days) * 24 * time.Hour),
+		MaxViews:  req.MaxViews,
+	}
+	if err := s.shareRepo.Create(share); err != nil {
+		return nil, err
+	}
+
+	return &models.ShareLink{
+		URL:       s.publicURL + "/shared/" + token,
+		Token:     token,
+		ExpiresAt: share.ExpiresAt,
+		MaxViews:  share.MaxViews,
+	}, nil
+}
+
+// GetShared counts a view of a share link and returns its memory. An unknown, expired
+// or used-up token gives ErrShareNotFound.
+func (s *ShareService) GetShared(token string) (*models.SharedMemory, error) {
+	memoryID, err := s.shareRepo.RecordView(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if memoryID == "" {
+		return nil, ErrShareNotFound
+	}
+
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return nil, err
+	}
+	if memory == nil {
+		return nil, ErrShareNotFound
+	}
+	return &models.SharedMemory{
+		Content:        memory.Content,
+		Summary:        memory.Summary,
+		Category:       memory.Category,
+		URL:            memory.URL,
+		URLTitle:       memory.URLTitle,
+		URLContent:     memory.URLContent,
+		GeneratedTitle: memory.GeneratedTitle,
+		CreatedAt:      memory.CreatedAt,
+		UpdatedAt:      memory.UpdatedAt,
+	}, nil
+}
+
+// Revoke deletes every share link of one of the user's memories, returning how many
+func (s *ShareService) Revoke(userID, memoryID string) (int64, error) {
+	memory, err := s.memoryRepo.GetByID(memoryID)
+	if err != nil {
+		return 0, err
+	}
+	if memory == nil || memory.UserID != userID {
+		return 0, ErrMemoryNotFound
+	}
+	return s.shareRepo.DeleteByMemoryID(memoryID)
+}
+
+// PruneExpired deletes expired and used-up share links and returns how many were deleted
+func (s *ShareService) PruneExpired() (int64, error) {
+	pruned, err := s.shareRepo.DeleteExpired(time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune share links: %w", err)
+	}
+	if pruned > 0 {
+		log.Printf("[ShareService] Pruned %d expired share links", pruned)
+	}
+	return pruned, nil
+}
+
+// newShareToken returns ShareTokenBytes random bytes, base64url-encoded without padding
+func newShareToken() (string, error) {
+	b := make([]byte, ShareTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate share token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
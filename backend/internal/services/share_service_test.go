@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+func TestShareLinkViewCapAndExpiry(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name      string
+		maxViews  *int
+		expiresAt time.Time // overrides the link's expiry when set
+		views     int
+		wantViews int // how many of the views succeed
+	}{
+		{name: "unlimited views", views: 5, wantViews: 5},
+		{name: "single view", maxViews: intPtr(1), views: 3, wantViews: 1},
+		{name: "capped views", maxViews: intPtr(3), views: 5, wantViews: 3},
+		{name: "views under the cap", maxViews: intPtr(3), views: 2, wantViews: 2},
+		{name: "expired", expiresAt: time.Now().Add(-time.Minute), views: 1, wantViews: 0},
+		{name: "expired with views left", maxViews: intPtr(3), expiresAt: time.Now().Add(-time.Minute), views: 2, wantViews: 0},
+		{name: "expiring later", expiresAt: time.Now().Add(time.Minute), views: 2, wantViews: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			user := newTestUser(t, db, "share@example.com")
+			memoryRepo := repository.NewMemoryRepository(db)
+			memory := &models.Memory{UserID: user.ID, Content: "Door code is 4321", Category: "Home"}
+			if err := memoryRepo.Create(memory); err != nil {
+				t.Fatalf("failed to create memory: %v", err)
+			}
+			service := NewShareService(repository.NewShareTokenRepository(db), memoryRepo, "https://app.example.com")
+
+			link, err := service.Create(user.ID, memory.ID, &models.ShareCreateRequest{MaxViews: tt.maxViews})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if !tt.expiresAt.IsZero() {
+				if _, err := db.Exec("UPDATE share_tokens SET expires_at = ? WHERE token = ?", tt.expiresAt, link.Token); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			succeeded := 0
+			for i := 0; i < tt.views; i++ {
+				shared, err := service.GetShared(link.Token)
+				switch {
+				case err == nil:
+					if shared.Content != memory.Content {
+						t.Errorf("GetShared content = %q, want %q", shared.Content, memory.Content)
+					}
+					succeeded++
+				case !errors.Is(err, ErrShareNotFound):
+					t.Fatalf("GetShared: %v", err)
+				}
+			}
+			if succeeded != tt.wantViews {
+				t.Errorf("%d of %d views succeeded, want %d", succeeded, tt.views, tt.wantViews)
+			}
+
+			pruned, err := service.PruneExpired()
+			if err != nil {
+				t.Fatalf("PruneExpired: %v", err)
+			}
+			// A link that turned a view away is expired or used up
+			var wantPruned int64
+			if succeeded < tt.views {
+				wantPruned = 1
+			}
+			if pruned != wantPruned {
+				t.Errorf("PruneExpired removed %d links, want %d", pruned, wantPruned)
+			}
+		})
+	}
+}
+
+func TestShareLinkExpiryDays(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name          string
+		expiresInDays *int
+		wantDays      int
+	}{
+		{"default", nil, models.DefaultShareExpiryDays},
+		{"requested", intPtr(30), 30},
+		{"below minimum", intPtr(0), 1},
+		{"above maximum", intPtr(1000), models.MaxShareExpiryDays},
+	}
+
+	db := newTestDB(t)
+	user := newTestUser(t, db, "share@example.com")
+	memoryRepo := repository.NewMemoryRepository(db)
+	memory := &models.Memory{UserID: user.ID, Content: "Door code is 4321", Category: "Home"}
+	if err := memoryRepo.Create(memory); err != nil {
+		t.Fatalf("failed to create memory: %v", err)
+	}
+	service := NewShareService(repository.NewShareTokenRepository(db), memoryRepo, "https://app.example.com")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := time.Now()
+			link, err := service.Create(user.ID, memory.ID, &models.ShareCreateRequest{ExpiresInDays: tt.expiresInDays})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			want := before.Add(time.Duration(tt.wantDays) * 24 * time.Hour)
+			if d := link.ExpiresAt.Sub(want); d < 0 || d > time.Minute {
+				t.Errorf("ExpiresAt = %v, want about %v", link.ExpiresAt, want)
+			}
+		})
+	}
+
+	t.Run("another user's memory", func(t *testing.T) {
+		other := newTestUser(t, db, "other@example.com")
+		if _, err := service.Create(other.ID, memory.ID, &models.ShareCreateRequest{}); !errors.Is(err, ErrMemoryNotFound) {
+			t.Errorf("Create = %v, want ErrMemoryNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// StorageService stores binary objects (e.g. memory attachments) outside the database
+type StorageService interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// PresignedURL returns a URL that grants read access to key until expiry
+	PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// Delete removes the object at key; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// S3StorageService stores objects in an S3-compatible bucket (AWS S3, MinIO, R2, ...)
+type S3StorageService struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3StorageService connects to an S3-compatible endpoint. The endpoint may carry
+// an http:// or https:// scheme; without one, TLS is used.
+func NewS3StorageService(endpoint, bucket, accessKey, secretKey string) (*S3StorageService, error) {
+	secure := !strings.HasPrefix(endpoint, "http://")
+	endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "http://"), "https://")
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: secure,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+
+	return &S3StorageService{
+		client: client,
+		bucket: bucket,
+	}, nil
+}
+
+func (s *S3StorageService) Put(ctx context.Context, key, contentType string, data []byte) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	return nil
+}
+
+func (s *S3StorageService) PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object URL: %w", err)
+	}
+	return u.String(), nil
+}
+
+func (s *S3StorageService) Delete(ctx context.Context, key string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/testcontainers/testcontainers-go"
+	miniocontainer "github.com/testcontainers/testcontainers-go/modules/minio"
+)
+
+// newMinIOStorage starts a MinIO container and returns storage backed by a new bucket
+// in it. The test is skipped when Docker isn't available.
+func newMinIOStorage(t *testing.T) *S3StorageService {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("starts a MinIO container")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	ctx := context.Background()
+	container, err := miniocontainer.Run(ctx, "minio/minio:RELEASE.2024-01-16T16-07-38Z")
+	testcontainers.CleanupContainer(t, container)
+	if err != nil {
+		t.Fatalf("failed to start MinIO: %v", err)
+	}
+	endpoint, err := container.ConnectionString(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage, err := NewS3StorageService("http://"+endpoint, "attachments", container.Username, container.Password)
+	if err != nil {
+		t.Fatalf("NewS3StorageService: %v", err)
+	}
+	if err := storage.client.MakeBucket(ctx, "attachments", minio.MakeBucketOptions{}); err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+	return storage
+}
+
+func TestS3StorageServiceWithMinIO(t *testing.T) {
+	storage := newMinIOStorage(t)
+	ctx := context.Background()
+	const key = "memories/user/image"
+
+	if err := storage.Put(ctx, key, "image/png", []byte("png bytes")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	url, err := storage.PresignedURL(ctx, key, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignedURL: %v", err)
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("failed to download presigned URL: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "png bytes" || resp.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("presigned download = %d %q (%s), want 200 \"png bytes\" (image/png)", resp.StatusCode, body, resp.Header.Get("Content-Type"))
+	}
+
+	if err := storage.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := storage.client.StatObject(ctx, "attachments", key, minio.StatObjectOptions{}); minio.ToErrorResponse(err).Code != "NoSuchKey" {
+		t.Errorf("StatObject after Delete = %v, want NoSuchKey", err)
+	}
+	if err := storage.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing object = %v, want nil", err)
+	}
+}
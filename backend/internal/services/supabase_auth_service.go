@@ -12,22 +12,33 @@ import (
 	"io"
 	"math/big"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/repository"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	ErrInvalidToken = errors.New("invalid or expired token")
 	ErrTokenExpired = errors.New("token has expired")
+	// ErrInvalidPassword is returned when a confirmation password doesn't match
+	ErrInvalidPassword = errors.New("invalid password")
 )
 
+// revokedSubjectTTL outlives any Supabase access token, so a revoked
+// subject can't keep using a token issued before revocation
+const revokedSubjectTTL = 24 * time.Hour
+
 type SupabaseClaims struct {
 	Sub   string `json:"sub"` // Supabase user ID (UUID)
 	Email string `json:"email"`
 	Exp   int64  `json:"exp"`
+	// SessionID identifies the login the token was issued for; it survives token refreshes
+	SessionID string `json:"session_id"`
 	jwt.RegisteredClaims
 }
 
@@ -39,6 +50,10 @@ type SupabaseAuthService struct {
 	anonKey         string // For verifying user tokens
 	serviceRoleKey  string
 	publicKey       *ecdsa.PublicKey // For ES256 verification
+
+	// Supabase subjects whose tokens must be rejected (e.g. deleted accounts)
+	revokedMu       sync.Mutex
+	revokedSubjects map[string]time.Time
 }
 
 func NewSupabaseAuthService(
@@ -78,6 +93,7 @@ func NewSupabaseAuthService(
 		anonKey:         anonKey,
 		serviceRoleKey:  serviceRoleKey,
 		publicKey:       publicKey,
+		revokedSubjects: make(map[string]time.Time),
 	}
 
 	if publicKey != nil {
@@ -373,3 +389,106 @@ func (s *SupabaseAuthService) SyncUserFromToken(claims *SupabaseClaims) (*models
 	// Fetch the created user
 	return s.userRepo.GetBySupabaseID(supabaseID)
 }
+
+// VerifyPassword checks a confirmation password for a user. Legacy users with a
+// local password hash are checked with bcrypt; Supabase users are checked by
+// attempting a password sign-in against Supabase.
+func (s *SupabaseAuthService) VerifyPassword(user *models.User, password string) error {
+	if user.PasswordHash != nil && *user.PasswordHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(password)); err != nil {
+			return ErrInvalidPassword
+		}
+		return nil
+	}
+
+	if s.supabaseURL == "" || s.anonKey == "" {
+		return fmt.Errorf("Supabase is not configured")
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"email":    user.Email,
+		"password": password,
+	})
+	if err != nil {
+		return err
+	}
+
+	tokenURL := fmt.Sprintf("%s/auth/v1/token?grant_type=password", s.supabaseURL)
+	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("apikey", s.anonKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to verify password with Supabase: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return ErrInvalidPassword
+	}
+
+	respBody, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("Supabase password check failed (status %d): %s", resp.StatusCode, string(respBody))
+}
+
+// DeleteSupabaseUser deletes the user from Supabase Auth, which also removes
+// their sessions and refresh tokens. Requires the service role key.
+func (s *SupabaseAuthService) DeleteSupabaseUser(supabaseID string) error {
+	if s.supabaseURL == "" || s.serviceRoleKey == "" {
+		return fmt.Errorf("Supabase service role key is not configured")
+	}
+
+	adminURL := fmt.Sprintf("%s/auth/v1/admin/users/%s", s.supabaseURL, supabaseID)
+	req, err := http.NewRequest("DELETE", adminURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("apikey", s.serviceRoleKey)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.serviceRoleKey))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete Supabase user: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Already gone is fine
+	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	return fmt.Errorf("Supabase rejected user deletion (status %d): %s", resp.StatusCode, string(body))
+}
+
+// RevokeSubject rejects all tokens for a Supabase subject until they have expired
+func (s *SupabaseAuthService) RevokeSubject(supabaseID string) {
+	s.revokedMu.Lock()
+	defer s.revokedMu.Unlock()
+
+	now := time.Now()
+	for sub, until := range s.revokedSubjects {
+		if now.After(until) {
+			delete(s.revokedSubjects, sub)
+		}
+	}
+	s.revokedSubjects[supabaseID] = now.Add(revokedSubjectTTL)
+}
+
+// IsSubjectRevoked reports whether tokens for a Supabase subject have been revoked
+func (s *SupabaseAuthService) IsSubjectRevoked(supabaseID string) bool {
+	s.revokedMu.Lock()
+	defer s.revokedMu.Unlock()
+
+	until, ok := s.revokedSubjects[supabaseID]
+	return ok && time.Now().Before(until)
+}
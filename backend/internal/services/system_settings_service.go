@@ -0,0 +1,145 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// AllowedOriginsPollInterval is how often the server reloads the allowed origins,
+// so changes made by another instance are picked up without a restart
+const AllowedOriginsPollInterval = 60 * time.Second
+
+var (
+	ErrNoAllowedOrigins = errors.New("at least one origin is required")
+	ErrInvalidOrigin    = errors.New("origins must be http(s)://host[:port] with no path")
+	// ErrInvalidEmbeddingEnsemble is returned for an embedding ensemble with an unknown
+	// mode, a negative weight or a provider listed twice
+	ErrInvalidEmbeddingEnsemble = errors.New("invalid embedding ensemble")
+)
+
+type SystemSettingsService struct {
+	repo *repository.SystemSettingsRepository
+}
+
+func NewSystemSettingsService(repo *repository.SystemSettingsRepository) *SystemSettingsService {
+	return &SystemSettingsService{repo: repo}
+}
+
+// GetAllowedOrigins returns the stored CORS allowed origins, or nil if they have
+// never been set and ALLOWED_ORIGINS applies
+func (s *SystemSettingsService) GetAllowedOrigins() ([]string, error) {
+	value, err := s.repo.Get(models.SettingAllowedOrigins)
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	var origins []string
+	if err := json.Unmarshal([]byte(*value), &origins); err != nil {
+		return nil, fmt.Errorf("invalid stored allowed origins: %w", err)
+	}
+	return origins, nil
+}
+
+// DatabasePragmas returns the SQLite PRAGMAs the database connections are running with
+func (s *SystemSettingsService) DatabasePragmas() (*models.DatabasePragmas, error) {
+	return s.repo.GetPragmas()
+}
+
+// SetAllowedOrigins validates and stores the CORS allowed origins, returning the
+// normalized list (trailing slashes dropped, duplicates removed)
+func (s *SystemSettingsService) SetAllowedOrigins(origins []string) ([]string, error) {
+	normalized := make([]string, 0, len(origins))
+	seen := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin == "" || seen[origin] {
+			continue
+		}
+		if err := validateOrigin(origin); err != nil {
+			return nil, err
+		}
+		seen[origin] = true
+		normalized = append(normalized, origin)
+	}
+	if len(normalized) == 0 {
+		return nil, ErrNoAllowedOrigins
+	}
+
+	value, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Set(models.SettingAllowedOrigins, string(value)); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+func validateOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidOrigin, origin)
+	}
+	return nil
+}
+
+// GetEmbeddingEnsemble returns the stored embedding ensemble, or nil if none has been set
+func (s *SystemSettingsService) GetEmbeddingEnsemble() (*models.EmbeddingEnsembleConfig, error) {
+	value, err := s.repo.Get(models.SettingEmbeddingEnsemble)
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	var config models.EmbeddingEnsembleConfig
+	if err := json.Unmarshal([]byte(*value), &config); err != nil {
+		return nil, fmt.Errorf("invalid stored embedding ensemble: %w", err)
+	}
+	return &config, nil
+}
+
+// SetEmbeddingEnsemble validates and stores the embedding ensemble, filling in the
+// default mode and weights. It is read at startup, so changes apply after a restart.
+func (s *SystemSettingsService) SetEmbeddingEnsemble(config *models.EmbeddingEnsembleConfig) (*models.EmbeddingEnsembleConfig, error) {
+	switch config.Mode {
+	case "":
+		config.Mode = models.EnsembleModeConcat
+	case models.EnsembleModeConcat, models.EnsembleModeAverage:
+	default:
+		return nil, fmt.Errorf("%w: unknown mode %q", ErrInvalidEmbeddingEnsemble, config.Mode)
+	}
+	if len(config.Providers) == 0 {
+		return nil, fmt.Errorf("%w: at least one provider is required", ErrInvalidEmbeddingEnsemble)
+	}
+
+	seen := make(map[string]bool, len(config.Providers))
+	for i := range config.Providers {
+		provider := &config.Providers[i]
+		if provider.ProviderID == "" || seen[provider.ProviderID] {
+			return nil, fmt.Errorf("%w: provider %q is empty or listed twice", ErrInvalidEmbeddingEnsemble, provider.ProviderID)
+		}
+		if provider.Weight < 0 {
+			return nil, fmt.Errorf("%w: provider %q has a negative weight", ErrInvalidEmbeddingEnsemble, provider.ProviderID)
+		}
+		if provider.Weight == 0 {
+			provider.Weight = 1
+		}
+		seen[provider.ProviderID] = true
+	}
+
+	value, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Set(models.SettingEmbeddingEnsemble, string(value)); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
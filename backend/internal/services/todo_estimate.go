@@ -0,0 +1,93 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/todomyday/backend/internal/metrics"
+	"github.com/todomyday/backend/internal/models"
+)
+
+// EstimateTodoEffort asks the AI how much effort a todo is, as Fibonacci story points
+// and a rough duration. An answer off the story point scale, or an empty duration,
+// is left nil rather than treated as an error.
+func EstimateTodoEffort(title string, config *AIProviderConfig) (*models.EffortEstimate, error) {
+	if config == nil || config.BaseURL == "" || config.APIKey == "" || config.Model == "" {
+		return nil, fmt.Errorf("AI not configured")
+	}
+
+	prompt := fmt.Sprintf(`You are a project planning assistant. Estimate the effort of this task.
+
+Task: "%s"
+
+INSTRUCTIONS:
+1. story_points: Pick ONE of 1, 2, 3, 5, 8, 13 (1 = trivial, 13 = several days of work)
+2. estimated_duration: A short human duration such as "~30 minutes", "~2 hours" or "~1 day"
+
+Respond with ONLY valid JSON (no markdown, no code blocks, no explanation):
+{"story_points": 3, "estimated_duration": "~2 hours"}`, title)
+
+	content, err := callProviderForJSON(config, prompt, effortResponseSchema, metrics.AIResponseEffort)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseEffortEstimate(content)
+}
+
+// parseEffortEstimate decodes an effort response that has already passed schema validation
+func parseEffortEstimate(content []byte) (*models.EffortEstimate, error) {
+	var raw struct {
+		StoryPoints       interface{} `json:"story_points"`
+		EstimatedDuration *string     `json:"estimated_duration"`
+	}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse effort estimate: %w", err)
+	}
+
+	estimate := &models.EffortEstimate{StoryPoints: storyPoints(raw.StoryPoints)}
+	if estimate.StoryPoints == nil {
+		log.Printf("[AI] Ignoring non-standard story points: %v", raw.StoryPoints)
+	}
+	if raw.EstimatedDuration != nil {
+		if duration := strings.TrimSpace(*raw.EstimatedDuration); duration != "" {
+			estimate.EstimatedDuration = &duration
+		}
+	}
+	return estimate, nil
+}
+
+// storyPoints returns the story points in an AI answer (a number, or a string like "5")
+// if they're on the scale, otherwise nil
+func storyPoints(value interface{}) *int {
+	var points int
+	switch v := value.(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return nil
+		}
+		points = int(v)
+	case string:
+		parsed, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil
+		}
+		points = parsed
+	default:
+		return nil
+	}
+
+	if !IsValidStoryPoints(points) {
+		return nil
+	}
+	return &points
+}
+
+// IsValidStoryPoints reports whether points is on the Fibonacci story point scale
+func IsValidStoryPoints(points int) bool {
+	return slices.Contains(models.StoryPointValues, points)
+}
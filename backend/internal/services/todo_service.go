@@ -2,82 +2,110 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/todomyday/backend/internal/metrics"
 	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/repository"
 )
 
+// GroupTagPrefix marks tags generated from a todo's group, distinguishing them from user tags
+const GroupTagPrefix = "group:"
+
+const (
+	// TodoFilterCacheTTL is how long a filtered todo list is reused
+	TodoFilterCacheTTL = 30 * time.Second
+	// todoFilterCacheMaxEntries bounds the cache; expired entries are swept when it fills
+	todoFilterCacheMaxEntries = 5000
+)
+
+var (
+	ErrTooManyTodoIDs    = fmt.Errorf("at most %d todos can be updated at once", models.MaxBulkTodoIDs)
+	ErrTodosNotFound     = newCodedError(models.ErrCodeNotFound, "one or more todos not found")
+	ErrInvalidTodoFilter = errors.New("invalid todo filter")
+	ErrTodoNotFound      = newCodedError(models.ErrCodeNotFound, "todo not found")
+	// ErrInvalidStoryPoints is returned when a manual estimate isn't on the story point scale
+	ErrInvalidStoryPoints = fmt.Errorf("story points must be one of %v", models.StoryPointValues)
+	ErrDependencyCycle    = errors.New("dependency would create a cycle")
+	ErrDependencyTooDeep  = fmt.Errorf("dependency chain is longer than %d todos", MaxDependencyDepth)
+	ErrDependencyNotFound = newCodedError(models.ErrCodeNotFound, "dependency not found")
+)
+
+// MaxDependencyDepth bounds how far AddDependency follows a dependency chain looking for cycles
+const MaxDependencyDepth = 10
+
 type TodoService struct {
-	todoRepo          *repository.TodoRepository
-	aiService         *AIService
-	aiProviderService *AIProviderService
-	ragService        *RAGService
+	todoRepo              *repository.TodoRepository
+	groupRepo             *repository.GroupRepository
+	userRepo              *repository.UserRepository
+	aiService             *AIService
+	aiProviderService     *AIProviderService
+	ragService            *RAGService
+	promptTemplateService *PromptTemplateService
+	auditService          *AuditService
+	preferences           *UserPreferencesService
+	briefings             *DailyBriefingService
+
+	// Filtered todo lists keyed by todoFilterCacheKey. Writes through this service
+	// clear the user's entries; other writers are picked up once entries expire.
+	filterCache *ttlCache[[]models.Todo]
 }
 
-func NewTodoService(todoRepo *repository.TodoRepository, aiService *AIService, aiProviderService *AIProviderService, ragService *RAGService) *TodoService {
+func NewTodoService(todoRepo *repository.TodoRepository, groupRepo *repository.GroupRepository, userRepo *repository.UserRepository, aiService *AIService, aiProviderService *AIProviderService, ragService *RAGService, promptTemplateService *PromptTemplateService, auditService *AuditService, preferences *UserPreferencesService) *TodoService {
 	return &TodoService{
-		todoRepo:          todoRepo,
-		aiService:         aiService,
-		aiProviderService: aiProviderService,
-		ragService:        ragService,
+		todoRepo:              todoRepo,
+		groupRepo:             groupRepo,
+		userRepo:              userRepo,
+		aiService:             aiService,
+		aiProviderService:     aiProviderService,
+		ragService:            ragService,
+		promptTemplateService: promptTemplateService,
+		auditService:          auditService,
+		preferences:           preferences,
+		filterCache:           newTTLCache[[]models.Todo](metrics.CacheTodos, TodoFilterCacheTTL, todoFilterCacheMaxEntries),
 	}
 }
 
+// SetDailyBriefingService makes changing todos invalidate the user's cached daily briefing
+func (s *TodoService) SetDailyBriefingService(briefings *DailyBriefingService) {
+	s.briefings = briefings
+}
+
 func (s *TodoService) Create(userID string, req *models.TodoCreateRequest) (*models.Todo, error) {
+	if err := checkTodoGroup(s.groupRepo, userID, req.GroupID); err != nil {
+		return nil, err
+	}
+
 	// Get max position for ordering
 	maxPos, err := s.todoRepo.GetMaxPosition(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Process with AI if available
-	var aiResult *AIProcessedTodo
-	aiProcessed := false
-
-	// First, try to use user's configured AI provider
-	if s.aiProviderService != nil {
-		provider, err := s.aiProviderService.GetDefaultByUserID(userID)
-		if err == nil && provider != nil && provider.SelectedModel != nil {
-			// Get decrypted API key
-			apiKey, err := s.aiProviderService.GetDecryptedAPIKey(provider)
-			if err == nil {
-				config := &AIProviderConfig{
-					ProviderType: provider.ProviderType,
-					BaseURL:      provider.BaseURL,
-					APIKey:       apiKey,
-					Model:        *provider.SelectedModel,
-				}
-				result, err := ProcessTodoWithProvider(req.Title, config)
-				if err == nil && result != nil {
-					aiResult = result
-					aiProcessed = true
-				}
+	// The frontend parses dates itself; for other API clients, pull a
+	// natural language date out of the title in the user's timezone
+	input := req.Title
+	dueDate := req.DueDate
+	if dueDate == nil {
+		if parsed, cleaned, err := ParseDueDate(req.Title, s.userTimezone(userID)); err != nil {
+			log.Printf("[TodoService] Date parsing skipped: %v", err)
+		} else if parsed != nil {
+			dueDate = parsed
+			if cleaned != "" {
+				input = cleaned
 			}
 		}
 	}
 
-	// Fall back to default AI service from env if user provider didn't work
-	if !aiProcessed && s.aiService != nil && s.aiService.IsConfigured() {
-		result, err := s.aiService.ProcessTodo(req.Title)
-		if err == nil && result != nil {
-			aiResult = result
-			aiProcessed = true
-		}
-	}
-
-	// Use AI results or fall back to original input
-	// Note: Date is parsed by frontend, AI only handles title cleanup and tags
-	title := req.Title
-	tags := []string{}
-	dueDate := req.DueDate // Frontend is source of truth for dates
-
-	if aiProcessed && aiResult != nil {
-		title = aiResult.Title
-		tags = aiResult.Tags
-	}
+	title, tags := s.processTitle(userID, input)
+	tags = s.withGroupTag(tags, req.GroupID)
 
 	if dueDate != nil {
 		log.Printf("[TodoService] Creating todo - title: %q, tags: %v, dueDate: %q", title, tags, *dueDate)
@@ -96,9 +124,19 @@ func (s *TodoService) Create(userID string, req *models.TodoCreateRequest) (*mod
 		Tags:        tags,
 	}
 
+	if req.EstimateEffort {
+		if estimate := s.estimateEffort(userID, title); estimate != nil {
+			todo.StoryPoints = estimate.StoryPoints
+			todo.EstimatedDuration = estimate.EstimatedDuration
+		}
+	}
+
 	if err := s.todoRepo.Create(todo); err != nil {
 		return nil, err
 	}
+	metrics.TodosCreatedTotal.Inc()
+	s.invalidateTodoCache(userID)
+	s.briefings.Invalidate(userID)
 
 	// Async RAG indexing - fire and forget
 	if s.ragService != nil && s.ragService.IsConfigured() {
@@ -107,6 +145,7 @@ func (s *TodoService) Create(userID string, req *models.TodoCreateRequest) (*mod
 			defer cancel()
 			if err := s.ragService.IndexTodo(ctx, t); err != nil {
 				log.Printf("[TodoService] Failed to index todo %s: %v", t.ID, err)
+				s.ragService.QueueIndexRetry(models.ContentTypeTodo, t.ID, t.UserID, err)
 			}
 		}(todo)
 	}
@@ -114,8 +153,285 @@ func (s *TodoService) Create(userID string, req *models.TodoCreateRequest) (*mod
 	return todo, nil
 }
 
-func (s *TodoService) GetAll(userID string) ([]models.Todo, error) {
-	return s.todoRepo.GetAllByUserID(userID)
+// createBatch stores todos in one transaction, positioned after the user's
+// existing todos in the given order, and indexes them for RAG
+func (s *TodoService) createBatch(userID string, todos []*models.Todo) error {
+	maxPos, err := s.todoRepo.GetMaxPosition(userID)
+	if err != nil {
+		return err
+	}
+	for i, todo := range todos {
+		todo.UserID = userID
+		todo.Position = fmt.Sprintf("%d", maxPos+1000*(i+1))
+	}
+
+	if err := s.todoRepo.CreateBatch(todos); err != nil {
+		return err
+	}
+	metrics.TodosCreatedTotal.Add(float64(len(todos)))
+	s.invalidateTodoCache(userID)
+	s.briefings.Invalidate(userID)
+
+	// Async RAG indexing - fire and forget
+	if s.ragService != nil && s.ragService.IsConfigured() {
+		go func(todos []*models.Todo) {
+			for _, t := range todos {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				if err := s.ragService.IndexTodo(ctx, t); err != nil {
+					log.Printf("[TodoService] Failed to index todo %s: %v", t.ID, err)
+					s.ragService.QueueIndexRetry(models.ContentTypeTodo, t.ID, t.UserID, err)
+				}
+				cancel()
+			}
+		}(todos)
+	}
+
+	return nil
+}
+
+// estimateEffort asks the user's AI provider, or the env-configured AI service, to
+// estimate a todo. It returns nil if no AI is available or the estimate fails.
+func (s *TodoService) estimateEffort(userID, title string) *models.EffortEstimate {
+	config := s.getAIConfig(userID)
+	if config == nil {
+		return nil
+	}
+
+	estimate, err := EstimateTodoEffort(title, config)
+	if err != nil {
+		log.Printf("[TodoService] Effort estimate failed for %q: %v", title, err)
+		return nil
+	}
+	return estimate
+}
+
+// SetEstimate replaces a todo's effort estimate with a manual one
+func (s *TodoService) SetEstimate(userID, todoID string, req *models.TodoEstimateRequest) (*models.Todo, error) {
+	todo, err := s.todoRepo.GetByID(todoID)
+	if err != nil {
+		return nil, err
+	}
+	if todo == nil || todo.UserID != userID {
+		return nil, ErrTodoNotFound
+	}
+	if req.StoryPoints != nil && !IsValidStoryPoints(*req.StoryPoints) {
+		return nil, ErrInvalidStoryPoints
+	}
+
+	var duration *string
+	if req.EstimatedDuration != nil {
+		if trimmed := strings.TrimSpace(*req.EstimatedDuration); trimmed != "" {
+			duration = &trimmed
+		}
+	}
+
+	if err := s.todoRepo.Update(todoID, map[string]interface{}{
+		"story_points":       req.StoryPoints,
+		"estimated_duration": duration,
+	}); err != nil {
+		return nil, err
+	}
+	s.invalidateTodoCache(userID)
+
+	return s.todoRepo.GetByID(todoID)
+}
+
+// getAIConfig returns the AI provider configuration for a user
+func (s *TodoService) getAIConfig(userID string) *AIProviderConfig {
+	// Try user's configured provider first
+	if s.aiProviderService != nil {
+		provider, err := s.aiProviderService.GetDefaultByUserID(userID)
+		if err == nil && provider != nil && provider.SelectedModel != nil {
+			config, err := s.aiProviderService.ProviderConfig(provider, userID)
+			if err == nil {
+				return config
+			}
+		}
+	}
+
+	// Fall back to default AI service
+	if s.aiService != nil && s.aiService.IsConfigured() {
+		return &AIProviderConfig{
+			ProviderType: models.ProviderTypeOpenAI,
+			BaseURL:      s.aiService.baseURL,
+			APIKey:       s.aiService.apiKey,
+			Model:        s.aiService.model,
+			PIIScrubber:  s.aiService.piiScrubber,
+		}
+	}
+
+	return nil
+}
+
+// processTitle cleans up a todo title and suggests tags using the user's AI
+// providers in fallback order, then the env-configured AI service and then the raw input
+func (s *TodoService) processTitle(userID, input string) (string, []string) {
+	// Users who turned AI processing off keep their titles as typed
+	if !s.preferences.AIProcessTodos(userID) {
+		return input, []string{}
+	}
+
+	// Process with AI if available
+	var aiResult *AIProcessedTodo
+	aiProcessed := false
+
+	// First, try the user's configured AI providers
+	if s.aiProviderService != nil {
+		_, err := s.aiProviderService.WithFallback(userID, func(config *AIProviderConfig) error {
+			config.DetectLanguage = true
+			result, err := ProcessTodoWithProvider(input, config, s.promptTemplateService, userID)
+			if err == nil {
+				aiResult = result
+			}
+			return err
+		})
+		aiProcessed = err == nil && aiResult != nil
+	}
+
+	// Fall back to default AI service from env if user provider didn't work
+	if !aiProcessed && s.aiService != nil && s.aiService.IsConfigured() {
+		result, err := s.aiService.ProcessTodo(input)
+		if err == nil && result != nil {
+			aiResult = result
+			aiProcessed = true
+		}
+	}
+
+	// Use AI results or fall back to original input
+	// Note: AI only handles title cleanup and tags, never dates
+	if aiProcessed && aiResult != nil {
+		return aiResult.Title, aiResult.Tags
+	}
+	return input, []string{}
+}
+
+// userTimezone returns the user's configured timezone, defaulting to UTC
+func (s *TodoService) userTimezone(userID string) string {
+	if s.userRepo == nil {
+		return "UTC"
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil || user == nil || user.Timezone == "" {
+		return "UTC"
+	}
+	return user.Timezone
+}
+
+// GetPage returns a page of the user's todos, newest first, after the cursor token (the
+// first page if it's empty)
+func (s *TodoService) GetPage(userID, after string, limit int, includeArchivedGroups bool) (*models.TodoPage, error) {
+	cursor, err := DecodePageCursor(after)
+	if err != nil {
+		return nil, err
+	}
+	limit = clampPageLimit(limit)
+
+	// One extra row tells whether there is a next page
+	todos, err := s.todoRepo.GetAfterCursor(userID, cursor, limit+1, includeArchivedGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TodoPage{Todos: todos}
+	if len(todos) > limit {
+		page.Todos = todos[:limit]
+		page.HasMore = true
+		last := page.Todos[limit-1]
+		page.NextCursor = EncodePageCursor(last.CreatedAt, last.ID)
+	}
+	if page.Todos == nil {
+		page.Todos = []models.Todo{}
+	}
+	return page, nil
+}
+
+// GetAll returns the user's todos, narrowed by the filter when it sets tags, status,
+// priority or group and ordered by its sort key. Filtered or sorted results are
+// cached for TodoFilterCacheTTL.
+func (s *TodoService) GetAll(userID string, filter *models.TodoFilterRequest) ([]models.Todo, error) {
+	if filter.TagOp == "" {
+		filter.TagOp = models.TagOpOr
+	}
+	filter.TagOp = strings.ToUpper(filter.TagOp)
+	if filter.TagOp != models.TagOpAnd && filter.TagOp != models.TagOpOr {
+		return nil, fmt.Errorf("%w: tag_op must be %s or %s", ErrInvalidTodoFilter, models.TagOpAnd, models.TagOpOr)
+	}
+	switch filter.Status {
+	case "", models.StatusPending, models.StatusCompleted:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidTodoFilter, filter.Status)
+	}
+	switch filter.Priority {
+	case "", models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+	default:
+		return nil, fmt.Errorf("%w: unknown priority %q", ErrInvalidTodoFilter, filter.Priority)
+	}
+	if filter.Sort == "" {
+		filter.Sort = models.TodoSortPosition
+	}
+	if !repository.IsTodoSortKey(filter.Sort) {
+		return nil, fmt.Errorf("%w: sort must be one of %s, %s, %s, %s or %s", ErrInvalidTodoFilter,
+			models.TodoSortPosition, models.TodoSortDueDate, models.TodoSortCreatedAt, models.TodoSortPriority, models.TodoSortTitle)
+	}
+	filter.Order = strings.ToLower(filter.Order)
+	switch filter.Order {
+	case "":
+		filter.Order = models.SortOrderAsc
+	case models.SortOrderAsc, models.SortOrderDesc:
+	default:
+		return nil, fmt.Errorf("%w: order must be %s or %s", ErrInvalidTodoFilter, models.SortOrderAsc, models.SortOrderDesc)
+	}
+	filter.Tags = normalizeFilterTags(filter.Tags)
+
+	if len(filter.Tags) == 0 && filter.Status == "" && filter.Priority == "" && filter.GroupID == "" &&
+		filter.Sort == models.TodoSortPosition && filter.Order == models.SortOrderAsc {
+		return s.todoRepo.GetAllByUserID(userID, filter.IncludeArchivedGroups)
+	}
+
+	key := todoFilterCacheKey(userID, filter)
+	if todos, ok := s.filterCache.Get(key); ok {
+		return todos, nil
+	}
+
+	todos, err := s.todoRepo.GetFiltered(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	s.filterCache.Set(key, todos)
+	return todos, nil
+}
+
+// normalizeFilterTags lowercases, trims and de-duplicates filter tags, keeping their order
+func normalizeFilterTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// todoFilterCacheKey returns "todos:<userID>:<hash>", where the hash covers every
+// filter field with tags sorted so their order doesn't matter
+func todoFilterCacheKey(userID string, filter *models.TodoFilterRequest) string {
+	tags := append([]string(nil), filter.Tags...)
+	sort.Strings(tags)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%s|%s|%s|%t|%s|%s", strings.Join(tags, ","), filter.TagOp, filter.Status, filter.Priority,
+		filter.GroupID, filter.IncludeArchivedGroups, filter.Sort, filter.Order)
+	return fmt.Sprintf("todos:%s:%x", userID, h.Sum(nil)[:8])
+}
+
+// invalidateTodoCache drops the user's cached filtered lists after their todos change
+func (s *TodoService) invalidateTodoCache(userID string) {
+	s.filterCache.DeletePrefix("todos:" + userID + ":")
 }
 
 func (s *TodoService) GetByID(userID, todoID string) (*models.Todo, error) {
@@ -126,6 +442,11 @@ func (s *TodoService) GetByID(userID, todoID string) (*models.Todo, error) {
 	if todo == nil || todo.UserID != userID {
 		return nil, nil
 	}
+
+	todo.Dependencies, err = s.todoRepo.GetBlockerIDs(todoID)
+	if err != nil {
+		return nil, err
+	}
 	return todo, nil
 }
 
@@ -136,7 +457,13 @@ func (s *TodoService) Update(userID, todoID string, req *models.TodoUpdateReques
 		return nil, err
 	}
 	if todo == nil || todo.UserID != userID {
-		return nil, fmt.Errorf("todo not found")
+		return nil, ErrTodoNotFound
+	}
+	if err := checkTodoGroup(s.groupRepo, userID, req.GroupID); err != nil {
+		return nil, err
+	}
+	if err := s.checkManualPriority(userID, todo, req); err != nil {
+		return nil, err
 	}
 
 	updates := make(map[string]interface{})
@@ -150,8 +477,13 @@ func (s *TodoService) Update(userID, todoID string, req *models.TodoUpdateReques
 	if req.DueDate != nil {
 		updates["due_date"] = *req.DueDate
 	}
-	if req.Priority != nil {
+	if req.Priority != nil && *req.Priority != todo.Priority {
+		// A priority set by hand is the one to keep when auto-priority is turned off
 		updates["priority"] = *req.Priority
+		updates["original_priority"] = nil
+	}
+	if req.PriorityLockedUntil != nil {
+		updates["priority_locked_until"] = *req.PriorityLockedUntil
 	}
 	if req.Status != nil {
 		updates["status"] = *req.Status
@@ -166,10 +498,25 @@ func (s *TodoService) Update(userID, todoID string, req *models.TodoUpdateReques
 		updates["tags"] = req.Tags
 	}
 
+	// Keep the group tag in sync when the group or the tags change
+	if req.GroupID != nil || req.Tags != nil {
+		tags := todo.Tags
+		if req.Tags != nil {
+			tags = req.Tags
+		}
+		groupID := todo.GroupID
+		if req.GroupID != nil {
+			groupID = req.GroupID
+		}
+		updates["tags"] = s.withGroupTag(tags, groupID)
+	}
+
 	if len(updates) > 0 {
 		if err := s.todoRepo.Update(todoID, updates); err != nil {
 			return nil, err
 		}
+		s.invalidateTodoCache(userID)
+		s.briefings.Invalidate(userID)
 	}
 
 	updatedTodo, err := s.todoRepo.GetByID(todoID)
@@ -177,6 +524,11 @@ func (s *TodoService) Update(userID, todoID string, req *models.TodoUpdateReques
 		return nil, err
 	}
 
+	if todo.Status != models.StatusCompleted && req.Status != nil && *req.Status == models.StatusCompleted {
+		s.recordCompletion(userID)
+		s.emitUnblocked(userID, todo)
+	}
+
 	// Async RAG indexing - fire and forget
 	if s.ragService != nil && s.ragService.IsConfigured() && updatedTodo != nil {
 		go func(t *models.Todo) {
@@ -184,6 +536,7 @@ func (s *TodoService) Update(userID, todoID string, req *models.TodoUpdateReques
 			defer cancel()
 			if err := s.ragService.IndexTodo(ctx, t); err != nil {
 				log.Printf("[TodoService] Failed to re-index todo %s: %v", t.ID, err)
+				s.ragService.QueueIndexRetry(models.ContentTypeTodo, t.ID, t.UserID, err)
 			}
 		}(updatedTodo)
 	}
@@ -191,14 +544,14 @@ func (s *TodoService) Update(userID, todoID string, req *models.TodoUpdateReques
 	return updatedTodo, nil
 }
 
-func (s *TodoService) Delete(userID, todoID string) error {
+func (s *TodoService) Delete(userID, todoID, ipAddress string) error {
 	// Verify ownership
 	todo, err := s.todoRepo.GetByID(todoID)
 	if err != nil {
 		return err
 	}
 	if todo == nil || todo.UserID != userID {
-		return fmt.Errorf("todo not found")
+		return ErrTodoNotFound
 	}
 
 	// Async RAG deletion - fire and forget
@@ -212,7 +565,305 @@ func (s *TodoService) Delete(userID, todoID string) error {
 		}(todoID)
 	}
 
-	return s.todoRepo.Delete(todoID)
+	err = s.todoRepo.Delete(todoID)
+	s.invalidateTodoCache(userID)
+	s.briefings.Invalidate(userID)
+
+	// Audit regardless of outcome so failed deletions are visible too
+	s.auditService.Log(userID, models.AuditActionTodoDeleted, map[string]string{
+		"todo_id": todoID,
+		"title":   todo.Title,
+		"success": strconv.FormatBool(err == nil),
+	}, ipAddress)
+
+	return err
+}
+
+// AddDependency marks blockedID as blocked by blockerID. Both todos must belong to the
+// user, and the dependency must not close a cycle: blockedID can't already block
+// blockerID, directly or through a chain of up to MaxDependencyDepth todos.
+func (s *TodoService) AddDependency(userID, blockerID, blockedID string) error {
+	if blockerID == blockedID {
+		return ErrDependencyCycle
+	}
+	for _, id := range []string{blockerID, blockedID} {
+		if err := s.checkOwned(userID, id); err != nil {
+			return err
+		}
+	}
+
+	cycle, err := s.blocksTransitively(blockedID, blockerID, 1, map[string]bool{})
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return ErrDependencyCycle
+	}
+
+	return s.todoRepo.AddDependency(blockerID, blockedID)
+}
+
+// blocksTransitively reports whether from blocks target through existing dependencies,
+// searching depth-first and giving up with ErrDependencyTooDeep past MaxDependencyDepth
+func (s *TodoService) blocksTransitively(from, target string, depth int, visited map[string]bool) (bool, error) {
+	if depth > MaxDependencyDepth {
+		return false, ErrDependencyTooDeep
+	}
+	visited[from] = true
+
+	blocked, err := s.todoRepo.GetBlockedIDs(from)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range blocked {
+		if id == target {
+			return true, nil
+		}
+		if visited[id] {
+			continue
+		}
+		found, err := s.blocksTransitively(id, target, depth+1, visited)
+		if err != nil || found {
+			return found, err
+		}
+	}
+	return false, nil
+}
+
+// RemoveDependency unmarks blockedID as blocked by blockerID
+func (s *TodoService) RemoveDependency(userID, blockerID, blockedID string) error {
+	if err := s.checkOwned(userID, blockedID); err != nil {
+		return err
+	}
+
+	removed, err := s.todoRepo.RemoveDependency(blockerID, blockedID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrDependencyNotFound
+	}
+	return nil
+}
+
+// GetBlockers returns the todos that must be completed before todoID
+func (s *TodoService) GetBlockers(userID, todoID string) ([]models.Todo, error) {
+	if err := s.checkOwned(userID, todoID); err != nil {
+		return nil, err
+	}
+	return s.todoRepo.GetBlockers(todoID)
+}
+
+// GetBlocking returns the todos waiting on todoID
+func (s *TodoService) GetBlocking(userID, todoID string) ([]models.Todo, error) {
+	if err := s.checkOwned(userID, todoID); err != nil {
+		return nil, err
+	}
+	return s.todoRepo.GetBlocking(todoID)
+}
+
+// checkOwned returns ErrTodoNotFound unless todoID is one of the user's todos
+func (s *TodoService) checkOwned(userID, todoID string) error {
+	todo, err := s.todoRepo.GetByID(todoID)
+	if err != nil {
+		return err
+	}
+	if todo == nil || todo.UserID != userID {
+		return ErrTodoNotFound
+	}
+	return nil
+}
+
+// emitUnblocked records a todo.unblocked event for each todo that completing blocker
+// left with no pending blockers
+func (s *TodoService) emitUnblocked(userID string, blocker *models.Todo) {
+	unblocked, err := s.todoRepo.GetUnblockedBy(blocker.ID)
+	if err != nil {
+		log.Printf("[TodoService] Failed to find todos unblocked by %s: %v", blocker.ID, err)
+		return
+	}
+
+	for _, todo := range unblocked {
+		log.Printf("[TodoService] Todo %s unblocked by completing %s", todo.ID, blocker.ID)
+		s.auditService.Log(userID, models.AuditActionTodoUnblocked, map[string]string{
+			"todo_id":    todo.ID,
+			"title":      todo.Title,
+			"blocker_id": blocker.ID,
+		}, "")
+	}
+}
+
+// SyncGroupTags backfills group tags for all of a user's todos. It is idempotent:
+// todos whose tags are already correct are left untouched.
+func (s *TodoService) SyncGroupTags(userID string) error {
+	todos, err := s.todoRepo.GetAllByUserID(userID, true)
+	if err != nil {
+		return err
+	}
+
+	updated := 0
+	for _, todo := range todos {
+		tags := s.withGroupTag(todo.Tags, todo.GroupID)
+		if equalTags(tags, todo.Tags) {
+			continue
+		}
+		if err := s.todoRepo.Update(todo.ID, map[string]interface{}{"tags": tags}); err != nil {
+			return err
+		}
+		updated++
+	}
+	if updated > 0 {
+		s.invalidateTodoCache(userID)
+	}
+
+	log.Printf("[TodoService] Synced group tags for user %s: %d of %d todos updated", userID, updated, len(todos))
+	return nil
+}
+
+// checkTodoGroup returns ErrGroupNotFound unless the group is a default group or one of
+// the user's own, and ErrGroupNotLeaf if it has sub-groups; todos belong in the leaves
+func checkTodoGroup(groupRepo *repository.GroupRepository, userID string, groupID *string) error {
+	if groupID == nil || *groupID == "" || groupRepo == nil {
+		return nil
+	}
+
+	group, err := groupRepo.GetByID(*groupID)
+	if err != nil {
+		return err
+	}
+	if group == nil || (!group.IsDefault && (group.UserID == nil || *group.UserID != userID)) {
+		return ErrGroupNotFound
+	}
+
+	hasChildren, err := groupRepo.HasChildren(userID, *groupID)
+	if err != nil {
+		return err
+	}
+	if hasChildren {
+		return ErrGroupNotLeaf
+	}
+	return nil
+}
+
+// GroupByName returns the user's unarchived group with the given name (ignoring
+// case), or nil if there is none
+func (s *TodoService) GroupByName(userID, name string) (*models.Group, error) {
+	if s.groupRepo == nil {
+		return nil, nil
+	}
+	groups, err := s.groupRepo.GetAllByUserID(userID, false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		if strings.EqualFold(groups[i].Name, strings.TrimSpace(name)) {
+			return &groups[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// withGroupTag replaces any existing group tags with the tag for groupID (if any)
+func (s *TodoService) withGroupTag(tags []string, groupID *string) []string {
+	result := make([]string, 0, len(tags)+1)
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, GroupTagPrefix) {
+			result = append(result, tag)
+		}
+	}
+
+	if groupID == nil || *groupID == "" || s.groupRepo == nil {
+		return result
+	}
+
+	group, err := s.groupRepo.GetByID(*groupID)
+	if err != nil || group == nil {
+		return result
+	}
+
+	tag := GroupTag(group.Name)
+	for _, existing := range result {
+		if existing == tag {
+			return result
+		}
+	}
+	return append(result, tag)
+}
+
+// GroupTag returns the normalized tag for a group name, e.g. "Side Project" -> "group:side-project"
+func GroupTag(name string) string {
+	return GroupTagPrefix + strings.Join(strings.Fields(strings.ToLower(name)), "-")
+}
+
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// BulkUpdatePriority sets the priority of several todos at once. Nothing is updated
+// unless every ID belongs to the user.
+func (s *TodoService) BulkUpdatePriority(userID string, ids []string, priority models.Priority) (int, error) {
+	// Drop duplicates so they don't skew the ownership count
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) > models.MaxBulkTodoIDs {
+		return 0, ErrTooManyTodoIDs
+	}
+	if len(unique) == 0 {
+		return 0, nil
+	}
+
+	owned, err := s.todoRepo.CountOwned(userID, unique)
+	if err != nil {
+		return 0, err
+	}
+	if owned != len(unique) {
+		return 0, ErrTodosNotFound
+	}
+	if err := s.checkManualBulkPriority(userID, unique, priority); err != nil {
+		return 0, err
+	}
+
+	updated, err := s.todoRepo.UpdatePriorityBulk(userID, unique, priority)
+	if err != nil {
+		return 0, err
+	}
+	s.invalidateTodoCache(userID)
+	s.briefings.Invalidate(userID)
+
+	// Async RAG indexing - fire and forget
+	if s.ragService != nil && s.ragService.IsConfigured() {
+		go func() {
+			todos, err := s.todoRepo.GetByIDs(userID, unique)
+			if err != nil {
+				log.Printf("[TodoService] Failed to load todos for re-indexing: %v", err)
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+			defer cancel()
+			if err := s.ragService.IndexTodos(ctx, userID, todos); err != nil {
+				log.Printf("[TodoService] Failed to re-index %d todos: %v", len(todos), err)
+				for i := range todos {
+					s.ragService.QueueIndexRetry(models.ContentTypeTodo, todos[i].ID, userID, err)
+				}
+			}
+		}()
+	}
+
+	return int(updated), nil
 }
 
 func (s *TodoService) Reorder(userID string, req *models.TodoReorderRequest) error {
@@ -227,5 +878,20 @@ func (s *TodoService) Reorder(userID string, req *models.TodoReorderRequest) err
 		}
 	}
 
-	return s.todoRepo.UpdatePositions(req.Todos)
+	if err := s.todoRepo.UpdatePositions(req.Todos); err != nil {
+		return err
+	}
+	s.invalidateTodoCache(userID)
+	return nil
+}
+
+// ResetPositions renumbers the user's todos 1000, 2000, ... keeping their order, for
+// when reordering has left positions large or uneven. Returns how many were renumbered.
+func (s *TodoService) ResetPositions(userID string) (int, error) {
+	updated, err := s.todoRepo.ResetPositions(userID)
+	if err != nil {
+		return 0, err
+	}
+	s.invalidateTodoCache(userID)
+	return updated, nil
 }
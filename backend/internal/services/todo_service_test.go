@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// newDependencyTodos returns a TodoService and n todos belonging to one user
+func newDependencyTodos(t *testing.T, n int) (*TodoService, *models.User, []*models.Todo) {
+	t.Helper()
+	db := newTestDB(t)
+	user := newTestUser(t, db, "dependencies@example.com")
+	userRepo := repository.NewUserRepository(db)
+	todoRepo := repository.NewTodoRepository(db)
+	todos := NewTodoService(todoRepo, repository.NewGroupRepository(db), userRepo, nil, nil, nil, nil, nil, NewUserPreferencesService(userRepo))
+
+	created := make([]*models.Todo, n)
+	for i := range created {
+		todo := &models.Todo{UserID: user.ID, Title: fmt.Sprintf("Step %d", i), Priority: models.PriorityMedium, Status: models.StatusPending}
+		if err := todoRepo.Create(todo); err != nil {
+			t.Fatalf("failed to create todo: %v", err)
+		}
+		created[i] = todo
+	}
+	return todos, user, created
+}
+
+// chainDependencies makes each todo block the next
+func chainDependencies(t *testing.T, todos *TodoService, userID string, chain []*models.Todo) {
+	t.Helper()
+	for i := 0; i+1 < len(chain); i++ {
+		if err := todos.AddDependency(userID, chain[i].ID, chain[i+1].ID); err != nil {
+			t.Fatalf("AddDependency %d -> %d: %v", i, i+1, err)
+		}
+	}
+}
+
+func TestAddDependencyRejectsCycles(t *testing.T) {
+	todos, user, chain := newDependencyTodos(t, 3)
+	a, b, c := chain[0], chain[1], chain[2]
+	chainDependencies(t, todos, user.ID, chain)
+
+	tests := []struct {
+		name             string
+		blocker, blocked *models.Todo
+	}{
+		{"self", a, a},
+		{"direct", b, a},
+		{"through another todo", c, a},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := todos.AddDependency(user.ID, tt.blocker.ID, tt.blocked.ID); !errors.Is(err, ErrDependencyCycle) {
+				t.Errorf("AddDependency = %v, want ErrDependencyCycle", err)
+			}
+		})
+	}
+
+	// A -> C is a shortcut, not a cycle, and adding it twice is a no-op
+	for i := 0; i < 2; i++ {
+		if err := todos.AddDependency(user.ID, a.ID, c.ID); err != nil {
+			t.Errorf("AddDependency A -> C: %v", err)
+		}
+	}
+}
+
+func TestAddDependencyDepthLimit(t *testing.T) {
+	// A chain of MaxDependencyDepth dependencies is still searched to its end
+	todos, user, chain := newDependencyTodos(t, MaxDependencyDepth+1)
+	chainDependencies(t, todos, user.ID, chain)
+	if err := todos.AddDependency(user.ID, chain[len(chain)-1].ID, chain[0].ID); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("closing a chain of %d dependencies = %v, want ErrDependencyCycle", MaxDependencyDepth, err)
+	}
+
+	// One more and the search gives up at depth MaxDependencyDepth+1
+	todos, user, chain = newDependencyTodos(t, MaxDependencyDepth+2)
+	chainDependencies(t, todos, user.ID, chain)
+	if err := todos.AddDependency(user.ID, chain[len(chain)-1].ID, chain[0].ID); !errors.Is(err, ErrDependencyTooDeep) {
+		t.Errorf("closing a chain of %d dependencies = %v, want ErrDependencyTooDeep", MaxDependencyDepth+1, err)
+	}
+}
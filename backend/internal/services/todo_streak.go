@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+const completionDateLayout = "2006-01-02"
+
+var ErrInvalidCalendarMonth = errors.New("year and month must be a valid calendar month, e.g. year=2025&month=6")
+
+// userToday returns the current time in the user's timezone
+func (s *TodoService) userToday(userID string) time.Time {
+	loc, err := time.LoadLocation(s.userTimezone(userID))
+	if err != nil {
+		loc = time.UTC
+	}
+	return time.Now().In(loc)
+}
+
+// dailyGoal returns the number of todos the user aims to complete each day
+func (s *TodoService) dailyGoal(userID string) int {
+	if s.userRepo == nil {
+		return models.DefaultDailyGoal
+	}
+	goal, err := s.userRepo.GetDailyGoal(userID)
+	if err != nil || goal <= 0 {
+		return models.DefaultDailyGoal
+	}
+	return goal
+}
+
+// recordCompletion counts a completed todo towards today's streak, in the user's timezone
+func (s *TodoService) recordCompletion(userID string) {
+	date := s.userToday(userID).Format(completionDateLayout)
+	if err := s.todoRepo.RecordCompletion(userID, date); err != nil {
+		log.Printf("[TodoService] Failed to record completion for user %s: %v", userID, err)
+	}
+}
+
+// GetStreak returns the user's current and longest completion streaks and how many
+// todos they completed today
+func (s *TodoService) GetStreak(userID string) (*models.StreakInfo, error) {
+	dates, err := s.todoRepo.GetCompletionDates(userID)
+	if err != nil {
+		return nil, err
+	}
+	today := s.userToday(userID).Format(completionDateLayout)
+	counts, err := s.todoRepo.GetCompletionCounts(userID, today, today)
+	if err != nil {
+		return nil, err
+	}
+
+	current, longest := computeStreak(dates, today)
+	return &models.StreakInfo{
+		CurrentStreak: current,
+		LongestStreak: longest,
+		TodayCount:    counts[today],
+		DailyGoal:     s.dailyGoal(userID),
+	}, nil
+}
+
+// GetStreakCalendar returns one entry per day of the month, with the number of todos
+// completed that day and whether it met the user's daily goal
+func (s *TodoService) GetStreakCalendar(userID string, year, month int) ([]models.DayActivity, error) {
+	if year < 1 || year > 9999 || month < 1 || month > 12 {
+		return nil, ErrInvalidCalendarMonth
+	}
+
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	counts, err := s.todoRepo.GetCompletionCounts(userID, first.Format(completionDateLayout), last.Format(completionDateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	goal := s.dailyGoal(userID)
+	days := make([]models.DayActivity, 0, last.Day())
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		date := day.Format(completionDateLayout)
+		days = append(days, models.DayActivity{
+			Date:      date,
+			Count:     counts[date],
+			IsGoalMet: counts[date] >= goal,
+		})
+	}
+	return days, nil
+}
+
+// computeStreak returns the current and longest runs of consecutive days in dates
+// (YYYY-MM-DD, sorted ascending). The current run must end today or yesterday, so a
+// streak isn't broken until a whole day passes without a completion.
+func computeStreak(dates []string, today string) (current, longest int) {
+	var prev time.Time
+	run := 0
+	for _, date := range dates {
+		day, err := time.Parse(completionDateLayout, date)
+		if err != nil {
+			continue
+		}
+		switch {
+		case run > 0 && day.Equal(prev):
+			continue
+		case run > 0 && day.Equal(prev.AddDate(0, 0, 1)):
+			run++
+		default:
+			run = 1
+		}
+		prev = day
+		longest = max(longest, run)
+	}
+
+	todayDate, err := time.Parse(completionDateLayout, today)
+	if err != nil || run == 0 {
+		return 0, longest
+	}
+	if prev.Equal(todayDate) || prev.Equal(todayDate.AddDate(0, 0, -1)) {
+		current = run
+	}
+	return current, longest
+}
@@ -0,0 +1,38 @@
+package services
+
+import "testing"
+
+func TestComputeStreak(t *testing.T) {
+	tests := []struct {
+		name        string
+		dates       []string
+		today       string
+		wantCurrent int
+		wantLongest int
+	}{
+		{"no completions", nil, "2025-06-15", 0, 0},
+		{"today only", []string{"2025-06-15"}, "2025-06-15", 1, 1},
+		{"ending yesterday", []string{"2025-06-13", "2025-06-14"}, "2025-06-15", 2, 2},
+		{"ending the day before yesterday", []string{"2025-06-12", "2025-06-13"}, "2025-06-15", 0, 2},
+		{"across a month boundary", []string{"2025-05-30", "2025-05-31", "2025-06-01"}, "2025-06-01", 3, 3},
+		{"across the end of February", []string{"2025-02-27", "2025-02-28", "2025-03-01"}, "2025-03-01", 3, 3},
+		{"across a leap day", []string{"2024-02-28", "2024-02-29", "2024-03-01"}, "2024-03-01", 3, 3},
+		{"no leap day to skip", []string{"2025-02-28", "2025-03-02"}, "2025-03-02", 1, 1},
+		{"across a year boundary", []string{"2024-12-30", "2024-12-31", "2025-01-01"}, "2025-01-02", 3, 3},
+		{"month boundary gap", []string{"2025-04-29", "2025-04-30", "2025-05-02"}, "2025-05-02", 1, 2},
+		{"gap breaks the run", []string{"2025-06-01", "2025-06-02", "2025-06-03", "2025-06-05", "2025-06-06"}, "2025-06-06", 2, 3},
+		{"longest run after a gap", []string{"2025-06-01", "2025-06-03", "2025-06-04", "2025-06-05"}, "2025-06-10", 0, 3},
+		{"same day twice", []string{"2025-06-14", "2025-06-14", "2025-06-15"}, "2025-06-15", 2, 2},
+		{"unparseable date skipped", []string{"2025-06-14", "not-a-date", "2025-06-15"}, "2025-06-15", 2, 2},
+		{"unparseable today", []string{"2025-06-14", "2025-06-15"}, "", 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current, longest := computeStreak(tt.dates, tt.today)
+			if current != tt.wantCurrent || longest != tt.wantLongest {
+				t.Errorf("computeStreak(%v, %q) = %d, %d; want %d, %d", tt.dates, tt.today, current, longest, tt.wantCurrent, tt.wantLongest)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+var (
+	ErrTodoTemplateNotFound    = newCodedError(models.ErrCodeNotFound, "todo template not found")
+	ErrSystemTemplateImmutable = errors.New("system templates cannot be modified")
+	ErrInvalidTemplateStart    = errors.New("start_date must be in YYYY-MM-DD format")
+)
+
+type TodoTemplateService struct {
+	templateRepo *repository.TodoTemplateRepository
+	todoService  *TodoService
+}
+
+func NewTodoTemplateService(templateRepo *repository.TodoTemplateRepository, todoService *TodoService) *TodoTemplateService {
+	return &TodoTemplateService{
+		templateRepo: templateRepo,
+		todoService:  todoService,
+	}
+}
+
+func (s *TodoTemplateService) Create(userID string, req *models.TodoTemplateCreateRequest) (*models.TodoTemplate, error) {
+	template := &models.TodoTemplate{
+		UserID:      &userID,
+		Name:        req.Name,
+		Description: req.Description,
+		Tasks:       req.Tasks,
+	}
+
+	if err := s.templateRepo.Create(template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// GetAll returns the system templates and the user's own templates
+func (s *TodoTemplateService) GetAll(userID string) ([]models.TodoTemplate, error) {
+	return s.templateRepo.GetAllByUserID(userID)
+}
+
+// GetByID returns a system template or one of the user's templates, or nil if neither matches
+func (s *TodoTemplateService) GetByID(userID, templateID string) (*models.TodoTemplate, error) {
+	template, err := s.templateRepo.GetByID(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil || (!template.IsSystem && *template.UserID != userID) {
+		return nil, nil
+	}
+	return template, nil
+}
+
+func (s *TodoTemplateService) Update(userID, templateID string, req *models.TodoTemplateUpdateRequest) (*models.TodoTemplate, error) {
+	if _, err := s.getOwned(userID, templateID); err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if req.Tasks != nil {
+		updates["tasks"] = req.Tasks
+	}
+
+	if len(updates) > 0 {
+		if err := s.templateRepo.Update(templateID, updates); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.templateRepo.GetByID(templateID)
+}
+
+func (s *TodoTemplateService) Delete(userID, templateID string) error {
+	if _, err := s.getOwned(userID, templateID); err != nil {
+		return err
+	}
+	return s.templateRepo.Delete(templateID)
+}
+
+// Apply creates a todo for every task in the template in a single transaction.
+// Each title goes through the same AI cleanup as a new todo, and due dates are
+// relative_due_days after today (or overrides.StartDate) in the user's timezone.
+func (s *TodoTemplateService) Apply(userID, templateID string, overrides models.TemplateApplyOptions) ([]models.Todo, error) {
+	template, err := s.GetByID(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTodoTemplateNotFound
+	}
+
+	loc, err := time.LoadLocation(s.todoService.userTimezone(userID))
+	if err != nil {
+		loc = time.UTC
+	}
+	start := startOfDay(time.Now().In(loc))
+	if overrides.StartDate != nil && *overrides.StartDate != "" {
+		start, err = time.ParseInLocation("2006-01-02", *overrides.StartDate, loc)
+		if err != nil {
+			return nil, ErrInvalidTemplateStart
+		}
+	}
+
+	todos := make([]*models.Todo, 0, len(template.Tasks))
+	for _, task := range template.Tasks {
+		title, aiTags := s.todoService.processTitle(userID, task.Title)
+
+		var dueDate *string
+		if task.RelativeDueDays != nil {
+			due := start.AddDate(0, 0, *task.RelativeDueDays).Format(time.RFC3339)
+			dueDate = &due
+		}
+
+		todos = append(todos, &models.Todo{
+			GroupID:  overrides.GroupID,
+			Title:    title,
+			DueDate:  dueDate,
+			Priority: task.Priority,
+			Tags:     s.todoService.withGroupTag(mergeTags(task.Tags, aiTags), overrides.GroupID),
+		})
+	}
+
+	if err := s.todoService.createBatch(userID, todos); err != nil {
+		return nil, err
+	}
+	log.Printf("[TodoTemplateService] Applied template %s for user %s: %d todos created", template.ID, userID, len(todos))
+
+	created := make([]models.Todo, 0, len(todos))
+	for _, todo := range todos {
+		created = append(created, *todo)
+	}
+	return created, nil
+}
+
+// getOwned returns one of the user's own templates, rejecting system templates
+func (s *TodoTemplateService) getOwned(userID, templateID string) (*models.TodoTemplate, error) {
+	template, err := s.GetByID(userID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTodoTemplateNotFound
+	}
+	if template.IsSystem {
+		return nil, ErrSystemTemplateImmutable
+	}
+	return template, nil
+}
+
+// mergeTags appends the suggested tags not already in base, preserving order
+func mergeTags(base, suggested []string) []string {
+	seen := make(map[string]bool, len(base)+len(suggested))
+	result := make([]string, 0, len(base)+len(suggested))
+	for _, tag := range append(append([]string{}, base...), suggested...) {
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/todomyday/backend/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates spans for AI provider, embedding and RAG calls. It resolves
+// against the global provider, so it's a no-op until tracing.Init installs one.
+var tracer = otel.Tracer("github.com/todomyday/backend/internal/services")
+
+// startAISpan starts a client span for a provider call, tagged with the provider and model
+func startAISpan(config *AIProviderConfig, name string) (context.Context, trace.Span) {
+	return tracer.Start(config.requestContext(), name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			tracing.AttrProviderType.String(string(config.ProviderType)),
+			tracing.AttrModel.String(config.Model),
+			tracing.AttrUserID.String(config.UserID),
+		),
+	)
+}
+
+// recordTokens adds a provider's reported token usage to the span
+func recordTokens(span trace.Span, input, output int) {
+	span.SetAttributes(
+		tracing.AttrInputTokens.Int(input),
+		tracing.AttrOutputTokens.Int(output),
+	)
+}
+
+// endSpan marks the span as failed when err is set, then ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceDB runs a repository call in its own span and records how long it took
+func traceDB(ctx context.Context, name string, fn func(context.Context) error) error {
+	ctx, span := tracer.Start(ctx, name)
+	start := time.Now()
+	err := fn(ctx)
+	span.SetAttributes(tracing.AttrDBLatencyMs.Int64(time.Since(start).Milliseconds()))
+	endSpan(span, err)
+	return err
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+type ttlCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// ttlCache is an in-process cache whose entries expire ttl after they're set. It
+// holds at most maxEntries; expired entries are swept when it fills. Lookups are
+// recorded in the cache metrics under resource.
+type ttlCache[V any] struct {
+	resource   string
+	ttl        time.Duration
+	maxEntries int
+	// now is time.Now, or a fake clock in tests
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]ttlCacheEntry[V]
+}
+
+func newTTLCache[V any](resource string, ttl time.Duration, maxEntries int) *ttlCache[V] {
+	return &ttlCache[V]{
+		resource:   resource,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]ttlCacheEntry[V]),
+	}
+}
+
+// Get returns the value cached under key, if it hasn't expired
+func (c *ttlCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.live(key)
+	observeCache(c.resource, ok)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Set caches value under key for the cache's ttl
+func (c *ttlCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value)
+}
+
+// Add caches value under key unless a live entry is already cached there, and
+// reports whether it did. Finding one counts as a hit.
+func (c *ttlCache[V]) Add(key string, value V) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.live(key)
+	observeCache(c.resource, ok)
+	if ok {
+		return false
+	}
+	c.set(key, value)
+	return true
+}
+
+// live returns the entry under key if it hasn't expired, dropping it if it has.
+// The caller holds mu.
+func (c *ttlCache[V]) live(key string) (ttlCacheEntry[V], bool) {
+	entry, ok := c.entries[key]
+	if ok && c.now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		ok = false
+	}
+	return entry, ok
+}
+
+// set stores value under key, sweeping the cache first if it's full. The caller
+// holds mu.
+func (c *ttlCache[V]) set(key string, value V) {
+	if len(c.entries) >= c.maxEntries {
+		now := c.now()
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		// Still full of live entries - start over rather than grow unbounded
+		if len(c.entries) >= c.maxEntries {
+			c.entries = make(map[string]ttlCacheEntry[V])
+		}
+	}
+
+	c.entries[key] = ttlCacheEntry[V]{value: value, expiresAt: c.now().Add(c.ttl)}
+}
+
+// Delete drops the entry cached under key
+func (c *ttlCache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// DeleteFunc drops the entries for which drop returns true and returns how many
+func (c *ttlCache[V]) DeleteFunc(drop func(key string, value V) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deleted := 0
+	for key, entry := range c.entries {
+		if drop(key, entry.value) {
+			delete(c.entries, key)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// DeletePrefix drops the entries whose key starts with prefix and returns how many
+func (c *ttlCache[V]) DeletePrefix(prefix string) int {
+	return c.DeleteFunc(func(key string, _ V) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+}
+
+// Len returns how many entries the cache holds, expired ones included
+func (c *ttlCache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
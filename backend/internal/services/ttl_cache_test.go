@@ -0,0 +1,65 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTTLCache(t *testing.T) {
+	t.Run("expired entries miss", func(t *testing.T) {
+		cache := newTTLCache[string]("test", -time.Second, 10)
+		cache.Set("key", "value")
+		if value, ok := cache.Get("key"); ok {
+			t.Errorf("Get = %q, true; want a miss", value)
+		}
+	})
+
+	t.Run("live entries hit", func(t *testing.T) {
+		cache := newTTLCache[string]("test", time.Minute, 10)
+		cache.Set("key", "value")
+		if value, ok := cache.Get("key"); !ok || value != "value" {
+			t.Errorf("Get = %q, %v; want value, true", value, ok)
+		}
+	})
+
+	t.Run("full of live entries starts over", func(t *testing.T) {
+		cache := newTTLCache[int]("test", time.Minute, 3)
+		for i := 0; i < 4; i++ {
+			cache.Set(fmt.Sprint(i), i)
+		}
+		if len(cache.entries) != 1 {
+			t.Errorf("cache holds %d entries, want 1", len(cache.entries))
+		}
+		if value, ok := cache.Get("3"); !ok || value != 3 {
+			t.Errorf("Get(3) = %d, %v; want 3, true", value, ok)
+		}
+	})
+
+	t.Run("delete prefix", func(t *testing.T) {
+		cache := newTTLCache[int]("test", time.Minute, 10)
+		cache.Set("todos:a:1", 1)
+		cache.Set("todos:a:2", 2)
+		cache.Set("todos:ab:1", 3)
+		if deleted := cache.DeletePrefix("todos:a:"); deleted != 2 {
+			t.Errorf("DeletePrefix = %d, want 2", deleted)
+		}
+		for key, want := range map[string]bool{"todos:a:1": false, "todos:a:2": false, "todos:ab:1": true} {
+			if _, ok := cache.Get(key); ok != want {
+				t.Errorf("Get(%s) ok = %v, want %v", key, ok, want)
+			}
+		}
+	})
+	t.Run("delete", func(t *testing.T) {
+		cache := newTTLCache[int]("test", time.Minute, 10)
+		cache.Set("a", 1)
+		cache.Set("b", 2)
+		cache.Delete("a")
+		if _, ok := cache.Get("a"); ok {
+			t.Error("Get(a) hit after Delete")
+		}
+		if cache.Len() != 1 {
+			t.Errorf("Len = %d, want 1", cache.Len())
+		}
+	})
+}
@@ -85,8 +85,9 @@ func (s *UploadJobService) UpdateJobStatus(jobID string, status models.UploadJob
 	return nil
 }
 
-// AddMemoryToJob adds a newly created memory to the job
-func (s *UploadJobService) AddMemoryToJob(jobID string, memory models.Memory) error {
+// AddMemoryToJob adds a newly created memory to the job, recording the source of the
+// section it was created from
+func (s *UploadJobService) AddMemoryToJob(jobID string, memory models.Memory, source SectionSource) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -96,6 +97,12 @@ func (s *UploadJobService) AddMemoryToJob(jobID string, memory models.Memory) er
 	}
 
 	job.Memories = append(job.Memories, memory)
+	if source != "" {
+		if job.MemorySources == nil {
+			job.MemorySources = make(map[string]string)
+		}
+		job.MemorySources[memory.ID] = string(source)
+	}
 	job.ProcessedItems++
 	job.UpdatedAt = time.Now()
 
@@ -107,6 +114,27 @@ func (s *UploadJobService) AddMemoryToJob(jobID string, memory models.Memory) er
 	return nil
 }
 
+// MarkDuplicates records items skipped as duplicates, counting them as processed
+func (s *UploadJobService) MarkDuplicates(jobID string, count int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[jobID]
+	if !exists {
+		return fmt.Errorf("job not found")
+	}
+
+	job.DeduplicatedCount += count
+	job.ProcessedItems += count
+	job.UpdatedAt = time.Now()
+
+	if job.TotalItems > 0 {
+		job.Progress = (job.ProcessedItems * 100) / job.TotalItems
+	}
+
+	return nil
+}
+
 // SetJobError sets an error message for the job
 func (s *UploadJobService) SetJobError(jobID string, errorMsg string) error {
 	s.mu.Lock()
@@ -151,16 +179,18 @@ func (s *UploadJobService) GetJobStatus(jobID string) (*models.UploadJobStatusRe
 	}
 
 	return &models.UploadJobStatusResponse{
-		JobID:          job.ID,
-		Status:         job.Status,
-		Progress:       job.Progress,
-		TotalItems:     job.TotalItems,
-		ProcessedItems: job.ProcessedItems,
-		Memories:       job.Memories,
-		ErrorMessage:   job.ErrorMessage,
-		CreatedAt:      job.CreatedAt,
-		UpdatedAt:      job.UpdatedAt,
-		CompletedAt:    job.CompletedAt,
+		JobID:             job.ID,
+		Status:            job.Status,
+		Progress:          job.Progress,
+		TotalItems:        job.TotalItems,
+		ProcessedItems:    job.ProcessedItems,
+		DeduplicatedCount: job.DeduplicatedCount,
+		Memories:          job.Memories,
+		MemorySources:     job.MemorySources,
+		ErrorMessage:      job.ErrorMessage,
+		CreatedAt:         job.CreatedAt,
+		UpdatedAt:         job.UpdatedAt,
+		CompletedAt:       job.CompletedAt,
 	}, nil
 }
 
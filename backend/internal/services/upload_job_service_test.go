@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+func TestAddMemoryToJobRecordsSectionSource(t *testing.T) {
+	service := NewUploadJobService()
+	job := service.CreateJob("user", "notes.zip", "zip", 2)
+
+	if err := service.AddMemoryToJob(job.ID, models.Memory{ID: "note"}, SectionSourceAppleNotes); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.AddMemoryToJob(job.ID, models.Memory{ID: "text"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := service.GetJobStatus(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Memories) != 2 {
+		t.Fatalf("job has %d memories, want 2", len(status.Memories))
+	}
+	if got := status.MemorySources["note"]; got != string(SectionSourceAppleNotes) {
+		t.Errorf("source of note = %q, want %q", got, SectionSourceAppleNotes)
+	}
+	if got, ok := status.MemorySources["text"]; ok {
+		t.Errorf("section without a source recorded %q", got)
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// URLRefreshSchedule is the default cron schedule of the check for stale URL content
+	URLRefreshSchedule = "0 * * * *"
+	// URLRefreshBatchSize caps the memories refreshed per check, to stay within
+	// scraping and AI provider rate limits
+	URLRefreshBatchSize = 20
+	// DefaultURLRefreshIntervalDays is how old URL content gets before it is refreshed
+	DefaultURLRefreshIntervalDays = 7
+)
+
+// URLRefreshScheduler keeps the scraped content of URL memories current by
+// re-scraping pages whose content is older than the refresh interval
+type URLRefreshScheduler struct {
+	memoryService *MemoryService
+	maxAge        time.Duration
+}
+
+func NewURLRefreshScheduler(memoryService *MemoryService, intervalDays int) *URLRefreshScheduler {
+	return &URLRefreshScheduler{
+		memoryService: memoryService,
+		maxAge:        time.Duration(intervalDays) * 24 * time.Hour,
+	}
+}
+
+// RefreshDue refreshes up to URLRefreshBatchSize memories whose URL content is stale
+// and returns how many were refreshed
+func (s *URLRefreshScheduler) RefreshDue(ctx context.Context) (int, error) {
+	refreshed, err := s.memoryService.RefreshStaleURLs(ctx, s.maxAge, URLRefreshBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load stale URL memories: %w", err)
+	}
+
+	if refreshed > 0 {
+		log.Printf("[URLRefreshScheduler] Refreshed URL content of %d memories", refreshed)
+	}
+	return refreshed, nil
+}
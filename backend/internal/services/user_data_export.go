@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+// exportMemoryPageSize is how many memories are read per query while streaming an export
+const exportMemoryPageSize = 500
+
+// ErrUnsupportedExportVersion is returned when an import's version isn't one we write
+var ErrUnsupportedExportVersion = fmt.Errorf("unsupported export version (expected %q)", models.DataExportVersion)
+
+// exportStream writes a JSON document piece by piece, keeping the first error
+type exportStream struct {
+	w   io.Writer
+	enc *json.Encoder
+	err error
+}
+
+func (e *exportStream) raw(s string) {
+	if e.err == nil {
+		_, e.err = io.WriteString(e.w, s)
+	}
+}
+
+func (e *exportStream) value(v interface{}) {
+	if e.err == nil {
+		e.err = e.enc.Encode(v)
+	}
+}
+
+// ExportJSON streams the user's todos, memories, custom groups, AI providers and
+// custom categories to w as a models.DataExport document. Records are encoded one
+// at a time and memories are read in pages, so the full export is never held in memory.
+// API keys are always masked.
+func (s *UserDataService) ExportJSON(userID string, w io.Writer) error {
+	todos, err := s.todoRepo.GetAllByUserID(userID, true)
+	if err != nil {
+		return fmt.Errorf("failed to fetch todos: %w", err)
+	}
+
+	out := &exportStream{w: w, enc: json.NewEncoder(w)}
+
+	out.raw(`{"version":`)
+	out.value(models.DataExportVersion)
+	out.raw(`,"exported_at":`)
+	out.value(time.Now().UTC())
+
+	out.raw(`,"todos":[`)
+	for i := range todos {
+		if i > 0 {
+			out.raw(",")
+		}
+		out.value(todos[i])
+	}
+
+	out.raw(`],"memories":[`)
+	written := 0
+	for offset := 0; out.err == nil; offset += exportMemoryPageSize {
+		memories, err := s.memoryRepo.GetPageIncludingArchived(userID, exportMemoryPageSize, offset)
+		if err != nil {
+			return fmt.Errorf("failed to fetch memories: %w", err)
+		}
+		for i := range memories {
+			if written > 0 {
+				out.raw(",")
+			}
+			out.value(memories[i])
+			written++
+		}
+		if len(memories) < exportMemoryPageSize {
+			break
+		}
+	}
+
+	groups, err := s.groupRepo.GetAllByUserID(userID, true)
+	if err != nil {
+		return fmt.Errorf("failed to fetch groups: %w", err)
+	}
+	out.raw(`],"groups":[`)
+	written = 0
+	for i := range groups {
+		// Default groups exist for everyone and aren't part of the user's data
+		if groups[i].IsDefault {
+			continue
+		}
+		if written > 0 {
+			out.raw(",")
+		}
+		out.value(groups[i])
+		written++
+	}
+
+	providers, err := s.aiProviderService.GetByUserID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch AI providers: %w", err)
+	}
+	out.raw(`],"ai_providers":[`)
+	for i := range providers {
+		// GetByUserID leaves the mask empty when a key can't be decrypted
+		if providers[i].APIKeyMasked == "" {
+			providers[i].APIKeyMasked = "****"
+		}
+		if i > 0 {
+			out.raw(",")
+		}
+		out.value(providers[i])
+	}
+
+	categories, err := s.memoryRepo.GetCategories(userID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch categories: %w", err)
+	}
+	out.raw(`],"categories":[`)
+	written = 0
+	for i := range categories {
+		if categories[i].IsSystem {
+			continue
+		}
+		if written > 0 {
+			out.raw(",")
+		}
+		out.value(categories[i])
+		written++
+	}
+	out.raw("]}\n")
+
+	return out.err
+}
+
+// ImportJSON creates the records in a JSON export for the user, in dependency order:
+// groups, categories, todos, then memories. Records keep their IDs and any whose ID
+// already exists is skipped. Every record is assigned to the importing user, todos
+// pointing at a group the user can't see lose the group, and AI providers are skipped
+// because exports only carry masked keys.
+func (s *UserDataService) ImportJSON(userID string, data *models.DataExport) (*models.DataImportResult, error) {
+	if data.Version != models.DataExportVersion {
+		return nil, ErrUnsupportedExportVersion
+	}
+
+	result := &models.DataImportResult{}
+	now := time.Now()
+
+	groups := make([]models.Group, 0, len(data.Groups))
+	for _, g := range data.Groups {
+		if g.IsDefault {
+			continue
+		}
+		g.UserID = &userID
+		g.CreatedAt, g.UpdatedAt = importTimestamps(g.CreatedAt, g.UpdatedAt, now)
+		groups = append(groups, g)
+	}
+	imported, err := s.groupRepo.Import(groups)
+	if err != nil {
+		return nil, err
+	}
+	result.Imported.Groups = imported
+	result.Skipped.Groups = len(data.Groups) - imported
+
+	categories := make([]models.MemoryCategory, 0, len(data.Categories))
+	for _, c := range data.Categories {
+		if c.IsSystem {
+			continue
+		}
+		c.UserID = &userID
+		if c.CreatedAt.IsZero() {
+			c.CreatedAt = now
+		}
+		categories = append(categories, c)
+	}
+	imported, err = s.memoryRepo.ImportCategories(categories)
+	if err != nil {
+		return nil, err
+	}
+	result.Imported.Categories = imported
+	result.Skipped.Categories = len(data.Categories) - imported
+
+	// Only keep group links to groups the user can see after the group import
+	visibleGroups, err := s.groupRepo.GetAllByUserID(userID, true)
+	if err != nil {
+		return nil, err
+	}
+	groupIDs := make(map[string]bool, len(visibleGroups))
+	for _, g := range visibleGroups {
+		groupIDs[g.ID] = true
+	}
+
+	todos := make([]models.Todo, 0, len(data.Todos))
+	for _, t := range data.Todos {
+		t.UserID = userID
+		if t.GroupID != nil && !groupIDs[*t.GroupID] {
+			t.GroupID = nil
+		}
+		t.CreatedAt, t.UpdatedAt = importTimestamps(t.CreatedAt, t.UpdatedAt, now)
+		todos = append(todos, t)
+	}
+	imported, err = s.todoRepo.Import(todos)
+	if err != nil {
+		return nil, err
+	}
+	result.Imported.Todos = imported
+	result.Skipped.Todos = len(data.Todos) - imported
+
+	memories := make([]models.Memory, 0, len(data.Memories))
+	for _, m := range data.Memories {
+		// Memories matching the blocklist are skipped like any the import can't take
+		var blocked *models.ContentBlockedError
+		if err := s.blocklist.Check(userID, m.Content); errors.As(err, &blocked) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		m.UserID = userID
+		m.CreatedAt, m.UpdatedAt = importTimestamps(m.CreatedAt, m.UpdatedAt, now)
+		memories = append(memories, m)
+	}
+	imported, err = s.memoryRepo.Import(memories)
+	if err != nil {
+		return nil, err
+	}
+	result.Imported.Memories = imported
+	result.Skipped.Memories = len(data.Memories) - imported
+
+	result.Skipped.AIProviders = len(data.AIProviders)
+
+	log.Printf("[UserDataService] JSON import for user %s: imported %+v, skipped %+v", userID, result.Imported, result.Skipped)
+
+	// Index the new todos and memories for RAG in the background
+	if s.ragService != nil && s.ragService.IsConfigured() && result.Imported.Todos+result.Imported.Memories > 0 {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			defer cancel()
+			if _, err := s.ragService.IndexAllForUser(ctx, userID); err != nil {
+				log.Printf("[UserDataService] Failed to index imported data for user %s: %v", userID, err)
+			}
+		}()
+	}
+
+	return result, nil
+}
+
+// importTimestamps fills in missing created/updated times on an imported record
+func importTimestamps(createdAt, updatedAt, now time.Time) (time.Time, time.Time) {
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+	if updatedAt.IsZero() {
+		updatedAt = createdAt
+	}
+	return createdAt, updatedAt
+}
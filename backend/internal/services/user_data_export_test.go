@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+func TestImportJSONSkipsBlockedMemories(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db, "import@example.com")
+
+	memoryRepo := repository.NewMemoryRepository(db)
+	blocklist := NewBlocklistService(repository.NewKeywordBlocklistRepository(db), []string{"/pass(word)?:\\s*\\S+/"})
+	if _, err := blocklist.Create(user.ID, &models.BlocklistPatternCreateRequest{Pattern: "hunter2"}); err != nil {
+		t.Fatalf("failed to add blocklist pattern: %v", err)
+	}
+	service := NewUserDataService(repository.NewUserRepository(db), memoryRepo, repository.NewTodoRepository(db), repository.NewGroupRepository(db), nil, nil, nil, nil, nil, blocklist, nil)
+
+	result, err := service.ImportJSON(user.ID, &models.DataExport{
+		Version: models.DataExportVersion,
+		Memories: []models.Memory{
+			{ID: "kept", Content: "Remember to water the plants"},
+			{ID: "user-pattern", Content: "my wifi key is HUNTER2"},
+			{ID: "system-pattern", Content: "bank password: letmein"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ImportJSON: %v", err)
+	}
+
+	if result.Imported.Memories != 1 || result.Skipped.Memories != 2 {
+		t.Errorf("imported %d and skipped %d memories, want 1 and 2", result.Imported.Memories, result.Skipped.Memories)
+	}
+	for id, want := range map[string]bool{"kept": true, "user-pattern": false, "system-pattern": false} {
+		memory, err := memoryRepo.GetByID(id)
+		if err != nil {
+			t.Fatalf("GetByID(%s): %v", id, err)
+		}
+		if got := memory != nil; got != want {
+			t.Errorf("memory %s stored = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -2,35 +2,78 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/todomyday/backend/internal/models"
 	"github.com/todomyday/backend/internal/repository"
 )
 
+const (
+	// AccountDeletionCooldown limits how often a user may attempt account deletion with a password
+	AccountDeletionCooldown = 10 * time.Minute
+	// AccountDeletionReauthMaxAge is how recently an SSO user must have signed in at
+	// their provider to delete their account without a password
+	AccountDeletionReauthMaxAge = 5 * time.Minute
+)
+
+var (
+	// ErrAccountDeletionRateLimited is returned when deletion is attempted again within the cooldown
+	ErrAccountDeletionRateLimited = errors.New("account deletion attempted too recently")
+	// ErrReauthenticationRequired is returned when an SSO user deletes their account
+	// without a password and hasn't signed in at the provider recently
+	ErrReauthenticationRequired = errors.New("sign in again with single sign-on to delete your account")
+)
+
 type UserDataService struct {
-	memoryRepo *repository.MemoryRepository
-	todoRepo   *repository.TodoRepository
-	groupRepo  *repository.GroupRepository
-	vectorRepo *repository.VectorRepository
-	ragService *RAGService
+	userRepo          *repository.UserRepository
+	memoryRepo        *repository.MemoryRepository
+	todoRepo          *repository.TodoRepository
+	groupRepo         *repository.GroupRepository
+	vectorRepo        *repository.VectorRepository
+	ragService        *RAGService
+	aiProviderService *AIProviderService
+	auditService      *AuditService
+	authService       *SupabaseAuthService
+	blocklist         *BlocklistService
+	attachments       *AttachmentService
+	briefings         *DailyBriefingService
+
+	// Last account deletion attempt per user, for rate limiting
+	deletionMu       sync.Mutex
+	deletionAttempts map[string]time.Time
 }
 
 func NewUserDataService(
+	userRepo *repository.UserRepository,
 	memoryRepo *repository.MemoryRepository,
 	todoRepo *repository.TodoRepository,
 	groupRepo *repository.GroupRepository,
 	vectorRepo *repository.VectorRepository,
 	ragService *RAGService,
+	aiProviderService *AIProviderService,
+	auditService *AuditService,
+	authService *SupabaseAuthService,
+	blocklist *BlocklistService,
+	attachments *AttachmentService,
 ) *UserDataService {
 	return &UserDataService{
-		memoryRepo: memoryRepo,
-		todoRepo:   todoRepo,
-		groupRepo:  groupRepo,
-		vectorRepo: vectorRepo,
-		ragService: ragService,
+		userRepo:          userRepo,
+		memoryRepo:        memoryRepo,
+		todoRepo:          todoRepo,
+		groupRepo:         groupRepo,
+		vectorRepo:        vectorRepo,
+		ragService:        ragService,
+		aiProviderService: aiProviderService,
+		auditService:      auditService,
+		authService:       authService,
+		blocklist:         blocklist,
+		attachments:       attachments,
+
+		deletionAttempts: make(map[string]time.Time),
 	}
 }
 
@@ -57,6 +100,11 @@ type DataStats struct {
 	CustomGroupCount int `json:"custom_group_count"`
 }
 
+// SetDailyBriefingService makes clearing data drop the user's cached daily briefings
+func (s *UserDataService) SetDailyBriefingService(briefings *DailyBriefingService) {
+	s.briefings = briefings
+}
+
 // ClearAllMemories deletes all memories for a user
 // Deletion order: Vector DB → SQL DB → FTS (auto via trigger)
 func (s *UserDataService) ClearAllMemories(userID string) (*ClearMemoriesResult, error) {
@@ -84,10 +132,13 @@ func (s *UserDataService) ClearAllMemories(userID string) (*ClearMemoriesResult,
 		return nil, fmt.Errorf("failed to count memories: %w", err)
 	}
 
+	attachmentKeys := s.userAttachmentKeys(userID)
 	rowsAffected, err := s.memoryRepo.DeleteAllByUserID(userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete memories: %w", err)
 	}
+	s.attachments.DeleteObjects(ctx, attachmentKeys...)
+	s.briefings.Invalidate(userID)
 
 	result.MemoriesDeleted = int(rowsAffected)
 	result.Success = true
@@ -100,12 +151,17 @@ func (s *UserDataService) ClearAllMemories(userID string) (*ClearMemoriesResult,
 // ClearAllData deletes all todos, memories, and custom groups for a user
 // Keeps: AI providers, default groups
 // Deletes: Custom groups, all todos, all memories
-func (s *UserDataService) ClearAllData(userID string) (*ClearAllResult, error) {
+func (s *UserDataService) ClearAllData(userID, ipAddress string) (*ClearAllResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
 	log.Printf("[UserDataService] Starting ClearAllData for user: %s", userID)
 
+	// Audit before deleting anything so the entry exists even if a step fails
+	s.auditService.Log(userID, models.AuditActionDataCleared, map[string]string{
+		"scope": "all",
+	}, ipAddress)
+
 	result := &ClearAllResult{Success: false}
 
 	// Step 1: Delete ALL vector embeddings for user (both todos and memories)
@@ -118,11 +174,13 @@ func (s *UserDataService) ClearAllData(userID string) (*ClearAllResult, error) {
 		}
 	}
 
-	// Step 2: Delete memories from SQL
+	// Step 2: Delete memories from SQL, then their attachment files
+	attachmentKeys := s.userAttachmentKeys(userID)
 	memoriesDeleted, err := s.memoryRepo.DeleteAllByUserID(userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete memories: %w", err)
 	}
+	s.attachments.DeleteObjects(ctx, attachmentKeys...)
 	result.MemoriesDeleted = int(memoriesDeleted)
 	log.Printf("[UserDataService] Deleted %d memories", memoriesDeleted)
 
@@ -142,6 +200,8 @@ func (s *UserDataService) ClearAllData(userID string) (*ClearAllResult, error) {
 	result.CustomGroupsDeleted = int(groupsDeleted)
 	log.Printf("[UserDataService] Deleted %d custom groups", groupsDeleted)
 
+	s.briefings.Invalidate(userID)
+
 	result.Success = true
 	log.Printf("[UserDataService] ClearAllData complete: memories=%d, todos=%d, groups=%d",
 		memoriesDeleted, todosDeleted, groupsDeleted)
@@ -149,6 +209,16 @@ func (s *UserDataService) ClearAllData(userID string) (*ClearAllResult, error) {
 	return result, nil
 }
 
+// userAttachmentKeys returns the storage keys of the user's memory attachments, to be
+// deleted once the memories are
+func (s *UserDataService) userAttachmentKeys(userID string) []string {
+	keys, err := s.attachments.UserAttachmentKeys(userID)
+	if err != nil {
+		log.Printf("[UserDataService] Warning: Failed to look up attachments: %v (continuing)", err)
+	}
+	return keys
+}
+
 // GetDataStats returns counts of user data (for confirmation UI)
 func (s *UserDataService) GetDataStats(userID string) (*DataStats, error) {
 	stats := &DataStats{}
@@ -173,3 +243,76 @@ func (s *UserDataService) GetDataStats(userID string) (*DataStats, error) {
 
 	return stats, nil
 }
+
+// DeleteAccount permanently deletes a user's account after confirming their password.
+// SSO users, who have no password, instead confirm by signing in at their provider
+// again: authTime, when the request's token says they last did, must be within
+// AccountDeletionReauthMaxAge. All SQL data and the user row are removed in one
+// transaction; vector embeddings and the Supabase auth user are removed afterwards,
+// and the user's tokens are revoked.
+func (s *UserDataService) DeleteAccount(userID, password string, authTime time.Time) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("user not found")
+	}
+
+	if password == "" && user.OIDCSub != nil {
+		if authTime.IsZero() || time.Since(authTime) > AccountDeletionReauthMaxAge {
+			return ErrReauthenticationRequired
+		}
+	} else {
+		// Rate limit attempts (successful or not) to slow down password guessing
+		s.deletionMu.Lock()
+		if last, ok := s.deletionAttempts[userID]; ok && time.Since(last) < AccountDeletionCooldown {
+			s.deletionMu.Unlock()
+			return ErrAccountDeletionRateLimited
+		}
+		s.deletionAttempts[userID] = time.Now()
+		s.deletionMu.Unlock()
+
+		if password == "" {
+			return ErrInvalidPassword
+		}
+		if err := s.authService.VerifyPassword(user, password); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[UserDataService] Deleting account for user: %s", userID)
+
+	// Step 1: Delete all SQL data and the user row in a single transaction
+	attachmentKeys := s.userAttachmentKeys(userID)
+	if err := s.userRepo.DeleteWithAllData(userID); err != nil {
+		return fmt.Errorf("failed to delete account data: %w", err)
+	}
+	s.briefings.Invalidate(userID)
+
+	// Step 2: Delete vector embeddings and attachment files (separate stores, can't join the transaction)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	if s.vectorRepo != nil {
+		if err := s.vectorRepo.DeleteAllByUser(ctx, userID); err != nil {
+			log.Printf("[UserDataService] Warning: Vector DB deletion failed: %v (continuing)", err)
+		}
+	}
+	s.attachments.DeleteObjects(ctx, attachmentKeys...)
+
+	if user.SupabaseID == nil {
+		log.Printf("[UserDataService] Account deleted for user: %s", userID)
+		return nil
+	}
+
+	// Step 3: Reject the user's outstanding access tokens so the account isn't recreated on the next request
+	s.authService.RevokeSubject(*user.SupabaseID)
+
+	// Step 4: Delete the Supabase auth user, which also revokes refresh tokens
+	if err := s.authService.DeleteSupabaseUser(*user.SupabaseID); err != nil {
+		return fmt.Errorf("account data deleted but failed to delete login: %w", err)
+	}
+
+	log.Printf("[UserDataService] Account deleted for user: %s", userID)
+	return nil
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/todomyday/backend/internal/metrics"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+// PreferencesCacheTTL is how long a user's preferences are served from memory
+const PreferencesCacheTTL = 5 * time.Minute
+
+// preferencesCacheMaxEntries bounds the cache; expired entries are swept when it fills
+const preferencesCacheMaxEntries = 10000
+
+var (
+	ErrInvalidSortMode  = errors.New("sort_mode must be manual, newest, updated, alphabetical or category")
+	ErrInvalidDailyGoal = fmt.Errorf("daily_goal must be between 1 and %d", models.MaxDailyGoal)
+)
+
+// UserPreferencesService reads and stores per-user display preferences
+type UserPreferencesService struct {
+	userRepo    *repository.UserRepository
+	todoService *TodoService
+
+	// Preferences keyed by user ID. Writes through this service drop the user's entry.
+	cache *ttlCache[models.UserPreferences]
+}
+
+func NewUserPreferencesService(userRepo *repository.UserRepository) *UserPreferencesService {
+	return &UserPreferencesService{
+		userRepo: userRepo,
+		cache:    newTTLCache[models.UserPreferences](metrics.CachePreferences, PreferencesCacheTTL, preferencesCacheMaxEntries),
+	}
+}
+
+// SetTodoService lets turning auto-priority on and off update the user's todos
+func (s *UserPreferencesService) SetTodoService(todoService *TodoService) {
+	s.todoService = todoService
+}
+
+// GetSortMode returns the user's memory sort mode, falling back to manual order when
+// none is stored or it can't be read
+func (s *UserPreferencesService) GetSortMode(userID string) string {
+	if s == nil {
+		return models.MemorySortManual
+	}
+	sortMode, err := s.userRepo.GetSortMode(userID)
+	if err != nil || !models.MemorySortModes[sortMode] {
+		return models.MemorySortManual
+	}
+	return sortMode
+}
+
+func (s *UserPreferencesService) SetSortMode(userID, sortMode string) error {
+	if !models.MemorySortModes[sortMode] {
+		return ErrInvalidSortMode
+	}
+	return s.userRepo.SetSortMode(userID, sortMode)
+}
+
+// GetDailyGoal returns the number of todos the user aims to complete each day,
+// falling back to models.DefaultDailyGoal when none is stored or it can't be read
+func (s *UserPreferencesService) GetDailyGoal(userID string) int {
+	if s == nil {
+		return models.DefaultDailyGoal
+	}
+	dailyGoal, err := s.userRepo.GetDailyGoal(userID)
+	if err != nil || dailyGoal <= 0 {
+		return models.DefaultDailyGoal
+	}
+	return dailyGoal
+}
+
+func (s *UserPreferencesService) SetDailyGoal(userID string, dailyGoal int) error {
+	if dailyGoal < 1 || dailyGoal > models.MaxDailyGoal {
+		return ErrInvalidDailyGoal
+	}
+	return s.userRepo.SetDailyGoal(userID, dailyGoal)
+}
+
+// GetPreferences returns the user's preferences, with defaults for those never set
+func (s *UserPreferencesService) GetPreferences(userID string) (*models.UserPreferences, error) {
+	if preferences, ok := s.cache.Get(userID); ok {
+		return &preferences, nil
+	}
+
+	stored, err := s.userRepo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	preferences := models.UserPreferences{
+		AIProcessTodos:    preferenceBool(stored, models.PreferenceAIProcessTodos, true),
+		AIProcessMemories: preferenceBool(stored, models.PreferenceAIProcessMemories, true),
+		AutoPriority:      preferenceBool(stored, models.PreferenceAutoPriority, false),
+	}
+
+	s.cache.Set(userID, preferences)
+	return &preferences, nil
+}
+
+// UpdatePreferences stores the preferences set in req and returns them all
+func (s *UserPreferencesService) UpdatePreferences(userID string, req *models.UserPreferencesUpdateRequest) (*models.UserPreferences, error) {
+	updates := make(map[string]string)
+	if req.AIProcessTodos != nil {
+		updates[models.PreferenceAIProcessTodos] = strconv.FormatBool(*req.AIProcessTodos)
+	}
+	if req.AIProcessMemories != nil {
+		updates[models.PreferenceAIProcessMemories] = strconv.FormatBool(*req.AIProcessMemories)
+	}
+	if req.AutoPriority != nil {
+		updates[models.PreferenceAutoPriority] = strconv.FormatBool(*req.AutoPriority)
+	}
+
+	if len(updates) > 0 {
+		if err := s.userRepo.SetPreferences(userID, updates); err != nil {
+			return nil, err
+		}
+		s.cache.Delete(userID)
+	}
+
+	if req.AutoPriority != nil && s.todoService != nil {
+		s.autoPriorityChanged(userID, *req.AutoPriority)
+	}
+	return s.GetPreferences(userID)
+}
+
+// autoPriorityChanged applies auto-priority to the user's todos right away when it's
+// turned on, rather than at the next run of the job, and restores their original
+// priorities when it's turned off. Failures are only logged, since the preference
+// is stored by then.
+func (s *UserPreferencesService) autoPriorityChanged(userID string, enabled bool) {
+	if enabled {
+		if _, err := s.todoService.ApplyAutoPriority(userID); err != nil {
+			log.Printf("[UserPreferencesService] Failed to apply auto-priority for user %s: %v", userID, err)
+		}
+		return
+	}
+	if err := s.todoService.RestoreOriginalPriorities(userID); err != nil {
+		log.Printf("[UserPreferencesService] Failed to restore priorities for user %s: %v", userID, err)
+	}
+}
+
+// AIProcessTodos reports whether the AI may rewrite and tag the user's new todos.
+// It defaults to true, including when the preference can't be read.
+func (s *UserPreferencesService) AIProcessTodos(userID string) bool {
+	if s == nil {
+		return true
+	}
+	preferences, err := s.GetPreferences(userID)
+	if err != nil {
+		log.Printf("[UserPreferencesService] Failed to read preferences for user %s: %v", userID, err)
+		return true
+	}
+	return preferences.AIProcessTodos
+}
+
+// AIProcessMemories reports whether the AI may categorize and summarize the user's
+// new memories. It defaults to true, including when the preference can't be read.
+func (s *UserPreferencesService) AIProcessMemories(userID string) bool {
+	if s == nil {
+		return true
+	}
+	preferences, err := s.GetPreferences(userID)
+	if err != nil {
+		log.Printf("[UserPreferencesService] Failed to read preferences for user %s: %v", userID, err)
+		return true
+	}
+	return preferences.AIProcessMemories
+}
+
+// AutoPriority reports whether the user's todo priorities follow their due dates.
+// It defaults to false, including when the preference can't be read.
+func (s *UserPreferencesService) AutoPriority(userID string) bool {
+	if s == nil {
+		return false
+	}
+	preferences, err := s.GetPreferences(userID)
+	if err != nil {
+		log.Printf("[UserPreferencesService] Failed to read preferences for user %s: %v", userID, err)
+		return false
+	}
+	return preferences.AutoPriority
+}
+
+// preferenceBool parses a stored boolean preference, returning fallback when it's
+// missing or malformed
+func preferenceBool(stored map[string]string, key string, fallback bool) bool {
+	value, err := strconv.ParseBool(stored[key])
+	if err != nil {
+		return fallback
+	}
+	return value
+}
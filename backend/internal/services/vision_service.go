@@ -4,14 +4,21 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/todomyday/backend/internal/models"
 )
 
+// ErrModelDoesNotSupportVision is returned, before anything is sent, when the vision
+// model's name doesn't mark it as accepting images
+var ErrModelDoesNotSupportVision = errors.New("model does not support vision")
+
 // VisionService handles image analysis using GLM-4.5V
 type VisionService struct {
 	baseURL string
@@ -89,6 +96,9 @@ func (s *VisionService) ProcessImage(imageData []byte, mimeType string) (*Vision
 	if !s.IsConfigured() {
 		return nil, fmt.Errorf("vision service not configured")
 	}
+	if !ModelSupports(s.model, models.ModelCapabilityVision) {
+		return nil, fmt.Errorf("%w: %s", ErrModelDoesNotSupportVision, s.model)
+	}
 
 	// Convert image to base64 data URI
 	base64Image := base64.StdEncoding.EncodeToString(imageData)
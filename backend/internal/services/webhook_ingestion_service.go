@@ -0,0 +1,287 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+var (
+	ErrWebhookSourceNotFound   = newCodedError(models.ErrCodeNotFound, "webhook source not found")
+	ErrWebhookSourceExists     = errors.New("a webhook source with this name already exists")
+	ErrWebhookSourceUserAbsent = errors.New("webhook source user not found")
+	// ErrInvalidWebhookPayload is returned for a payload that isn't a JSON object, or
+	// one a rule matches but that has no title
+	ErrInvalidWebhookPayload = errors.New("invalid webhook payload")
+)
+
+// WebhookIngestionService turns webhooks from external services (GitHub, Linear,
+// Jira...) into todos and memories, following the routing rules of their source.
+// Source secrets are encrypted by the AI provider service, so rotating the
+// encryption key covers them.
+type WebhookIngestionService struct {
+	repo              *repository.InboundWebhookSourceRepository
+	userRepo          *repository.UserRepository
+	todoService       *TodoService
+	memoryService     *MemoryService
+	aiProviderService *AIProviderService
+}
+
+func NewWebhookIngestionService(repo *repository.InboundWebhookSourceRepository, userRepo *repository.UserRepository, todoService *TodoService, memoryService *MemoryService, aiProviderService *AIProviderService) *WebhookIngestionService {
+	return &WebhookIngestionService{
+		repo:              repo,
+		userRepo:          userRepo,
+		todoService:       todoService,
+		memoryService:     memoryService,
+		aiProviderService: aiProviderService,
+	}
+}
+
+// VerifyWebhookSignature reports whether signature, an X-Hub-Signature-256 header,
+// is the HMAC-SHA256 of payload keyed with secret
+func VerifyWebhookSignature(secret string, payload []byte, signature string) bool {
+	digest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	expected, err := hex.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// VerifySignature decrypts the source's secret and reports whether signature is
+// the HMAC-SHA256 of payload keyed with it. The secret is only ever decrypted here.
+func (s *WebhookIngestionService) VerifySignature(source *models.InboundWebhookSource, payload []byte, signature string) (bool, error) {
+	secret, err := s.aiProviderService.DecryptSecret(source.SecretEncrypted)
+	if err != nil {
+		return false, fmt.Errorf("failed to decrypt secret of webhook source %s: %w", source.Name, err)
+	}
+	return VerifyWebhookSignature(secret, payload, signature), nil
+}
+
+// GetEnabledSource returns the source named name, or nil if there is none or it is disabled
+func (s *WebhookIngestionService) GetEnabledSource(name string) (*models.InboundWebhookSource, error) {
+	source, err := s.repo.GetByName(name)
+	if err != nil || source == nil || !source.IsEnabled {
+		return nil, err
+	}
+	return source, nil
+}
+
+// Process creates what the first matching rule of the source asks for from a payload
+// whose signature has been checked. A payload no rule matches is accepted and ignored.
+func (s *WebhookIngestionService) Process(sourceName string, payload []byte) (*models.InboundWebhookResult, error) {
+	source, err := s.GetEnabledSource(sourceName)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, ErrWebhookSourceNotFound
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhookPayload, err)
+	}
+
+	result := &models.InboundWebhookResult{Source: source.Name}
+	rule, object := matchWebhookRule(source.Rules, body)
+	if rule == nil {
+		return result, nil
+	}
+
+	title := strings.TrimSpace(webhookField(object, rule.TitleField, "title"))
+	if title == "" {
+		return nil, fmt.Errorf("%w: %s has no title", ErrInvalidWebhookPayload, rule.Object)
+	}
+	text := joinNonEmpty(strings.TrimSpace(webhookField(object, rule.BodyField, "body")), webhookField(object, "html_url", ""))
+
+	switch rule.Create {
+	case models.InboundWebhookCreateTodo:
+		req := &models.TodoCreateRequest{Title: title}
+		if text != "" {
+			req.Description = &text
+		}
+		todo, err := s.todoService.Create(source.UserID, req)
+		if err != nil {
+			return nil, err
+		}
+		result.ID = todo.ID
+	case models.InboundWebhookCreateMemory:
+		memory, err := s.memoryService.Create(source.UserID, &models.MemoryCreateRequest{
+			Content:    joinNonEmpty(title, text),
+			ScrapeMode: models.ScrapeModeNone,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.ID = memory.ID
+	default:
+		return nil, fmt.Errorf("webhook source %s has a rule creating %q", source.Name, rule.Create)
+	}
+
+	result.Created = rule.Create
+	log.Printf("[Webhooks] %s webhook created %s %s for user %s", source.Name, result.Created, result.ID, source.UserID)
+	return result, nil
+}
+
+// matchWebhookRule returns the first rule matching body, and the object it matched
+func matchWebhookRule(rules []models.InboundWebhookRule, body map[string]interface{}) (*models.InboundWebhookRule, map[string]interface{}) {
+	action, _ := body["action"].(string)
+	for i := range rules {
+		rule := &rules[i]
+		object, ok := body[rule.Object].(map[string]interface{})
+		if !ok || (rule.Action != "" && rule.Action != action) {
+			continue
+		}
+		if rule.Merged != nil {
+			if merged, _ := object["merged"].(bool); merged != *rule.Merged {
+				continue
+			}
+		}
+		return rule, object
+	}
+	return nil, nil
+}
+
+// webhookField returns the string at the dotted path in object (def when path is
+// empty), or "" if there is none
+func webhookField(object map[string]interface{}, path, def string) string {
+	if path == "" {
+		path = def
+	}
+	if path == "" {
+		return ""
+	}
+
+	var value interface{} = object
+	for _, key := range strings.Split(path, ".") {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = fields[key]
+	}
+	text, _ := value.(string)
+	return text
+}
+
+func joinNonEmpty(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, "\n\n")
+}
+
+// GetAll returns every webhook source
+func (s *WebhookIngestionService) GetAll() ([]models.InboundWebhookSource, error) {
+	return s.repo.GetAll()
+}
+
+// Create adds a webhook source. Sources are enabled unless the request says otherwise.
+func (s *WebhookIngestionService) Create(req *models.InboundWebhookSourceCreateRequest) (*models.InboundWebhookSource, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	existing, err := s.repo.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrWebhookSourceExists
+	}
+	if err := s.checkUser(req.UserID); err != nil {
+		return nil, err
+	}
+
+	source := &models.InboundWebhookSource{
+		Name:      name,
+		UserID:    req.UserID,
+		Rules:     req.Rules,
+		IsEnabled: req.IsEnabled == nil || *req.IsEnabled,
+	}
+	if len(source.Rules) == 0 {
+		source.Rules = models.DefaultInboundWebhookRules
+	}
+
+	err = s.aiProviderService.StoreEncryptedSecret(req.Secret, func(ciphertext string) error {
+		source.SecretEncrypted = ciphertext
+		return s.repo.Create(source)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func (s *WebhookIngestionService) Update(id string, req *models.InboundWebhookSourceUpdateRequest) (*models.InboundWebhookSource, error) {
+	source, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, ErrWebhookSourceNotFound
+	}
+
+	if req.UserID != nil {
+		if err := s.checkUser(*req.UserID); err != nil {
+			return nil, err
+		}
+		source.UserID = *req.UserID
+	}
+	if req.Rules != nil {
+		source.Rules = *req.Rules
+	}
+	if req.IsEnabled != nil {
+		source.IsEnabled = *req.IsEnabled
+	}
+
+	if req.Secret != nil {
+		err = s.aiProviderService.StoreEncryptedSecret(*req.Secret, func(ciphertext string) error {
+			source.SecretEncrypted = ciphertext
+			return s.repo.Update(source)
+		})
+	} else {
+		err = s.repo.Update(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func (s *WebhookIngestionService) Delete(id string) error {
+	source, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		return ErrWebhookSourceNotFound
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *WebhookIngestionService) checkUser(userID string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrWebhookSourceUserAbsent
+	}
+	return nil
+}
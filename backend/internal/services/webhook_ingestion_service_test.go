@@ -0,0 +1,85 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/todomyday/backend/internal/crypto"
+	"github.com/todomyday/backend/internal/models"
+	"github.com/todomyday/backend/internal/repository"
+)
+
+func signWebhook(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookSignatureVerification(t *testing.T) {
+	db := newTestDB(t)
+	user := newTestUser(t, db, "webhooks@example.com")
+	aiProviderService := NewAIProviderService(repository.NewAIProviderRepository(db), crypto.NewEncryptor("test-key"), nil)
+	service := NewWebhookIngestionService(repository.NewInboundWebhookSourceRepository(db), repository.NewUserRepository(db), nil, nil, aiProviderService)
+
+	const secret = "0123456789abcdef-secret"
+	source, err := service.Create(&models.InboundWebhookSourceCreateRequest{Name: "GitHub", UserID: user.ID, Secret: secret})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	var stored string
+	if err := db.QueryRow("SELECT secret_encrypted FROM inbound_webhook_sources WHERE id = ?", source.ID).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stored, secret) {
+		t.Fatalf("secret is stored in plaintext: %q", stored)
+	}
+
+	payload := []byte(`{"action":"opened","issue":{"title":"Broken build"}}`)
+	valid := signWebhook(secret, payload)
+	tests := []struct {
+		name      string
+		payload   []byte
+		signature string
+		want      bool
+	}{
+		{"valid signature", payload, valid, true},
+		{"tampered payload", []byte(`{"action":"opened","issue":{"title":"Pay invoice"}}`), valid, false},
+		{"tampered signature", payload, valid[:len(valid)-1] + "0", false},
+		{"signed with another secret", payload, signWebhook("another-secret-of-16", payload), false},
+		{"missing sha256 prefix", payload, strings.TrimPrefix(valid, "sha256="), false},
+		{"not hex", payload, "sha256=not-hex", false},
+		{"missing signature", payload, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.VerifySignature(source, tt.payload, tt.signature)
+			if err != nil {
+				t.Fatalf("VerifySignature: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("VerifySignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("after key rotation", func(t *testing.T) {
+		if _, _, err := aiProviderService.RotateEncryptionKey("rotated-key"); err != nil {
+			t.Fatalf("RotateEncryptionKey: %v", err)
+		}
+		rotated, err := service.GetEnabledSource("github")
+		if err != nil || rotated == nil {
+			t.Fatalf("GetEnabledSource = %v, %v", rotated, err)
+		}
+		if rotated.SecretEncrypted == source.SecretEncrypted {
+			t.Error("secret was not re-encrypted")
+		}
+		if got, err := service.VerifySignature(rotated, payload, valid); err != nil || !got {
+			t.Errorf("VerifySignature = %v, %v; want true", got, err)
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlRedactedKeys are keys whose values are replaced with [REDACTED] before
+// storage, at any depth (matched case-insensitively)
+var yamlRedactedKeys = map[string]bool{
+	"password": true,
+	"secret":   true,
+	"token":    true,
+	"api_key":  true,
+}
+
+// yamlSection is the rendered text of one top-level key (or of a whole document
+// that isn't a mapping, with an empty key)
+type yamlSection struct {
+	key  string
+	text string
+}
+
+// parseYAMLFile flattens YAML into "dotted.path: value" lines in document order,
+// with lists rendered as numbered paragraphs and credentials redacted. A file too
+// long for one section is split at its top-level keys.
+func (s *FileParserService) parseYAMLFile(filename string, content []byte) ([]ParsedMemorySection, error) {
+	docs, err := decodeYAMLDocuments(content)
+	if err != nil {
+		return nil, &FileUploadError{
+			Code:    "parse_error",
+			Message: fmt.Sprintf("Invalid YAML: %v", err),
+		}
+	}
+
+	var parts []yamlSection
+	for _, doc := range docs {
+		root := resolveYAMLAlias(doc)
+		if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+			root = resolveYAMLAlias(root.Content[0])
+		}
+
+		if root.Kind != yaml.MappingNode {
+			if text := strings.TrimSpace(s.yamlToText(root, "")); text != "" {
+				parts = append(parts, yamlSection{text: text})
+			}
+			continue
+		}
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if text := strings.TrimSpace(s.yamlEntryToText(root.Content[i], root.Content[i+1], "")); text != "" {
+				parts = append(parts, yamlSection{key: root.Content[i].Value, text: text})
+			}
+		}
+	}
+
+	if len(parts) == 0 {
+		return nil, &FileUploadError{
+			Code:    "empty_file",
+			Message: "YAML file contains no data",
+		}
+	}
+
+	texts := make([]string, len(parts))
+	for i, part := range parts {
+		texts[i] = part.text
+	}
+	if fullText := strings.Join(texts, "\n\n"); len(fullText) <= maxCharsPerSection {
+		return []ParsedMemorySection{
+			{
+				Content: fullText,
+				Heading: fmt.Sprintf("%s (YAML)", filename),
+				Order:   0,
+			},
+		}, nil
+	}
+
+	// Too long for one memory: one section per top-level key, with any key that is
+	// still too long split into parts
+	sections := []ParsedMemorySection{}
+	for _, part := range parts {
+		heading := filename
+		if part.key != "" {
+			heading = fmt.Sprintf("%s: %s", filename, part.key)
+		}
+
+		chunks := []string{part.text}
+		if len(part.text) > maxCharsPerSection {
+			chunks = s.splitTextIntoChunks(part.text, maxCharsPerSection)
+		}
+		for i, chunk := range chunks {
+			sectionHeading := heading
+			if len(chunks) > 1 {
+				sectionHeading = fmt.Sprintf("%s (Part %d)", heading, i+1)
+			}
+			sections = append(sections, ParsedMemorySection{
+				Content: chunk,
+				Heading: sectionHeading,
+				Order:   len(sections),
+			})
+		}
+	}
+
+	return sections, nil
+}
+
+// decodeYAMLDocuments returns the node tree of each document in a (possibly
+// multi-document) YAML file
+func decodeYAMLDocuments(content []byte) ([]*yaml.Node, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	var docs []*yaml.Node
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, err
+		}
+
+		// Decoding into a value applies yaml.v3's limits on alias expansion, which
+		// rendering the node tree would otherwise skip (e.g. a "billion laughs" file)
+		if err := doc.Decode(new(interface{})); err != nil {
+			return nil, err
+		}
+		docs = append(docs, &doc)
+	}
+}
+
+// yamlToText renders a YAML node found at the dotted key path: mappings as one
+// "path.key: value" line per leaf, lists as numbered paragraphs under "path:"
+func (s *FileParserService) yamlToText(node *yaml.Node, path string) string {
+	node = resolveYAMLAlias(node)
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return ""
+		}
+		return s.yamlToText(node.Content[0], path)
+
+	case yaml.MappingNode:
+		var lines []string
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if line := s.yamlEntryToText(node.Content[i], node.Content[i+1], path); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return strings.Join(lines, "\n")
+
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			return ""
+		}
+		items := make([]string, len(node.Content))
+		for i, item := range node.Content {
+			// Paths restart inside each item; its later lines line up under the first
+			text := strings.TrimSpace(s.yamlToText(item, ""))
+			items[i] = fmt.Sprintf("%d. %s", i+1, indentLines(text, "   "))
+		}
+		list := strings.Join(items, "\n\n")
+		if path == "" {
+			return list
+		}
+		// Blank lines keep the list's paragraphs apart from the keys around it
+		return "\n" + path + ":\n\n" + list + "\n"
+
+	default:
+		if path == "" {
+			return node.Value
+		}
+		return path + ": " + node.Value
+	}
+}
+
+// yamlEntryToText renders one key/value pair of a mapping at path
+func (s *FileParserService) yamlEntryToText(key, value *yaml.Node, path string) string {
+	// "<<: *defaults" merges the aliased mapping's keys into this one
+	if key.Tag == "!!merge" {
+		return s.yamlToText(value, path)
+	}
+
+	keyPath := key.Value
+	if path != "" {
+		keyPath = path + "." + key.Value
+	}
+
+	if yamlRedactedKeys[strings.ToLower(key.Value)] {
+		return keyPath + ": [REDACTED]"
+	}
+	return s.yamlToText(value, keyPath)
+}
+
+// resolveYAMLAlias follows *alias references to the anchored node
+func resolveYAMLAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// indentLines prefixes every non-empty line after the first with indent
+func indentLines(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
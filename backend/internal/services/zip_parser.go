@@ -0,0 +1,164 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/todomyday/backend/internal/models"
+)
+
+// maxZipEntries caps how many notes a single archive may contain
+const maxZipEntries = 200
+
+// VaultParseResult holds the sections extracted from a ZIP archive along with
+// the notes that were skipped (empty) or failed to parse
+type VaultParseResult struct {
+	Sections []ParsedMemorySection
+	Skipped  int
+	Errors   []models.VaultImportError
+}
+
+// parseZipFile extracts sections from every note in a ZIP archive (e.g. an Obsidian vault
+// or Apple Notes export)
+func (s *FileParserService) parseZipFile(filename string, content []byte) ([]ParsedMemorySection, error) {
+	result, err := s.ParseVaultZip(content)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entryErr := range result.Errors {
+		log.Printf("[FileParser] Skipping %s in %s: %s", entryErr.File, filename, entryErr.Error)
+	}
+
+	if len(result.Sections) == 0 {
+		return nil, &FileUploadError{
+			Code:    "empty_file",
+			Message: "Archive contains no .md, .txt or .html notes with content",
+		}
+	}
+
+	return result.Sections, nil
+}
+
+// ParseVaultZip walks a ZIP archive and parses each .md and .txt note, and each .html
+// note exported from Apple Notes. Each section's heading is prefixed with the note's
+// folder path so the vault structure is kept as a breadcrumb. Unreadable notes are
+// reported in Errors rather than failing the import.
+func (s *FileParserService) ParseVaultZip(content []byte) (*VaultParseResult, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, &FileUploadError{
+			Code:    "parse_error",
+			Message: fmt.Sprintf("Failed to parse ZIP: %v", err),
+		}
+	}
+
+	var notes []*zip.File
+	for _, f := range zr.File {
+		if isVaultNote(f) {
+			notes = append(notes, f)
+		}
+	}
+
+	if len(notes) > maxZipEntries {
+		return nil, &FileUploadError{
+			Code:    "too_many_files",
+			Message: fmt.Sprintf("Archive contains %d notes (limit %d)", len(notes), maxZipEntries),
+		}
+	}
+
+	// Import in a stable folder/file order regardless of how the archive was written
+	sort.Slice(notes, func(i, j int) bool { return notes[i].Name < notes[j].Name })
+
+	result := &VaultParseResult{Sections: []ParsedMemorySection{}}
+
+	for _, f := range notes {
+		data, err := readZipNote(f)
+		if err != nil {
+			result.Errors = append(result.Errors, models.VaultImportError{File: f.Name, Error: err.Error()})
+			continue
+		}
+
+		base := path.Base(f.Name)
+		var sections []ParsedMemorySection
+		source := SectionSourceZip
+		switch strings.ToLower(path.Ext(base)) {
+		case ".md":
+			sections, err = s.parseMarkdownFile(base, data)
+		case ".html":
+			sections, err = parseAppleNote(strings.TrimSuffix(base, path.Ext(base)), data)
+			source = SectionSourceAppleNotes
+		default:
+			sections, err = s.parseTxtFile(base, data)
+		}
+		if err != nil {
+			if uploadErr, ok := err.(*FileUploadError); ok && uploadErr.Code == "empty_file" {
+				result.Skipped++
+				continue
+			}
+			result.Errors = append(result.Errors, models.VaultImportError{File: f.Name, Error: err.Error()})
+			continue
+		}
+
+		breadcrumb := vaultBreadcrumb(f.Name)
+		for _, section := range sections {
+			section.Heading = breadcrumb + section.Heading
+			section.Order = len(result.Sections)
+			section.Source = source
+			result.Sections = append(result.Sections, section)
+		}
+	}
+
+	return result, nil
+}
+
+// isVaultNote reports whether a ZIP entry is a note worth importing, ignoring
+// directories, hidden files and folders (.obsidian, .trash) and macOS metadata
+func isVaultNote(f *zip.File) bool {
+	if f.FileInfo().IsDir() {
+		return false
+	}
+
+	for _, part := range strings.Split(f.Name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return false
+		}
+	}
+
+	ext := strings.ToLower(path.Ext(f.Name))
+	return ext == ".md" || ext == ".txt" || ext == ".html"
+}
+
+// readZipNote reads a single note, capped at the regular per-file size limit
+func readZipNote(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > MaxFileSize {
+		return nil, fmt.Errorf("file exceeds %dMB limit", MaxFileSize/(1024*1024))
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, MaxFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %w", err)
+	}
+	return data, nil
+}
+
+// vaultBreadcrumb turns "Projects/Work/note.md" into "Projects > Work > "
+func vaultBreadcrumb(name string) string {
+	dir := path.Dir(name)
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return strings.Join(strings.Split(strings.Trim(dir, "/"), "/"), " > ") + " > "
+}
@@ -0,0 +1,80 @@
+// Package tracing configures OpenTelemetry and holds the span attribute keys
+// shared by the instrumented services.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies this backend in exported traces
+const ServiceName = "todomyday-backend"
+
+// Span attribute keys
+const (
+	AttrProviderType = attribute.Key("ai.provider_type")
+	AttrModel        = attribute.Key("ai.model")
+	AttrInputTokens  = attribute.Key("ai.input_tokens")
+	AttrOutputTokens = attribute.Key("ai.output_tokens")
+	AttrUserID       = attribute.Key("user.id")
+	AttrDBLatencyMs  = attribute.Key("db.latency_ms")
+)
+
+// Config selects the trace exporter. With neither option set the global no-op
+// tracer stays in place, so spans cost nothing.
+type Config struct {
+	// OTLPEndpoint is OTEL_EXPORTER_OTLP_ENDPOINT; the exporter reads the rest of the OTEL_* env
+	OTLPEndpoint string
+	// Stdout prints spans to stdout for local development
+	Stdout bool
+}
+
+// Init installs the global tracer provider and returns a function that flushes
+// and stops it on shutdown
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch {
+	case cfg.OTLPEndpoint != "":
+		exporter, err = otlptracehttp.New(ctx)
+	case cfg.Stdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if cfg.OTLPEndpoint != "" {
+		log.Printf("Tracing enabled, exporting to %s", cfg.OTLPEndpoint)
+	} else {
+		log.Println("Tracing enabled, exporting to stdout")
+	}
+	return provider.Shutdown, nil
+}
+
+// Detach returns a context for background work that outlives the request: it
+// carries ctx's span, so the work joins the request's trace, but not its cancellation
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
@@ -4,30 +4,54 @@ export interface AIProvider {
   id: string;
   user_id: string;
   name: string;
-  provider_type: 'openai' | 'anthropic' | 'google' | 'custom';
+  provider_type: 'openai' | 'anthropic' | 'google' | 'custom' | 'assistant' | 'ollama';
   base_url: string;
   api_key_masked?: string;
   selected_model?: string | null;
   is_default: boolean;
   is_enabled: boolean;
+  requests_per_minute: number;
+  rate_limit_remaining?: number;
+  timeout_seconds: number;
+  no_auth: boolean;
+  supports_structured_output: boolean;
+  default_temperature: number;
+  default_max_tokens: number;
+  embedding_model?: string | null;
+  embedding_dimension?: number | null;
+  // Provider tried next when a call to this one fails
+  fallback_provider_id?: string | null;
+  // Sent as the system message of every call, at most 2000 characters
+  system_prompt: string;
+  metadata?: Record<string, string>;
   created_at: string;
   updated_at: string;
 }
 
+export type ModelCapability = 'vision' | 'function_calling' | 'streaming' | 'structured_output';
+
 export interface AIProviderModel {
   id: string;
   provider_id: string;
   model_id: string;
   model_name: string;
+  capabilities: ModelCapability[];
   created_at: string;
 }
 
 export interface AIProviderCreate {
   name: string;
-  provider_type: 'openai' | 'anthropic' | 'google' | 'custom';
+  provider_type: 'openai' | 'anthropic' | 'google' | 'custom' | 'assistant' | 'ollama';
   base_url: string;
   api_key: string;
   is_default?: boolean;
+  requests_per_minute?: number;
+  timeout_seconds?: number;
+  no_auth?: boolean;
+  supports_structured_output?: boolean;
+  embedding_model?: string;
+  embedding_dimension?: number;
+  system_prompt?: string;
 }
 
 export interface AIProviderUpdate {
@@ -37,12 +61,25 @@ export interface AIProviderUpdate {
   selected_model?: string | null;
   is_default?: boolean;
   is_enabled?: boolean;
+  requests_per_minute?: number;
+  timeout_seconds?: number;
+  no_auth?: boolean;
+  supports_structured_output?: boolean;
+  // 0 to 2 and 1 to 32000
+  default_temperature?: number;
+  default_max_tokens?: number;
+  embedding_model?: string;
+  embedding_dimension?: number;
+  // An empty string clears the fallback
+  fallback_provider_id?: string;
+  system_prompt?: string;
 }
 
 export interface TestConnectionRequest {
-  provider_type: 'openai' | 'anthropic' | 'google' | 'custom';
+  provider_type: 'openai' | 'anthropic' | 'google' | 'custom' | 'assistant' | 'ollama';
   base_url: string;
   api_key: string;
+  no_auth?: boolean;
 }
 
 export interface TestConnectionResponse {
@@ -100,6 +137,8 @@ export const DEFAULT_BASE_URLS: Record<string, string> = {
   anthropic: 'https://api.anthropic.com/v1',
   google: 'https://generativelanguage.googleapis.com/v1beta',
   custom: '',
+  assistant: 'https://api.openai.com/v1',
+  ollama: 'http://localhost:11434/v1',
 };
 
 export const PROVIDER_LABELS: Record<string, string> = {
@@ -107,4 +146,6 @@ export const PROVIDER_LABELS: Record<string, string> = {
   anthropic: 'Anthropic',
   google: 'Google',
   custom: 'Custom (OpenAI-compatible)',
+  assistant: 'OpenAI Assistant',
+  ollama: 'Ollama (local)',
 };
@@ -1,5 +1,5 @@
 import client from './client';
-import { User } from '../types';
+import { OIDCInitiateResponse, OIDCLoginResponse, OIDCProvider, Session, User } from '../types';
 
 export interface LoginRequest {
   email: string;
@@ -30,4 +30,40 @@ export const authApi = {
     const response = await client.get('/auth/me');
     return response.data.user;
   },
+
+  updateMe: async (data: { timezone?: string; email_digest_enabled?: boolean }): Promise<User> => {
+    const response = await client.patch('/auth/me', data);
+    return response.data.user;
+  },
+
+  getSessions: async (): Promise<Session[]> => {
+    const response = await client.get('/auth/sessions');
+    return response.data.sessions;
+  },
+
+  revokeSession: async (id: string): Promise<void> => {
+    await client.delete(`/auth/sessions/${id}`);
+  },
+
+  revokeOtherSessions: async (): Promise<number> => {
+    const response = await client.delete('/auth/sessions');
+    return response.data.revoked;
+  },
+
+  getOIDCProviders: async (): Promise<OIDCProvider[]> => {
+    const response = await client.get('/auth/oidc/providers');
+    return response.data.providers;
+  },
+
+  initiateOIDC: async (): Promise<OIDCInitiateResponse> => {
+    // The state cookie set here is checked by the callback
+    const response = await client.get('/auth/oidc/initiate', { withCredentials: true });
+    return response.data;
+  },
+
+  // Completes a sign-in with the code and state the provider redirected back with
+  completeOIDC: async (code: string, state: string): Promise<OIDCLoginResponse> => {
+    const response = await client.get('/auth/oidc/callback', { params: { code, state }, withCredentials: true });
+    return response.data;
+  },
 };
@@ -1,8 +1,11 @@
 import client from './client';
+import { supabase } from '../lib/supabase';
+import type { RAGSearchResult, Todo } from '../types';
 
 export interface ChatThread {
   id: string;
   user_id: string;
+  title: string | null;
   created_at: string;
   updated_at: string;
 }
@@ -14,6 +17,7 @@ export interface ChatMessage {
   content: string;
   mode?: string;
   sources?: string;
+  is_partial: boolean;
   created_at: string;
 }
 
@@ -33,6 +37,29 @@ export interface ChatMessageCreateRequest {
   sources?: string;
 }
 
+export interface ChatAskRequest {
+  question: string;
+  mode?: string;
+  content_types?: string[];
+  max_context?: number;
+  // Lets the assistant act on instructions like "add a todo to ..."
+  allow_actions?: boolean;
+}
+
+export interface ChatAskResponse {
+  user_message: ChatMessage;
+  assistant_message: ChatMessage;
+  sources: RAGSearchResult[];
+  time_taken_ms: number;
+  created_todo?: Todo;
+}
+
+export type ChatStreamEvent =
+  | { type: 'thinking' }
+  | { type: 'token'; content: string }
+  | { type: 'done'; message_id: string; created_todo?: Todo }
+  | { type: 'error'; error: string };
+
 export const chatApi = {
   getActiveThread: async (): Promise<ChatThreadResponse> => {
     const response = await client.get('/chat/threads/active');
@@ -59,6 +86,54 @@ export const chatApi = {
     return response.data;
   },
 
+  ask: async (threadId: string, request: ChatAskRequest): Promise<ChatAskResponse> => {
+    const response = await client.post(`/chat/threads/${threadId}/ask`, request);
+    return response.data;
+  },
+
+  // Streams the answer as server-sent events; abort the signal to stop (the text so far is kept as a partial message)
+  askStream: async (
+    threadId: string,
+    request: ChatAskRequest,
+    onEvent: (event: ChatStreamEvent) => void,
+    signal?: AbortSignal
+  ): Promise<void> => {
+    const { data: { session } } = await supabase.auth.getSession();
+    const response = await fetch(`${client.defaults.baseURL}/chat/threads/${threadId}/ask/stream`, {
+      method: 'POST',
+      headers: {
+        'Content-Type': 'application/json',
+        ...(session?.access_token ? { Authorization: `Bearer ${session.access_token}` } : {}),
+      },
+      body: JSON.stringify(request),
+      signal,
+    });
+    if (!response.ok || !response.body) {
+      throw new Error(`Chat stream failed: ${response.status}`);
+    }
+
+    const reader = response.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = '';
+    for (;;) {
+      const { done, value } = await reader.read();
+      if (done) break;
+      buffer += decoder.decode(value, { stream: true });
+      const events = buffer.split('\n\n');
+      buffer = events.pop() ?? '';
+      for (const event of events) {
+        if (event.startsWith('data: ')) {
+          onEvent(JSON.parse(event.slice(6)));
+        }
+      }
+    }
+  },
+
+  updateThreadTitle: async (threadId: string, title: string): Promise<{ thread: ChatThread }> => {
+    const response = await client.patch(`/chat/threads/${threadId}/title`, { title });
+    return response.data;
+  },
+
   deleteThread: async (threadId: string): Promise<void> => {
     await client.delete(`/chat/threads/${threadId}`);
   },
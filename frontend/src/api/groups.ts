@@ -1,9 +1,12 @@
 import client from './client';
-import { Group, GroupCreate, GroupUpdate } from '../types';
+import { Group, GroupColorPreview, GroupCreate, GroupStats, GroupUpdate, Todo } from '../types';
+import type { TodoReorderRequest } from './todos';
 
 export const groupApi = {
-  getAll: async (): Promise<Group[]> => {
-    const response = await client.get('/groups');
+  getAll: async (includeArchived = false): Promise<Group[]> => {
+    const response = await client.get('/groups', {
+      params: includeArchived ? { include_archived: true } : undefined,
+    });
     return response.data.groups;
   },
 
@@ -12,6 +15,30 @@ export const groupApi = {
     return response.data.group;
   },
 
+  getStats: async (id: string): Promise<GroupStats> => {
+    const response = await client.get(`/groups/${id}/stats`);
+    return response.data.stats;
+  },
+
+  getChildren: async (id: string): Promise<Group[]> => {
+    const response = await client.get(`/groups/${id}/children`);
+    return response.data.groups;
+  },
+
+  getColorPreview: async (id: string): Promise<GroupColorPreview> => {
+    const response = await client.get(`/groups/${id}/color-preview`);
+    return response.data;
+  },
+
+  getTodos: async (id: string): Promise<Todo[]> => {
+    const response = await client.get(`/groups/${id}/todos`);
+    return response.data.todos;
+  },
+
+  reorderTodos: async (id: string, data: TodoReorderRequest): Promise<void> => {
+    await client.put(`/groups/${id}/todos/reorder`, data);
+  },
+
   create: async (data: GroupCreate): Promise<Group> => {
     const response = await client.post('/groups', data);
     return response.data.group;
@@ -25,4 +52,14 @@ export const groupApi = {
   delete: async (id: string): Promise<void> => {
     await client.delete(`/groups/${id}`);
   },
+
+  archive: async (id: string): Promise<Group> => {
+    const response = await client.put(`/groups/${id}/archive`);
+    return response.data.group;
+  },
+
+  unarchive: async (id: string): Promise<Group> => {
+    const response = await client.put(`/groups/${id}/unarchive`);
+    return response.data.group;
+  },
 };
@@ -6,8 +6,12 @@ export { ragApi } from './rag';
 export { default as aiProviderApi } from './aiProviders';
 export { userDataApi } from './userData';
 export { chatApi } from './chat';
+export { searchApi } from './search';
+export { ipAllowlistApi } from './ipAllowlist';
+export { todoTemplateApi } from './todoTemplates';
 export type { LoginRequest, RegisterRequest } from './auth';
-export type { TodoReorderRequest } from './todos';
+export type { TodoReorderRequest, TodoFilter } from './todos';
+export type { MemorySortMode } from './memories';
 export type {
   AIProvider,
   AIProviderModel,
@@ -16,5 +20,5 @@ export type {
   TestConnectionRequest,
   TestConnectionResponse
 } from './aiProviders';
-export type { DataStats, ClearMemoriesResult, ClearAllResult } from './userData';
+export type { DataStats, ClearMemoriesResult, ClearAllResult, DataImportCounts, DataImportResult } from './userData';
 export { DEFAULT_BASE_URLS, PROVIDER_LABELS } from './aiProviders';
@@ -0,0 +1,23 @@
+import client from './client';
+import { IPAllowlistEntry } from '../types';
+
+export const ipAllowlistApi = {
+  getAll: async (): Promise<{ entries: IPAllowlistEntry[]; client_ip: string }> => {
+    const response = await client.get('/settings/ip-allowlist');
+    return response.data;
+  },
+
+  create: async (data: { cidr: string; label?: string }): Promise<IPAllowlistEntry> => {
+    const response = await client.post('/settings/ip-allowlist', data);
+    return response.data.entry;
+  },
+
+  update: async (id: string, data: { cidr?: string; label?: string }): Promise<IPAllowlistEntry> => {
+    const response = await client.put(`/settings/ip-allowlist/${id}`, data);
+    return response.data.entry;
+  },
+
+  delete: async (id: string): Promise<void> => {
+    await client.delete(`/settings/ip-allowlist/${id}`);
+  },
+};
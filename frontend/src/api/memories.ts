@@ -1,24 +1,56 @@
 import client from './client';
 import {
+  Attachment,
   Memory,
   MemoryCategory,
   MemoryDigest,
+  MemoryRevision,
+  ShareLink,
+  SharedMemory,
   MemoryCreate,
+  MemoryBatchCreate,
+  MemoryBatchCreateResult,
   MemoryUpdate,
   MemorySearchParams,
+  MemoryBulkDeleteFilter,
+  MemoryBulkArchiveFilter,
   MemoryToTodoParams,
   MemoryStats,
+  MemoryPage,
+  KeywordScore,
   MemoryFileUploadResponse,
+  VaultImportResponse,
+  RSSImportRequest,
+  RSSImportResponse,
+  SavedRSSFeed,
   WebSearchResult,
   Todo,
 } from '../types';
 
+export type MemorySortMode = 'manual' | 'newest' | 'updated' | 'alphabetical' | 'category';
+
 export const memoryApi = {
+  getSortMode: async (): Promise<MemorySortMode> => {
+    const response = await client.get('/settings/memory-sort');
+    return response.data.sort_mode;
+  },
+
+  setSortMode: async (sortMode: MemorySortMode): Promise<MemorySortMode> => {
+    const response = await client.put('/settings/memory-sort', { sort_mode: sortMode });
+    return response.data.sort_mode;
+  },
+
   getAll: async (limit = 50, offset = 0): Promise<Memory[]> => {
     const response = await client.get('/memories', { params: { limit, offset } });
     return response.data.memories;
   },
 
+  // Unarchived memories newest first; stays consistent while memories are added or removed
+  getPage: async (after = '', limit = 50): Promise<MemoryPage> => {
+    const response = await client.get('/memories', { params: { after, limit } });
+    return response.data;
+  },
+
   getById: async (id: string): Promise<Memory> => {
     const response = await client.get(`/memories/${id}`);
     return response.data.memory;
@@ -29,6 +61,11 @@ export const memoryApi = {
     return response.data.memory;
   },
 
+  createBatch: async (data: MemoryBatchCreate): Promise<MemoryBatchCreateResult> => {
+    const response = await client.post('/memories/batch', data);
+    return response.data;
+  },
+
   update: async (id: string, data: MemoryUpdate): Promise<Memory> => {
     const response = await client.put(`/memories/${id}`, data);
     return response.data.memory;
@@ -38,6 +75,21 @@ export const memoryApi = {
     await client.delete(`/memories/${id}`);
   },
 
+  bulkDelete: async (filter: MemoryBulkDeleteFilter): Promise<{ deleted: number }> => {
+    const response = await client.delete('/memories/bulk', { params: { confirm: true }, data: filter });
+    return response.data;
+  },
+
+  bulkArchive: async (filter: MemoryBulkArchiveFilter): Promise<{ archived: number }> => {
+    const response = await client.post('/memories/bulk/archive', filter);
+    return response.data;
+  },
+
+  bulkUnarchive: async (filter: MemoryBulkArchiveFilter): Promise<{ unarchived: number }> => {
+    const response = await client.post('/memories/bulk/unarchive', filter);
+    return response.data;
+  },
+
   getCategories: async (): Promise<MemoryCategory[]> => {
     const response = await client.get('/memories/categories');
     return response.data.categories;
@@ -60,6 +112,60 @@ export const memoryApi = {
     return response.data.todo;
   },
 
+  clone: async (id: string, overrides?: { content?: string; category?: string }): Promise<Memory> => {
+    const response = await client.post(`/memories/${id}/clone`, overrides || {});
+    return response.data.memory;
+  },
+
+  refreshUrl: async (id: string): Promise<Memory> => {
+    const response = await client.post(`/memories/${id}/refresh-url`);
+    return response.data.memory;
+  },
+
+  generateTitle: async (id: string): Promise<Memory> => {
+    const response = await client.post(`/memories/${id}/generate-title`);
+    return response.data.memory;
+  },
+
+  // The 10 most recent revisions, newest first
+  getRevisions: async (id: string): Promise<MemoryRevision[]> => {
+    const response = await client.get(`/memories/${id}/revisions`);
+    return response.data.revisions;
+  },
+
+  restoreRevision: async (id: string, revisionId: string): Promise<Memory> => {
+    const response = await client.post(`/memories/${id}/revisions/${revisionId}/restore`);
+    return response.data.memory;
+  },
+
+  // Public link to a memory; it expires after 7 days unless expires_in_days is set
+  share: async (id: string, options?: { expires_in_days?: number; max_views?: number }): Promise<ShareLink> => {
+    const response = await client.post(`/memories/${id}/share`, options || {});
+    return response.data;
+  },
+
+  revokeShares: async (id: string): Promise<number> => {
+    const response = await client.delete(`/memories/${id}/share`);
+    return response.data.revoked;
+  },
+
+  // Public endpoint; counts a view of the link
+  getShared: async (token: string): Promise<SharedMemory> => {
+    const response = await client.get(`/shared/${token}`);
+    return response.data.memory;
+  },
+
+  getRelated: async (id: string, limit = 10): Promise<Memory[]> => {
+    const response = await client.get(`/memories/${id}/related`, { params: { limit } });
+    return response.data.memories;
+  },
+
+  // Returns sanitized HTML rendered from the memory's Markdown content
+  getPreview: async (id: string): Promise<string> => {
+    const response = await client.get(`/memories/${id}/preview`, { responseType: 'text' });
+    return response.data;
+  },
+
   getDigest: async (): Promise<MemoryDigest | null> => {
     const response = await client.get('/memories/digest');
     return response.data.digest;
@@ -80,6 +186,13 @@ export const memoryApi = {
     return response.data;
   },
 
+  getKeywords: async (limit = 50, minFrequency = 2): Promise<KeywordScore[]> => {
+    const response = await client.get('/memories/keywords', {
+      params: { limit, min_frequency: minFrequency },
+    });
+    return response.data.keywords;
+  },
+
   uploadFile: async (file: File): Promise<MemoryFileUploadResponse> => {
     const formData = new FormData();
     formData.append('file', file);
@@ -105,6 +218,32 @@ export const memoryApi = {
     return response.data;
   },
 
+  importVault: async (file: File): Promise<VaultImportResponse> => {
+    const formData = new FormData();
+    formData.append('file', file);
+
+    const response = await client.post('/memories/import/vault', formData, {
+      headers: {
+        'Content-Type': 'multipart/form-data',
+      },
+    });
+    return response.data;
+  },
+
+  importRSS: async (data: RSSImportRequest): Promise<RSSImportResponse> => {
+    const response = await client.post('/memories/import/rss', data);
+    return response.data;
+  },
+
+  getRSSFeeds: async (): Promise<SavedRSSFeed[]> => {
+    const response = await client.get('/memories/rss-feeds');
+    return response.data.feeds;
+  },
+
+  deleteRSSFeed: async (id: string): Promise<void> => {
+    await client.delete(`/memories/rss-feeds/${id}`);
+  },
+
   getUploadJobStatus: async (jobId: string) => {
     const response = await client.get(`/memories/upload/jobs/${jobId}`);
     return response.data;
@@ -114,7 +253,7 @@ export const memoryApi = {
     await client.put('/memories/reorder', data);
   },
 
-  uploadImage: async (file: File): Promise<{ memory: Memory; vision_result: { content: string; summary: string; category: string; tags: string[] } }> => {
+  uploadImage: async (file: File): Promise<{ memory: Memory; vision_result: { content: string; summary: string; category: string; tags: string[] }; attachment?: Attachment }> => {
     const formData = new FormData();
     formData.append('image', file);
 
@@ -125,4 +264,9 @@ export const memoryApi = {
     });
     return response.data;
   },
+
+  getAttachmentUrl: async (memoryId: string, attachmentId: string): Promise<{ url: string; expires_at: string }> => {
+    const response = await client.get(`/memories/${memoryId}/attachments/${attachmentId}`);
+    return response.data;
+  },
 };
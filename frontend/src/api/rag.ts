@@ -1,5 +1,5 @@
 import client from './client';
-import type { RAGSearchResult, RAGAskResponse, RAGStats } from '../types';
+import type { RAGSearchResult, RAGAskResponse, RAGIndexQueueItem, RAGReindexResponse, RAGStats } from '../types';
 
 export interface RAGSearchParams {
   query: string;
@@ -13,6 +13,7 @@ export interface RAGAskParams {
   content_types?: ('todo' | 'memory')[];
   max_context?: number;
   mode?: 'memories' | 'internet' | 'hybrid' | 'llm';
+  allow_actions?: boolean;
 }
 
 export const ragApi = {
@@ -31,6 +32,21 @@ export const ragApi = {
     return response.data;
   },
 
+  getQueue: async (): Promise<RAGIndexQueueItem[]> => {
+    const response = await client.get('/rag/queue');
+    return response.data.items;
+  },
+
+  reindexMemory: async (id: string): Promise<RAGReindexResponse> => {
+    const response = await client.post(`/memories/${id}/reindex`);
+    return response.data;
+  },
+
+  reindexTodo: async (id: string): Promise<RAGReindexResponse> => {
+    const response = await client.post(`/todos/${id}/reindex`);
+    return response.data;
+  },
+
   getStats: async (): Promise<RAGStats> => {
     const response = await client.get('/rag/stats');
     return response.data;
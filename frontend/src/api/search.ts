@@ -0,0 +1,64 @@
+import client from './client';
+import type { RAGSearchResult } from '../types';
+
+export interface SearchHistoryEntry {
+  id: string;
+  user_id: string;
+  query: string;
+  content_types: string[];
+  result_count: number;
+  created_at: string;
+}
+
+export interface SavedSearch {
+  id: string;
+  user_id: string;
+  name: string;
+  query: string;
+  content_types: string[];
+  created_at: string;
+}
+
+export interface SavedSearchCreateRequest {
+  name: string;
+  query: string;
+  content_types?: ('todo' | 'memory')[];
+}
+
+export const searchApi = {
+  suggest: async (q: string, types?: Array<'todo' | 'memory'>, limit = 5): Promise<string[]> => {
+    const response = await client.get('/search/suggest', {
+      params: { q, types: types?.join(','), limit },
+    });
+    return response.data.suggestions;
+  },
+
+  getHistory: async (limit = 20): Promise<SearchHistoryEntry[]> => {
+    const response = await client.get('/search/history', { params: { limit } });
+    return response.data.history;
+  },
+
+  clearHistory: async (): Promise<{ deleted: number }> => {
+    const response = await client.delete('/search/history');
+    return response.data;
+  },
+
+  getSaved: async (): Promise<SavedSearch[]> => {
+    const response = await client.get('/search/saved');
+    return response.data.saved_searches;
+  },
+
+  createSaved: async (request: SavedSearchCreateRequest): Promise<SavedSearch> => {
+    const response = await client.post('/search/saved', request);
+    return response.data.saved_search;
+  },
+
+  deleteSaved: async (id: string): Promise<void> => {
+    await client.delete(`/search/saved/${id}`);
+  },
+
+  runSaved: async (id: string): Promise<RAGSearchResult[]> => {
+    const response = await client.post(`/search/saved/${id}/run`);
+    return response.data.results;
+  },
+};
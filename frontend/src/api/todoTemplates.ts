@@ -0,0 +1,39 @@
+import client from './client';
+import {
+  Todo,
+  TodoTemplate,
+  TodoTemplateApplyOptions,
+  TodoTemplateCreate,
+  TodoTemplateUpdate,
+} from '../types';
+
+export const todoTemplateApi = {
+  getAll: async (): Promise<TodoTemplate[]> => {
+    const response = await client.get('/todo-templates');
+    return response.data.templates;
+  },
+
+  getById: async (id: string): Promise<TodoTemplate> => {
+    const response = await client.get(`/todo-templates/${id}`);
+    return response.data.template;
+  },
+
+  create: async (data: TodoTemplateCreate): Promise<TodoTemplate> => {
+    const response = await client.post('/todo-templates', data);
+    return response.data.template;
+  },
+
+  update: async (id: string, data: TodoTemplateUpdate): Promise<TodoTemplate> => {
+    const response = await client.put(`/todo-templates/${id}`, data);
+    return response.data.template;
+  },
+
+  delete: async (id: string): Promise<void> => {
+    await client.delete(`/todo-templates/${id}`);
+  },
+
+  apply: async (id: string, options: TodoTemplateApplyOptions = {}): Promise<Todo[]> => {
+    const response = await client.post(`/todo-templates/${id}/apply`, options);
+    return response.data.todos;
+  },
+};
@@ -1,5 +1,5 @@
 import client from './client';
-import { Todo, TodoCreate, TodoUpdate } from '../types';
+import { DayActivity, Priority, Status, StreakInfo, Todo, TodoCreate, TodoEstimate, TodoPage, TodoUpdate } from '../types';
 
 export interface TodoReorderRequest {
   todos: Array<{
@@ -8,12 +8,61 @@ export interface TodoReorderRequest {
   }>;
 }
 
+export type TodoSort = 'position' | 'due_date' | 'created_at' | 'priority' | 'title';
+
+export interface TodoFilter {
+  tags?: string[];
+  tag_op?: 'AND' | 'OR';
+  status?: Status;
+  priority?: Priority;
+  group_id?: string;
+  sort?: TodoSort;
+  order?: 'asc' | 'desc';
+}
+
 export const todoApi = {
-  getAll: async (): Promise<Todo[]> => {
-    const response = await client.get('/todos');
+  getAll: async (includeArchivedGroups = false, filter: TodoFilter = {}): Promise<Todo[]> => {
+    const params: Record<string, string | boolean> = {};
+    if (includeArchivedGroups) params.include_archived_groups = true;
+    if (filter.tags && filter.tags.length > 0) params.tags = filter.tags.join(',');
+    if (filter.tag_op) params.tag_op = filter.tag_op;
+    if (filter.status) params.status = filter.status;
+    if (filter.priority) params.priority = filter.priority;
+    if (filter.group_id) params.group_id = filter.group_id;
+    if (filter.sort) params.sort = filter.sort;
+    if (filter.order) params.order = filter.order;
+    const response = await client.get('/todos', { params });
     return response.data.todos;
   },
 
+  // Todos newest first; filters and sorting can't be combined with paging
+  getPage: async (after = '', limit = 50, includeArchivedGroups = false): Promise<TodoPage> => {
+    const params: Record<string, string | number | boolean> = { after, limit };
+    if (includeArchivedGroups) params.include_archived_groups = true;
+    const response = await client.get('/todos', { params });
+    return response.data;
+  },
+
+  getStreak: async (): Promise<StreakInfo> => {
+    const response = await client.get('/todos/streak');
+    return response.data;
+  },
+
+  getStreakCalendar: async (year: number, month: number): Promise<DayActivity[]> => {
+    const response = await client.get('/todos/streak/calendar', { params: { year, month } });
+    return response.data.days;
+  },
+
+  getDailyGoal: async (): Promise<number> => {
+    const response = await client.get('/settings/daily-goal');
+    return response.data.daily_goal;
+  },
+
+  setDailyGoal: async (dailyGoal: number): Promise<number> => {
+    const response = await client.put('/settings/daily-goal', { daily_goal: dailyGoal });
+    return response.data.daily_goal;
+  },
+
   getById: async (id: string): Promise<Todo> => {
     const response = await client.get(`/todos/${id}`);
     return response.data.todo;
@@ -29,6 +78,29 @@ export const todoApi = {
     return response.data.todo;
   },
 
+  setEstimate: async (id: string, data: TodoEstimate): Promise<Todo> => {
+    const response = await client.put(`/todos/${id}/estimate`, data);
+    return response.data.todo;
+  },
+
+  getBlockers: async (id: string): Promise<Todo[]> => {
+    const response = await client.get(`/todos/${id}/blockers`);
+    return response.data.todos;
+  },
+
+  getBlocking: async (id: string): Promise<Todo[]> => {
+    const response = await client.get(`/todos/${id}/blocking`);
+    return response.data.todos;
+  },
+
+  addDependency: async (id: string, blockerId: string): Promise<void> => {
+    await client.post(`/todos/${id}/dependencies`, { blocker_id: blockerId });
+  },
+
+  removeDependency: async (id: string, blockerId: string): Promise<void> => {
+    await client.delete(`/todos/${id}/dependencies/${blockerId}`);
+  },
+
   delete: async (id: string): Promise<void> => {
     await client.delete(`/todos/${id}`);
   },
@@ -36,4 +108,13 @@ export const todoApi = {
   reorder: async (data: TodoReorderRequest): Promise<void> => {
     await client.put('/todos/reorder', data);
   },
+
+  bulkUpdatePriority: async (ids: string[], priority: Priority): Promise<{ updated: number }> => {
+    const response = await client.put('/todos/bulk/priority', { ids, priority });
+    return response.data;
+  },
+
+  syncTags: async (): Promise<void> => {
+    await client.post('/todos/sync-tags');
+  },
 };
@@ -1,4 +1,5 @@
 import client from './client';
+import { UserPreferences } from '../types';
 
 export interface DataStats {
   memory_count: number;
@@ -20,6 +21,39 @@ export interface ClearAllResult {
   error_message?: string;
 }
 
+export interface DataImportCounts {
+  groups: number;
+  categories: number;
+  todos: number;
+  memories: number;
+  ai_providers: number;
+}
+
+export interface DataImportResult {
+  imported: DataImportCounts;
+  skipped: DataImportCounts;
+}
+
+export type MemoryCSVField =
+  | 'id'
+  | 'content'
+  | 'summary'
+  | 'category'
+  | 'url'
+  | 'url_title'
+  | 'generated_title'
+  | 'content_language'
+  | 'is_pinned'
+  | 'created_at'
+  | 'updated_at';
+
+export interface MemoryCSVExportFilter {
+  fields?: MemoryCSVField[];
+  category?: string;
+  from?: string; // YYYY-MM-DD
+  to?: string; // YYYY-MM-DD
+}
+
 export const userDataApi = {
   getStats: async (): Promise<DataStats> => {
     const response = await client.get('/user/data/stats');
@@ -35,4 +69,38 @@ export const userDataApi = {
     const response = await client.post('/user/data/clear-all');
     return response.data;
   },
+
+  exportJSON: async (): Promise<Blob> => {
+    const response = await client.get('/export/json', { responseType: 'blob' });
+    return response.data;
+  },
+
+  exportMemoriesCSV: async (filter: MemoryCSVExportFilter = {}): Promise<Blob> => {
+    const params: Record<string, string> = {};
+    if (filter.fields && filter.fields.length > 0) params.fields = filter.fields.join(',');
+    if (filter.category) params.category = filter.category;
+    if (filter.from) params.from = filter.from;
+    if (filter.to) params.to = filter.to;
+    const response = await client.get('/export/memories.csv', { params, responseType: 'blob' });
+    return response.data;
+  },
+
+  importJSON: async (data: unknown): Promise<DataImportResult> => {
+    const response = await client.post('/import/json', data);
+    return response.data;
+  },
+
+  getPreferences: async (): Promise<UserPreferences> => {
+    const response = await client.get('/settings/preferences');
+    return response.data;
+  },
+
+  updatePreferences: async (data: Partial<UserPreferences>): Promise<UserPreferences> => {
+    const response = await client.put('/settings/preferences', data);
+    return response.data;
+  },
+
+  deleteAccount: async (password: string): Promise<void> => {
+    await client.delete('/auth/account', { data: { password } });
+  },
 };
@@ -32,6 +32,7 @@ export default function AIProviderCard({
   const getProviderIcon = () => {
     switch (provider.provider_type) {
       case 'openai':
+      case 'assistant':
         return <SiOpenai className="text-green-500" size={20} />;
       case 'anthropic':
         return (
@@ -11,7 +11,7 @@ interface AIProviderFormProps {
   onCancel: () => void;
 }
 
-type ProviderType = 'openai' | 'anthropic' | 'google' | 'custom';
+type ProviderType = 'openai' | 'anthropic' | 'google' | 'custom' | 'assistant' | 'ollama';
 
 export default function AIProviderForm({ provider, onSubmit, onCancel }: AIProviderFormProps) {
   const isEditing = !!provider;
@@ -128,7 +128,7 @@ export default function AIProviderForm({ provider, onSubmit, onCancel }: AIProvi
                 Provider Type
               </label>
               <div className="grid grid-cols-2 gap-2">
-                {(['openai', 'anthropic', 'google', 'custom'] as const).map((type) => (
+                {(['openai', 'anthropic', 'google', 'custom', 'assistant', 'ollama'] as const).map((type) => (
                   <button
                     key={type}
                     type="button"
@@ -185,7 +185,7 @@ export default function AIProviderForm({ provider, onSubmit, onCancel }: AIProvi
                 id="apiKey"
                 value={apiKey}
                 onChange={(e) => setApiKey(e.target.value)}
-                placeholder={isEditing ? '********' : 'sk-...'}
+                placeholder={isEditing ? '********' : providerType === 'ollama' ? 'ollama (any value; not sent)' : 'sk-...'}
                 required={!isEditing}
                 className="w-full px-3 py-2 rounded-lg border border-gray-200 dark:border-gray-600 bg-white dark:bg-gray-700 text-gray-900 dark:text-white placeholder-gray-400 focus:ring-2 focus:ring-primary-500 focus:border-transparent font-mono text-sm"
               />
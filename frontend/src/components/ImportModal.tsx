@@ -70,15 +70,15 @@ export default function ImportModal({ isOpen, onClose, onImportComplete, onUploa
     const ext = file.name.substring(file.name.lastIndexOf('.')).toLowerCase();
 
     // Client-side validation - size limits
-    const maxSize = ext === '.pdf' ? 20 * 1024 * 1024 : 10 * 1024 * 1024;
-    if (file.size > maxSize) {
-      alert(`File too large. Maximum size is ${ext === '.pdf' ? '20' : '10'} MB.`);
+    const maxSizeMB = ext === '.zip' ? 50 : ext === '.pdf' || ext === '.epub' ? 20 : 10;
+    if (file.size > maxSizeMB * 1024 * 1024) {
+      alert(`File too large. Maximum size is ${maxSizeMB} MB.`);
       return;
     }
 
-    const validTypes = ['.txt', '.md', '.pdf', '.json'];
+    const validTypes = ['.txt', '.md', '.pdf', '.json', '.yaml', '.yml', '.epub', '.zip'];
     if (!validTypes.includes(ext)) {
-      alert('Invalid file type. Supported: .txt, .md, .pdf, .json');
+      alert('Invalid file type. Supported: .txt, .md, .pdf, .json, .yaml, .yml, .epub, .zip');
       return;
     }
 
@@ -197,12 +197,12 @@ export default function ImportModal({ isOpen, onClose, onImportComplete, onUploa
                 Drop a file here or click to browse
               </p>
               <p className="text-xs text-gray-500 dark:text-gray-400 mb-4">
-                Supported formats: .txt, .md, .pdf, .json
+                Supported formats: .txt, .md, .pdf, .json, .yaml, .yml, .epub, .zip
               </p>
               <label className="inline-block">
                 <input
                   type="file"
-                  accept=".txt,.md,.pdf,.json"
+                  accept=".txt,.md,.pdf,.json,.yaml,.yml,.epub,.zip"
                   onChange={handleFileInput}
                   className="hidden"
                 />
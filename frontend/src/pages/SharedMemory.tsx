@@ -0,0 +1,67 @@
+import { useEffect, useState } from 'react';
+import { Link, useParams } from 'react-router-dom';
+import { CircleNotch } from '@phosphor-icons/react';
+import { memoryApi } from '../api';
+import { SharedMemory as SharedMemoryType } from '../types';
+
+// Public, read-only view of a memory opened through a share link
+export default function SharedMemory() {
+  const { token } = useParams<{ token: string }>();
+  const [memory, setMemory] = useState<SharedMemoryType | null>(null);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState(false);
+
+  useEffect(() => {
+    if (!token) return;
+    memoryApi
+      .getShared(token)
+      .then(setMemory)
+      .catch(() => setError(true))
+      .finally(() => setLoading(false));
+  }, [token]);
+
+  if (loading) {
+    return (
+      <div className="min-h-screen flex items-center justify-center">
+        <CircleNotch className="h-8 w-8 animate-spin text-primary-600" />
+      </div>
+    );
+  }
+
+  return (
+    <div className="min-h-screen flex items-center justify-center py-12 px-6 lg:px-12 bg-surface-light dark:bg-surface-dark">
+      <div className="w-full max-w-2xl bg-white/70 dark:bg-surface-dark-elevated/70 backdrop-blur-glass rounded-2xl shadow-float border border-white/50 dark:border-gray-800/30 p-8">
+        {error || !memory ? (
+          <p className="text-center text-gray-600 dark:text-gray-400">
+            This share link doesn't exist or has expired.
+          </p>
+        ) : (
+          <>
+            <p className="text-xs uppercase tracking-wide text-gray-500 dark:text-gray-400 mb-2">{memory.category}</p>
+            {(memory.url_title || memory.generated_title) && (
+              <h2 className="text-2xl font-heading text-gray-900 dark:text-white mb-4">
+                {memory.url_title || memory.generated_title}
+              </h2>
+            )}
+            <p className="whitespace-pre-wrap text-gray-800 dark:text-gray-200">{memory.content}</p>
+            {memory.url && (
+              <a
+                href={memory.url}
+                target="_blank"
+                rel="noopener noreferrer"
+                className="mt-4 inline-block text-sm text-primary-600 hover:underline break-all"
+              >
+                {memory.url}
+              </a>
+            )}
+          </>
+        )}
+        <div className="mt-8 text-center">
+          <Link to="/" className="text-sm text-primary-600 hover:underline">
+            Open memlane
+          </Link>
+        </div>
+      </div>
+    </div>
+  );
+}
@@ -9,6 +9,7 @@ import Unified from '../pages/Unified';
 import ForgotPassword from '../pages/ForgotPassword';
 import ResetPassword from '../pages/ResetPassword';
 import Settings from '../pages/Settings';
+import SharedMemory from '../pages/SharedMemory';
 
 // OAuth callback handler component
 function AuthCallback() {
@@ -71,6 +72,7 @@ export default function AppRoutes() {
       <Route path="/forgot-password" element={!user ? <ForgotPassword /> : <Navigate to="/home" replace />} />
       <Route path="/reset-password" element={<ResetPassword />} />
       <Route path="/auth/callback" element={<AuthCallback />} />
+      <Route path="/shared/:token" element={<SharedMemory />} />
       <Route path="/" element={!user ? <Landing /> : <Navigate to="/home" replace />} />
       <Route path="/home" element={user ? <Unified /> : <Navigate to="/login" replace />} />
       <Route path="/settings" element={user ? <Settings /> : <Navigate to="/login" replace />} />
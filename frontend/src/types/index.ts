@@ -4,9 +4,50 @@ export interface User {
   email: string;
   full_name: string | null;
   theme: string;
+  timezone: string;
+  email_digest_enabled: boolean;
   created_at: string;
 }
 
+export interface IPAllowlistEntry {
+  id: string;
+  user_id: string;
+  cidr: string;
+  label: string;
+  created_at: string;
+}
+
+// A signed-in device; ip_address has its host part masked
+export interface Session {
+  id: string;
+  device: string;
+  ip_address: string;
+  last_active: string;
+  created_at: string;
+  is_current: boolean;
+}
+
+// An OpenID Connect provider users can sign in with
+export interface OIDCProvider {
+  id: string;
+  name: string;
+  issuer: string;
+  initiate_url: string;
+}
+
+export interface OIDCInitiateResponse {
+  auth_url: string;
+  state: string;
+}
+
+// An app token issued after an OIDC sign-in; expires_in is in seconds
+export interface OIDCLoginResponse {
+  token: string;
+  token_type: string;
+  expires_in: number;
+  user: User;
+}
+
 // Todo types
 export type Priority = 'low' | 'medium' | 'high';
 export type Status = 'pending' | 'completed';
@@ -22,16 +63,47 @@ export interface Todo {
   status: Status;
   position: string;
   tags: string[];
+  story_points: StoryPoints | null;
+  estimated_duration: string | null;
+  dependencies?: string[]; // IDs of blocking todos, on single-todo responses
   created_at: string;
   updated_at: string;
 }
 
+// Fibonacci effort estimate
+export type StoryPoints = 1 | 2 | 3 | 5 | 8 | 13;
+
+export interface TodoEstimate {
+  story_points: StoryPoints | null;
+  estimated_duration: string | null;
+}
+
+export interface UserPreferences {
+  ai_process_todos: boolean;
+  ai_process_memories: boolean;
+}
+
+export interface StreakInfo {
+  current_streak: number;
+  longest_streak: number;
+  today_count: number;
+  daily_goal: number;
+}
+
+export interface DayActivity {
+  date: string;
+  count: number;
+  is_goal_met: boolean;
+}
+
 export interface TodoCreate {
   title: string;
   description?: string | null;
   due_date?: string | null;
   priority?: Priority;
   group_id?: string | null;
+  // Ask the AI for story points and a duration
+  estimate_effort?: boolean;
 }
 
 export interface TodoUpdate {
@@ -45,25 +117,88 @@ export interface TodoUpdate {
   tags?: string[];
 }
 
+// Todo template types
+export interface TodoTemplateTask {
+  title: string;
+  priority?: Priority;
+  tags?: string[];
+  relative_due_days?: number | null;
+}
+
+export interface TodoTemplate {
+  id: string;
+  user_id: string | null;
+  name: string;
+  description: string;
+  tasks: TodoTemplateTask[];
+  is_system: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface TodoTemplateCreate {
+  name: string;
+  description?: string;
+  tasks: TodoTemplateTask[];
+}
+
+export interface TodoTemplateUpdate {
+  name?: string;
+  description?: string;
+  tasks?: TodoTemplateTask[];
+}
+
+export interface TodoTemplateApplyOptions {
+  group_id?: string;
+  start_date?: string;
+}
+
 // Group types
 export interface Group {
   id: string;
   user_id: string | null;
   name: string;
   color_code: string;
+  // Dark-mode color; derived from color_code unless set explicitly
+  color_dark: string;
   is_default: boolean;
+  is_archived: boolean;
+  parent_id: string | null;
   created_at: string;
   updated_at: string;
+  stats?: GroupStats;
+  // Direct sub-groups; only populated when listing groups
+  children?: Group[];
+}
+
+export interface GroupStats {
+  total: number;
+  completed: number;
+  pending: number;
+  overdue: number;
+  story_points: number;
 }
 
 export interface GroupCreate {
   name: string;
   color_code?: string;
+  color_dark?: string;
+  parent_id?: string;
 }
 
 export interface GroupUpdate {
   name?: string;
   color_code?: string;
+  // Empty string reverts to the derived dark color
+  color_dark?: string;
+}
+
+export interface GroupColorPreview {
+  color_light: string;
+  color_dark: string;
+  // WCAG contrast ratios against the light and dark page backgrounds
+  contrast_light: number;
+  contrast_dark: number;
 }
 
 // Memory types
@@ -77,11 +212,61 @@ export interface Memory {
   url_title: string | null;
   url_content: string | null;
   is_archived: boolean;
+  ai_processing_failed: boolean;
+  content_language: string | null;
   position: string;
+  last_scraped_at: string | null;
+  // AI display title for long content without a url_title (at most 60 characters)
+  generated_title: string | null;
+  scrape_mode: ScrapeMode;
+  thumbnail_url: string | null; // the url's og:image
+  created_at: string;
+  updated_at: string;
+}
+
+// How much of a memory's URL is fetched: the whole page, only its title and Open Graph
+// tags, or nothing
+export type ScrapeMode = 'full' | 'metadata_only' | 'none';
+
+// A memory as seen through a public share link, without its owner
+export interface SharedMemory {
+  content: string;
+  summary: string | null;
+  category: string;
+  url: string | null;
+  url_title: string | null;
+  url_content: string | null;
+  generated_title: string | null;
   created_at: string;
   updated_at: string;
 }
 
+export interface ShareLink {
+  url: string;
+  token: string;
+  expires_at: string;
+  max_views: number | null;
+}
+
+// A memory's content before an edit; diff_chars is how much the edit changed
+export interface MemoryRevision {
+  id: string;
+  memory_id: string;
+  content: string;
+  summary: string | null;
+  changed_at: string;
+  changed_by: string;
+  diff_chars: number;
+}
+
+export interface Attachment {
+  id: string;
+  memory_id: string;
+  mime_type: string;
+  size_bytes: number;
+  created_at: string;
+}
+
 export interface MemoryCategory {
   id: string;
   user_id: string | null;
@@ -103,6 +288,17 @@ export interface MemoryDigest {
 
 export interface MemoryCreate {
   content: string;
+  scrape_mode?: ScrapeMode; // default 'full'
+}
+
+export interface MemoryBatchCreate {
+  memories: MemoryCreate[];
+  stop_on_error?: boolean;
+}
+
+export interface MemoryBatchCreateResult {
+  created: string[];
+  failed: { index: number; error: string }[];
 }
 
 export interface MemoryUpdate {
@@ -116,10 +312,22 @@ export interface MemorySearchParams {
   category?: string;
   date_from?: string;
   date_to?: string;
+  language_filter?: string[];
   limit?: number;
   offset?: number;
 }
 
+export interface MemoryBulkDeleteFilter {
+  category?: string;
+  before_date?: string;
+}
+
+export interface MemoryBulkArchiveFilter {
+  older_than_days?: number;
+  category?: string;
+  include_pinned?: boolean;
+}
+
 export interface MemoryToTodoParams {
   title?: string;
   description?: string;
@@ -132,6 +340,32 @@ export interface MemoryStats {
   by_category: Record<string, number>;
   this_week: number;
   this_month: number;
+  total_characters: number;
+  avg_characters_per_memory: number;
+  estimated_reading_time_minutes: number;
+  longest_memory: { id: string; content_length: number } | null;
+  url_count: number;
+  with_summary_count: number;
+}
+
+// One page of a cursor-paginated list; pass next_cursor as `after` for the next page
+export interface MemoryPage {
+  memories: Memory[];
+  next_cursor: string; // empty on the last page
+  has_more: boolean;
+}
+
+export interface TodoPage {
+  todos: Todo[];
+  next_cursor: string; // empty on the last page
+  has_more: boolean;
+}
+
+export interface KeywordScore {
+  keyword: string;
+  score: number;
+  frequency: number;
+  document_count: number;
 }
 
 export interface MemoryFileUploadResponse {
@@ -141,6 +375,36 @@ export interface MemoryFileUploadResponse {
   file_type: string;
 }
 
+export interface VaultImportResponse {
+  imported: number;
+  skipped: number;
+  deduplicated_count: number;
+  errors: Array<{ file: string; error: string }>;
+  imported_by_source: Record<string, number>;
+}
+
+export interface RSSImportRequest {
+  url: string;
+  max_items?: number;
+  save?: boolean;
+}
+
+export interface RSSImportResponse {
+  imported: number;
+  skipped: number;
+  failed: number;
+  errors: Array<{ url: string; error: string }>;
+}
+
+export interface SavedRSSFeed {
+  id: string;
+  user_id: string;
+  url: string;
+  max_items: number;
+  last_fetched_at: string | null;
+  created_at: string;
+}
+
 export type UploadJobStatus = 'pending' | 'processing' | 'completed' | 'failed';
 
 export interface UploadJobCreateResponse {
@@ -156,7 +420,9 @@ export interface UploadJobStatusResponse {
   progress: number;
   total_items: number;
   processed_items: number;
+  deduplicated_count: number;
   memories: Memory[];
+  memory_sources?: Record<string, string>;
   error_message?: string;
   created_at: string;
   updated_at: string;
@@ -187,12 +453,20 @@ export interface RAGSearchResult {
   score: number;
   match_type: string;
   highlights: string[];
+  // Where the highlighted words are in document.content, in code points (use Array.from)
+  spans?: HighlightSpan[];
+}
+
+export interface HighlightSpan {
+  start: number;
+  end: number;
 }
 
 export interface RAGAskResponse {
   answer: string;
   sources: RAGSearchResult[];
   model: string;
+  created_todo?: Todo;
 }
 
 export interface RAGStats {
@@ -200,6 +474,24 @@ export interface RAGStats {
   todos_indexed: number;
   memories_indexed: number;
   fts_enabled: boolean;
+  stale_count: number;
+}
+
+export interface RAGIndexQueueItem {
+  id: string;
+  content_type: 'todo' | 'memory';
+  content_id: string;
+  user_id: string;
+  attempt_count: number;
+  last_error: string;
+  next_attempt_at: string;
+  status: 'pending' | 'dead';
+  created_at: string;
+}
+
+export interface RAGReindexResponse {
+  indexed: boolean;
+  elapsed_ms: number;
 }
 
 // Legacy type exports for backward compatibility during migration